@@ -2,55 +2,554 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/dnakolan/worker-pool-service/internal/alerts"
+	"github.com/dnakolan/worker-pool-service/internal/anomaly"
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/config"
+	"github.com/dnakolan/worker-pool-service/internal/egress"
+	"github.com/dnakolan/worker-pool-service/internal/events"
+	"github.com/dnakolan/worker-pool-service/internal/executor/docker"
+	"github.com/dnakolan/worker-pool-service/internal/executor/httpjob"
+	"github.com/dnakolan/worker-pool-service/internal/executor/kubernetes"
+	"github.com/dnakolan/worker-pool-service/internal/features"
 	"github.com/dnakolan/worker-pool-service/internal/handler"
+	"github.com/dnakolan/worker-pool-service/internal/listener"
+	"github.com/dnakolan/worker-pool-service/internal/metrics"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/peers"
+	"github.com/dnakolan/worker-pool-service/internal/policy"
 	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/release"
+	"github.com/dnakolan/worker-pool-service/internal/replica"
+	"github.com/dnakolan/worker-pool-service/internal/secrets"
 	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/dnakolan/worker-pool-service/internal/soak"
+	"github.com/dnakolan/worker-pool-service/internal/systemd"
+	"github.com/dnakolan/worker-pool-service/internal/usage"
+	"github.com/dnakolan/worker-pool-service/internal/version"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+// v1Introduced is when the API moved under /v1, deprecating the
+// unversioned routes
+var v1Introduced = time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
 func main() {
+	checkHealth := flag.Bool("healthcheck", false, "check whether a running server is ready and exit 0 if so, 1 otherwise")
+	checkConfig := flag.Bool("validate-config", false, "validate the configuration and the services it refers to, print a JSON report and exit 0 if valid, 1 otherwise")
+	fsck := flag.Bool("fsck", false, "check the job store and the bookkeeping around it for inconsistencies, repair them, print a JSON report and exit 0 if consistent, 1 otherwise")
+	flag.Parse()
+
+	if *checkConfig {
+		os.Exit(validateConfig(os.Stdout))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if *checkHealth {
+		os.Exit(healthcheck(cfg.Addr))
+	}
+
+	buildInfo := version.Get()
+	buildInfo.Features = backends(cfg)
+	slog.Info("Starting worker pool service",
+		"version", buildInfo.Version,
+		"git_sha", buildInfo.Commit,
+		"build_date", buildInfo.BuildDate,
+		"go_version", buildInfo.GoVersion,
+		"features", buildInfo.Features)
+
+	model.MaxInputSize = int64(cfg.MaxInputMB) << 20
+	model.MaxArtifactSize = int64(cfg.MaxArtifactMB) << 20
+
+	flags, err := features.NewSet(cfg.FeatureFlags)
+	if err != nil {
+		slog.Error("invalid feature flags", "error", err)
+		os.Exit(1)
+	}
+
 	router := chi.NewRouter()
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
+	if len(cfg.CORSOrigins) > 0 {
+		router.Use(handler.CORS(handler.CORSConfig{
+			Origins:        cfg.CORSOrigins,
+			Methods:        cfg.CORSMethods,
+			Headers:        cfg.CORSHeaders,
+			ExposedHeaders: []string{"ETag", "Content-Disposition", handler.APIVersionHeader, "Deprecation", "Sunset", "Link"},
+			Credentials:    cfg.CORSCredentials,
+			MaxAge:         cfg.CORSMaxAge,
+		}))
+	}
+	router.Use(handler.CompressWhen(func() bool { return flags.Enabled(features.ResponseCompression) }))
 
 	healthHandler := handler.NewHealthHandler()
 	router.Get("/health", healthHandler.GetHealthHandler)
+	router.Get("/readyz", healthHandler.GetReadyHandler)
+	router.Get("/version", handler.NewVersionHandler(buildInfo).GetVersionHandler)
+
+	// API routes are served under /v1. A future /v2 gets its own router
+	// here, reusing the handlers whose responses it doesn't change.
+	v1 := chi.NewRouter()
+	v1.Use(handler.APIVersion("v1"))
+	// API requests must finish within the write timeout, while following
+	// logs and moving files may take longer
+	api := v1.With(handler.Timeout(cfg.WriteTimeout))
+	stream := v1.With(handler.Timeout(cfg.StreamTimeout))
+	transfer := v1.With(handler.Timeout(cfg.TransferTimeout))
+
+	// Admin endpoints are served with the API unless they have a listener
+	// of their own
+	admin := api
+	var adminRouter, adminV1 *chi.Mux
+	if cfg.AdminAddr != "" {
+		adminRouter = chi.NewRouter()
+		adminRouter.Use(middleware.Logger)
+		adminRouter.Use(middleware.Recoverer)
+		if cfg.AdminToken != "" {
+			adminRouter.Use(handler.RequireToken(cfg.AdminToken))
+		}
+		adminRouter.Mount("/debug", middleware.Profiler())
+		adminV1 = chi.NewRouter()
+		adminV1.Use(handler.APIVersion("v1"))
+		admin = adminV1.With(handler.Timeout(cfg.WriteTimeout))
+	} else if cfg.AdminToken != "" {
+		admin = api.With(handler.RequireToken(cfg.AdminToken))
+	}
+	// Searches and GraphQL queries only read, though they're POSTed, so
+	// replicas serve them too
+	search := api
+	if cfg.Follow != "" {
+		readOnly := handler.ReadOnly(cfg.Follow)
+		api, stream, transfer, admin = api.With(readOnly), stream.With(readOnly), transfer.With(readOnly), admin.With(readOnly)
+	}
+
+	pool := pool.NewWorkerPool(context.Background(), cfg.Workers, cfg.QueueSize)
+	for _, group := range cfg.CapableWorkers {
+		pool.AddWorkers(group.Count, group.Capabilities...)
+	}
+	pool.SetWorkerCapacity(cfg.WorkerCapacity)
+	pool.SetInstanceLabels(cfg.InstanceLabels)
+	pool.SetNodeName(cfg.NodeName)
+	if cfg.LargeJobKB > 0 {
+		pool.SetLargeJobs(cfg.LargeJobKB<<10, cfg.LargeJobSlots, cfg.LargeJobQueueSize)
+	}
+	pool.SharePayloads(cfg.DedupPayloadKB << 10)
+	pool.SetMaxFinishedJobs(cfg.MaxFinishedJobs)
+	pool.SetArchiveEvicted(cfg.ArchiveEvicted)
+	if cfg.OverflowDir != "" {
+		if err := pool.SetOverflow(cfg.OverflowDir, cfg.OverflowMaxJobs); err != nil {
+			slog.Error("failed to open overflow queue", "error", err)
+			os.Exit(1)
+		}
+	}
+	egressClient, err := egress.NewClient(cfg.Egress)
+	if err != nil {
+		slog.Error("failed to configure egress", "error", err)
+		os.Exit(1)
+	}
+	pool.RegisterExecutor("http", httpjob.NewExecutor(egressClient))
+	if cfg.KubernetesPodTemplate != "" {
+		k8sConfig, err := kubernetes.InClusterConfig(cfg.KubernetesPodTemplate)
+		if err != nil {
+			slog.Error("failed to configure kubernetes executor", "error", err)
+			os.Exit(1)
+		}
+		k8s := kubernetes.NewExecutor(*k8sConfig)
+		for _, jobType := range cfg.KubernetesJobTypes {
+			pool.RegisterExecutor(jobType, k8s)
+		}
+		if err := splitToKubernetes(pool, k8s, cfg); err != nil {
+			slog.Error("failed to split job types", "error", err)
+			os.Exit(1)
+		}
+		for _, jobType := range cfg.KubernetesShadowJobTypes {
+			if !slices.Contains(cfg.KubernetesJobTypes, jobType) {
+				pool.RegisterShadowExecutor(jobType, k8s)
+			}
+		}
+	}
+	if cfg.DockerHost != "" {
+		dockerExecutor, err := docker.NewExecutor(docker.Config{
+			Host:        cfg.DockerHost,
+			CPUs:        cfg.DockerCPUs,
+			MemoryBytes: int64(cfg.DockerMemoryMB) << 20,
+		})
+		if err != nil {
+			slog.Error("failed to configure docker executor", "error", err)
+			os.Exit(1)
+		}
+		pool.RegisterExecutor("container", dockerExecutor)
+	}
+	var blobs blob.Store
+	if cfg.BlobDir != "" {
+		if blobs, err = blob.NewDiskStore(cfg.BlobDir); err != nil {
+			slog.Error("failed to open blob directory", "error", err)
+			os.Exit(1)
+		}
+		pool.SetBlobStore(blobs)
+	}
+	var vault *secrets.VaultClient
+	if cfg.Vault != nil {
+		if vault, err = secrets.NewVaultClient(*cfg.Vault); err != nil {
+			slog.Error("failed to configure vault", "error", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.BlobS3 != nil {
+		if cfg.BlobS3VaultRole != "" {
+			cfg.BlobS3.Credentials = vaultS3Credentials(vault, cfg.VaultAWSMount, cfg.BlobS3VaultRole)
+		}
+		if blobs, err = blob.NewS3Store(*cfg.BlobS3); err != nil {
+			slog.Error("failed to configure s3 blob storage", "error", err)
+			os.Exit(1)
+		}
+		pool.SetBlobStore(blobs)
+	}
+	switch cfg.SecretsBackend {
+	case "env":
+		pool.SetSecrets(secrets.NewEnvProvider(secrets.DefaultEnvPrefix))
+	case "file":
+		provider, err := secrets.NewFileProvider(cfg.SecretsDir)
+		if err != nil {
+			slog.Error("failed to open secrets directory", "error", err)
+			os.Exit(1)
+		}
+		pool.SetSecrets(provider)
+	case "vault":
+		pool.SetSecrets(secrets.NewVaultProvider(vault))
+	}
+	pool.SetArtifactTTL(cfg.ArtifactTTL)
+	pool.SetArtifactURLExpiry(cfg.ArtifactURLExpiry)
+	pool.SetLeaseTimeout(cfg.LeaseTimeout)
+	pool.SetMaxAttempts(cfg.MaxAttempts)
+	pool.SetMaxRetries(cfg.MaxRetries)
+	pool.SetRetryBackoff("", cfg.RetryBackoff)
+	for jobType, strategy := range cfg.RetryBackoffTypes {
+		pool.SetRetryBackoff(jobType, strategy)
+	}
+	for jobType, rate := range cfg.DispatchRates {
+		pool.SetDispatchRate(jobType, rate)
+	}
+	for jobType, rate := range cfg.CostRates {
+		pool.SetCostRate(jobType, rate)
+	}
+	for jobType, timeout := range cfg.JobTimeouts {
+		pool.SetJobTimeout(jobType, timeout)
+	}
+	for jobType, defaults := range cfg.JobTypeDefaults {
+		if err := pool.SetJobTypeDefaults(jobType, defaults); err != nil {
+			slog.Error("invalid job type defaults", "job_type", jobType, "error", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.Policy != nil {
+		opa, err := policy.NewOPA(*cfg.Policy)
+		if err != nil {
+			slog.Error("failed to configure policy", "error", err)
+			os.Exit(1)
+		}
+		pool.AddHooks(policyHooks(opa))
+	}
+	pool.SetMaintenanceWindows(cfg.MaintenanceWindows)
+	healthHandler.SetMaintenance(pool.InMaintenance)
+	for jobType, ttl := range cfg.ResultCacheTTLs {
+		pool.SetResultCacheTTL(jobType, ttl)
+	}
+	pool.SetMemoLimit(cfg.MemoMaxEntries)
+	pool.SetConsistencyCheckInterval(cfg.ConsistencyCheckInterval)
+	var follower *replica.Follower
+	if cfg.Follow != "" {
+		if follower, err = followPrimary(pool, cfg); err != nil {
+			slog.Error("failed to configure replica", "error", err)
+			os.Exit(1)
+		}
+		healthHandler.SetReplica(follower.Synced)
+	}
 
-	pool := pool.NewWorkerPool(context.Background(), 10, 10)
-	pool.Start()
-	defer pool.Stop()
+	if *fsck {
+		os.Exit(checkConsistency(pool, os.Stdout))
+	}
+
+	collector := metrics.NewCollector(func() *model.PoolStats { return pool.Stats(context.Background()) })
+	pool.Subscribe(collector.Observe)
+	pool.NotifyShadowRuns(collector.ObserveShadowRun)
+	pool.NotifyQueueWaits(collector.ObserveQueueWait)
+	detector := anomaly.NewDetector(cfg.AnomalyStdDevs)
+	detector.Notify(collector.ObserveAnomaly)
+	pool.Subscribe(detector.Observe)
+	alertEngine := alerts.NewEngine()
+	pool.Subscribe(alertEngine.Observe)
+	go alertEngine.Run(context.Background())
 
-	jobService := service.NewJobsService(pool)
+	if len(cfg.ResultReleases) > 0 {
+		releaser, err := release.NewReleaser(pool, cfg.ResultReleases, release.DefaultBuffer)
+		if err != nil {
+			slog.Error("failed to configure result releases", "error", err)
+			os.Exit(1)
+		}
+		pool.Subscribe(releaser.Observe)
+		go releaser.Run(context.Background())
+	}
+
+	// Subscribe before starting, so no job's submission goes unexported
+	var exporter *events.Exporter
+	exportCtx, stopExport := context.WithCancel(context.Background())
+	exportDone := make(chan struct{})
+	if cfg.EventsURL != "" {
+		publisher, err := events.NewPublisher(cfg.EventsURL)
+		if err != nil {
+			slog.Error("failed to configure event export", "error", err)
+			os.Exit(1)
+		}
+		defer publisher.Close()
+		exporter = events.NewExporter(publisher, cfg.EventsTopic, cfg.EventsBuffer)
+		pool.Subscribe(exporter.Enqueue)
+		go func() {
+			exporter.Run(exportCtx)
+			close(exportDone)
+		}()
+	} else {
+		close(exportDone)
+	}
+	// A replica only serves the jobs it copies from its primary, which
+	// runs them
+	if follower != nil {
+		slog.Info("Following primary as a read-only replica", "primary", cfg.Follow)
+		go follower.Run(context.Background())
+	} else {
+		pool.Start()
+	}
+
+	jobService := service.NewJobsService(pool, cfg.TenantBudget, cfg.EnabledJobTypes, cfg.PayloadLimits)
+	if len(cfg.Peers) > 0 && follower == nil {
+		forwarder, err := peers.NewForwarder(cfg.NodeName, cfg.Peers)
+		if err != nil {
+			slog.Error("failed to configure peers", "error", err)
+			os.Exit(1)
+		}
+		forwarder.SetToken(cfg.ClusterToken)
+		forwarder.SetTenantHeader(cfg.TenantHeader)
+		jobService.SetForwarder(forwarder)
+	}
+	statsService := service.NewStatsService(pool, cfg.TenantBudget)
+	if (len(cfg.Peers) > 0 || cfg.AdvertiseURL != "") && follower == nil {
+		membership, err := peers.NewMembership(cfg.NodeName, cfg.AdvertiseURL, cfg.Peers, cfg.GossipInterval,
+			func(ctx context.Context) model.NodeLoad { return pool.Stats(ctx).Load() })
+		if err != nil {
+			slog.Error("failed to configure cluster membership", "error", err)
+			os.Exit(1)
+		}
+		membership.SetToken(cfg.ClusterToken)
+		membership.AllowHosts(cfg.ClusterHosts...)
+		go membership.Run(context.Background())
+		statsService.SetCluster(membership)
+		clusterHandler := handler.NewClusterHandler(service.NewClusterService(membership))
+		api.With(handler.RequireToken(cfg.ClusterToken)).Post("/cluster/gossip", clusterHandler.GossipHandler)
+	}
 	jobsHandler := handler.NewJobsHandler(jobService)
+	jobsHandler.SetClusterToken(cfg.ClusterToken)
+	jobsHandler.SetTenantHeader(cfg.TenantHeader)
+
+	jobTypesService := service.NewJobTypesService(pool, cfg.EnabledJobTypes, cfg.PayloadLimits)
+	jobTypesHandler := handler.NewJobTypesHandler(jobTypesService)
+	api.Get("/job-types", jobTypesHandler.ListJobTypesHandler)
+	admin.Put("/job-types/{type}/config", jobTypesHandler.ConfigureJobTypeHandler)
+
+	statsHandler := handler.NewStatsHandler(statsService)
+	api.Get("/pool/stats", statsHandler.GetStatsHandler)
+	api.Get("/stats/timeseries", statsHandler.GetTimeseriesHandler)
+
+	usageService := service.NewUsageService(pool)
+	usageHandler := handler.NewUsageHandler(usageService)
+	api.Get("/tenants/{id}/usage", usageHandler.GetTenantUsageHandler)
+	if cfg.UsageExport {
+		go usage.NewExporter(blobs, usageService.Reports).Run(context.Background())
+	}
+
+	workersService := service.NewWorkersService(pool)
+	workersHandler := handler.NewWorkersHandler(workersService)
+	api.Post("/workers/lease", workersHandler.LeaseJobHandler)
+	api.Post("/workers/{id}/heartbeat", workersHandler.HeartbeatHandler)
+	api.Post("/workers/{id}/complete", workersHandler.CompleteJobHandler)
+
+	metricsHandler := handler.NewMetricsHandler(service.NewMetricsService(collector))
+	admin.Get("/admin/metrics", metricsHandler.GetMetricsHandler)
+	admin.Get("/admin/dashboard", metricsHandler.GetDashboardHandler)
+
+	alertsHandler := handler.NewAlertsHandler(service.NewAlertsService(alertEngine))
+	admin.Post("/admin/alert-rules", alertsHandler.CreateAlertRuleHandler)
+	admin.Get("/admin/alert-rules", alertsHandler.ListAlertRulesHandler)
+	admin.Get("/admin/alert-rules/{id}", alertsHandler.GetAlertRuleHandler)
+	admin.Put("/admin/alert-rules/{id}", alertsHandler.UpdateAlertRuleHandler)
+	admin.Delete("/admin/alert-rules/{id}", alertsHandler.DeleteAlertRuleHandler)
+	admin.Get("/admin/alerts", alertsHandler.ListAlertsHandler)
+
+	featuresHandler := handler.NewFeaturesHandler(service.NewFeaturesService(flags))
+	admin.Get("/admin/features", featuresHandler.ListFeaturesHandler)
+	admin.Put("/admin/features/{name}", featuresHandler.SetFeatureHandler)
+
+	configHandler := handler.NewConfigHandler(service.NewConfigService())
+	admin.Post("/admin/config/preview", configHandler.PreviewConfigHandler)
+
+	shadowRunsHandler := handler.NewShadowRunsHandler(service.NewShadowRunsService(pool))
+	admin.Get("/admin/shadow-runs", shadowRunsHandler.ListShadowRunsHandler)
+
+	queueHandler := handler.NewQueueHandler(service.NewQueueService(pool, jobService))
+	admin.Get("/admin/queue/snapshot", queueHandler.SnapshotQueueHandler)
+	admin.Post("/admin/queue/replay", queueHandler.ReplayQueueHandler)
 
-	router.Post("/jobs", jobsHandler.CreateJobsHandler)
-	router.Get("/jobs", jobsHandler.ListJobsHandler)
-	router.Get("/jobs/{uid}", jobsHandler.GetJobsHandler)
+	maintenanceHandler := handler.NewMaintenanceHandler(service.NewMaintenanceService(pool))
+	admin.Get("/admin/maintenance", maintenanceHandler.GetMaintenanceHandler)
+	admin.Put("/admin/maintenance", maintenanceHandler.SetMaintenanceOverrideHandler)
+
+	if cfg.FaultInjection {
+		slog.Warn("Fault injection is enabled")
+		faultsHandler := handler.NewFaultsHandler(service.NewFaultsService(pool))
+		admin.Get("/admin/faults", faultsHandler.GetFaultsHandler)
+		admin.Put("/admin/faults", faultsHandler.SetFaultsHandler)
+	}
+
+	if cfg.SoakCheckInterval > 0 {
+		monitor := soak.NewMonitor(pool, soak.Config{
+			Interval: cfg.SoakCheckInterval,
+			Window:   cfg.SoakWindow,
+			MaxJobs:  cfg.SoakMaxJobs,
+		})
+		go monitor.Run(context.Background())
+		soakHandler := handler.NewSoakHandler(service.NewSoakService(monitor))
+		admin.Get("/admin/soak", soakHandler.GetSoakHandler)
+	}
+
+	artifactsHandler := handler.NewArtifactsHandler(service.NewArtifactsService(pool))
+	transfer.Post("/artifacts", artifactsHandler.CreateArtifactHandler)
+	api.Get("/artifacts", artifactsHandler.ListArtifactsHandler)
+	transfer.Get("/artifacts/{id}", artifactsHandler.GetArtifactHandler)
+	api.Delete("/artifacts/{id}", artifactsHandler.DeleteArtifactHandler)
+
+	// Submissions may be compressed
+	decompress := handler.Decompress(int64(cfg.MaxDecompressedMB) << 20)
+	transfer.With(decompress).Post("/jobs", jobsHandler.CreateJobsHandler)
+	stream.With(decompress).Post("/jobs/stream", jobsHandler.StreamJobsHandler)
+	api.Get("/jobs", jobsHandler.ListJobsHandler)
+	search.Post("/jobs/search", jobsHandler.SearchJobsHandler)
+	graphQLHandler := handler.NewGraphQLHandler(service.NewGraphQLService(jobService, statsService, jobTypesService))
+	search.Get("/graphql", graphQLHandler.QueryHandler)
+	search.Post("/graphql", graphQLHandler.QueryHandler)
+	anomaliesHandler := handler.NewAnomaliesHandler(service.NewAnomaliesService(detector))
+	api.Get("/jobs/anomalies", anomaliesHandler.ListAnomaliesHandler)
+	api.Get("/jobs/{uid}", jobsHandler.GetJobsHandler)
+	api.Patch("/jobs/{uid}", jobsHandler.UpdateJobsHandler)
+	api.Post("/jobs/{uid}/replay", jobsHandler.ReplayJobHandler)
+	api.Get("/jobs/{uid}/lineage", jobsHandler.GetJobLineageHandler)
+	stream.Get("/jobs/{uid}/logs", jobsHandler.GetJobLogsHandler)
+	transfer.Get("/jobs/{uid}/input", jobsHandler.GetJobInputHandler)
+	transfer.Get("/jobs/{uid}/binaries/{field}", jobsHandler.GetJobBinaryHandler)
+
+	router.Mount("/v1", v1)
+	if adminRouter != nil {
+		adminRouter.Mount("/v1", adminV1)
+	}
+	// The routes predate /v1 and stay available unversioned, deprecated
+	router.Mount("/", handler.Deprecated(handler.Deprecation{
+		Since:     v1Introduced,
+		Sunset:    cfg.LegacyAPISunset,
+		Successor: func(path string) string { return "/v1" + path },
+	})(handler.Negotiate(map[string]http.Handler{"v1": v1}, "v1")))
 
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: router,
+		Addr:              cfg.Addr,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderKB << 10,
+	}
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			slog.Error("failed to load TLS certificate", "error", err)
+			os.Exit(1)
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	} else if cfg.H2C {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	ln, err := listener.Listen(cfg.Addr, cfg.ReusePort)
+	if err != nil {
+		slog.Error("failed to start server", "error", err)
+		os.Exit(1)
 	}
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		serve := srv.Serve
+		if srv.TLSConfig != nil {
+			serve = func(ln net.Listener) error { return srv.ServeTLS(ln, "", "") }
+		}
+		if err := serve(ln); err != nil && err != http.ErrServerClosed {
 			slog.Error("failed to start server", "error", err)
 			os.Exit(1)
 		}
 	}()
 
+	var adminSrv *http.Server
+	if adminRouter != nil {
+		// Profiles are written for as long as they were asked to run, so
+		// writes aren't bounded here
+		adminSrv = &http.Server{
+			Addr:              cfg.AdminAddr,
+			Handler:           adminRouter,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderKB << 10,
+		}
+		adminLn, err := listener.Bind(cfg.AdminAddr, cfg.ReusePort)
+		if err != nil {
+			slog.Error("failed to start admin server", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Serving admin endpoints", "addr", adminLn.Addr().String())
+		go func() {
+			if err := adminSrv.Serve(adminLn); err != nil && err != http.ErrServerClosed {
+				slog.Error("failed to start admin server", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	healthHandler.SetReady(true)
+	if _, err := systemd.Notify(systemd.Ready); err != nil {
+		slog.Warn("failed to notify service manager", "error", err)
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-sigChan
 	slog.Info("Received terminate, graceful shutdown", "signal", sig)
 
+	healthHandler.SetReady(false)
+	systemd.Notify(systemd.Stopping)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -58,7 +557,122 @@ func main() {
 		slog.Error("Server Shutdown Failed", "error", err)
 		os.Exit(1)
 	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			slog.Error("Admin Server Shutdown Failed", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// The listener is closed, so a replacement process now receives all new
+	// submissions while jobs already accepted here finish
+	if follower == nil {
+		drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+		defer drainCancel()
+		if err := pool.Drain(drainCtx); err != nil {
+			slog.Warn("Jobs still pending after drain timeout", "error", err)
+		}
+	}
+	pool.Stop()
+
+	// Pass on what happened to the jobs that were still running
+	stopExport()
+	<-exportDone
+	if exporter != nil {
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer flushCancel()
+		if err := exporter.Flush(flushCtx); err != nil {
+			slog.Warn("Job events lost at shutdown", "error", err)
+		}
+	}
 	slog.Info("Server exited properly")
 
 	os.Exit(0)
 }
+
+// policyHooks rejects or adjusts submissions as opa decides
+func policyHooks(opa *policy.OPA) pool.Hooks {
+	return pool.Hooks{BeforeSubmit: opa.BeforeSubmit}
+}
+
+// followPrimary makes p a replica of the primary instance: its store is
+// kept a copy of the primary's jobs by the returned follower
+func followPrimary(p *pool.WorkerPool, cfg *config.Config) (*replica.Follower, error) {
+	store := pool.NewMemoryStore()
+	store.SharePayloads(cfg.DedupPayloadKB << 10)
+	p.SetStore(store)
+	return replica.NewFollower(cfg.Follow, store, cfg.FollowInterval)
+}
+
+// vaultS3Credentials issues S3 credentials from role of the AWS secrets
+// engine mounted at mount
+func vaultS3Credentials(vault *secrets.VaultClient, mount, role string) func(context.Context) (blob.S3Credentials, error) {
+	creds := vault.AWSCredentials(mount, role)
+	return func(ctx context.Context) (blob.S3Credentials, error) {
+		keys, err := creds.Get(ctx)
+		return blob.S3Credentials(keys), err
+	}
+}
+
+// splitToKubernetes runs the share of each job type's jobs given by
+// WPS_KUBERNETES_SPLIT on Kubernetes and the rest with the built-in
+// implementation
+func splitToKubernetes(p *pool.WorkerPool, k8s *kubernetes.Executor, cfg *config.Config) error {
+	for jobType, percent := range cfg.KubernetesSplit {
+		if slices.Contains(cfg.KubernetesJobTypes, jobType) {
+			continue
+		}
+		builtin := pool.ExecutorVariant{Name: "builtin"}
+		candidate := pool.ExecutorVariant{Name: "kubernetes", Executor: k8s}
+		if err := p.SplitExecutor(jobType, builtin, candidate, percent); err != nil {
+			return fmt.Errorf("%s: %w", jobType, err)
+		}
+	}
+	return nil
+}
+
+// backends names the backend this configuration uses for each pluggable
+// part of the service, as reported by GET /version
+func backends(cfg *config.Config) map[string]string {
+	f := map[string]string{
+		// Jobs and the queue are always kept in memory, and the API is
+		// unauthenticated
+		"job_storage":  "memory",
+		"queue":        "memory",
+		"auth":         "none",
+		"admin_auth":   "none",
+		"blob_storage": "none",
+		"secrets":      "none",
+		"events":       "none",
+	}
+	switch {
+	case cfg.BlobDir != "":
+		f["blob_storage"] = "disk"
+	case cfg.BlobS3 != nil:
+		f["blob_storage"] = "s3"
+	}
+	if cfg.Follow != "" {
+		f["job_storage"] = "replica"
+	}
+	if cfg.AdminToken != "" {
+		f["admin_auth"] = "token"
+	}
+	if cfg.EventsURL != "" {
+		f["events"], _, _ = strings.Cut(cfg.EventsURL, ":")
+	}
+	if cfg.Policy != nil {
+		f["policy"] = "opa"
+	}
+	if cfg.SecretsBackend != "" {
+		f["secrets"] = cfg.SecretsBackend
+	}
+	executors := []string{"builtin"}
+	if cfg.DockerHost != "" {
+		executors = append(executors, "docker")
+	}
+	if cfg.KubernetesPodTemplate != "" {
+		executors = append(executors, "kubernetes")
+	}
+	f["executors"] = strings.Join(executors, ",")
+	return f
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/config"
+	"github.com/dnakolan/worker-pool-service/internal/egress"
+	"github.com/dnakolan/worker-pool-service/internal/events"
+	"github.com/dnakolan/worker-pool-service/internal/executor/docker"
+	"github.com/dnakolan/worker-pool-service/internal/executor/kubernetes"
+	"github.com/dnakolan/worker-pool-service/internal/policy"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/secrets"
+)
+
+// configReport is what -validate-config prints
+type configReport struct {
+	Valid    bool          `json:"valid"`
+	Checks   []configCheck `json:"checks"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+type configCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// validateProbe is the blob written and read back to check blob storage
+const validateProbe = ".validate-config"
+
+// validateConfig loads the configuration, checks that the services it
+// refers to can be reached with it and writes a JSON report to w. It
+// returns the process exit code, so deployments can be gated on it.
+func validateConfig(w io.Writer) int {
+	report := configReport{Valid: true}
+	check := func(name string, fn func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		result := configCheck{Name: name, OK: true}
+		if err := fn(ctx); err != nil {
+			result.OK = false
+			result.Error = err.Error()
+			report.Valid = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	cfg, err := config.Load()
+	check("config", func(context.Context) error { return err })
+	if cfg != nil {
+		report.Warnings = cfg.Warnings()
+		checkServices(cfg, check)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
+
+// checkConsistency checks the pool's store for inconsistencies without
+// starting it, repairs them and writes a JSON report to w. It returns the
+// process exit code.
+func checkConsistency(p *pool.WorkerPool, w io.Writer) int {
+	report := p.CheckConsistency(context.Background(), true)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+	if !report.Consistent() {
+		return 1
+	}
+	return 0
+}
+
+func checkServices(cfg *config.Config, check func(string, func(context.Context) error)) {
+	if cfg.EventsURL != "" {
+		check("events", func(ctx context.Context) error {
+			publisher, err := events.NewPublisher(cfg.EventsURL)
+			if err != nil {
+				return err
+			}
+			defer publisher.Close()
+			return publisher.Check(ctx)
+		})
+	}
+	if cfg.TLSCertFile != "" {
+		check("tls", func(context.Context) error {
+			_, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+			return err
+		})
+	}
+	if cfg.KubernetesPodTemplate != "" {
+		check("kubernetes", func(context.Context) error {
+			_, err := kubernetes.InClusterConfig(cfg.KubernetesPodTemplate)
+			return err
+		})
+	}
+	if cfg.Egress.CAFile != "" || cfg.Egress.ClientCertFile != "" {
+		check("egress", func(context.Context) error {
+			_, err := egress.NewClient(cfg.Egress)
+			return err
+		})
+	}
+	if cfg.Policy != nil {
+		check("policy", func(ctx context.Context) error {
+			opa, err := policy.NewOPA(*cfg.Policy)
+			if err != nil {
+				return err
+			}
+			return opa.Check(ctx)
+		})
+	}
+	if cfg.DockerHost != "" {
+		check("docker", func(ctx context.Context) error {
+			e, err := docker.NewExecutor(docker.Config{Host: cfg.DockerHost})
+			if err != nil {
+				return err
+			}
+			return e.Ping(ctx)
+		})
+	}
+
+	var vault *secrets.VaultClient
+	if cfg.Vault != nil {
+		check("vault", func(ctx context.Context) error {
+			client, err := secrets.NewVaultClient(*cfg.Vault)
+			if err != nil {
+				return err
+			}
+			if err := client.Check(ctx); err != nil {
+				return err
+			}
+			vault = client
+			return nil
+		})
+	}
+	if cfg.SecretsBackend == "file" {
+		check("secrets", func(context.Context) error {
+			_, err := secrets.NewFileProvider(cfg.SecretsDir)
+			return err
+		})
+	}
+
+	if cfg.BlobDir != "" {
+		check("blob", func(ctx context.Context) error {
+			store, err := blob.NewDiskStore(cfg.BlobDir)
+			if err != nil {
+				return err
+			}
+			if _, err := store.Put(ctx, validateProbe, strings.NewReader("ok")); err != nil {
+				return err
+			}
+			return store.Delete(ctx, validateProbe)
+		})
+	}
+	if cfg.BlobS3 != nil {
+		check("blob", func(ctx context.Context) error {
+			s3 := *cfg.BlobS3
+			if cfg.BlobS3VaultRole != "" {
+				if vault == nil {
+					return errors.New("s3 credentials: vault is unavailable")
+				}
+				s3.Credentials = vaultS3Credentials(vault, cfg.VaultAWSMount, cfg.BlobS3VaultRole)
+			}
+			store, err := blob.NewS3Store(s3)
+			if err != nil {
+				return err
+			}
+			// Reading a blob that doesn't exist proves the bucket is
+			// reachable with these credentials without writing to it
+			r, err := store.Open(ctx, validateProbe)
+			if errors.Is(err, blob.ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return r.Close()
+		})
+	}
+}
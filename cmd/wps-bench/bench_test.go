@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "weights", input: "sleep=3,math=1", want: "sleep=3,math=1"},
+		{name: "default weight", input: "math", want: "math=1"},
+		{name: "spaces and empty parts", input: " sleep = 2 ,, math=0", want: "sleep=2,math=0"},
+		{name: "unknown type", input: "email=1", wantErr: true},
+		{name: "duplicate type", input: "sleep,sleep", wantErr: true},
+		{name: "bad weight", input: "sleep=x", wantErr: true},
+		{name: "negative weight", input: "sleep=-1", wantErr: true},
+		{name: "all zero", input: "sleep=0", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseMix(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, m.String())
+		})
+	}
+}
+
+func TestMix_Picker(t *testing.T) {
+	m, err := parseMix("sleep=3,math=1")
+	assert.NoError(t, err)
+
+	pick := m.picker(1)
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		counts[pick()]++
+	}
+	assert.InDelta(t, 3000, counts["sleep"], 200)
+	assert.InDelta(t, 1000, counts["math"], 200)
+
+	m, err = parseMix("sleep=0,math=1")
+	assert.NoError(t, err)
+	pick = m.picker(1)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, "math", pick())
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 100))
+	assert.Equal(t, 7*time.Millisecond, percentile([]time.Duration{7 * time.Millisecond}, 50))
+}
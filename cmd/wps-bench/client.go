@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// client submits jobs and waits for them to finish
+type client struct {
+	baseURL  string
+	http     *http.Client
+	tenant   string
+	poll     time.Duration
+	timeout  time.Duration
+	sleepFor time.Duration
+}
+
+// jobStatus is the part of a job document the benchmark reads
+type jobStatus struct {
+	UID    string          `json:"uid"`
+	Status model.JobStatus `json:"status"`
+	Error  string          `json:"error"`
+}
+
+// run submits jobs one after another until ctx is done. A job still running
+// when ctx ends is waited for, up to the job timeout, so its completion is
+// recorded.
+func (c *client) run(ctx context.Context, pick func() string, rec *recorder) {
+	for ctx.Err() == nil {
+		jobType := pick()
+		start := time.Now()
+		uid, err := c.submit(ctx, jobType)
+		if err != nil {
+			if ctx.Err() == nil {
+				rec.submitFailed(err)
+			}
+			// Back off so a rejecting or unreachable server isn't hammered
+			select {
+			case <-time.After(c.poll):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		rec.submittedJob(time.Since(start))
+
+		waitCtx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		job, err := c.wait(waitCtx, uid)
+		cancel()
+		switch {
+		case err != nil:
+			rec.waitFailed(err)
+		case job.Status == model.JobStatusFailed:
+			rec.jobFailed(jobType, time.Since(start))
+		default:
+			rec.jobCompleted(jobType, time.Since(start))
+		}
+	}
+}
+
+func (c *client) submit(ctx context.Context, jobType string) (string, error) {
+	payload, err := c.payload(jobType)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(map[string]any{"type": jobType, "payload": payload})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/jobs", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.tenant)
+	}
+
+	var job jobStatus
+	if err := c.do(req, http.StatusCreated, &job); err != nil {
+		return "", err
+	}
+	return job.UID, nil
+}
+
+// wait polls the job until it completes or fails
+func (c *client) wait(ctx context.Context, uid string) (*jobStatus, error) {
+	ticker := time.NewTicker(c.poll)
+	defer ticker.Stop()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/v1/jobs/"+uid, nil)
+		if err != nil {
+			return nil, err
+		}
+		var job jobStatus
+		if err := c.do(req, http.StatusOK, &job); err != nil {
+			return nil, err
+		}
+		if job.Status == model.JobStatusCompleted || job.Status == model.JobStatusFailed {
+			return &job, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, errTimedOut
+		}
+	}
+}
+
+var errTimedOut = errors.New("timed out waiting for job")
+
+// statusError is a response with an unexpected status code
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.code, http.StatusText(e.code), e.message)
+}
+
+func (c *client) do(req *http.Request, want int, v any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &statusError{code: resp.StatusCode, message: strings.TrimSpace(string(msg))}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *client) payload(jobType string) (model.JobPayload, error) {
+	switch jobType {
+	case "sleep":
+		return model.SleepJobPayload{Duration: c.sleepFor.String()}, nil
+	case "math":
+		return model.MathJobPayload{Number: rand.Intn(1000)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported job type %q", jobType)
+	}
+}
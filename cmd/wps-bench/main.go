@@ -0,0 +1,71 @@
+// Command wps-bench load tests a running worker-pool-service over its HTTP
+// API. Each of -concurrency clients submits a job drawn from -mix, polls it
+// until it finishes, then submits the next, for -duration. It then reports
+// submission and end-to-end completion latency percentiles and error rates.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the service")
+	concurrency := flag.Int("concurrency", 10, "number of clients submitting jobs in parallel")
+	duration := flag.Duration("duration", 30*time.Second, "how long to keep submitting jobs")
+	mixFlag := flag.String("mix", "sleep=1,math=1", "job types to submit and their relative weights")
+	sleep := flag.Duration("sleep", 100*time.Millisecond, "duration of submitted sleep jobs")
+	poll := flag.Duration("poll", 20*time.Millisecond, "interval between job status checks; bounds completion latency resolution")
+	timeout := flag.Duration("timeout", time.Minute, "give up waiting for a job to finish after this long")
+	tenant := flag.String("tenant", "", "X-Tenant-ID to submit jobs as")
+	flag.Parse()
+
+	m, err := parseMix(*mixFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "wps-bench:", err)
+		os.Exit(2)
+	}
+	if *concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "wps-bench: concurrency must be at least 1")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	c := &client{
+		baseURL:  *baseURL,
+		http:     &http.Client{Timeout: 10 * time.Second},
+		tenant:   *tenant,
+		poll:     *poll,
+		timeout:  *timeout,
+		sleepFor: *sleep,
+	}
+	rec := newRecorder()
+
+	fmt.Printf("Running %d clients against %s for %s with mix %s\n", *concurrency, *baseURL, *duration, m)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			c.run(ctx, m.picker(seed), rec)
+		}(start.UnixNano() + int64(i))
+	}
+	wg.Wait()
+
+	rec.report(os.Stdout, time.Since(start))
+	if rec.submitted() == 0 {
+		os.Exit(1)
+	}
+}
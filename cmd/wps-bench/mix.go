@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// benchTypes are the job types the benchmark knows how to build payloads for
+var benchTypes = []string{"sleep", "math"}
+
+// mix is a weighted choice of job types
+type mix struct {
+	types   []string
+	weights []int
+	total   int
+}
+
+// parseMix reads a list like "sleep=3,math=1". A type without a weight has
+// weight 1.
+func parseMix(s string) (*mix, error) {
+	m := &mix{}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		jobType, weightStr, hasWeight := strings.Cut(part, "=")
+		jobType = strings.TrimSpace(jobType)
+		if !isBenchType(jobType) {
+			return nil, fmt.Errorf("unsupported job type %q in mix, want one of %s", jobType, strings.Join(benchTypes, ", "))
+		}
+		if seen[jobType] {
+			return nil, fmt.Errorf("job type %q appears twice in mix", jobType)
+		}
+		seen[jobType] = true
+
+		weight := 1
+		if hasWeight {
+			w, err := strconv.Atoi(strings.TrimSpace(weightStr))
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight %q for job type %q", weightStr, jobType)
+			}
+			weight = w
+		}
+		m.types = append(m.types, jobType)
+		m.weights = append(m.weights, weight)
+		m.total += weight
+	}
+	if m.total == 0 {
+		return nil, errors.New("mix must give at least one job type a positive weight")
+	}
+	return m, nil
+}
+
+func isBenchType(jobType string) bool {
+	for _, t := range benchTypes {
+		if t == jobType {
+			return true
+		}
+	}
+	return false
+}
+
+// picker returns a func drawing job types in proportion to their weights.
+// Each client gets its own so they don't contend on a shared source.
+func (m *mix) picker(seed int64) func() string {
+	r := rand.New(rand.NewSource(seed))
+	return func() string {
+		n := r.Intn(m.total)
+		for i, w := range m.weights {
+			if n < w {
+				return m.types[i]
+			}
+			n -= w
+		}
+		return m.types[len(m.types)-1]
+	}
+}
+
+func (m *mix) String() string {
+	parts := make([]string, len(m.types))
+	for i := range m.types {
+		parts[i] = fmt.Sprintf("%s=%d", m.types[i], m.weights[i])
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// recorder collects latencies and errors from every client
+type recorder struct {
+	mu        sync.Mutex
+	attempts  int
+	submits   []time.Duration
+	completes map[string][]time.Duration
+	finished  int
+	failed    int
+	errors    map[string]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		completes: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+}
+
+func (r *recorder) submittedJob(latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	r.submits = append(r.submits, latency)
+}
+
+func (r *recorder) submitFailed(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	r.errors["submit: "+describe(err)]++
+}
+
+func (r *recorder) jobCompleted(jobType string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished++
+	r.completes[jobType] = append(r.completes[jobType], latency)
+}
+
+func (r *recorder) jobFailed(jobType string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished++
+	r.failed++
+	r.completes[jobType] = append(r.completes[jobType], latency)
+	r.errors["job failed"]++
+}
+
+func (r *recorder) waitFailed(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors["wait: "+describe(err)]++
+}
+
+func (r *recorder) submitted() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.submits)
+}
+
+// describe groups errors so the report doesn't list one line per request
+func describe(err error) string {
+	var se *statusError
+	switch {
+	case errors.As(err, &se):
+		return fmt.Sprintf("%d %s", se.code, http.StatusText(se.code))
+	case errors.Is(err, errTimedOut):
+		return "timed out"
+	default:
+		return "request error"
+	}
+}
+
+func (r *recorder) report(w io.Writer, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Fprintf(w, "\nSubmitted %d of %d jobs in %s (%.1f jobs/s)\n",
+		len(r.submits), r.attempts, elapsed.Round(time.Millisecond), float64(len(r.submits))/elapsed.Seconds())
+	fmt.Fprintf(w, "Finished %d jobs, %d failed\n", r.finished, r.failed)
+	fmt.Fprintf(w, "Submission error rate %s, job failure rate %s\n\n",
+		percent(r.attempts-len(r.submits), r.attempts), percent(r.failed, r.finished))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "latency\tcount\tp50\tp90\tp99\tmax\t")
+	writeLatencies(tw, "submit", r.submits)
+
+	var all []time.Duration
+	types := make([]string, 0, len(r.completes))
+	for jobType, latencies := range r.completes {
+		types = append(types, jobType)
+		all = append(all, latencies...)
+	}
+	sort.Strings(types)
+	writeLatencies(tw, "complete", all)
+	for _, jobType := range types {
+		writeLatencies(tw, "  "+jobType, r.completes[jobType])
+	}
+	tw.Flush()
+
+	if len(r.errors) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nErrors:")
+	kinds := make([]string, 0, len(r.errors))
+	for kind := range r.errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "  %-40s %d\n", kind, r.errors[kind])
+	}
+}
+
+func writeLatencies(w io.Writer, name string, latencies []time.Duration) {
+	if len(latencies) == 0 {
+		fmt.Fprintf(w, "%s\t0\t-\t-\t-\t-\t\n", name)
+		return
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t\n", name, len(sorted),
+		round(percentile(sorted, 50)), round(percentile(sorted, 90)), round(percentile(sorted, 99)), round(sorted[len(sorted)-1]))
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+func percent(n, of int) string {
+	if of == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", 100*float64(n)/float64(of))
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// initialisms are kept upper case in Go names, as in HTTPPayload
+var initialisms = map[string]bool{
+	"api": true, "cpu": true, "dns": true, "http": true, "https": true, "id": true, "ip": true,
+	"json": true, "sql": true, "tls": true, "ttl": true, "uid": true, "uri": true, "url": true,
+	"uuid": true, "xml": true,
+}
+
+// exported turns a job type or property name such as max_items into an
+// exported Go name such as MaxItems
+func exported(name string) string {
+	var b strings.Builder
+	words := strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	if b.Len() == 0 || unicode.IsDigit(rune(b.String()[0])) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
+
+// generator writes the source of a client package
+type generator struct {
+	buf bytes.Buffer
+	// structs are the object schemas still to be written, by Go name
+	structs []pendingStruct
+	names   map[string]bool
+	// secrets is set once a property takes SecretString values
+	secrets bool
+}
+
+type pendingStruct struct {
+	name   string
+	doc    string
+	schema map[string]any
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// generate returns the formatted source of a client package for the job
+// types
+func generate(pkg string, types []jobType) ([]byte, error) {
+	g := &generator{names: make(map[string]bool)}
+	g.printf("// Code generated by wps-gen. DO NOT EDIT.\n\n")
+	g.printf("// Package %s is a typed client for the jobs of a worker-pool-service.\n", pkg)
+	g.printf("package %s\n\n", pkg)
+	g.printf("%s", clientSource)
+
+	for _, t := range types {
+		if err := g.jobType(t); err != nil {
+			return nil, fmt.Errorf("job type %q: %w", t.Name, err)
+		}
+	}
+	if g.secrets {
+		if _, err := g.reserve("SecretString"); err != nil {
+			return nil, err
+		}
+		g.printf("%s", secretStringSource)
+	}
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// jobType writes the payload struct of t and the methods submitting it
+func (g *generator) jobType(t jobType) error {
+	name := exported(t.Name)
+	if t.Schema["type"] != "object" {
+		return fmt.Errorf("payload schema must describe an object")
+	}
+	payload, err := g.reserve(name + "Payload")
+	if err != nil {
+		return err
+	}
+	g.structs = append(g.structs, pendingStruct{
+		name:   payload,
+		doc:    fmt.Sprintf("%s is the payload of %s jobs", payload, t.Name),
+		schema: t.Schema,
+	})
+	for len(g.structs) > 0 {
+		s := g.structs[0]
+		g.structs = g.structs[1:]
+		if err := g.object(s); err != nil {
+			return err
+		}
+	}
+
+	what := ""
+	if t.Description != "" {
+		what = ", which " + lowerFirst(strings.TrimSuffix(t.Description, "."))
+	}
+	g.printf("// Submit%s submits a job of the %s type%s\n", name, t.Name, what)
+	g.printf("func (c *Client) Submit%s(ctx context.Context, payload %s, opts *SubmitOptions) (*Job, error) {\n", name, payload)
+	g.printf("return c.Submit(ctx, %q, payload, opts)\n}\n\n", t.Name)
+	g.printf("// Run%s submits a job of the %s type and waits for it to finish\n", name, t.Name)
+	g.printf("func (c *Client) Run%s(ctx context.Context, payload %s, opts *SubmitOptions) (*Job, error) {\n", name, payload)
+	g.printf("job, err := c.Submit%s(ctx, payload, opts)\nif err != nil {\nreturn nil, err\n}\n", name)
+	g.printf("return c.Wait(ctx, job.UID)\n}\n\n")
+	return nil
+}
+
+// reserve claims a Go type name, failing if two job types or properties
+// would share it
+func (g *generator) reserve(name string) (string, error) {
+	if g.names[name] {
+		return "", fmt.Errorf("more than one type would be named %s", name)
+	}
+	g.names[name] = true
+	return name, nil
+}
+
+// object writes a struct with a field for each of the schema's properties,
+// in name order. Required fields are always sent and the rest only when
+// set.
+func (g *generator) object(s pendingStruct) error {
+	properties, _ := s.schema["properties"].(map[string]any)
+	required := make(map[string]bool)
+	if list, ok := s.schema["required"].([]any); ok {
+		for _, r := range list {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	g.printf("// %s\ntype %s struct {\n", s.doc, s.name)
+	for _, prop := range slices.Sorted(maps.Keys(properties)) {
+		schema, _ := properties[prop].(map[string]any)
+		field := exported(prop)
+		typ, err := g.goType(s.name+field, schema)
+		if err != nil {
+			return fmt.Errorf("property %q: %w", prop, err)
+		}
+		for _, line := range fieldDoc(schema) {
+			g.printf("// %s\n", line)
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+		}
+		g.printf("%s %s `json:%q`\n", field, typ, tag)
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// goType returns the Go type values of schema decode to. Objects with
+// properties of their own become structs named name.
+func (g *generator) goType(name string, schema map[string]any) (string, error) {
+	if isSecretString(schema) {
+		g.secrets = true
+		return "SecretString", nil
+	}
+	switch schema["type"] {
+	case "string":
+		return "string", nil
+	case "integer":
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return "[]any", nil
+		}
+		item, err := g.goType(name+"Item", items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case "object":
+		if _, ok := schema["properties"].(map[string]any); ok {
+			s, err := g.reserve(name)
+			if err != nil {
+				return "", err
+			}
+			g.structs = append(g.structs, pendingStruct{name: s, doc: s + " is part of a payload", schema: schema})
+			return s, nil
+		}
+		if values, ok := schema["additionalProperties"].(map[string]any); ok {
+			value, err := g.goType(name+"Value", values)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + value, nil
+		}
+		return "map[string]any", nil
+	}
+	return "any", nil
+}
+
+// isSecretString reports whether schema takes a string or a reference to
+// a secret, {"$secret": "name"}
+func isSecretString(schema map[string]any) bool {
+	alternatives, ok := schema["oneOf"].([]any)
+	if !ok || len(alternatives) != 2 {
+		return false
+	}
+	str, _ := alternatives[0].(map[string]any)
+	ref, _ := alternatives[1].(map[string]any)
+	properties, _ := ref["properties"].(map[string]any)
+	_, named := properties["$secret"]
+	return str["type"] == "string" && ref["type"] == "object" && named && len(properties) == 1
+}
+
+// fieldDoc describes a property from its schema's description and
+// constraints
+func fieldDoc(schema map[string]any) []string {
+	var lines []string
+	if d, ok := schema["description"].(string); ok && d != "" {
+		lines = append(lines, d)
+	}
+	if values, ok := schema["enum"].([]any); ok {
+		var names []string
+		for _, v := range values {
+			names = append(names, fmt.Sprint(v))
+		}
+		lines = append(lines, "One of "+strings.Join(names, ", "))
+	}
+	minimum, hasMin := schema["minimum"].(float64)
+	maximum, hasMax := schema["maximum"].(float64)
+	switch {
+	case hasMin && hasMax:
+		lines = append(lines, fmt.Sprintf("From %s to %s", number(minimum), number(maximum)))
+	case hasMin:
+		lines = append(lines, "At least "+number(minimum))
+	case hasMax:
+		lines = append(lines, "At most "+number(maximum))
+	}
+	if v, ok := schema["minLength"].(float64); ok && v == 1 {
+		lines = append(lines, "Must not be empty")
+	} else if ok {
+		lines = append(lines, fmt.Sprintf("At least %s characters", number(v)))
+	}
+	if v, ok := schema["format"].(string); ok {
+		lines = append(lines, "Format: "+v)
+	}
+	return lines
+}
+
+// number formats a JSON number without an exponent
+func number(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// clientSource is the part of the client that doesn't depend on the job
+// types
+const clientSource = `import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client submits jobs to a worker-pool-service and waits for them
+type Client struct {
+	// BaseURL is where the service is served, such as http://localhost:8080
+	BaseURL string
+	// HTTPClient makes the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+	// Tenant is sent as X-Tenant-ID when set
+	Tenant string
+	// PollInterval is how often Wait checks on a job, every second when zero
+	PollInterval time.Duration
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// SubmitOptions are the optional parts of a submission
+type SubmitOptions struct {
+	Labels map[string]string ` + "`json:\"labels,omitempty\"`" + `
+	// Requires lists the capabilities a worker must offer to run the job
+	Requires []string ` + "`json:\"requires,omitempty\"`" + `
+	// Placement lists the worker labels the job must run on
+	Placement map[string]string ` + "`json:\"placement,omitempty\"`" + `
+	Weight    int               ` + "`json:\"weight,omitempty\"`" + `
+	Backoff   string            ` + "`json:\"backoff,omitempty\"`" + `
+	// Deadline is when the job should finish by
+	Deadline *time.Time ` + "`json:\"deadline,omitempty\"`" + `
+	// Timeout and Retention are durations such as 30s or 24h
+	Timeout          string ` + "`json:\"timeout,omitempty\"`" + `
+	MaxRetries       *int   ` + "`json:\"max_retries,omitempty\"`" + `
+	Priority         *int   ` + "`json:\"priority,omitempty\"`" + `
+	Retention        string ` + "`json:\"retention,omitempty\"`" + `
+	SerializationKey string ` + "`json:\"serialization_key,omitempty\"`" + `
+	// Parent is the UID of the job submitting this one
+	Parent string ` + "`json:\"parent,omitempty\"`" + `
+}
+
+// Job is a job as the service reports it
+type Job struct {
+	UID         string            ` + "`json:\"uid\"`" + `
+	Type        string            ` + "`json:\"type\"`" + `
+	Labels      map[string]string ` + "`json:\"labels,omitempty\"`" + `
+	Status      string            ` + "`json:\"status\"`" + `
+	Error       string            ` + "`json:\"error,omitempty\"`" + `
+	Result      *Result           ` + "`json:\"result,omitempty\"`" + `
+	CreatedAt   time.Time         ` + "`json:\"created_at\"`" + `
+	StartedAt   *time.Time        ` + "`json:\"started_at,omitempty\"`" + `
+	CompletedAt *time.Time        ` + "`json:\"completed_at,omitempty\"`" + `
+}
+
+// Result is what a completed job produced
+type Result struct {
+	Type string          ` + "`json:\"type\"`" + `
+	Data json.RawMessage ` + "`json:\"data\"`" + `
+}
+
+// Finished reports whether the job has stopped for good
+func (j *Job) Finished() bool {
+	return j.Status == "completed" || j.Status == "failed"
+}
+
+// DecodeResult decodes the job's result into v
+func (j *Job) DecodeResult(v any) error {
+	if j.Result == nil {
+		return errors.New("job has no result")
+	}
+	return json.Unmarshal(j.Result.Data, v)
+}
+
+// Error is a response the service turned a request away with
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Submit submits a job of any type. The typed Submit methods are usually
+// what you want.
+func (c *Client) Submit(ctx context.Context, jobType string, payload any, opts *SubmitOptions) (*Job, error) {
+	body, err := json.Marshal(struct {
+		Type    string ` + "`json:\"type\"`" + `
+		Payload any    ` + "`json:\"payload\"`" + `
+		*SubmitOptions
+	}{jobType, payload, opts})
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := c.do(ctx, http.MethodPost, "/v1/jobs", bytes.NewReader(body), &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Get looks up a job by UID
+func (c *Client) Get(ctx context.Context, uid string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodGet, "/v1/jobs/"+url.PathEscape(uid), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Wait polls a job until it finishes or ctx is done
+func (c *Client) Wait(ctx context.Context, uid string) (*Job, error) {
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job, err := c.Get(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		if job.Finished() {
+			return job, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, v any) error {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.Tenant)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+`
+
+// secretStringSource is written when a payload takes values that may come
+// from secrets
+const secretStringSource = `// SecretString is a payload value given either literally or as a reference
+// to a secret, which the service resolves only while the job runs
+type SecretString struct {
+	Value string
+	// Secret names the secret the value comes from
+	Secret string
+}
+
+// Literal returns a value given as is
+func Literal(value string) SecretString {
+	return SecretString{Value: value}
+}
+
+// SecretRef returns a value read from the named secret
+func SecretRef(name string) SecretString {
+	return SecretString{Secret: name}
+}
+
+func (s SecretString) MarshalJSON() ([]byte, error) {
+	if s.Secret != "" {
+		return json.Marshal(map[string]string{"$secret": s.Secret})
+	}
+	return json.Marshal(s.Value)
+}
+
+func (s *SecretString) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		*s = SecretString{}
+		return json.Unmarshal(data, &s.Value)
+	}
+	var ref map[string]string
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return err
+	}
+	name, ok := ref["$secret"]
+	if !ok || len(ref) != 1 {
+		return errors.New(` + "`" + `secret reference must be {"$secret": "name"}` + "`" + `)
+	}
+	*s = SecretString{Secret: name}
+	return nil
+}
+`
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExported(t *testing.T) {
+	tests := map[string]string{
+		"sleep":        "Sleep",
+		"http":         "HTTP",
+		"max_items":    "MaxItems",
+		"callback-url": "CallbackURL",
+		"retryCount":   "RetryCount",
+		"3d":           "X3d",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, exported(in), in)
+	}
+}
+
+// check type checks generated source, returning its package
+func check(t *testing.T, src []byte) *types.Package {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "client.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	assert.True(t, ast.IsGenerated(file))
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("wps", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+	return pkg
+}
+
+// field returns the type and tag of the named field of a struct type
+func field(t *testing.T, pkg *types.Package, typeName, fieldName string) (string, string) {
+	obj := pkg.Scope().Lookup(typeName)
+	require.NotNil(t, obj, typeName)
+	s := obj.Type().Underlying().(*types.Struct)
+	for i := 0; i < s.NumFields(); i++ {
+		if s.Field(i).Name() == fieldName {
+			return types.TypeString(s.Field(i).Type(), types.RelativeTo(pkg)), s.Tag(i)
+		}
+	}
+	t.Fatalf("%s has no field %s", typeName, fieldName)
+	return "", ""
+}
+
+func TestGenerate_Builtin(t *testing.T) {
+	jobTypes, err := load("", "")
+	require.NoError(t, err)
+	src, err := generate("wps", jobTypes)
+	require.NoError(t, err)
+	pkg := check(t, src)
+
+	typ, tag := field(t, pkg, "SleepPayload", "Duration")
+	assert.Equal(t, "string", typ)
+	assert.Equal(t, `json:"duration"`, tag)
+	typ, _ = field(t, pkg, "MathPayload", "Number")
+	assert.Equal(t, "int", typ)
+	typ, tag = field(t, pkg, "ContainerPayload", "Command")
+	assert.Equal(t, "[]string", typ)
+	assert.Equal(t, `json:"command,omitempty"`, tag)
+	typ, _ = field(t, pkg, "HTTPPayload", "Headers")
+	assert.Equal(t, "map[string]SecretString", typ)
+	typ, _ = field(t, pkg, "ContainerPayload", "Env")
+	assert.Equal(t, "map[string]SecretString", typ)
+	typ, _ = field(t, pkg, "SecretString", "Secret")
+	assert.Equal(t, "string", typ)
+
+	client := pkg.Scope().Lookup("Client").Type()
+	for _, method := range []string{"SubmitSleep", "RunSleep", "SubmitMath", "RunMath", "SubmitContainer", "SubmitHTTP", "RunHTTP", "Wait"} {
+		obj, _, _ := types.LookupFieldOrMethod(client, true, pkg, method)
+		assert.NotNil(t, obj, method)
+	}
+}
+
+func TestGenerate_NestedObjects(t *testing.T) {
+	src, err := generate("reports", []jobType{{
+		Name:        "render-report",
+		Description: "Renders a report.",
+		Schema: map[string]any{
+			"type":     "object",
+			"required": []any{"title"},
+			"properties": map[string]any{
+				"title":   map[string]any{"type": "string"},
+				"scale":   map[string]any{"type": "number", "minimum": 0.5},
+				"draft":   map[string]any{"type": "boolean"},
+				"extra":   map[string]any{},
+				"columns": map[string]any{"type": "array", "items": map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"type": "string"}}}},
+				"page":    map[string]any{"type": "object", "properties": map[string]any{"size": map[string]any{"type": "string", "enum": []any{"a4", "letter"}}}},
+			},
+		},
+	}})
+	require.NoError(t, err)
+	pkg := check(t, src)
+
+	typ, tag := field(t, pkg, "RenderReportPayload", "Title")
+	assert.Equal(t, "string", typ)
+	assert.Equal(t, `json:"title"`, tag)
+	typ, _ = field(t, pkg, "RenderReportPayload", "Scale")
+	assert.Equal(t, "float64", typ)
+	typ, _ = field(t, pkg, "RenderReportPayload", "Extra")
+	assert.Equal(t, "any", typ)
+	typ, _ = field(t, pkg, "RenderReportPayload", "Columns")
+	assert.Equal(t, "[]RenderReportPayloadColumnsItem", typ)
+	typ, _ = field(t, pkg, "RenderReportPayload", "Page")
+	assert.Equal(t, "RenderReportPayloadPage", typ)
+	typ, _ = field(t, pkg, "RenderReportPayloadPage", "Size")
+	assert.Equal(t, "string", typ)
+	assert.Contains(t, string(src), "// One of a4, letter")
+	assert.Contains(t, string(src), "// At least 0.5")
+	assert.Contains(t, string(src), "func (c *Client) RunRenderReport(")
+	assert.Nil(t, pkg.Scope().Lookup("SecretString"))
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	_, err := generate("wps", []jobType{{Name: "bad", Schema: map[string]any{"type": "string"}}})
+	assert.EqualError(t, err, `job type "bad": payload schema must describe an object`)
+
+	object := map[string]any{"type": "object"}
+	_, err = generate("wps", []jobType{{Name: "a-b", Schema: object}, {Name: "a_b", Schema: object}})
+	assert.EqualError(t, err, `job type "a_b": more than one type would be named ABPayload`)
+}
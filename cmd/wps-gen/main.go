@@ -0,0 +1,111 @@
+// Command wps-gen generates a typed Go client for a worker-pool-service's
+// job types. Each type gets a payload struct built from its JSON Schema and
+// Submit and Run methods taking it, so payloads are checked when the client
+// is compiled rather than when the service rejects them. The schemas are
+// read from a running service with -url, from a file saved from its
+// GET /v1/job-types with -schemas, or otherwise are those of the built-in
+// job types.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+func main() {
+	baseURL := flag.String("url", "", "base URL of a running service to read the job types from")
+	schemas := flag.String("schemas", "", "file holding the output of GET /v1/job-types to read the job types from")
+	pkg := flag.String("package", "wps", "package name of the generated code")
+	out := flag.String("o", "", "file to write the generated code to, standard output when empty")
+	flag.Parse()
+
+	if *baseURL != "" && *schemas != "" {
+		fmt.Fprintln(os.Stderr, "wps-gen: -url and -schemas can't both be set")
+		os.Exit(2)
+	}
+	types, err := load(*baseURL, *schemas)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "wps-gen:", err)
+		os.Exit(1)
+	}
+	src, err := generate(*pkg, types)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "wps-gen:", err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "wps-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// jobType is the part of a job type the generator reads
+type jobType struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Schema      map[string]any `json:"payload_schema"`
+}
+
+// load reads the job types from the service at baseURL, from the file at
+// schemas, or failing both from the built-in ones
+func load(baseURL, schemas string) ([]jobType, error) {
+	var data []byte
+	var err error
+	switch {
+	case baseURL != "":
+		data, err = fetch(baseURL)
+	case schemas != "":
+		data, err = os.ReadFile(schemas)
+	default:
+		// Round trip them, so they read as they would from the service
+		data, err = json.Marshal(model.BuiltinJobTypes())
+	}
+	if err != nil {
+		return nil, err
+	}
+	var types []jobType
+	if err := json.Unmarshal(data, &types); err != nil {
+		return nil, fmt.Errorf("reading job types: %w", err)
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("no job types to generate a client for")
+	}
+	return types, nil
+}
+
+func fetch(baseURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/v1/job-types", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET /v1/job-types: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
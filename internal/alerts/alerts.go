@@ -0,0 +1,275 @@
+// Package alerts evaluates alert rules against job events and delivers the
+// alerts they fire to webhooks.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/backoff"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+var ErrRuleNotFound = errors.New("alert rule not found")
+
+// MaxHistory is how many alerts are kept, newest first
+const MaxHistory = 1000
+
+// deliveryAttempts and deliveryBackoff bound how hard delivering an alert is
+// tried before it is recorded as failed
+const deliveryAttempts = 3
+
+var deliveryBackoff backoff.Strategy = backoff.Exponential{Base: time.Second, Max: 10 * time.Second}
+
+// Engine holds the alert rules, counts the job events matching each and
+// fires an alert when a rule's threshold is crossed
+type Engine struct {
+	client *http.Client
+	now    func() time.Time
+
+	mu         sync.Mutex
+	rules      map[uuid.UUID]*ruleState
+	history    []*model.Alert
+	deliveries chan delivery
+}
+
+type ruleState struct {
+	rule *model.AlertRule
+	// seen holds when matching events happened within the window, oldest
+	// first
+	seen   []time.Time
+	firing bool
+}
+
+type delivery struct {
+	alert *model.Alert
+	rule  model.AlertRule
+}
+
+func NewEngine() *Engine {
+	return &Engine{
+		client:     &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		rules:      make(map[uuid.UUID]*ruleState),
+		deliveries: make(chan delivery, 100),
+	}
+}
+
+func (e *Engine) CreateRule(req *model.AlertRuleRequest) *model.AlertRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rule := req.Rule(uuid.New(), e.now())
+	e.rules[rule.ID] = &ruleState{rule: rule}
+	copied := *rule
+	return &copied
+}
+
+// ListRules returns the rules, oldest first
+func (e *Engine) ListRules() []*model.AlertRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rules := make([]*model.AlertRule, 0, len(e.rules))
+	for _, state := range e.rules {
+		copied := *state.rule
+		rules = append(rules, &copied)
+	}
+	slices.SortFunc(rules, func(a, b *model.AlertRule) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return rules
+}
+
+func (e *Engine) GetRule(id uuid.UUID) (*model.AlertRule, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	state, ok := e.rules[id]
+	if !ok {
+		return nil, ErrRuleNotFound
+	}
+	copied := *state.rule
+	return &copied, nil
+}
+
+// UpdateRule replaces a rule, which starts counting afresh
+func (e *Engine) UpdateRule(id uuid.UUID, req *model.AlertRuleRequest) (*model.AlertRule, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	state, ok := e.rules[id]
+	if !ok {
+		return nil, ErrRuleNotFound
+	}
+	rule := req.Rule(id, state.rule.CreatedAt)
+	e.rules[id] = &ruleState{rule: rule}
+	copied := *rule
+	return &copied, nil
+}
+
+func (e *Engine) DeleteRule(id uuid.UUID) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.rules[id]; !ok {
+		return ErrRuleNotFound
+	}
+	delete(e.rules, id)
+	return nil
+}
+
+// History returns the alerts fired, newest first
+func (e *Engine) History() []*model.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	alerts := make([]*model.Alert, len(e.history))
+	for i, alert := range e.history {
+		copied := *alert
+		alerts[len(alerts)-1-i] = &copied
+	}
+	return alerts
+}
+
+// Observe counts a job event against the rules it matches. It is meant to
+// be passed to WorkerPool.Subscribe, so delivering the alerts it fires is
+// left to Run.
+func (e *Engine) Observe(ev model.JobEvent) {
+	status := ev.To
+	switch {
+	case ev.DeadlineMissed:
+		status = model.AlertDeadlineMissed
+	case ev.To != model.JobStatusCompleted && ev.To != model.JobStatusFailed:
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, state := range e.rules {
+		rule := state.rule
+		if rule.Status != status || (rule.JobType != "" && rule.JobType != ev.JobType) {
+			continue
+		}
+		cutoff := ev.At.Add(-rule.WindowDuration())
+		i, _ := slices.BinarySearchFunc(state.seen, cutoff, func(t, cutoff time.Time) int {
+			if t.After(cutoff) {
+				return 1
+			}
+			return -1
+		})
+		state.seen = append(state.seen[i:], ev.At)
+		if len(state.seen) <= rule.Threshold {
+			state.firing = false
+			continue
+		}
+		if !state.firing {
+			state.firing = true
+			e.fire(rule, len(state.seen), ev.At)
+		}
+	}
+}
+
+// fire records an alert for the rule and queues its delivery
+func (e *Engine) fire(rule *model.AlertRule, count int, at time.Time) {
+	alert := &model.Alert{
+		ID:       uuid.New(),
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		Count:    count,
+		Message:  message(rule, count),
+		FiredAt:  at,
+	}
+	e.history = append(e.history, alert)
+	if len(e.history) > MaxHistory {
+		e.history = slices.Delete(e.history, 0, len(e.history)-MaxHistory)
+	}
+	select {
+	case e.deliveries <- delivery{alert: alert, rule: *rule}:
+	default:
+		alert.Error = "too many alerts waiting to be delivered"
+	}
+}
+
+func message(rule *model.AlertRule, count int) string {
+	jobs := "jobs"
+	if count == 1 {
+		jobs = "job"
+	}
+	if rule.JobType != "" {
+		jobs = rule.JobType + " " + jobs
+	}
+	outcome := string(rule.Status)
+	if rule.Status == model.AlertDeadlineMissed {
+		outcome = "missed a deadline"
+	}
+	return fmt.Sprintf("%s: %d %s %s in the last %s, more than %d", rule.Name, count, jobs, outcome, rule.Window, rule.Threshold)
+}
+
+// Run delivers fired alerts until ctx is done
+func (e *Engine) Run(ctx context.Context) {
+	for {
+		select {
+		case d := <-e.deliveries:
+			err := e.deliver(ctx, d)
+			e.mu.Lock()
+			if err != nil {
+				slog.Warn("Failed to deliver alert", "rule", d.rule.Name, "error", err)
+				d.alert.Error = err.Error()
+			} else {
+				now := e.now()
+				d.alert.DeliveredAt = &now
+			}
+			e.mu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (e *Engine) deliver(ctx context.Context, d delivery) error {
+	e.mu.Lock()
+	alert := *d.alert
+	e.mu.Unlock()
+
+	var body any = alert
+	if d.rule.Format == model.AlertFormatSlack {
+		body = map[string]string{"text": alert.Message}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = e.post(ctx, d.rule.WebhookURL, data)
+		if err == nil || attempt == deliveryAttempts {
+			return err
+		}
+		select {
+		case <-time.After(deliveryBackoff.Delay(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (e *Engine) post(ctx context.Context, url string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook responded %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/backoff"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func finished(jobType string, status model.JobStatus, at time.Time) model.JobEvent {
+	return model.JobEvent{JobUID: uuid.New(), JobType: jobType, From: model.JobStatusRunning, To: status, At: at}
+}
+
+func TestEngine_Observe(t *testing.T) {
+	e := NewEngine()
+	rule := e.CreateRule(&model.AlertRuleRequest{
+		Name:       "http failures",
+		JobType:    "math",
+		Threshold:  2,
+		Window:     "10m",
+		WebhookURL: "http://alerts.internal/hook",
+	})
+	assert.Equal(t, model.JobStatusFailed, rule.Status)
+	assert.Equal(t, model.AlertFormatWebhook, rule.Format)
+
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	e.Observe(finished("math", model.JobStatusFailed, start))
+	e.Observe(finished("math", model.JobStatusCompleted, start.Add(time.Minute)))
+	e.Observe(finished("sleep", model.JobStatusFailed, start.Add(time.Minute)))
+	e.Observe(finished("math", model.JobStatusFailed, start.Add(2*time.Minute)))
+	assert.Empty(t, e.History())
+
+	// The third failure within the window crosses the threshold
+	e.Observe(finished("math", model.JobStatusFailed, start.Add(3*time.Minute)))
+	history := e.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, rule.ID, history[0].RuleID)
+	assert.Equal(t, 3, history[0].Count)
+	assert.Equal(t, "http failures: 3 math jobs failed in the last 10m, more than 2", history[0].Message)
+
+	// It doesn't fire again while the count stays over the threshold
+	e.Observe(finished("math", model.JobStatusFailed, start.Add(4*time.Minute)))
+	assert.Len(t, e.History(), 1)
+
+	// Once earlier failures leave the window it can fire again
+	e.Observe(finished("math", model.JobStatusFailed, start.Add(30*time.Minute)))
+	e.Observe(finished("math", model.JobStatusFailed, start.Add(31*time.Minute)))
+	e.Observe(finished("math", model.JobStatusFailed, start.Add(32*time.Minute)))
+	history = e.History()
+	require.Len(t, history, 2)
+	assert.Equal(t, start.Add(32*time.Minute), history[0].FiredAt)
+
+	require.NoError(t, e.DeleteRule(rule.ID))
+	assert.ErrorIs(t, e.DeleteRule(rule.ID), ErrRuleNotFound)
+	e.Observe(finished("math", model.JobStatusFailed, start.Add(33*time.Minute)))
+	assert.Len(t, e.History(), 2)
+}
+
+func TestEngine_ObserveDeadlineMissed(t *testing.T) {
+	e := NewEngine()
+	e.CreateRule(&model.AlertRuleRequest{
+		Name:       "late",
+		Status:     model.AlertDeadlineMissed,
+		Window:     "1h",
+		WebhookURL: "http://alerts.internal/hook",
+	})
+
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	missed := model.JobEvent{JobUID: uuid.New(), JobType: "sleep", From: model.JobStatusCompleted, To: model.JobStatusCompleted, At: start, DeadlineMissed: true}
+	e.Observe(finished("sleep", model.JobStatusCompleted, start))
+	assert.Empty(t, e.History())
+
+	e.Observe(missed)
+	history := e.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, "late: 1 job missed a deadline in the last 1h, more than 0", history[0].Message)
+}
+
+func TestEngine_Rules(t *testing.T) {
+	e := NewEngine()
+	req := &model.AlertRuleRequest{Name: "any failure", Window: "1m", WebhookURL: "https://alerts.internal/hook"}
+	first := e.CreateRule(req)
+	second := e.CreateRule(&model.AlertRuleRequest{Name: "successes", Status: model.JobStatusCompleted, Window: "1h", WebhookURL: "https://alerts.internal/hook"})
+
+	rules := e.ListRules()
+	require.Len(t, rules, 2)
+	assert.Equal(t, first.ID, rules[0].ID)
+
+	updated, err := e.UpdateRule(second.ID, &model.AlertRuleRequest{Name: "renamed", Window: "5m", WebhookURL: "https://alerts.internal/hook", Format: model.AlertFormatSlack})
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, updated.ID)
+	assert.Equal(t, second.CreatedAt, updated.CreatedAt)
+	assert.Equal(t, "renamed", updated.Name)
+
+	got, err := e.GetRule(second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated, got)
+
+	_, err = e.GetRule(uuid.New())
+	assert.ErrorIs(t, err, ErrRuleNotFound)
+	_, err = e.UpdateRule(uuid.New(), req)
+	assert.ErrorIs(t, err, ErrRuleNotFound)
+}
+
+func TestEngine_Deliver(t *testing.T) {
+	defer func(b backoff.Strategy) { deliveryBackoff = b }(deliveryBackoff)
+	deliveryBackoff = backoff.Constant(0)
+
+	var mu sync.Mutex
+	received := map[string][]map[string]any{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "no such channel", http.StatusNotFound)
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], body)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	e := NewEngine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx)
+
+	e.CreateRule(&model.AlertRuleRequest{Name: "webhook", Window: "1m", WebhookURL: srv.URL + "/webhook"})
+	e.CreateRule(&model.AlertRuleRequest{Name: "slack", Window: "1m", WebhookURL: srv.URL + "/slack", Format: model.AlertFormatSlack})
+	e.CreateRule(&model.AlertRuleRequest{Name: "broken", Window: "1m", WebhookURL: srv.URL + "/broken"})
+	e.Observe(finished("math", model.JobStatusFailed, time.Now()))
+
+	require.Eventually(t, func() bool {
+		for _, alert := range e.History() {
+			if alert.DeliveredAt == nil && alert.Error == "" {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received["/webhook"], 1)
+	assert.Equal(t, "webhook", received["/webhook"][0]["rule_name"])
+	assert.Equal(t, float64(1), received["/webhook"][0]["count"])
+	assert.Equal(t, []map[string]any{{"text": "slack: 1 job failed in the last 1m, more than 0"}}, received["/slack"])
+
+	for _, alert := range e.History() {
+		if alert.RuleName == "broken" {
+			assert.Nil(t, alert.DeliveredAt)
+			assert.Equal(t, "webhook responded 404 Not Found: no such channel", alert.Error)
+		} else {
+			assert.NotNil(t, alert.DeliveredAt)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package anomaly flags job runs whose duration is far from the usual for
+// their type, to catch executors that have quietly become slower.
+package anomaly
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+const (
+	// Window is how many recent runs of a job type its baseline is taken
+	// from, so the baseline follows lasting changes
+	Window = 100
+	// MinSamples is how many runs of a type must be seen before any is
+	// flagged
+	MinSamples = 20
+	// MaxHistory is how many anomalies are kept
+	MaxHistory = 1000
+	// minRelativeStdDev floors the standard deviation at a fraction of the
+	// mean, so types that take almost exactly as long every time aren't
+	// flagged for ordinary jitter
+	minRelativeStdDev = 0.05
+)
+
+// Detector keeps a baseline of completed run durations per job type and
+// flags runs more than a number of standard deviations from its mean
+type Detector struct {
+	stdDevs float64
+	notify  func(model.DurationAnomaly)
+
+	mu        sync.Mutex
+	started   map[uuid.UUID]time.Time
+	baselines map[string]*baseline
+	history   []model.DurationAnomaly
+}
+
+// NewDetector returns a detector flagging runs more than stdDevs standard
+// deviations from their type's mean. Zero turns detection off.
+func NewDetector(stdDevs float64) *Detector {
+	return &Detector{
+		stdDevs:   stdDevs,
+		started:   make(map[uuid.UUID]time.Time),
+		baselines: make(map[string]*baseline),
+	}
+}
+
+// Notify has fn called with every anomaly found. It must be called before
+// the detector observes any events.
+func (d *Detector) Notify(fn func(model.DurationAnomaly)) {
+	d.notify = fn
+}
+
+// Observe records a job event. It is meant to be passed to
+// WorkerPool.Subscribe.
+func (d *Detector) Observe(ev model.JobEvent) {
+	if d.stdDevs == 0 || ev.DeadlineMissed {
+		return
+	}
+	anomaly, found := d.observe(ev)
+	if found && d.notify != nil {
+		d.notify(anomaly)
+	}
+}
+
+func (d *Detector) observe(ev model.JobEvent) (model.DurationAnomaly, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ev.To == model.JobStatusRunning {
+		d.started[ev.JobUID] = ev.At
+		return model.DurationAnomaly{}, false
+	}
+	start, ok := d.started[ev.JobUID]
+	if !ok {
+		return model.DurationAnomaly{}, false
+	}
+	delete(d.started, ev.JobUID)
+	// Failures often end early, so only successful runs make the baseline
+	if ev.To != model.JobStatusCompleted {
+		return model.DurationAnomaly{}, false
+	}
+
+	duration := ev.At.Sub(start).Seconds()
+	b, ok := d.baselines[ev.JobType]
+	if !ok {
+		b = &baseline{}
+		d.baselines[ev.JobType] = b
+	}
+	// The run joins the baseline whether or not it is flagged, so a lasting
+	// change is reported for a while and then becomes the new normal
+	defer b.add(duration)
+	if len(b.samples) < MinSamples {
+		return model.DurationAnomaly{}, false
+	}
+	mean, stdDev := b.stats()
+	deviations := (duration - mean) / max(stdDev, mean*minRelativeStdDev)
+	if math.IsNaN(deviations) || math.Abs(deviations) <= d.stdDevs {
+		return model.DurationAnomaly{}, false
+	}
+
+	anomaly := model.DurationAnomaly{
+		JobUID:          ev.JobUID,
+		JobType:         ev.JobType,
+		DurationSeconds: duration,
+		MeanSeconds:     mean,
+		StdDevSeconds:   stdDev,
+		Deviations:      deviations,
+		DetectedAt:      ev.At,
+		TraceID:         ev.TraceID,
+	}
+	d.history = append(d.history, anomaly)
+	if len(d.history) > MaxHistory {
+		d.history = slices.Delete(d.history, 0, len(d.history)-MaxHistory)
+	}
+	return anomaly, true
+}
+
+// List returns the anomalies found in runs of jobType, or of every type when
+// it is empty, newest first
+func (d *Detector) List(jobType string) []model.DurationAnomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	anomalies := make([]model.DurationAnomaly, 0)
+	for _, a := range slices.Backward(d.history) {
+		if jobType == "" || a.JobType == jobType {
+			anomalies = append(anomalies, a)
+		}
+	}
+	return anomalies
+}
+
+// baseline holds the most recent durations of a job type, in seconds
+type baseline struct {
+	samples []float64
+	next    int // where the next sample goes once the window is full
+}
+
+func (b *baseline) add(v float64) {
+	if len(b.samples) < Window {
+		b.samples = append(b.samples, v)
+		return
+	}
+	b.samples[b.next] = v
+	b.next = (b.next + 1) % Window
+}
+
+// stats returns the mean and population standard deviation of the samples
+func (b *baseline) stats() (mean, stdDev float64) {
+	for _, v := range b.samples {
+		mean += v
+	}
+	mean /= float64(len(b.samples))
+	var variance float64
+	for _, v := range b.samples {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(b.samples)))
+}
@@ -0,0 +1,98 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// run feeds the detector a job of jobType that ran for d and ended in to
+func run(det *Detector, jobType string, d time.Duration, to model.JobStatus) uuid.UUID {
+	uid := uuid.New()
+	det.Observe(model.JobEvent{JobUID: uid, JobType: jobType, From: model.JobStatusPending, To: model.JobStatusRunning, At: start})
+	det.Observe(model.JobEvent{JobUID: uid, JobType: jobType, From: model.JobStatusRunning, To: to, At: start.Add(d), TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"})
+	return uid
+}
+
+func TestDetector(t *testing.T) {
+	det := NewDetector(3)
+	var notified []model.DurationAnomaly
+	det.Notify(func(a model.DurationAnomaly) { notified = append(notified, a) })
+
+	// The baseline alternates between 0.9s and 1.1s: a mean of 1s and a
+	// standard deviation of 0.1s
+	for i := range MinSamples {
+		d := 900 * time.Millisecond
+		if i%2 == 1 {
+			d = 1100 * time.Millisecond
+		}
+		run(det, "math", d, model.JobStatusCompleted)
+	}
+	// Nothing is flagged before the baseline has enough samples, nor runs
+	// within three standard deviations of the mean
+	assert.Empty(t, det.List(""))
+	run(det, "math", 1250*time.Millisecond, model.JobStatusCompleted)
+	assert.Empty(t, det.List(""))
+
+	slow := run(det, "math", 2*time.Second, model.JobStatusCompleted)
+	anomalies := det.List("")
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, slow, anomalies[0].JobUID)
+	assert.Equal(t, "math", anomalies[0].JobType)
+	assert.Equal(t, 2.0, anomalies[0].DurationSeconds)
+	assert.InDelta(t, 1.012, anomalies[0].MeanSeconds, 0.001)
+	assert.Greater(t, anomalies[0].Deviations, 3.0)
+	assert.Equal(t, start.Add(2*time.Second), anomalies[0].DetectedAt)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", anomalies[0].TraceID)
+	assert.Equal(t, anomalies, notified)
+
+	// Quick runs are flagged too, with negative deviations
+	run(det, "math", 10*time.Millisecond, model.JobStatusCompleted)
+	anomalies = det.List("math")
+	require.Len(t, anomalies, 2)
+	assert.Less(t, anomalies[0].Deviations, -3.0)
+
+	// Failures neither count towards the baseline nor get flagged, and each
+	// type has its own baseline
+	run(det, "math", time.Hour, model.JobStatusFailed)
+	run(det, "sleep", time.Hour, model.JobStatusCompleted)
+	assert.Len(t, det.List(""), 2)
+	assert.Empty(t, det.List("sleep"))
+}
+
+func TestDetector_SteadyDurations(t *testing.T) {
+	det := NewDetector(3)
+	for range MinSamples {
+		run(det, "sleep", time.Second, model.JobStatusCompleted)
+	}
+	// Jitter in a type that always takes the same time isn't an anomaly
+	run(det, "sleep", 1010*time.Millisecond, model.JobStatusCompleted)
+	assert.Empty(t, det.List(""))
+	run(det, "sleep", 2*time.Second, model.JobStatusCompleted)
+	assert.Len(t, det.List(""), 1)
+}
+
+func TestDetector_Disabled(t *testing.T) {
+	det := NewDetector(0)
+	for range MinSamples {
+		run(det, "sleep", time.Second, model.JobStatusCompleted)
+	}
+	run(det, "sleep", time.Hour, model.JobStatusCompleted)
+	assert.Empty(t, det.List(""))
+}
+
+func TestBaseline_Window(t *testing.T) {
+	var b baseline
+	for i := range Window + 10 {
+		b.add(float64(i))
+	}
+	assert.Len(t, b.samples, Window)
+	mean, _ := b.stats()
+	assert.Equal(t, float64(10+Window+9)/2, mean)
+}
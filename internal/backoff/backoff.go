@@ -0,0 +1,120 @@
+// Package backoff provides the delay strategies used between repeated
+// attempts, such as retries of failed jobs.
+package backoff
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Strategy decides how long to wait before an attempt
+type Strategy interface {
+	// Delay returns the wait before the given retry, counting from 1
+	Delay(retry int) time.Duration
+}
+
+// Constant waits the same time before every retry
+type Constant time.Duration
+
+func (c Constant) Delay(int) time.Duration {
+	return time.Duration(c)
+}
+
+// Exponential doubles the wait before each retry, starting at Base and
+// capped at Max when Max is set
+type Exponential struct {
+	Base time.Duration
+	Max  time.Duration
+	// Jitter picks a random wait between zero and the computed delay, so
+	// many jobs failing at once don't retry in lockstep
+	Jitter bool
+}
+
+func (e Exponential) Delay(retry int) time.Duration {
+	d := float64(e.Base) * math.Pow(2, float64(max(retry, 1)-1))
+	if e.Max > 0 && d > float64(e.Max) {
+		d = float64(e.Max)
+	}
+	delay := time.Duration(math.MaxInt64)
+	if d < math.MaxInt64 {
+		delay = time.Duration(d)
+	}
+	if e.Jitter && delay > 0 {
+		delay = rand.N(delay)
+	}
+	return delay
+}
+
+// Schedule waits the listed times in turn, repeating the last one once the
+// list runs out
+type Schedule []time.Duration
+
+func (s Schedule) Delay(retry int) time.Duration {
+	if len(s) == 0 {
+		return 0
+	}
+	return s[min(max(retry, 1), len(s))-1]
+}
+
+// Parse reads a strategy written as name:arguments:
+//
+//	constant:5s
+//	exponential:1s:5m             (base and optional cap)
+//	exponential-jitter:1s:5m      (as exponential, with full jitter)
+//	schedule:1s/10s/1m
+func Parse(s string) (Strategy, error) {
+	name, args, _ := strings.Cut(strings.TrimSpace(s), ":")
+	switch name {
+	case "constant":
+		d, err := parseDuration(args)
+		if err != nil {
+			return nil, err
+		}
+		return Constant(d), nil
+	case "exponential", "exponential-jitter":
+		baseArg, maxArg, hasMax := strings.Cut(args, ":")
+		base, err := parseDuration(baseArg)
+		if err != nil {
+			return nil, err
+		}
+		e := Exponential{Base: base, Jitter: name == "exponential-jitter"}
+		if hasMax {
+			if e.Max, err = parseDuration(maxArg); err != nil {
+				return nil, err
+			}
+			if e.Max < e.Base {
+				return nil, fmt.Errorf("backoff cap %s is below the base delay %s", e.Max, e.Base)
+			}
+		}
+		return e, nil
+	case "schedule":
+		var schedule Schedule
+		for _, arg := range strings.Split(args, "/") {
+			d, err := parseDuration(arg)
+			if err != nil {
+				return nil, err
+			}
+			schedule = append(schedule, d)
+		}
+		return schedule, nil
+	case "":
+		return nil, errors.New("backoff strategy is empty")
+	default:
+		return nil, fmt.Errorf("unknown backoff strategy %q", name)
+	}
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid backoff delay %q", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("backoff delay %s is negative", d)
+	}
+	return d, nil
+}
@@ -0,0 +1,67 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   Strategy
+		errMsg string
+	}{
+		{name: "constant", input: "constant:5s", want: Constant(5 * time.Second)},
+		{name: "exponential", input: "exponential:1s", want: Exponential{Base: time.Second}},
+		{name: "exponential with cap", input: "exponential:1s:5m", want: Exponential{Base: time.Second, Max: 5 * time.Minute}},
+		{name: "jitter", input: "exponential-jitter:100ms:10s", want: Exponential{Base: 100 * time.Millisecond, Max: 10 * time.Second, Jitter: true}},
+		{name: "schedule", input: "schedule:1s/10s/1m", want: Schedule{time.Second, 10 * time.Second, time.Minute}},
+		{name: "empty", input: "", errMsg: "backoff strategy is empty"},
+		{name: "unknown", input: "linear:1s", errMsg: `unknown backoff strategy "linear"`},
+		{name: "missing delay", input: "constant", errMsg: `invalid backoff delay ""`},
+		{name: "negative delay", input: "schedule:1s/-1s", errMsg: "backoff delay -1s is negative"},
+		{name: "cap below base", input: "exponential:1m:1s", errMsg: "backoff cap 1s is below the base delay 1m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStrategies_Delay(t *testing.T) {
+	assert.Equal(t, 5*time.Second, Constant(5*time.Second).Delay(3))
+
+	e := Exponential{Base: time.Second, Max: 10 * time.Second}
+	var delays []time.Duration
+	for retry := 1; retry <= 6; retry++ {
+		delays = append(delays, e.Delay(retry))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}, delays)
+
+	// Without a cap the delay saturates rather than overflowing
+	assert.Positive(t, Exponential{Base: time.Second}.Delay(200))
+
+	jittered := Exponential{Base: time.Second, Max: 10 * time.Second, Jitter: true}
+	for retry := 1; retry <= 100; retry++ {
+		d := jittered.Delay(retry)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.LessOrEqual(t, d, e.Delay(retry))
+	}
+
+	s := Schedule{time.Second, time.Minute}
+	assert.Equal(t, time.Second, s.Delay(1))
+	assert.Equal(t, time.Minute, s.Delay(2))
+	assert.Equal(t, time.Minute, s.Delay(7))
+	assert.Equal(t, time.Duration(0), Schedule{}.Delay(1))
+}
@@ -0,0 +1,70 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir)
+	assert.NoError(t, err)
+
+	n, err := s.Put(ctx, "input", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	r, err := s.Open(ctx, "input")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello", string(data))
+
+	// Replacing a blob leaves no temporary files behind
+	_, err = s.Put(ctx, "input", strings.NewReader("bye"))
+	assert.NoError(t, err)
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1)
+
+	assert.NoError(t, s.Delete(ctx, "input"))
+	assert.NoError(t, s.Delete(ctx, "input"))
+	_, err = s.Open(ctx, "input")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, name := range []string{"", ".", "..", "../escape", "a/b"} {
+		_, err := s.Put(ctx, name, strings.NewReader("x"))
+		assert.Error(t, err, name)
+	}
+}
+
+// failingReader fails after returning some data
+type failingReader struct{ sent bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errors.New("connection reset")
+	}
+	r.sent = true
+	return copy(p, "partial"), nil
+}
+
+func TestDiskStore_FailedPut(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewDiskStore(dir)
+	assert.NoError(t, err)
+
+	_, err = s.Put(ctx, "input", &failingReader{})
+	assert.EqualError(t, err, "connection reset")
+	_, err = s.Open(ctx, "input")
+	assert.ErrorIs(t, err, ErrNotFound)
+	entries, _ := os.ReadDir(dir)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,307 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config locates a bucket and the credentials used to reach it
+type S3Config struct {
+	Bucket string
+	Region string
+	// Endpoint overrides the AWS endpoint for S3-compatible services such
+	// as MinIO. Buckets are always addressed path-style.
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Credentials, when set, is asked for the credentials to sign each
+	// request with in place of the static keys, for credentials that
+	// rotate. It should cache them between calls.
+	Credentials func(ctx context.Context) (S3Credentials, error) `json:"-"`
+	// Prefix is prepended to every object key
+	Prefix string
+}
+
+// S3Credentials sign requests to S3
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// S3Store keeps blobs as objects in an S3 bucket, signing requests with
+// AWS Signature Version 4
+type S3Store struct {
+	cfg    S3Config
+	base   *url.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, fmt.Errorf("s3 bucket and region are required")
+	}
+	if cfg.Credentials == nil && (cfg.AccessKeyID == "" || cfg.SecretAccessKey == "") {
+		return nil, fmt.Errorf("s3 credentials are required")
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint: %w", err)
+	}
+	return &S3Store{cfg: cfg, base: base, client: http.DefaultClient, now: time.Now}, nil
+}
+
+// Put spools the blob to a temporary file first, since S3 needs its length
+// up front
+func (s *S3Store) Put(ctx context.Context, name string, r io.Reader) (int64, error) {
+	f, err := os.CreateTemp("", "wps-blob-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return n, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return n, err
+	}
+
+	req, err := s.request(ctx, http.MethodPut, name, f)
+	if err != nil {
+		return n, err
+	}
+	req.ContentLength = n
+	resp, err := s.do(req, http.StatusOK)
+	if err != nil {
+		return n, err
+	}
+	resp.Body.Close()
+	return n, nil
+}
+
+func (s *S3Store) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := s.request(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, name string) error {
+	req, err := s.request(ctx, http.MethodDelete, name, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Store) request(ctx context.Context, method, name string, body io.Reader) (*http.Request, error) {
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid blob name %q", name)
+	}
+	u := *s.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Bucket + "/" + s.cfg.Prefix + name
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := s.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, s.now().UTC(), creds)
+	return req, nil
+}
+
+func (s *S3Store) credentials(ctx context.Context) (S3Credentials, error) {
+	if s.cfg.Credentials == nil {
+		return S3Credentials{
+			AccessKeyID:     s.cfg.AccessKeyID,
+			SecretAccessKey: s.cfg.SecretAccessKey,
+			SessionToken:    s.cfg.SessionToken,
+		}, nil
+	}
+	creds, err := s.cfg.Credentials(ctx)
+	if err != nil {
+		return S3Credentials{}, fmt.Errorf("s3 credentials: %w", err)
+	}
+	return creds, nil
+}
+
+func (s *S3Store) do(req *http.Request, want int) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == want || (want == http.StatusNoContent && resp.StatusCode == http.StatusOK) {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, req.URL.Path)
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// PresignGet returns a URL that downloads the blob without credentials
+// until it expires, served as an attachment called filename
+func (s *S3Store) PresignGet(ctx context.Context, name, filename string, expires time.Duration) (string, error) {
+	req, err := s.request(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return "", err
+	}
+	creds, err := s.credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	u := req.URL
+	if filename != "" {
+		q := u.Query()
+		q.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		u.RawQuery = q.Encode()
+	}
+	return s.presign(u, s.now().UTC(), expires, creds), nil
+}
+
+// presign adds AWS Signature Version 4 query parameters to a GET URL
+func (s *S3Store) presign(u *url.URL, now time.Time, expires time.Duration, creds S3Credentials) string {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+
+	q := u.Query()
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", creds.AccessKeyID+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+	if creds.SessionToken != "" {
+		q.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	query := awsQuery(q)
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		query,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+	key := signingKey(creds.SecretAccessKey, date, s.cfg.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	signed := *u
+	signed.RawQuery = query + "&X-Amz-Signature=" + signature
+	return signed.String()
+}
+
+// awsQuery encodes query parameters sorted by key with spaces as %20, as
+// signing requires
+func awsQuery(q url.Values) string {
+	return strings.ReplaceAll(q.Encode(), "+", "%20")
+}
+
+// unsignedPayload lets request bodies stream without hashing them first
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// sign adds AWS Signature Version 4 headers to the request
+func (s *S3Store) sign(req *http.Request, now time.Time, creds S3Credentials) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if creds.SessionToken != "" {
+		headers = append(headers, "x-amz-security-token")
+	}
+	var canonicalHeaders strings.Builder
+	for _, h := range headers {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		awsQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+	key := signingKey(creds.SecretAccessKey, date, s.cfg.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,1264 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/backoff"
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/egress"
+	"github.com/dnakolan/worker-pool-service/internal/events"
+	"github.com/dnakolan/worker-pool-service/internal/features"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/peers"
+	"github.com/dnakolan/worker-pool-service/internal/policy"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/release"
+	"github.com/dnakolan/worker-pool-service/internal/replica"
+	"github.com/dnakolan/worker-pool-service/internal/secrets"
+)
+
+type Config struct {
+	Addr      string
+	Workers   int
+	QueueSize int
+
+	// ReusePort opens the listener with SO_REUSEPORT so a new process can
+	// bind the address before this one exits
+	ReusePort bool
+	// DrainTimeout bounds how long shutdown waits for queued and running
+	// jobs to finish
+	DrainTimeout time.Duration
+
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout bound
+	// each connection as on http.Server, and request headers may not exceed
+	// MaxHeaderKB. WriteTimeout also limits how long API requests run.
+	// Following job logs may take up to StreamTimeout, and uploading or
+	// downloading files up to TransferTimeout; zero means no limit.
+	// Compressed submissions may inflate to at most MaxDecompressedMB.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderKB       int
+	MaxDecompressedMB int
+	StreamTimeout     time.Duration
+	TransferTimeout   time.Duration
+
+	// TLSCertFile and TLSKeyFile, when set, serve HTTPS, over which clients
+	// negotiate HTTP/2. H2C accepts HTTP/2 without TLS from clients that
+	// start with it, for plaintext connections inside a cluster or behind a
+	// proxy.
+	TLSCertFile string
+	TLSKeyFile  string
+	H2C         bool
+
+	// AdminAddr, when set, moves the /admin endpoints off the public
+	// address onto a listener of its own, which also serves profiles under
+	// /debug/pprof. AdminToken, when set, must be sent as a bearer token to
+	// reach them.
+	AdminAddr  string
+	AdminToken string
+
+	// WorkerCapacity is how many slots each worker has, shared by the
+	// jobs it runs according to their weight
+	WorkerCapacity int
+
+	// LargeJobKB is the payload size above which jobs are queued and run
+	// apart from the rest, at most LargeJobSlots at once and
+	// LargeJobQueueSize waiting. Zero turns the large lane off.
+	LargeJobKB        int
+	LargeJobSlots     int
+	LargeJobQueueSize int
+	// DedupPayloadKB is the payload size from which jobs with identical
+	// payloads share one copy in memory. Zero turns sharing off.
+	DedupPayloadKB int
+
+	// OverflowDir is where jobs submitted while the queue is full spill
+	// to, up to OverflowMaxJobs of them, instead of being rejected
+	OverflowDir     string
+	OverflowMaxJobs int
+
+	// MaxFinishedJobs caps how many finished jobs are kept, evicting the
+	// least recently read beyond it. Zero keeps every job.
+	MaxFinishedJobs int
+	// ArchiveEvicted writes evicted jobs to blob storage, where looking
+	// them up still finds them
+	ArchiveEvicted bool
+
+	// CapableWorkers are started in addition to the generic workers
+	CapableWorkers []WorkerGroup
+
+	// InstanceLabels describe where this instance runs, such as its region,
+	// zone or hardware, for jobs placed on them
+	InstanceLabels map[string]string
+
+	// NodeName names this instance on the jobs it accepts, the host name
+	// by default
+	NodeName string
+	// Peers are the base URLs of other instances that submissions this one
+	// can't run are forwarded to, and that it gossips with to learn the
+	// rest of the cluster
+	Peers []string
+	// AdvertiseURL is the base URL other instances reach this one at
+	AdvertiseURL string
+	// ClusterToken is the token instances gossip and forward submissions
+	// with, which every member of the cluster must share
+	ClusterToken string
+	// ClusterHosts are the hosts, besides those of the peers, that members
+	// learned of by gossip may be reached at
+	ClusterHosts []string
+	// GossipInterval is how often this instance gossips with a peer
+	GossipInterval time.Duration
+	// Follow, when set, is the base URL of the primary instance this one
+	// replicates. A replica serves reads from a copy of the primary's jobs
+	// and never runs or accepts jobs itself.
+	Follow string
+	// FollowInterval is how often a replica copies the primary's jobs
+	FollowInterval time.Duration
+
+	// LeaseTimeout is how long a remote worker may go without a heartbeat
+	LeaseTimeout time.Duration
+	// MaxAttempts bounds how often a job is handed out before it fails
+	MaxAttempts int
+	// MaxRetries is how often a job whose execution fails is run again
+	MaxRetries int
+	// RetryBackoff is the delay strategy between retries, with per-type
+	// overrides in RetryBackoffTypes
+	RetryBackoff      backoff.Strategy
+	RetryBackoffTypes map[string]backoff.Strategy
+
+	// KubernetesPodTemplate, when set, is the path of a pod template used to
+	// run KubernetesJobTypes as Kubernetes Jobs
+	KubernetesPodTemplate string
+	KubernetesJobTypes    []string
+	// KubernetesShadowJobTypes keep running as usual while a copy of each
+	// job also runs on Kubernetes, to compare the outcomes before moving
+	// the type there
+	KubernetesShadowJobTypes []string
+	// KubernetesSplit is the percentage of each job type's jobs run on
+	// Kubernetes, the rest running as usual, to move a type there gradually
+	KubernetesSplit map[string]float64
+
+	// DockerHost, when set, is the Docker daemon container jobs run on.
+	// Each container is limited to DockerCPUs CPUs and DockerMemoryMB of
+	// memory; zero means unlimited.
+	DockerHost     string
+	DockerCPUs     float64
+	DockerMemoryMB int
+
+	// Egress constrains the requests http jobs make: the proxy they go
+	// through, the schemes, hosts and addresses they may reach, the
+	// certificates trusted and how much of a response is read
+	Egress egress.Config
+
+	// FaultInjection exposes the /admin/faults endpoints used to inject
+	// worker crashes, queue latency and storage errors. Never enable it in
+	// production.
+	FaultInjection bool
+
+	// EnabledJobTypes limits the job types this deployment accepts; empty
+	// enables every built-in type
+	EnabledJobTypes model.EnabledJobTypes
+
+	// DispatchRates limits how many jobs of each type start per second
+	DispatchRates map[string]float64
+
+	// CostRates is what a second of run time costs for each job type,
+	// charged to attempts whose executor reports no cost of its own
+	CostRates map[string]float64
+
+	// JobTimeouts bounds how long jobs of each type may run; types without
+	// an entry run until they finish
+	JobTimeouts map[string]time.Duration
+
+	// JobTypeDefaults are the timeout, retries, priority, concurrency and
+	// retention each job type's jobs get unless their submission says
+	// otherwise, read from the JSON file WPS_JOB_TYPE_DEFAULTS names
+	JobTypeDefaults map[string]model.JobTypeDefaults
+
+	// MaintenanceWindows are the periods during which no job is started,
+	// read from the JSON file WPS_MAINTENANCE_WINDOWS names
+	MaintenanceWindows []model.MaintenanceWindow
+
+	// ResultCacheTTLs opts job types into result caching: an identical job
+	// submitted within the TTL of one completing reuses its result
+	ResultCacheTTLs map[string]time.Duration
+
+	// MemoMaxEntries bounds the values executors may memoize per job type;
+	// zero turns memoization off
+	MemoMaxEntries int
+
+	// PayloadLimits bound sleep and math payloads, which are rejected at
+	// submission when they exceed them
+	PayloadLimits model.PayloadLimits
+
+	// BlobDir or BlobS3 choose where job input files and artifacts are
+	// kept; with neither, uploads are refused. Inputs larger than MaxInputMB
+	// and artifacts larger than MaxArtifactMB are rejected, and artifacts
+	// are deleted ArtifactTTL after they are created unless it is zero.
+	// Artifacts in S3 are downloaded from pre-signed URLs valid for
+	// ArtifactURLExpiry, or through the service when it is zero.
+	BlobDir           string
+	BlobS3            *blob.S3Config
+	MaxInputMB        int
+	MaxArtifactMB     int
+	ArtifactTTL       time.Duration
+	ArtifactURLExpiry time.Duration
+
+	// SecretsBackend is where secrets referenced by job payloads are looked
+	// up: "env", "file" (from SecretsDir) or "vault". Empty means jobs
+	// referencing secrets fail.
+	SecretsBackend string
+	SecretsDir     string
+
+	// Vault is set when anything is read from Vault: secrets, or S3
+	// credentials issued for BlobS3VaultRole by the AWS secrets engine
+	// mounted at VaultAWSMount, which replace the static AWS keys.
+	Vault           *secrets.VaultConfig
+	BlobS3VaultRole string
+	VaultAWSMount   string
+
+	// ConsistencyCheckInterval, when set, checks the pool's bookkeeping for
+	// orphans and index drift at this interval and repairs what it finds
+	ConsistencyCheckInterval time.Duration
+
+	// SoakCheckInterval, when set, runs leak self-checks at this interval
+	// and exposes the latest report at /admin/soak. A trend must hold for
+	// SoakWindow checks to be reported, and more than SoakMaxJobs retained
+	// jobs raises a warning unless it is zero.
+	SoakCheckInterval time.Duration
+	SoakWindow        int
+	SoakMaxJobs       int
+
+	// TenantBudget limits the execution time each tenant may use per day
+	TenantBudget model.TenantBudget
+	// TenantHeader is the request header a submission names its tenant in.
+	// It is taken on trust, so behind a proxy that authenticates callers it
+	// should be one the proxy sets and strips from what clients send.
+	TenantHeader string
+
+	// CORSOrigins lets browser pages from these origins call the API; empty
+	// disables CORS. Preflight requests may use CORSMethods and CORSHeaders,
+	// and CORSCredentials allows cookies and authorization headers.
+	CORSOrigins     []string
+	CORSMethods     []string
+	CORSHeaders     []string
+	CORSCredentials bool
+	CORSMaxAge      time.Duration
+
+	// LegacyAPISunset, when set, is announced as the date the unversioned
+	// aliases of the /v1 routes will be removed
+	LegacyAPISunset time.Time
+
+	// EventsURL, when set, is the message bus job status changes are
+	// published to, on EventsTopic. Up to EventsBuffer events wait while the
+	// bus is unavailable.
+	EventsURL    string
+	EventsTopic  string
+	EventsBuffer int
+
+	// Policy, when set, is the OPA decision every submission is checked
+	// against
+	Policy *policy.OPAConfig
+
+	// ResultReleases are the targets each job type's results are copied to
+	// once its jobs complete, read from the file WPS_RESULT_RELEASES names
+	ResultReleases map[string][]release.Target
+
+	// UsageExport writes every tenant's usage report for each month to
+	// blob storage once the month ends
+	UsageExport bool
+
+	// AnomalyStdDevs is how many standard deviations from its type's mean a
+	// job's run time must be to be reported as an anomaly. Zero turns
+	// detection off.
+	AnomalyStdDevs float64
+
+	// FeatureFlags overrides the default state of feature flags, which can
+	// still be toggled at runtime through /admin/features
+	FeatureFlags map[string]bool
+}
+
+// DefaultArtifactTTL is how long artifacts are kept unless WPS_ARTIFACT_TTL
+// says otherwise
+const DefaultArtifactTTL = 7 * 24 * time.Hour
+
+// DefaultArtifactURLExpiry is how long pre-signed artifact URLs stay valid
+// unless WPS_ARTIFACT_URL_EXPIRY says otherwise
+const DefaultArtifactURLExpiry = 15 * time.Minute
+
+// DefaultRetryBackoff spaces out retries unless WPS_RETRY_BACKOFF says
+// otherwise
+const DefaultRetryBackoff = "exponential-jitter:1s:1m"
+
+// Load reads the service configuration from the environment, falling back to
+// defaults for anything unset
+func Load() (*Config, error) {
+	return LoadFrom(os.Getenv)
+}
+
+// env looks up a setting by its environment variable name, returning "" when
+// it's unset
+type env func(key string) string
+
+// LoadFrom reads the service configuration from the settings getenv looks
+// up by environment variable name, so a configuration can be checked
+// without being in the environment
+func LoadFrom(getenv func(key string) string) (*Config, error) {
+	e := env(getenv)
+	cfg := &Config{
+		Addr:         ":8080",
+		Workers:      10,
+		QueueSize:    10,
+		DrainTimeout: 30 * time.Second,
+
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderKB:       64,
+		MaxDecompressedMB: 256,
+		StreamTimeout:     time.Hour,
+		TransferTimeout:   10 * time.Minute,
+
+		GossipInterval: peers.DefaultGossipInterval,
+		FollowInterval: replica.DefaultSyncInterval,
+
+		LeaseTimeout: pool.DefaultLeaseTimeout,
+		MaxAttempts:  pool.DefaultMaxAttempts,
+		SoakWindow:   5,
+
+		PayloadLimits: model.DefaultPayloadLimits(),
+		MaxInputMB:    int(model.MaxInputSize >> 20),
+		MaxArtifactMB: int(model.MaxArtifactSize >> 20),
+	}
+
+	if v := e("WPS_ADDR"); v != "" {
+		cfg.Addr = v
+	}
+
+	var err error
+	if cfg.Workers, err = e.intEnv("WPS_WORKERS", cfg.Workers); err != nil {
+		return nil, err
+	}
+	if cfg.QueueSize, err = e.intEnv("WPS_QUEUE_SIZE", cfg.QueueSize); err != nil {
+		return nil, err
+	}
+	if cfg.ReusePort, err = e.boolEnv("WPS_REUSEPORT", false); err != nil {
+		return nil, err
+	}
+	if cfg.DrainTimeout, err = e.durationEnv("WPS_DRAIN_TIMEOUT", cfg.DrainTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.ReadHeaderTimeout, err = e.durationEnv("WPS_READ_HEADER_TIMEOUT", cfg.ReadHeaderTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.ReadTimeout, err = e.durationEnv("WPS_READ_TIMEOUT", cfg.ReadTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.WriteTimeout, err = e.durationEnv("WPS_WRITE_TIMEOUT", cfg.WriteTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.IdleTimeout, err = e.durationEnv("WPS_IDLE_TIMEOUT", cfg.IdleTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.StreamTimeout, err = e.durationEnv("WPS_STREAM_TIMEOUT", cfg.StreamTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.TransferTimeout, err = e.durationEnv("WPS_TRANSFER_TIMEOUT", cfg.TransferTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.MaxHeaderKB, err = e.intEnv("WPS_MAX_HEADER_KB", cfg.MaxHeaderKB); err != nil {
+		return nil, err
+	}
+	if cfg.MaxHeaderKB == 0 {
+		return nil, fmt.Errorf("WPS_MAX_HEADER_KB must be at least 1")
+	}
+	if cfg.MaxDecompressedMB, err = e.intEnv("WPS_MAX_DECOMPRESSED_MB", cfg.MaxDecompressedMB); err != nil {
+		return nil, err
+	}
+	if cfg.MaxDecompressedMB == 0 {
+		return nil, fmt.Errorf("WPS_MAX_DECOMPRESSED_MB must be at least 1")
+	}
+	cfg.TLSCertFile = e("WPS_TLS_CERT")
+	cfg.TLSKeyFile = e("WPS_TLS_KEY")
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("WPS_TLS_CERT and WPS_TLS_KEY must be set together")
+	}
+	if cfg.H2C, err = e.boolEnv("WPS_H2C", false); err != nil {
+		return nil, err
+	}
+	cfg.AdminAddr = e("WPS_ADMIN_ADDR")
+	if cfg.AdminAddr != "" && cfg.AdminAddr == cfg.Addr {
+		return nil, fmt.Errorf("WPS_ADMIN_ADDR must differ from WPS_ADDR")
+	}
+	cfg.AdminToken = e("WPS_ADMIN_TOKEN")
+	if cfg.WorkerCapacity, err = e.intEnv("WPS_WORKER_CAPACITY", 1); err != nil {
+		return nil, err
+	}
+	if cfg.WorkerCapacity == 0 {
+		return nil, fmt.Errorf("WPS_WORKER_CAPACITY must be at least 1")
+	}
+	if cfg.LargeJobKB, err = e.intEnv("WPS_LARGE_JOB_KB", 0); err != nil {
+		return nil, err
+	}
+	if cfg.LargeJobSlots, err = e.intEnv("WPS_LARGE_JOB_SLOTS", 1); err != nil {
+		return nil, err
+	}
+	if cfg.LargeJobSlots == 0 {
+		return nil, fmt.Errorf("WPS_LARGE_JOB_SLOTS must be at least 1")
+	}
+	if cfg.LargeJobQueueSize, err = e.intEnv("WPS_LARGE_JOB_QUEUE_SIZE", cfg.QueueSize); err != nil {
+		return nil, err
+	}
+	if cfg.DedupPayloadKB, err = e.intEnv("WPS_DEDUP_PAYLOAD_KB", 0); err != nil {
+		return nil, err
+	}
+	cfg.OverflowDir = e("WPS_OVERFLOW_DIR")
+	if cfg.OverflowMaxJobs, err = e.intEnv("WPS_OVERFLOW_MAX_JOBS", 100000); err != nil {
+		return nil, err
+	}
+	if cfg.OverflowMaxJobs == 0 {
+		return nil, fmt.Errorf("WPS_OVERFLOW_MAX_JOBS must be at least 1")
+	}
+	if cfg.MaxFinishedJobs, err = e.intEnv("WPS_MAX_FINISHED_JOBS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.CapableWorkers, err = e.workerGroupsEnv("WPS_CAPABLE_WORKERS"); err != nil {
+		return nil, err
+	}
+	if cfg.InstanceLabels, err = e.labelsEnv("WPS_INSTANCE_LABELS"); err != nil {
+		return nil, err
+	}
+	if cfg.NodeName = e("WPS_NODE_NAME"); cfg.NodeName == "" {
+		cfg.NodeName, _ = os.Hostname()
+	}
+	if cfg.Peers = e.listEnv("WPS_PEERS", nil); len(cfg.Peers) > 0 {
+		if _, err := peers.NewForwarder(cfg.NodeName, cfg.Peers); err != nil {
+			return nil, fmt.Errorf("WPS_PEERS: %w", err)
+		}
+	}
+	if cfg.AdvertiseURL = e("WPS_ADVERTISE_URL"); cfg.AdvertiseURL != "" {
+		if _, err := peers.NewMembership(cfg.NodeName, cfg.AdvertiseURL, nil, 0, nil); err != nil {
+			return nil, fmt.Errorf("WPS_ADVERTISE_URL: %w", err)
+		}
+	}
+	cfg.ClusterToken = e("WPS_CLUSTER_TOKEN")
+	cfg.ClusterHosts = e.listEnv("WPS_CLUSTER_HOSTS", nil)
+	if cfg.GossipInterval, err = e.durationEnv("WPS_GOSSIP_INTERVAL", cfg.GossipInterval); err != nil {
+		return nil, err
+	}
+	if cfg.GossipInterval <= 0 {
+		return nil, fmt.Errorf("WPS_GOSSIP_INTERVAL must be positive")
+	}
+	if cfg.Follow = e("WPS_FOLLOW"); cfg.Follow != "" {
+		if _, err := replica.NewFollower(cfg.Follow, nil, 0); err != nil {
+			return nil, fmt.Errorf("WPS_FOLLOW: %w", err)
+		}
+	}
+	if cfg.FollowInterval, err = e.durationEnv("WPS_FOLLOW_INTERVAL", cfg.FollowInterval); err != nil {
+		return nil, err
+	}
+	if cfg.FollowInterval <= 0 {
+		return nil, fmt.Errorf("WPS_FOLLOW_INTERVAL must be positive")
+	}
+	// Peers trust each other's forwarded submissions and gossip by the
+	// token, so an instance can't join the cluster without it. A replica
+	// does neither.
+	if cfg.Follow == "" && cfg.ClusterToken == "" {
+		if len(cfg.Peers) > 0 {
+			return nil, fmt.Errorf("WPS_PEERS requires WPS_CLUSTER_TOKEN")
+		}
+		if cfg.AdvertiseURL != "" {
+			return nil, fmt.Errorf("WPS_ADVERTISE_URL requires WPS_CLUSTER_TOKEN")
+		}
+	}
+	if cfg.LeaseTimeout, err = e.durationEnv("WPS_LEASE_TIMEOUT", cfg.LeaseTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.LeaseTimeout <= 0 {
+		return nil, fmt.Errorf("WPS_LEASE_TIMEOUT must be positive")
+	}
+	if cfg.MaxAttempts, err = e.intEnv("WPS_MAX_ATTEMPTS", cfg.MaxAttempts); err != nil {
+		return nil, err
+	}
+	if cfg.MaxAttempts == 0 {
+		return nil, fmt.Errorf("WPS_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.MaxRetries, err = e.intEnv("WPS_MAX_RETRIES", 0); err != nil {
+		return nil, err
+	}
+	if cfg.RetryBackoff, err = e.backoffEnv("WPS_RETRY_BACKOFF", DefaultRetryBackoff); err != nil {
+		return nil, err
+	}
+	if cfg.RetryBackoffTypes, err = e.backoffMapEnv("WPS_RETRY_BACKOFF_TYPES"); err != nil {
+		return nil, err
+	}
+	for jobType := range cfg.RetryBackoffTypes {
+		if !model.IsBuiltinJobType(jobType) {
+			return nil, fmt.Errorf("WPS_RETRY_BACKOFF_TYPES: unknown job type %q", jobType)
+		}
+	}
+	cfg.KubernetesPodTemplate = e("WPS_KUBERNETES_POD_TEMPLATE")
+	cfg.KubernetesJobTypes = e.listEnv("WPS_KUBERNETES_JOB_TYPES", nil)
+	for _, jobType := range cfg.KubernetesJobTypes {
+		if !model.IsBuiltinJobType(jobType) {
+			return nil, fmt.Errorf("WPS_KUBERNETES_JOB_TYPES: unknown job type %q", jobType)
+		}
+	}
+	cfg.KubernetesShadowJobTypes = e.listEnv("WPS_KUBERNETES_SHADOW_JOB_TYPES", nil)
+	for _, jobType := range cfg.KubernetesShadowJobTypes {
+		if !model.IsBuiltinJobType(jobType) {
+			return nil, fmt.Errorf("WPS_KUBERNETES_SHADOW_JOB_TYPES: unknown job type %q", jobType)
+		}
+	}
+	if cfg.KubernetesSplit, err = e.floatMapEnv("WPS_KUBERNETES_SPLIT"); err != nil {
+		return nil, err
+	}
+	for jobType, percent := range cfg.KubernetesSplit {
+		if !model.IsBuiltinJobType(jobType) {
+			return nil, fmt.Errorf("WPS_KUBERNETES_SPLIT: unknown job type %q", jobType)
+		}
+		if percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("WPS_KUBERNETES_SPLIT: percentage for %s must be between 0 and 100", jobType)
+		}
+	}
+
+	cfg.DockerHost = e("WPS_DOCKER_HOST")
+	if cfg.DockerCPUs, err = e.floatEnv("WPS_DOCKER_CPUS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.DockerMemoryMB, err = e.intEnv("WPS_DOCKER_MEMORY_MB", 0); err != nil {
+		return nil, err
+	}
+
+	if v := e("WPS_EGRESS_PROXY"); v != "" {
+		if cfg.Egress.Proxy, err = url.Parse(v); err != nil || cfg.Egress.Proxy.Host == "" {
+			return nil, fmt.Errorf("WPS_EGRESS_PROXY must be a URL like http://proxy:3128")
+		}
+	}
+	cfg.Egress.AllowedHosts = e.listEnv("WPS_EGRESS_ALLOWED_HOSTS", nil)
+	cfg.Egress.Schemes = e.listEnv("WPS_EGRESS_SCHEMES", egress.DefaultSchemes)
+	for _, scheme := range cfg.Egress.Schemes {
+		if scheme != "http" && scheme != "https" {
+			return nil, fmt.Errorf("WPS_EGRESS_SCHEMES: unsupported scheme %q; use http or https", scheme)
+		}
+	}
+	if cfg.Egress.BlockPrivate, err = e.boolEnv("WPS_EGRESS_BLOCK_PRIVATE", true); err != nil {
+		return nil, err
+	}
+	for _, v := range e.listEnv("WPS_EGRESS_ALLOWED_NETWORKS", nil) {
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("WPS_EGRESS_ALLOWED_NETWORKS: %q is not a CIDR like 10.1.0.0/16", v)
+		}
+		cfg.Egress.AllowedNetworks = append(cfg.Egress.AllowedNetworks, prefix)
+	}
+	cfg.Egress.CAFile = e("WPS_EGRESS_CA_FILE")
+	cfg.Egress.ClientCertFile = e("WPS_EGRESS_CLIENT_CERT")
+	cfg.Egress.ClientKeyFile = e("WPS_EGRESS_CLIENT_KEY")
+	if (cfg.Egress.ClientCertFile == "") != (cfg.Egress.ClientKeyFile == "") {
+		return nil, fmt.Errorf("WPS_EGRESS_CLIENT_CERT and WPS_EGRESS_CLIENT_KEY must be set together")
+	}
+	maxResponseKB, err := e.intEnv("WPS_EGRESS_MAX_RESPONSE_KB", egress.DefaultMaxResponseBytes>>10)
+	if err != nil {
+		return nil, err
+	}
+	if maxResponseKB < 1 {
+		return nil, fmt.Errorf("WPS_EGRESS_MAX_RESPONSE_KB must be at least 1")
+	}
+	cfg.Egress.MaxResponseBytes = int64(maxResponseKB) << 10
+	if cfg.Egress.Timeout, err = e.durationEnv("WPS_EGRESS_TIMEOUT", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.FaultInjection, err = e.boolEnv("WPS_FAULT_INJECTION", false); err != nil {
+		return nil, err
+	}
+
+	cfg.EnabledJobTypes = e.listEnv("WPS_ENABLED_JOB_TYPES", nil)
+	for _, jobType := range cfg.EnabledJobTypes {
+		if !model.IsBuiltinJobType(jobType) {
+			return nil, fmt.Errorf("WPS_ENABLED_JOB_TYPES: unknown job type %q", jobType)
+		}
+	}
+	if cfg.DispatchRates, err = e.floatMapEnv("WPS_DISPATCH_RATES"); err != nil {
+		return nil, err
+	}
+	for jobType := range cfg.DispatchRates {
+		if !model.IsBuiltinJobType(jobType) {
+			return nil, fmt.Errorf("WPS_DISPATCH_RATES: unknown job type %q", jobType)
+		}
+	}
+	if cfg.CostRates, err = e.floatMapEnv("WPS_COST_RATES"); err != nil {
+		return nil, err
+	}
+	for jobType := range cfg.CostRates {
+		if !model.IsBuiltinJobType(jobType) {
+			return nil, fmt.Errorf("WPS_COST_RATES: unknown job type %q", jobType)
+		}
+	}
+	if cfg.JobTimeouts, err = e.durationMapEnv("WPS_JOB_TIMEOUTS"); err != nil {
+		return nil, err
+	}
+	for jobType := range cfg.JobTimeouts {
+		if !model.IsBuiltinJobType(jobType) {
+			return nil, fmt.Errorf("WPS_JOB_TIMEOUTS: unknown job type %q", jobType)
+		}
+	}
+	if path := e("WPS_JOB_TYPE_DEFAULTS"); path != "" {
+		if cfg.JobTypeDefaults, err = loadJobTypeDefaults(path); err != nil {
+			return nil, fmt.Errorf("WPS_JOB_TYPE_DEFAULTS: %w", err)
+		}
+		for jobType, d := range cfg.JobTypeDefaults {
+			if !model.IsBuiltinJobType(jobType) {
+				return nil, fmt.Errorf("WPS_JOB_TYPE_DEFAULTS: unknown job type %q", jobType)
+			}
+			if _, ok := cfg.JobTimeouts[jobType]; ok && d.Timeout != "" {
+				return nil, fmt.Errorf("WPS_JOB_TYPE_DEFAULTS: the timeout of %s is already set by WPS_JOB_TIMEOUTS", jobType)
+			}
+		}
+	}
+	if path := e("WPS_MAINTENANCE_WINDOWS"); path != "" {
+		if cfg.MaintenanceWindows, err = loadMaintenanceWindows(path); err != nil {
+			return nil, fmt.Errorf("WPS_MAINTENANCE_WINDOWS: %w", err)
+		}
+	}
+	if cfg.ResultCacheTTLs, err = e.durationMapEnv("WPS_RESULT_CACHE_TTLS"); err != nil {
+		return nil, err
+	}
+	for jobType := range cfg.ResultCacheTTLs {
+		if !model.IsBuiltinJobType(jobType) {
+			return nil, fmt.Errorf("WPS_RESULT_CACHE_TTLS: unknown job type %q", jobType)
+		}
+	}
+	if cfg.MemoMaxEntries, err = e.intEnv("WPS_MEMO_MAX_ENTRIES", 10000); err != nil {
+		return nil, err
+	}
+	limits := &cfg.PayloadLimits
+	if limits.MaxSleepDuration, err = e.durationEnv("WPS_MAX_SLEEP_DURATION", limits.MaxSleepDuration); err != nil {
+		return nil, err
+	}
+	if limits.MaxSleepDuration <= 0 {
+		return nil, fmt.Errorf("WPS_MAX_SLEEP_DURATION must be positive")
+	}
+	if limits.MaxMathNumber, err = e.intEnv("WPS_MAX_MATH_NUMBER", limits.MaxMathNumber); err != nil {
+		return nil, err
+	}
+	if limits.MaxMathNumber == 0 {
+		return nil, fmt.Errorf("WPS_MAX_MATH_NUMBER must be at least 1")
+	}
+
+	cfg.BlobDir = e("WPS_BLOB_DIR")
+	if bucket := e("WPS_BLOB_S3_BUCKET"); bucket != "" {
+		if cfg.BlobDir != "" {
+			return nil, fmt.Errorf("WPS_BLOB_DIR and WPS_BLOB_S3_BUCKET cannot both be set")
+		}
+		cfg.BlobS3 = &blob.S3Config{
+			Bucket:          bucket,
+			Region:          e("WPS_BLOB_S3_REGION"),
+			Endpoint:        e("WPS_BLOB_S3_ENDPOINT"),
+			Prefix:          e("WPS_BLOB_S3_PREFIX"),
+			AccessKeyID:     e("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: e("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    e("AWS_SESSION_TOKEN"),
+		}
+		if cfg.BlobS3.Region == "" {
+			cfg.BlobS3.Region = e("AWS_REGION")
+		}
+		if cfg.BlobS3.Region == "" {
+			return nil, fmt.Errorf("WPS_BLOB_S3_REGION or AWS_REGION is required with WPS_BLOB_S3_BUCKET")
+		}
+	}
+	if cfg.MaxInputMB, err = e.intEnv("WPS_MAX_INPUT_MB", cfg.MaxInputMB); err != nil {
+		return nil, err
+	}
+	if cfg.MaxArtifactMB, err = e.intEnv("WPS_MAX_ARTIFACT_MB", cfg.MaxArtifactMB); err != nil {
+		return nil, err
+	}
+	if cfg.ArtifactTTL, err = e.durationEnv("WPS_ARTIFACT_TTL", DefaultArtifactTTL); err != nil {
+		return nil, err
+	}
+	if cfg.ArtifactURLExpiry, err = e.durationEnv("WPS_ARTIFACT_URL_EXPIRY", DefaultArtifactURLExpiry); err != nil {
+		return nil, err
+	}
+
+	cfg.SecretsBackend = e("WPS_SECRETS_BACKEND")
+	switch cfg.SecretsBackend {
+	case "", "env":
+	case "file":
+		if cfg.SecretsDir = e("WPS_SECRETS_DIR"); cfg.SecretsDir == "" {
+			return nil, fmt.Errorf("WPS_SECRETS_DIR is required with WPS_SECRETS_BACKEND=file")
+		}
+	case "vault":
+	default:
+		return nil, fmt.Errorf("WPS_SECRETS_BACKEND: unknown backend %q", cfg.SecretsBackend)
+	}
+	if cfg.BlobS3VaultRole = e("WPS_BLOB_S3_VAULT_ROLE"); cfg.BlobS3VaultRole != "" && cfg.BlobS3 == nil {
+		return nil, fmt.Errorf("WPS_BLOB_S3_VAULT_ROLE requires WPS_BLOB_S3_BUCKET")
+	}
+	if cfg.SecretsBackend == "vault" || cfg.BlobS3VaultRole != "" {
+		if cfg.Vault, err = e.vaultEnv(); err != nil {
+			return nil, err
+		}
+		cfg.VaultAWSMount = e("WPS_VAULT_AWS_MOUNT")
+		if cfg.VaultAWSMount == "" {
+			cfg.VaultAWSMount = "aws"
+		}
+	}
+
+	if cfg.ConsistencyCheckInterval, err = e.durationEnv("WPS_CONSISTENCY_CHECK_INTERVAL", 0); err != nil {
+		return nil, err
+	}
+	if cfg.SoakCheckInterval, err = e.durationEnv("WPS_SOAK_CHECK_INTERVAL", 0); err != nil {
+		return nil, err
+	}
+	if cfg.SoakWindow, err = e.intEnv("WPS_SOAK_WINDOW", cfg.SoakWindow); err != nil {
+		return nil, err
+	}
+	if cfg.SoakWindow == 0 {
+		return nil, fmt.Errorf("WPS_SOAK_WINDOW must be at least 1")
+	}
+	if cfg.SoakMaxJobs, err = e.intEnv("WPS_SOAK_MAX_JOBS", 0); err != nil {
+		return nil, err
+	}
+
+	if cfg.TenantBudget.Default, err = e.durationEnv("WPS_TENANT_DAILY_BUDGET", 0); err != nil {
+		return nil, err
+	}
+	if cfg.TenantBudget.Overrides, err = e.durationMapEnv("WPS_TENANT_BUDGETS"); err != nil {
+		return nil, err
+	}
+	if cfg.TenantHeader = strings.TrimSpace(e("WPS_TENANT_HEADER")); cfg.TenantHeader == "" {
+		cfg.TenantHeader = model.DefaultTenantHeader
+	} else if strings.ContainsAny(cfg.TenantHeader, " \t:,") {
+		return nil, fmt.Errorf("WPS_TENANT_HEADER must be a header name, got %q", cfg.TenantHeader)
+	}
+
+	cfg.CORSOrigins = e.listEnv("WPS_CORS_ORIGINS", nil)
+	cfg.CORSMethods = e.listEnv("WPS_CORS_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE"})
+	cfg.CORSHeaders = e.listEnv("WPS_CORS_HEADERS", []string{"Content-Type", "If-Match", cfg.TenantHeader})
+	if cfg.CORSCredentials, err = e.boolEnv("WPS_CORS_CREDENTIALS", false); err != nil {
+		return nil, err
+	}
+	if cfg.CORSMaxAge, err = e.durationEnv("WPS_CORS_MAX_AGE", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	for _, origin := range cfg.CORSOrigins {
+		if origin == "*" && cfg.CORSCredentials {
+			return nil, fmt.Errorf("WPS_CORS_CREDENTIALS cannot be combined with WPS_CORS_ORIGINS=*")
+		}
+	}
+
+	if v := e("WPS_LEGACY_API_SUNSET"); v != "" {
+		if cfg.LegacyAPISunset, err = time.Parse(time.DateOnly, v); err != nil {
+			return nil, fmt.Errorf("WPS_LEGACY_API_SUNSET must be a date like 2006-01-02")
+		}
+	}
+
+	if cfg.EventsURL = e("WPS_EVENTS_URL"); cfg.EventsURL != "" {
+		if _, err := events.NewPublisher(cfg.EventsURL); err != nil {
+			return nil, fmt.Errorf("WPS_EVENTS_URL: %w", err)
+		}
+	}
+	cfg.EventsTopic = events.DefaultTopic
+	if v := e("WPS_EVENTS_TOPIC"); v != "" {
+		cfg.EventsTopic = v
+	}
+	if cfg.EventsBuffer, err = e.intEnv("WPS_EVENTS_BUFFER", 10000); err != nil {
+		return nil, err
+	}
+
+	if v := e("WPS_OPA_URL"); v != "" {
+		cfg.Policy = &policy.OPAConfig{URL: v, Token: e("WPS_OPA_TOKEN")}
+		if cfg.Policy.FailOpen, err = e.boolEnv("WPS_OPA_FAIL_OPEN", false); err != nil {
+			return nil, err
+		}
+		if _, err := policy.NewOPA(*cfg.Policy); err != nil {
+			return nil, fmt.Errorf("WPS_OPA_URL: %w", err)
+		}
+	}
+
+	if path := e("WPS_RESULT_RELEASES"); path != "" {
+		if cfg.ResultReleases, err = release.LoadConfig(path); err != nil {
+			return nil, fmt.Errorf("WPS_RESULT_RELEASES: %w", err)
+		}
+		for jobType := range cfg.ResultReleases {
+			if !model.IsBuiltinJobType(jobType) {
+				return nil, fmt.Errorf("WPS_RESULT_RELEASES: unknown job type %q", jobType)
+			}
+		}
+	}
+	if cfg.AnomalyStdDevs, err = e.floatEnv("WPS_ANOMALY_STDDEVS", 3); err != nil {
+		return nil, err
+	}
+
+	if cfg.UsageExport, err = e.boolEnv("WPS_USAGE_EXPORT", false); err != nil {
+		return nil, err
+	}
+	if cfg.UsageExport && cfg.BlobDir == "" && cfg.BlobS3 == nil {
+		return nil, fmt.Errorf("WPS_USAGE_EXPORT requires WPS_BLOB_DIR or WPS_BLOB_S3_BUCKET")
+	}
+	if cfg.ArchiveEvicted, err = e.boolEnv("WPS_ARCHIVE_EVICTED", false); err != nil {
+		return nil, err
+	}
+	if cfg.ArchiveEvicted && cfg.BlobDir == "" && cfg.BlobS3 == nil {
+		return nil, fmt.Errorf("WPS_ARCHIVE_EVICTED requires WPS_BLOB_DIR or WPS_BLOB_S3_BUCKET")
+	}
+	if cfg.ArchiveEvicted && cfg.MaxFinishedJobs == 0 {
+		return nil, fmt.Errorf("WPS_ARCHIVE_EVICTED requires WPS_MAX_FINISHED_JOBS")
+	}
+
+	if cfg.FeatureFlags, err = e.boolMapEnv("WPS_FEATURES"); err != nil {
+		return nil, err
+	}
+	for name := range cfg.FeatureFlags {
+		if !features.IsKnown(name) {
+			return nil, fmt.Errorf("WPS_FEATURES: unknown feature flag %q", name)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Warnings describes settings that load but probably don't do what was
+// intended, such as limits for job types that aren't enabled
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.Workers == 0 && len(c.CapableWorkers) == 0 && c.Follow == "" {
+		warnings = append(warnings, "WPS_WORKERS is 0 and WPS_CAPABLE_WORKERS is unset, so only remote workers will run jobs")
+	}
+	if c.H2C && c.TLSCertFile != "" {
+		warnings = append(warnings, "WPS_H2C has no effect with WPS_TLS_CERT, as HTTP/2 is negotiated over TLS")
+	}
+	if c.Follow != "" && (len(c.Peers) > 0 || c.AdvertiseURL != "") {
+		warnings = append(warnings, "WPS_PEERS and WPS_ADVERTISE_URL have no effect with WPS_FOLLOW, as a replica neither forwards jobs nor joins the cluster")
+	}
+	if c.FaultInjection {
+		warnings = append(warnings, "WPS_FAULT_INJECTION is enabled, which must never be used in production")
+	}
+	if c.KubernetesPodTemplate != "" {
+		if len(c.KubernetesJobTypes) == 0 && len(c.KubernetesShadowJobTypes) == 0 && len(c.KubernetesSplit) == 0 {
+			warnings = append(warnings, "WPS_KUBERNETES_POD_TEMPLATE has no effect without WPS_KUBERNETES_JOB_TYPES, WPS_KUBERNETES_SHADOW_JOB_TYPES or WPS_KUBERNETES_SPLIT")
+		}
+		for _, jobType := range c.KubernetesShadowJobTypes {
+			if slices.Contains(c.KubernetesJobTypes, jobType) {
+				warnings = append(warnings, fmt.Sprintf("WPS_KUBERNETES_SHADOW_JOB_TYPES: job type %q already runs on Kubernetes, so it isn't shadowed", jobType))
+			}
+		}
+		for _, jobType := range slices.Sorted(maps.Keys(c.KubernetesSplit)) {
+			if slices.Contains(c.KubernetesJobTypes, jobType) {
+				warnings = append(warnings, fmt.Sprintf("WPS_KUBERNETES_SPLIT: job type %q already runs on Kubernetes, so it isn't split", jobType))
+			}
+		}
+	} else {
+		if len(c.KubernetesJobTypes) > 0 {
+			warnings = append(warnings, "WPS_KUBERNETES_JOB_TYPES has no effect without WPS_KUBERNETES_POD_TEMPLATE")
+		}
+		if len(c.KubernetesShadowJobTypes) > 0 {
+			warnings = append(warnings, "WPS_KUBERNETES_SHADOW_JOB_TYPES has no effect without WPS_KUBERNETES_POD_TEMPLATE")
+		}
+		if len(c.KubernetesSplit) > 0 {
+			warnings = append(warnings, "WPS_KUBERNETES_SPLIT has no effect without WPS_KUBERNETES_POD_TEMPLATE")
+		}
+	}
+	perType := []struct {
+		key   string
+		types []string
+	}{
+		{"WPS_RETRY_BACKOFF_TYPES", slices.Sorted(maps.Keys(c.RetryBackoffTypes))},
+		{"WPS_DISPATCH_RATES", slices.Sorted(maps.Keys(c.DispatchRates))},
+		{"WPS_COST_RATES", slices.Sorted(maps.Keys(c.CostRates))},
+		{"WPS_JOB_TIMEOUTS", slices.Sorted(maps.Keys(c.JobTimeouts))},
+		{"WPS_JOB_TYPE_DEFAULTS", slices.Sorted(maps.Keys(c.JobTypeDefaults))},
+		{"WPS_RESULT_CACHE_TTLS", slices.Sorted(maps.Keys(c.ResultCacheTTLs))},
+		{"WPS_RESULT_RELEASES", slices.Sorted(maps.Keys(c.ResultReleases))},
+	}
+	for _, setting := range perType {
+		for _, jobType := range setting.types {
+			if !c.EnabledJobTypes.Allows(jobType) {
+				warnings = append(warnings, fmt.Sprintf("%s: job type %q is not enabled", setting.key, jobType))
+			}
+		}
+	}
+	if c.SoakMaxJobs > 0 && c.SoakCheckInterval == 0 {
+		warnings = append(warnings, "WPS_SOAK_MAX_JOBS has no effect without WPS_SOAK_CHECK_INTERVAL")
+	}
+	return warnings
+}
+
+// redacted stands in for secrets in a redacted configuration
+const redacted = "REDACTED"
+
+// Redacted returns a copy of the configuration with its tokens, keys and
+// passwords replaced, so it can be shown to an operator
+func (c *Config) Redacted() *Config {
+	r := *c
+	r.AdminToken = redactString(c.AdminToken)
+	r.ClusterToken = redactString(c.ClusterToken)
+	r.EventsURL = redactURL(c.EventsURL)
+	r.Follow = redactURL(c.Follow)
+	if c.Peers != nil {
+		r.Peers = make([]string, len(c.Peers))
+		for i, peer := range c.Peers {
+			r.Peers[i] = redactURL(peer)
+		}
+	}
+	if c.Egress.Proxy != nil {
+		proxy := *c.Egress.Proxy
+		if _, ok := proxy.User.Password(); ok {
+			proxy.User = url.UserPassword(proxy.User.Username(), redacted)
+		}
+		r.Egress.Proxy = &proxy
+	}
+	if c.BlobS3 != nil {
+		s3 := *c.BlobS3
+		s3.SecretAccessKey = redactString(s3.SecretAccessKey)
+		s3.SessionToken = redactString(s3.SessionToken)
+		r.BlobS3 = &s3
+	}
+	if c.Vault != nil {
+		vault := *c.Vault
+		vault.Token = redactString(vault.Token)
+		vault.SecretID = redactString(vault.SecretID)
+		r.Vault = &vault
+	}
+	if c.Policy != nil {
+		opa := *c.Policy
+		opa.Token = redactString(opa.Token)
+		r.Policy = &opa
+	}
+	if c.ResultReleases != nil {
+		r.ResultReleases = make(map[string][]release.Target, len(c.ResultReleases))
+		for jobType, targets := range c.ResultReleases {
+			for _, target := range targets {
+				target.SecretAccessKey = redactString(target.SecretAccessKey)
+				target.SessionToken = redactString(target.SessionToken)
+				target.DSN = redactURL(target.DSN)
+				if target.Headers != nil {
+					headers := make(map[string]string, len(target.Headers))
+					for name := range target.Headers {
+						headers[name] = redacted
+					}
+					target.Headers = headers
+				}
+				r.ResultReleases[jobType] = append(r.ResultReleases[jobType], target)
+			}
+		}
+	}
+	return &r
+}
+
+func redactString(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactURL replaces the password in a URL, or the whole of it when it
+// can't be parsed
+func redactURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	return u.String()
+}
+
+// WorkerGroup is a set of workers sharing the same capability tags
+type WorkerGroup struct {
+	Count        int
+	Capabilities []string
+}
+
+// workerGroupsEnv parses a comma separated list of caps=count pairs, where
+// caps joins capability tags with '+', e.g. "gpu=2,gpu+large-mem=1"
+func (e env) workerGroupsEnv(key string) ([]WorkerGroup, error) {
+	v := e(key)
+	if v == "" {
+		return nil, nil
+	}
+	var groups []WorkerGroup
+	for _, pair := range strings.Split(v, ",") {
+		caps, count, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || caps == "" {
+			return nil, fmt.Errorf("%s: expected capabilities=count, got %q", key, pair)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%s: invalid worker count for %s", key, caps)
+		}
+		groups = append(groups, WorkerGroup{Count: n, Capabilities: strings.Split(caps, "+")})
+	}
+	return groups, nil
+}
+
+// vaultEnv reads how to reach and log in to Vault
+func (e env) vaultEnv() (*secrets.VaultConfig, error) {
+	cfg := &secrets.VaultConfig{
+		Address:   e("VAULT_ADDR"),
+		Auth:      e("WPS_VAULT_AUTH"),
+		AuthMount: e("WPS_VAULT_AUTH_MOUNT"),
+		Token:     e("VAULT_TOKEN"),
+		Role:      e("WPS_VAULT_ROLE"),
+		JWTPath:   e("WPS_VAULT_JWT_PATH"),
+		RoleID:    e("VAULT_ROLE_ID"),
+		SecretID:  e("VAULT_SECRET_ID"),
+		Mount:     e("WPS_VAULT_MOUNT"),
+		Path:      e("WPS_VAULT_PATH"),
+	}
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("VAULT_ADDR is required to use vault")
+	}
+	if cfg.Auth == "" {
+		cfg.Auth = secrets.VaultAuthToken
+	}
+	switch cfg.Auth {
+	case secrets.VaultAuthToken:
+		if cfg.Token == "" {
+			return nil, fmt.Errorf("VAULT_TOKEN is required with WPS_VAULT_AUTH=token")
+		}
+	case secrets.VaultAuthKubernetes:
+		if cfg.Role == "" {
+			return nil, fmt.Errorf("WPS_VAULT_ROLE is required with WPS_VAULT_AUTH=kubernetes")
+		}
+	case secrets.VaultAuthAppRole:
+		if cfg.RoleID == "" || cfg.SecretID == "" {
+			return nil, fmt.Errorf("VAULT_ROLE_ID and VAULT_SECRET_ID are required with WPS_VAULT_AUTH=approle")
+		}
+	default:
+		return nil, fmt.Errorf("WPS_VAULT_AUTH: unknown auth method %q", cfg.Auth)
+	}
+	return cfg, nil
+}
+
+// listEnv parses a comma separated list
+func (e env) listEnv(key string, def []string) []string {
+	v := e(key)
+	if v == "" {
+		return def
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (e env) intEnv(key string, def int) (int, error) {
+	v := e(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", key)
+	}
+	return n, nil
+}
+
+func (e env) boolEnv(key string, def bool) (bool, error) {
+	v := e(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", key)
+	}
+	return b, nil
+}
+
+func (e env) floatEnv(key string, def float64) (float64, error) {
+	v := e(key)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number", key)
+	}
+	return f, nil
+}
+
+func (e env) durationEnv(key string, def time.Duration) (time.Duration, error) {
+	v := e(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration", key)
+	}
+	return d, nil
+}
+
+func (e env) backoffEnv(key, def string) (backoff.Strategy, error) {
+	v := e(key)
+	if v == "" {
+		v = def
+	}
+	s, err := backoff.Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return s, nil
+}
+
+// backoffMapEnv parses a comma separated list of name=strategy pairs
+func (e env) backoffMapEnv(key string) (map[string]backoff.Strategy, error) {
+	v := e(key)
+	if v == "" {
+		return nil, nil
+	}
+	m := make(map[string]backoff.Strategy)
+	for _, pair := range strings.Split(v, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s: expected name=strategy, got %q", key, pair)
+		}
+		s, err := backoff.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", key, name, err)
+		}
+		m[name] = s
+	}
+	return m, nil
+}
+
+// boolMapEnv parses a comma separated list of name=bool pairs
+func (e env) boolMapEnv(key string) (map[string]bool, error) {
+	v := e(key)
+	if v == "" {
+		return nil, nil
+	}
+	m := make(map[string]bool)
+	for _, pair := range strings.Split(v, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s: expected name=bool, got %q", key, pair)
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid boolean for %s", key, name)
+		}
+		m[name] = b
+	}
+	return m, nil
+}
+
+// floatMapEnv parses a comma separated list of name=number pairs
+func (e env) floatMapEnv(key string) (map[string]float64, error) {
+	v := e(key)
+	if v == "" {
+		return nil, nil
+	}
+	m := make(map[string]float64)
+	for _, pair := range strings.Split(v, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s: expected name=number, got %q", key, pair)
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < 0 {
+			return nil, fmt.Errorf("%s: invalid number for %s", key, name)
+		}
+		m[name] = f
+	}
+	return m, nil
+}
+
+// loadJobTypeDefaults reads a JSON file mapping job types to their defaults
+func loadJobTypeDefaults(path string) (map[string]model.JobTypeDefaults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading job type defaults: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var defaults map[string]model.JobTypeDefaults
+	if err := dec.Decode(&defaults); err != nil {
+		return nil, fmt.Errorf("invalid job type defaults: %w", err)
+	}
+	for _, jobType := range slices.Sorted(maps.Keys(defaults)) {
+		d := defaults[jobType]
+		if err := d.Validate(); err != nil {
+			return nil, fmt.Errorf("job type defaults for %s: %w", jobType, err)
+		}
+	}
+	return defaults, nil
+}
+
+// loadMaintenanceWindows reads a JSON file listing maintenance windows
+func loadMaintenanceWindows(path string) ([]model.MaintenanceWindow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading maintenance windows: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var windows []model.MaintenanceWindow
+	if err := dec.Decode(&windows); err != nil {
+		return nil, fmt.Errorf("invalid maintenance windows: %w", err)
+	}
+	if err := model.ValidateMaintenanceWindows(windows); err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// labelsEnv parses a comma separated list of name=value pairs
+func (e env) labelsEnv(key string) (map[string]string, error) {
+	v := e(key)
+	if v == "" {
+		return nil, nil
+	}
+	m := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("%s: expected name=value, got %q", key, pair)
+		}
+		m[name] = value
+	}
+	return m, nil
+}
+
+// durationMapEnv parses a comma separated list of name=duration pairs
+func (e env) durationMapEnv(key string) (map[string]time.Duration, error) {
+	v := e(key)
+	if v == "" {
+		return nil, nil
+	}
+	m := make(map[string]time.Duration)
+	for _, pair := range strings.Split(v, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s: expected name=duration, got %q", key, pair)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%s: invalid duration for %s", key, name)
+		}
+		m[name] = d
+	}
+	return m, nil
+}
@@ -0,0 +1,182 @@
+// Package egress builds the HTTP client executors reach the outside world
+// with, so operators decide in one place which proxy jobs go through, which
+// hosts they may reach, which certificates they trust and how much they may
+// read back.
+package egress
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrHostNotAllowed   = errors.New("host is not on the egress allow list")
+	ErrResponseTooLarge = errors.New("response exceeds the egress size limit")
+)
+
+// DefaultMaxResponseBytes bounds responses unless configured otherwise
+const DefaultMaxResponseBytes = 1 << 20
+
+type Config struct {
+	// Proxy is the proxy every request goes through. Unset, the standard
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables apply.
+	Proxy *url.URL
+	// AllowedHosts, when set, are the only hosts requests may go to,
+	// including after redirects. "*.example.com" allows any subdomain of
+	// example.com, but not example.com itself.
+	AllowedHosts []string
+	// Schemes, when set, are the only URL schemes requests may use,
+	// including after redirects. Unset allows DefaultSchemes.
+	Schemes []string
+	// BlockPrivate refuses connections to loopback, private, link-local
+	// and other internal addresses, so a job can't reach the service's own
+	// network by naming it, or a host name resolving into it. Host names
+	// are resolved once and connected to by the addresses checked.
+	BlockPrivate bool
+	// AllowedNetworks are exceptions to BlockPrivate
+	AllowedNetworks []netip.Prefix
+	// CAFile adds PEM certificates to the system roots servers are
+	// verified against
+	CAFile string
+	// ClientCertFile and ClientKeyFile are presented to servers that ask
+	// for a client certificate
+	ClientCertFile string
+	ClientKeyFile  string
+	// MaxResponseBytes bounds how much of a response body can be read
+	MaxResponseBytes int64
+	// Timeout bounds each request, including reading the response
+	Timeout time.Duration
+}
+
+// NewClient returns a client enforcing cfg
+func NewClient(cfg Config) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("egress CA file: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("egress CA file: no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+	if cfg.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("egress client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.Proxy = http.ProxyFromEnvironment
+	if cfg.Proxy != nil {
+		transport.Proxy = http.ProxyURL(cfg.Proxy)
+	}
+	g := &guard{next: transport, proxy: transport.Proxy, allowed: cfg.AllowedHosts, schemes: cfg.Schemes}
+	if cfg.BlockPrivate {
+		g.dialer = &pinningDialer{allowed: cfg.AllowedNetworks, dial: transport.DialContext}
+		transport.DialContext = g.dialer.DialContext
+		transport.Proxy = g.dialer.trustProxies(transport.Proxy)
+		g.proxy = transport.Proxy
+	}
+	g.maxBytes = cfg.MaxResponseBytes
+	if g.maxBytes <= 0 {
+		g.maxBytes = DefaultMaxResponseBytes
+	}
+	return &http.Client{Transport: g, Timeout: cfg.Timeout}, nil
+}
+
+// guard checks every request, redirects included, against the allowed
+// schemes, hosts and addresses, and bounds what can be read from the
+// response
+type guard struct {
+	next     http.RoundTripper
+	proxy    func(*http.Request) (*url.URL, error)
+	dialer   *pinningDialer
+	allowed  []string
+	schemes  []string
+	maxBytes int64
+}
+
+func (g *guard) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !schemeAllowed(g.schemes, req.URL.Scheme) {
+		return nil, fmt.Errorf("%w: %s", ErrSchemeNotAllowed, req.URL.Scheme)
+	}
+	if !Allowed(g.allowed, req.URL.Hostname()) {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotAllowed, req.URL.Hostname())
+	}
+	// A proxied request's destination is never dialed here, so its
+	// addresses are checked up front. The proxy makes its own lookup.
+	if g.dialer != nil {
+		if proxy, _ := g.proxy(req); proxy != nil {
+			if _, err := g.dialer.resolve(req.Context(), req.URL.Hostname()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	resp, err := g.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > g.maxBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w of %d bytes", ErrResponseTooLarge, g.maxBytes)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: g.maxBytes}
+	return resp, nil
+}
+
+// Allowed reports whether host is on the allow list. An empty list allows
+// every host.
+func Allowed(allowed []string, host string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedBody fails reads once more than the limit has been read, rather
+// than quietly truncating the body
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrResponseTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,140 @@
+package egress
+
+import (
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowed(t *testing.T) {
+	allowed := []string{"api.example.com", "*.internal.example.com"}
+	assert.True(t, Allowed(nil, "anything.example.org"))
+	assert.True(t, Allowed(allowed, "api.example.com"))
+	assert.True(t, Allowed(allowed, "API.Example.com."))
+	assert.True(t, Allowed(allowed, "db.internal.example.com"))
+	assert.False(t, Allowed(allowed, "internal.example.com"))
+	assert.False(t, Allowed(allowed, "evil-api.example.com"))
+	assert.False(t, Allowed(allowed, "api.example.com.evil.org"))
+}
+
+func TestClient_AllowList(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://localhost:1/", http.StatusFound)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Config{AllowedHosts: []string{"127.0.0.1"}})
+	require.NoError(t, err)
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+
+	// Redirects are checked too
+	_, err = client.Get(srv.URL + "/redirect")
+	assert.ErrorIs(t, err, ErrHostNotAllowed)
+
+	_, err = client.Get(strings.Replace(srv.URL, "127.0.0.1", "localhost", 1))
+	assert.ErrorIs(t, err, ErrHostNotAllowed)
+}
+
+func TestClient_MaxResponseBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/declared" {
+			w.Header().Set("Content-Length", "11")
+		} else {
+			w.(http.Flusher).Flush() // send the body chunked, of unknown length
+		}
+		io.WriteString(w, "hello world")
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Config{MaxResponseBytes: 5})
+	require.NoError(t, err)
+	_, err = client.Get(srv.URL + "/declared")
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+
+	resp, err := client.Get(srv.URL + "/chunked")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.True(t, errors.Is(err, ErrResponseTooLarge))
+	assert.Equal(t, "hello", string(body))
+
+	client, err = NewClient(Config{MaxResponseBytes: 11})
+	require.NoError(t, err)
+	resp, err = client.Get(srv.URL + "/chunked")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(body))
+}
+
+func TestClient_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	client, err := NewClient(Config{Proxy: proxyURL, AllowedHosts: []string{"api.example.com"}})
+	require.NoError(t, err)
+	resp, err := client.Get("http://api.example.com/v1/things")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "http://api.example.com/v1/things", proxied)
+
+	// The allow list applies to the destination, not the proxy
+	_, err = client.Get("http://other.example.com/")
+	assert.ErrorIs(t, err, ErrHostNotAllowed)
+}
+
+func TestNewClient_TLSFiles(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secure")
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Config{})
+	require.NoError(t, err)
+	_, err = client.Get(srv.URL)
+	assert.Error(t, err, "the test server's certificate isn't trusted by default")
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pemCertificate(srv), 0o600))
+	client, err = NewClient(Config{CAFile: caFile})
+	require.NoError(t, err)
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+	_, err = NewClient(Config{CAFile: caFile})
+	assert.ErrorContains(t, err, "no certificates found")
+	_, err = NewClient(Config{ClientCertFile: filepath.Join(dir, "missing.pem"), ClientKeyFile: filepath.Join(dir, "missing.key")})
+	assert.ErrorContains(t, err, "egress client certificate")
+}
+
+func pemCertificate(srv *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+}
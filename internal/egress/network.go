@@ -0,0 +1,153 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrSchemeNotAllowed  = errors.New("scheme is not allowed for egress")
+	ErrAddressNotAllowed = errors.New("address is not allowed for egress")
+)
+
+// DefaultSchemes are the URL schemes requests may use unless configured
+// otherwise
+var DefaultSchemes = []string{"http", "https"}
+
+// privateNetworks are refused when private networks are blocked: loopback,
+// private, shared and link-local ranges, where the service's own network
+// and cloud metadata endpoints live, plus addresses that aren't a single
+// reachable host. IPv6 ranges carrying an IPv4 address (IPv4-compatible,
+// NAT64, 6to4 and Teredo) are refused whole, as they can reach any of these.
+var privateNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/96"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// lookup resolves a host name; tests replace it
+var lookup = func(ctx context.Context, host string) ([]netip.Addr, error) {
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+// Private reports whether addr is in a range refused when private networks
+// are blocked
+func Private(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range privateNetworks {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// pinningDialer resolves a host once, checks every address it resolves to
+// and connects to those addresses directly, so a second lookup can't swap
+// in an address that wasn't checked
+type pinningDialer struct {
+	allowed []netip.Prefix
+	dial    func(ctx context.Context, network, address string) (net.Conn, error)
+	// proxies are the addresses of proxies requests went through, which
+	// are dialed without checks since they are configured by operators
+	proxies sync.Map
+}
+
+func (d *pinningDialer) permits(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range d.allowed {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return !Private(addr)
+}
+
+// resolve returns host's addresses, failing if any of them isn't permitted
+func (d *pinningDialer) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		addrs = []netip.Addr{addr}
+	} else if addrs, err = lookup(ctx, host); err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if !d.permits(addr) {
+			return nil, fmt.Errorf("%w: %s resolves to %s", ErrAddressNotAllowed, host, addr)
+		}
+	}
+	return addrs, nil
+}
+
+func (d *pinningDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if _, ok := d.proxies.Load(address); ok {
+		return d.dial(ctx, network, address)
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		var conn net.Conn
+		if conn, err = d.dial(ctx, network, net.JoinHostPort(addr.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// trustProxies wraps a transport's proxy function so the proxies it picks
+// are dialed without checks
+func (d *pinningDialer) trustProxies(proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := proxy(req)
+		if u != nil {
+			d.proxies.Store(proxyAddr(u), true)
+		}
+		return u, err
+	}
+}
+
+// proxyAddr is the address the transport dials to reach a proxy
+func proxyAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[u.Scheme]
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+func schemeAllowed(schemes []string, scheme string) bool {
+	if len(schemes) == 0 {
+		schemes = DefaultSchemes
+	}
+	return slices.Contains(schemes, strings.ToLower(scheme))
+}
@@ -0,0 +1,123 @@
+package egress
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDNS replaces lookups with fixed answers for the test
+func fakeDNS(t *testing.T, answers map[string][]string) {
+	orig := lookup
+	t.Cleanup(func() { lookup = orig })
+	lookup = func(ctx context.Context, host string) ([]netip.Addr, error) {
+		var addrs []netip.Addr
+		for _, a := range answers[host] {
+			addrs = append(addrs, netip.MustParseAddr(a))
+		}
+		if len(addrs) == 0 {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return addrs, nil
+	}
+}
+
+func TestPrivate(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "172.20.0.1", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "fd00::1", "fe80::1", "::ffff:127.0.0.1", "64:ff9b::a00:1",
+		"::", "::7f00:1", "::a9fe:a9fe", "2002:7f00:1::1", "2002:c0a8:101::", "2001:0:4136:e378:8000:63bf:3fff:fdd2"} {
+		assert.True(t, Private(netip.MustParseAddr(addr)), addr)
+	}
+	for _, addr := range []string{"8.8.8.8", "172.32.0.1", "2606:4700::1111"} {
+		assert.False(t, Private(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestClient_BlockPrivate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://127.0.0.2:1/", http.StatusFound)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	fakeDNS(t, map[string][]string{
+		"app.example.com":      {"127.0.0.1"},
+		"metadata.example.com": {"169.254.169.254"},
+		"mixed.example.com":    {"93.184.216.34", "10.0.0.1"},
+	})
+
+	client, err := NewClient(Config{BlockPrivate: true})
+	require.NoError(t, err)
+	_, err = client.Get(srv.URL)
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+	_, err = client.Get("http://metadata.example.com/latest/meta-data/")
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+	// Every address a name resolves to must be allowed
+	_, err = client.Get("http://mixed.example.com/")
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+
+	client, err = NewClient(Config{BlockPrivate: true, AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}})
+	require.NoError(t, err)
+	resp, err := client.Get("http://app.example.com:" + port + "/")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+
+	// Redirects are checked too
+	_, err = client.Get(srv.URL + "/redirect")
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+}
+
+func TestClient_BlockPrivateThroughProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+	fakeDNS(t, map[string][]string{
+		"api.example.com":      {"93.184.216.34"},
+		"metadata.example.com": {"169.254.169.254"},
+	})
+
+	// The proxy is on loopback, but it's trusted; destinations aren't
+	client, err := NewClient(Config{Proxy: proxyURL, BlockPrivate: true})
+	require.NoError(t, err)
+	resp, err := client.Get("http://api.example.com/")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = client.Get("http://metadata.example.com/")
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+	_, err = client.Get("http://10.0.0.1/")
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+}
+
+func TestClient_Schemes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Config{})
+	require.NoError(t, err)
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	client, err = NewClient(Config{Schemes: []string{"https"}})
+	require.NoError(t, err)
+	_, err = client.Get(srv.URL)
+	assert.ErrorIs(t, err, ErrSchemeNotAllowed)
+}
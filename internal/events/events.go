@@ -0,0 +1,127 @@
+// Package events exports job status changes to a message bus, so pipelines
+// outside the service can follow jobs without polling it.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/backoff"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// DefaultTopic is the subject or channel events are published to unless
+// configured otherwise
+const DefaultTopic = "wps.job-events"
+
+// Publisher sends messages to a message bus
+type Publisher interface {
+	// Publish sends data to topic, returning once the bus has accepted it
+	Publish(ctx context.Context, topic string, data []byte) error
+	// Check connects to the bus without publishing anything
+	Check(ctx context.Context) error
+	Close() error
+}
+
+// NewPublisher returns a publisher for the bus at rawURL, which is either
+// nats://[user:pass@]host[:port] or redis://[:pass@]host[:port][/db]
+func NewPublisher(rawURL string) (Publisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "nats":
+		return newNATS(u), nil
+	case "redis":
+		return newRedis(u)
+	default:
+		return nil, fmt.Errorf("unsupported message bus %q; use nats:// or redis://", u.Scheme)
+	}
+}
+
+// retryBackoff spaces out attempts to publish an event the bus refused
+var retryBackoff = backoff.Exponential{Base: 100 * time.Millisecond, Max: 30 * time.Second, Jitter: true}
+
+// Exporter publishes job events in the order they happen. Events wait in a
+// buffer while the bus is slow or unreachable; once it is full, further
+// events are dropped rather than holding up the pool.
+type Exporter struct {
+	pub     Publisher
+	topic   string
+	events  chan model.JobEvent
+	dropped atomic.Int64
+}
+
+func NewExporter(pub Publisher, topic string, buffer int) *Exporter {
+	return &Exporter{pub: pub, topic: topic, events: make(chan model.JobEvent, buffer)}
+}
+
+// Enqueue buffers ev for publishing without blocking. It is meant to be
+// passed to WorkerPool.Subscribe.
+func (e *Exporter) Enqueue(ev model.JobEvent) {
+	select {
+	case e.events <- ev:
+	default:
+		if e.dropped.Add(1) == 1 {
+			slog.Warn("Event export buffer is full, dropping job events", "topic", e.topic)
+		}
+	}
+}
+
+// Dropped is how many events didn't fit in the buffer
+func (e *Exporter) Dropped() int64 {
+	return e.dropped.Load()
+}
+
+// Run publishes buffered events until ctx is done, retrying each until the
+// bus accepts it
+func (e *Exporter) Run(ctx context.Context) {
+	for {
+		select {
+		case ev := <-e.events:
+			e.publish(ctx, ev)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Flush publishes the events still buffered, giving up when ctx is done
+func (e *Exporter) Flush(ctx context.Context) error {
+	for {
+		select {
+		case ev := <-e.events:
+			if err := e.publish(ctx, ev); err != nil {
+				return fmt.Errorf("%d job events were not exported: %w", len(e.events)+1, err)
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (e *Exporter) publish(ctx context.Context, ev model.JobEvent) error {
+	data, err := json.Marshal(model.NewJobEventMessage(ev))
+	if err != nil {
+		return err
+	}
+	for retry := 1; ; retry++ {
+		err := e.pub.Publish(ctx, e.topic, data)
+		if err == nil {
+			return nil
+		}
+		delay := retryBackoff.Delay(retry)
+		slog.Warn("Failed to export job event", "job_uid", ev.JobUID, "error", err, "retry_in", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
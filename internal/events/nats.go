@@ -0,0 +1,156 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dialTimeout bounds connecting and each exchange with a bus when the
+// caller's context has no deadline
+const dialTimeout = 10 * time.Second
+
+// NATS publishes to a NATS server over its text protocol. Connections are
+// opened in verbose mode, so every publish is acknowledged.
+type NATS struct {
+	addr string
+	auth map[string]string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func newNATS(u *url.URL) *NATS {
+	n := &NATS{addr: u.Host, auth: map[string]string{}}
+	if u.Port() == "" {
+		n.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			n.auth["user"] = u.User.Username()
+			n.auth["pass"] = pass
+		} else {
+			n.auth["auth_token"] = u.User.Username()
+		}
+	}
+	return n
+}
+
+func (n *NATS) Publish(ctx context.Context, subject string, data []byte) error {
+	return n.exchange(ctx, func() error {
+		_, err := fmt.Fprintf(n.conn, "PUB %s %d\r\n%s\r\n", subject, len(data), data)
+		return err
+	})
+}
+
+func (n *NATS) Check(ctx context.Context) error {
+	return n.exchange(ctx, func() error {
+		_, err := n.conn.Write([]byte("PING\r\n"))
+		return err
+	})
+}
+
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
+
+// exchange sends a command and waits for the server to acknowledge it,
+// connecting first if needed. A failed exchange drops the connection so the
+// next one starts afresh.
+func (n *NATS) exchange(ctx context.Context, send func() error) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	err := n.connect(ctx)
+	if err == nil {
+		n.conn.SetDeadline(deadline(ctx))
+		if err = send(); err == nil {
+			err = n.readAck()
+		}
+	}
+	if err != nil && n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+	if err != nil {
+		return fmt.Errorf("nats %s: %w", n.addr, err)
+	}
+	return nil
+}
+
+func (n *NATS) connect(ctx context.Context) error {
+	if n.conn != nil {
+		return nil
+	}
+	var d net.Dialer
+	dialCtx, cancel := context.WithDeadline(ctx, deadline(ctx))
+	defer cancel()
+	conn, err := d.DialContext(dialCtx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	n.conn, n.r = conn, bufio.NewReader(conn)
+	conn.SetDeadline(deadline(ctx))
+
+	line, err := n.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+	opts := map[string]any{"verbose": true, "pedantic": false, "name": "worker-pool-service", "lang": "go"}
+	for k, v := range n.auth {
+		opts[k] = v
+	}
+	connect, _ := json.Marshal(opts)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\n", connect); err != nil {
+		return err
+	}
+	return n.readAck()
+}
+
+// readAck reads until the server acknowledges the last command, answering
+// its pings on the way
+func (n *NATS) readAck() error {
+	for {
+		line, err := n.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "+OK", line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+	}
+}
+
+// deadline is ctx's deadline, or dialTimeout from now if it has none
+func deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(dialTimeout)
+}
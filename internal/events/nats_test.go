@@ -0,0 +1,121 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNATS accepts connections speaking enough of the NATS protocol for a
+// publisher, recording what it receives
+type fakeNATS struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	connects []string
+	pubs     []string
+	// reject makes the next publish fail
+	reject bool
+}
+
+func newFakeNATS(t *testing.T) *fakeNATS {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f := &fakeNATS{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	io.WriteString(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		f.mu.Lock()
+		switch fields[0] {
+		case "CONNECT":
+			f.connects = append(f.connects, strings.TrimSpace(strings.TrimPrefix(line, "CONNECT ")))
+			// Servers ping clients at will
+			io.WriteString(conn, "+OK\r\nPING\r\n")
+		case "PUB":
+			n, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, n+2)
+			io.ReadFull(r, payload)
+			if f.reject {
+				f.reject = false
+				io.WriteString(conn, "-ERR 'Permissions Violation for Publish'\r\n")
+			} else {
+				f.pubs = append(f.pubs, fmt.Sprintf("%s %s", fields[1], payload[:n]))
+				io.WriteString(conn, "+OK\r\n")
+			}
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func TestNATS(t *testing.T) {
+	f := newFakeNATS(t)
+	ctx := context.Background()
+	u, _ := url.Parse("nats://s3cret@" + f.ln.Addr().String())
+	n := newNATS(u)
+	defer n.Close()
+
+	require.NoError(t, n.Check(ctx))
+	require.NoError(t, n.Publish(ctx, "wps.job-events", []byte(`{"to":"pending"}`)))
+	require.NoError(t, n.Publish(ctx, "wps.job-events", []byte(`{"to":"running"}`)))
+
+	f.mu.Lock()
+	f.reject = true
+	f.mu.Unlock()
+	err := n.Publish(ctx, "wps.job-events", []byte(`{"to":"completed"}`))
+	assert.EqualError(t, err, "nats "+f.ln.Addr().String()+": Permissions Violation for Publish")
+	// The next publish reconnects
+	require.NoError(t, n.Publish(ctx, "wps.job-events", []byte(`{"to":"completed"}`)))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.Equal(t, []string{
+		`wps.job-events {"to":"pending"}`,
+		`wps.job-events {"to":"running"}`,
+		`wps.job-events {"to":"completed"}`,
+	}, f.pubs)
+	require.Len(t, f.connects, 2)
+	assert.Contains(t, f.connects[0], `"auth_token":"s3cret"`)
+	assert.Contains(t, f.connects[0], `"verbose":true`)
+}
+
+func TestNATS_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	u, _ := url.Parse("nats://" + addr)
+	assert.Error(t, newNATS(u).Check(context.Background()))
+}
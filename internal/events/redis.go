@@ -0,0 +1,137 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Redis publishes to Redis pub/sub channels over RESP
+type Redis struct {
+	addr     string
+	user     string
+	password string
+	db       int
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func newRedis(u *url.URL) (*Redis, error) {
+	r := &Redis{addr: u.Host}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.user = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+		r.db = n
+	}
+	return r, nil
+}
+
+func (r *Redis) Publish(ctx context.Context, channel string, data []byte) error {
+	return r.command(ctx, "PUBLISH", channel, string(data))
+}
+
+func (r *Redis) Check(ctx context.Context) error {
+	return r.command(ctx, "PING")
+}
+
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+// command runs a command, connecting first if needed. A failed command
+// drops the connection so the next one starts afresh.
+func (r *Redis) command(ctx context.Context, args ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.connect(ctx)
+	if err == nil {
+		r.conn.SetDeadline(deadline(ctx))
+		err = r.do(args...)
+	}
+	if err != nil && r.conn != nil {
+		r.conn.Close()
+		r.conn = nil
+	}
+	if err != nil {
+		return fmt.Errorf("redis %s: %w", r.addr, err)
+	}
+	return nil
+}
+
+func (r *Redis) connect(ctx context.Context) error {
+	if r.conn != nil {
+		return nil
+	}
+	var d net.Dialer
+	dialCtx, cancel := context.WithDeadline(ctx, deadline(ctx))
+	defer cancel()
+	conn, err := d.DialContext(dialCtx, "tcp", r.addr)
+	if err != nil {
+		return err
+	}
+	r.conn, r.r = conn, bufio.NewReader(conn)
+	conn.SetDeadline(deadline(ctx))
+
+	switch {
+	case r.user != "" && r.password != "":
+		err = r.do("AUTH", r.user, r.password)
+	case r.password != "":
+		err = r.do("AUTH", r.password)
+	}
+	if err == nil && r.db != 0 {
+		err = r.do("SELECT", strconv.Itoa(r.db))
+	}
+	return err
+}
+
+// do sends a command as a RESP array of bulk strings and reads its simple
+// reply
+func (r *Redis) do(args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := r.conn.Write([]byte(b.String())); err != nil {
+		return err
+	}
+
+	line, err := r.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "-"):
+		return errors.New(line[1:])
+	case strings.HasPrefix(line, "+"), strings.HasPrefix(line, ":"):
+		return nil
+	default:
+		return fmt.Errorf("unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,128 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis answers the commands a publisher sends, recording them
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu       sync.Mutex
+	commands [][]string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f := &fakeRedis{ln: ln, password: password}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, args)
+		f.mu.Unlock()
+		switch {
+		case args[0] == "AUTH":
+			if args[len(args)-1] != f.password {
+				io.WriteString(conn, "-WRONGPASS invalid username-password pair\r\n")
+				continue
+			}
+			authed = true
+			io.WriteString(conn, "+OK\r\n")
+		case !authed:
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+		case args[0] == "PING":
+			io.WriteString(conn, "+PONG\r\n")
+		case args[0] == "SELECT":
+			io.WriteString(conn, "+OK\r\n")
+		case args[0] == "PUBLISH":
+			io.WriteString(conn, ":0\r\n")
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, fmt.Errorf("bad array header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	f := newFakeRedis(t, "s3cret")
+	ctx := context.Background()
+
+	u, _ := url.Parse("redis://:s3cret@" + f.ln.Addr().String() + "/3")
+	r, err := newRedis(u)
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.NoError(t, r.Check(ctx))
+	require.NoError(t, r.Publish(ctx, "wps.job-events", []byte("{\"to\":\"pending\"}\r\n")))
+
+	f.mu.Lock()
+	assert.Equal(t, [][]string{
+		{"AUTH", "s3cret"},
+		{"SELECT", "3"},
+		{"PING"},
+		{"PUBLISH", "wps.job-events", "{\"to\":\"pending\"}\r\n"},
+	}, f.commands)
+	f.mu.Unlock()
+
+	u, _ = url.Parse("redis://:guess@" + f.ln.Addr().String())
+	r, err = newRedis(u)
+	require.NoError(t, err)
+	err = r.Publish(ctx, "wps.job-events", []byte("{}"))
+	assert.EqualError(t, err, "redis "+f.ln.Addr().String()+": WRONGPASS invalid username-password pair")
+}
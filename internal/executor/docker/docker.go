@@ -0,0 +1,326 @@
+// Package docker runs container jobs through the Docker Engine API.
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+const DefaultHost = "unix:///var/run/docker.sock"
+
+type Config struct {
+	// Host is the Docker daemon address, either unix:///path/to/socket or
+	// tcp://host:port
+	Host string
+	// CPUs limits each container to this many CPUs, 0 for no limit
+	CPUs float64
+	// MemoryBytes limits each container's memory, 0 for no limit
+	MemoryBytes int64
+	HTTPClient  *http.Client
+}
+
+// Executor runs each container job in a fresh container created from the
+// job's image, command and environment. Container output is streamed to the
+// job log while it runs and the container is removed once it exits.
+type Executor struct {
+	cfg     Config
+	client  *http.Client
+	baseURL string
+}
+
+func NewExecutor(cfg Config) (*Executor, error) {
+	if cfg.Host == "" {
+		cfg.Host = DefaultHost
+	}
+	if cfg.CPUs < 0 || cfg.MemoryBytes < 0 {
+		return nil, errors.New("container limits cannot be negative")
+	}
+
+	e := &Executor{cfg: cfg, client: cfg.HTTPClient}
+	switch {
+	case strings.HasPrefix(cfg.Host, "unix://"):
+		socket := strings.TrimPrefix(cfg.Host, "unix://")
+		if e.client == nil {
+			e.client = &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+						var d net.Dialer
+						return d.DialContext(ctx, "unix", socket)
+					},
+				},
+			}
+		}
+		// The host part is ignored when dialing the socket
+		e.baseURL = "http://docker"
+	case strings.HasPrefix(cfg.Host, "tcp://"):
+		e.baseURL = "http://" + strings.TrimPrefix(cfg.Host, "tcp://")
+	case strings.HasPrefix(cfg.Host, "http://"), strings.HasPrefix(cfg.Host, "https://"):
+		e.baseURL = strings.TrimSuffix(cfg.Host, "/")
+	default:
+		return nil, fmt.Errorf("unsupported docker host %q", cfg.Host)
+	}
+	if e.client == nil {
+		e.client = http.DefaultClient
+	}
+	return e, nil
+}
+
+// Describe names the executor in the job type catalog
+func (e *Executor) Describe(jt *model.JobType) {
+	jt.Executor = "docker"
+}
+
+func (e *Executor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	payload, ok := job.Payload.(model.ContainerJobPayload)
+	if !ok {
+		return nil, pool.Permanent(errors.New("invalid container payload type"))
+	}
+
+	id, err := e.create(ctx, job, payload)
+	if errors.Is(err, errImageNotFound) {
+		if err := e.pull(ctx, payload.Image); err != nil {
+			return nil, err
+		}
+		id, err = e.create(ctx, job, payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer e.remove(id)
+	if err := pool.Annotate(ctx, "container_id", id); err != nil {
+		return nil, err
+	}
+
+	if err := e.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil); err != nil {
+		return nil, fmt.Errorf("starting container: %w", err)
+	}
+
+	// Following the logs blocks until the container exits
+	if err := e.streamLogs(ctx, id, logs); err != nil {
+		return nil, err
+	}
+
+	var wait struct {
+		StatusCode int `json:"StatusCode"`
+		Error      *struct {
+			Message string `json:"Message"`
+		} `json:"Error"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/containers/"+id+"/wait", nil, &wait); err != nil {
+		return nil, fmt.Errorf("waiting for container: %w", err)
+	}
+	if wait.Error != nil && wait.Error.Message != "" {
+		return nil, fmt.Errorf("waiting for container: %s", wait.Error.Message)
+	}
+
+	if wait.StatusCode != 0 {
+		var inspect struct {
+			State struct {
+				OOMKilled bool `json:"OOMKilled"`
+			} `json:"State"`
+		}
+		if err := e.do(ctx, http.MethodGet, "/containers/"+id+"/json", nil, &inspect); err == nil && inspect.State.OOMKilled {
+			return nil, fmt.Errorf("container exceeded its memory limit of %d bytes", e.cfg.MemoryBytes)
+		}
+		return nil, fmt.Errorf("container exited with code %d", wait.StatusCode)
+	}
+	return model.ContainerJobResult{ExitCode: 0}, nil
+}
+
+var errImageNotFound = errors.New("image not found")
+
+func (e *Executor) create(ctx context.Context, job *model.Job, payload model.ContainerJobPayload) (string, error) {
+	env := make([]string, 0, len(payload.Env)+2)
+	for k, v := range payload.Env {
+		env = append(env, k+"="+v.Value)
+	}
+	sort.Strings(env)
+	env = append(env, "WPS_JOB_UID="+job.UID.String(), "WPS_JOB_TYPE="+job.Type)
+
+	body := map[string]any{
+		"Image": payload.Image,
+		"Env":   env,
+		"Labels": map[string]string{
+			"worker-pool-service.job-uid": job.UID.String(),
+		},
+		"HostConfig": map[string]any{
+			"NanoCpus": int64(e.cfg.CPUs * 1e9),
+			"Memory":   e.cfg.MemoryBytes,
+		},
+	}
+	if len(payload.Command) > 0 {
+		body["Cmd"] = payload.Command
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	path := "/containers/create?name=" + url.QueryEscape("wps-"+job.UID.String())
+	err := e.do(ctx, http.MethodPost, path, body, &created)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound {
+		return "", errImageNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("creating container: %w", err)
+	}
+	return created.ID, nil
+}
+
+// pull fetches an image. The daemon streams progress messages and reports
+// failures in-band, so the whole stream is read and checked.
+func (e *Executor) pull(ctx context.Context, image string) error {
+	req, err := e.request(ctx, http.MethodPost, "/images/create?fromImage="+url.QueryEscape(image), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pulling image %s: %w", image, err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return fmt.Errorf("pulling image %s: %w", image, err)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("pulling image %s: %w", image, err)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("pulling image %s: %s", image, msg.Error)
+		}
+	}
+}
+
+func (e *Executor) streamLogs(ctx context.Context, id string, logs io.Writer) error {
+	req, err := e.request(ctx, http.MethodGet, "/containers/"+id+"/logs?follow=true&stdout=true&stderr=true", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("reading container logs: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return fmt.Errorf("reading container logs: %w", err)
+	}
+	if err := demux(logs, resp.Body); err != nil {
+		return fmt.Errorf("reading container logs: %w", err)
+	}
+	return nil
+}
+
+// demux copies a multiplexed stdout/stderr log stream to w. Each frame starts
+// with an 8 byte header holding the stream id and the big-endian frame size.
+func demux(w io.Writer, r io.Reader) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+// remove deletes the container. It runs detached from the execution context
+// so cancelled jobs are still cleaned up.
+func (e *Executor) remove(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	e.do(ctx, http.MethodDelete, "/containers/"+id+"?force=true", nil, nil)
+}
+
+// Ping checks that the daemon is reachable
+func (e *Executor) Ping(ctx context.Context) error {
+	return e.do(ctx, http.MethodGet, "/_ping", nil, nil)
+}
+
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.status, http.StatusText(e.status), e.message)
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	var body struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		message = body.Message
+	}
+	return &apiError{status: resp.StatusCode, message: message}
+}
+
+func (e *Executor) request(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, e.baseURL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (e *Executor) do(ctx context.Context, method, path string, body any, out any) error {
+	req, err := e.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
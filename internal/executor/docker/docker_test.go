@@ -0,0 +1,213 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDaemon imitates the parts of the Docker Engine API the executor uses
+type fakeDaemon struct {
+	mu        sync.Mutex
+	hasImage  bool
+	pulled    bool
+	created   map[string]any
+	started   bool
+	removed   bool
+	exitCode  int
+	oomKilled bool
+	stdout    string
+	stderr    string
+}
+
+func (f *fakeDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/containers/create":
+		if !f.hasImage {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "No such image"})
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&f.created)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": "c1"})
+	case r.Method == http.MethodPost && r.URL.Path == "/images/create":
+		f.pulled = true
+		f.hasImage = true
+		w.Write([]byte(`{"status":"Pulling"}` + "\n" + `{"status":"Downloaded"}` + "\n"))
+	case r.Method == http.MethodPost && r.URL.Path == "/containers/c1/start":
+		f.started = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Path == "/containers/c1/logs":
+		w.Write(frame(1, f.stdout))
+		w.Write(frame(2, f.stderr))
+	case r.Method == http.MethodPost && r.URL.Path == "/containers/c1/wait":
+		json.NewEncoder(w).Encode(map[string]any{"StatusCode": f.exitCode})
+	case r.Method == http.MethodGet && r.URL.Path == "/containers/c1/json":
+		json.NewEncoder(w).Encode(map[string]any{"State": map[string]any{"OOMKilled": f.oomKilled}})
+	case r.Method == http.MethodDelete && r.URL.Path == "/containers/c1":
+		f.removed = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Path == "/_ping":
+		w.Write([]byte("OK"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// frame encodes one multiplexed log frame
+func frame(stream byte, data string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return append(header, data...)
+}
+
+func newTestExecutor(t *testing.T, server *httptest.Server) *Executor {
+	e, err := NewExecutor(Config{Host: server.URL, CPUs: 0.5, MemoryBytes: 64 << 20})
+	assert.NoError(t, err)
+	return e
+}
+
+func TestExecutor_Execute(t *testing.T) {
+	job := &model.Job{
+		UID:  uuid.New(),
+		Type: "container",
+		Payload: model.ContainerJobPayload{
+			Image:   "alpine:3",
+			Command: []string{"sh", "-c", "echo hello"},
+			Env:     map[string]model.SecretString{"GREETING": {Value: "hello"}},
+		},
+	}
+
+	t.Run("successful job", func(t *testing.T) {
+		daemon := &fakeDaemon{hasImage: true, stdout: "hello\n", stderr: "warning\n"}
+		server := httptest.NewServer(daemon)
+		defer server.Close()
+
+		var logs bytes.Buffer
+		result, err := newTestExecutor(t, server).Execute(context.Background(), job, &logs)
+		assert.NoError(t, err)
+		assert.Equal(t, model.ContainerJobResult{ExitCode: 0}, result)
+		assert.Equal(t, "hello\nwarning\n", logs.String())
+		assert.True(t, daemon.started)
+		assert.True(t, daemon.removed)
+		assert.False(t, daemon.pulled)
+
+		assert.Equal(t, "alpine:3", daemon.created["Image"])
+		assert.Equal(t, []any{"sh", "-c", "echo hello"}, daemon.created["Cmd"])
+		assert.Contains(t, daemon.created["Env"], "GREETING=hello")
+		assert.Contains(t, daemon.created["Env"], "WPS_JOB_UID="+job.UID.String())
+		hostConfig := daemon.created["HostConfig"].(map[string]any)
+		assert.Equal(t, float64(500000000), hostConfig["NanoCpus"])
+		assert.Equal(t, float64(64<<20), hostConfig["Memory"])
+	})
+
+	t.Run("pulls missing image", func(t *testing.T) {
+		daemon := &fakeDaemon{}
+		server := httptest.NewServer(daemon)
+		defer server.Close()
+
+		_, err := newTestExecutor(t, server).Execute(context.Background(), job, &bytes.Buffer{})
+		assert.NoError(t, err)
+		assert.True(t, daemon.pulled)
+		assert.True(t, daemon.started)
+	})
+
+	t.Run("non-zero exit", func(t *testing.T) {
+		daemon := &fakeDaemon{hasImage: true, exitCode: 2}
+		server := httptest.NewServer(daemon)
+		defer server.Close()
+
+		_, err := newTestExecutor(t, server).Execute(context.Background(), job, &bytes.Buffer{})
+		assert.Error(t, err)
+		assert.Equal(t, "container exited with code 2", err.Error())
+		assert.True(t, daemon.removed)
+	})
+
+	t.Run("memory limit exceeded", func(t *testing.T) {
+		daemon := &fakeDaemon{hasImage: true, exitCode: 137, oomKilled: true}
+		server := httptest.NewServer(daemon)
+		defer server.Close()
+
+		_, err := newTestExecutor(t, server).Execute(context.Background(), job, &bytes.Buffer{})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeded its memory limit")
+	})
+
+	t.Run("pull error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/images/create" {
+				w.Write([]byte(`{"error":"manifest unknown"}`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := newTestExecutor(t, server).Execute(context.Background(), job, &bytes.Buffer{})
+		assert.Error(t, err)
+		assert.Equal(t, "pulling image alpine:3: manifest unknown", err.Error())
+	})
+}
+
+func TestExecutor_Ping(t *testing.T) {
+	server := httptest.NewServer(&fakeDaemon{})
+	e := newTestExecutor(t, server)
+	assert.NoError(t, e.Ping(context.Background()))
+
+	server.Close()
+	assert.Error(t, e.Ping(context.Background()))
+}
+
+func TestNewExecutor(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		baseURL string
+		wantErr bool
+	}{
+		{name: "default socket", cfg: Config{}, baseURL: "http://docker"},
+		{name: "tcp host", cfg: Config{Host: "tcp://10.0.0.5:2375"}, baseURL: "http://10.0.0.5:2375"},
+		{name: "unsupported scheme", cfg: Config{Host: "ssh://docker"}, wantErr: true},
+		{name: "negative limit", cfg: Config{CPUs: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewExecutor(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.baseURL, e.baseURL)
+		})
+	}
+}
+
+func TestDemux(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(frame(1, "out "))
+	stream.Write(frame(2, "err"))
+
+	var out strings.Builder
+	assert.NoError(t, demux(&out, &stream))
+	assert.Equal(t, "out err", out.String())
+
+	// A truncated frame is an error
+	assert.Error(t, demux(&out, bytes.NewReader(frame(1, "partial")[:10])))
+}
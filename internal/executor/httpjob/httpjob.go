@@ -0,0 +1,105 @@
+// Package httpjob runs http jobs, each making one HTTP request whose
+// response becomes the job's result.
+package httpjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dnakolan/worker-pool-service/internal/egress"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+// Executor makes requests through a client built by the egress package, so
+// the operator's proxy, allow list and limits apply to every job. Client
+// errors (4xx) fail the job for good, while server errors (5xx) and network
+// failures are retried.
+type Executor struct {
+	client *http.Client
+}
+
+func NewExecutor(client *http.Client) *Executor {
+	return &Executor{client: client}
+}
+
+// Describe lists http jobs as built in, since they run in-process
+func (e *Executor) Describe(jt *model.JobType) {
+	jt.Executor = model.BuiltinExecutor
+}
+
+func (e *Executor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	payload, ok := job.Payload.(model.HTTPJobPayload)
+	if !ok {
+		return nil, pool.Permanent(errors.New("invalid http payload type"))
+	}
+	method := payload.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var reqBody io.Reader = strings.NewReader(payload.Body)
+	if payload.BinaryBody != nil {
+		// A body that isn't text is streamed from the blob store
+		binary, r, err := pool.Binary(ctx, "binary_body")
+		if errors.Is(err, pool.ErrNoBinary) {
+			return nil, pool.Permanent(fmt.Errorf("binary_body: %w", err))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("binary_body: %w", err)
+		}
+		defer r.Close()
+		reqBody = r
+		payload.BinaryBody = binary
+	}
+	req, err := http.NewRequestWithContext(ctx, method, payload.URL, reqBody)
+	if err != nil {
+		return nil, pool.Permanent(err)
+	}
+	if b := payload.BinaryBody; b != nil {
+		req.ContentLength = *b.Size
+		if b.ContentType != "" {
+			req.Header.Set("Content-Type", b.ContentType)
+		}
+	}
+	for name, value := range payload.Headers {
+		req.Header.Set(name, value.Value)
+	}
+	fmt.Fprintf(logs, "%s %s\n", method, payload.URL)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, classify(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, classify(err)
+	}
+	fmt.Fprintf(logs, "%s, %d bytes\n", resp.Status, len(body))
+
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, fmt.Errorf("server responded %s", resp.Status)
+	case resp.StatusCode >= 400:
+		return nil, pool.Permanent(fmt.Errorf("server responded %s", resp.Status))
+	}
+	return model.HTTPJobResult{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	}, nil
+}
+
+// classify marks failures that would recur on every attempt as permanent
+func classify(err error) error {
+	if errors.Is(err, egress.ErrHostNotAllowed) || errors.Is(err, egress.ErrSchemeNotAllowed) ||
+		errors.Is(err, egress.ErrAddressNotAllowed) || errors.Is(err, egress.ErrResponseTooLarge) {
+		return pool.Permanent(err)
+	}
+	return err
+}
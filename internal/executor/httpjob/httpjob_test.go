@@ -0,0 +1,106 @@
+package httpjob
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/egress"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			w.Header().Set("Content-Type", "text/plain")
+			io.Copy(w, r.Body)
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := egress.NewClient(egress.Config{AllowedHosts: []string{"127.0.0.1"}})
+	require.NoError(t, err)
+	e := NewExecutor(client)
+	run := func(payload model.HTTPJobPayload) (model.JobResult, error) {
+		return e.Execute(context.Background(), &model.Job{Type: "http", Payload: payload}, io.Discard)
+	}
+
+	var logs bytes.Buffer
+	result, err := e.Execute(context.Background(), &model.Job{Type: "http", Payload: model.HTTPJobPayload{
+		Method:  http.MethodPost,
+		URL:     srv.URL + "/echo",
+		Headers: map[string]model.SecretString{"Authorization": {Value: "Bearer token"}},
+		Body:    "ping",
+	}}, &logs)
+	require.NoError(t, err)
+	assert.Equal(t, model.HTTPJobResult{StatusCode: http.StatusOK, ContentType: "text/plain", Body: "ping"}, result)
+	assert.Contains(t, logs.String(), "POST "+srv.URL+"/echo")
+
+	_, err = run(model.HTTPJobPayload{URL: srv.URL + "/missing"})
+	assert.True(t, pool.IsPermanent(err))
+
+	_, err = run(model.HTTPJobPayload{URL: srv.URL + "/busy"})
+	assert.Error(t, err)
+	assert.False(t, pool.IsPermanent(err))
+
+	_, err = run(model.HTTPJobPayload{URL: "http://localhost:1/"})
+	assert.ErrorIs(t, err, egress.ErrHostNotAllowed)
+	assert.True(t, pool.IsPermanent(err))
+
+	client, err = egress.NewClient(egress.Config{BlockPrivate: true})
+	require.NoError(t, err)
+	_, err = NewExecutor(client).Execute(context.Background(), &model.Job{Type: "http", Payload: model.HTTPJobPayload{URL: srv.URL}}, io.Discard)
+	assert.ErrorIs(t, err, egress.ErrAddressNotAllowed)
+	assert.True(t, pool.IsPermanent(err))
+}
+
+func TestExecutor_BinaryBody(t *testing.T) {
+	data := []byte{0x1f, 0x8b, 0x00, 0xff, 0x10}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/gzip", r.Header.Get("Content-Type"))
+		assert.Equal(t, int64(len(data)), r.ContentLength)
+		got, _ := io.ReadAll(r.Body)
+		assert.Equal(t, data, got)
+	}))
+	defer srv.Close()
+
+	client, err := egress.NewClient(egress.Config{AllowedHosts: []string{"127.0.0.1"}})
+	require.NoError(t, err)
+	h := pooltest.New(t, pooltest.WithExecutor("http", NewExecutor(client)))
+	store, err := blob.NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+	h.Pool.SetBlobStore(store)
+
+	job := &model.Job{UID: uuid.New(), Type: "http", Payload: model.HTTPJobPayload{
+		Method:     http.MethodPut,
+		URL:        srv.URL,
+		BinaryBody: &model.Binary{Base64: base64.StdEncoding.EncodeToString(data), ContentType: "application/gzip"},
+	}}
+	require.NoError(t, h.Pool.StoreBinaries(context.Background(), job))
+	h.Submit(job)
+	require.True(t, h.RunNext())
+	assert.Equal(t, model.JobStatusCompleted, h.Job(job.UID).Status, h.Job(job.UID).Error)
+
+	// Data that was never stored fails the job for good
+	job = h.Submit(&model.Job{Type: "http", Payload: model.HTTPJobPayload{URL: srv.URL, BinaryBody: &model.Binary{Part: "body"}}})
+	require.True(t, h.RunNext())
+	assert.Equal(t, model.JobStatusFailed, h.Job(job.UID).Status)
+	assert.Len(t, h.Job(job.UID).Attempts, 1)
+}
@@ -0,0 +1,387 @@
+// Package kubernetes runs jobs as Kubernetes Jobs instead of in-process.
+package kubernetes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	logTailLines      = 50
+)
+
+type Config struct {
+	// APIServer is the base URL of the Kubernetes API, e.g. https://10.0.0.1:443
+	APIServer string
+	Token     string
+	Namespace string
+	// PodTemplate is a core/v1 PodTemplateSpec the job's pod is built from
+	PodTemplate  map[string]any
+	PollInterval time.Duration
+	HTTPClient   *http.Client
+}
+
+// InClusterConfig builds a Config from the pod's service account and the
+// pod template at templatePath
+func InClusterConfig(templatePath string) (*Config, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running inside a kubernetes cluster")
+	}
+
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("reading service account token: %w", err)
+	}
+	namespace, err := os.ReadFile(serviceAccountDir + "/namespace")
+	if err != nil {
+		return nil, fmt.Errorf("reading service account namespace: %w", err)
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("reading cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid cluster CA certificate")
+	}
+
+	template, err := LoadPodTemplate(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		APIServer:   "https://" + host + ":" + port,
+		Token:       strings.TrimSpace(string(token)),
+		Namespace:   strings.TrimSpace(string(namespace)),
+		PodTemplate: template,
+		HTTPClient: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+// LoadPodTemplate reads a JSON PodTemplateSpec from disk
+func LoadPodTemplate(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading pod template: %w", err)
+	}
+	var template map[string]any
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("invalid pod template: %w", err)
+	}
+	if _, err := containers(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// Executor creates one Kubernetes Job per pool job and waits for it to finish.
+// The job's type and payload are passed to every container as the
+// WPS_JOB_UID, WPS_JOB_TYPE and WPS_JOB_PAYLOAD environment variables. On
+// success the last line the pod logs is decoded as the job result. The log
+// tail is copied to the job's log once the pod has finished.
+type Executor struct {
+	cfg    Config
+	client *http.Client
+}
+
+func NewExecutor(cfg Config) *Executor {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	return &Executor{cfg: cfg, client: client}
+}
+
+// ExportsPayload marks the executor as a pool.PayloadExporter: the payload
+// is written into the Kubernetes Job, where anyone able to read Jobs, and
+// etcd, would see a resolved secret
+func (e *Executor) ExportsPayload() {}
+
+// Describe names the executor in the job type catalog
+func (e *Executor) Describe(jt *model.JobType) {
+	jt.Executor = "kubernetes"
+}
+
+func (e *Executor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	name := "wps-" + job.UID.String()
+	manifest, err := e.manifest(name, job)
+	if err != nil {
+		return nil, pool.Permanent(err)
+	}
+
+	if err := e.do(ctx, http.MethodPost, e.jobsPath(""), manifest, nil); err != nil {
+		return nil, fmt.Errorf("creating kubernetes job: %w", err)
+	}
+	defer e.cleanup(name)
+	if err := pool.Annotate(ctx, "kubernetes_job", name); err != nil {
+		return nil, err
+	}
+
+	succeeded, err := e.wait(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	pod, exitCode, err := e.pod(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Annotate(ctx, "pod", pod); err != nil {
+		return nil, err
+	}
+	output, err := e.logs(ctx, pod)
+	if err != nil {
+		return nil, err
+	}
+	io.WriteString(logs, output)
+
+	if !succeeded {
+		return nil, fmt.Errorf("kubernetes job failed with exit code %d: %s", exitCode, strings.TrimSpace(output))
+	}
+	return model.DecodeJobResult(job.Type, []byte(lastLine(output)))
+}
+
+func (e *Executor) manifest(name string, job *model.Job) (map[string]any, error) {
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deep copy the template so concurrent jobs don't share env slices
+	raw, err := json.Marshal(e.cfg.PodTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var template map[string]any
+	if err := json.Unmarshal(raw, &template); err != nil {
+		return nil, err
+	}
+
+	list, err := containers(template)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range list {
+		container := c.(map[string]any)
+		env, _ := container["env"].([]any)
+		container["env"] = append(env,
+			map[string]any{"name": "WPS_JOB_UID", "value": job.UID.String()},
+			map[string]any{"name": "WPS_JOB_TYPE", "value": job.Type},
+			map[string]any{"name": "WPS_JOB_PAYLOAD", "value": string(payload)},
+		)
+	}
+	template["spec"].(map[string]any)["restartPolicy"] = "Never"
+
+	return map[string]any{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]any{
+			"name": name,
+			"labels": map[string]any{
+				"app.kubernetes.io/managed-by": "worker-pool-service",
+				"wps/job-type":                 job.Type,
+			},
+		},
+		"spec": map[string]any{
+			"backoffLimit": 0,
+			"template":     template,
+		},
+	}, nil
+}
+
+// wait polls the Kubernetes Job until it succeeds or fails
+func (e *Executor) wait(ctx context.Context, name string) (bool, error) {
+	ticker := time.NewTicker(e.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		var status struct {
+			Status struct {
+				Succeeded int `json:"succeeded"`
+				Failed    int `json:"failed"`
+			} `json:"status"`
+		}
+		if err := e.do(ctx, http.MethodGet, e.jobsPath(name), nil, &status); err != nil {
+			return false, fmt.Errorf("reading kubernetes job: %w", err)
+		}
+		switch {
+		case status.Status.Succeeded > 0:
+			return true, nil
+		case status.Status.Failed > 0:
+			return false, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// pod finds the pod the job ran in and its container's exit code
+func (e *Executor) pod(ctx context.Context, jobName string) (string, int, error) {
+	var pods struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				ContainerStatuses []struct {
+					State struct {
+						Terminated *struct {
+							ExitCode int `json:"exitCode"`
+						} `json:"terminated"`
+					} `json:"state"`
+				} `json:"containerStatuses"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	path := "/api/v1/namespaces/" + e.cfg.Namespace + "/pods?labelSelector=" + url.QueryEscape("job-name="+jobName)
+	if err := e.do(ctx, http.MethodGet, path, nil, &pods); err != nil {
+		return "", 0, fmt.Errorf("listing pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", 0, errors.New("kubernetes job has no pods")
+	}
+
+	pod := pods.Items[0]
+	exitCode := 0
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0 {
+			exitCode = cs.State.Terminated.ExitCode
+		}
+	}
+	return pod.Metadata.Name, exitCode, nil
+}
+
+func (e *Executor) logs(ctx context.Context, pod string) (string, error) {
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/log?tailLines=%d", e.cfg.Namespace, pod, logTailLines)
+	req, err := e.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("reading pod logs: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading pod logs: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("reading pod logs: %s", resp.Status)
+	}
+	return string(data), nil
+}
+
+// cleanup deletes the Kubernetes Job and its pods. It runs detached from the
+// execution context so cancelled jobs are still removed.
+func (e *Executor) cleanup(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	e.do(ctx, http.MethodDelete, e.jobsPath(name)+"?propagationPolicy=Background", nil, nil)
+}
+
+func (e *Executor) jobsPath(name string) string {
+	path := "/apis/batch/v1/namespaces/" + e.cfg.Namespace + "/jobs"
+	if name != "" {
+		path += "/" + name
+	}
+	return path
+}
+
+func (e *Executor) request(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, e.cfg.APIServer+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.cfg.Token)
+	}
+	return req, nil
+}
+
+func (e *Executor) do(ctx context.Context, method, path string, body any, out any) error {
+	req, err := e.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func containers(template map[string]any) ([]any, error) {
+	spec, ok := template["spec"].(map[string]any)
+	if !ok {
+		return nil, errors.New("pod template has no spec")
+	}
+	list, ok := spec["containers"].([]any)
+	if !ok || len(list) == 0 {
+		return nil, errors.New("pod template has no containers")
+	}
+	for _, c := range list {
+		if _, ok := c.(map[string]any); !ok {
+			return nil, errors.New("pod template containers must be objects")
+		}
+	}
+	return list, nil
+}
+
+func lastLine(s string) string {
+	var last string
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			last = line
+		}
+	}
+	return last
+}
@@ -0,0 +1,174 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPIServer imitates the parts of the Kubernetes API the executor uses
+type fakeAPIServer struct {
+	mu       sync.Mutex
+	created  map[string]any
+	polls    int
+	deleted  bool
+	failed   bool
+	exitCode int
+	logs     string
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/apis/batch/v1/namespaces/jobs-ns/jobs":
+		json.NewDecoder(r.Body).Decode(&f.created)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/apis/batch/v1/namespaces/jobs-ns/jobs/"):
+		f.polls++
+		status := map[string]int{}
+		if f.polls > 1 {
+			if f.failed {
+				status["failed"] = 1
+			} else {
+				status["succeeded"] = 1
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": status})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/jobs-ns/pods":
+		json.NewEncoder(w).Encode(map[string]any{
+			"items": []any{map[string]any{
+				"metadata": map[string]any{"name": "pod-1"},
+				"status": map[string]any{"containerStatuses": []any{map[string]any{
+					"state": map[string]any{"terminated": map[string]any{"exitCode": f.exitCode}},
+				}}},
+			}},
+		})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/jobs-ns/pods/pod-1/log":
+		w.Write([]byte(f.logs))
+	case r.Method == http.MethodDelete:
+		f.deleted = true
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestExecutor(server *httptest.Server) *Executor {
+	return NewExecutor(Config{
+		APIServer: server.URL,
+		Token:     "secret",
+		Namespace: "jobs-ns",
+		PodTemplate: map[string]any{
+			"spec": map[string]any{
+				"containers": []any{map[string]any{"name": "runner", "image": "wps-runner:latest"}},
+			},
+		},
+		PollInterval: time.Millisecond,
+	})
+}
+
+func TestExecutor_Execute(t *testing.T) {
+	job := &model.Job{
+		UID:     uuid.New(),
+		Type:    "math",
+		Payload: model.MathJobPayload{Number: 4},
+	}
+
+	t.Run("successful job", func(t *testing.T) {
+		api := &fakeAPIServer{logs: "computing\n{\"result\": 6}\n"}
+		server := httptest.NewServer(api)
+		defer server.Close()
+
+		result, err := newTestExecutor(server).Execute(context.Background(), job, io.Discard)
+		assert.NoError(t, err)
+		assert.Equal(t, model.MathJobResult{Result: 6}, result)
+		assert.True(t, api.deleted)
+
+		// The job's payload is passed to the container
+		spec := api.created["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)
+		assert.Equal(t, "Never", spec["restartPolicy"])
+		env := spec["containers"].([]any)[0].(map[string]any)["env"].([]any)
+		assert.Contains(t, env, map[string]any{"name": "WPS_JOB_PAYLOAD", "value": `{"number":4}`})
+		assert.Contains(t, env, map[string]any{"name": "WPS_JOB_TYPE", "value": "math"})
+	})
+
+	t.Run("failed job", func(t *testing.T) {
+		api := &fakeAPIServer{failed: true, exitCode: 3, logs: "out of memory\n"}
+		server := httptest.NewServer(api)
+		defer server.Close()
+
+		_, err := newTestExecutor(server).Execute(context.Background(), job, io.Discard)
+		assert.Error(t, err)
+		assert.Equal(t, "kubernetes job failed with exit code 3: out of memory", err.Error())
+		assert.True(t, api.deleted)
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		_, err := newTestExecutor(server).Execute(context.Background(), job, io.Discard)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "creating kubernetes job")
+	})
+}
+
+func TestLoadPodTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "valid template",
+			content: `{"spec": {"containers": [{"name": "runner", "image": "busybox"}]}}`,
+		},
+		{
+			name:    "no containers",
+			content: `{"spec": {"containers": []}}`,
+			wantErr: "pod template has no containers",
+		},
+		{
+			name:    "invalid json",
+			content: `{`,
+			wantErr: "invalid pod template",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("template-%d.json", i))
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			_, err := LoadPodTemplate(path)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
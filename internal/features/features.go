@@ -0,0 +1,104 @@
+// Package features holds the flags that gate experimental behavior, so it
+// can be turned on per environment through configuration and toggled at
+// runtime without a rebuild.
+package features
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Flag names
+const (
+	// ResponseCompression gzips API responses for clients that accept it
+	ResponseCompression = "response-compression"
+)
+
+// Flag describes a feature flag and whether it is on unless configured
+// otherwise
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// Known lists every flag. Experimental behavior gets a flag here, defaulting
+// to off, and checks Set.Enabled before taking effect.
+var Known = []Flag{
+	{Name: ResponseCompression, Description: "gzip API responses for clients that accept it"},
+}
+
+// IsKnown reports whether name is a flag
+func IsKnown(name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+func lookup(name string) (Flag, bool) {
+	for _, f := range Known {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// Set holds the current state of every flag. It is safe for concurrent use.
+type Set struct {
+	mu      sync.RWMutex
+	enabled map[string]bool
+}
+
+// NewSet starts every flag at its default, except those given in overrides
+func NewSet(overrides map[string]bool) (*Set, error) {
+	s := &Set{enabled: make(map[string]bool, len(Known))}
+	for _, f := range Known {
+		s.enabled[f.Name] = f.Default
+	}
+	for name, enabled := range overrides {
+		if !IsKnown(name) {
+			return nil, fmt.Errorf("%w %q", ErrUnknownFlag, name)
+		}
+		s.enabled[name] = enabled
+	}
+	return s, nil
+}
+
+// Enabled reports whether the flag is on. Unknown flags are off.
+func (s *Set) Enabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled[name]
+}
+
+// Set turns the flag on or off
+func (s *Set) Set(name string, enabled bool) error {
+	if !IsKnown(name) {
+		return fmt.Errorf("%w %q", ErrUnknownFlag, name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled[name] = enabled
+	return nil
+}
+
+// Flags returns every flag and its current state, in the order of Known
+func (s *Set) Flags() []model.FeatureFlag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	flags := make([]model.FeatureFlag, 0, len(Known))
+	for _, f := range Known {
+		flags = append(flags, model.FeatureFlag{
+			Name:        f.Name,
+			Description: f.Description,
+			Enabled:     s.enabled[f.Name],
+			Default:     f.Default,
+		})
+	}
+	return flags
+}
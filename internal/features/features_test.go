@@ -0,0 +1,31 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+	s, err := NewSet(nil)
+	assert.NoError(t, err)
+	assert.False(t, s.Enabled(ResponseCompression))
+	assert.False(t, s.Enabled("nonexistent"))
+
+	s, err = NewSet(map[string]bool{ResponseCompression: true})
+	assert.NoError(t, err)
+	assert.True(t, s.Enabled(ResponseCompression))
+	assert.Equal(t, []model.FeatureFlag{{
+		Name:        ResponseCompression,
+		Description: "gzip API responses for clients that accept it",
+		Enabled:     true,
+	}}, s.Flags())
+
+	assert.NoError(t, s.Set(ResponseCompression, false))
+	assert.False(t, s.Enabled(ResponseCompression))
+
+	assert.ErrorIs(t, s.Set("nonexistent", true), ErrUnknownFlag)
+	_, err = NewSet(map[string]bool{"nonexistent": true})
+	assert.EqualError(t, err, `unknown feature flag "nonexistent"`)
+}
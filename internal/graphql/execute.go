@@ -0,0 +1,204 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Request is a GraphQL request, as POSTed
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Data is left out when the request
+// couldn't be executed at all, and is otherwise the fields queried, null
+// where they failed.
+type Response struct {
+	Data   any                        `json:"data,omitempty"`
+	Errors []gqlerrors.FormattedError `json:"errors,omitempty"`
+}
+
+// Execute parses, validates and runs a query
+func (s *Schema) Execute(ctx context.Context, req *Request) *Response {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return &Response{Errors: gqlerrors.FormatErrors(err)}
+	}
+	// graphql-go's other rules follow fragment spreads without checking for
+	// cycles, so they only run once there are none
+	if result := gql.ValidateDocument(&s.schema, doc, fragmentRules); !result.IsValid {
+		return &Response{Errors: result.Errors}
+	}
+	if result := gql.ValidateDocument(&s.schema, doc, nil); !result.IsValid {
+		return &Response{Errors: result.Errors}
+	}
+	if err := s.checkDepth(doc); err != nil {
+		return &Response{Errors: []gqlerrors.FormattedError{*err}}
+	}
+
+	ctx = context.WithValue(ctx, budgetKey{}, &budget{limit: s.maxResolves(), left: s.maxResolves()})
+	result := gql.Execute(gql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+	return &Response{Data: result.Data, Errors: result.Errors}
+}
+
+var fragmentRules = []gql.ValidationRuleFn{
+	gql.UniqueFragmentNamesRule,
+	gql.KnownFragmentNamesRule,
+	gql.NoFragmentCyclesRule,
+}
+
+func (s *Schema) maxDepth() int {
+	if s.MaxDepth > 0 {
+		return s.MaxDepth
+	}
+	return DefaultMaxDepth
+}
+
+func (s *Schema) maxResolves() int {
+	if s.MaxResolves > 0 {
+		return s.MaxResolves
+	}
+	return DefaultMaxResolves
+}
+
+// checkDepth rejects a document with an operation nesting fields more than
+// MaxDepth deep, counting through fragments. It runs once the document is
+// valid, so fragments exist and don't spread themselves.
+func (s *Schema) checkDepth(doc *ast.Document) *gqlerrors.FormattedError {
+	d := &depths{fragments: make(map[string]*ast.FragmentDefinition), known: make(map[string]int)}
+	for _, def := range doc.Definitions {
+		if f, ok := def.(*ast.FragmentDefinition); ok {
+			d.fragments[f.Name.Value] = f
+		}
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if field := d.tooDeep(op.SelectionSet, s.maxDepth()); field != nil {
+			err := gqlerrors.NewFormattedError(fmt.Sprintf("Query nests fields more than %d deep.", s.maxDepth()))
+			err.Locations = []location.SourceLocation{location.GetLocation(field.Loc.Source, field.Loc.Start)}
+			return &err
+		}
+	}
+	return nil
+}
+
+// depths measures how deeply selection sets nest fields. Each fragment is
+// measured once, so spreading fragments many times doesn't multiply the
+// work.
+type depths struct {
+	fragments map[string]*ast.FragmentDefinition
+	known     map[string]int
+}
+
+func (d *depths) of(set *ast.SelectionSet) int {
+	if set == nil {
+		return 0
+	}
+	deepest := 0
+	for _, sel := range set.Selections {
+		deepest = max(deepest, d.selection(sel))
+	}
+	return deepest
+}
+
+func (d *depths) selection(sel ast.Selection) int {
+	switch sel := sel.(type) {
+	case *ast.Field:
+		return 1 + d.of(sel.SelectionSet)
+	case *ast.InlineFragment:
+		return d.of(sel.SelectionSet)
+	case *ast.FragmentSpread:
+		name := sel.Name.Value
+		if depth, ok := d.known[name]; ok {
+			return depth
+		}
+		depth := 0
+		if f, ok := d.fragments[name]; ok {
+			depth = d.of(f.SelectionSet)
+		}
+		d.known[name] = depth
+		return depth
+	}
+	return 0
+}
+
+// tooDeep returns the first field nested past limit in set, or nil
+func (d *depths) tooDeep(set *ast.SelectionSet, limit int) *ast.Field {
+	if set == nil || d.of(set) <= limit {
+		return nil
+	}
+	for _, sel := range set.Selections {
+		if d.selection(sel) <= limit {
+			continue
+		}
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if limit == 0 {
+				return sel
+			}
+			return d.tooDeep(sel.SelectionSet, limit-1)
+		case *ast.InlineFragment:
+			return d.tooDeep(sel.SelectionSet, limit)
+		case *ast.FragmentSpread:
+			return d.tooDeep(d.fragments[sel.Name.Value].SelectionSet, limit)
+		}
+	}
+	return nil
+}
+
+type budgetKey struct{}
+
+// budget counts down the fields a query may still resolve
+type budget struct {
+	mu       sync.Mutex
+	limit    int
+	left     int
+	reported bool
+}
+
+// spend takes one field from the budget, reporting false once it has run
+// out. The first field refused fails with an error saying so and the rest
+// resolve to null, so a large query reports the limit once.
+func (b *budget) spend() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.left > 0 {
+		b.left--
+		return true, nil
+	}
+	if b.reported {
+		return false, nil
+	}
+	b.reported = true
+	return false, fmt.Errorf("Query resolves more than %d fields.", b.limit)
+}
+
+// counted charges each resolution of a field to the query's budget
+func counted(resolve gql.FieldResolveFn) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (any, error) {
+		if b, ok := p.Context.Value(budgetKey{}).(*budget); ok {
+			if ok, err := b.spend(); !ok {
+				return nil, err
+			}
+		}
+		return resolve(p)
+	}
+}
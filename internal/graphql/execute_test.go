@@ -0,0 +1,275 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLoad struct {
+	Slots int `json:"slots"`
+}
+
+type testTask struct {
+	ID       string            `json:"id"`
+	Status   string            `json:"status"`
+	Parent   string            `json:"parent,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Payload  any               `json:"payload"`
+	Tries    []int             `json:"tries,omitempty"`
+	Bytes    int64             `json:"bytes"`
+	Created  *time.Time        `json:"created_at"`
+	Internal string            `json:"-"`
+	*testLoad
+}
+
+var created = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+var testTasks = map[string]*testTask{
+	"a": {ID: "a", Status: "completed", Labels: map[string]string{"team": "x"}, Payload: map[string]any{"n": 1}, Tries: []int{1, 2}, Bytes: 5 << 30, Created: &created, testLoad: &testLoad{Slots: 2}},
+	"b": {ID: "b", Status: "failed", Parent: "a"},
+	"c": {ID: "c", Status: "pending", Parent: "b"},
+}
+
+func testSchema() *Schema {
+	s := NewSchema()
+	task := s.ObjectOf(testTask{})
+	task.AddFieldConfig("parent_task", &gql.Field{
+		Type: task,
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return testTasks[p.Source.(*testTask).Parent], nil
+		},
+	})
+	task.AddFieldConfig("children", &gql.Field{
+		Type: gql.NewList(task),
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			var children []*testTask
+			for _, id := range []string{"a", "b", "c"} {
+				if testTasks[id].Parent == p.Source.(*testTask).ID {
+					children = append(children, testTasks[id])
+				}
+			}
+			return children, nil
+		},
+	})
+	s.Query.AddFieldConfig("task", &gql.Field{
+		Type: task,
+		Args: gql.FieldConfigArgument{"id": {Type: gql.NewNonNull(gql.ID)}},
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return testTasks[p.Args["id"].(string)], nil
+		},
+	})
+	s.Query.AddFieldConfig("tasks", &gql.Field{
+		Type: gql.NewList(task),
+		Args: gql.FieldConfigArgument{
+			"status": {Type: gql.NewList(gql.String)},
+			"limit":  {Type: gql.Int, DefaultValue: 10},
+		},
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			var tasks []*testTask
+			for _, id := range []string{"a", "b", "c"} {
+				if len(tasks) == p.Args["limit"].(int) {
+					break
+				}
+				statuses, filtered := p.Args["status"].([]any)
+				if !filtered || contains(statuses, testTasks[id].Status) {
+					tasks = append(tasks, testTasks[id])
+				}
+			}
+			return tasks, nil
+		},
+	})
+	s.Query.AddFieldConfig("broken", &gql.Field{
+		Type: gql.String,
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return nil, errors.New("it broke")
+		},
+	})
+	s.Query.AddFieldConfig("echo", &gql.Field{
+		Type: JSON,
+		Args: gql.FieldConfigArgument{"value": {Type: JSON}},
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return p.Args["value"], nil
+		},
+	})
+	if err := s.Compile(); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func contains(list []any, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// run executes a query and returns the response as JSON
+func run(t *testing.T, s *Schema, query string, vars map[string]any) string {
+	t.Helper()
+	resp := s.Execute(context.Background(), &Request{Query: query, Variables: vars})
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestExecute(t *testing.T) {
+	s := testSchema()
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+		want  string
+	}{
+		{
+			name:  "fields in the order queried",
+			query: `{ task(id: "a") { status id __typename } }`,
+			want:  `{"data":{"task":{"status":"completed","id":"a","__typename":"testTask"}}}`,
+		},
+		{
+			name:  "reflected field types",
+			query: `query { task(id: "a") { labels payload tries bytes created_at slots } }`,
+			want:  `{"data":{"task":{"labels":{"team":"x"},"payload":{"n":1},"tries":[1,2],"bytes":5368709120,"created_at":"2024-01-02T03:04:05Z","slots":2}}}`,
+		},
+		{
+			name:  "nil values and embedded pointers",
+			query: `{ task(id: "b") { labels slots created_at } missing: task(id: "z") { id } }`,
+			want:  `{"data":{"task":{"labels":null,"slots":null,"created_at":null},"missing":null}}`,
+		},
+		{
+			name:  "nested traversal",
+			query: `{ task(id: "c") { parent_task { id parent_task { id children { id } } } } }`,
+			want:  `{"data":{"task":{"parent_task":{"id":"b","parent_task":{"id":"a","children":[{"id":"b"}]}}}}}`,
+		},
+		{
+			name:  "aliases and arguments",
+			query: `{ failed: tasks(status: ["failed", "pending"]) { id } first: tasks(limit: 1) { id } one: tasks(status: "failed") { id } }`,
+			want:  `{"data":{"failed":[{"id":"b"},{"id":"c"}],"first":[{"id":"a"}],"one":[{"id":"b"}]}}`,
+		},
+		{
+			name:  "variables",
+			query: `query Tasks($id: ID!, $limit: Int = 2) { task(id: $id) { id } tasks(limit: $limit) { id } }`,
+			vars:  map[string]any{"id": "b"},
+			want:  `{"data":{"task":{"id":"b"},"tasks":[{"id":"a"},{"id":"b"}]}}`,
+		},
+		{
+			name:  "fragments",
+			query: `{ task(id: "b") { ...Summary ... on testTask { parent } ... { status } } } fragment Summary on testTask { id status }`,
+			want:  `{"data":{"task":{"id":"b","status":"failed","parent":"a"}}}`,
+		},
+		{
+			name:  "merged selections",
+			query: `{ task(id: "c") { parent_task { id } parent_task { status } } }`,
+			want:  `{"data":{"task":{"parent_task":{"id":"b","status":"failed"}}}}`,
+		},
+		{
+			name:  "directives",
+			query: `query($full: Boolean!) { task(id: "a") { id status @include(if: $full) labels @skip(if: true) } }`,
+			vars:  map[string]any{"full": false},
+			want:  `{"data":{"task":{"id":"a"}}}`,
+		},
+		{
+			name:  "JSON arguments",
+			query: `{ echo(value: {status: failed, labels: {team: "x"}, n: [1, 2.5]}) }`,
+			want:  `{"data":{"echo":{"labels":{"team":"x"},"n":[1,2.5],"status":"failed"}}}`,
+		},
+		{
+			name:  "resolver errors",
+			query: `{ broken tasks(limit: 1) { id } }`,
+			want:  `{"data":{"broken":null,"tasks":[{"id":"a"}]},"errors":[{"message":"it broke","locations":[{"line":1,"column":3}],"path":["broken"]}]}`,
+		},
+		{
+			name:  "invalid argument values",
+			query: `{ tasks(limit: "lots") { id } }`,
+			want:  `{"errors":[{"message":"Argument \"limit\" has invalid value \"lots\".\nExpected type \"Int\", found \"lots\".","locations":[{"line":1,"column":16}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.JSONEq(t, tt.want, run(t, s, tt.query, tt.vars))
+		})
+	}
+}
+
+func TestExecute_Errors(t *testing.T) {
+	s := testSchema()
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+		want  string
+	}{
+		{"syntax", `{ task(id: "a") { id }`, nil, "Syntax Error GraphQL (1:23) Expected Name, found EOF\n\n1: { task(id: \"a\") { id }\n                         ^\n"},
+		{"unknown field", `{ task(id: "a") { name } }`, nil, `Cannot query field "name" on type "testTask".`},
+		{"missing selection", `{ task(id: "a") }`, nil, `Field "task" of type "testTask" must have a sub selection.`},
+		{"selection on scalar", `{ task(id: "a") { id { x } } }`, nil, `Field "id" of type "String" must not have a sub selection.`},
+		{"unknown argument", `{ task(id: "a", name: "x") { id } }`, nil, `Unknown argument "name" on field "task" of type "Query".`},
+		{"missing argument", `{ task { id } }`, nil, `Field "task" argument "id" of type "ID!" is required but not provided.`},
+		{"unknown fragment", `{ task(id: "a") { ...Missing } }`, nil, `Unknown fragment "Missing".`},
+		{"fragment cycle", `{ task(id: "a") { ...A } } fragment A on testTask { ...B } fragment B on testTask { ...A }`, nil, `Cannot spread fragment "A" within itself via B.`},
+		{"fragment on another type", `{ task(id: "a") { ...Q } } fragment Q on Query { broken }`, nil, `Fragment "Q" cannot be spread here as objects of type "testTask" can never be of type "Query".`},
+		{"undefined variable", `{ task(id: $id) { id } }`, nil, `Variable "$id" is not defined.`},
+		{"missing variable", `query($id: ID!) { task(id: $id) { id } }`, nil, `Variable "$id" of required type "ID!" was not provided.`},
+		{"invalid variable", `query($limit: Int) { tasks(limit: $limit) { id } }`, map[string]any{"limit": "lots"}, "Variable \"$limit\" got invalid value \"lots\".\nExpected type \"Int\", found \"lots\"."},
+		{"unknown directive", `{ broken @defer }`, nil, `Unknown directive "defer".`},
+		{"mutation", `mutation { broken }`, nil, `Schema is not configured for mutations`},
+		{"several operations", `query A { broken } query B { broken }`, nil, `Must provide operation name if query contains multiple operations.`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Execute(context.Background(), &Request{Query: tt.query, Variables: tt.vars})
+			assert.Nil(t, resp.Data)
+			if assert.NotEmpty(t, resp.Errors) {
+				assert.Equal(t, tt.want, resp.Errors[0].Message)
+			}
+		})
+	}
+}
+
+func TestExecute_OperationName(t *testing.T) {
+	s := testSchema()
+	resp := s.Execute(context.Background(), &Request{
+		Query:         `query A { task(id: "a") { id } } query B { task(id: "b") { id } }`,
+		OperationName: "B",
+	})
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"task":{"id":"b"}}}`, string(data))
+}
+
+func TestExecute_MaxDepth(t *testing.T) {
+	s := testSchema()
+	s.MaxDepth = 3
+	resp := s.Execute(context.Background(), &Request{Query: `{ task(id: "c") { parent_task { id } } }`})
+	assert.Empty(t, resp.Errors)
+
+	resp = s.Execute(context.Background(), &Request{Query: `{ task(id: "c") { parent_task { parent_task { id } } } }`})
+	if assert.Len(t, resp.Errors, 1) {
+		assert.Equal(t, "Query nests fields more than 3 deep.", resp.Errors[0].Message)
+		assert.Equal(t, []location.SourceLocation{{Line: 1, Column: 47}}, resp.Errors[0].Locations)
+	}
+}
+
+func TestExecute_MaxResolves(t *testing.T) {
+	s := testSchema()
+	s.MaxResolves = 5
+	assert.JSONEq(t, `{"data":{"tasks":[{"id":"a"},{"id":"b"},{"id":"c"}]}}`, run(t, s, `{ tasks { id } }`, nil))
+
+	resp := s.Execute(context.Background(), &Request{Query: `{ tasks { id status } }`})
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tasks":[{"id":"a","status":"completed"},{"id":"b","status":"failed"},{"id":null,"status":null}]}`, string(data))
+	if assert.Len(t, resp.Errors, 1) {
+		assert.Equal(t, "Query resolves more than 5 fields.", resp.Errors[0].Message)
+	}
+}
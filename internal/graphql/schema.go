@@ -0,0 +1,352 @@
+// Package graphql answers GraphQL queries with graphql-go. On top of it,
+// object types can be built from structs, so a schema can follow the API's
+// JSON types without restating them, and queries are limited in how deeply
+// they nest and how many fields they resolve.
+package graphql
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	// DefaultMaxDepth bounds how deeply a query may nest fields unless the
+	// schema says otherwise
+	DefaultMaxDepth = 12
+	// DefaultMaxResolves bounds how many fields a query may resolve unless
+	// the schema says otherwise, counting each field of every object in
+	// every list
+	DefaultMaxResolves = 10000
+)
+
+// JSON is a scalar for values of any shape. Values are written out as
+// encoding/json would write them, and arguments are taken as written, with
+// enum values as strings.
+var JSON = gql.NewScalar(gql.ScalarConfig{
+	Name:         "JSON",
+	Description:  "Any JSON value.",
+	Serialize:    func(v any) any { return v },
+	ParseValue:   func(v any) any { return v },
+	ParseLiteral: parseLiteral,
+})
+
+// Long is an integer outside Int's 32 bits, such as a size in bytes
+var Long = gql.NewScalar(gql.ScalarConfig{
+	Name:        "Long",
+	Description: "A 64-bit integer.",
+	Serialize:   func(v any) any { return v },
+	ParseValue: func(v any) any {
+		if f, ok := v.(float64); ok && f == float64(int64(f)) {
+			return int64(f)
+		}
+		return nil
+	},
+	ParseLiteral: func(v ast.Value) any {
+		if v, ok := v.(*ast.IntValue); ok {
+			if n, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+				return n
+			}
+		}
+		return nil
+	},
+})
+
+// Schema is what can be queried. Objects are added to Query and built with
+// NewObject and ObjectOf, then the schema is compiled before it is used.
+type Schema struct {
+	Query *gql.Object
+	// MaxDepth bounds how deeply queries may nest fields, DefaultMaxDepth
+	// when zero
+	MaxDepth int
+	// MaxResolves bounds how many fields a query may resolve,
+	// DefaultMaxResolves when zero
+	MaxResolves int
+
+	schema  gql.Schema
+	objects map[reflect.Type]gql.Output
+	// fields holds the fields of every object the schema built, so their
+	// resolvers can be counted against MaxResolves
+	fields []gql.Fields
+}
+
+// NewSchema returns a schema whose Query has no fields yet
+func NewSchema() *Schema {
+	s := &Schema{objects: make(map[reflect.Type]gql.Output)}
+	s.Query = s.NewObject("Query")
+	return s
+}
+
+// NewObject returns an object with no fields yet. Fields are added with
+// AddFieldConfig and must have a resolver.
+func (s *Schema) NewObject(name string) *gql.Object {
+	fields := gql.Fields{}
+	s.fields = append(s.fields, fields)
+	return gql.NewObject(gql.ObjectConfig{Name: name, Fields: fields})
+}
+
+// Compile checks the schema once every field has been added
+func (s *Schema) Compile() error {
+	for _, fields := range s.fields {
+		for name, f := range fields {
+			if f.Resolve == nil {
+				return fmt.Errorf("graphql: field %s has no resolver", name)
+			}
+			f.Resolve = counted(f.Resolve)
+		}
+	}
+	// Every scalar is known, so variables may be declared with any of them
+	schema, err := gql.NewSchema(gql.SchemaConfig{
+		Query: s.Query,
+		Types: []gql.Type{gql.Int, gql.Float, gql.String, gql.Boolean, gql.ID, JSON, Long},
+	})
+	if err != nil {
+		return fmt.Errorf("graphql: %w", err)
+	}
+	s.schema = schema
+	return nil
+}
+
+var (
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+	rawMessage    = reflect.TypeFor[json.RawMessage]()
+)
+
+// ObjectOf returns the object for a struct type, such as that of
+// prototype, building it from the struct's fields the first time. Fields
+// are named and left out as encoding/json would, and hold the types their
+// Go types map to: structs to objects, slices to lists, and maps,
+// interfaces and raw JSON to JSON. Types that
+// marshal as text, such as times and UUIDs, are strings. Fields added to
+// the object are seen wherever the struct type appears.
+func (s *Schema) ObjectOf(prototype any) *gql.Object {
+	t := reflect.TypeOf(prototype)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	obj, ok := s.object(t).(*gql.Object)
+	if !ok {
+		panic(fmt.Sprintf("graphql: %s has no fields", t))
+	}
+	return obj
+}
+
+// object returns the object for a struct type, or JSON if it has no fields
+func (s *Schema) object(t reflect.Type) gql.Output {
+	if obj, ok := s.objects[t]; ok {
+		return obj
+	}
+	fields := gql.Fields{}
+	obj := gql.NewObject(gql.ObjectConfig{Name: t.Name(), Fields: fields})
+	// Register it before its fields, which may refer back to it
+	s.objects[t] = obj
+	s.addFields(fields, t, nil)
+	if len(fields) == 0 {
+		s.objects[t] = JSON
+		return JSON
+	}
+	s.fields = append(s.fields, fields)
+	return obj
+}
+
+func (s *Schema) addFields(fields gql.Fields, t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(fields, embedded, fieldIndex)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		typ, convert := s.typeOf(sf.Type)
+		fields[name] = &gql.Field{Type: typ, Resolve: structField(fieldIndex, convert)}
+	}
+}
+
+// converter turns a struct field's value into what graphql-go expects of
+// its type: plain strings, numbers and booleans for scalars, so named
+// types serialize as their JSON would, and nil for nil pointers
+type converter func(v reflect.Value) any
+
+// typeOf maps a Go type to a schema type and how its values are converted
+func (s *Schema) typeOf(t reflect.Type) (gql.Output, converter) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == rawMessage:
+		return JSON, asIs
+	case t.Implements(textMarshaler) || reflect.PointerTo(t).Implements(textMarshaler):
+		return gql.String, nonNil(marshalText)
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return s.object(t), asIs
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return gql.String, nonNil(func(v reflect.Value) any {
+				return base64.StdEncoding.EncodeToString(v.Bytes())
+			})
+		}
+		elem, convert := s.typeOf(t.Elem())
+		return gql.NewList(elem), nonNil(list(elem, convert))
+	case reflect.Array:
+		elem, convert := s.typeOf(t.Elem())
+		return gql.NewList(elem), nonNil(list(elem, convert))
+	case reflect.String:
+		return gql.String, nonNil(func(v reflect.Value) any { return v.String() })
+	case reflect.Bool:
+		return gql.Boolean, nonNil(func(v reflect.Value) any { return v.Bool() })
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return gql.Int, nonNil(func(v reflect.Value) any { return v.Int() })
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return gql.Int, nonNil(func(v reflect.Value) any { return int64(v.Uint()) })
+	case reflect.Int64:
+		return Long, nonNil(func(v reflect.Value) any { return v.Int() })
+	case reflect.Uint, reflect.Uint64:
+		return Long, nonNil(func(v reflect.Value) any { return v.Uint() })
+	case reflect.Float32, reflect.Float64:
+		return gql.Float, nonNil(func(v reflect.Value) any { return v.Float() })
+	}
+	return JSON, asIs
+}
+
+// asIs hands the value over unchanged, for objects and JSON
+func asIs(v reflect.Value) any {
+	if isNil(v) {
+		return nil
+	}
+	return v.Interface()
+}
+
+// nonNil applies convert to the value pointers and interfaces lead to
+func nonNil(convert converter) converter {
+	return func(v reflect.Value) any {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		return convert(v)
+	}
+}
+
+// list converts each item of a list of scalars, and leaves a list of
+// objects for graphql-go to read
+func list(elem gql.Output, convert converter) converter {
+	return func(v reflect.Value) any {
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if _, ok := elem.(*gql.Object); ok {
+			return v.Interface()
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = convert(v.Index(i))
+		}
+		return items
+	}
+}
+
+func marshalText(v reflect.Value) any {
+	if !v.Type().Implements(textMarshaler) {
+		if !v.CanAddr() {
+			copied := reflect.New(v.Type())
+			copied.Elem().Set(v)
+			v = copied.Elem()
+		}
+		v = v.Addr()
+	}
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil
+	}
+	return string(text)
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return !v.IsValid()
+}
+
+// structField resolves a reflected field by reading it from the struct
+func structField(index []int, convert converter) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (any, error) {
+		v := reflect.ValueOf(p.Source)
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		f, err := v.FieldByIndexErr(index)
+		if err != nil {
+			// Through a nil embedded pointer
+			return nil, nil
+		}
+		return convert(f), nil
+	}
+}
+
+// parseLiteral reads a JSON argument written in the query. Variables
+// inside it aren't supported and read as null.
+func parseLiteral(v ast.Value) any {
+	switch v := v.(type) {
+	case *ast.StringValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.IntValue:
+		if n, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			return n
+		}
+	case *ast.FloatValue:
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return f
+		}
+	case *ast.ListValue:
+		items := make([]any, len(v.Values))
+		for i, item := range v.Values {
+			items[i] = parseLiteral(item)
+		}
+		return items
+	case *ast.ObjectValue:
+		obj := make(map[string]any, len(v.Fields))
+		for _, f := range v.Fields {
+			obj[f.Name.Value] = parseLiteral(f.Value)
+		}
+		return obj
+	}
+	return nil
+}
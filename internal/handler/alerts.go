@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/google/uuid"
+)
+
+// AlertsHandler manages alert rules and lists the alerts they fired
+type AlertsHandler struct {
+	service service.AlertsService
+}
+
+func NewAlertsHandler(service service.AlertsService) *AlertsHandler {
+	return &AlertsHandler{service: service}
+}
+
+func (h *AlertsHandler) CreateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAlertRule(w, r)
+	if !ok {
+		return
+	}
+	rule, err := h.service.CreateAlertRule(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (h *AlertsHandler) ListAlertRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.service.ListAlertRules(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rules)
+}
+
+func (h *AlertsHandler) GetAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(extractLastPathSegment(r.URL.Path))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rule, err := h.service.GetAlertRule(r.Context(), id)
+	if err != nil {
+		writeAlertRuleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateAlertRuleHandler replaces a rule, which starts counting afresh
+func (h *AlertsHandler) UpdateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(extractLastPathSegment(r.URL.Path))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req, ok := decodeAlertRule(w, r)
+	if !ok {
+		return
+	}
+	rule, err := h.service.UpdateAlertRule(r.Context(), id, req)
+	if err != nil {
+		writeAlertRuleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (h *AlertsHandler) DeleteAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(extractLastPathSegment(r.URL.Path))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.service.DeleteAlertRule(r.Context(), id); err != nil {
+		writeAlertRuleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAlertsHandler lists the alerts fired, newest first
+func (h *AlertsHandler) ListAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	alerts, err := h.service.ListAlerts(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// decodeAlertRule reads and validates a rule from the request body,
+// responding 400 if it is invalid
+func decodeAlertRule(w http.ResponseWriter, r *http.Request) (*model.AlertRuleRequest, bool) {
+	var req model.AlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequest(w, model.DecodeError(err))
+		return nil, false
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(w, err)
+		return nil, false
+	}
+	return &req, true
+}
+
+func writeAlertRuleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrAlertRuleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAlertsService is a mock implementation of service.AlertsService
+type MockAlertsService struct {
+	mock.Mock
+}
+
+func (m *MockAlertsService) CreateAlertRule(ctx context.Context, req *model.AlertRuleRequest) (*model.AlertRule, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AlertRule), args.Error(1)
+}
+
+func (m *MockAlertsService) ListAlertRules(ctx context.Context) ([]*model.AlertRule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.AlertRule), args.Error(1)
+}
+
+func (m *MockAlertsService) GetAlertRule(ctx context.Context, id uuid.UUID) (*model.AlertRule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AlertRule), args.Error(1)
+}
+
+func (m *MockAlertsService) UpdateAlertRule(ctx context.Context, id uuid.UUID, req *model.AlertRuleRequest) (*model.AlertRule, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AlertRule), args.Error(1)
+}
+
+func (m *MockAlertsService) DeleteAlertRule(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAlertsService) ListAlerts(ctx context.Context) ([]*model.Alert, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.Alert), args.Error(1)
+}
+
+const validAlertRule = `{"name":"math failures","job_type":"math","threshold":5,"window":"10m","webhook_url":"https://alerts.internal/hook"}`
+
+func TestCreateAlertRuleHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(m *MockAlertsService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "valid rule",
+			body: validAlertRule,
+			setupMock: func(m *MockAlertsService) {
+				m.On("CreateAlertRule", mock.Anything, mock.MatchedBy(func(req *model.AlertRuleRequest) bool {
+					return req.Name == "math failures" && req.Threshold == 5
+				})).Return(&model.AlertRule{ID: uuid.New(), Name: "math failures"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invalid rule",
+			body:           `{"name":"math failures","window":"10m","webhook_url":"alerts"}`,
+			setupMock:      func(m *MockAlertsService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"webhook_url: must be an http or https URL","fields":[{"field":"webhook_url","message":"must be an http or https URL"}]}` + "\n",
+		},
+		{
+			name:           "malformed body",
+			body:           `{"threshold":"five"}`,
+			setupMock:      func(m *MockAlertsService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"threshold: expected integer","fields":[{"field":"threshold","message":"expected integer"}]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAlertsService)
+			tt.setupMock(mockService)
+			handler := NewAlertsHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/alert-rules", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.CreateAlertRuleHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAlertRuleHandlers_NotFound(t *testing.T) {
+	id := uuid.New()
+	mockService := new(MockAlertsService)
+	mockService.On("GetAlertRule", mock.Anything, id).Return(nil, service.ErrAlertRuleNotFound)
+	mockService.On("UpdateAlertRule", mock.Anything, id, mock.Anything).Return(nil, service.ErrAlertRuleNotFound)
+	mockService.On("DeleteAlertRule", mock.Anything, id).Return(service.ErrAlertRuleNotFound)
+	handler := NewAlertsHandler(mockService)
+	path := "/admin/alert-rules/" + id.String()
+
+	w := httptest.NewRecorder()
+	handler.GetAlertRuleHandler(w, httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.UpdateAlertRuleHandler(w, httptest.NewRequest(http.MethodPut, path, strings.NewReader(validAlertRule)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.DeleteAlertRuleHandler(w, httptest.NewRequest(http.MethodDelete, path, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.GetAlertRuleHandler(w, httptest.NewRequest(http.MethodGet, "/admin/alert-rules/nope", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestListAlertsHandler(t *testing.T) {
+	firedAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	alerts := []*model.Alert{{ID: uuid.New(), RuleName: "math failures", Count: 6, FiredAt: firedAt, Error: "webhook responded 500 Internal Server Error: "}}
+	mockService := new(MockAlertsService)
+	mockService.On("ListAlerts", mock.Anything).Return(alerts, nil)
+	handler := NewAlertsHandler(mockService)
+
+	w := httptest.NewRecorder()
+	handler.ListAlertsHandler(w, httptest.NewRequest(http.MethodGet, "/admin/alerts", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got []*model.Alert
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, alerts, got)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// AnomaliesHandler lists job runs whose durations were far from usual
+type AnomaliesHandler struct {
+	service service.AnomaliesService
+}
+
+func NewAnomaliesHandler(service service.AnomaliesService) *AnomaliesHandler {
+	return &AnomaliesHandler{service: service}
+}
+
+func (h *AnomaliesHandler) ListAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	jobType := r.URL.Query().Get("type")
+	if jobType != "" && !model.IsBuiltinJobType(jobType) {
+		http.Error(w, fmt.Sprintf("unknown job type %q", jobType), http.StatusBadRequest)
+		return
+	}
+	anomalies, err := h.service.ListAnomalies(r.Context(), jobType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(anomalies)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAnomaliesService is a mock implementation of service.AnomaliesService
+type MockAnomaliesService struct {
+	mock.Mock
+}
+
+func (m *MockAnomaliesService) ListAnomalies(ctx context.Context, jobType string) ([]model.DurationAnomaly, error) {
+	args := m.Called(ctx, jobType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.DurationAnomaly), args.Error(1)
+}
+
+func TestListAnomaliesHandler(t *testing.T) {
+	anomalies := []model.DurationAnomaly{{
+		JobUID:          uuid.New(),
+		JobType:         "math",
+		DurationSeconds: 2,
+		MeanSeconds:     1,
+		StdDevSeconds:   0.1,
+		Deviations:      10,
+		DetectedAt:      time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	}}
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *MockAnomaliesService)
+		wantStatus int
+		want       []model.DurationAnomaly
+	}{
+		{
+			name: "every type",
+			setupMock: func(m *MockAnomaliesService) {
+				m.On("ListAnomalies", mock.Anything, "").Return(anomalies, nil)
+			},
+			wantStatus: http.StatusOK,
+			want:       anomalies,
+		},
+		{
+			name:  "one type",
+			query: "?type=sleep",
+			setupMock: func(m *MockAnomaliesService) {
+				m.On("ListAnomalies", mock.Anything, "sleep").Return([]model.DurationAnomaly{}, nil)
+			},
+			wantStatus: http.StatusOK,
+			want:       []model.DurationAnomaly{},
+		},
+		{
+			name:       "unknown type",
+			query:      "?type=email",
+			setupMock:  func(m *MockAnomaliesService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAnomaliesService)
+			tt.setupMock(mockService)
+			handler := NewAnomaliesHandler(mockService)
+
+			w := httptest.NewRecorder()
+			handler.ListAnomaliesHandler(w, httptest.NewRequest(http.MethodGet, "/jobs/anomalies"+tt.query, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.want != nil {
+				var got []model.DurationAnomaly
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, tt.want, got)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
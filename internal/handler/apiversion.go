@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIVersionHeader names the API version a request asks for on
+// unversioned routes, and the version that served a response
+const APIVersionHeader = "API-Version"
+
+// APIVersion tags responses with the API version serving them
+func APIVersion(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(APIVersionHeader, version)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Negotiate serves requests to unversioned routes with the API version
+// named in their API-Version header, "v2" or just "2", or with fallback
+// when they name none
+func Negotiate(versions map[string]http.Handler, fallback string) http.Handler {
+	supported := slices.Sorted(maps.Keys(versions))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := r.Header.Get(APIVersionHeader)
+		if version == "" {
+			version = fallback
+		}
+		if !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+		h, ok := versions[version]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unsupported API version %q; supported versions are %s",
+				r.Header.Get(APIVersionHeader), strings.Join(supported, ", ")), http.StatusBadRequest)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Deprecation describes a deprecated route
+type Deprecation struct {
+	// Since is when the route was deprecated
+	Since time.Time
+	// Sunset is when the route will be removed, or zero if no date is set
+	Sunset time.Time
+	// Successor returns the path replacing the requested one
+	Successor func(path string) string
+}
+
+// Deprecated adds the Deprecation (RFC 9745) and Sunset (RFC 8594) headers
+// to responses, with a Link to the route that replaces the one requested
+func Deprecated(d Deprecation) func(http.Handler) http.Handler {
+	since := "@" + strconv.FormatInt(d.Since.Unix(), 10)
+	var sunset string
+	if !d.Sunset.IsZero() {
+		sunset = d.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", since)
+			if sunset != "" {
+				w.Header().Set("Sunset", sunset)
+			}
+			if d.Successor != nil {
+				w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", d.Successor(r.URL.Path)))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
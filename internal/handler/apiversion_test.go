@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	serve := func(version string) http.Handler {
+		return APIVersion(version)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(version))
+		}))
+	}
+	h := Negotiate(map[string]http.Handler{"v1": serve("v1"), "v2": serve("v2")}, "v1")
+
+	tests := []struct {
+		requested string
+		status    int
+		served    string
+	}{
+		{requested: "", status: http.StatusOK, served: "v1"},
+		{requested: "v2", status: http.StatusOK, served: "v2"},
+		{requested: "2", status: http.StatusOK, served: "v2"},
+		{requested: "v3", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+		if tt.requested != "" {
+			req.Header.Set(APIVersionHeader, tt.requested)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.requested)
+		if tt.status == http.StatusOK {
+			assert.Equal(t, tt.served, w.Header().Get(APIVersionHeader))
+			assert.Equal(t, tt.served, w.Body.String())
+		} else {
+			assert.Contains(t, w.Body.String(), `unsupported API version "v3"; supported versions are v1, v2`)
+		}
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	d := Deprecation{
+		Since:     time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		Successor: func(path string) string { return "/v1" + path },
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/abc", nil)
+	w := httptest.NewRecorder()
+	Deprecated(d)(next).ServeHTTP(w, req)
+	assert.Equal(t, "@1719792000", w.Header().Get("Deprecation"))
+	assert.Equal(t, `</v1/jobs/abc>; rel="successor-version"`, w.Header().Get("Link"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+
+	d.Sunset = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	w = httptest.NewRecorder()
+	Deprecated(d)(next).ServeHTTP(w, req)
+	assert.Equal(t, "Wed, 01 Jan 2025 00:00:00 GMT", w.Header().Get("Sunset"))
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/google/uuid"
+)
+
+type ArtifactsHandler struct {
+	service service.ArtifactsService
+}
+
+func NewArtifactsHandler(service service.ArtifactsService) *ArtifactsHandler {
+	return &ArtifactsHandler{service: service}
+}
+
+// CreateArtifactHandler stores the request body as an artifact of the job
+// named by ?job_uid, called ?name and typed by the Content-Type header
+func (h *ArtifactsHandler) CreateArtifactHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	jobID := query.Get("job_uid")
+	if _, err := uuid.Parse(jobID); err != nil {
+		http.Error(w, "invalid job_uid: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	name := query.Get("name")
+	if err := model.ValidateArtifactName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	artifact, err := h.service.CreateArtifact(r.Context(), jobID, name, r.Header.Get("Content-Type"), r.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrJobNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrNoBlobStore):
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+		case errors.Is(err, model.ErrArtifactTooLarge):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(artifact)
+}
+
+// ListArtifactsHandler lists artifacts, only those of one job with ?job_uid
+func (h *ArtifactsHandler) ListArtifactsHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := r.URL.Query().Get("job_uid")
+	if jobID != "" {
+		if _, err := uuid.Parse(jobID); err != nil {
+			http.Error(w, "invalid job_uid: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	artifacts, err := h.service.ListArtifacts(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, service.ErrJobNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(artifacts)
+}
+
+// GetArtifactHandler downloads an artifact
+func (h *ArtifactsHandler) GetArtifactHandler(w http.ResponseWriter, r *http.Request) {
+	id := extractLastPathSegment(r.URL.Path)
+	url, err := h.service.ArtifactURL(r.Context(), id)
+	if err != nil {
+		writeArtifactError(w, err)
+		return
+	}
+	if url != "" {
+		http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+		return
+	}
+
+	artifact, body, err := h.service.GetArtifact(r.Context(), id)
+	if err != nil {
+		writeArtifactError(w, err)
+		return
+	}
+	defer body.Close()
+
+	contentType := artifact.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(artifact.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": artifact.Name}))
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+func (h *ArtifactsHandler) DeleteArtifactHandler(w http.ResponseWriter, r *http.Request) {
+	id := extractLastPathSegment(r.URL.Path)
+	if err := h.service.DeleteArtifact(r.Context(), id); err != nil {
+		writeArtifactError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeArtifactError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrArtifactNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockArtifactsService is a mock implementation of service.ArtifactsService
+type MockArtifactsService struct {
+	mock.Mock
+}
+
+func (m *MockArtifactsService) CreateArtifact(ctx context.Context, jobID, name, contentType string, content io.Reader) (*model.Artifact, error) {
+	data, _ := io.ReadAll(content)
+	args := m.Called(ctx, jobID, name, contentType, string(data))
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Artifact), args.Error(1)
+}
+
+func (m *MockArtifactsService) ListArtifacts(ctx context.Context, jobID string) ([]*model.Artifact, error) {
+	args := m.Called(ctx, jobID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Artifact), args.Error(1)
+}
+
+func (m *MockArtifactsService) GetArtifact(ctx context.Context, id string) (*model.Artifact, io.ReadCloser, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*model.Artifact), io.NopCloser(strings.NewReader(args.String(1))), args.Error(2)
+}
+
+func (m *MockArtifactsService) ArtifactURL(ctx context.Context, id string) (string, error) {
+	args := m.Called(ctx, id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockArtifactsService) DeleteArtifact(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestCreateArtifactHandler(t *testing.T) {
+	jobID := uuid.New()
+	artifact := &model.Artifact{ID: "a1", JobUID: jobID, Name: "report.pdf", ContentType: "application/pdf", Size: 4, CreatedAt: time.Now()}
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(m *MockArtifactsService)
+		expectedStatus int
+	}{
+		{
+			name:  "created",
+			query: "?job_uid=" + jobID.String() + "&name=report.pdf",
+			setupMock: func(m *MockArtifactsService) {
+				m.On("CreateArtifact", mock.Anything, jobID.String(), "report.pdf", "application/pdf", "%PDF").Return(artifact, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing job",
+			query:          "?name=report.pdf",
+			setupMock:      func(m *MockArtifactsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing name",
+			query:          "?job_uid=" + jobID.String(),
+			setupMock:      func(m *MockArtifactsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "unknown job",
+			query: "?job_uid=" + jobID.String() + "&name=report.pdf",
+			setupMock: func(m *MockArtifactsService) {
+				m.On("CreateArtifact", mock.Anything, jobID.String(), "report.pdf", mock.Anything, mock.Anything).Return(nil, service.ErrJobNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:  "storage disabled",
+			query: "?job_uid=" + jobID.String() + "&name=report.pdf",
+			setupMock: func(m *MockArtifactsService) {
+				m.On("CreateArtifact", mock.Anything, jobID.String(), "report.pdf", mock.Anything, mock.Anything).Return(nil, service.ErrNoBlobStore)
+			},
+			expectedStatus: http.StatusNotImplemented,
+		},
+		{
+			name:  "too large",
+			query: "?job_uid=" + jobID.String() + "&name=report.pdf",
+			setupMock: func(m *MockArtifactsService) {
+				m.On("CreateArtifact", mock.Anything, jobID.String(), "report.pdf", mock.Anything, mock.Anything).Return(nil, model.ErrArtifactTooLarge)
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockArtifactsService)
+			tt.setupMock(mockService)
+			handler := NewArtifactsHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/artifacts"+tt.query, strings.NewReader("%PDF"))
+			req.Header.Set("Content-Type", "application/pdf")
+			w := httptest.NewRecorder()
+
+			handler.CreateArtifactHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListArtifactsHandler(t *testing.T) {
+	mockService := new(MockArtifactsService)
+	handler := NewArtifactsHandler(mockService)
+	jobID := uuid.New()
+	unknownID := uuid.New()
+
+	mockService.On("ListArtifacts", mock.Anything, jobID.String()).
+		Return([]*model.Artifact{{ID: "a1", JobUID: jobID, Name: "report.pdf", Size: 4}}, nil)
+	mockService.On("ListArtifacts", mock.Anything, "").Return([]*model.Artifact{}, nil)
+	mockService.On("ListArtifacts", mock.Anything, unknownID.String()).Return(nil, service.ErrJobNotFound)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "by job", query: "?job_uid=" + jobID.String(), expectedStatus: http.StatusOK, expectedBody: `"name":"report.pdf"`},
+		{name: "all", expectedStatus: http.StatusOK, expectedBody: "[]"},
+		{name: "unknown job", query: "?job_uid=" + unknownID.String(), expectedStatus: http.StatusNotFound},
+		{name: "invalid job", query: "?job_uid=nope", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/artifacts"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.ListArtifactsHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestGetArtifactHandler(t *testing.T) {
+	mockService := new(MockArtifactsService)
+	handler := NewArtifactsHandler(mockService)
+
+	mockService.On("GetArtifact", mock.Anything, "a1").
+		Return(&model.Artifact{ID: "a1", Name: "report.pdf", ContentType: "application/pdf", Size: 4}, "%PDF", nil)
+	mockService.On("ArtifactURL", mock.Anything, "a1").Return("", nil)
+	mockService.On("ArtifactURL", mock.Anything, "s3").Return("https://s3.example.com/a?X-Amz-Signature=abc", nil)
+	mockService.On("ArtifactURL", mock.Anything, "missing").Return("", service.ErrArtifactNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/artifacts/a1", nil)
+	w := httptest.NewRecorder()
+	handler.GetArtifactHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "%PDF", w.Body.String())
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=report.pdf", w.Header().Get("Content-Disposition"))
+
+	req = httptest.NewRequest(http.MethodGet, "/artifacts/s3", nil)
+	w = httptest.NewRecorder()
+	handler.GetArtifactHandler(w, req)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Equal(t, "https://s3.example.com/a?X-Amz-Signature=abc", w.Header().Get("Location"))
+
+	req = httptest.NewRequest(http.MethodGet, "/artifacts/missing", nil)
+	w = httptest.NewRecorder()
+	handler.GetArtifactHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteArtifactHandler(t *testing.T) {
+	mockService := new(MockArtifactsService)
+	handler := NewArtifactsHandler(mockService)
+
+	mockService.On("DeleteArtifact", mock.Anything, "a1").Return(nil)
+	mockService.On("DeleteArtifact", mock.Anything, "missing").Return(service.ErrArtifactNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/artifacts/a1", nil)
+	w := httptest.NewRecorder()
+	handler.DeleteArtifactHandler(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/artifacts/missing", nil)
+	w = httptest.NewRecorder()
+	handler.DeleteArtifactHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// ClusterHandler serves the endpoint instances in a cluster gossip through
+type ClusterHandler struct {
+	service service.ClusterService
+}
+
+func NewClusterHandler(service service.ClusterService) *ClusterHandler {
+	return &ClusterHandler{service: service}
+}
+
+func (h *ClusterHandler) GossipHandler(w http.ResponseWriter, r *http.Request) {
+	var gossip model.Gossip
+	if err := json.NewDecoder(r.Body).Decode(&gossip); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reply, err := h.service.Gossip(r.Context(), &gossip)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(reply)
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockClusterService is a mock implementation of service.ClusterService
+type MockClusterService struct {
+	mock.Mock
+}
+
+func (m *MockClusterService) Gossip(ctx context.Context, gossip *model.Gossip) (*model.Gossip, error) {
+	args := m.Called(ctx, gossip)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Gossip), args.Error(1)
+}
+
+func TestGossipHandler(t *testing.T) {
+	mockService := new(MockClusterService)
+	handler := NewClusterHandler(mockService)
+
+	in := &model.Gossip{Members: []model.Member{{Node: "node-a", Heartbeat: 7}}}
+	mockService.On("Gossip", mock.Anything, in).Return(&model.Gossip{Members: []model.Member{
+		{Node: "node-b", Heartbeat: 3, Load: model.NodeLoad{QueueDepth: 2}},
+		{Node: "node-a", Heartbeat: 7},
+	}}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/cluster/gossip", strings.NewReader(`{"members": [{"node": "node-a", "heartbeat": 7}]}`))
+	w := httptest.NewRecorder()
+	handler.GossipHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got model.Gossip
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Len(t, got.Members, 2)
+	assert.Equal(t, 2, got.Members[0].Load.QueueDepth)
+	mockService.AssertExpectations(t)
+
+	req = httptest.NewRequest(http.MethodPost, "/cluster/gossip", strings.NewReader(`{`))
+	w = httptest.NewRecorder()
+	handler.GossipHandler(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
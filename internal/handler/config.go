@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// ConfigHandler serves the admin endpoint that checks a candidate
+// configuration before it's deployed
+type ConfigHandler struct {
+	service service.ConfigService
+}
+
+func NewConfigHandler(service service.ConfigService) *ConfigHandler {
+	return &ConfigHandler{service: service}
+}
+
+// PreviewConfigHandler answers with how the candidate configuration would be
+// read. An invalid configuration is still a successful preview, reported
+// with valid set to false.
+func (h *ConfigHandler) PreviewConfigHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.PreviewConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Settings == nil {
+		http.Error(w, "settings is required", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.service.PreviewConfig(r.Context(), req.Settings)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(preview)
+}
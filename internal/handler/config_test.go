@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockConfigService is a mock implementation of service.ConfigService
+type MockConfigService struct {
+	mock.Mock
+}
+
+func (m *MockConfigService) PreviewConfig(ctx context.Context, settings map[string]string) (*model.ConfigPreview, error) {
+	args := m.Called(ctx, settings)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ConfigPreview), args.Error(1)
+}
+
+func TestPreviewConfigHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(m *MockConfigService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "valid config",
+			body: `{"settings": {"WPS_WORKERS": "4"}}`,
+			setupMock: func(m *MockConfigService) {
+				m.On("PreviewConfig", mock.Anything, map[string]string{"WPS_WORKERS": "4"}).
+					Return(&model.ConfigPreview{Valid: true, Config: map[string]int{"Workers": 4}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"valid":true,"config":{"Workers":4}}`,
+		},
+		{
+			name: "invalid config",
+			body: `{"settings": {"WPS_WORKERS": "four"}}`,
+			setupMock: func(m *MockConfigService) {
+				m.On("PreviewConfig", mock.Anything, map[string]string{"WPS_WORKERS": "four"}).
+					Return(&model.ConfigPreview{Errors: []string{"WPS_WORKERS: invalid integer"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"valid":false,"errors":["WPS_WORKERS: invalid integer"]}`,
+		},
+		{
+			name:           "missing settings",
+			body:           `{}`,
+			setupMock:      func(m *MockConfigService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "settings is required",
+		},
+		{
+			name:           "settings that aren't strings",
+			body:           `{"settings": {"WPS_WORKERS": 4}}`,
+			setupMock:      func(m *MockConfigService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockConfigService)
+			tt.setupMock(mockService)
+			handler := NewConfigHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/config/preview", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.PreviewConfigHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+	cfg := CORSConfig{
+		Origins:        []string{"https://dash.example.com", "https://*.internal.example.com"},
+		Methods:        []string{"GET", "POST"},
+		Headers:        []string{"Content-Type", "X-Tenant-ID"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	serve := func(cfg CORSConfig, method, origin string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/jobs", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		CORS(cfg)(next).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("allowed origin", func(t *testing.T) {
+		w := serve(cfg, http.MethodGet, "https://dash.example.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://dash.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "ETag", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"))
+	})
+
+	t.Run("subdomain", func(t *testing.T) {
+		w := serve(cfg, http.MethodGet, "https://ops.internal.example.com", nil)
+		assert.Equal(t, "https://ops.internal.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		w = serve(cfg, http.MethodGet, "http://ops.internal.example.com", nil)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		w := serve(cfg, http.MethodGet, "https://evil.example.org", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("same origin", func(t *testing.T) {
+		w := serve(cfg, http.MethodGet, "", nil)
+		assert.Equal(t, "OK", w.Body.String())
+		assert.Empty(t, w.Header().Values("Vary"))
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		w := serve(cfg, http.MethodOptions, "https://dash.example.com", map[string]string{
+			"Access-Control-Request-Method":  "POST",
+			"Access-Control-Request-Headers": "content-type",
+		})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Content-Type, X-Tenant-ID", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("any origin", func(t *testing.T) {
+		wildcard := CORSConfig{Origins: []string{"*"}, Methods: []string{"GET"}}
+		w := serve(wildcard, http.MethodGet, "https://anywhere.example", nil)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+		// Credentialed responses must name the origin
+		wildcard.Credentials = true
+		w = serve(wildcard, http.MethodGet, "https://anywhere.example", nil)
+		assert.Equal(t, "https://anywhere.example", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxZstdWindow is the largest window a zstd frame may use, the size the
+// format recommends every decoder support
+const maxZstdWindow = 8 << 20
+
+// Decompress inflates request bodies sent with Content-Encoding gzip or
+// zstd, so producers on slow links can compress large submissions. A body
+// that inflates to more than limit bytes fails to read with a
+// *http.MaxBytesError, so a small body can't expand without bound. Other
+// encodings are refused with 415.
+func Decompress(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			var body io.ReadCloser
+			switch encoding {
+			case "", "identity":
+				next.ServeHTTP(w, r)
+				return
+			case "gzip", "x-gzip":
+				zr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					http.Error(w, "request body is not valid gzip", http.StatusBadRequest)
+					return
+				}
+				body = zr
+			case "zstd":
+				// The window a frame may ask the decoder to hold is capped
+				// too, since it is allocated up front
+				zr, err := zstd.NewReader(r.Body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(maxZstdWindow))
+				if err != nil {
+					http.Error(w, "request body is not valid zstd", http.StatusBadRequest)
+					return
+				}
+				body = zr.IOReadCloser()
+			default:
+				w.Header().Set("Accept-Encoding", "gzip, zstd")
+				http.Error(w, "unsupported Content-Encoding "+encoding, http.StatusUnsupportedMediaType)
+				return
+			}
+			defer body.Close()
+
+			r.Body = http.MaxBytesReader(w, body, limit)
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(s))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func zstded(t *testing.T, s string) []byte {
+	zw, err := zstd.NewWriter(nil)
+	assert.NoError(t, err)
+	defer zw.Close()
+	return zw.EncodeAll([]byte(s), nil)
+}
+
+func TestDecompress(t *testing.T) {
+	const body = `{"type":"math","payload":{"number":7}}`
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		limit      int64
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "uncompressed", body: []byte(body), limit: 1 << 10, wantStatus: http.StatusOK, wantBody: body},
+		{name: "gzip", encoding: "gzip", body: gzipped(t, body), limit: 1 << 10, wantStatus: http.StatusOK, wantBody: body},
+		{name: "zstd", encoding: "zstd", body: zstded(t, body), limit: 1 << 10, wantStatus: http.StatusOK, wantBody: body},
+		{name: "unsupported encoding", encoding: "br", body: []byte(body), limit: 1 << 10, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "not gzip", encoding: "gzip", body: []byte(body), limit: 1 << 10, wantStatus: http.StatusBadRequest},
+		{name: "inflates past the limit", encoding: "gzip", body: gzipped(t, strings.Repeat("a", 1<<11)), limit: 1 << 10, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "zstd inflates past the limit", encoding: "zstd", body: zstded(t, strings.Repeat("a", 1<<11)), limit: 1 << 10, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Empty(t, r.Header.Get("Content-Encoding"))
+				data, err := io.ReadAll(r.Body)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+					return
+				}
+				w.Write(data)
+			})
+			req := httptest.NewRequest(http.MethodPost, "/jobs", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", tt.encoding)
+			w := httptest.NewRecorder()
+
+			Decompress(tt.limit)(echo).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateJobsHandler_Compressed(t *testing.T) {
+	mockService := new(MockJobsService)
+	mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+		payload, ok := j.Payload.(model.MathJobPayload)
+		return ok && payload.Number == 7
+	})).Return(nil)
+	handler := Decompress(1 << 10)(http.HandlerFunc(NewJobsHandler(mockService).CreateJobsHandler))
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs", bytes.NewReader(gzipped(t, `{"type":"math","payload":{"number":7}}`)))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Padding that inflates past the limit is refused before it is decoded
+	padded := `{"type":"math","payload":{"number":7}` + strings.Repeat(" ", 1<<11) + `}`
+	req = httptest.NewRequest(http.MethodPost, "/jobs", bytes.NewReader(zstded(t, padded)))
+	req.Header.Set("Content-Encoding", "zstd")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	mockService.AssertNumberOfCalls(t, "CreateJobs", 1)
+}
+
+func TestStreamJobsHandler_Compressed(t *testing.T) {
+	mockService := new(MockJobsService)
+	mockService.On("CreateJobs", mock.Anything, mock.Anything).Return(nil)
+	handler := Decompress(1 << 10)(http.HandlerFunc(NewJobsHandler(mockService).StreamJobsHandler))
+
+	// Lines are submitted until the body inflates past the limit
+	line := `{"type":"math","payload":{"number":1}}` + "\n"
+	req := httptest.NewRequest(http.MethodPost, "/jobs/stream", bytes.NewReader(gzipped(t, strings.Repeat(line, 100))))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	accepted := (1 << 10) / len(line)
+	if assert.Len(t, lines, accepted+1) {
+		assert.Contains(t, lines[accepted], `"status":413`)
+	}
+	mockService.AssertNumberOfCalls(t, "CreateJobs", accepted)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// FaultsHandler serves the admin endpoints that toggle fault injection. It is
+// only routed when fault injection is enabled in the configuration.
+type FaultsHandler struct {
+	service service.FaultsService
+}
+
+func NewFaultsHandler(service service.FaultsService) *FaultsHandler {
+	return &FaultsHandler{service: service}
+}
+
+func (h *FaultsHandler) GetFaultsHandler(w http.ResponseWriter, r *http.Request) {
+	scenario, err := h.service.GetFaults(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(scenario)
+}
+
+func (h *FaultsHandler) SetFaultsHandler(w http.ResponseWriter, r *http.Request) {
+	var scenario model.FaultScenario
+	if err := json.NewDecoder(r.Body).Decode(&scenario); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := scenario.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetFaults(r.Context(), &scenario); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(scenario)
+}
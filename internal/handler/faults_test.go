@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockFaultsService is a mock implementation of service.FaultsService
+type MockFaultsService struct {
+	mock.Mock
+}
+
+func (m *MockFaultsService) GetFaults(ctx context.Context) (*model.FaultScenario, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FaultScenario), args.Error(1)
+}
+
+func (m *MockFaultsService) SetFaults(ctx context.Context, scenario *model.FaultScenario) error {
+	args := m.Called(ctx, scenario)
+	return args.Error(0)
+}
+
+func TestGetFaultsHandler(t *testing.T) {
+	mockService := new(MockFaultsService)
+	handler := NewFaultsHandler(mockService)
+
+	mockService.On("GetFaults", mock.Anything).Return(&model.FaultScenario{WorkerCrashRate: 0.5}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/faults", nil)
+	w := httptest.NewRecorder()
+	handler.GetFaultsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got model.FaultScenario
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, 0.5, got.WorkerCrashRate)
+}
+
+func TestSetFaultsHandler(t *testing.T) {
+	mockService := new(MockFaultsService)
+	handler := NewFaultsHandler(mockService)
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "set scenario",
+			body: `{"worker_crash_rate": 0.2, "queue_latency": "100ms", "storage_error_rate": 0.1}`,
+			setupMock: func() {
+				mockService.On("SetFaults", mock.Anything, &model.FaultScenario{
+					WorkerCrashRate:  0.2,
+					QueueLatency:     "100ms",
+					StorageErrorRate: 0.1,
+				}).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid rate",
+			body:           `{"storage_error_rate": 2}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "storage_error_rate must be between 0 and 1",
+		},
+		{
+			name:           "invalid JSON",
+			body:           `{`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/faults", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.SetFaultsHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedError)
+			}
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
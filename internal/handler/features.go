@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// FeaturesHandler serves the admin endpoints that list and toggle feature
+// flags at runtime
+type FeaturesHandler struct {
+	service service.FeaturesService
+}
+
+func NewFeaturesHandler(service service.FeaturesService) *FeaturesHandler {
+	return &FeaturesHandler{service: service}
+}
+
+func (h *FeaturesHandler) ListFeaturesHandler(w http.ResponseWriter, r *http.Request) {
+	flags, err := h.service.ListFeatureFlags(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(flags)
+}
+
+func (h *FeaturesHandler) SetFeatureHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.SetFeatureFlagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Enabled == nil {
+		http.Error(w, "enabled is required", http.StatusBadRequest)
+		return
+	}
+
+	flag, err := h.service.SetFeatureFlag(r.Context(), extractLastPathSegment(r.URL.Path), *req.Enabled)
+	if errors.Is(err, service.ErrUnknownFeatureFlag) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(flag)
+}
+
+// CompressWhen gzips responses for clients that accept it, but only while
+// enabled returns true, so compression can be switched by a feature flag
+func CompressWhen(enabled func() bool) func(http.Handler) http.Handler {
+	compress := middleware.Compress(5)
+	return func(next http.Handler) http.Handler {
+		compressed := compress(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if enabled() {
+				compressed.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
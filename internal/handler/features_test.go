@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockFeaturesService is a mock implementation of service.FeaturesService
+type MockFeaturesService struct {
+	mock.Mock
+}
+
+func (m *MockFeaturesService) ListFeatureFlags(ctx context.Context) ([]model.FeatureFlag, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.FeatureFlag), args.Error(1)
+}
+
+func (m *MockFeaturesService) SetFeatureFlag(ctx context.Context, name string, enabled bool) (*model.FeatureFlag, error) {
+	args := m.Called(ctx, name, enabled)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FeatureFlag), args.Error(1)
+}
+
+func TestListFeaturesHandler(t *testing.T) {
+	mockService := new(MockFeaturesService)
+	handler := NewFeaturesHandler(mockService)
+
+	flags := []model.FeatureFlag{{Name: "response-compression", Description: "gzip", Enabled: true}}
+	mockService.On("ListFeatureFlags", mock.Anything).Return(flags, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/features", nil)
+	w := httptest.NewRecorder()
+	handler.ListFeaturesHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got []model.FeatureFlag
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, flags, got)
+}
+
+func TestSetFeatureHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		setupMock      func(m *MockFeaturesService)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "enable flag",
+			path: "/admin/features/response-compression",
+			body: `{"enabled": true}`,
+			setupMock: func(m *MockFeaturesService) {
+				m.On("SetFeatureFlag", mock.Anything, "response-compression", true).
+					Return(&model.FeatureFlag{Name: "response-compression", Enabled: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "unknown flag",
+			path: "/admin/features/warp-drive",
+			body: `{"enabled": false}`,
+			setupMock: func(m *MockFeaturesService) {
+				m.On("SetFeatureFlag", mock.Anything, "warp-drive", false).Return(nil, service.ErrUnknownFeatureFlag)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "unknown feature flag",
+		},
+		{
+			name:           "missing enabled",
+			path:           "/admin/features/response-compression",
+			body:           `{}`,
+			setupMock:      func(m *MockFeaturesService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "enabled is required",
+		},
+		{
+			name:           "invalid body",
+			path:           "/admin/features/response-compression",
+			body:           `{"enabled": "yes"}`,
+			setupMock:      func(m *MockFeaturesService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockFeaturesService)
+			tt.setupMock(mockService)
+			handler := NewFeaturesHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.SetFeatureHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedError)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCompressWhen(t *testing.T) {
+	enabled := false
+	body := strings.Repeat(`{"status":"completed"}`, 100)
+	handler := CompressWhen(func() bool { return enabled })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get()
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, w.Body.String())
+
+	enabled = true
+	w = get()
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	r, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(data))
+}
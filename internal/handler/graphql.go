@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/graphql"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// GraphQLHandler answers GraphQL queries, POSTed as JSON or given in the
+// query string of a GET
+type GraphQLHandler struct {
+	service service.GraphQLService
+}
+
+func NewGraphQLHandler(service service.GraphQLService) *GraphQLHandler {
+	return &GraphQLHandler{service: service}
+}
+
+// QueryHandler runs a query. A query that can't run at all, because it
+// doesn't parse or doesn't fit the schema, is a 400 with only errors; one
+// that runs is a 200, even if some of its fields failed.
+func (h *GraphQLHandler) QueryHandler(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				http.Error(w, fmt.Sprintf("variables: %s", err), http.StatusBadRequest)
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.Execute(r.Context(), &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Data == nil {
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/graphql"
+	gql "github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockGraphQLService is a mock implementation of service.GraphQLService
+type MockGraphQLService struct {
+	mock.Mock
+}
+
+func (m *MockGraphQLService) Execute(ctx context.Context, req *graphql.Request) (*graphql.Response, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*graphql.Response), args.Error(1)
+}
+
+func TestQueryHandler(t *testing.T) {
+	// A real schema stands in for the service's, so responses have data
+	schema := graphql.NewSchema()
+	schema.Query.AddFieldConfig("status", &gql.Field{
+		Type: gql.String,
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return "ok", nil
+		},
+	})
+	if err := schema.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		want           *graphql.Request
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "POST",
+			method:         http.MethodPost,
+			target:         "/graphql",
+			body:           `{"query": "query Q { status }", "operationName": "Q", "variables": {"x": 1}}`,
+			want:           &graphql.Request{Query: "query Q { status }", OperationName: "Q", Variables: map[string]any{"x": 1.0}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"status":"ok"}}`,
+		},
+		{
+			name:           "GET",
+			method:         http.MethodGet,
+			target:         "/graphql?" + url.Values{"query": {"{ status }"}, "variables": {`{"x": 1}`}}.Encode(),
+			want:           &graphql.Request{Query: "{ status }", Variables: map[string]any{"x": 1.0}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"status":"ok"}}`,
+		},
+		{
+			name:           "invalid query",
+			method:         http.MethodPost,
+			target:         "/graphql",
+			body:           `{"query": "{ nope }"}`,
+			want:           &graphql.Request{Query: "{ nope }"},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"errors":[{"message":"Cannot query field \"nope\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+		{
+			name:           "missing query",
+			method:         http.MethodPost,
+			target:         "/graphql",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "query is required",
+		},
+		{
+			name:           "invalid variables",
+			method:         http.MethodGet,
+			target:         "/graphql?query=%7B+status+%7D&variables=%7B",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "variables:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockGraphQLService)
+			if tt.want != nil {
+				mockService.On("Execute", mock.Anything, tt.want).Return(schema.Execute(context.Background(), tt.want), nil)
+			}
+			handler := NewGraphQLHandler(mockService)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.QueryHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
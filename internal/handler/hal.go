@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// halBase is the base path links point under. Requests to the deprecated
+// unversioned routes are linked to their /v1 successors.
+const halBase = "/v1"
+
+// wantsHAL reports whether the client accepts jobs in the HAL format
+func wantsHAL(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), model.HALMediaType)
+}
+
+// writeJob writes a job with the given status, in the HAL format when the
+// client accepts it
+func writeJob(w http.ResponseWriter, r *http.Request, status int, job *model.Job) {
+	w.Header().Add("Vary", "Accept")
+	if wantsHAL(r) {
+		w.Header().Set("Content-Type", model.HALMediaType)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(model.NewHALJob(halBase, job))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(job)
+}
+
+// writeHALJobs writes a list of jobs in the HAL format, self linking to the
+// list
+func writeHALJobs(w http.ResponseWriter, self model.Link, jobs []*model.Job, nextCursor string) {
+	list := model.NewHALJobs(halBase, self, jobs)
+	list.NextCursor = nextCursor
+	w.Header().Set("Content-Type", model.HALMediaType)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(list)
+}
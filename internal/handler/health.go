@@ -1,14 +1,65 @@
 package handler
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	ready       atomic.Bool
+	maintenance func() bool
+	synced      func() time.Time
+}
 
 func NewHealthHandler() *HealthHandler {
 	return &HealthHandler{}
 }
 
+// SetReady controls whether the readiness check passes. The service is marked
+// ready once it can accept jobs and unready again when it starts draining.
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// SetMaintenance reports through the readiness check whether dispatch is
+// paused for maintenance. The service stays ready meanwhile, since it still
+// accepts jobs.
+func (h *HealthHandler) SetMaintenance(paused func() bool) {
+	h.maintenance = paused
+}
+
+// SetReplica makes the readiness check of a read-only replica wait until
+// it has copied its primary's jobs, as synced reports
+func (h *HealthHandler) SetReplica(synced func() time.Time) {
+	h.synced = synced
+}
+
 func (h *HealthHandler) GetHealthHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
+
+func (h *HealthHandler) GetReadyHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.ready.Load() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	if h.synced != nil {
+		synced := h.synced()
+		if synced.IsZero() {
+			http.Error(w, "not synced with primary", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "OK (replica, synced %s ago)", time.Since(synced).Round(time.Second))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if h.maintenance != nil && h.maintenance() {
+		w.Write([]byte("OK (dispatch paused for maintenance)"))
+		return
+	}
+	w.Write([]byte("OK"))
+}
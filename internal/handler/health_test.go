@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-playground/assert/v2"
 )
@@ -35,3 +36,78 @@ func TestGetHealthHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGetReadyHandler(t *testing.T) {
+	handler := NewHealthHandler()
+
+	tests := []struct {
+		name           string
+		ready          bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "not ready",
+			ready:          false,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "not ready\n",
+		},
+		{
+			name:           "ready",
+			ready:          true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "OK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler.SetReady(tt.ready)
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			w := httptest.NewRecorder()
+
+			handler.GetReadyHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestGetReadyHandler_Maintenance(t *testing.T) {
+	handler := NewHealthHandler()
+	handler.SetReady(true)
+	paused := true
+	handler.SetMaintenance(func() bool { return paused })
+
+	// The service stays ready, since it still accepts jobs
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	handler.GetReadyHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "OK (dispatch paused for maintenance)", w.Body.String())
+
+	paused = false
+	w = httptest.NewRecorder()
+	handler.GetReadyHandler(w, req)
+	assert.Equal(t, "OK", w.Body.String())
+}
+
+func TestGetReadyHandler_Replica(t *testing.T) {
+	handler := NewHealthHandler()
+	handler.SetReady(true)
+	var synced time.Time
+	handler.SetReplica(func() time.Time { return synced })
+
+	// A replica isn't ready until it has a copy of the primary's jobs
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	handler.GetReadyHandler(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	synced = time.Now().Add(-3 * time.Second)
+	w = httptest.NewRecorder()
+	handler.GetReadyHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "OK (replica, synced 3s ago)", w.Body.String())
+}
@@ -1,56 +1,491 @@
 package handler
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dnakolan/worker-pool-service/internal/backoff"
 	"github.com/dnakolan/worker-pool-service/internal/model"
 	"github.com/dnakolan/worker-pool-service/internal/service"
 	"github.com/google/uuid"
 )
 
 type JobsHandler struct {
-	service service.JobsService
+	service      service.JobsService
+	clusterToken string
+	tenantHeader string
 }
 
 func NewJobsHandler(service service.JobsService) *JobsHandler {
-	return &JobsHandler{service: service}
+	return &JobsHandler{service: service, tenantHeader: model.DefaultTenantHeader}
+}
+
+// SetTenantHeader sets the request header submissions name their tenant in.
+// Whatever it holds is believed, so it should be one that only a trusted
+// proxy in front of the service can set.
+func (h *JobsHandler) SetTenantHeader(header string) {
+	h.tenantHeader = header
+}
+
+// SetClusterToken sets the token peers forward submissions with. Only
+// submissions carrying it are taken to have been forwarded; without one,
+// none are.
+func (h *JobsHandler) SetClusterToken(token string) {
+	h.clusterToken = token
+}
+
+// forwarded marks the request's context as forwarded when it came from a
+// peer, which it proves by carrying the cluster token
+func (h *JobsHandler) forwarded(r *http.Request) context.Context {
+	ctx := r.Context()
+	from := r.Header.Get(model.ForwardedByHeader)
+	token := r.Header.Get(model.ClusterTokenHeader)
+	if from == "" || h.clusterToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.clusterToken)) != 1 {
+		return ctx
+	}
+	return service.Forwarded(ctx, from)
 }
 
 func (h *JobsHandler) CreateJobsHandler(w http.ResponseWriter, r *http.Request) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		h.createJobWithInput(w, r)
+		return
+	}
+
 	var req model.CreateJobRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		// A compressed body may inflate past its limit
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		writeBadRequest(w, model.DecodeError(err))
 		return
 	}
 
-	payload, err := req.ParsePayload()
+	job, err := newJob(&req, r, h.tenantHeader)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	existing, err := h.create(h.forwarded(r), job, &req)
+	if err != nil {
+		writeCreateError(w, r, err)
+		return
+	}
+
+	if existing != nil {
+		// The submission was skipped or coalesced for a job already there
+		writeJob(w, r, http.StatusOK, existing)
+		return
+	}
+	writeJob(w, r, http.StatusCreated, job)
+}
+
+// create submits a job as the validated request asks. If an existing job
+// stands in for it instead, because the request was conditional or
+// coalesced, that job is returned.
+func (h *JobsHandler) create(ctx context.Context, job *model.Job, req *model.CreateJobRequest) (*model.Job, error) {
+	switch {
+	case req.UnlessExists != nil:
+		return h.service.CreateJobUnlessExists(ctx, job, req.UnlessExists)
+	case req.Coalesce != nil:
+		window, _ := req.Coalesce.Parse()
+		return h.service.CreateJobCoalesced(ctx, job, req.Coalesce.Key, window)
+	default:
+		return nil, h.service.CreateJobs(ctx, job)
+	}
+}
+
+// createJobWithInput handles a multipart/form-data submission: a "manifest"
+// part holding the same JSON as a plain submission, followed by a part for
+// each binary payload field given in one, named as the field's part, and
+// then a "file" part stored as the job's input. Each part is stored as it
+// arrives. Either the file or the binary fields' parts may be left out.
+func (h *JobsHandler) createJobWithInput(w http.ResponseWriter, r *http.Request) {
+	// Leave room for a file and a binary field at their limits, and for the
+	// manifest and multipart framing around them
+	r.Body = http.MaxBytesReader(w, r.Body, 2*model.MaxInputSize+1<<20)
+	mr, err := r.MultipartReader()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	now := time.Now()
-	job := &model.Job{
-		UID:       uuid.New(),
-		Type:      req.Type,
-		Payload:   payload,
-		Status:    model.JobStatusPending,
-		CreatedAt: &now,
+	part, err := mr.NextPart()
+	if err != nil || part.FormName() != "manifest" {
+		http.Error(w, "the first part must be the job manifest", http.StatusBadRequest)
+		return
+	}
+	var req model.CreateJobRequest
+	if err := json.NewDecoder(part).Decode(&req); err != nil {
+		writeBadRequest(w, model.DecodeError(err))
+		return
+	}
+	job, err := newJob(&req, r, h.tenantHeader)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if req.UnlessExists != nil || req.Coalesce != nil {
+		http.Error(w, "unless_exists and coalesce can't be used with an input file", http.StatusBadRequest)
+		return
 	}
 
+	// discard removes the data of any binary fields stored before the
+	// submission failed
+	discard := func() {
+		if len(model.PayloadBinaries(job.Payload)) > 0 {
+			h.service.DeleteJobBinaries(context.WithoutCancel(r.Context()), job)
+		}
+	}
+	fail := func(err error) {
+		discard()
+		writeCreateError(w, r, err)
+	}
+	uploaded := false
+	for {
+		part, err = mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			discard()
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		contentType := part.Header.Get("Content-Type")
+		if part.FormName() == "file" {
+			if err := h.service.CreateJobWithInput(r.Context(), job, part.FileName(), contentType, part); err != nil {
+				fail(err)
+				return
+			}
+			writeJob(w, r, http.StatusCreated, job)
+			return
+		}
+		if err := h.service.StoreJobBinary(r.Context(), job, part.FormName(), contentType, part); err != nil {
+			fail(err)
+			return
+		}
+		uploaded = true
+	}
+	if !uploaded {
+		http.Error(w, "the manifest must be followed by a file part or a binary field's part", http.StatusBadRequest)
+		return
+	}
 	if err := h.service.CreateJobs(r.Context(), job); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		fail(err)
 		return
 	}
+	writeJob(w, r, http.StatusCreated, job)
+}
+
+// newJob validates a submission and builds the pending job it describes.
+// Every invalid field is reported, not just the first.
+func newJob(req *model.CreateJobRequest, r *http.Request, tenantHeader string) (*model.Job, error) {
+	var problems model.ValidationError
+	payload, err := req.ParsePayload()
+	problems.AddErr("", err)
+	if len(model.PayloadBinaries(payload)) > 0 {
+		problems.AddErr("payload", model.CheckNewBinaries(payload))
+		if req.UnlessExists != nil || req.Coalesce != nil {
+			problems.Add("payload", "binary fields can't be used with unless_exists or coalesce")
+		}
+	}
+	problems.AddErr("labels", model.ValidateLabels(req.Labels))
+	problems.AddErr("requires", model.ValidateCapabilities(req.Requires))
+	problems.AddErr("placement", model.ValidatePlacement(req.Placement))
+	problems.AddErr("weight", model.ValidateWeight(req.Weight))
+	problems.AddErr("serialization_key", model.ValidateSerializationKey(req.SerializationKey))
+	if req.UnlessExists != nil {
+		problems.AddErr("unless_exists", req.UnlessExists.Validate(req.Labels, req.SerializationKey))
+	}
+	if req.Coalesce != nil {
+		_, err := req.Coalesce.Parse()
+		problems.AddErr("coalesce", err)
+		if req.UnlessExists != nil {
+			problems.Add("coalesce", "can't be combined with unless_exists")
+		}
+	}
+	if req.Backoff != "" {
+		_, err := backoff.Parse(req.Backoff)
+		problems.AddErr("backoff", err)
+	}
+	if req.Timeout != "" {
+		problems.AddErr("timeout", model.ValidateJobDuration(req.Timeout))
+	}
+	if req.MaxRetries != nil {
+		problems.AddErr("max_retries", model.ValidateRetries(*req.MaxRetries))
+	}
+	if req.Priority != nil {
+		problems.AddErr("priority", model.ValidatePriority(*req.Priority))
+	}
+	if req.Retention != "" {
+		problems.AddErr("retention", model.ValidateJobDuration(req.Retention))
+	}
+	now := time.Now()
+	deadline, err := model.ParseDeadline(req.Deadline, now)
+	problems.AddErr("deadline", err)
+	var parent *uuid.UUID
+	if req.Parent != "" {
+		uid, err := uuid.Parse(req.Parent)
+		problems.AddErr("parent", err)
+		parent = &uid
+	}
+	if err := problems.Err(); err != nil {
+		return nil, err
+	}
+
+	tenant := r.Header.Get(tenantHeader)
+	if tenant == "" {
+		tenant = model.DefaultTenant
+	}
+
+	return &model.Job{
+		UID:              uuid.New(),
+		Type:             req.Type,
+		Payload:          payload,
+		Labels:           req.Labels,
+		Tenant:           tenant,
+		Requires:         req.Requires,
+		Placement:        req.Placement,
+		Weight:           req.Weight,
+		SerializationKey: req.SerializationKey,
+		Backoff:          req.Backoff,
+		Timeout:          req.Timeout,
+		MaxRetries:       req.MaxRetries,
+		Priority:         req.Priority,
+		Retention:        req.Retention,
+		TraceID:          traceID(r.Header.Get("traceparent")),
+		Deadline:         deadline,
+		Parent:           parent,
+		Status:           model.JobStatusPending,
+		CreatedAt:        &now,
+	}, nil
+}
+
+// traceID returns the trace ID of a W3C traceparent header, or "" if the
+// header is missing or malformed, which the spec says to ignore
+func traceID(traceparent string) string {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || !lowerHex(parts[0], 2) || parts[0] == "ff" || !lowerHex(parts[1], 32) {
+		return ""
+	}
+	// Version 00 has exactly four fields; later versions may add more
+	if (parts[0] == "00" && len(parts) != 4) || parts[1] == strings.Repeat("0", 32) {
+		return ""
+	}
+	return parts[1]
+}
+
+func lowerHex(s string, n int) bool {
+	_, err := hex.DecodeString(s)
+	return len(s) == n && err == nil && s == strings.ToLower(s)
+}
 
+// writeBadRequest responds 400, listing the offending fields when err is a
+// validation error
+func writeBadRequest(w http.ResponseWriter, err error) {
+	var invalid *model.ValidationError
+	if !errors.As(err, &invalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(job)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(model.ValidationErrorResponse{Error: invalid.Error(), Fields: invalid.Fields})
+}
+
+// statusClientClosedRequest is logged for submissions the client gave up on
+// before the job was accepted, following nginx
+const statusClientClosedRequest = 499
+
+// writeCreateError responds to a submission that failed
+func writeCreateError(w http.ResponseWriter, r *http.Request, err error) {
+	var invalid *model.ValidationError
+	if errors.As(err, &invalid) && r.Context().Err() == nil {
+		writeBadRequest(w, err)
+		return
+	}
+	status, msg := createError(r, err)
+	if errors.Is(err, service.ErrPolicyUnavailable) && r.Context().Err() == nil {
+		w.Header().Set("Retry-After", policyRetryAfter)
+	}
+	http.Error(w, msg, status)
+}
+
+// policyRetryAfter is how many seconds a client is asked to wait before
+// submitting again while no policy decision can be had
+const policyRetryAfter = "5"
+
+// createError picks the status and message for a submission that failed.
+// The job was not accepted whatever the error, so a submission whose
+// request was cancelled first, typically by the client disconnecting
+// mid-upload, is reported as such rather than as the error it caused.
+func createError(r *http.Request, err error) (int, string) {
+	if ctxErr := r.Context().Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	var tooLarge *http.MaxBytesError
+	var invalid *model.ValidationError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest, "the request was cancelled before the job was accepted"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable, "the request timed out before the job was accepted"
+	case errors.Is(err, service.ErrPolicyUnavailable):
+		// The job wasn't denied, there was just no one to ask
+		return http.StatusServiceUnavailable, err.Error()
+	case errors.Is(err, service.ErrJobTypeDisabled), errors.Is(err, service.ErrJobRejected):
+		return http.StatusForbidden, err.Error()
+	case errors.Is(err, service.ErrBudgetExceeded):
+		return http.StatusTooManyRequests, err.Error()
+	case errors.Is(err, service.ErrUnschedulable), errors.Is(err, service.ErrParentNotFound):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, service.ErrStorageFault):
+		return http.StatusServiceUnavailable, err.Error()
+	case errors.Is(err, service.ErrNoBlobStore):
+		return http.StatusNotImplemented, err.Error()
+	case errors.Is(err, service.ErrUnexpectedPart), errors.Is(err, service.ErrMissingPart), errors.As(err, &invalid):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, model.ErrInputTooLarge), errors.Is(err, model.ErrBinaryTooLarge), errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge, err.Error()
+	default:
+		return http.StatusInternalServerError, err.Error()
+	}
+}
+
+// maxStreamedJobLine bounds one line of a streamed submission
+const maxStreamedJobLine = 1 << 20
+
+var errLineTooLong = fmt.Errorf("line is longer than %d bytes", maxStreamedJobLine)
+
+// StreamJobsHandler takes an NDJSON body holding one submission per line.
+// Each line is submitted as soon as it arrives and answered with a line of
+// the response, so producers can submit any number of jobs in one request
+// and learn which were rejected as they go. A rejected line doesn't stop
+// the rest.
+func (h *JobsHandler) StreamJobsHandler(w http.ResponseWriter, r *http.Request) {
+	// Lines are answered while later ones are still being read
+	rc := http.NewResponseController(w)
+	rc.EnableFullDuplex()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	body := bufio.NewReader(r.Body)
+	enc := json.NewEncoder(w)
+	for n := 1; ; n++ {
+		line, err := readLine(body, maxStreamedJobLine)
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil && !errors.Is(err, errLineTooLong) {
+			// Nothing more can be read, say because the body inflated past
+			// its limit, so the lines from here on go unsubmitted
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			enc.Encode(&model.StreamedJobResult{Line: n, Status: status, Error: err.Error()})
+			return
+		}
+		if err == nil && len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		result := h.submitLine(r, line, err)
+		result.Line = n
+		if err := enc.Encode(result); err != nil {
+			return
+		}
+		rc.Flush()
+		if r.Context().Err() != nil {
+			return
+		}
+	}
+}
+
+// submitLine submits the job on one line of a streamed submission
+func (h *JobsHandler) submitLine(r *http.Request, line []byte, readErr error) *model.StreamedJobResult {
+	if readErr != nil {
+		return &model.StreamedJobResult{Status: http.StatusRequestEntityTooLarge, Error: readErr.Error()}
+	}
+	rejected := func(err error) *model.StreamedJobResult {
+		result := &model.StreamedJobResult{Status: http.StatusBadRequest, Error: err.Error()}
+		var invalid *model.ValidationError
+		if errors.As(err, &invalid) {
+			result.Fields = invalid.Fields
+		}
+		return result
+	}
+
+	var req model.CreateJobRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return rejected(model.DecodeError(err))
+	}
+	job, err := newJob(&req, r, h.tenantHeader)
+	if err != nil {
+		return rejected(err)
+	}
+	existing, err := h.create(r.Context(), job, &req)
+	if err != nil {
+		status, msg := createError(r, err)
+		if status == http.StatusBadRequest {
+			return rejected(err)
+		}
+		return &model.StreamedJobResult{Status: status, Error: msg}
+	}
+	if existing != nil {
+		return &model.StreamedJobResult{Existing: true, JobUID: &existing.UID}
+	}
+	return &model.StreamedJobResult{Accepted: true, JobUID: &job.UID}
+}
+
+// readLine reads up to the next newline or the end of the input. A line
+// longer than limit is read to its end and dropped, returning
+// errLineTooLong, so reading can carry on with the next.
+func readLine(r *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if tooLong = tooLong || len(line)+len(chunk) > limit; tooLong {
+			line = nil
+		} else {
+			line = append(line, chunk...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) && (len(line) > 0 || tooLong) {
+			err = nil
+		}
+		switch {
+		case err != nil:
+			return nil, err
+		case tooLong:
+			return nil, errLineTooLong
+		default:
+			return line, nil
+		}
+	}
 }
 
 func (h *JobsHandler) ListJobsHandler(w http.ResponseWriter, r *http.Request) {
@@ -66,11 +501,48 @@ func (h *JobsHandler) ListJobsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Add("Vary", "Accept")
+	if wantsHAL(r) {
+		self := halBase + "/jobs"
+		if r.URL.RawQuery != "" {
+			self += "?" + r.URL.RawQuery
+		}
+		writeHALJobs(w, model.Link{Href: self}, jobs, "")
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(jobs)
 }
 
+func (h *JobsHandler) SearchJobsHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.SearchJobsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequest(w, model.DecodeError(err))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.SearchJobs(r.Context(), &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if wantsHAL(r) {
+		writeHALJobs(w, model.Link{Href: halBase + "/jobs/search", Method: http.MethodPost}, resp.Jobs, resp.NextCursor)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
 // extractLastPathSegment returns the last segment of the URL path
 func extractLastPathSegment(path string) string {
 	segments := strings.Split(path, "/")
@@ -80,6 +552,15 @@ func extractLastPathSegment(path string) string {
 	return segments[len(segments)-1]
 }
 
+// extractParentPathSegment returns {id} from paths like /jobs/{id}/<action>
+func extractParentPathSegment(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < 2 {
+		return ""
+	}
+	return segments[len(segments)-2]
+}
+
 func (h *JobsHandler) GetJobsHandler(w http.ResponseWriter, r *http.Request) {
 	jobID := extractLastPathSegment(r.URL.Path)
 
@@ -101,8 +582,287 @@ func (h *JobsHandler) GetJobsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", formatETag(job.Version))
+	writeJob(w, r, http.StatusOK, job)
+}
+
+// GetJobLogsHandler returns the output a job has produced so far. With
+// ?follow=true the response streams new output until the job finishes.
+func (h *JobsHandler) GetJobLogsHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := extractParentPathSegment(r.URL.Path)
+	if _, err := uuid.Parse(jobID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	follow := false
+	if v := r.URL.Query().Get("follow"); v != "" {
+		var err error
+		if follow, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "follow must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
+
+	out := &logWriter{w: w}
+	if err := h.service.StreamJobLogs(r.Context(), jobID, follow, out); err != nil {
+		if !out.started {
+			if errors.Is(err, service.ErrJobNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		}
+		return
+	}
+	if !out.started {
+		out.writeHeader()
+	}
+}
+
+// GetJobInputHandler downloads the file uploaded with a job
+func (h *JobsHandler) GetJobInputHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := extractParentPathSegment(r.URL.Path)
+	if _, err := uuid.Parse(jobID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	input, body, err := h.service.GetJobInput(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, service.ErrJobNotFound) || errors.Is(err, service.ErrNoInput) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	defer body.Close()
+
+	contentType := input.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(input.Size, 10))
+	if input.Filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": input.Filename}))
+	}
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+// GetJobBinaryHandler downloads the data of a binary payload field, at
+// /jobs/{uid}/binaries/{field}
+func (h *JobsHandler) GetJobBinaryHandler(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) < 4 {
+		http.Error(w, "invalid binary field path", http.StatusBadRequest)
+		return
+	}
+	jobID, field := segments[len(segments)-3], segments[len(segments)-1]
+	if _, err := uuid.Parse(jobID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	binary, body, err := h.service.GetJobBinary(r.Context(), jobID, field)
+	if err != nil {
+		if errors.Is(err, service.ErrJobNotFound) || errors.Is(err, service.ErrNoBinary) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	defer body.Close()
+
+	contentType := binary.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(*binary.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+// logWriter sends the response header on the first write and flushes every
+// write so followers see output as it arrives
+type logWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (lw *logWriter) writeHeader() {
+	lw.started = true
+	lw.w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	lw.w.WriteHeader(http.StatusOK)
+}
+
+func (lw *logWriter) Write(p []byte) (int, error) {
+	if !lw.started {
+		lw.writeHeader()
+	}
+	n, err := lw.w.Write(p)
+	if f, ok := lw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
+func (h *JobsHandler) UpdateJobsHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := extractLastPathSegment(r.URL.Path)
+
+	if _, err := uuid.Parse(jobID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := parseIfMatch(r.Header.Get("If-Match"))
+	if errors.Is(err, errWeakIfMatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	patch, err := model.ParseJobPatch(body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	job, err := h.service.UpdateJobs(r.Context(), jobID, version, patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrJobNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrVersionConflict):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		case errors.Is(err, model.ErrJobNotMutable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, service.ErrPolicyUnavailable):
+			w.Header().Set("Retry-After", policyRetryAfter)
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case errors.Is(err, service.ErrJobTypeDisabled), errors.Is(err, service.ErrJobRejected):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, service.ErrStorageFault):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			writeBadRequest(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(job.Version))
+	writeJob(w, r, http.StatusOK, job)
+}
+
+// GetJobLineageHandler returns the tree of jobs a job belongs to, linked by
+// parent and replay
+func (h *JobsHandler) GetJobLineageHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := extractParentPathSegment(r.URL.Path)
+	if _, err := uuid.Parse(jobID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lineage, err := h.service.GetJobLineage(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, service.ErrJobNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(job)
+	json.NewEncoder(w).Encode(lineage)
+}
+
+// ReplayJobHandler submits a finished job again as a new job. The body may
+// be empty, or carry a merge patch for the payload.
+func (h *JobsHandler) ReplayJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := extractParentPathSegment(r.URL.Path)
+	if _, err := uuid.Parse(jobID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := parseIfMatch(r.Header.Get("If-Match"))
+	if errors.Is(err, errWeakIfMatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req model.ReplayJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeBadRequest(w, model.DecodeError(err))
+		return
+	}
+
+	job, err := h.service.ReplayJob(r.Context(), jobID, version, &req, traceID(r.Header.Get("traceparent")))
+	if err != nil {
+		var invalid *model.ValidationError
+		switch {
+		case errors.Is(err, service.ErrJobNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrVersionConflict):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		case errors.Is(err, model.ErrJobNotFinished):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.As(err, &invalid):
+			writeBadRequest(w, err)
+		default:
+			writeCreateError(w, r, err)
+		}
+		return
+	}
+
+	writeJob(w, r, http.StatusCreated, job)
+}
+
+// errWeakIfMatch is returned for an If-Match header naming a weak entity
+// tag, which never matches as If-Match compares tags strongly (RFC 9110)
+var errWeakIfMatch = errors.New("If-Match requires a strong entity tag")
+
+// parseIfMatch returns the job version named by an If-Match header, or 0 when
+// the request is unconditional
+func parseIfMatch(header string) (int64, error) {
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, errWeakIfMatch
+	}
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, fmt.Errorf("invalid If-Match header: %s", header)
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid If-Match header: %s", header)
+	}
+	return version, nil
+}
+
+// formatETag renders a job version as a strong entity tag
+func formatETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
 }
 
 func parseFilter(query url.Values) (*model.JobFilter, error) {
@@ -123,9 +883,19 @@ func parseFilter(query url.Values) (*model.JobFilter, error) {
 		jobStatus = &status
 	}
 
+	var deadlineMissed *bool
+	if v := query.Get("deadline_missed"); v != "" {
+		missed, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deadline_missed: %s", v)
+		}
+		deadlineMissed = &missed
+	}
+
 	filter := &model.JobFilter{
-		Type:   jobType,
-		Status: jobStatus,
+		Type:           jobType,
+		Status:         jobStatus,
+		DeadlineMissed: deadlineMissed,
 	}
 
 	if err := filter.Validate(); err != nil {
@@ -5,12 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -27,6 +32,55 @@ func (m *MockJobsService) CreateJobs(ctx context.Context, wp *model.Job) error {
 	return args.Error(0)
 }
 
+func (m *MockJobsService) CreateJobUnlessExists(ctx context.Context, wp *model.Job, match *model.ExistingJobMatch) (*model.Job, error) {
+	args := m.Called(ctx, wp, match)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Job), args.Error(1)
+}
+
+func (m *MockJobsService) CreateJobCoalesced(ctx context.Context, wp *model.Job, key string, window time.Duration) (*model.Job, error) {
+	args := m.Called(ctx, wp, key, window)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Job), args.Error(1)
+}
+
+func (m *MockJobsService) CreateJobWithInput(ctx context.Context, wp *model.Job, filename, contentType string, input io.Reader) error {
+	data, _ := io.ReadAll(input)
+	args := m.Called(ctx, wp, filename, contentType, string(data))
+	return args.Error(0)
+}
+
+func (m *MockJobsService) GetJobInput(ctx context.Context, uid string) (*model.JobInput, io.ReadCloser, error) {
+	args := m.Called(ctx, uid)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*model.JobInput), io.NopCloser(strings.NewReader(args.String(1))), args.Error(2)
+}
+
+func (m *MockJobsService) StoreJobBinary(ctx context.Context, wp *model.Job, part, contentType string, data io.Reader) error {
+	content, _ := io.ReadAll(data)
+	args := m.Called(ctx, wp, part, contentType, string(content))
+	return args.Error(0)
+}
+
+func (m *MockJobsService) DeleteJobBinaries(ctx context.Context, wp *model.Job) error {
+	args := m.Called(ctx, wp)
+	return args.Error(0)
+}
+
+func (m *MockJobsService) GetJobBinary(ctx context.Context, uid, field string) (*model.Binary, io.ReadCloser, error) {
+	args := m.Called(ctx, uid, field)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*model.Binary), io.NopCloser(strings.NewReader(args.String(1))), args.Error(2)
+}
+
 func (m *MockJobsService) ListJobs(ctx context.Context, filter *model.JobFilter) ([]*model.Job, error) {
 	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
@@ -43,6 +97,43 @@ func (m *MockJobsService) GetJobs(ctx context.Context, uid string) (*model.Job,
 	return args.Get(0).(*model.Job), args.Error(1)
 }
 
+func (m *MockJobsService) UpdateJobs(ctx context.Context, uid string, version int64, patch *model.JobPatch) (*model.Job, error) {
+	args := m.Called(ctx, uid, version, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Job), args.Error(1)
+}
+
+func (m *MockJobsService) SearchJobs(ctx context.Context, req *model.SearchJobsRequest) (*model.SearchJobsResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SearchJobsResponse), args.Error(1)
+}
+
+func (m *MockJobsService) ReplayJob(ctx context.Context, uid string, version int64, req *model.ReplayJobRequest, traceID string) (*model.Job, error) {
+	args := m.Called(ctx, uid, version, req, traceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Job), args.Error(1)
+}
+
+func (m *MockJobsService) GetJobLineage(ctx context.Context, uid string) (*model.Lineage, error) {
+	args := m.Called(ctx, uid)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Lineage), args.Error(1)
+}
+
+func (m *MockJobsService) StreamJobLogs(ctx context.Context, uid string, follow bool, w io.Writer) error {
+	args := m.Called(ctx, uid, follow, w)
+	return args.Error(0)
+}
+
 func TestCreateJobsHandler(t *testing.T) {
 	mockService := new(MockJobsService)
 	handler := NewJobsHandler(mockService)
@@ -53,6 +144,24 @@ func TestCreateJobsHandler(t *testing.T) {
 		setupMock      func()
 		expectedStatus int
 	}{
+		{
+			name: "overrides of the type's defaults",
+			request: model.CreateJobRequest{
+				Type:       "sleep",
+				Payload:    json.RawMessage(`{"duration":"1s"}`),
+				Timeout:    "1m",
+				MaxRetries: new(int),
+				Priority:   new(int),
+				Retention:  "2h",
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					return j.Timeout == "1m" && j.MaxRetries != nil && *j.MaxRetries == 0 &&
+						j.Priority != nil && *j.Priority == 0 && j.Retention == "2h"
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
 		{
 			name: "successful creation",
 			request: model.CreateJobRequest{
@@ -70,6 +179,231 @@ func TestCreateJobsHandler(t *testing.T) {
 			},
 			expectedStatus: http.StatusCreated,
 		},
+		{
+			name: "tenant over budget",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"2s"}`),
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					payload, ok := j.Payload.(model.SleepJobPayload)
+					return ok && payload.Duration == "2s" && j.Tenant == model.DefaultTenant
+				})).Return(service.ErrBudgetExceeded)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name: "job type disabled",
+			request: model.CreateJobRequest{
+				Type:    "math",
+				Payload: json.RawMessage(`{"number":7}`),
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					payload, ok := j.Payload.(model.MathJobPayload)
+					return ok && payload.Number == 7
+				})).Return(fmt.Errorf("%w: math jobs are not enabled in this deployment", service.ErrJobTypeDisabled))
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "job rejected by hook",
+			request: model.CreateJobRequest{
+				Type:    "math",
+				Payload: json.RawMessage(`{"number":13}`),
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					payload, ok := j.Payload.(model.MathJobPayload)
+					return ok && payload.Number == 13
+				})).Return(fmt.Errorf("%w: unlucky", service.ErrJobRejected))
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "no worker with required capabilities",
+			request: model.CreateJobRequest{
+				Type:     "sleep",
+				Payload:  json.RawMessage(`{"duration":"3s"}`),
+				Requires: []string{"tpu"},
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					return len(j.Requires) == 1 && j.Requires[0] == "tpu"
+				})).Return(service.ErrUnschedulable)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "injected storage fault",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"4s"}`),
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					payload, ok := j.Payload.(model.SleepJobPayload)
+					return ok && payload.Duration == "4s"
+				})).Return(service.ErrStorageFault)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "client disconnected before commit",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"5s"}`),
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					payload, ok := j.Payload.(model.SleepJobPayload)
+					return ok && payload.Duration == "5s"
+				})).Return(context.Canceled)
+			},
+			expectedStatus: statusClientClosedRequest,
+		},
+		{
+			name: "timed out before commit",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"6s"}`),
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					payload, ok := j.Payload.(model.SleepJobPayload)
+					return ok && payload.Duration == "6s"
+				})).Return(context.DeadlineExceeded)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "empty capability",
+			request: model.CreateJobRequest{
+				Type:     "sleep",
+				Payload:  json.RawMessage(`{"duration":"1s"}`),
+				Requires: []string{""},
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "weighted job",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"1s"}`),
+				Weight:  4,
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					return j.Weight == 4
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "negative weight",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"1s"}`),
+				Weight:  -1,
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "serialization key",
+			request: model.CreateJobRequest{
+				Type:             "sleep",
+				Payload:          json.RawMessage(`{"duration":"1s"}`),
+				SerializationKey: "customer-42",
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					return j.SerializationKey == "customer-42"
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "serialization key too long",
+			request: model.CreateJobRequest{
+				Type:             "sleep",
+				Payload:          json.RawMessage(`{"duration":"1s"}`),
+				SerializationKey: strings.Repeat("k", model.MaxSerializationKeyLength+1),
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "retry backoff",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"1s"}`),
+				Backoff: "constant:10s",
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					return j.Backoff == "constant:10s"
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "child job",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"1s"}`),
+				Parent:  uuid.NewString(),
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "unknown parent",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"9s"}`),
+				Parent:  uuid.NewString(),
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					payload, ok := j.Payload.(model.SleepJobPayload)
+					return ok && payload.Duration == "9s"
+				})).Return(service.ErrParentNotFound)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "invalid parent",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"1s"}`),
+				Parent:  "not-a-uid",
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid placement",
+			request: model.CreateJobRequest{
+				Type:      "sleep",
+				Payload:   json.RawMessage(`{"duration":"1s"}`),
+				Placement: map[string]string{"zone": ""},
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid backoff",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"1s"}`),
+				Backoff: "linear:1s",
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "invalid job type",
 			request: model.CreateJobRequest{
@@ -88,6 +422,38 @@ func TestCreateJobsHandler(t *testing.T) {
 			setupMock:      func() {},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "sleep duration over the limit",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"10000h"}`),
+			},
+			setupMock: func() {
+				mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					payload, ok := j.Payload.(model.SleepJobPayload)
+					return ok && payload.Duration == "10000h"
+				})).Return(model.DefaultPayloadLimits().Check(model.SleepJobPayload{Duration: "10000h"}))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unparseable sleep duration",
+			request: model.CreateJobRequest{
+				Type:    "sleep",
+				Payload: json.RawMessage(`{"duration":"soon"}`),
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative math number",
+			request: model.CreateJobRequest{
+				Type:    "math",
+				Payload: json.RawMessage(`{"number":-3}`),
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "invalid payload format",
 			request: model.CreateJobRequest{
@@ -163,6 +529,9 @@ func TestCreateJobsHandler(t *testing.T) {
 				payload, ok := response.Payload.(model.SleepJobPayload)
 				assert.True(t, ok)
 				assert.Equal(t, "1s", payload.Duration)
+				if tt.request.Parent != "" {
+					assert.Equal(t, tt.request.Parent, response.Parent.String())
+				}
 			}
 
 			mockService.AssertExpectations(t)
@@ -170,29 +539,115 @@ func TestCreateJobsHandler(t *testing.T) {
 	}
 }
 
-func TestGetJobsHandler(t *testing.T) {
-	mockService := new(MockJobsService)
-	handler := NewJobsHandler(mockService)
-	testUID := uuid.MustParse("ef09a103-f005-414c-9f1c-315a72f38281")
-	notFoundUID := uuid.New()
+func TestCreateJobsHandler_ValidationErrors(t *testing.T) {
+	handler := NewJobsHandler(new(MockJobsService))
 
 	tests := []struct {
-		name           string
-		uid            string
-		setupMock      func()
-		expectedStatus int
-		expectedError  string
+		name   string
+		body   string
+		fields []model.FieldError
 	}{
 		{
-			name: "successful retrieval",
-			uid:  testUID.String(),
-			setupMock: func() {
-				job := &model.Job{
-					UID:     testUID,
-					Type:    "sleep",
-					Payload: model.SleepJobPayload{Duration: "1s"},
-					Status:  model.JobStatusPending,
-				}
+			name:   "wrong payload type",
+			body:   `{"type": "sleep", "payload": {"duration": 5}}`,
+			fields: []model.FieldError{{Field: "payload.duration", Message: "expected string"}},
+		},
+		{
+			name:   "wrong request field type",
+			body:   `{"type": "sleep", "payload": {"duration": "1s"}, "weight": "heavy"}`,
+			fields: []model.FieldError{{Field: "weight", Message: "expected integer"}},
+		},
+		{
+			name: "every invalid field is listed",
+			body: `{"type": "math", "payload": {"number": -1}, "weight": 100, "requires": [""], "backoff": "linear:1s"}`,
+			fields: []model.FieldError{
+				{Field: "payload.number", Message: "cannot be negative"},
+				{Field: "requires", Message: "capability names cannot be empty"},
+				{Field: "weight", Message: "must be between 1 and 64"},
+				{Field: "backoff", Message: `unknown backoff strategy "linear"`},
+			},
+		},
+		{
+			name: "invalid overrides of the type's defaults",
+			body: `{"type": "sleep", "payload": {"duration": "1s"}, "timeout": "0s", "max_retries": -1, "priority": 101, "retention": "forever"}`,
+			fields: []model.FieldError{
+				{Field: "timeout", Message: "must be more than 0s"},
+				{Field: "max_retries", Message: "must be between 0 and 100"},
+				{Field: "priority", Message: "must be between -100 and 100"},
+				{Field: "retention", Message: `"forever" is not a duration like 10s`},
+			},
+		},
+		{
+			name:   "deadline in the past",
+			body:   `{"type": "sleep", "payload": {"duration": "1s"}, "deadline": "2020-01-01T00:00:00Z"}`,
+			fields: []model.FieldError{{Field: "deadline", Message: "must be in the future"}},
+		},
+		{
+			name:   "deadline not a time",
+			body:   `{"type": "sleep", "payload": {"duration": "1s"}, "deadline": "tomorrow"}`,
+			fields: []model.FieldError{{Field: "deadline", Message: `"tomorrow" is not an RFC 3339 time`}},
+		},
+		{
+			name: "binary field claiming stored data",
+			body: `{"type": "http", "payload": {"url": "https://example.com/", "binary_body": {"part": "body", "size": 3}}, "coalesce": {"key": "k", "window": "1m"}}`,
+			fields: []model.FieldError{
+				{Field: "payload.binary_body.size", Message: "is set once the data is stored"},
+				{Field: "payload", Message: "binary fields can't be used with unless_exists or coalesce"},
+			},
+		},
+		{
+			name:   "missing payload",
+			body:   `{"type": "sleep"}`,
+			fields: []model.FieldError{{Field: "payload", Message: "is required"}},
+		},
+		{
+			name:   "malformed body",
+			body:   `{"type": "sleep",`,
+			fields: []model.FieldError{{Message: "invalid JSON: unexpected end of input"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.CreateJobsHandler(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var resp model.ValidationErrorResponse
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, tt.fields, resp.Fields)
+			assert.NotEmpty(t, resp.Error)
+		})
+	}
+}
+
+func TestGetJobsHandler(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	testUID := uuid.MustParse("ef09a103-f005-414c-9f1c-315a72f38281")
+	notFoundUID := uuid.New()
+
+	tests := []struct {
+		name           string
+		uid            string
+		setupMock      func()
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "successful retrieval",
+			uid:  testUID.String(),
+			setupMock: func() {
+				job := &model.Job{
+					UID:     testUID,
+					Type:    "sleep",
+					Payload: model.SleepJobPayload{Duration: "1s"},
+					Status:  model.JobStatusPending,
+					Version: 2,
+				}
 				mockService.On("GetJobs", mock.Anything, testUID.String()).Return(job, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -240,6 +695,7 @@ func TestGetJobsHandler(t *testing.T) {
 				err := json.NewDecoder(w.Body).Decode(&response)
 				assert.NoError(t, err)
 				assert.Equal(t, testUID.String(), response.UID.String())
+				assert.Equal(t, `"2"`, w.Header().Get("ETag"))
 				assert.Equal(t, "sleep", response.Type)
 				assert.Equal(t, model.JobStatusPending, response.Status)
 				payload, ok := response.Payload.(model.SleepJobPayload)
@@ -254,6 +710,36 @@ func TestGetJobsHandler(t *testing.T) {
 	}
 }
 
+func TestGetJobsHandler_HAL(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	testUID := uuid.New()
+	mockService.On("GetJobs", mock.Anything, testUID.String()).Return(&model.Job{
+		UID:     testUID,
+		Type:    "sleep",
+		Payload: model.SleepJobPayload{Duration: "1s"},
+		Status:  model.JobStatusFailed,
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/"+testUID.String(), nil)
+	req.Header.Set("Accept", "application/hal+json, application/json;q=0.9")
+	w := httptest.NewRecorder()
+	handler.GetJobsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, model.HALMediaType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	var response struct {
+		UID   uuid.UUID             `json:"uid"`
+		Links map[string]model.Link `json:"_links"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, testUID, response.UID)
+	assert.Equal(t, model.Link{Href: "/v1/jobs/" + testUID.String()}, response.Links["self"])
+	assert.Equal(t, model.Link{Href: "/v1/jobs/" + testUID.String() + "/replay", Method: http.MethodPost}, response.Links["replay"])
+	mockService.AssertExpectations(t)
+}
+
 func TestListJobsHandler(t *testing.T) {
 	mockService := new(MockJobsService)
 	handler := NewJobsHandler(mockService)
@@ -271,7 +757,7 @@ func TestListJobsHandler(t *testing.T) {
 		queryParams: map[string]string{},
 		setupMock: func() {
 			mockService.On("ListJobs", mock.Anything, mock.MatchedBy(func(f *model.JobFilter) bool {
-				return f.Type == nil && f.Status == nil
+				return f.Type == nil && f.Status == nil && f.DeadlineMissed == nil
 			})).Return([]*model.Job{
 				{
 					UID:       testUID,
@@ -304,6 +790,37 @@ func TestListJobsHandler(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedLen:    1,
 		},
+		{
+			name: "successful list - missed deadlines",
+			queryParams: map[string]string{
+				"deadline_missed": "true",
+			},
+			setupMock: func() {
+				mockService.On("ListJobs", mock.Anything, mock.MatchedBy(func(f *model.JobFilter) bool {
+					return f.DeadlineMissed != nil && *f.DeadlineMissed
+				})).Return([]*model.Job{
+					{
+						UID:            testUID,
+						Type:           "sleep",
+						Payload:        model.SleepJobPayload{Duration: "1s"},
+						Deadline:       &now,
+						DeadlineMissed: true,
+						CreatedAt:      &now,
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    1,
+		},
+		{
+			name: "invalid deadline_missed",
+			queryParams: map[string]string{
+				"deadline_missed": "maybe",
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedLen:    0,
+		},
 		{
 			name: "invalid filter values",
 			queryParams: map[string]string{
@@ -344,3 +861,853 @@ func TestListJobsHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateJobsHandler(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	testUID := uuid.New()
+	staleUID := uuid.New()
+	runningUID := uuid.New()
+
+	tests := []struct {
+		name           string
+		uid            string
+		ifMatch        string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedETag   string
+	}{
+		{
+			name:    "successful update",
+			uid:     testUID.String(),
+			ifMatch: `"1"`,
+			body:    `{"labels": {"team": "core"}}`,
+			setupMock: func() {
+				mockService.On("UpdateJobs", mock.Anything, testUID.String(), int64(1), mock.Anything).Return(&model.Job{
+					UID:     testUID,
+					Type:    "sleep",
+					Payload: model.SleepJobPayload{Duration: "1s"},
+					Labels:  map[string]string{"team": "core"},
+					Status:  model.JobStatusPending,
+					Version: 2,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"2"`,
+		},
+		{
+			name:    "stale version",
+			uid:     staleUID.String(),
+			ifMatch: `"1"`,
+			body:    `{"labels": {"team": "core"}}`,
+			setupMock: func() {
+				mockService.On("UpdateJobs", mock.Anything, staleUID.String(), int64(1), mock.Anything).Return(nil, service.ErrVersionConflict)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "payload of running job",
+			uid:  runningUID.String(),
+			body: `{"payload": {"duration": "2s"}}`,
+			setupMock: func() {
+				mockService.On("UpdateJobs", mock.Anything, runningUID.String(), int64(0), mock.Anything).Return(nil, model.ErrJobNotMutable)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "patch rejected by policy",
+			uid:  staleUID.String(),
+			body: `{"payload": {"duration": "99h"}}`,
+			setupMock: func() {
+				mockService.On("UpdateJobs", mock.Anything, staleUID.String(), int64(0), mock.Anything).
+					Return(nil, fmt.Errorf("%w: duration too long", service.ErrJobRejected))
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "immutable field",
+			uid:            testUID.String(),
+			body:           `{"type": "math"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "weak If-Match",
+			uid:            testUID.String(),
+			ifMatch:        `W/"1"`,
+			body:           `{"labels": {"team": "core"}}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "invalid If-Match",
+			uid:            testUID.String(),
+			ifMatch:        "abc",
+			body:           `{}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid UUID",
+			uid:            "invalid-uuid",
+			body:           `{}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodPatch, "/jobs/"+tt.uid, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			handler.UpdateJobsHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedETag != "" {
+				assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchJobsHandler(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	testUID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedLen    int
+	}{
+		{
+			name: "successful search",
+			body: `{"query": {"or": [{"status": "failed"}, {"error_contains": "timeout"}]}, "limit": 10}`,
+			setupMock: func() {
+				mockService.On("SearchJobs", mock.Anything, mock.MatchedBy(func(r *model.SearchJobsRequest) bool {
+					return r.Limit == 10 && len(r.Query.Or) == 2
+				})).Return(&model.SearchJobsResponse{
+					Jobs: []*model.Job{
+						{
+							UID:       testUID,
+							Type:      "sleep",
+							Payload:   model.SleepJobPayload{Duration: "1s"},
+							Status:    model.JobStatusFailed,
+							CreatedAt: &now,
+						},
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    1,
+		},
+		{
+			name:           "invalid query",
+			body:           `{"query": {"and": [{"type": "invalid"}]}}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed body",
+			body:           `{"query": `,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodPost, "/jobs/search", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.SearchJobsHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response model.SearchJobsResponse
+				err := json.NewDecoder(w.Body).Decode(&response)
+				assert.NoError(t, err)
+				assert.Len(t, response.Jobs, tt.expectedLen)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchJobsHandler_HAL(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	testUID := uuid.New()
+	mockService.On("SearchJobs", mock.Anything, mock.Anything).Return(&model.SearchJobsResponse{
+		Jobs:       []*model.Job{{UID: testUID, Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Status: model.JobStatusRunning}},
+		NextCursor: "next",
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/search", bytes.NewBufferString(`{"query": {"status": "running"}}`))
+	req.Header.Set("Accept", model.HALMediaType)
+	w := httptest.NewRecorder()
+	handler.SearchJobsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, model.HALMediaType, w.Header().Get("Content-Type"))
+	var response struct {
+		Links    map[string]model.Link `json:"_links"`
+		Embedded struct {
+			Jobs []struct {
+				UID   uuid.UUID             `json:"uid"`
+				Links map[string]model.Link `json:"_links"`
+			} `json:"jobs"`
+		} `json:"_embedded"`
+		NextCursor string `json:"next_cursor"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, model.Link{Href: "/v1/jobs/search", Method: http.MethodPost}, response.Links["self"])
+	assert.Equal(t, "next", response.NextCursor)
+	if assert.Len(t, response.Embedded.Jobs, 1) {
+		assert.Equal(t, testUID, response.Embedded.Jobs[0].UID)
+		assert.NotContains(t, response.Embedded.Jobs[0].Links, "replay", "a running job can't be replayed yet")
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestGetJobLogsHandler(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	testUID := uuid.New()
+	notFoundUID := uuid.New()
+
+	tests := []struct {
+		name           string
+		path           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "logs",
+			path: "/jobs/" + testUID.String() + "/logs",
+			setupMock: func() {
+				mockService.On("StreamJobLogs", mock.Anything, testUID.String(), false, mock.Anything).
+					Run(func(args mock.Arguments) {
+						io.WriteString(args.Get(3).(io.Writer), "hello\n")
+					}).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "hello\n",
+		},
+		{
+			name: "follow with no output",
+			path: "/jobs/" + testUID.String() + "/logs?follow=true",
+			setupMock: func() {
+				mockService.On("StreamJobLogs", mock.Anything, testUID.String(), true, mock.Anything).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "job not found",
+			path: "/jobs/" + notFoundUID.String() + "/logs",
+			setupMock: func() {
+				mockService.On("StreamJobLogs", mock.Anything, notFoundUID.String(), false, mock.Anything).Return(service.ErrJobNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "job not found\n",
+		},
+		{
+			name:           "invalid follow",
+			path:           "/jobs/" + testUID.String() + "/logs?follow=maybe",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "follow must be a boolean\n",
+		},
+		{
+			name:           "invalid UUID",
+			path:           "/jobs/invalid-uuid/logs",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid UUID length: 12\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			handler.GetJobLogsHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+// multipartBody builds a multipart/form-data body from (name, filename,
+// content) parts, where an empty filename makes a plain form field
+func multipartBody(t *testing.T, parts ...[3]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var w io.Writer
+		var err error
+		if p[1] == "" {
+			w, err = mw.CreateFormField(p[0])
+		} else {
+			w, err = mw.CreateFormFile(p[0], p[1])
+		}
+		assert.NoError(t, err)
+		io.WriteString(w, p[2])
+	}
+	assert.NoError(t, mw.Close())
+	return &body, mw.FormDataContentType()
+}
+
+func TestCreateJobsHandler_Multipart(t *testing.T) {
+	manifest := `{"type":"sleep","payload":{"duration":"1s"}}`
+	binaryManifest := `{"type":"http","payload":{"method":"PUT","url":"https://example.com/","binary_body":{"part":"body"}}}`
+
+	tests := []struct {
+		name           string
+		parts          [][3]string
+		setupMock      func(m *MockJobsService)
+		expectedStatus int
+	}{
+		{
+			name:  "manifest and file",
+			parts: [][3]string{{"manifest", "", manifest}, {"file", "input.csv", "a,b\n1,2\n"}},
+			setupMock: func(m *MockJobsService) {
+				m.On("CreateJobWithInput", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+					return j.Type == "sleep" && j.Tenant == model.DefaultTenant
+				}), "input.csv", "application/octet-stream", "a,b\n1,2\n").Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing file",
+			parts:          [][3]string{{"manifest", "", manifest}},
+			setupMock:      func(m *MockJobsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "file before manifest",
+			parts:          [][3]string{{"file", "input.csv", "a"}, {"manifest", "", manifest}},
+			setupMock:      func(m *MockJobsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid manifest",
+			parts:          [][3]string{{"manifest", "", `{"type":"sleep","payload":{}}`}, {"file", "input.csv", "a"}},
+			setupMock:      func(m *MockJobsService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "uploads disabled",
+			parts: [][3]string{{"manifest", "", manifest}, {"file", "input.csv", "a"}},
+			setupMock: func(m *MockJobsService) {
+				m.On("CreateJobWithInput", mock.Anything, mock.Anything, "input.csv", mock.Anything, "a").Return(service.ErrNoBlobStore)
+			},
+			expectedStatus: http.StatusNotImplemented,
+		},
+		{
+			name:  "file too large",
+			parts: [][3]string{{"manifest", "", manifest}, {"file", "input.csv", "a"}},
+			setupMock: func(m *MockJobsService) {
+				m.On("CreateJobWithInput", mock.Anything, mock.Anything, "input.csv", mock.Anything, "a").Return(model.ErrInputTooLarge)
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:  "binary field part",
+			parts: [][3]string{{"manifest", "", binaryManifest}, {"body", "data.bin", "\x00\x01"}},
+			setupMock: func(m *MockJobsService) {
+				m.On("StoreJobBinary", mock.Anything, mock.Anything, "body", "application/octet-stream", "\x00\x01").Return(nil)
+				m.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool { return j.Type == "http" })).Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:  "part for no binary field",
+			parts: [][3]string{{"manifest", "", binaryManifest}, {"other", "data.bin", "x"}},
+			setupMock: func(m *MockJobsService) {
+				m.On("StoreJobBinary", mock.Anything, mock.Anything, "other", mock.Anything, "x").Return(service.ErrUnexpectedPart)
+				m.On("DeleteJobBinaries", mock.Anything, mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "binary field part missing",
+			parts: [][3]string{{"manifest", "", binaryManifest}, {"body", "a.bin", "a"}},
+			setupMock: func(m *MockJobsService) {
+				m.On("StoreJobBinary", mock.Anything, mock.Anything, "body", mock.Anything, "a").Return(nil)
+				m.On("CreateJobs", mock.Anything, mock.Anything).Return(service.ErrMissingPart)
+				m.On("DeleteJobBinaries", mock.Anything, mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockJobsService)
+			tt.setupMock(mockService)
+			handler := NewJobsHandler(mockService)
+
+			body, contentType := multipartBody(t, tt.parts...)
+			req := httptest.NewRequest(http.MethodPost, "/jobs", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+
+			handler.CreateJobsHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateJobsHandler_ClientDisconnect(t *testing.T) {
+	// A client hanging up mid-upload surfaces as a read error from the
+	// body, but is reported as the cancelled request it is
+	mockService := new(MockJobsService)
+	mockService.On("CreateJobWithInput", mock.Anything, mock.Anything, "input.csv", mock.Anything, "a").Return(io.ErrUnexpectedEOF)
+	handler := NewJobsHandler(mockService)
+
+	body, contentType := multipartBody(t, [3]string{"manifest", "", `{"type":"sleep","payload":{"duration":"1s"}}`}, [3]string{"file", "input.csv", "a"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/jobs", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+
+	handler.CreateJobsHandler(w, req)
+
+	assert.Equal(t, statusClientClosedRequest, w.Code)
+	assert.Equal(t, "the request was cancelled before the job was accepted\n", w.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateJobsHandler_PolicyUnavailable(t *testing.T) {
+	// OPA being down is an outage, not a denial
+	mockService := new(MockJobsService)
+	mockService.On("CreateJobs", mock.Anything, mock.Anything).
+		Return(fmt.Errorf("%w: %w: connection refused", service.ErrJobRejected, service.ErrPolicyUnavailable))
+	handler := NewJobsHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"type":"sleep","payload":{"duration":"1s"}}`))
+	w := httptest.NewRecorder()
+
+	handler.CreateJobsHandler(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, policyRetryAfter, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "policy unavailable")
+	mockService.AssertExpectations(t)
+}
+
+func TestJobsHandler_Forwarded(t *testing.T) {
+	handler := NewJobsHandler(new(MockJobsService))
+	req := httptest.NewRequest(http.MethodPost, "/jobs", nil)
+	req.Header.Set(model.ForwardedByHeader, "node-b")
+
+	// Without a cluster token nothing vouches for the header
+	assert.Equal(t, req.Context(), handler.forwarded(req))
+
+	handler.SetClusterToken("secret")
+	assert.Equal(t, req.Context(), handler.forwarded(req))
+	req.Header.Set(model.ClusterTokenHeader, "guess")
+	assert.Equal(t, req.Context(), handler.forwarded(req))
+
+	req.Header.Set(model.ClusterTokenHeader, "secret")
+	assert.Equal(t, service.Forwarded(req.Context(), "node-b"), handler.forwarded(req))
+}
+
+func TestCreateJobsHandler_TenantHeader(t *testing.T) {
+	mockService := new(MockJobsService)
+	var tenants []string
+	mockService.On("CreateJobs", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		tenants = append(tenants, args.Get(1).(*model.Job).Tenant)
+	}).Return(nil)
+	handler := NewJobsHandler(mockService)
+
+	submit := func(header, tenant string) {
+		req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"type":"sleep","payload":{"duration":"1s"}}`))
+		req.Header.Set(header, tenant)
+		w := httptest.NewRecorder()
+		handler.CreateJobsHandler(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	submit("X-Tenant-ID", "acme")
+	// Once another header is trusted, X-Tenant-ID is ignored
+	handler.SetTenantHeader("X-Authenticated-Tenant")
+	submit("X-Tenant-ID", "acme")
+	submit("X-Authenticated-Tenant", "globex")
+	assert.Equal(t, []string{"acme", model.DefaultTenant, "globex"}, tenants)
+}
+
+func TestCreateJobsHandler_UnlessExists(t *testing.T) {
+	existing := &model.Job{UID: uuid.New(), Type: "math", Status: model.JobStatusRunning, Labels: map[string]string{"customer": "x"}}
+	match := &model.ExistingJobMatch{Labels: []string{"customer"}}
+	mockService := new(MockJobsService)
+	mockService.On("CreateJobUnlessExists", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+		return j.Labels["customer"] == "x"
+	}), match).Return(existing, nil)
+	mockService.On("CreateJobUnlessExists", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+		return j.Labels["customer"] == "y"
+	}), match).Return(nil, nil)
+	handler := NewJobsHandler(mockService)
+
+	submit := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.CreateJobsHandler(w, req)
+		return w
+	}
+
+	// An equivalent job is returned in place of a new one
+	w := submit(`{"type":"math","payload":{"number":1},"labels":{"customer":"x"},"unless_exists":{"labels":["customer"]}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var job model.Job
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&job))
+	assert.Equal(t, existing.UID, job.UID)
+
+	w = submit(`{"type":"math","payload":{"number":1},"labels":{"customer":"y"},"unless_exists":{"labels":["customer"]}}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&job))
+	assert.NotEqual(t, existing.UID, job.UID)
+
+	// The match may only compare what the submission has
+	w = submit(`{"type":"math","payload":{"number":1},"unless_exists":{"labels":["customer"],"serialization_key":true}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp model.ValidationErrorResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, []model.FieldError{
+		{Field: "unless_exists.labels", Message: `the submission has no label "customer"`},
+		{Field: "unless_exists.serialization_key", Message: "the submission has no serialization key"},
+	}, resp.Fields)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateJobsHandler_Coalesce(t *testing.T) {
+	held := &model.Job{UID: uuid.New(), Type: "math", Status: model.JobStatusPending, Coalesced: 2}
+	mockService := new(MockJobsService)
+	mockService.On("CreateJobCoalesced", mock.Anything, mock.Anything, "refresh:x", 10*time.Second).Return(nil, nil).Once()
+	mockService.On("CreateJobCoalesced", mock.Anything, mock.Anything, "refresh:x", 10*time.Second).Return(held, nil).Once()
+	handler := NewJobsHandler(mockService)
+
+	submit := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.CreateJobsHandler(w, req)
+		return w
+	}
+
+	const body = `{"type":"math","payload":{"number":1},"coalesce":{"key":"refresh:x","window":"10s"}}`
+	assert.Equal(t, http.StatusCreated, submit(body).Code)
+	// Folded into the job already waiting
+	w := submit(body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var job model.Job
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&job))
+	assert.Equal(t, held.UID, job.UID)
+	assert.Equal(t, 2, job.Coalesced)
+
+	w = submit(`{"type":"math","payload":{"number":1},"coalesce":{"window":"2h"},"unless_exists":{}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp model.ValidationErrorResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, []model.FieldError{
+		{Field: "coalesce.key", Message: "is required"},
+		{Field: "coalesce.window", Message: "must be more than 0s and at most 1h0m0s"},
+		{Field: "coalesce", Message: "can't be combined with unless_exists"},
+	}, resp.Fields)
+	mockService.AssertExpectations(t)
+}
+
+func TestStreamJobsHandler(t *testing.T) {
+	mockService := new(MockJobsService)
+	mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+		return j.Type == "math"
+	})).Return(nil)
+	mockService.On("CreateJobs", mock.Anything, mock.MatchedBy(func(j *model.Job) bool {
+		return j.Type == "sleep"
+	})).Return(service.ErrBudgetExceeded)
+	handler := NewJobsHandler(mockService)
+
+	body := strings.Join([]string{
+		`{"type":"math","payload":{"number":1}}`,
+		``,
+		`{"type":"math","payload":`,
+		`{"type":"math","payload":{"number":"two"}}`,
+		`{"type":"sleep","payload":{"duration":"1s"}}`,
+		`{"type":"math","payload":{"number":` + strings.Repeat("9", maxStreamedJobLine) + `}}`,
+		`{"type":"math","payload":{"number":3}}`,
+	}, "\n")
+	req := httptest.NewRequest(http.MethodPost, "/jobs/stream", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.StreamJobsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	dec := json.NewDecoder(w.Body)
+	var results []model.StreamedJobResult
+	for dec.More() {
+		var result model.StreamedJobResult
+		assert.NoError(t, dec.Decode(&result))
+		results = append(results, result)
+	}
+	if !assert.Len(t, results, 6) {
+		return
+	}
+
+	assert.Equal(t, 1, results[0].Line)
+	assert.True(t, results[0].Accepted)
+	assert.NotNil(t, results[0].JobUID)
+	// The blank line is skipped but counted
+	assert.Equal(t, 3, results[1].Line)
+	assert.Equal(t, http.StatusBadRequest, results[1].Status)
+	assert.Equal(t, "invalid JSON at offset 26: unexpected end of JSON input", results[1].Error)
+	assert.Equal(t, 4, results[2].Line)
+	assert.Equal(t, http.StatusBadRequest, results[2].Status)
+	assert.NotEmpty(t, results[2].Fields)
+	assert.Equal(t, model.StreamedJobResult{Line: 5, Status: http.StatusTooManyRequests, Error: service.ErrBudgetExceeded.Error()}, results[3])
+	assert.Equal(t, model.StreamedJobResult{Line: 6, Status: http.StatusRequestEntityTooLarge, Error: errLineTooLong.Error()}, results[4])
+	assert.Equal(t, 7, results[5].Line)
+	assert.True(t, results[5].Accepted)
+	mockService.AssertNumberOfCalls(t, "CreateJobs", 3)
+}
+
+func TestStreamJobsHandler_AnswersAsLinesArrive(t *testing.T) {
+	mockService := new(MockJobsService)
+	mockService.On("CreateJobs", mock.Anything, mock.Anything).Return(nil)
+	srv := httptest.NewServer(http.HandlerFunc(NewJobsHandler(mockService).StreamJobsHandler))
+	defer srv.Close()
+
+	// Each line is answered before the next is sent
+	body, producer := io.Pipe()
+	req, err := http.NewRequest(http.MethodPost, srv.URL, body)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resps := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resps <- resp
+	}()
+
+	fmt.Fprintln(producer, `{"type":"math","payload":{"number":1}}`)
+	resp := <-resps
+	defer resp.Body.Close()
+	dec := json.NewDecoder(resp.Body)
+	for line := 1; line <= 3; line++ {
+		if line > 1 {
+			fmt.Fprintln(producer, `{"type":"math","payload":{"number":1}}`)
+		}
+		var result model.StreamedJobResult
+		assert.NoError(t, dec.Decode(&result))
+		assert.Equal(t, line, result.Line)
+		assert.True(t, result.Accepted)
+	}
+	producer.Close()
+	assert.False(t, dec.More())
+}
+
+func TestGetJobInputHandler(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	testUID := uuid.New()
+	noInputUID := uuid.New()
+
+	mockService.On("GetJobInput", mock.Anything, testUID.String()).
+		Return(&model.JobInput{Filename: "input.csv", ContentType: "text/csv", Size: 8}, "a,b\n1,2\n", nil)
+	mockService.On("GetJobInput", mock.Anything, noInputUID.String()).Return(nil, "", service.ErrNoInput)
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/"+testUID.String()+"/input", nil)
+	w := httptest.NewRecorder()
+	handler.GetJobInputHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a,b\n1,2\n", w.Body.String())
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=input.csv`, w.Header().Get("Content-Disposition"))
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs/"+noInputUID.String()+"/input", nil)
+	w = httptest.NewRecorder()
+	handler.GetJobInputHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs/invalid-uuid/input", nil)
+	w = httptest.NewRecorder()
+	handler.GetJobInputHandler(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetJobBinaryHandler(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	testUID := uuid.New()
+	size := int64(2)
+
+	mockService.On("GetJobBinary", mock.Anything, testUID.String(), "binary_body").
+		Return(&model.Binary{ContentType: "image/png", Size: &size}, "\x89P", nil)
+	mockService.On("GetJobBinary", mock.Anything, testUID.String(), "other").Return(nil, "", service.ErrNoBinary)
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/"+testUID.String()+"/binaries/binary_body", nil)
+	w := httptest.NewRecorder()
+	handler.GetJobBinaryHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "\x89P", w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "2", w.Header().Get("Content-Length"))
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs/"+testUID.String()+"/binaries/other", nil)
+	w = httptest.NewRecorder()
+	handler.GetJobBinaryHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs/invalid-uuid/binaries/binary_body", nil)
+	w = httptest.NewRecorder()
+	handler.GetJobBinaryHandler(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetJobLineageHandler(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	rootUID := uuid.New()
+	childUID := uuid.New()
+	missingUID := uuid.New()
+
+	lineage := &model.Lineage{
+		Job:   childUID,
+		Root:  rootUID,
+		Nodes: []model.LineageNode{{UID: rootUID, Type: "sleep"}, {UID: childUID, Type: "sleep"}},
+		Edges: []model.LineageEdge{{From: rootUID, To: childUID, Kind: model.LineageParent}},
+	}
+	mockService.On("GetJobLineage", mock.Anything, childUID.String()).Return(lineage, nil)
+	mockService.On("GetJobLineage", mock.Anything, missingUID.String()).Return(nil, service.ErrJobNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/"+childUID.String()+"/lineage", nil)
+	w := httptest.NewRecorder()
+	handler.GetJobLineageHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got model.Lineage
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, *lineage, got)
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs/"+missingUID.String()+"/lineage", nil)
+	w = httptest.NewRecorder()
+	handler.GetJobLineageHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs/invalid-uuid/lineage", nil)
+	w = httptest.NewRecorder()
+	handler.GetJobLineageHandler(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestReplayJobHandler(t *testing.T) {
+	mockService := new(MockJobsService)
+	handler := NewJobsHandler(mockService)
+	originalUID := uuid.New()
+	runningUID := uuid.New()
+	missingUID := uuid.New()
+	invalidUID := uuid.New()
+
+	replay := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "2s"}, ReplayedFrom: &originalUID, Status: model.JobStatusPending}
+	mockService.On("ReplayJob", mock.Anything, originalUID.String(), int64(0), &model.ReplayJobRequest{Payload: json.RawMessage(`{"duration":"2s"}`)}, "").Return(replay, nil)
+	mockService.On("ReplayJob", mock.Anything, originalUID.String(), int64(0), &model.ReplayJobRequest{}, "").Return(replay, nil)
+	mockService.On("ReplayJob", mock.Anything, originalUID.String(), int64(3), &model.ReplayJobRequest{}, "").Return(replay, nil)
+	mockService.On("ReplayJob", mock.Anything, originalUID.String(), int64(2), &model.ReplayJobRequest{}, "").Return(nil, service.ErrVersionConflict)
+	mockService.On("ReplayJob", mock.Anything, runningUID.String(), int64(0), mock.Anything, "").Return(nil, model.ErrJobNotFinished)
+	mockService.On("ReplayJob", mock.Anything, missingUID.String(), int64(0), mock.Anything, "").Return(nil, service.ErrJobNotFound)
+	mockService.On("ReplayJob", mock.Anything, invalidUID.String(), int64(0), mock.Anything, "").Return(nil, &model.ValidationError{Fields: []model.FieldError{{Field: "payload.duration", Message: "invalid duration"}}})
+
+	tests := []struct {
+		name           string
+		uid            string
+		body           string
+		ifMatch        string
+		expectedStatus int
+	}{
+		{name: "with payload override", uid: originalUID.String(), body: `{"payload":{"duration":"2s"}}`, expectedStatus: http.StatusCreated},
+		{name: "empty body", uid: originalUID.String(), expectedStatus: http.StatusCreated},
+		{name: "not finished", uid: runningUID.String(), body: `{}`, expectedStatus: http.StatusConflict},
+		{name: "not found", uid: missingUID.String(), body: `{}`, expectedStatus: http.StatusNotFound},
+		{name: "invalid override", uid: invalidUID.String(), body: `{"payload":{"duration":"soon"}}`, expectedStatus: http.StatusBadRequest},
+		{name: "malformed body", uid: originalUID.String(), body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid uid", uid: "invalid-uuid", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "matching version", uid: originalUID.String(), body: `{}`, ifMatch: `"3"`, expectedStatus: http.StatusCreated},
+		{name: "stale version", uid: originalUID.String(), body: `{}`, ifMatch: `"2"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "weak version", uid: originalUID.String(), body: `{}`, ifMatch: `W/"3"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "invalid If-Match", uid: originalUID.String(), body: `{}`, ifMatch: `3`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/jobs/"+tt.uid+"/replay", strings.NewReader(tt.body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.ReplayJobHandler(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var got model.Job
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, &originalUID, got.ReplayedFrom)
+			}
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTraceID(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		want        string
+	}{
+		{name: "valid", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "future version with more fields", traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "missing"},
+		{name: "uppercase", traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "all zeros", traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "invalid version", traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "extra fields in version 00", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "short trace id", traceparent: "00-4bf92f35-00f067aa0ba902b7-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, traceID(tt.traceparent))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+type JobTypesHandler struct {
+	service service.JobTypesService
+}
+
+func NewJobTypesHandler(service service.JobTypesService) *JobTypesHandler {
+	return &JobTypesHandler{service: service}
+}
+
+func (h *JobTypesHandler) ListJobTypesHandler(w http.ResponseWriter, r *http.Request) {
+	types, err := h.service.ListJobTypes(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(types)
+}
+
+// ConfigureJobTypeHandler replaces a job type's runtime settings. Unknown
+// settings are refused rather than ignored, so a typo can't look applied.
+// With blob storage configured the settings outlast a restart; otherwise
+// they last until the service stops.
+func (h *JobTypesHandler) ConfigureJobTypeHandler(w http.ResponseWriter, r *http.Request) {
+	var cfg model.JobTypeConfig
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		writeBadRequest(w, model.DecodeError(err))
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	jt, err := h.service.ConfigureJobType(r.Context(), extractParentPathSegment(r.URL.Path), cfg)
+	if errors.Is(err, service.ErrUnknownJobType) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrAdaptiveConcurrency) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(jt)
+}
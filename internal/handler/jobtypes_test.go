@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockJobTypesService is a mock implementation of service.JobTypesService
+type MockJobTypesService struct {
+	mock.Mock
+}
+
+func (m *MockJobTypesService) ListJobTypes(ctx context.Context) ([]model.JobType, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.JobType), args.Error(1)
+}
+
+func (m *MockJobTypesService) ConfigureJobType(ctx context.Context, name string, cfg model.JobTypeConfig) (*model.JobType, error) {
+	args := m.Called(ctx, name, cfg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.JobType), args.Error(1)
+}
+
+func TestListJobTypesHandler(t *testing.T) {
+	mockService := new(MockJobTypesService)
+	handler := NewJobTypesHandler(mockService)
+
+	mockService.On("ListJobTypes", mock.Anything).Return([]model.JobType{
+		{Name: "math", Description: "Sums the integers below number", Schema: map[string]any{"type": "object"}},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/job-types", nil)
+	w := httptest.NewRecorder()
+	handler.ListJobTypesHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got []model.JobType
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Len(t, got, 1)
+	assert.Equal(t, "math", got[0].Name)
+	assert.Equal(t, "object", got[0].Schema["type"])
+}
+
+func TestConfigureJobTypeHandler(t *testing.T) {
+	mockService := new(MockJobTypesService)
+	handler := NewJobTypesHandler(mockService)
+	mockService.On("ConfigureJobType", mock.Anything, "math", model.JobTypeConfig{Timeout: "45s", Concurrency: 3}).
+		Return(&model.JobType{Name: "math", DefaultTimeout: "45s", Concurrency: 3}, nil)
+	mockService.On("ConfigureJobType", mock.Anything, "email", mock.Anything).
+		Return(nil, fmt.Errorf("%w %q", service.ErrUnknownJobType, "email"))
+	mockService.On("ConfigureJobType", mock.Anything, "http", mock.Anything).
+		Return(nil, fmt.Errorf("%w: %s", service.ErrAdaptiveConcurrency, "http"))
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "applied",
+			path:       "/job-types/math/config",
+			body:       `{"timeout": "45s", "concurrency": 3}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `"default_timeout":"45s"`,
+		},
+		{
+			name:       "unknown job type",
+			path:       "/job-types/email/config",
+			body:       `{}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `unknown job type "email"`,
+		},
+		{
+			name:       "adaptive concurrency",
+			path:       "/job-types/http/config",
+			body:       `{"concurrency": 3}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   `limit adjusts itself`,
+		},
+		{
+			name:       "invalid settings",
+			path:       "/job-types/math/config",
+			body:       `{"timeout": "soon", "concurrency": -1}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"field":"concurrency","message":"cannot be negative"}`,
+		},
+		{
+			name:       "unknown setting",
+			path:       "/job-types/math/config",
+			body:       `{"circuit_breaker": {"failures": 5}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `unknown field \"circuit_breaker\"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ConfigureJobTypeHandler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMaintenanceService is a mock implementation of service.MaintenanceService
+type MockMaintenanceService struct {
+	mock.Mock
+}
+
+func (m *MockMaintenanceService) GetMaintenance(ctx context.Context) (*model.MaintenanceStatus, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MaintenanceStatus), args.Error(1)
+}
+
+func (m *MockMaintenanceService) SetMaintenanceOverride(ctx context.Context, override *model.MaintenanceOverride) (*model.MaintenanceStatus, error) {
+	args := m.Called(ctx, override)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MaintenanceStatus), args.Error(1)
+}
+
+func TestGetMaintenanceHandler(t *testing.T) {
+	mockService := new(MockMaintenanceService)
+	handler := NewMaintenanceHandler(mockService)
+
+	mockService.On("GetMaintenance", mock.Anything).Return(&model.MaintenanceStatus{Paused: true, Override: "pause"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil)
+	w := httptest.NewRecorder()
+	handler.GetMaintenanceHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got model.MaintenanceStatus
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.True(t, got.Paused)
+	assert.Equal(t, "pause", got.Override)
+}
+
+func TestSetMaintenanceOverrideHandler(t *testing.T) {
+	mockService := new(MockMaintenanceService)
+	handler := NewMaintenanceHandler(mockService)
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "pause",
+			body: `{"override": "pause"}`,
+			setupMock: func() {
+				mockService.On("SetMaintenanceOverride", mock.Anything, &model.MaintenanceOverride{Override: "pause"}).
+					Return(&model.MaintenanceStatus{Paused: true, Override: "pause"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "follow the schedule again",
+			body: `{"override": ""}`,
+			setupMock: func() {
+				mockService.On("SetMaintenanceOverride", mock.Anything, &model.MaintenanceOverride{}).
+					Return(&model.MaintenanceStatus{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown override",
+			body:           `{"override": "drain"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `override must be "pause", "resume" or empty`,
+		},
+		{
+			name:           "invalid JSON",
+			body:           `{`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.SetMaintenanceOverrideHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedError)
+			}
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
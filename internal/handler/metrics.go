@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/dnakolan/worker-pool-service/internal/metrics"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// MetricsHandler serves metrics for Prometheus to scrape and a Grafana
+// dashboard charting them
+type MetricsHandler struct {
+	service service.MetricsService
+}
+
+func NewMetricsHandler(service service.MetricsService) *MetricsHandler {
+	return &MetricsHandler{service: service}
+}
+
+// GetMetricsHandler answers in OpenMetrics, which carries exemplars, when
+// the scraper accepts it and in the Prometheus text format otherwise
+func (h *MetricsHandler) GetMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	contentType := metrics.PrometheusContentType
+	if openMetrics {
+		contentType = metrics.OpenMetricsContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	if err := h.service.WriteMetrics(r.Context(), w, openMetrics); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetDashboardHandler serves the dashboard as JSON to import into Grafana
+func (h *MetricsHandler) GetDashboardHandler(w http.ResponseWriter, r *http.Request) {
+	dashboard, err := h.service.GetDashboard(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(dashboard)
+}
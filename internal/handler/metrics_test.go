@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMetricsService is a mock implementation of service.MetricsService
+type MockMetricsService struct {
+	mock.Mock
+}
+
+func (m *MockMetricsService) WriteMetrics(ctx context.Context, w io.Writer, openMetrics bool) error {
+	args := m.Called(ctx, w, openMetrics)
+	if args.Error(0) == nil {
+		io.WriteString(w, "wps_queue_depth 3\n")
+	}
+	return args.Error(0)
+}
+
+func (m *MockMetricsService) GetDashboard(ctx context.Context) (map[string]any, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]any), args.Error(1)
+}
+
+func TestGetMetricsHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantOpenMetrics bool
+		wantContentType string
+	}{
+		{
+			name:            "prometheus",
+			accept:          "text/plain;version=0.0.4;q=0.3,*/*;q=0.1",
+			wantContentType: metrics.PrometheusContentType,
+		},
+		{
+			name:            "openmetrics",
+			accept:          "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5",
+			wantOpenMetrics: true,
+			wantContentType: metrics.OpenMetricsContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockMetricsService)
+			mockService.On("WriteMetrics", mock.Anything, mock.Anything, tt.wantOpenMetrics).Return(nil)
+			handler := NewMetricsHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/metrics", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			handler.GetMetricsHandler(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "wps_queue_depth 3\n", w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetDashboardHandler(t *testing.T) {
+	mockService := new(MockMetricsService)
+	mockService.On("GetDashboard", mock.Anything).Return(map[string]any{"uid": "worker-pool-service"}, nil)
+	handler := NewMetricsHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/dashboard", nil)
+	w := httptest.NewRecorder()
+	handler.GetDashboardHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var dashboard map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &dashboard))
+	assert.Equal(t, "worker-pool-service", dashboard["uid"])
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// snapshotTimeFormat names snapshot files after when they were taken
+const snapshotTimeFormat = "20060102T150405Z"
+
+// QueueHandler serves the admin endpoints that snapshot the job queue and
+// replay snapshots into it
+type QueueHandler struct {
+	service service.QueueService
+}
+
+func NewQueueHandler(service service.QueueService) *QueueHandler {
+	return &QueueHandler{service: service}
+}
+
+func (h *QueueHandler) SnapshotQueueHandler(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.service.SnapshotQueue(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := "queue-" + snapshot.TakenAt.UTC().Format(snapshotTimeFormat) + ".json"
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+func (h *QueueHandler) ReplayQueueHandler(w http.ResponseWriter, r *http.Request) {
+	var snapshot model.QueueSnapshot
+	if err := json.NewDecoder(r.Body).Decode(&snapshot); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.ReplayQueue(r.Context(), &snapshot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
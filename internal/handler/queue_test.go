@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockQueueService is a mock implementation of service.QueueService
+type MockQueueService struct {
+	mock.Mock
+}
+
+func (m *MockQueueService) SnapshotQueue(ctx context.Context) (*model.QueueSnapshot, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.QueueSnapshot), args.Error(1)
+}
+
+func (m *MockQueueService) ReplayQueue(ctx context.Context, snapshot *model.QueueSnapshot) (*model.ReplayResult, error) {
+	args := m.Called(ctx, snapshot)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReplayResult), args.Error(1)
+}
+
+func TestSnapshotQueueHandler(t *testing.T) {
+	mockService := new(MockQueueService)
+	handler := NewQueueHandler(mockService)
+	job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 7}, Status: model.JobStatusPending}
+	takenAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	mockService.On("SnapshotQueue", mock.Anything).Return(&model.QueueSnapshot{TakenAt: takenAt, Jobs: []*model.Job{job}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/queue/snapshot", nil)
+	w := httptest.NewRecorder()
+	handler.SnapshotQueueHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=queue-20261016T120000Z.json", w.Header().Get("Content-Disposition"))
+	var got model.QueueSnapshot
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Len(t, got.Jobs, 1)
+	assert.Equal(t, model.MathJobPayload{Number: 7}, got.Jobs[0].Payload)
+}
+
+func TestReplayQueueHandler(t *testing.T) {
+	jobID := uuid.New()
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(m *MockQueueService)
+		expectedStatus int
+	}{
+		{
+			name: "replays the snapshot",
+			body: `{"jobs": [{"uid": "` + jobID.String() + `", "type": "math", "payload": {"number": 7}, "status": "pending"}]}`,
+			setupMock: func(m *MockQueueService) {
+				m.On("ReplayQueue", mock.Anything, mock.MatchedBy(func(s *model.QueueSnapshot) bool {
+					return len(s.Jobs) == 1 && s.Jobs[0].UID == jobID
+				})).Return(&model.ReplayResult{Queued: []uuid.UUID{jobID}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid payload",
+			body:           `{"jobs": [{"type": "math", "payload": {"number": -1}}]}`,
+			setupMock:      func(m *MockQueueService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not json",
+			body:           `queue`,
+			setupMock:      func(m *MockQueueService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockQueueService)
+			tt.setupMock(mockService)
+			handler := NewQueueHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/queue/replay", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ReplayQueueHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
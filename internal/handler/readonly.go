@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ReadOnly turns away every request that could change something, for a
+// replica that only serves reads. Writers are told to go to the primary
+// instead.
+func ReadOnly(primary string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+			http.Error(w, fmt.Sprintf("this instance is a read-only replica; send %s requests to %s", r.Method, primary), http.StatusMethodNotAllowed)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// ShadowRunsHandler lists how the runs of shadow executors compared with
+// the jobs they copied
+type ShadowRunsHandler struct {
+	service service.ShadowRunsService
+}
+
+func NewShadowRunsHandler(service service.ShadowRunsService) *ShadowRunsHandler {
+	return &ShadowRunsHandler{service: service}
+}
+
+func (h *ShadowRunsHandler) ListShadowRunsHandler(w http.ResponseWriter, r *http.Request) {
+	jobType := r.URL.Query().Get("type")
+	if jobType != "" && !model.IsBuiltinJobType(jobType) {
+		http.Error(w, fmt.Sprintf("unknown job type %q", jobType), http.StatusBadRequest)
+		return
+	}
+	runs, err := h.service.ListShadowRuns(r.Context(), jobType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(runs)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockShadowRunsService is a mock implementation of service.ShadowRunsService
+type MockShadowRunsService struct {
+	mock.Mock
+}
+
+func (m *MockShadowRunsService) ListShadowRuns(ctx context.Context, jobType string) ([]model.ShadowRun, error) {
+	args := m.Called(ctx, jobType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ShadowRun), args.Error(1)
+}
+
+func TestListShadowRunsHandler(t *testing.T) {
+	runs := []model.ShadowRun{{
+		JobUID:       uuid.New(),
+		JobType:      "math",
+		Differences:  []string{"result.result"},
+		Result:       json.RawMessage(`{"result":45}`),
+		ShadowResult: json.RawMessage(`{"result":46}`),
+	}}
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(m *MockShadowRunsService)
+		wantStatus int
+		want       []model.ShadowRun
+	}{
+		{
+			name: "every type",
+			setupMock: func(m *MockShadowRunsService) {
+				m.On("ListShadowRuns", mock.Anything, "").Return(runs, nil)
+			},
+			wantStatus: http.StatusOK,
+			want:       runs,
+		},
+		{
+			name:  "one type",
+			query: "?type=sleep",
+			setupMock: func(m *MockShadowRunsService) {
+				m.On("ListShadowRuns", mock.Anything, "sleep").Return([]model.ShadowRun{}, nil)
+			},
+			wantStatus: http.StatusOK,
+			want:       []model.ShadowRun{},
+		},
+		{
+			name:       "unknown type",
+			query:      "?type=email",
+			setupMock:  func(m *MockShadowRunsService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockShadowRunsService)
+			tt.setupMock(mockService)
+			handler := NewShadowRunsHandler(mockService)
+
+			w := httptest.NewRecorder()
+			handler.ListShadowRunsHandler(w, httptest.NewRequest(http.MethodGet, "/admin/shadow-runs"+tt.query, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.want != nil {
+				var got []model.ShadowRun
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tt.want, got)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
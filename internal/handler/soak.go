@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// SoakHandler serves the latest leak self-check. It is only routed when soak
+// checks are enabled in the configuration.
+type SoakHandler struct {
+	service service.SoakService
+}
+
+func NewSoakHandler(service service.SoakService) *SoakHandler {
+	return &SoakHandler{service: service}
+}
+
+func (h *SoakHandler) GetSoakHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetSoakReport(r.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrNoSoakReport) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
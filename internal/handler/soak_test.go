@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSoakService is a mock implementation of service.SoakService
+type MockSoakService struct {
+	mock.Mock
+}
+
+func (m *MockSoakService) GetSoakReport(ctx context.Context) (*model.SoakReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SoakReport), args.Error(1)
+}
+
+func TestGetSoakHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		report         *model.SoakReport
+		err            error
+		expectedStatus int
+	}{
+		{
+			name: "report with warnings",
+			report: &model.SoakReport{
+				Goroutines: 42,
+				Pool:       model.PoolInternals{Jobs: 10},
+				Warnings:   []string{"goroutines rose at each of the last 5 checks, from 30 to 42"},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no check yet",
+			err:            service.ErrNoSoakReport,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockSoakService)
+			mockService.On("GetSoakReport", mock.Anything).Return(tt.report, tt.err)
+			handler := NewSoakHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/soak", nil)
+			w := httptest.NewRecorder()
+			handler.GetSoakHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.report != nil {
+				var got model.SoakReport
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, 42, got.Goroutines)
+				assert.Equal(t, tt.report.Warnings, got.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+type StatsHandler struct {
+	service service.StatsService
+}
+
+func NewStatsHandler(service service.StatsService) *StatsHandler {
+	return &StatsHandler{service: service}
+}
+
+// GetStatsHandler describes this instance, or with scope=cluster sums the
+// load of every instance in the cluster
+func (h *StatsHandler) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	var stats any
+	var err error
+	switch scope := r.URL.Query().Get("scope"); scope {
+	case "", model.StatsScopeLocal:
+		stats, err = h.service.GetStats(r.Context())
+	case model.StatsScopeCluster:
+		stats, err = h.service.GetClusterStats(r.Context())
+	default:
+		http.Error(w, fmt.Sprintf("scope must be %q or %q", model.StatsScopeLocal, model.StatsScopeCluster), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *StatsHandler) GetTimeseriesHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := parseTimeseriesRequest(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	ts, err := h.service.GetTimeseries(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ts)
+}
+
+// parseTimeseriesRequest reads a timeseries request from the query string,
+// counting completed jobs per minute over the last hour by default
+func parseTimeseriesRequest(r *http.Request) (*model.TimeseriesRequest, error) {
+	query := r.URL.Query()
+	req := &model.TimeseriesRequest{
+		Metric: model.TimeseriesCompleted,
+		Type:   query.Get("type"),
+		Window: time.Hour,
+		Step:   time.Minute,
+	}
+	if v := query.Get("metric"); v != "" {
+		req.Metric = v
+	}
+
+	var problems model.ValidationError
+	duration := func(name string, d *time.Duration) {
+		if v := query.Get(name); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil {
+				problems.Add(name, "%q is not a duration", v)
+				return
+			}
+			*d = parsed
+		}
+	}
+	duration("window", &req.Window)
+	duration("step", &req.Step)
+	if err := problems.Err(); err != nil {
+		return nil, err
+	}
+	return req, req.Validate()
+}
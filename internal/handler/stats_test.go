@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStatsService is a mock implementation of service.StatsService
+type MockStatsService struct {
+	mock.Mock
+}
+
+func (m *MockStatsService) GetStats(ctx context.Context) (*model.PoolStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PoolStats), args.Error(1)
+}
+
+func (m *MockStatsService) GetClusterStats(ctx context.Context) (*model.ClusterStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ClusterStats), args.Error(1)
+}
+
+func (m *MockStatsService) GetTimeseries(ctx context.Context, req *model.TimeseriesRequest) (*model.Timeseries, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Timeseries), args.Error(1)
+}
+
+func TestGetStatsHandler(t *testing.T) {
+	mockService := new(MockStatsService)
+	handler := NewStatsHandler(mockService)
+
+	mockService.On("GetStats", mock.Anything).Return(&model.PoolStats{
+		Workers:       2,
+		QueueDepth:    1,
+		QueueCapacity: 10,
+		Jobs:          map[model.JobStatus]int{model.JobStatusCompleted: 3},
+		TenantUsage: map[string]model.TenantUsage{
+			"team-a": {ExecutionSeconds: 12, BudgetSeconds: 3600},
+		},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/pool/stats", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetStatsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response model.PoolStats
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, response.Jobs[model.JobStatusCompleted])
+	assert.Equal(t, float64(3600), response.TenantUsage["team-a"].BudgetSeconds)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetStatsHandler_ClusterScope(t *testing.T) {
+	mockService := new(MockStatsService)
+	handler := NewStatsHandler(mockService)
+
+	mockService.On("GetClusterStats", mock.Anything).Return(model.NewClusterStats([]model.NodeStats{
+		{Node: "node-a", NodeLoad: model.NodeLoad{Slots: 2, SlotsInUse: 2, QueueDepth: 4}},
+		{Node: "node-b", NodeLoad: model.NodeLoad{Slots: 2, QueueDepth: 1}},
+	}), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/pool/stats?scope=cluster", nil)
+	w := httptest.NewRecorder()
+	handler.GetStatsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response model.ClusterStats
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, 5, response.QueueDepth)
+	assert.Equal(t, 0.5, response.Utilization)
+	assert.Len(t, response.Nodes, 2)
+	mockService.AssertExpectations(t)
+
+	req = httptest.NewRequest(http.MethodGet, "/pool/stats?scope=region", nil)
+	w = httptest.NewRecorder()
+	handler.GetStatsHandler(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetTimeseriesHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		want       *model.TimeseriesRequest
+		wantStatus int
+		wantFields []model.FieldError
+	}{
+		{
+			name:       "defaults",
+			want:       &model.TimeseriesRequest{Metric: model.TimeseriesCompleted, Window: time.Hour, Step: time.Minute},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "every parameter",
+			query:      "?metric=failed&type=math&window=24h&step=15m",
+			want:       &model.TimeseriesRequest{Metric: model.TimeseriesFailed, Type: "math", Window: 24 * time.Hour, Step: 15 * time.Minute},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "malformed durations",
+			query:      "?window=day&step=1",
+			wantStatus: http.StatusBadRequest,
+			wantFields: []model.FieldError{
+				{Field: "window", Message: `"day" is not a duration`},
+				{Field: "step", Message: `"1" is not a duration`},
+			},
+		},
+		{
+			name:       "invalid request",
+			query:      "?metric=cancelled",
+			wantStatus: http.StatusBadRequest,
+			wantFields: []model.FieldError{{Field: "metric", Message: "must be submitted, started, completed or failed"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockStatsService)
+			ts := &model.Timeseries{Metric: model.TimeseriesCompleted, Window: "1h0m0s", Step: "1m0s", Points: []model.TimeseriesPoint{}}
+			if tt.want != nil {
+				mockService.On("GetTimeseries", mock.Anything, tt.want).Return(ts, nil)
+			}
+			handler := NewStatsHandler(mockService)
+
+			w := httptest.NewRecorder()
+			handler.GetTimeseriesHandler(w, httptest.NewRequest(http.MethodGet, "/stats/timeseries"+tt.query, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantFields != nil {
+				var resp model.ValidationErrorResponse
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(t, tt.wantFields, resp.Fields)
+			} else {
+				var got model.Timeseries
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, *ts, got)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
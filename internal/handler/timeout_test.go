@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/short", slow)
+	mux.Handle("/long", Timeout(time.Second)(slow))
+	mux.Handle("/unlimited", Timeout(0)(slow))
+
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	get := func(path string) (string, error) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	t.Run("server deadline", func(t *testing.T) {
+		_, err := get("/short")
+		assert.Error(t, err)
+	})
+
+	t.Run("extended", func(t *testing.T) {
+		body, err := get("/long")
+		require.NoError(t, err)
+		assert.Equal(t, "done", body)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		body, err := get("/unlimited")
+		require.NoError(t, err)
+		assert.Equal(t, "done", body)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		var deadline time.Time
+		var ok bool
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok = r.Context().Deadline()
+		})
+
+		Timeout(time.Minute)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+		Timeout(0)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+// UsageHandler reports what tenants have used, for chargeback
+type UsageHandler struct {
+	service service.UsageService
+}
+
+func NewUsageHandler(service service.UsageService) *UsageHandler {
+	return &UsageHandler{service: service}
+}
+
+// GetTenantUsageHandler reports a tenant's usage in the month given as
+// ?month=2026-10, or the current month
+func (h *UsageHandler) GetTenantUsageHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := extractParentPathSegment(r.URL.Path)
+	if tenant == "" {
+		http.Error(w, "tenant is required", http.StatusBadRequest)
+		return
+	}
+	month := time.Now().UTC()
+	if v := r.URL.Query().Get("month"); v != "" {
+		var err error
+		if month, err = time.Parse(model.UsageMonthFormat, v); err != nil {
+			http.Error(w, "month must be written like 2026-10", http.StatusBadRequest)
+			return
+		}
+	}
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	report, err := h.service.GetTenantUsage(r.Context(), tenant, month)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
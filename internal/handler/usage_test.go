@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUsageService is a mock implementation of service.UsageService
+type MockUsageService struct {
+	mock.Mock
+}
+
+func (m *MockUsageService) GetTenantUsage(ctx context.Context, tenant string, month time.Time) (*model.UsageReport, error) {
+	args := m.Called(ctx, tenant, month)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UsageReport), args.Error(1)
+}
+
+func TestGetTenantUsageHandler(t *testing.T) {
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	september := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantMonth  time.Time
+		wantStatus int
+	}{
+		{name: "current month", path: "/tenants/team-a/usage", wantMonth: thisMonth, wantStatus: http.StatusOK},
+		{name: "given month", path: "/tenants/team-a/usage?month=2026-09", wantMonth: september, wantStatus: http.StatusOK},
+		{name: "malformed month", path: "/tenants/team-a/usage?month=September", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUsageService)
+			report := &model.UsageReport{
+				Tenant:           "team-a",
+				Month:            tt.wantMonth.Format(model.UsageMonthFormat),
+				Jobs:             4,
+				Failed:           1,
+				ExecutionSeconds: 12.5,
+				ByType:           map[string]model.JobTypeUsage{"math": {Jobs: 4, Failed: 1, ExecutionSeconds: 12.5}},
+			}
+			if tt.wantStatus == http.StatusOK {
+				mockService.On("GetTenantUsage", mock.Anything, "team-a", tt.wantMonth).Return(report, nil)
+			}
+			handler := NewUsageHandler(mockService)
+
+			w := httptest.NewRecorder()
+			handler.GetTenantUsageHandler(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var got model.UsageReport
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, *report, got)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
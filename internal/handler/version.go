@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+type VersionHandler struct {
+	info model.BuildInfo
+}
+
+func NewVersionHandler(info model.BuildInfo) *VersionHandler {
+	return &VersionHandler{info: info}
+}
+
+func (h *VersionHandler) GetVersionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.info)
+}
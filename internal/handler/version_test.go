@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVersionHandler(t *testing.T) {
+	info := model.BuildInfo{
+		Version:   "v1.4.0",
+		Commit:    "abc123",
+		BuildDate: "2024-05-01T12:00:00Z",
+		GoVersion: "go1.24.3",
+		Features:  map[string]string{"job_storage": "memory", "blob_storage": "s3"},
+	}
+	handler := NewVersionHandler(info)
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	w := httptest.NewRecorder()
+	handler.GetVersionHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "abc123", body["git_sha"])
+	assert.Equal(t, "2024-05-01T12:00:00Z", body["build_date"])
+
+	var got model.BuildInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, info, got)
+}
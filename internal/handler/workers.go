@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+)
+
+type WorkersHandler struct {
+	service service.WorkersService
+}
+
+func NewWorkersHandler(service service.WorkersService) *WorkersHandler {
+	return &WorkersHandler{service: service}
+}
+
+func (h *WorkersHandler) LeaseJobHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.LeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequest(w, model.DecodeError(err))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.LeaseJob(r.Context(), &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if job == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(job)
+}
+
+func (h *WorkersHandler) HeartbeatHandler(w http.ResponseWriter, r *http.Request) {
+	workerID := extractParentPathSegment(r.URL.Path)
+
+	var req model.HeartbeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequest(w, model.DecodeError(err))
+		return
+	}
+
+	if err := h.service.Heartbeat(r.Context(), workerID, &req); err != nil {
+		writeWorkerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WorkersHandler) CompleteJobHandler(w http.ResponseWriter, r *http.Request) {
+	workerID := extractParentPathSegment(r.URL.Path)
+
+	var req model.CompleteJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequest(w, model.DecodeError(err))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.CompleteJob(r.Context(), workerID, &req); err != nil {
+		writeWorkerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeWorkerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrJobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrNotLeased):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWorkersService is a mock implementation of service.WorkersService
+type MockWorkersService struct {
+	mock.Mock
+}
+
+func (m *MockWorkersService) LeaseJob(ctx context.Context, req *model.LeaseRequest) (*model.Job, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Job), args.Error(1)
+}
+
+func (m *MockWorkersService) Heartbeat(ctx context.Context, workerID string, req *model.HeartbeatRequest) error {
+	args := m.Called(ctx, workerID, req)
+	return args.Error(0)
+}
+
+func (m *MockWorkersService) CompleteJob(ctx context.Context, workerID string, req *model.CompleteJobRequest) error {
+	args := m.Called(ctx, workerID, req)
+	return args.Error(0)
+}
+
+func TestLeaseJobHandler(t *testing.T) {
+	mockService := new(MockWorkersService)
+	handler := NewWorkersHandler(mockService)
+	testUID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "job leased",
+			body: `{"worker_id": "gpu-1", "capabilities": ["gpu"]}`,
+			setupMock: func() {
+				mockService.On("LeaseJob", mock.Anything, mock.MatchedBy(func(r *model.LeaseRequest) bool {
+					return r.WorkerID == "gpu-1"
+				})).Return(&model.Job{
+					UID:      testUID,
+					Type:     "math",
+					Payload:  model.MathJobPayload{Number: 2},
+					Status:   model.JobStatusRunning,
+					LeasedBy: "gpu-1",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "no job available",
+			body: `{"worker_id": "cpu-1", "wait": "1s"}`,
+			setupMock: func() {
+				mockService.On("LeaseJob", mock.Anything, mock.MatchedBy(func(r *model.LeaseRequest) bool {
+					return r.WorkerID == "cpu-1"
+				})).Return(nil, nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "missing worker id",
+			body:           `{}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodPost, "/workers/lease", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.LeaseJobHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response model.Job
+				err := json.NewDecoder(w.Body).Decode(&response)
+				assert.NoError(t, err)
+				assert.Equal(t, testUID, response.UID)
+				assert.Equal(t, "gpu-1", response.LeasedBy)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHeartbeatHandler(t *testing.T) {
+	mockService := new(MockWorkersService)
+	handler := NewWorkersHandler(mockService)
+	leasedUID := uuid.New()
+	otherUID := uuid.New()
+
+	mockService.On("Heartbeat", mock.Anything, "gpu-1", &model.HeartbeatRequest{JobUID: leasedUID}).Return(nil)
+	mockService.On("Heartbeat", mock.Anything, "gpu-1", &model.HeartbeatRequest{JobUID: otherUID}).Return(service.ErrNotLeased)
+
+	tests := []struct {
+		name           string
+		uid            uuid.UUID
+		expectedStatus int
+	}{
+		{"lease renewed", leasedUID, http.StatusNoContent},
+		{"not leased", otherUID, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"job_uid": "` + tt.uid.String() + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/workers/gpu-1/heartbeat", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+
+			handler.HeartbeatHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCompleteJobHandler(t *testing.T) {
+	mockService := new(MockWorkersService)
+	handler := NewWorkersHandler(mockService)
+	testUID := uuid.New()
+	unknownUID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "completed",
+			body: `{"job_uid": "` + testUID.String() + `", "result": {"result": 1}}`,
+			setupMock: func() {
+				mockService.On("CompleteJob", mock.Anything, "gpu-1", mock.MatchedBy(func(r *model.CompleteJobRequest) bool {
+					return r.JobUID == testUID
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "unknown job",
+			body: `{"job_uid": "` + unknownUID.String() + `", "error": "boom"}`,
+			setupMock: func() {
+				mockService.On("CompleteJob", mock.Anything, "gpu-1", mock.MatchedBy(func(r *model.CompleteJobRequest) bool {
+					return r.JobUID == unknownUID
+				})).Return(service.ErrJobNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing outcome",
+			body:           `{"job_uid": "` + testUID.String() + `"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodPost, "/workers/gpu-1/complete", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.CompleteJobHandler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
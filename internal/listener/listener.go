@@ -0,0 +1,64 @@
+// Package listener opens the service's listening socket in a way that lets a
+// new process take over from an old one without refusing connections.
+package listener
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation
+const listenFDsStart = 3
+
+// Listen returns the socket passed in by the service manager if there is one,
+// otherwise it binds addr. With reusePort set the socket is opened with
+// SO_REUSEPORT so a replacement process can bind the same address while this
+// one drains.
+func Listen(addr string, reusePort bool) (net.Listener, error) {
+	if ln, err := inherited(); ln != nil || err != nil {
+		return ln, err
+	}
+	return Bind(addr, reusePort)
+}
+
+// Bind opens addr like Listen, without looking for an inherited socket, for
+// listeners beyond the one socket activation provides
+func Bind(addr string, reusePort bool) (net.Listener, error) {
+	var lc net.ListenConfig
+	if reusePort {
+		if !reusePortSupported {
+			return nil, fmt.Errorf("SO_REUSEPORT is not supported on this platform")
+		}
+		lc.Control = setReusePort
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+// inherited returns the first socket passed through the systemd socket
+// activation protocol (LISTEN_FDS and LISTEN_PID), or nil if there is none
+func inherited() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, nil
+	}
+
+	// Keep the sockets from leaking into child processes
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(uintptr(listenFDsStart), "listener")
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherited socket: %w", err)
+	}
+	return ln, nil
+}
@@ -0,0 +1,52 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListen_ReusePort(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("SO_REUSEPORT is not supported on this platform")
+	}
+
+	first, err := Listen("127.0.0.1:0", true)
+	assert.NoError(t, err)
+	defer first.Close()
+
+	// A second process can bind the same port while the first is still open
+	second, err := Listen(first.Addr().String(), true)
+	assert.NoError(t, err)
+	defer second.Close()
+
+	// Without the option the port is taken
+	_, err = Listen(first.Addr().String(), false)
+	assert.Error(t, err)
+}
+
+func TestListen_IgnoresSocketsForOtherProcesses(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	ln, err := Listen("127.0.0.1:0", false)
+	assert.NoError(t, err)
+	defer ln.Close()
+	assert.IsType(t, &net.TCPListener{}, ln)
+	assert.Equal(t, "1", os.Getenv("LISTEN_FDS"))
+}
+
+func TestBind_IgnoresInheritedSockets(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+
+	ln, err := Bind("127.0.0.1:0", false)
+	assert.NoError(t, err)
+	defer ln.Close()
+	assert.IsType(t, &net.TCPListener{}, ln)
+	// The inherited socket is left for Listen
+	assert.Equal(t, "1", os.Getenv("LISTEN_FDS"))
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import "fmt"
+
+// DashboardUID identifies the dashboard in Grafana, so importing it again
+// replaces the earlier copy
+const DashboardUID = "worker-pool-service"
+
+// panel is a time series panel plotting one or more PromQL queries
+type panel struct {
+	title   string
+	unit    string
+	queries [][2]string // expression and legend
+}
+
+var panels = []panel{
+	{title: "Queue depth", unit: "short", queries: [][2]string{
+		{QueueDepth, "queued"},
+		{QueueCapacity, "capacity"},
+	}},
+	{title: "Slots in use", unit: "short", queries: [][2]string{
+		{SlotsInUse, "in use"},
+		{Slots, "total"},
+	}},
+	{title: "Jobs by status", unit: "short", queries: [][2]string{
+		{fmt.Sprintf("sum by (status) (%s)", Jobs), "{{status}}"},
+	}},
+	{title: "Jobs finished per second", unit: "ops", queries: [][2]string{
+		{fmt.Sprintf("sum by (type, status) (rate(%s_count[$__rate_interval]))", JobDuration), "{{type}} {{status}}"},
+	}},
+	{title: "Job duration p50", unit: "s", queries: [][2]string{
+		{fmt.Sprintf("histogram_quantile(0.5, sum by (type, le) (rate(%s_bucket[$__rate_interval])))", JobDuration), "{{type}}"},
+	}},
+	{title: "Job duration p95", unit: "s", queries: [][2]string{
+		{fmt.Sprintf("histogram_quantile(0.95, sum by (type, le) (rate(%s_bucket[$__rate_interval])))", JobDuration), "{{type}}"},
+	}},
+	{title: "Queue wait p50", unit: "s", queries: [][2]string{
+		{fmt.Sprintf("histogram_quantile(0.5, sum by (type, le) (rate(%s_bucket[$__rate_interval])))", QueueWait), "{{type}}"},
+	}},
+	{title: "Queue wait p95", unit: "s", queries: [][2]string{
+		{fmt.Sprintf("histogram_quantile(0.95, sum by (type, le) (rate(%s_bucket[$__rate_interval])))", QueueWait), "{{type}}"},
+	}},
+	{title: "Deadline misses per second", unit: "ops", queries: [][2]string{
+		{fmt.Sprintf("sum by (type) (rate(%s[$__rate_interval]))", DeadlineMisses), "{{type}}"},
+	}},
+	{title: "Duration anomalies per second", unit: "ops", queries: [][2]string{
+		{fmt.Sprintf("sum by (type) (rate(%s[$__rate_interval]))", DurationAnomalies), "{{type}}"},
+	}},
+}
+
+// Dashboard returns a Grafana dashboard charting the metrics, in the JSON
+// model Grafana imports. Queries go to the Prometheus data source picked in
+// the dashboard, and duration panels show exemplars, which link to traces
+// when the data source is configured to.
+func Dashboard() map[string]any {
+	datasource := map[string]any{"type": "prometheus", "uid": "${datasource}"}
+	var built []map[string]any
+	for i, p := range panels {
+		var targets []map[string]any
+		for j, q := range p.queries {
+			targets = append(targets, map[string]any{
+				"datasource":   datasource,
+				"expr":         q[0],
+				"legendFormat": q[1],
+				"refId":        string(rune('A' + j)),
+				"exemplar":     true,
+			})
+		}
+		built = append(built, map[string]any{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      p.title,
+			"datasource": datasource,
+			"gridPos":    map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]any{
+				"defaults":  map[string]any{"unit": p.unit},
+				"overrides": []any{},
+			},
+			"targets": targets,
+		})
+	}
+	return map[string]any{
+		"uid":           DashboardUID,
+		"title":         "Worker pool",
+		"tags":          []string{"worker-pool-service"},
+		"schemaVersion": 39,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating": map[string]any{
+			"list": []map[string]any{{
+				"name":  "datasource",
+				"label": "Data source",
+				"type":  "datasource",
+				"query": "prometheus",
+			}},
+		},
+		"panels": built,
+	}
+}
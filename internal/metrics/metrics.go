@@ -0,0 +1,335 @@
+// Package metrics exposes the pool's state, job durations and queue waits
+// in the Prometheus and OpenMetrics text formats, and a Grafana dashboard
+// built on them.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// Metric names, shared with the dashboard
+const (
+	DeadlineMisses    = "wps_job_deadline_misses_total"
+	DurationAnomalies = "wps_job_duration_anomalies_total"
+	JobDuration       = "wps_job_duration_seconds"
+	QueueWait         = "wps_job_queue_seconds"
+	Jobs              = "wps_jobs"
+	QueueDepth        = "wps_queue_depth"
+	QueueCapacity     = "wps_queue_capacity"
+	LargeQueueDepth   = "wps_large_queue_depth"
+	LargeRunning      = "wps_large_jobs_running"
+	OverflowDepth     = "wps_overflow_depth"
+	OverflowSpilled   = "wps_overflow_spilled_total"
+	JobsEvicted       = "wps_jobs_evicted_total"
+	JobsEvictedUnread = "wps_jobs_evicted_unread_total"
+	PayloadsShared    = "wps_payloads_shared"
+	PayloadBytesSaved = "wps_payload_bytes_saved"
+	Slots             = "wps_slots"
+	SlotsInUse        = "wps_slots_in_use"
+	Workers           = "wps_workers"
+	ConcurrencyLimit  = "wps_concurrency_limit"
+	ShadowRuns        = "wps_shadow_runs_total"
+	ShadowMismatches  = "wps_shadow_mismatches_total"
+	VariantRuns       = "wps_executor_variant_runs_total"
+	VariantFailures   = "wps_executor_variant_failures_total"
+	VariantSeconds    = "wps_executor_variant_seconds_total"
+)
+
+// Content types of the two formats Write produces
+const (
+	PrometheusContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// DurationBuckets are the upper bounds of the job duration histogram, in
+// seconds
+var DurationBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600}
+
+// QueueBuckets are the upper bounds of the queue wait histogram, in seconds.
+// They start lower than DurationBuckets, since a job that isn't held up
+// starts within milliseconds.
+var QueueBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600}
+
+// Collector records how long jobs run from the pool's events, and how long
+// they wait to start as the pool reports it, and reads everything else from
+// its stats when scraped
+type Collector struct {
+	stats func() *model.PoolStats
+
+	mu        sync.Mutex
+	started   map[uuid.UUID]time.Time
+	durations map[durationKey]*histogram
+	waits     map[string]*histogram
+	// misses counts jobs that missed their deadline, and anomalies runs
+	// with unusual durations, by type
+	misses    map[string]uint64
+	anomalies map[string]uint64
+	// shadowRuns counts the runs of shadow executors, and shadowMismatches
+	// those whose outcome differed from the job's, by type
+	shadowRuns       map[string]uint64
+	shadowMismatches map[string]uint64
+}
+
+// durationKey is the labels job durations are recorded under
+type durationKey struct {
+	jobType string
+	status  model.JobStatus
+}
+
+func NewCollector(stats func() *model.PoolStats) *Collector {
+	return &Collector{
+		stats:            stats,
+		started:          make(map[uuid.UUID]time.Time),
+		durations:        make(map[durationKey]*histogram),
+		waits:            make(map[string]*histogram),
+		misses:           make(map[string]uint64),
+		anomalies:        make(map[string]uint64),
+		shadowRuns:       make(map[string]uint64),
+		shadowMismatches: make(map[string]uint64),
+	}
+}
+
+// Observe records a job event. It is meant to be passed to
+// WorkerPool.Subscribe.
+func (c *Collector) Observe(ev model.JobEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ev.DeadlineMissed {
+		c.misses[ev.JobType]++
+		return
+	}
+	if ev.To == model.JobStatusRunning {
+		c.started[ev.JobUID] = ev.At
+		return
+	}
+	start, ok := c.started[ev.JobUID]
+	if !ok {
+		return
+	}
+	// A run that ends back in the queue, to be retried or because its
+	// lease lapsed, isn't the job finishing
+	delete(c.started, ev.JobUID)
+	if ev.To != model.JobStatusCompleted && ev.To != model.JobStatusFailed {
+		return
+	}
+	key := durationKey{jobType: ev.JobType, status: ev.To}
+	h, ok := c.durations[key]
+	if !ok {
+		h = newHistogram(DurationBuckets)
+		c.durations[key] = h
+	}
+	h.observe(ev.At.Sub(start).Seconds(), ev.TraceID)
+}
+
+// ObserveQueueWait records how long a job waited from submission to the
+// start of its first attempt. It is meant to be passed to
+// WorkerPool.NotifyQueueWaits.
+func (c *Collector) ObserveQueueWait(job *model.Job, wait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.waits[job.Type]
+	if !ok {
+		h = newHistogram(QueueBuckets)
+		c.waits[job.Type] = h
+	}
+	h.observe(wait.Seconds(), job.TraceID)
+}
+
+// ObserveAnomaly counts a run with an unusual duration. It is meant to be
+// passed to anomaly.Detector.Notify.
+func (c *Collector) ObserveAnomaly(a model.DurationAnomaly) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.anomalies[a.JobType]++
+}
+
+// ObserveShadowRun counts a run of a shadow executor. It is meant to be
+// passed to WorkerPool.NotifyShadowRuns.
+func (c *Collector) ObserveShadowRun(run model.ShadowRun) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shadowRuns[run.JobType]++
+	if !run.Match {
+		c.shadowMismatches[run.JobType]++
+	}
+}
+
+// Write writes every metric to w, in OpenMetrics with exemplars linking
+// duration buckets to traces if openMetrics is set and in the Prometheus
+// text format otherwise
+func (c *Collector) Write(w io.Writer, openMetrics bool) error {
+	bw := bufio.NewWriter(w)
+	stats := c.stats()
+
+	gauge(bw, Workers, "Local workers.", float64(stats.Workers))
+	gauge(bw, Slots, "Slots across local workers.", float64(stats.Slots))
+	gauge(bw, SlotsInUse, "Slots taken by running jobs.", float64(stats.SlotsInUse))
+	gauge(bw, QueueDepth, "Jobs waiting in the queue.", float64(stats.QueueDepth))
+	gauge(bw, QueueCapacity, "Jobs the queue can hold.", float64(stats.QueueCapacity))
+	if large := stats.LargeJobs; large != nil {
+		gauge(bw, LargeQueueDepth, "Jobs with large payloads waiting in their own queue.", float64(large.QueueDepth))
+		gauge(bw, LargeRunning, "Jobs with large payloads running.", float64(large.Running))
+	}
+	if overflow := stats.Overflow; overflow != nil {
+		gauge(bw, OverflowDepth, "Jobs spilled to disk waiting to be queued.", float64(overflow.Depth))
+		counter(bw, OverflowSpilled, "Jobs spilled to disk because the queue was full.", float64(overflow.Spilled), openMetrics)
+	}
+	if retention := stats.Retention; retention != nil {
+		counter(bw, JobsEvicted, "Finished jobs evicted to stay within the retention limit.", float64(retention.Evicted), openMetrics)
+		counter(bw, JobsEvictedUnread, "Finished jobs evicted before anyone read them.", float64(retention.EvictedUnread), openMetrics)
+	}
+	if payloads := stats.Payloads; payloads != nil {
+		gauge(bw, PayloadsShared, "Distinct large payloads held once for every job submitted with them.", float64(payloads.Unique))
+		gauge(bw, PayloadBytesSaved, "Encoded payload bytes jobs would hold without sharing.", float64(payloads.BytesSaved))
+	}
+
+	if adaptive := stats.AdaptiveConcurrency; adaptive != nil {
+		fmt.Fprintf(bw, "# HELP %s Where adaptive concurrency limits stand, by type.\n# TYPE %s gauge\n", ConcurrencyLimit, ConcurrencyLimit)
+		for _, jobType := range slices.Sorted(maps.Keys(adaptive)) {
+			fmt.Fprintf(bw, "%s{type=%q} %d\n", ConcurrencyLimit, jobType, adaptive[jobType].Limit)
+		}
+	}
+
+	fmt.Fprintf(bw, "# HELP %s Jobs retained, by status.\n# TYPE %s gauge\n", Jobs, Jobs)
+	for _, status := range []model.JobStatus{model.JobStatusPending, model.JobStatusRunning, model.JobStatusCompleted, model.JobStatusFailed} {
+		fmt.Fprintf(bw, "%s{status=%q} %d\n", Jobs, status, stats.Jobs[status])
+	}
+
+	counterByVariant(bw, VariantRuns, "Runs of split job types, by type and executor variant.", stats.Variants, func(v model.VariantStats) float64 { return float64(v.Runs) }, openMetrics)
+	counterByVariant(bw, VariantFailures, "Failed runs of split job types, by type and executor variant.", stats.Variants, func(v model.VariantStats) float64 { return float64(v.Failed) }, openMetrics)
+	counterByVariant(bw, VariantSeconds, "Run time of split job types, by type and executor variant.", stats.Variants, func(v model.VariantStats) float64 { return v.Seconds }, openMetrics)
+
+	fmt.Fprintf(bw, "# HELP %s How long jobs ran, by type and outcome.\n# TYPE %s histogram\n", JobDuration, JobDuration)
+	c.mu.Lock()
+	keys := slices.SortedFunc(maps.Keys(c.durations), func(a, b durationKey) int {
+		return strings.Compare(a.jobType+"\x00"+string(a.status), b.jobType+"\x00"+string(b.status))
+	})
+	for _, key := range keys {
+		labels := fmt.Sprintf("type=%q,status=%q", key.jobType, key.status)
+		c.durations[key].write(bw, JobDuration, labels, openMetrics)
+	}
+	fmt.Fprintf(bw, "# HELP %s How long jobs waited from submission to their first start, by type.\n# TYPE %s histogram\n", QueueWait, QueueWait)
+	for _, jobType := range slices.Sorted(maps.Keys(c.waits)) {
+		c.waits[jobType].write(bw, QueueWait, fmt.Sprintf("type=%q", jobType), openMetrics)
+	}
+	counterByType(bw, DeadlineMisses, "Jobs that missed their deadline, by type.", c.misses, openMetrics)
+	counterByType(bw, DurationAnomalies, "Runs whose duration was far from usual, by type.", c.anomalies, openMetrics)
+	counterByType(bw, ShadowRuns, "Runs of shadow executors, by type.", c.shadowRuns, openMetrics)
+	counterByType(bw, ShadowMismatches, "Shadow runs whose outcome differed from the job's, by type.", c.shadowMismatches, openMetrics)
+	c.mu.Unlock()
+
+	if openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	return bw.Flush()
+}
+
+func gauge(w io.Writer, name, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, formatFloat(value))
+}
+
+// counter writes an unlabelled counter. OpenMetrics names the family
+// without the _total suffix its sample carries.
+func counter(w io.Writer, name, help string, value float64, openMetrics bool) {
+	family := name
+	if openMetrics {
+		family = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %s\n", family, help, family, name, formatFloat(value))
+}
+
+// counterByType writes a counter labelled by job type. OpenMetrics names the
+// family without the _total suffix its samples carry.
+func counterByType(w io.Writer, name, help string, counts map[string]uint64, openMetrics bool) {
+	family := name
+	if openMetrics {
+		family = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", family, help, family)
+	for _, jobType := range slices.Sorted(maps.Keys(counts)) {
+		fmt.Fprintf(w, "%s{type=%q} %d\n", name, jobType, counts[jobType])
+	}
+}
+
+// counterByVariant writes a counter labelled by job type and executor
+// variant, read from the pool's stats
+func counterByVariant(w io.Writer, name, help string, variants []model.VariantStats, value func(model.VariantStats) float64, openMetrics bool) {
+	family := name
+	if openMetrics {
+		family = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", family, help, family)
+	for _, v := range variants {
+		fmt.Fprintf(w, "%s{type=%q,variant=%q} %s\n", name, v.JobType, v.Variant, formatFloat(value(v)))
+	}
+}
+
+// histogram counts observations per bucket, keeping the trace of the most
+// recent observation in each as its exemplar
+type histogram struct {
+	bounds    []float64
+	counts    []uint64 // per bucket, plus one for +Inf
+	exemplars []exemplar
+	sum       float64
+	count     uint64
+}
+
+type exemplar struct {
+	traceID string
+	value   float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds:    bounds,
+		counts:    make([]uint64, len(bounds)+1),
+		exemplars: make([]exemplar, len(bounds)+1),
+	}
+}
+
+func (h *histogram) observe(v float64, traceID string) {
+	i, _ := slices.BinarySearch(h.bounds, v)
+	h.counts[i]++
+	if traceID != "" {
+		h.exemplars[i] = exemplar{traceID: traceID, value: v}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name, labels string, withExemplars bool) {
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i]
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = formatFloat(h.bounds[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=%q} %d", name, labels, le, cumulative)
+		if ex := h.exemplars[i]; withExemplars && ex.traceID != "" {
+			fmt.Fprintf(w, " # {trace_id=%q} %s", ex.traceID, formatFloat(ex.value))
+		}
+		io.WriteString(w, "\n")
+	}
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(func() *model.PoolStats {
+		return &model.PoolStats{
+			Workers:       2,
+			Slots:         4,
+			SlotsInUse:    1,
+			QueueDepth:    3,
+			QueueCapacity: 10,
+			LargeJobs:     &model.LaneStats{ThresholdBytes: 1 << 20, Slots: 1, Running: 1, QueueDepth: 2, QueueCapacity: 10},
+			Overflow:      &model.OverflowStats{Depth: 4, Capacity: 100, Spilled: 9},
+			Retention:     &model.RetentionStats{Limit: 100, Retained: 100, Evicted: 12, EvictedUnread: 5},
+			Payloads:      &model.PayloadStats{ThresholdBytes: 4096, Unique: 2, Jobs: 50, BytesSaved: 4096},
+			Jobs:          map[model.JobStatus]int{model.JobStatusPending: 3, model.JobStatusCompleted: 2},
+			AdaptiveConcurrency: map[string]model.AdaptiveConcurrencyStats{
+				"http": {Limit: 6, Min: 1, Max: 20, Increases: 7, Decreases: 1},
+			},
+			Variants: []model.VariantStats{
+				{JobType: "math", Variant: "builtin", Percent: 90, Runs: 9, Failed: 1, Seconds: 1.5},
+				{JobType: "math", Variant: "kubernetes", Percent: 10, Runs: 1, Seconds: 12},
+			},
+		}
+	})
+
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	run := func(jobType string, d time.Duration, to model.JobStatus, traceID string) {
+		uid := uuid.New()
+		c.Observe(model.JobEvent{JobUID: uid, JobType: jobType, To: model.JobStatusPending, At: start})
+		c.Observe(model.JobEvent{JobUID: uid, JobType: jobType, From: model.JobStatusPending, To: model.JobStatusRunning, At: start})
+		c.Observe(model.JobEvent{JobUID: uid, JobType: jobType, From: model.JobStatusRunning, To: to, At: start.Add(d), TraceID: traceID})
+	}
+	run("math", 20*time.Millisecond, model.JobStatusCompleted, "4bf92f3577b34da6a3ce929d0e0e4736")
+	run("math", 2*time.Second, model.JobStatusCompleted, "")
+	run("sleep", time.Hour+time.Second, model.JobStatusFailed, "0af7651916cd43dd8448eb211c80319c")
+
+	// A run cut short by a lapsed lease isn't recorded
+	uid := uuid.New()
+	c.Observe(model.JobEvent{JobUID: uid, JobType: "math", To: model.JobStatusRunning, At: start})
+	c.Observe(model.JobEvent{JobUID: uid, JobType: "math", From: model.JobStatusRunning, To: model.JobStatusPending, At: start.Add(time.Minute)})
+
+	// A deadline missed while running is counted without ending the run
+	uid = uuid.New()
+	c.Observe(model.JobEvent{JobUID: uid, JobType: "sleep", To: model.JobStatusRunning, At: start})
+	c.Observe(model.JobEvent{JobUID: uid, JobType: "sleep", From: model.JobStatusRunning, To: model.JobStatusRunning, At: start.Add(time.Minute), DeadlineMissed: true})
+	c.Observe(model.JobEvent{JobUID: uid, JobType: "sleep", From: model.JobStatusRunning, To: model.JobStatusCompleted, At: start.Add(2 * time.Minute)})
+
+	c.ObserveQueueWait(&model.Job{Type: "math", TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"}, 3*time.Millisecond)
+	c.ObserveQueueWait(&model.Job{Type: "math"}, 2*time.Minute)
+
+	c.ObserveAnomaly(model.DurationAnomaly{JobUID: uuid.New(), JobType: "math"})
+	c.ObserveAnomaly(model.DurationAnomaly{JobUID: uuid.New(), JobType: "math"})
+	c.ObserveShadowRun(model.ShadowRun{JobUID: uuid.New(), JobType: "math", Match: true})
+	c.ObserveShadowRun(model.ShadowRun{JobUID: uuid.New(), JobType: "math", Differences: []string{"result.result"}})
+
+	t.Run("prometheus", func(t *testing.T) {
+		var out strings.Builder
+		require.NoError(t, c.Write(&out, false))
+		text := out.String()
+
+		assert.Contains(t, text, "# TYPE wps_queue_depth gauge\nwps_queue_depth 3\n")
+		assert.Contains(t, text, "wps_large_queue_depth 2\n")
+		assert.Contains(t, text, "wps_large_jobs_running 1\n")
+		assert.Contains(t, text, "# TYPE wps_overflow_depth gauge\nwps_overflow_depth 4\n")
+		assert.Contains(t, text, "# TYPE wps_overflow_spilled_total counter\nwps_overflow_spilled_total 9\n")
+		assert.Contains(t, text, "wps_jobs_evicted_total 12\n")
+		assert.Contains(t, text, "wps_jobs_evicted_unread_total 5\n")
+		assert.Contains(t, text, "# TYPE wps_payloads_shared gauge\nwps_payloads_shared 2\n")
+		assert.Contains(t, text, "wps_payload_bytes_saved 4096\n")
+		assert.Contains(t, text, "# TYPE wps_concurrency_limit gauge\n"+`wps_concurrency_limit{type="http"} 6`+"\n")
+		assert.Contains(t, text, `wps_jobs{status="pending"} 3`)
+		assert.Contains(t, text, `wps_jobs{status="failed"} 0`)
+		assert.Contains(t, text, `wps_job_duration_seconds_bucket{type="math",status="completed",le="0.01"} 0`+"\n")
+		assert.Contains(t, text, `wps_job_duration_seconds_bucket{type="math",status="completed",le="0.05"} 1`+"\n")
+		assert.Contains(t, text, `wps_job_duration_seconds_bucket{type="math",status="completed",le="5"} 2`+"\n")
+		assert.Contains(t, text, `wps_job_duration_seconds_count{type="math",status="completed"} 2`)
+		assert.Contains(t, text, `wps_job_duration_seconds_sum{type="math",status="completed"} 2.02`)
+		assert.Contains(t, text, `wps_job_duration_seconds_bucket{type="sleep",status="failed",le="+Inf"} 1`+"\n")
+		assert.Contains(t, text, `wps_job_duration_seconds_sum{type="sleep",status="completed"} 120`)
+		assert.Contains(t, text, "# TYPE wps_job_queue_seconds histogram\n"+`wps_job_queue_seconds_bucket{type="math",le="0.001"} 0`+"\n")
+		assert.Contains(t, text, `wps_job_queue_seconds_bucket{type="math",le="0.005"} 1`+"\n")
+		assert.Contains(t, text, `wps_job_queue_seconds_bucket{type="math",le="300"} 2`+"\n")
+		assert.Contains(t, text, `wps_job_queue_seconds_count{type="math"} 2`)
+		assert.Contains(t, text, "# TYPE wps_job_deadline_misses_total counter\n"+`wps_job_deadline_misses_total{type="sleep"} 1`+"\n")
+		assert.Contains(t, text, "# TYPE wps_job_duration_anomalies_total counter\n"+`wps_job_duration_anomalies_total{type="math"} 2`+"\n")
+		assert.Contains(t, text, `wps_shadow_runs_total{type="math"} 2`)
+		assert.Contains(t, text, `wps_shadow_mismatches_total{type="math"} 1`)
+		assert.Contains(t, text, `wps_executor_variant_runs_total{type="math",variant="builtin"} 9`)
+		assert.Contains(t, text, `wps_executor_variant_failures_total{type="math",variant="kubernetes"} 0`)
+		assert.Contains(t, text, `wps_executor_variant_seconds_total{type="math",variant="kubernetes"} 12`)
+		assert.NotContains(t, text, "trace_id")
+		assert.NotContains(t, text, "# EOF")
+	})
+
+	t.Run("openmetrics", func(t *testing.T) {
+		var out strings.Builder
+		require.NoError(t, c.Write(&out, true))
+		text := out.String()
+
+		assert.Contains(t, text, `wps_job_duration_seconds_bucket{type="math",status="completed",le="0.05"} 1 # {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"} 0.02`+"\n")
+		assert.Contains(t, text, `wps_job_duration_seconds_bucket{type="math",status="completed",le="5"} 2`+"\n")
+		assert.Contains(t, text, `wps_job_duration_seconds_bucket{type="sleep",status="failed",le="+Inf"} 1 # {trace_id="0af7651916cd43dd8448eb211c80319c"} 3601`+"\n")
+		assert.Contains(t, text, "# TYPE wps_job_deadline_misses counter\n"+`wps_job_deadline_misses_total{type="sleep"} 1`+"\n")
+		assert.Contains(t, text, "# TYPE wps_job_duration_anomalies counter\n")
+		assert.Contains(t, text, `wps_job_queue_seconds_bucket{type="math",le="0.005"} 1 # {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"} 0.003`+"\n")
+		assert.True(t, strings.HasSuffix(text, "# EOF\n"))
+	})
+}
+
+func TestDashboard(t *testing.T) {
+	dashboard := Dashboard()
+	assert.Equal(t, DashboardUID, dashboard["uid"])
+
+	panels := dashboard["panels"].([]map[string]any)
+	require.Len(t, panels, 10)
+	for _, p := range panels {
+		for _, target := range p["targets"].([]map[string]any) {
+			assert.Contains(t, target["expr"], "wps_", p["title"])
+		}
+	}
+	assert.Equal(t, map[string]int{"h": 8, "w": 12, "x": 12, "y": 0}, panels[1]["gridPos"])
+}
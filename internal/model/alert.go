@@ -0,0 +1,128 @@
+package model
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Formats an alert can be delivered in
+const (
+	// AlertFormatWebhook posts the Alert as JSON
+	AlertFormatWebhook = "webhook"
+	// AlertFormatSlack posts a message to a Slack incoming webhook
+	AlertFormatSlack = "slack"
+)
+
+// AlertDeadlineMissed is the status an alert rule counts jobs missing their
+// deadline under, whatever their actual status
+const AlertDeadlineMissed JobStatus = "deadline_missed"
+
+// MaxAlertWindow is the longest window a rule may count jobs over
+const MaxAlertWindow = 24 * time.Hour
+
+// AlertRule fires when more than Threshold jobs of JobType, or of any type
+// when it is empty, reach Status within Window, or miss their deadline when
+// Status is AlertDeadlineMissed. It fires once each time the count crosses
+// the threshold.
+type AlertRule struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	JobType    string    `json:"job_type,omitempty"`
+	Status     JobStatus `json:"status"`
+	Threshold  int       `json:"threshold"`
+	Window     string    `json:"window"`
+	WebhookURL string    `json:"webhook_url"`
+	Format     string    `json:"format"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WindowDuration is the rule's window, which Validate has checked parses
+func (r *AlertRule) WindowDuration() time.Duration {
+	d, _ := time.ParseDuration(r.Window)
+	return d
+}
+
+// AlertRuleRequest creates or replaces an alert rule. Status defaults to
+// failed and Format to webhook.
+type AlertRuleRequest struct {
+	Name       string    `json:"name"`
+	JobType    string    `json:"job_type,omitempty"`
+	Status     JobStatus `json:"status,omitempty"`
+	Threshold  int       `json:"threshold"`
+	Window     string    `json:"window"`
+	WebhookURL string    `json:"webhook_url"`
+	Format     string    `json:"format,omitempty"`
+}
+
+// Validate reports every invalid field of the request
+func (r *AlertRuleRequest) Validate() error {
+	var problems ValidationError
+	if r.Name == "" {
+		problems.Add("name", "is required")
+	}
+	if r.JobType != "" && !IsBuiltinJobType(r.JobType) {
+		problems.Add("job_type", "unknown job type %q", r.JobType)
+	}
+	switch r.Status {
+	case "", JobStatusCompleted, JobStatusFailed, AlertDeadlineMissed:
+	default:
+		problems.Add("status", "must be completed, failed or %s", AlertDeadlineMissed)
+	}
+	if r.Threshold < 0 {
+		problems.Add("threshold", "cannot be negative")
+	}
+	if r.Window == "" {
+		problems.Add("window", "is required")
+	} else if d, err := time.ParseDuration(r.Window); err != nil {
+		problems.Add("window", "%q is not a duration", r.Window)
+	} else if d <= 0 || d > MaxAlertWindow {
+		problems.Add("window", "must be positive and at most %s", MaxAlertWindow)
+	}
+	if u, err := url.Parse(r.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems.Add("webhook_url", "must be an http or https URL")
+	}
+	switch r.Format {
+	case "", AlertFormatWebhook, AlertFormatSlack:
+	default:
+		problems.Add("format", "must be %s or %s", AlertFormatWebhook, AlertFormatSlack)
+	}
+	return problems.Err()
+}
+
+// Rule builds the rule the request describes, with defaults filled in
+func (r *AlertRuleRequest) Rule(id uuid.UUID, createdAt time.Time) *AlertRule {
+	rule := &AlertRule{
+		ID:         id,
+		Name:       r.Name,
+		JobType:    r.JobType,
+		Status:     r.Status,
+		Threshold:  r.Threshold,
+		Window:     r.Window,
+		WebhookURL: r.WebhookURL,
+		Format:     r.Format,
+		CreatedAt:  createdAt,
+	}
+	if rule.Status == "" {
+		rule.Status = JobStatusFailed
+	}
+	if rule.Format == "" {
+		rule.Format = AlertFormatWebhook
+	}
+	return rule
+}
+
+// Alert records a rule firing and whether it was delivered
+type Alert struct {
+	ID       uuid.UUID `json:"id"`
+	RuleID   uuid.UUID `json:"rule_id"`
+	RuleName string    `json:"rule_name"`
+	// Count is how many matching jobs were seen within the window
+	Count       int        `json:"count"`
+	Message     string     `json:"message"`
+	FiredAt     time.Time  `json:"fired_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitzero"`
+	// Error is why delivery failed, after retries
+	Error string `json:"error,omitempty"`
+}
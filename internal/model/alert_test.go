@@ -0,0 +1,20 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertRuleRequest_Validate(t *testing.T) {
+	valid := AlertRuleRequest{Name: "failures", JobType: "math", Threshold: 5, Window: "10m", WebhookURL: "https://hooks.slack.com/services/T0/B0/x", Format: AlertFormatSlack}
+	assert.NoError(t, valid.Validate())
+
+	invalid := AlertRuleRequest{JobType: "ftp", Status: JobStatusRunning, Threshold: -1, Window: "48h", WebhookURL: "ftp://alerts", Format: "email"}
+	assert.Equal(t, "name: is required; job_type: unknown job type \"ftp\"; status: must be completed, failed or deadline_missed; "+
+		"threshold: cannot be negative; window: must be positive and at most 24h0m0s; webhook_url: must be an http or https URL; "+
+		"format: must be webhook or slack", invalid.Validate().Error())
+
+	missing := AlertRuleRequest{Name: "failures", Window: "soon", WebhookURL: "https://alerts.internal"}
+	assert.EqualError(t, missing.Validate(), `window: "soon" is not a duration`)
+}
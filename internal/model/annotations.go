@@ -0,0 +1,47 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// Limits on the annotations a job may carry
+const (
+	MaxAnnotations         = 64
+	MaxAnnotationKeyLength = 128
+	MaxAnnotationSize      = 4 << 10
+)
+
+var ErrTooManyAnnotations = fmt.Errorf("a job may carry at most %d annotations", MaxAnnotations)
+
+// MergeAnnotations returns a copy of existing with added applied on top,
+// leaving existing untouched so readers holding it never see a partial
+// update. Each value must be JSON no larger than MaxAnnotationSize.
+func MergeAnnotations(existing, added map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	for key, value := range added {
+		if key == "" {
+			return nil, errors.New("annotation key cannot be empty")
+		}
+		if len(key) > MaxAnnotationKeyLength {
+			return nil, fmt.Errorf("annotation key %.16q... is longer than %d bytes", key, MaxAnnotationKeyLength)
+		}
+		if len(value) > MaxAnnotationSize {
+			return nil, fmt.Errorf("annotation %q is larger than %d bytes", key, MaxAnnotationSize)
+		}
+		if !json.Valid(value) {
+			return nil, fmt.Errorf("annotation %q is not valid JSON", key)
+		}
+	}
+
+	merged := maps.Clone(existing)
+	if merged == nil {
+		merged = make(map[string]json.RawMessage, len(added))
+	}
+	maps.Copy(merged, added)
+	if len(merged) > MaxAnnotations {
+		return nil, ErrTooManyAnnotations
+	}
+	return merged, nil
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeAnnotations(t *testing.T) {
+	existing := map[string]json.RawMessage{"pod": json.RawMessage(`"wps-1"`)}
+
+	full := make(map[string]json.RawMessage, MaxAnnotations)
+	for i := 0; i < MaxAnnotations; i++ {
+		full[fmt.Sprintf("key-%d", i)] = json.RawMessage(`1`)
+	}
+
+	tests := []struct {
+		name     string
+		existing map[string]json.RawMessage
+		added    map[string]json.RawMessage
+		want     map[string]json.RawMessage
+		errMsg   string
+	}{
+		{
+			name:  "first annotation",
+			added: map[string]json.RawMessage{"bytes": json.RawMessage(`1024`)},
+			want:  map[string]json.RawMessage{"bytes": json.RawMessage(`1024`)},
+		},
+		{
+			name:     "add and replace",
+			existing: existing,
+			added:    map[string]json.RawMessage{"pod": json.RawMessage(`"wps-2"`), "node": json.RawMessage(`{"zone":"a"}`)},
+			want:     map[string]json.RawMessage{"pod": json.RawMessage(`"wps-2"`), "node": json.RawMessage(`{"zone":"a"}`)},
+		},
+		{
+			name:   "empty key",
+			added:  map[string]json.RawMessage{"": json.RawMessage(`1`)},
+			errMsg: "annotation key cannot be empty",
+		},
+		{
+			name:   "invalid JSON",
+			added:  map[string]json.RawMessage{"bytes": json.RawMessage(`{`)},
+			errMsg: `annotation "bytes" is not valid JSON`,
+		},
+		{
+			name:   "value too large",
+			added:  map[string]json.RawMessage{"blob": json.RawMessage(`"` + strings.Repeat("x", MaxAnnotationSize) + `"`)},
+			errMsg: `annotation "blob" is larger than 4096 bytes`,
+		},
+		{
+			name:     "too many",
+			existing: full,
+			added:    map[string]json.RawMessage{"one-more": json.RawMessage(`1`)},
+			errMsg:   ErrTooManyAnnotations.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeAnnotations(tt.existing, tt.added)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, merged)
+		})
+	}
+
+	// The existing map is never modified
+	assert.Equal(t, map[string]json.RawMessage{"pod": json.RawMessage(`"wps-1"`)}, existing)
+}
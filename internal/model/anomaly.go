@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DurationAnomaly records a job that ran far longer or shorter than is usual
+// for its type. Deviations is how many standard deviations its duration was
+// from the mean, negative when it was quicker.
+type DurationAnomaly struct {
+	JobUID          uuid.UUID `json:"job_uid"`
+	JobType         string    `json:"job_type"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	MeanSeconds     float64   `json:"mean_seconds"`
+	StdDevSeconds   float64   `json:"stddev_seconds"`
+	Deviations      float64   `json:"deviations"`
+	DetectedAt      time.Time `json:"detected_at"`
+	TraceID         string    `json:"trace_id,omitempty"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Artifact is a file a job produced, such as a report or a transcoded video
+type Artifact struct {
+	ID          string    `json:"id"`
+	JobUID      uuid.UUID `json:"job_uid"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type,omitempty"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+	// ExpiresAt is when the artifact is deleted, unset if it is kept
+	ExpiresAt *time.Time `json:"expires_at,omitzero"`
+	// URL is a pre-signed URL the artifact can be downloaded from directly,
+	// filled in when it is read from a store that supports them
+	URL string `json:"url,omitempty"`
+}
+
+// MaxArtifactSize bounds the size of an artifact. The server overrides it
+// from its configuration before serving requests.
+var MaxArtifactSize int64 = 1 << 30
+
+// MaxArtifactNameLength bounds an artifact's name
+const MaxArtifactNameLength = 256
+
+var ErrArtifactTooLarge = errors.New("artifact too large")
+
+func ValidateArtifactName(name string) error {
+	if name == "" {
+		return errors.New("artifact name is required")
+	}
+	if len(name) > MaxArtifactNameLength {
+		return fmt.Errorf("artifact name exceeds %d bytes", MaxArtifactNameLength)
+	}
+	return nil
+}
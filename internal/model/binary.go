@@ -0,0 +1,105 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"maps"
+	"slices"
+)
+
+// Binary is binary data a payload field holds. The data is kept in the blob
+// store rather than in the payload, so once submitted it doesn't travel
+// base64 encoded through JSON. A submission gives the data inline in Base64,
+// or names the part of a multipart submission streaming it in Part. Once
+// the data is stored, Base64 is dropped and Size set.
+type Binary struct {
+	Base64      string `json:"base64,omitempty"`
+	Part        string `json:"part,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// Size is the data's length in bytes, set once it's stored
+	Size *int64 `json:"size,omitempty"`
+}
+
+var ErrBinaryTooLarge = errors.New("binary field too large")
+
+// Stored reports whether the data has been stored
+func (b *Binary) Stored() bool {
+	return b.Size != nil
+}
+
+// Validate checks the field gives its data one way, or has it stored
+func (b *Binary) Validate() error {
+	var problems ValidationError
+	switch {
+	case b.Stored():
+		if b.Base64 != "" {
+			problems.Add("base64", "can't be given once the data is stored")
+		}
+	case b.Base64 != "" && b.Part != "":
+		problems.Add("", "base64 and part can't both be given")
+	case b.Base64 != "":
+		if base64.StdEncoding.DecodedLen(len(b.Base64)) > int(MaxInputSize)+2 {
+			problems.Add("base64", "the limit is %d bytes", MaxInputSize)
+		} else if _, err := base64.StdEncoding.DecodeString(b.Base64); err != nil {
+			problems.Add("base64", "is not valid base64")
+		}
+	case b.Part == "":
+		problems.Add("", "base64 or part is required")
+	}
+	if b.Part == "manifest" || b.Part == "file" {
+		problems.Add("part", "%q is reserved", b.Part)
+	}
+	return problems.Err()
+}
+
+// BinaryPayload is a payload with binary fields
+type BinaryPayload interface {
+	JobPayload
+	// Binaries returns the binary fields that are set, by JSON field name
+	Binaries() map[string]*Binary
+}
+
+// PayloadBinaries returns the binary fields set in a payload, by name
+func PayloadBinaries(p JobPayload) map[string]*Binary {
+	if b, ok := p.(BinaryPayload); ok {
+		return b.Binaries()
+	}
+	return nil
+}
+
+// CheckNewBinaries checks a submitted payload's binary fields bring their
+// data rather than claiming it is already stored
+func CheckNewBinaries(p JobPayload) error {
+	var problems ValidationError
+	binaries := PayloadBinaries(p)
+	for _, name := range slices.Sorted(maps.Keys(binaries)) {
+		if binaries[name].Stored() {
+			problems.Add(name+".size", "is set once the data is stored")
+		}
+	}
+	return problems.Err()
+}
+
+// checkSameBinaries checks a changed payload keeps the binary fields of the
+// one it changes as they were. Their data is stored with the job it was
+// submitted with, so changing it takes a new submission.
+func checkSameBinaries(before, after JobPayload) error {
+	var problems ValidationError
+	old, changed := PayloadBinaries(before), PayloadBinaries(after)
+	names := slices.Collect(maps.Keys(old))
+	names = append(names, slices.Collect(maps.Keys(changed))...)
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		if !old[name].same(changed[name]) {
+			problems.Add(name, "binary fields can't be changed; submit a new job instead")
+		}
+	}
+	return problems.Err()
+}
+
+func (b *Binary) same(other *Binary) bool {
+	if b == nil || other == nil || !b.Stored() || !other.Stored() {
+		return b == other
+	}
+	return *b.Size == *other.Size && b.ContentType == other.ContentType && b.Part == other.Part && other.Base64 == ""
+}
@@ -0,0 +1,87 @@
+package model
+
+import "time"
+
+// Stats scopes
+const (
+	// StatsScopeLocal describes this instance alone
+	StatsScopeLocal = "local"
+	// StatsScopeCluster sums the load of every instance in the cluster
+	StatsScopeCluster = "cluster"
+)
+
+// NodeLoad is the load an instance reports to the rest of its cluster
+type NodeLoad struct {
+	Workers       int `json:"workers"`
+	Slots         int `json:"slots"`
+	SlotsInUse    int `json:"slots_in_use"`
+	QueueDepth    int `json:"queue_depth"`
+	QueueCapacity int `json:"queue_capacity"`
+}
+
+// Load picks out of pool stats what is shared with the cluster
+func (s *PoolStats) Load() NodeLoad {
+	return NodeLoad{
+		Workers:       s.Workers,
+		Slots:         s.Slots,
+		SlotsInUse:    s.SlotsInUse,
+		QueueDepth:    s.QueueDepth,
+		QueueCapacity: s.QueueCapacity,
+	}
+}
+
+// Utilization is the share of slots in use, from 0 to 1
+func (l NodeLoad) Utilization() float64 {
+	if l.Slots == 0 {
+		return 0
+	}
+	return float64(l.SlotsInUse) / float64(l.Slots)
+}
+
+// Member is what an instance knows of another, or of itself, as passed
+// around by gossip
+type Member struct {
+	Node string `json:"node"`
+	// URL is where the member can be reached, if it says
+	URL string `json:"url,omitempty"`
+	// Heartbeat is raised by the member each time it gossips. A member whose
+	// heartbeat stops rising has left the cluster.
+	Heartbeat uint64   `json:"heartbeat"`
+	Load      NodeLoad `json:"load"`
+}
+
+// Gossip is what two instances exchange about the cluster
+type Gossip struct {
+	Members []Member `json:"members"`
+}
+
+// NodeStats describes the load of one instance in a cluster
+type NodeStats struct {
+	Node string `json:"node"`
+	URL  string `json:"url,omitempty"`
+	NodeLoad
+	Utilization float64 `json:"utilization"`
+	// Seen is when this instance last heard the node's heartbeat rise
+	Seen time.Time `json:"seen"`
+}
+
+// ClusterStats sums the load of every live instance in a cluster
+type ClusterStats struct {
+	NodeLoad
+	Utilization float64     `json:"utilization"`
+	Nodes       []NodeStats `json:"nodes"`
+}
+
+// NewClusterStats totals the nodes' load
+func NewClusterStats(nodes []NodeStats) *ClusterStats {
+	stats := &ClusterStats{Nodes: nodes}
+	for _, n := range nodes {
+		stats.Workers += n.Workers
+		stats.Slots += n.Slots
+		stats.SlotsInUse += n.SlotsInUse
+		stats.QueueDepth += n.QueueDepth
+		stats.QueueCapacity += n.QueueCapacity
+	}
+	stats.Utilization = stats.NodeLoad.Utilization()
+	return stats
+}
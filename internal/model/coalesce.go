@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// MaxCoalesceWindow bounds how long a coalesced job may wait to be queued
+const MaxCoalesceWindow = time.Hour
+
+// CoalesceOptions fold bursts of submissions into one job. The first
+// submission with a key holds its job back for the window, and later ones
+// with the same key, type and tenant replace its payload instead of
+// creating jobs of their own. Once the window ends the job is queued.
+type CoalesceOptions struct {
+	Key string `json:"key"`
+	// Window is how long the job waits for more submissions, as a Go
+	// duration such as "10s"
+	Window string `json:"window"`
+}
+
+// Parse validates the options and returns the window
+func (c *CoalesceOptions) Parse() (time.Duration, error) {
+	var problems ValidationError
+	switch {
+	case c.Key == "":
+		problems.Add("key", "is required")
+	case len(c.Key) > MaxSerializationKeyLength:
+		problems.Add("key", "exceeds %d bytes", MaxSerializationKeyLength)
+	}
+	window, err := time.ParseDuration(c.Window)
+	switch {
+	case err != nil:
+		problems.Add("window", "%q is not a duration like 10s", c.Window)
+	case window <= 0 || window > MaxCoalesceWindow:
+		problems.Add("window", "must be more than 0s and at most %s", MaxCoalesceWindow)
+	}
+	return window, problems.Err()
+}
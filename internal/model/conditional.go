@@ -0,0 +1,46 @@
+package model
+
+// ExistingJobMatch makes a submission conditional: it is skipped in favour
+// of a pending or running job already equivalent to it. An equivalent job
+// has the submission's type and tenant, its values for each label named in
+// Labels, and its serialization key when SerializationKey is set. With
+// neither, any pending or running job of the type is equivalent.
+type ExistingJobMatch struct {
+	Labels           []string `json:"labels,omitempty"`
+	SerializationKey bool     `json:"serialization_key,omitempty"`
+}
+
+// Validate checks that the submission has everything the match compares
+func (m *ExistingJobMatch) Validate(labels map[string]string, serializationKey string) error {
+	var problems ValidationError
+	for _, key := range m.Labels {
+		if _, ok := labels[key]; !ok {
+			problems.Add("labels", "the submission has no label %q", key)
+		}
+	}
+	if m.SerializationKey && serializationKey == "" {
+		problems.Add("serialization_key", "the submission has no serialization key")
+	}
+	return problems.Err()
+}
+
+// Equivalent reports whether other is a pending or running job standing in
+// for the submitted job
+func (m *ExistingJobMatch) Equivalent(job, other *Job) bool {
+	if other.Status != JobStatusPending && other.Status != JobStatusRunning {
+		return false
+	}
+	if other.Type != job.Type || other.Tenant != job.Tenant {
+		return false
+	}
+	if m.SerializationKey && other.SerializationKey != job.SerializationKey {
+		return false
+	}
+	for _, key := range m.Labels {
+		value, ok := other.Labels[key]
+		if !ok || value != job.Labels[key] {
+			return false
+		}
+	}
+	return true
+}
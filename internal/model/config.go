@@ -0,0 +1,17 @@
+package model
+
+// PreviewConfigRequest is a candidate configuration, given as the
+// environment variables that would be set to deploy it
+type PreviewConfigRequest struct {
+	Settings map[string]string `json:"settings"`
+}
+
+// ConfigPreview is how the service would read a candidate configuration.
+// Config is the effective configuration with every default filled in and
+// secrets redacted, and is only set when the configuration is valid.
+type ConfigPreview struct {
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	Config   any      `json:"config,omitempty"`
+}
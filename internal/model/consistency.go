@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Kinds of inconsistency a consistency check looks for
+const (
+	// InconsistencyIndexDrift is a store index entry that disagrees with
+	// the job it points to
+	InconsistencyIndexDrift = "index_drift"
+	// InconsistencyOrphanedArtifact is an artifact whose job doesn't exist
+	InconsistencyOrphanedArtifact = "orphaned_artifact"
+	// InconsistencyMissingArtifact is an artifact a job lists that doesn't
+	// exist
+	InconsistencyMissingArtifact = "missing_artifact"
+	// InconsistencyOrphanedLog is output kept for a job that doesn't exist
+	InconsistencyOrphanedLog = "orphaned_log"
+	// InconsistencyOrphanedLease is a lease on a job that doesn't exist or
+	// isn't running
+	InconsistencyOrphanedLease = "orphaned_lease"
+)
+
+// Inconsistency is one broken invariant found by a consistency check
+type Inconsistency struct {
+	Kind string `json:"kind"`
+	// ID names what is inconsistent: a job, artifact or index entry
+	ID       string `json:"id"`
+	Detail   string `json:"detail,omitempty"`
+	Repaired bool   `json:"repaired"`
+}
+
+// ConsistencyReport is the outcome of one consistency check
+type ConsistencyReport struct {
+	CheckedAt       time.Time       `json:"checked_at"`
+	Jobs            int             `json:"jobs"`
+	Inconsistencies []Inconsistency `json:"inconsistencies,omitempty"`
+}
+
+// Consistent reports whether every inconsistency found was repaired
+func (r *ConsistencyReport) Consistent() bool {
+	for _, i := range r.Inconsistencies {
+		if !i.Repaired {
+			return false
+		}
+	}
+	return true
+}
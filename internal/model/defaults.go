@@ -0,0 +1,152 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxJobPriority bounds a job's priority either way. Jobs with a higher
+// priority are queued ahead of those with a lower one.
+const MaxJobPriority = 100
+
+// MaxJobRetries is the most times a submission may ask for its job to be
+// retried
+const MaxJobRetries = 100
+
+// JobTypeDefaults are the settings a job type's jobs get when their
+// submission doesn't choose its own. Unset fields leave the pool's
+// defaults in place.
+type JobTypeDefaults struct {
+	// Timeout bounds how long a job may run, as a Go duration
+	Timeout    string `json:"timeout,omitempty"`
+	MaxRetries *int   `json:"max_retries,omitempty"`
+	Priority   int    `json:"priority,omitempty"`
+	// Concurrency is the most jobs of the type that may run at once
+	Concurrency int `json:"concurrency,omitempty"`
+	// AdaptiveConcurrency has the limit on jobs running at once follow
+	// how they fare instead of staying at Concurrency
+	AdaptiveConcurrency *AdaptiveConcurrency `json:"adaptive_concurrency,omitempty"`
+	// Retention is how long a finished job is kept, as a Go duration
+	Retention string `json:"retention,omitempty"`
+}
+
+// Validate reports every invalid setting, naming its field
+func (d *JobTypeDefaults) Validate() error {
+	var problems ValidationError
+	if d.Timeout != "" {
+		problems.AddErr("timeout", ValidateJobDuration(d.Timeout))
+	}
+	if d.MaxRetries != nil {
+		problems.AddErr("max_retries", ValidateRetries(*d.MaxRetries))
+	}
+	problems.AddErr("priority", ValidatePriority(d.Priority))
+	if d.Concurrency < 0 {
+		problems.Add("concurrency", "cannot be negative")
+	}
+	if d.AdaptiveConcurrency != nil {
+		if d.Concurrency != 0 {
+			problems.Add("concurrency", "can't be given with adaptive_concurrency")
+		}
+		problems.AddErr("adaptive_concurrency", d.AdaptiveConcurrency.Validate())
+	}
+	if d.Retention != "" {
+		problems.AddErr("retention", ValidateJobDuration(d.Retention))
+	}
+	return problems.Err()
+}
+
+// ValidatePriority checks a requested job priority
+func ValidatePriority(priority int) error {
+	if priority < -MaxJobPriority || priority > MaxJobPriority {
+		return fmt.Errorf("must be between %d and %d", -MaxJobPriority, MaxJobPriority)
+	}
+	return nil
+}
+
+// ValidateRetries checks a requested number of retries
+func ValidateRetries(n int) error {
+	if n < 0 || n > MaxJobRetries {
+		return fmt.Errorf("must be between 0 and %d", MaxJobRetries)
+	}
+	return nil
+}
+
+// ValidateJobDuration checks a requested timeout or retention, which must
+// be a positive Go duration
+func ValidateJobDuration(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration like 10s", s)
+	}
+	if d <= 0 {
+		return errors.New("must be more than 0s")
+	}
+	return nil
+}
+
+// DefaultMaxFailureRate is the share of a round's runs that may fail or
+// run slow before an adaptive concurrency limit is cut
+const DefaultMaxFailureRate = 0.1
+
+// AdaptiveConcurrency bounds a concurrency limit that adjusts itself to
+// protect what a job type's jobs depend on. The limit starts at Min and
+// moves in rounds of as many runs as the limit: after a round in which the
+// limit was reached and few enough runs failed or ran slow it grows by
+// one, and as soon as too many of a round's runs have done either it is
+// halved.
+type AdaptiveConcurrency struct {
+	// Min is the lowest the limit goes, 1 if unset
+	Min int `json:"min,omitempty"`
+	Max int `json:"max"`
+	// TargetLatency is how long a run may take before it counts against
+	// the limit as a failure does, as a Go duration. Without one only
+	// failures count.
+	TargetLatency string `json:"target_latency,omitempty"`
+	// MaxFailureRate is the share of a round's runs, from 0 up to but not
+	// including 1, that may fail or run slow before the limit is cut.
+	// Nil means DefaultMaxFailureRate.
+	MaxFailureRate *float64 `json:"max_failure_rate,omitempty"`
+}
+
+// Validate reports every invalid setting, naming its field
+func (a *AdaptiveConcurrency) Validate() error {
+	var problems ValidationError
+	if a.Min < 0 {
+		problems.Add("min", "cannot be negative")
+	}
+	if a.Max < 1 {
+		problems.Add("max", "must be at least 1")
+	} else if a.Min > a.Max {
+		problems.Add("min", "can't be more than max")
+	}
+	if a.TargetLatency != "" {
+		problems.AddErr("target_latency", ValidateJobDuration(a.TargetLatency))
+	}
+	if r := a.MaxFailureRate; r != nil && (*r < 0 || *r >= 1) {
+		problems.Add("max_failure_rate", "must be at least 0 and less than 1")
+	}
+	return problems.Err()
+}
+
+// JobTypeConfig holds the settings of a job type that can be changed while
+// the service runs. Setting it replaces both, so an empty Timeout removes
+// the type's bound and a zero Concurrency its limit.
+type JobTypeConfig struct {
+	// Timeout bounds how long newly submitted jobs may run, as a Go
+	// duration
+	Timeout     string `json:"timeout,omitempty"`
+	Concurrency int    `json:"concurrency,omitempty"`
+}
+
+// Validate reports every invalid setting, naming its field
+func (c *JobTypeConfig) Validate() error {
+	var problems ValidationError
+	if c.Timeout != "" {
+		problems.AddErr("timeout", ValidateJobDuration(c.Timeout))
+	}
+	if c.Concurrency < 0 {
+		problems.Add("concurrency", "cannot be negative")
+	}
+	return problems.Err()
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobEvent records a job's status change. From is empty when the job was
+// just submitted. A job missing its deadline is also announced, with
+// DeadlineMissed set and From and To both its current status.
+type JobEvent struct {
+	JobUID  uuid.UUID `json:"job_uid"`
+	JobType string    `json:"job_type"`
+	From    JobStatus `json:"from,omitempty"`
+	To      JobStatus `json:"to"`
+	Version int64     `json:"version"`
+	At      time.Time `json:"at"`
+	// TraceID is the trace the job was submitted in, if any
+	TraceID        string `json:"trace_id,omitempty"`
+	DeadlineMissed bool   `json:"deadline_missed,omitempty"`
+}
+
+// JobEventSchema names the version of the message job events are exported
+// as. Fields may be added within a version, but never removed or changed.
+const JobEventSchema = "wps.job_event.v1"
+
+// JobEventMessage is a job event as exported to a message bus. Delivery is
+// at least once, so consumers should drop messages whose ID they have seen.
+type JobEventMessage struct {
+	Schema string `json:"schema"`
+	ID     string `json:"id"`
+	JobEvent
+}
+
+// NewJobEventMessage wraps ev for export. Every status change bumps the
+// job's version, so the job and version identify the event.
+func NewJobEventMessage(ev JobEvent) JobEventMessage {
+	return JobEventMessage{
+		Schema:   JobEventSchema,
+		ID:       fmt.Sprintf("%s:%d", ev.JobUID, ev.Version),
+		JobEvent: ev,
+	}
+}
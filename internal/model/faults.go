@@ -0,0 +1,45 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FaultScenario configures the faults injected into the pool for testing how
+// clients cope with failures. The zero value injects nothing.
+type FaultScenario struct {
+	// WorkerCrashRate is the probability a local worker crashes while
+	// running a job, failing it
+	WorkerCrashRate float64 `json:"worker_crash_rate"`
+	// QueueLatency delays every submission before it is queued
+	QueueLatency string `json:"queue_latency,omitempty"`
+	// StorageErrorRate is the probability a job write fails
+	StorageErrorRate float64 `json:"storage_error_rate"`
+}
+
+// QueueLatencyDuration parses the optional queue latency
+func (f *FaultScenario) QueueLatencyDuration() (time.Duration, error) {
+	if f.QueueLatency == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(f.QueueLatency)
+	if err != nil {
+		return 0, fmt.Errorf("invalid queue_latency: %w", err)
+	}
+	if d < 0 {
+		return 0, errors.New("queue_latency cannot be negative")
+	}
+	return d, nil
+}
+
+func (f *FaultScenario) Validate() error {
+	if f.WorkerCrashRate < 0 || f.WorkerCrashRate > 1 {
+		return errors.New("worker_crash_rate must be between 0 and 1")
+	}
+	if f.StorageErrorRate < 0 || f.StorageErrorRate > 1 {
+		return errors.New("storage_error_rate must be between 0 and 1")
+	}
+	_, err := f.QueueLatencyDuration()
+	return err
+}
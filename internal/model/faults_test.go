@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultScenario_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario FaultScenario
+		errMsg   string
+	}{
+		{name: "no faults", scenario: FaultScenario{}},
+		{
+			name:     "all faults",
+			scenario: FaultScenario{WorkerCrashRate: 0.1, QueueLatency: "200ms", StorageErrorRate: 1},
+		},
+		{
+			name:     "crash rate above one",
+			scenario: FaultScenario{WorkerCrashRate: 1.5},
+			errMsg:   "worker_crash_rate must be between 0 and 1",
+		},
+		{
+			name:     "negative storage error rate",
+			scenario: FaultScenario{StorageErrorRate: -0.1},
+			errMsg:   "storage_error_rate must be between 0 and 1",
+		},
+		{
+			name:     "invalid latency",
+			scenario: FaultScenario{QueueLatency: "soon"},
+			errMsg:   "invalid queue_latency",
+		},
+		{
+			name:     "negative latency",
+			scenario: FaultScenario{QueueLatency: "-1s"},
+			errMsg:   "queue_latency cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.scenario.Validate()
+			if tt.errMsg != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package model
+
+// FeatureFlag is the state of a flag gating experimental behavior
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	// Default is the state the flag starts in unless configured otherwise
+	Default bool `json:"default"`
+}
+
+// SetFeatureFlagRequest turns a flag on or off
+type SetFeatureFlagRequest struct {
+	Enabled *bool `json:"enabled"`
+}
@@ -5,6 +5,8 @@ import "fmt"
 type JobFilter struct {
 	Type   *string    `json:"type,omitempty"`
 	Status *JobStatus `json:"status,omitempty"`
+	// DeadlineMissed selects jobs by whether they missed their deadline
+	DeadlineMissed *bool `json:"deadline_missed,omitempty"`
 }
 
 func (f *JobFilter) Validate() error {
@@ -13,7 +15,7 @@ func (f *JobFilter) Validate() error {
 	}
 
 	if f.Type != nil {
-		if *f.Type != "math" && *f.Type != "sleep" {
+		if !IsBuiltinJobType(*f.Type) {
 			return fmt.Errorf("unsupported job type")
 		}
 	}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"encoding/json"
+	"path"
+)
+
+// HALMediaType is the media type clients accept to have jobs returned in
+// the HAL format, with links to what can be done with them
+const HALMediaType = "application/hal+json"
+
+// Link points to a related resource or an action. Method is the HTTP
+// method to use, GET when empty.
+type Link struct {
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"`
+}
+
+// JobLinks are the links a job offers in its current state, relative to
+// the API's base path, such as /v1. A job can always be read, updated and
+// traced, and its logs read. Only a finished job can be replayed, and only
+// a job submitted with a file has one to download. Each stored binary
+// payload field links to its data under the field's name.
+func JobLinks(base string, job *Job) map[string]Link {
+	self := path.Join(base, "jobs", job.UID.String())
+	links := map[string]Link{
+		"self":    {Href: self},
+		"update":  {Href: self, Method: "PATCH"},
+		"logs":    {Href: self + "/logs"},
+		"lineage": {Href: self + "/lineage"},
+	}
+	if job.Status.Finished() {
+		links["replay"] = Link{Href: self + "/replay", Method: "POST"}
+	}
+	if job.Input != nil {
+		links["input"] = Link{Href: self + "/input"}
+	}
+	if job.Parent != nil {
+		links["parent"] = Link{Href: path.Join(base, "jobs", job.Parent.String())}
+	}
+	if job.ReplayedFrom != nil {
+		links["replayed_from"] = Link{Href: path.Join(base, "jobs", job.ReplayedFrom.String())}
+	}
+	for name, b := range PayloadBinaries(job.Payload) {
+		if b.Stored() {
+			links[name] = Link{Href: self + "/binaries/" + name}
+		}
+	}
+	return links
+}
+
+// HALJob is a job in the HAL format: its usual fields, with its links
+// under _links
+type HALJob struct {
+	Job   *Job
+	Links map[string]Link
+}
+
+// NewHALJob links job relative to the API's base path
+func NewHALJob(base string, job *Job) HALJob {
+	return HALJob{Job: job, Links: JobLinks(base, job)}
+}
+
+func (h HALJob) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(h.Job)
+	if err != nil {
+		return nil, err
+	}
+	links, err := json.Marshal(h.Links)
+	if err != nil {
+		return nil, err
+	}
+	// Add the links as the job object's last field
+	data = append(data[:len(data)-1], `,"_links":`...)
+	data = append(data, links...)
+	return append(data, '}'), nil
+}
+
+// HALJobs is a list of jobs in the HAL format, embedded under _embedded.
+// NextCursor is set on a page of search results with another after it.
+type HALJobs struct {
+	Links    map[string]Link `json:"_links"`
+	Embedded struct {
+		Jobs []HALJob `json:"jobs"`
+	} `json:"_embedded"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewHALJobs links jobs relative to the API's base path, with self linking
+// to the list itself
+func NewHALJobs(base string, self Link, jobs []*Job) *HALJobs {
+	list := &HALJobs{Links: map[string]Link{"self": self}}
+	list.Embedded.Jobs = make([]HALJob, len(jobs))
+	for i, job := range jobs {
+		list.Embedded.Jobs[i] = NewHALJob(base, job)
+	}
+	return list
+}
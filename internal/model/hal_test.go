@@ -0,0 +1,64 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobLinks(t *testing.T) {
+	uid := uuid.MustParse("ef09a103-f005-414c-9f1c-315a72f38281")
+	parent := uuid.MustParse("0b3c6e5e-2f9a-4c61-9a39-5c1e3a3f0d11")
+
+	links := JobLinks("/v1", &Job{UID: uid, Status: JobStatusRunning})
+	assert.Equal(t, map[string]Link{
+		"self":    {Href: "/v1/jobs/" + uid.String()},
+		"update":  {Href: "/v1/jobs/" + uid.String(), Method: "PATCH"},
+		"logs":    {Href: "/v1/jobs/" + uid.String() + "/logs"},
+		"lineage": {Href: "/v1/jobs/" + uid.String() + "/lineage"},
+	}, links)
+
+	links = JobLinks("/v1", &Job{
+		UID:          uid,
+		Status:       JobStatusFailed,
+		Input:        &JobInput{Filename: "data.csv"},
+		Parent:       &parent,
+		ReplayedFrom: &parent,
+	})
+	assert.Equal(t, Link{Href: "/v1/jobs/" + uid.String() + "/replay", Method: "POST"}, links["replay"])
+	assert.Equal(t, Link{Href: "/v1/jobs/" + uid.String() + "/input"}, links["input"])
+	assert.Equal(t, Link{Href: "/v1/jobs/" + parent.String()}, links["parent"])
+	assert.Equal(t, Link{Href: "/v1/jobs/" + parent.String()}, links["replayed_from"])
+}
+
+func TestHALJob_MarshalJSON(t *testing.T) {
+	uid := uuid.MustParse("ef09a103-f005-414c-9f1c-315a72f38281")
+	job := &Job{
+		UID:     uid,
+		Type:    "sleep",
+		Payload: SleepJobPayload{Duration: "1s"},
+		Status:  JobStatusCompleted,
+	}
+
+	data, err := json.Marshal(NewHALJobs("/v1", Link{Href: "/v1/jobs"}, []*Job{job}))
+	require.NoError(t, err)
+
+	var got struct {
+		Links    map[string]Link `json:"_links"`
+		Embedded struct {
+			Jobs []map[string]any `json:"jobs"`
+		} `json:"_embedded"`
+	}
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, "/v1/jobs", got.Links["self"].Href)
+	require.Len(t, got.Embedded.Jobs, 1)
+	embedded := got.Embedded.Jobs[0]
+	assert.Equal(t, uid.String(), embedded["uid"])
+	assert.Equal(t, "completed", embedded["status"])
+	assert.Equal(t, map[string]any{"duration": "1s"}, embedded["payload"])
+	assert.Equal(t, map[string]any{"href": "/v1/jobs/" + uid.String() + "/replay", "method": "POST"},
+		embedded["_links"].(map[string]any)["replay"])
+}
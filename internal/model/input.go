@@ -0,0 +1,16 @@
+package model
+
+import "errors"
+
+// JobInput describes the file uploaded with a job
+type JobInput struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+}
+
+// MaxInputSize bounds the size of a file uploaded with a job. The server
+// overrides it from its configuration before serving requests.
+var MaxInputSize int64 = 64 << 20
+
+var ErrInputTooLarge = errors.New("input file too large")
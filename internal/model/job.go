@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,24 +22,222 @@ const (
 	JobStatusFailed    JobStatus = "failed"
 )
 
+// JobLaneLarge is the lane of jobs with large payloads, which are queued
+// and run apart from the rest so a burst of them can't crowd others out
+const JobLaneLarge = "large"
+
+// JobStorageArchive marks a job that was read from the archive because it
+// had been evicted from memory
+const JobStorageArchive = "archive"
+
+// Finished reports whether a job in this status has stopped for good
+func (s JobStatus) Finished() bool {
+	return s == JobStatusCompleted || s == JobStatusFailed
+}
+
 type Job struct {
-	UID         uuid.UUID  `json:"uid"`
-	Type        string     `json:"type"`
-	Payload     JobPayload `json:"payload"`
-	Status      JobStatus  `json:"status"`
-	Result      JobResult  `json:"result,omitempty"`
-	Error       string     `json:"error,omitempty"`
-	CreatedAt   *time.Time `json:"created_at"`
-	StartedAt   *time.Time `json:"started_at,omitempty"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
-}
-
-// JobPayload is an interface that all job payloads must implement
+	UID      uuid.UUID         `json:"uid"`
+	Type     string            `json:"type"`
+	Payload  JobPayload        `json:"payload"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Tenant   string            `json:"tenant,omitempty"`
+	Requires []string          `json:"requires,omitempty"`
+	// Placement pins the job to workers whose labels, such as region, zone
+	// or hardware, have these values
+	Placement map[string]string `json:"placement,omitempty"`
+	Weight    int               `json:"weight,omitempty"`
+	// SerializationKey makes the job wait for every earlier job with the
+	// same key to finish before it starts
+	SerializationKey string `json:"serialization_key,omitempty"`
+	// Lane is set to JobLaneLarge on a job whose payload was large enough
+	// to be queued and run apart from the rest
+	Lane     string       `json:"lane,omitempty"`
+	LeasedBy string       `json:"leased_by,omitempty"`
+	Attempts []JobAttempt `json:"attempts,omitempty"`
+	// Backoff overrides the delay between retries, written as accepted by
+	// backoff.Parse
+	Backoff string `json:"backoff,omitempty"`
+	// Timeout, MaxRetries, Priority and Retention are the job's effective
+	// settings, as submitted or else taken from its type's defaults.
+	// Timeout bounds how long the job may run and Retention how long it is
+	// kept once finished, both as Go durations.
+	Timeout    string `json:"timeout,omitempty"`
+	MaxRetries *int   `json:"max_retries,omitempty"`
+	Priority   *int   `json:"priority,omitempty"`
+	Retention  string `json:"retention,omitempty"`
+	// TraceID is the W3C trace the job was submitted in, taken from the
+	// submission's traceparent header
+	TraceID string `json:"trace_id,omitempty"`
+	// Deadline is when the job should have finished by. DeadlineMissed is
+	// set once it is still pending or running at its deadline, or finished
+	// after it.
+	Deadline       *time.Time `json:"deadline,omitzero"`
+	DeadlineMissed bool       `json:"deadline_missed,omitempty"`
+	// Input is the file uploaded with the job, if any
+	Input  *JobInput `json:"input,omitempty"`
+	Status JobStatus `json:"status"`
+	Result JobResult `json:"result,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	// Cached is set on a job that was completed with the result of an
+	// identical earlier job instead of being run
+	Cached bool `json:"cached,omitempty"`
+	// Storage is JobStorageArchive on a job read back from the archive
+	// after it was evicted
+	Storage string `json:"storage,omitempty"`
+	// Coalesced counts the submissions folded into a job submitted with a
+	// coalescing key. The job waits until CoalesceUntil before it is
+	// queued, carrying the payload of the latest.
+	Coalesced     int        `json:"coalesced,omitempty"`
+	CoalesceUntil *time.Time `json:"coalesce_until,omitzero"`
+	// ReplayedFrom is the job this one was created to run again
+	ReplayedFrom *uuid.UUID `json:"replayed_from,omitempty"`
+	// Parent is the job that submitted this one, if the submission named it
+	Parent *uuid.UUID `json:"parent,omitempty"`
+	// Node names the instance that accepted the job, whose workers run it
+	Node string `json:"node,omitempty"`
+	// Cost is the total cost of the job's attempts
+	Cost float64 `json:"cost,omitempty"`
+	// Annotations are values executors attach while running the job, such
+	// as external IDs or bytes processed
+	Annotations map[string]json.RawMessage `json:"annotations,omitempty"`
+	// Artifacts are the IDs of the files the job produced
+	Artifacts []string `json:"artifacts,omitempty"`
+	// ArtifactURLs maps artifact IDs to pre-signed download URLs. They are
+	// generated when the job is read rather than stored.
+	ArtifactURLs map[string]string `json:"artifact_urls,omitempty"`
+	CreatedAt    *time.Time        `json:"created_at"`
+	// RetryAt is when a failed job waiting out its backoff is queued again
+	RetryAt     *time.Time `json:"retry_at,omitzero"`
+	StartedAt   *time.Time `json:"started_at,omitzero"`
+	CompletedAt *time.Time `json:"completed_at,omitzero"`
+	Version     int64      `json:"version"`
+}
+
+// Clone returns a copy of the job sharing no maps or slices with it, so
+// neither sees changes made to the other. Times, payloads and results are
+// shared, since they are replaced rather than changed in place.
+func (j *Job) Clone() *Job {
+	c := *j
+	c.Labels = maps.Clone(j.Labels)
+	c.Requires = slices.Clone(j.Requires)
+	c.Placement = maps.Clone(j.Placement)
+	c.Attempts = slices.Clone(j.Attempts)
+	c.Annotations = maps.Clone(j.Annotations)
+	c.Artifacts = slices.Clone(j.Artifacts)
+	c.ArtifactURLs = maps.Clone(j.ArtifactURLs)
+	return &c
+}
+
+// MaxJobWeight is the most slots a job may ask for
+const MaxJobWeight = 64
+
+// Slots returns how many worker slots the job occupies while it runs
+func (j *Job) Slots() int {
+	return max(j.Weight, 1)
+}
+
+// ValidateWeight checks a requested job weight, where zero means one slot
+func ValidateWeight(weight int) error {
+	if weight < 0 || weight > MaxJobWeight {
+		return fmt.Errorf("must be between 1 and %d", MaxJobWeight)
+	}
+	return nil
+}
+
+// ParseDeadline parses a requested completion deadline, which must be an
+// RFC 3339 time later than now. An empty deadline gives nil.
+func ParseDeadline(deadline string, now time.Time) (*time.Time, error) {
+	if deadline == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, deadline)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an RFC 3339 time", deadline)
+	}
+	if !t.After(now) {
+		return nil, errors.New("must be in the future")
+	}
+	return &t, nil
+}
+
+// MissedDeadline reports whether the job has a deadline it did not finish
+// by, as of now
+func (j *Job) MissedDeadline(now time.Time) bool {
+	if j.Deadline == nil {
+		return false
+	}
+	if j.CompletedAt != nil && j.Status.Finished() {
+		return j.CompletedAt.After(*j.Deadline)
+	}
+	return !now.Before(*j.Deadline)
+}
+
+type AttemptOutcome string
+
+const (
+	AttemptCompleted    AttemptOutcome = "completed"
+	AttemptFailed       AttemptOutcome = "failed"
+	AttemptLeaseExpired AttemptOutcome = "lease_expired"
+)
+
+// JobAttempt records one execution attempt of a job
+type JobAttempt struct {
+	Worker    string         `json:"worker"`
+	StartedAt time.Time      `json:"started_at"`
+	EndedAt   *time.Time     `json:"ended_at,omitempty"`
+	Outcome   AttemptOutcome `json:"outcome,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	// Cost is what the attempt cost, as reported by its executor or worked
+	// out from its run time and the job type's rate. It is nil when neither
+	// is known.
+	Cost *float64 `json:"cost,omitempty"`
+}
+
+// JobPayload is an interface that all job payloads must implement.
+// Validate reports problems as a *ValidationError naming the payload's
+// fields.
 type JobPayload interface {
 	Type() string
 	Validate() error
 }
 
+// Upper bounds on sleep and math payloads unless the deployment sets others
+const (
+	DefaultMaxSleepDuration = time.Hour
+	// DefaultMaxMathNumber keeps the sum computed by a math job within an
+	// int64 and its run time to a fraction of a second
+	DefaultMaxMathNumber = 100_000_000
+)
+
+// PayloadLimits bounds sleep and math payloads. Unlike Validate, which
+// checks what every payload must be, the limits are a deployment's choice,
+// so they are checked where jobs are admitted.
+type PayloadLimits struct {
+	MaxSleepDuration time.Duration
+	MaxMathNumber    int
+}
+
+// DefaultPayloadLimits returns the limits of a deployment that sets none
+func DefaultPayloadLimits() PayloadLimits {
+	return PayloadLimits{MaxSleepDuration: DefaultMaxSleepDuration, MaxMathNumber: DefaultMaxMathNumber}
+}
+
+// Check reports a valid payload that exceeds the limits as a
+// *ValidationError naming its field under payload
+func (l PayloadLimits) Check(payload JobPayload) error {
+	switch p := payload.(type) {
+	case SleepJobPayload:
+		if d, err := time.ParseDuration(p.Duration); err == nil && d > l.MaxSleepDuration {
+			return nestedError("payload", fieldError("duration", "cannot exceed %s", l.MaxSleepDuration))
+		}
+	case MathJobPayload:
+		if p.Number > l.MaxMathNumber {
+			return nestedError("payload", fieldError("number", "cannot exceed %d", l.MaxMathNumber))
+		}
+	}
+	return nil
+}
+
 // SleepJobPayload represents the payload for a sleep job
 type SleepJobPayload struct {
 	Duration string `json:"duration"`
@@ -47,7 +249,14 @@ func (p SleepJobPayload) Type() string {
 
 func (p SleepJobPayload) Validate() error {
 	if p.Duration == "" {
-		return errors.New("duration is required")
+		return fieldError("duration", "is required")
+	}
+	d, err := time.ParseDuration(p.Duration)
+	if err != nil {
+		return fieldError("duration", "%q is not a duration", p.Duration)
+	}
+	if d < 0 {
+		return fieldError("duration", "cannot be negative")
 	}
 	return nil
 }
@@ -62,22 +271,212 @@ func (p MathJobPayload) Type() string {
 }
 
 func (p MathJobPayload) Validate() error {
+	if p.Number < 0 {
+		return fieldError("number", "cannot be negative")
+	}
 	return nil
 }
 
+// ContainerJobPayload represents the payload for a job run in a container.
+// Environment variables may take their values from secrets.
+type ContainerJobPayload struct {
+	Image   string                  `json:"image"`
+	Command []string                `json:"command,omitempty"`
+	Env     map[string]SecretString `json:"env,omitempty"`
+}
+
+func (p ContainerJobPayload) Type() string {
+	return "container"
+}
+
+func (p ContainerJobPayload) Validate() error {
+	var problems ValidationError
+	if p.Image == "" {
+		problems.Add("image", "is required")
+	}
+	for _, k := range slices.Sorted(maps.Keys(p.Env)) {
+		if k == "" || strings.Contains(k, "=") {
+			problems.Add("env", "invalid environment variable name %q", k)
+			continue
+		}
+		if secret := p.Env[k].Secret; secret != "" {
+			problems.AddErr("env."+k, ValidateSecretName(secret))
+		}
+	}
+	return problems.Err()
+}
+
+func (p ContainerJobPayload) Secrets() []string {
+	var names []string
+	for _, v := range p.Env {
+		if v.Secret != "" && !slices.Contains(names, v.Secret) {
+			names = append(names, v.Secret)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (p ContainerJobPayload) WithSecrets(values map[string]string) JobPayload {
+	env := make(map[string]SecretString, len(p.Env))
+	for k, v := range p.Env {
+		if v.Secret != "" {
+			v = SecretString{Value: values[v.Secret]}
+		}
+		env[k] = v
+	}
+	p.Env = env
+	return p
+}
+
+// HTTPMethods are the methods an http job may use
+var HTTPMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// HTTPJobPayload represents the payload for a job that makes one HTTP
+// request. Header values may take their values from secrets.
+type HTTPJobPayload struct {
+	// Method defaults to GET
+	Method  string                  `json:"method,omitempty"`
+	URL     string                  `json:"url"`
+	Headers map[string]SecretString `json:"headers,omitempty"`
+	Body    string                  `json:"body,omitempty"`
+	// BinaryBody is sent in place of Body for bodies that aren't text
+	BinaryBody *Binary `json:"binary_body,omitempty"`
+}
+
+func (p HTTPJobPayload) Type() string {
+	return "http"
+}
+
+func (p HTTPJobPayload) Validate() error {
+	var problems ValidationError
+	if p.Method != "" && !slices.Contains(HTTPMethods, p.Method) {
+		problems.Add("method", "must be one of %s", strings.Join(HTTPMethods, ", "))
+	}
+	if p.URL == "" {
+		problems.Add("url", "is required")
+	} else if u, err := url.Parse(p.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems.Add("url", "must be an absolute http or https URL")
+	}
+	for _, k := range slices.Sorted(maps.Keys(p.Headers)) {
+		if k == "" || strings.ContainsAny(k, ": \t\r\n") {
+			problems.Add("headers", "invalid header name %q", k)
+			continue
+		}
+		if secret := p.Headers[k].Secret; secret != "" {
+			problems.AddErr("headers."+k, ValidateSecretName(secret))
+		}
+	}
+	if p.BinaryBody != nil {
+		if p.Body != "" {
+			problems.Add("binary_body", "can't be given with body")
+		}
+		problems.AddErr("binary_body", p.BinaryBody.Validate())
+	}
+	return problems.Err()
+}
+
+func (p HTTPJobPayload) Binaries() map[string]*Binary {
+	if p.BinaryBody == nil {
+		return nil
+	}
+	return map[string]*Binary{"binary_body": p.BinaryBody}
+}
+
+func (p HTTPJobPayload) Secrets() []string {
+	var names []string
+	for _, v := range p.Headers {
+		if v.Secret != "" && !slices.Contains(names, v.Secret) {
+			names = append(names, v.Secret)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (p HTTPJobPayload) WithSecrets(values map[string]string) JobPayload {
+	headers := make(map[string]SecretString, len(p.Headers))
+	for k, v := range p.Headers {
+		if v.Secret != "" {
+			v = SecretString{Value: values[v.Secret]}
+		}
+		headers[k] = v
+	}
+	p.Headers = headers
+	return p
+}
+
+// encodedResult is the JSON form of a JobResult, tagged with its type so it
+// can be decoded without guessing
+type encodedResult struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// MarshalJSON implements custom JSON marshaling for Job, encoding the result
+// as {"type": ..., "data": ...}. Timestamps that are unset or zero are left
+// out.
+func (j Job) MarshalJSON() ([]byte, error) {
+	type job Job
+	out := struct {
+		job
+		Result *encodedResult `json:"result,omitempty"`
+	}{job: job(j)}
+
+	if j.Result != nil {
+		data, err := json.Marshal(j.Result)
+		if err != nil {
+			return nil, err
+		}
+		out.Result = &encodedResult{Type: j.Result.Type(), Data: data}
+	}
+	return json.Marshal(out)
+}
+
 // UnmarshalJSON implements custom JSON unmarshaling for Job
 func (j *Job) UnmarshalJSON(data []byte) error {
 	// First unmarshal into a temporary struct with a generic payload
 	type tempJob struct {
-		UID         uuid.UUID       `json:"uid"`
-		Type        string          `json:"type"`
-		Payload     json.RawMessage `json:"payload"`
-		Status      JobStatus       `json:"status"`
-		Result      json.RawMessage `json:"result,omitempty"`
-		Error       string          `json:"error,omitempty"`
-		CreatedAt   time.Time       `json:"created_at"`
-		StartedAt   time.Time       `json:"started_at,omitempty"`
-		CompletedAt time.Time       `json:"completed_at,omitempty"`
+		UID              uuid.UUID                  `json:"uid"`
+		Type             string                     `json:"type"`
+		Payload          json.RawMessage            `json:"payload"`
+		Labels           map[string]string          `json:"labels,omitempty"`
+		Tenant           string                     `json:"tenant,omitempty"`
+		Requires         []string                   `json:"requires,omitempty"`
+		Placement        map[string]string          `json:"placement,omitempty"`
+		Weight           int                        `json:"weight,omitempty"`
+		SerializationKey string                     `json:"serialization_key,omitempty"`
+		Lane             string                     `json:"lane,omitempty"`
+		LeasedBy         string                     `json:"leased_by,omitempty"`
+		Attempts         []JobAttempt               `json:"attempts,omitempty"`
+		Backoff          string                     `json:"backoff,omitempty"`
+		Timeout          string                     `json:"timeout,omitempty"`
+		MaxRetries       *int                       `json:"max_retries,omitempty"`
+		Priority         *int                       `json:"priority,omitempty"`
+		Retention        string                     `json:"retention,omitempty"`
+		TraceID          string                     `json:"trace_id,omitempty"`
+		Deadline         *time.Time                 `json:"deadline"`
+		DeadlineMissed   bool                       `json:"deadline_missed,omitempty"`
+		Input            *JobInput                  `json:"input,omitempty"`
+		ReplayedFrom     *uuid.UUID                 `json:"replayed_from,omitempty"`
+		Parent           *uuid.UUID                 `json:"parent,omitempty"`
+		Node             string                     `json:"node,omitempty"`
+		Status           JobStatus                  `json:"status"`
+		Result           json.RawMessage            `json:"result,omitempty"`
+		Error            string                     `json:"error,omitempty"`
+		Cached           bool                       `json:"cached,omitempty"`
+		Storage          string                     `json:"storage,omitempty"`
+		Coalesced        int                        `json:"coalesced,omitempty"`
+		CoalesceUntil    *time.Time                 `json:"coalesce_until"`
+		Cost             float64                    `json:"cost,omitempty"`
+		Annotations      map[string]json.RawMessage `json:"annotations,omitempty"`
+		Artifacts        []string                   `json:"artifacts,omitempty"`
+		ArtifactURLs     map[string]string          `json:"artifact_urls,omitempty"`
+		CreatedAt        *time.Time                 `json:"created_at"`
+		RetryAt          *time.Time                 `json:"retry_at"`
+		StartedAt        *time.Time                 `json:"started_at"`
+		CompletedAt      *time.Time                 `json:"completed_at"`
+		Version          int64                      `json:"version"`
 	}
 
 	var temp tempJob
@@ -88,11 +487,42 @@ func (j *Job) UnmarshalJSON(data []byte) error {
 	// Copy the simple fields
 	j.UID = temp.UID
 	j.Type = temp.Type
+	j.Labels = temp.Labels
+	j.Tenant = temp.Tenant
+	j.Requires = temp.Requires
+	j.Placement = temp.Placement
+	j.Weight = temp.Weight
+	j.SerializationKey = temp.SerializationKey
+	j.Lane = temp.Lane
+	j.Input = temp.Input
+	j.ReplayedFrom = temp.ReplayedFrom
+	j.Parent = temp.Parent
+	j.Node = temp.Node
+	j.Artifacts = temp.Artifacts
+	j.ArtifactURLs = temp.ArtifactURLs
+	j.LeasedBy = temp.LeasedBy
+	j.Attempts = temp.Attempts
+	j.Backoff = temp.Backoff
+	j.Timeout = temp.Timeout
+	j.MaxRetries = temp.MaxRetries
+	j.Priority = temp.Priority
+	j.Retention = temp.Retention
+	j.TraceID = temp.TraceID
+	j.Deadline = optionalTime(temp.Deadline)
+	j.DeadlineMissed = temp.DeadlineMissed
 	j.Status = temp.Status
 	j.Error = temp.Error
-	j.CreatedAt = &temp.CreatedAt
-	j.StartedAt = &temp.StartedAt
-	j.CompletedAt = &temp.CompletedAt
+	j.Cached = temp.Cached
+	j.Storage = temp.Storage
+	j.Coalesced = temp.Coalesced
+	j.CoalesceUntil = optionalTime(temp.CoalesceUntil)
+	j.Cost = temp.Cost
+	j.Annotations = temp.Annotations
+	j.CreatedAt = temp.CreatedAt
+	j.RetryAt = optionalTime(temp.RetryAt)
+	j.StartedAt = optionalTime(temp.StartedAt)
+	j.CompletedAt = optionalTime(temp.CompletedAt)
+	j.Version = temp.Version
 
 	// Unmarshal the payload based on the job type
 	switch temp.Type {
@@ -114,13 +544,55 @@ func (j *Job) UnmarshalJSON(data []byte) error {
 			return fmt.Errorf("invalid math job payload: %w", err)
 		}
 		j.Payload = payload
+	case "container":
+		var payload ContainerJobPayload
+		if err := json.Unmarshal(temp.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid container job payload: %w", err)
+		}
+		if err := payload.Validate(); err != nil {
+			return fmt.Errorf("invalid container job payload: %w", err)
+		}
+		j.Payload = payload
+	case "http":
+		var payload HTTPJobPayload
+		if err := json.Unmarshal(temp.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid http job payload: %w", err)
+		}
+		if err := payload.Validate(); err != nil {
+			return fmt.Errorf("invalid http job payload: %w", err)
+		}
+		j.Payload = payload
 	default:
 		return fmt.Errorf("unknown job type: %s", temp.Type)
 	}
 
+	j.Result = nil
+	if len(temp.Result) > 0 && string(temp.Result) != "null" {
+		var encoded encodedResult
+		if err := json.Unmarshal(temp.Result, &encoded); err != nil {
+			return fmt.Errorf("invalid job result: %w", err)
+		}
+		if encoded.Type != temp.Type {
+			return fmt.Errorf("%s job has a %q result", temp.Type, encoded.Type)
+		}
+		result, err := DecodeJobResult(encoded.Type, encoded.Data)
+		if err != nil {
+			return err
+		}
+		j.Result = result
+	}
+
 	return nil
 }
 
+// optionalTime treats a zero timestamp the same as a missing one
+func optionalTime(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t
+}
+
 type JobResult interface {
 	Type() string
 }
@@ -141,34 +613,140 @@ func (r MathJobResult) Type() string {
 	return "math"
 }
 
+type ContainerJobResult struct {
+	ExitCode int `json:"exit_code"`
+}
+
+func (r ContainerJobResult) Type() string {
+	return "container"
+}
+
+// HTTPJobResult is the response to an http job's request. The body is kept
+// as text, so binary responses are best fetched by other means.
+type HTTPJobResult struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body,omitempty"`
+}
+
+func (r HTTPJobResult) Type() string {
+	return "http"
+}
+
+// DecodeJobResult decodes a result reported for a job of the given type
+func DecodeJobResult(jobType string, data json.RawMessage) (JobResult, error) {
+	switch jobType {
+	case "sleep":
+		var result SleepJobResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("invalid sleep job result: %w", err)
+		}
+		return result, nil
+	case "math":
+		var result MathJobResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("invalid math job result: %w", err)
+		}
+		return result, nil
+	case "container":
+		var result ContainerJobResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("invalid container job result: %w", err)
+		}
+		return result, nil
+	case "http":
+		var result HTTPJobResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("invalid http job result: %w", err)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unknown job type: %s", jobType)
+	}
+}
+
+// ForwardedByHeader names the instance a forwarded submission came from. A
+// submission carrying it, along with the cluster token in
+// ClusterTokenHeader, is never forwarded again, so two instances that can't
+// run a job don't hand it back and forth.
+const ForwardedByHeader = "X-WPS-Forwarded-By"
+
+// ClusterTokenHeader carries the cluster token on submissions forwarded
+// between instances
+const ClusterTokenHeader = "X-WPS-Cluster-Token"
+
 type CreateJobRequest struct {
-	Type    string          `json:"type" validate:"required"`
-	Payload json.RawMessage `json:"payload"`
+	Type     string            `json:"type" validate:"required"`
+	Payload  json.RawMessage   `json:"payload"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Requires []string          `json:"requires,omitempty"`
+	// Placement lists the worker labels the job must run on
+	Placement map[string]string `json:"placement,omitempty"`
+	Weight    int               `json:"weight,omitempty"`
+	Backoff   string            `json:"backoff,omitempty"`
+	// Deadline is when the job should finish by, as an RFC 3339 time
+	Deadline string `json:"deadline,omitempty"`
+	// Timeout, MaxRetries, Priority and Retention override the defaults of
+	// the job's type
+	Timeout    string `json:"timeout,omitempty"`
+	MaxRetries *int   `json:"max_retries,omitempty"`
+	Priority   *int   `json:"priority,omitempty"`
+	Retention  string `json:"retention,omitempty"`
+
+	SerializationKey string `json:"serialization_key,omitempty"`
+	// Parent is the UID of the job submitting this one
+	Parent string `json:"parent,omitempty"`
+	// UnlessExists skips the submission if an equivalent job is already
+	// pending or running
+	UnlessExists *ExistingJobMatch `json:"unless_exists,omitempty"`
+	// Coalesce folds the submission into a job waiting with the same key
+	Coalesce *CoalesceOptions `json:"coalesce,omitempty"`
 }
 
 // ParsePayload validates the request and returns the appropriate JobPayload
 func (r *CreateJobRequest) ParsePayload() (JobPayload, error) {
-	if r.Type != "sleep" && r.Type != "math" {
-		return nil, errors.New("type is invalid")
+	if !IsBuiltinJobType(r.Type) {
+		return nil, fieldError("type", "unknown job type %q", r.Type)
+	}
+	if len(r.Payload) == 0 || isJSONNull(r.Payload) {
+		return nil, fieldError("payload", "is required")
 	}
 
 	switch r.Type {
 	case "sleep":
 		var payload SleepJobPayload
 		if err := json.Unmarshal(r.Payload, &payload); err != nil {
-			return nil, fmt.Errorf("invalid sleep job payload: %w", err)
+			return nil, nestedError("payload", DecodeError(err))
 		}
 		if err := payload.Validate(); err != nil {
-			return nil, err
+			return nil, nestedError("payload", err)
 		}
 		return payload, nil
 	case "math":
 		var payload MathJobPayload
 		if err := json.Unmarshal(r.Payload, &payload); err != nil {
-			return nil, fmt.Errorf("invalid math job payload: %w", err)
+			return nil, nestedError("payload", DecodeError(err))
 		}
 		if err := payload.Validate(); err != nil {
-			return nil, err
+			return nil, nestedError("payload", err)
+		}
+		return payload, nil
+	case "container":
+		var payload ContainerJobPayload
+		if err := json.Unmarshal(r.Payload, &payload); err != nil {
+			return nil, nestedError("payload", DecodeError(err))
+		}
+		if err := payload.Validate(); err != nil {
+			return nil, nestedError("payload", err)
+		}
+		return payload, nil
+	case "http":
+		var payload HTTPJobPayload
+		if err := json.Unmarshal(r.Payload, &payload); err != nil {
+			return nil, nestedError("payload", DecodeError(err))
+		}
+		if err := payload.Validate(); err != nil {
+			return nil, nestedError("payload", err)
 		}
 		return payload, nil
 	default:
@@ -176,6 +754,50 @@ func (r *CreateJobRequest) ParsePayload() (JobPayload, error) {
 	}
 }
 
+// ValidateLabels checks that label keys are non-empty
+func ValidateLabels(labels map[string]string) error {
+	for k := range labels {
+		if k == "" {
+			return errors.New("keys cannot be empty")
+		}
+	}
+	return nil
+}
+
+// ValidateCapabilities checks that required capability names are non-empty
+func ValidateCapabilities(capabilities []string) error {
+	for _, c := range capabilities {
+		if strings.TrimSpace(c) == "" {
+			return errors.New("capability names cannot be empty")
+		}
+	}
+	return nil
+}
+
+// ValidatePlacement checks that placement constraints name a label and the
+// value it must have
+func ValidatePlacement(placement map[string]string) error {
+	for k, v := range placement {
+		if k == "" {
+			return errors.New("label names cannot be empty")
+		}
+		if v == "" {
+			return fmt.Errorf("%s: value cannot be empty", k)
+		}
+	}
+	return nil
+}
+
+// MaxSerializationKeyLength bounds the length of a job's serialization key
+const MaxSerializationKeyLength = 256
+
+func ValidateSerializationKey(key string) error {
+	if len(key) > MaxSerializationKeyLength {
+		return fmt.Errorf("exceeds %d bytes", MaxSerializationKeyLength)
+	}
+	return nil
+}
+
 // IsValidJobStatus checks if a string is a valid job status
 func IsValidJobStatus(s string) bool {
 	switch JobStatus(s) {
@@ -20,7 +20,7 @@ func TestSleepJobPayload_Validate(t *testing.T) {
 			name:    "empty duration",
 			payload: SleepJobPayload{},
 			wantErr: true,
-			errMsg:  "duration is required",
+			errMsg:  "duration: is required",
 		},
 		{
 			name: "valid duration",
@@ -29,6 +29,28 @@ func TestSleepJobPayload_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "zero duration",
+			payload: SleepJobPayload{Duration: "0s"},
+			wantErr: false,
+		},
+		{
+			name:    "unparseable duration",
+			payload: SleepJobPayload{Duration: "a while"},
+			wantErr: true,
+			errMsg:  `duration: "a while" is not a duration`,
+		},
+		{
+			name:    "negative duration",
+			payload: SleepJobPayload{Duration: "-5s"},
+			wantErr: true,
+			errMsg:  "duration: cannot be negative",
+		},
+		{
+			name:    "long duration",
+			payload: SleepJobPayload{Duration: "10000h"},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -44,6 +66,59 @@ func TestSleepJobPayload_Validate(t *testing.T) {
 	}
 }
 
+func TestMathJobPayload_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload MathJobPayload
+		wantErr bool
+		errMsg  string
+	}{
+		{name: "zero", payload: MathJobPayload{Number: 0}},
+		{name: "large", payload: MathJobPayload{Number: DefaultMaxMathNumber + 1}},
+		{name: "negative", payload: MathJobPayload{Number: -1}, wantErr: true, errMsg: "number: cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.payload.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPayloadLimits_Check(t *testing.T) {
+	limits := PayloadLimits{MaxSleepDuration: time.Minute, MaxMathNumber: 1000}
+	tests := []struct {
+		name    string
+		payload JobPayload
+		errMsg  string
+	}{
+		{name: "sleep at the limit", payload: SleepJobPayload{Duration: "1m"}},
+		{name: "sleep over the limit", payload: SleepJobPayload{Duration: "61s"}, errMsg: "payload.duration: cannot exceed 1m0s"},
+		{name: "math at the limit", payload: MathJobPayload{Number: 1000}},
+		{name: "math over the limit", payload: MathJobPayload{Number: 1001}, errMsg: "payload.number: cannot exceed 1000"},
+		{name: "other job types", payload: ContainerJobPayload{Image: "alpine"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.Check(tt.payload)
+			if tt.errMsg != "" {
+				var invalid *ValidationError
+				assert.ErrorAs(t, err, &invalid)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestJob_UnmarshalJSON(t *testing.T) {
 	testUUID := uuid.New()
 	now := time.Now()
@@ -91,6 +166,51 @@ func TestJob_UnmarshalJSON(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "completed math job",
+			json: `{
+				"uid": "` + testUUID.String() + `",
+				"type": "math",
+				"payload": {"number": 4},
+				"status": "completed",
+				"result": {"type": "math", "data": {"result": 6}},
+				"created_at": "` + now.Format(time.RFC3339) + `"
+			}`,
+			want: Job{
+				UID:       testUUID,
+				Type:      "math",
+				Payload:   MathJobPayload{Number: 4},
+				Status:    JobStatusCompleted,
+				Result:    MathJobResult{Result: 6},
+				CreatedAt: &now,
+			},
+		},
+		{
+			name: "result of another type",
+			json: `{
+				"uid": "` + testUUID.String() + `",
+				"type": "math",
+				"payload": {"number": 4},
+				"status": "completed",
+				"result": {"type": "sleep", "data": {"slept_for": "1s"}},
+				"created_at": "` + now.Format(time.RFC3339) + `"
+			}`,
+			wantErr: true,
+			errMsg:  `math job has a "sleep" result`,
+		},
+		{
+			name: "malformed result",
+			json: `{
+				"uid": "` + testUUID.String() + `",
+				"type": "math",
+				"payload": {"number": 4},
+				"status": "completed",
+				"result": {"type": "math", "data": {"result": "six"}},
+				"created_at": "` + now.Format(time.RFC3339) + `"
+			}`,
+			wantErr: true,
+			errMsg:  "invalid math job result",
+		},
 		{
 			name: "invalid job type",
 			json: `{
@@ -142,12 +262,214 @@ func TestJob_UnmarshalJSON(t *testing.T) {
 				assert.Equal(t, tt.want.Type, job.Type)
 				assert.Equal(t, tt.want.Status, job.Status)
 				assert.Equal(t, tt.want.Payload, job.Payload)
+				assert.Equal(t, tt.want.Result, job.Result)
 				assert.Equal(t, tt.want.CreatedAt.Format(time.RFC3339), job.CreatedAt.Format(time.RFC3339))
 			}
 		})
 	}
 }
 
+func TestJob_MarshalJSON_Result(t *testing.T) {
+	tests := []struct {
+		name   string
+		job    Job
+		want   string
+		absent bool
+	}{
+		{
+			name: "math result",
+			job:  Job{Type: "math", Payload: MathJobPayload{Number: 4}, Result: MathJobResult{Result: 6}},
+			want: `{"type":"math","data":{"result":6}}`,
+		},
+		{
+			name: "container result",
+			job:  Job{Type: "container", Payload: ContainerJobPayload{Image: "alpine"}, Result: ContainerJobResult{ExitCode: 0}},
+			want: `{"type":"container","data":{"exit_code":0}}`,
+		},
+		{
+			name:   "no result",
+			job:    Job{Type: "sleep", Payload: SleepJobPayload{Duration: "1s"}},
+			absent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.job)
+			assert.NoError(t, err)
+
+			var doc map[string]json.RawMessage
+			assert.NoError(t, json.Unmarshal(data, &doc))
+			if tt.absent {
+				assert.NotContains(t, doc, "result")
+			} else {
+				assert.JSONEq(t, tt.want, string(doc["result"]))
+			}
+
+			var decoded Job
+			assert.NoError(t, json.Unmarshal(data, &decoded))
+			assert.Equal(t, tt.job.Result, decoded.Result)
+		})
+	}
+}
+
+func TestJob_JSONRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	started := created.Add(time.Second)
+	completed := started.Add(1500 * time.Millisecond)
+	zero := time.Time{}
+
+	tests := []struct {
+		name string
+		job  Job
+		// want is the job expected back when it differs from job
+		want *Job
+	}{
+		{
+			name: "pending",
+			job: Job{
+				UID:       uuid.New(),
+				Type:      "sleep",
+				Payload:   SleepJobPayload{Duration: "1s"},
+				Status:    JobStatusPending,
+				CreatedAt: &created,
+				Version:   1,
+			},
+		},
+		{
+			name: "running",
+			job: Job{
+				UID:       uuid.New(),
+				Type:      "math",
+				Payload:   MathJobPayload{Number: 10},
+				Labels:    map[string]string{"team": "core"},
+				Tenant:    "analytics",
+				Requires:  []string{"gpu"},
+				LeasedBy:  "worker-1",
+				Attempts:  []JobAttempt{{Worker: "worker-1", StartedAt: started}},
+				Status:    JobStatusRunning,
+				CreatedAt: &created,
+				StartedAt: &started,
+				Version:   2,
+			},
+		},
+		{
+			name: "completed",
+			job: Job{
+				UID:         uuid.New(),
+				Type:        "container",
+				Payload:     ContainerJobPayload{Image: "alpine", Command: []string{"true"}, Env: map[string]SecretString{"A": {Value: "1"}, "TOKEN": {Secret: "github"}}},
+				Attempts:    []JobAttempt{{Worker: "local-0", StartedAt: started, EndedAt: &completed, Outcome: AttemptCompleted}},
+				Status:      JobStatusCompleted,
+				Result:      ContainerJobResult{ExitCode: 0},
+				Annotations: map[string]json.RawMessage{"container_id": json.RawMessage(`"abc123"`)},
+				CreatedAt:   &created,
+				StartedAt:   &started,
+				CompletedAt: &completed,
+				Version:     3,
+			},
+		},
+		{
+			name: "failed",
+			job: Job{
+				UID:            uuid.New(),
+				Type:           "sleep",
+				Payload:        SleepJobPayload{Duration: "1s"},
+				TraceID:        "4bf92f3577b34da6a3ce929d0e0e4736",
+				Deadline:       &started,
+				DeadlineMissed: true,
+				Status:         JobStatusFailed,
+				Error:          "boom",
+				CreatedAt:      &created,
+				StartedAt:      &started,
+				CompletedAt:    &completed,
+				Version:        3,
+			},
+		},
+		{
+			name: "zero timestamps",
+			job: Job{
+				Type:        "sleep",
+				Payload:     SleepJobPayload{Duration: "1s"},
+				Status:      JobStatusPending,
+				CreatedAt:   &created,
+				StartedAt:   &zero,
+				CompletedAt: &zero,
+			},
+			want: &Job{
+				Type:      "sleep",
+				Payload:   SleepJobPayload{Duration: "1s"},
+				Status:    JobStatusPending,
+				CreatedAt: &created,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.job
+			if tt.want != nil {
+				want = *tt.want
+			}
+
+			data, err := json.Marshal(tt.job)
+			assert.NoError(t, err)
+
+			var doc map[string]json.RawMessage
+			assert.NoError(t, json.Unmarshal(data, &doc))
+			for field, ts := range map[string]*time.Time{"started_at": want.StartedAt, "completed_at": want.CompletedAt} {
+				if ts == nil {
+					assert.NotContains(t, doc, field)
+				}
+			}
+
+			var decoded Job
+			assert.NoError(t, json.Unmarshal(data, &decoded))
+			assert.Equal(t, want, decoded)
+
+			again, err := json.Marshal(decoded)
+			assert.NoError(t, err)
+			assert.JSONEq(t, string(data), string(again))
+		})
+	}
+}
+
+func TestJob_MissedDeadline(t *testing.T) {
+	deadline := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	early, late := deadline.Add(-time.Second), deadline.Add(time.Second)
+
+	tests := []struct {
+		name string
+		job  Job
+		now  time.Time
+		want bool
+	}{
+		{name: "no deadline", job: Job{Status: JobStatusPending}, now: late, want: false},
+		{name: "pending before deadline", job: Job{Status: JobStatusPending, Deadline: &deadline}, now: early, want: false},
+		{name: "pending at deadline", job: Job{Status: JobStatusPending, Deadline: &deadline}, now: deadline, want: true},
+		{name: "running past deadline", job: Job{Status: JobStatusRunning, Deadline: &deadline}, now: late, want: true},
+		{name: "completed in time", job: Job{Status: JobStatusCompleted, Deadline: &deadline, CompletedAt: &early}, now: late, want: false},
+		{name: "failed late", job: Job{Status: JobStatusFailed, Deadline: &deadline, CompletedAt: &late}, now: late, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.job.MissedDeadline(tt.now))
+		})
+	}
+
+	parsed, err := ParseDeadline("", deadline)
+	assert.NoError(t, err)
+	assert.Nil(t, parsed)
+	parsed, err = ParseDeadline("2024-03-01T13:00:00+01:00", early)
+	assert.NoError(t, err)
+	assert.True(t, deadline.Equal(*parsed))
+	_, err = ParseDeadline("2024-03-01T12:00:00Z", deadline)
+	assert.EqualError(t, err, "must be in the future")
+	_, err = ParseDeadline("noon", early)
+	assert.EqualError(t, err, `"noon" is not an RFC 3339 time`)
+}
+
 func TestCreateJobRequest_ParsePayload(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -174,6 +496,66 @@ func TestCreateJobRequest_ParsePayload(t *testing.T) {
 			want:    MathJobPayload{Number: 42},
 			wantErr: false,
 		},
+		{
+			name: "valid container job",
+			request: CreateJobRequest{
+				Type:    "container",
+				Payload: json.RawMessage(`{"image": "alpine:3", "command": ["echo", "hi"], "env": {"FOO": "bar"}}`),
+			},
+			want: ContainerJobPayload{
+				Image:   "alpine:3",
+				Command: []string{"echo", "hi"},
+				Env:     map[string]SecretString{"FOO": {Value: "bar"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "container job without image",
+			request: CreateJobRequest{
+				Type:    "container",
+				Payload: json.RawMessage(`{"command": ["true"]}`),
+			},
+			wantErr: true,
+			errMsg:  "image: is required",
+		},
+		{
+			name: "container job with a secret",
+			request: CreateJobRequest{
+				Type:    "container",
+				Payload: json.RawMessage(`{"image": "alpine:3", "env": {"TOKEN": {"$secret": "github"}}}`),
+			},
+			want: ContainerJobPayload{
+				Image: "alpine:3",
+				Env:   map[string]SecretString{"TOKEN": {Secret: "github"}},
+			},
+		},
+		{
+			name: "container job with a malformed secret reference",
+			request: CreateJobRequest{
+				Type:    "container",
+				Payload: json.RawMessage(`{"image": "alpine:3", "env": {"TOKEN": {"secret": "github"}}}`),
+			},
+			wantErr: true,
+			errMsg:  `secret reference must be {"$secret": "name"}`,
+		},
+		{
+			name: "container job with an invalid secret name",
+			request: CreateJobRequest{
+				Type:    "container",
+				Payload: json.RawMessage(`{"image": "alpine:3", "env": {"TOKEN": {"$secret": "../etc"}}}`),
+			},
+			wantErr: true,
+			errMsg:  `payload.env.TOKEN: invalid secret name "../etc"`,
+		},
+		{
+			name: "container job with invalid env name",
+			request: CreateJobRequest{
+				Type:    "container",
+				Payload: json.RawMessage(`{"image": "alpine:3", "env": {"A=B": "c"}}`),
+			},
+			wantErr: true,
+			errMsg:  "invalid environment variable name",
+		},
 		{
 			name: "invalid job type",
 			request: CreateJobRequest{
@@ -181,7 +563,7 @@ func TestCreateJobRequest_ParsePayload(t *testing.T) {
 				Payload: json.RawMessage(`{}`),
 			},
 			wantErr: true,
-			errMsg:  "type is invalid",
+			errMsg:  `type: unknown job type "invalid"`,
 		},
 		{
 			name: "invalid sleep payload",
@@ -190,7 +572,7 @@ func TestCreateJobRequest_ParsePayload(t *testing.T) {
 				Payload: json.RawMessage(`{}`),
 			},
 			wantErr: true,
-			errMsg:  "duration is required",
+			errMsg:  "duration: is required",
 		},
 		{
 			name: "invalid math payload format",
@@ -199,7 +581,7 @@ func TestCreateJobRequest_ParsePayload(t *testing.T) {
 				Payload: json.RawMessage(`{"number": "not a number"}`),
 			},
 			wantErr: true,
-			errMsg:  "invalid math job payload",
+			errMsg:  "payload.number: expected integer",
 		},
 	}
 
@@ -298,3 +680,84 @@ func TestJobResult(t *testing.T) {
 		})
 	}
 }
+
+func TestContainerJobPayload_WithSecrets(t *testing.T) {
+	payload := ContainerJobPayload{
+		Image: "alpine:3",
+		Env: map[string]SecretString{
+			"A":     {Value: "1"},
+			"TOKEN": {Secret: "github"},
+			"ALIAS": {Secret: "github"},
+			"KEY":   {Secret: "aws"},
+		},
+	}
+	assert.Equal(t, []string{"aws", "github"}, payload.Secrets())
+
+	resolved := payload.WithSecrets(map[string]string{"github": "gh-token", "aws": "aws-key"}).(ContainerJobPayload)
+	assert.Equal(t, map[string]SecretString{
+		"A":     {Value: "1"},
+		"TOKEN": {Value: "gh-token"},
+		"ALIAS": {Value: "gh-token"},
+		"KEY":   {Value: "aws-key"},
+	}, resolved.Env)
+	assert.Empty(t, resolved.Secrets())
+	// The original keeps its references
+	assert.Equal(t, SecretString{Secret: "github"}, payload.Env["TOKEN"])
+
+	data, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"image": "alpine:3", "env": {"A": "1", "TOKEN": {"$secret": "github"}, "ALIAS": {"$secret": "github"}, "KEY": {"$secret": "aws"}}}`, string(data))
+}
+
+func TestHTTPJobPayload_Validate(t *testing.T) {
+	assert.NoError(t, HTTPJobPayload{URL: "https://example.com/"}.Validate())
+	assert.NoError(t, HTTPJobPayload{Method: "POST", URL: "http://example.com/hook",
+		Headers: map[string]SecretString{"Authorization": {Secret: "hook-token"}}}.Validate())
+
+	err := HTTPJobPayload{Method: "TRACE", URL: "ftp://example.com/", Headers: map[string]SecretString{"Bad Header": {Value: "x"}}}.Validate()
+	var invalid *ValidationError
+	if assert.ErrorAs(t, err, &invalid) {
+		var fields []string
+		for _, f := range invalid.Fields {
+			fields = append(fields, f.Field)
+		}
+		assert.Equal(t, []string{"method", "url", "headers"}, fields)
+	}
+	assert.Error(t, HTTPJobPayload{}.Validate())
+	assert.Error(t, HTTPJobPayload{URL: "/relative"}.Validate())
+
+	payload := HTTPJobPayload{URL: "https://example.com/", Headers: map[string]SecretString{
+		"Authorization": {Secret: "token"}, "Accept": {Value: "application/json"}}}
+	assert.Equal(t, []string{"token"}, payload.Secrets())
+	resolved := payload.WithSecrets(map[string]string{"token": "Bearer abc"}).(HTTPJobPayload)
+	assert.Equal(t, "Bearer abc", resolved.Headers["Authorization"].Value)
+	assert.Empty(t, resolved.Secrets())
+	assert.Equal(t, "token", payload.Headers["Authorization"].Secret)
+}
+
+func TestBinary_Validate(t *testing.T) {
+	size := int64(3)
+	assert.NoError(t, (&Binary{Base64: "AAEC"}).Validate())
+	assert.NoError(t, (&Binary{Part: "body", ContentType: "image/png"}).Validate())
+	assert.NoError(t, (&Binary{Part: "body", Size: &size}).Validate())
+
+	tests := map[string]*Binary{
+		"base64 or part is required":                     {},
+		"base64 and part can't both be given":            {Base64: "AAEC", Part: "body"},
+		"base64: is not valid base64":                    {Base64: "not base64!"},
+		`part: "manifest" is reserved`:                   {Part: "manifest"},
+		"base64: can't be given once the data is stored": {Base64: "AAEC", Size: &size},
+	}
+	for want, b := range tests {
+		assert.EqualError(t, b.Validate(), want)
+	}
+
+	defer func(max int64) { MaxInputSize = max }(MaxInputSize)
+	MaxInputSize = 2
+	assert.EqualError(t, (&Binary{Base64: "AAECAwQF"}).Validate(), "base64: the limit is 2 bytes")
+
+	payload := HTTPJobPayload{URL: "https://example.com/", Body: "text", BinaryBody: &Binary{Part: "body"}}
+	assert.EqualError(t, payload.Validate(), "binary_body: can't be given with body")
+	assert.EqualError(t, CheckNewBinaries(HTTPJobPayload{URL: "https://example.com/", BinaryBody: &Binary{Part: "body", Size: &size}}),
+		"binary_body.size: is set once the data is stored")
+}
@@ -0,0 +1,175 @@
+package model
+
+import "slices"
+
+// JobType describes a kind of job the service can run
+type JobType struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Executor names what runs the jobs, "builtin" for in-process execution
+	Executor string         `json:"executor"`
+	Schema   map[string]any `json:"payload_schema"`
+	// DefaultTimeout bounds how long a job may run; empty means unbounded
+	DefaultTimeout string `json:"default_timeout,omitempty"`
+	// DispatchRate is the most jobs per second handed to workers; zero
+	// means unlimited
+	DispatchRate float64 `json:"dispatch_rate,omitempty"`
+	// ResultCacheTTL is how long results are reused for identical jobs;
+	// empty means jobs always run
+	ResultCacheTTL string `json:"result_cache_ttl,omitempty"`
+	// MaxRetries, Priority and Retention are what jobs get when their
+	// submission doesn't set them; an empty Retention keeps jobs until
+	// they are evicted
+	MaxRetries int    `json:"max_retries"`
+	Priority   int    `json:"priority,omitempty"`
+	Retention  string `json:"retention,omitempty"`
+	// Concurrency is the most jobs that may run at once; zero means
+	// unlimited. With AdaptiveConcurrency it is where the limit stands.
+	Concurrency         int                  `json:"concurrency,omitempty"`
+	AdaptiveConcurrency *AdaptiveConcurrency `json:"adaptive_concurrency,omitempty"`
+	Example             JobPayload           `json:"example_payload"`
+}
+
+// BuiltinExecutor names in-process execution in JobType.Executor
+const BuiltinExecutor = "builtin"
+
+var builtinJobTypes = []string{"sleep", "math", "container", "http"}
+
+// IsBuiltinJobType reports whether the service knows how to run the type
+func IsBuiltinJobType(name string) bool {
+	return slices.Contains(builtinJobTypes, name)
+}
+
+// BuiltinJobTypes describes every job type the service can run. The payload
+// schemas are JSON Schemas that reflect the default payload limits.
+func BuiltinJobTypes() []JobType {
+	return []JobType{
+		{
+			Name:        "sleep",
+			Description: "Waits for the given duration",
+			Executor:    BuiltinExecutor,
+			Schema:      DefaultPayloadLimits().sleepSchema(),
+			Example:     SleepJobPayload{Duration: "5s"},
+		},
+		{
+			Name:        "math",
+			Description: "Sums the integers below number",
+			Executor:    BuiltinExecutor,
+			Schema:      DefaultPayloadLimits().mathSchema(),
+			Example:     MathJobPayload{Number: 1000},
+		},
+		{
+			Name:        "container",
+			Description: "Runs a command in a container image",
+			Executor:    BuiltinExecutor,
+			Schema: objectSchema([]string{"image"}, map[string]any{
+				"image": map[string]any{"type": "string", "minLength": 1},
+				"command": map[string]any{
+					"type":  "array",
+					"items": map[string]any{"type": "string"},
+				},
+				"env": map[string]any{
+					"type":                 "object",
+					"additionalProperties": secretStringSchema(),
+				},
+			}),
+			Example: ContainerJobPayload{Image: "alpine:3", Command: []string{"echo", "hello"}},
+		},
+		{
+			Name:        "http",
+			Description: "Makes an HTTP request and returns the response",
+			Executor:    BuiltinExecutor,
+			Schema: objectSchema([]string{"url"}, map[string]any{
+				"method": map[string]any{"type": "string", "enum": HTTPMethods},
+				"url":    map[string]any{"type": "string", "format": "uri"},
+				"headers": map[string]any{
+					"type":                 "object",
+					"additionalProperties": secretStringSchema(),
+				},
+				"body":        map[string]any{"type": "string"},
+				"binary_body": binarySchema("Request body that isn't text, sent in place of body"),
+			}),
+			Example: HTTPJobPayload{URL: "https://example.com/"},
+		},
+	}
+}
+
+// Describe makes the payload schemas of sleep and math job types reflect
+// the limits
+func (l PayloadLimits) Describe(jt *JobType) {
+	switch jt.Name {
+	case "sleep":
+		jt.Schema = l.sleepSchema()
+	case "math":
+		jt.Schema = l.mathSchema()
+	}
+}
+
+func (l PayloadLimits) sleepSchema() map[string]any {
+	return objectSchema([]string{"duration"}, map[string]any{
+		"duration": map[string]any{
+			"type":        "string",
+			"description": "Go duration such as 500ms or 2m, at most " + l.MaxSleepDuration.String(),
+		},
+	})
+}
+
+func (l PayloadLimits) mathSchema() map[string]any {
+	return objectSchema([]string{"number"}, map[string]any{
+		"number": map[string]any{
+			"type":    "integer",
+			"minimum": 0,
+			"maximum": l.MaxMathNumber,
+		},
+	})
+}
+
+// binarySchema describes a binary payload field, whose data is given
+// inline in base64 or streamed in a part of a multipart submission
+func binarySchema(description string) map[string]any {
+	contentType := map[string]any{"type": "string"}
+	return map[string]any{
+		"type":        "object",
+		"description": description + ", given inline in base64 or as a part of a multipart submission",
+		"oneOf": []any{
+			objectSchema([]string{"base64"}, map[string]any{
+				"base64":       map[string]any{"type": "string", "contentEncoding": "base64"},
+				"content_type": contentType,
+			}),
+			objectSchema([]string{"part"}, map[string]any{
+				"part":         map[string]any{"type": "string", "minLength": 1},
+				"content_type": contentType,
+			}),
+		},
+	}
+}
+
+// secretStringSchema describes a SecretString: a string, or a reference to
+// the secret the value is read from
+func secretStringSchema() map[string]any {
+	ref := objectSchema([]string{"$secret"}, map[string]any{
+		"$secret": map[string]any{
+			"type":      "string",
+			"pattern":   secretName.String(),
+			"maxLength": MaxSecretNameLength,
+		},
+	})
+	ref["additionalProperties"] = false
+	return map[string]any{"oneOf": []any{map[string]any{"type": "string"}, ref}}
+}
+
+func objectSchema(required []string, properties map[string]any) map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"required":   required,
+		"properties": properties,
+	}
+}
+
+// EnabledJobTypes restricts which job types a deployment accepts. An empty
+// list enables every built-in type.
+type EnabledJobTypes []string
+
+func (e EnabledJobTypes) Allows(name string) bool {
+	return len(e) == 0 || slices.Contains(e, name)
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinJobTypes(t *testing.T) {
+	types := BuiltinJobTypes()
+	names := make([]string, len(types))
+	for i, jt := range types {
+		names[i] = jt.Name
+		assert.True(t, IsBuiltinJobType(jt.Name))
+		assert.Equal(t, "object", jt.Schema["type"])
+		assert.NotEmpty(t, jt.Description)
+		assert.Equal(t, BuiltinExecutor, jt.Executor)
+		if assert.NotNil(t, jt.Example) {
+			assert.Equal(t, jt.Name, jt.Example.Type())
+			assert.NoError(t, jt.Example.Validate())
+		}
+	}
+	assert.Equal(t, builtinJobTypes, names)
+	assert.False(t, IsBuiltinJobType("email"))
+}
+
+func TestEnabledJobTypes_Allows(t *testing.T) {
+	assert.True(t, EnabledJobTypes(nil).Allows("container"))
+	enabled := EnabledJobTypes{"sleep", "math"}
+	assert.True(t, enabled.Allows("math"))
+	assert.False(t, enabled.Allows("container"))
+}
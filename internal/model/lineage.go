@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// LineageParent links a job to one it submitted
+	LineageParent = "parent"
+	// LineageReplay links a job to a replay of it
+	LineageReplay = "replay"
+)
+
+// Origin returns the job this one came from and how, or nil if it was
+// submitted on its own
+func (j *Job) Origin() (*uuid.UUID, string) {
+	switch {
+	case j.ReplayedFrom != nil:
+		return j.ReplayedFrom, LineageReplay
+	case j.Parent != nil:
+		return j.Parent, LineageParent
+	}
+	return nil, ""
+}
+
+// LineageNode is one job in a lineage graph. Retries run as further
+// attempts of the same job, so they are counted here rather than linked.
+type LineageNode struct {
+	UID       uuid.UUID  `json:"uid"`
+	Type      string     `json:"type"`
+	Status    JobStatus  `json:"status"`
+	Attempts  int        `json:"attempts"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// LineageEdge links a job to one that came from it
+type LineageEdge struct {
+	From uuid.UUID `json:"from"`
+	To   uuid.UUID `json:"to"`
+	Kind string    `json:"kind"`
+}
+
+// Lineage is the tree of jobs a job belongs to: everything back to the job
+// the work originated with, and everything that came from that since.
+// Jobs that no longer exist end the tree, so Root is the oldest job still
+// known.
+type Lineage struct {
+	Job   uuid.UUID     `json:"job"`
+	Root  uuid.UUID     `json:"root"`
+	Nodes []LineageNode `json:"nodes"`
+	Edges []LineageEdge `json:"edges"`
+}
+
+// NewLineageNode summarises job for a lineage graph
+func NewLineageNode(job *Job) LineageNode {
+	return LineageNode{
+		UID:       job.UID,
+		Type:      job.Type,
+		Status:    job.Status,
+		Attempts:  len(job.Attempts),
+		CreatedAt: job.CreatedAt,
+	}
+}
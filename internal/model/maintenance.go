@@ -0,0 +1,78 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Maintenance overrides
+const (
+	// MaintenanceOverridePause pauses dispatch whatever the schedule says
+	MaintenanceOverridePause = "pause"
+	// MaintenanceOverrideResume lets jobs be dispatched even during a
+	// scheduled window
+	MaintenanceOverrideResume = "resume"
+)
+
+// MaintenanceWindow is a period during which no job is started. Jobs are
+// still accepted and queued, and those already running carry on.
+type MaintenanceWindow struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Contains reports whether t falls within the window
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// ValidateMaintenanceWindows checks that each window ends after it starts,
+// naming the first that doesn't by its position
+func ValidateMaintenanceWindows(windows []MaintenanceWindow) error {
+	for i, w := range windows {
+		if w.Start.IsZero() || w.End.IsZero() {
+			return fmt.Errorf("window %d: start and end are required", i)
+		}
+		if !w.End.After(w.Start) {
+			return fmt.Errorf("window %d: end must be after start", i)
+		}
+	}
+	return nil
+}
+
+// SortMaintenanceWindows orders windows by when they start
+func SortMaintenanceWindows(windows []MaintenanceWindow) {
+	slices.SortFunc(windows, func(a, b MaintenanceWindow) int {
+		return a.Start.Compare(b.Start)
+	})
+}
+
+// MaintenanceOverride replaces the schedule until it is cleared with an
+// empty Override
+type MaintenanceOverride struct {
+	Override string `json:"override"`
+}
+
+func (o *MaintenanceOverride) Validate() error {
+	switch o.Override {
+	case "", MaintenanceOverridePause, MaintenanceOverrideResume:
+		return nil
+	}
+	return errors.New(`override must be "pause", "resume" or empty`)
+}
+
+// MaintenanceStatus reports whether dispatch is paused and why
+type MaintenanceStatus struct {
+	Paused   bool   `json:"paused"`
+	Override string `json:"override,omitempty"`
+	// Window is the scheduled window in effect, if any, even when the
+	// override resumes dispatch
+	Window *MaintenanceWindow `json:"window,omitempty"`
+	// Next is the next scheduled window to start
+	Next *MaintenanceWindow `json:"next,omitempty"`
+	// Windows are the scheduled windows that haven't ended
+	Windows []MaintenanceWindow `json:"windows"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMaintenanceWindows(t *testing.T) {
+	start := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	assert.NoError(t, ValidateMaintenanceWindows([]MaintenanceWindow{{Start: start, End: start.Add(time.Hour)}}))
+	assert.EqualError(t, ValidateMaintenanceWindows([]MaintenanceWindow{
+		{Start: start, End: start.Add(time.Hour)},
+		{Start: start, End: start},
+	}), "window 1: end must be after start")
+	assert.EqualError(t, ValidateMaintenanceWindows([]MaintenanceWindow{{Start: start}}), "window 0: start and end are required")
+}
+
+func TestMaintenanceWindow_Contains(t *testing.T) {
+	start := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	w := MaintenanceWindow{Start: start, End: start.Add(time.Hour)}
+	assert.False(t, w.Contains(start.Add(-time.Second)))
+	assert.True(t, w.Contains(start))
+	assert.True(t, w.Contains(start.Add(59*time.Minute)))
+	assert.False(t, w.Contains(start.Add(time.Hour)))
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrJobNotMutable is returned when a patch is not allowed in the job's current state
+var ErrJobNotMutable = errors.New("job cannot be modified in its current state")
+
+// JobPatch is a JSON Merge Patch (RFC 7386) against the mutable fields of a job
+type JobPatch struct {
+	// Labels holds label changes; a nil value removes the label
+	Labels      map[string]*string
+	ClearLabels bool
+	// Payload is a merge patch applied to the current payload
+	Payload json.RawMessage
+}
+
+// ParseJobPatch decodes a merge patch document, rejecting immutable fields
+func ParseJobPatch(data []byte) (*JobPatch, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, DecodeError(err)
+	}
+
+	patch := &JobPatch{}
+	for field, raw := range doc {
+		switch field {
+		case "labels":
+			if isJSONNull(raw) {
+				patch.ClearLabels = true
+				continue
+			}
+			if err := json.Unmarshal(raw, &patch.Labels); err != nil {
+				return nil, nestedError("labels", DecodeError(err))
+			}
+			for k := range patch.Labels {
+				if k == "" {
+					return nil, fieldError("labels", "keys cannot be empty")
+				}
+			}
+		case "payload":
+			if isJSONNull(raw) {
+				return nil, fieldError("payload", "cannot be removed")
+			}
+			patch.Payload = raw
+		default:
+			return nil, fieldError(field, "cannot be modified")
+		}
+	}
+	return patch, nil
+}
+
+// Apply validates the patch against the job's state and applies it
+func (p *JobPatch) Apply(job *Job) error {
+	if p.Payload != nil {
+		if job.Status != JobStatusPending {
+			return fmt.Errorf("%w: payload can only be changed while the job is pending", ErrJobNotMutable)
+		}
+		payload, err := patchPayload(job, p.Payload)
+		if err != nil {
+			return err
+		}
+		job.Payload = payload
+	}
+
+	if p.ClearLabels {
+		job.Labels = nil
+	}
+	for k, v := range p.Labels {
+		if v == nil {
+			delete(job.Labels, k)
+			continue
+		}
+		if job.Labels == nil {
+			job.Labels = make(map[string]string)
+		}
+		job.Labels[k] = *v
+	}
+	return nil
+}
+
+func patchPayload(job *Job, patch json.RawMessage) (JobPayload, error) {
+	current, err := json.Marshal(job.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var target, changes any
+	if err := json.Unmarshal(current, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, nestedError("payload", DecodeError(err))
+	}
+
+	merged, err := json.Marshal(mergePatch(target, changes))
+	if err != nil {
+		return nil, err
+	}
+
+	req := CreateJobRequest{Type: job.Type, Payload: merged}
+	payload, err := req.ParsePayload()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSameBinaries(job.Payload, payload); err != nil {
+		return nil, nestedError("payload", err)
+	}
+	return payload, nil
+}
+
+// mergePatch implements the RFC 7386 MergePatch algorithm
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = mergePatch(targetObj[k], v)
+	}
+	return targetObj
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return string(raw) == "null"
+}
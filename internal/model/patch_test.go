@@ -0,0 +1,169 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJobPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "labels and payload",
+			body: `{"labels": {"team": "core", "old": null}, "payload": {"number": 7}}`,
+		},
+		{
+			name: "clear labels",
+			body: `{"labels": null}`,
+		},
+		{
+			name:    "immutable field",
+			body:    `{"status": "completed"}`,
+			wantErr: true,
+			errMsg:  "status: cannot be modified",
+		},
+		{
+			name:    "remove payload",
+			body:    `{"payload": null}`,
+			wantErr: true,
+			errMsg:  "payload: cannot be removed",
+		},
+		{
+			name:    "empty label key",
+			body:    `{"labels": {"": "x"}}`,
+			wantErr: true,
+			errMsg:  "labels: keys cannot be empty",
+		},
+		{
+			name:    "not an object",
+			body:    `[]`,
+			wantErr: true,
+			errMsg:  "expected object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJobPatch([]byte(tt.body))
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestJobPatch_Apply(t *testing.T) {
+	tests := []struct {
+		name        string
+		job         *Job
+		body        string
+		wantPayload JobPayload
+		wantLabels  map[string]string
+		wantErr     error
+	}{
+		{
+			name: "merge labels",
+			job: &Job{
+				Type:    "math",
+				Payload: MathJobPayload{Number: 1},
+				Labels:  map[string]string{"team": "core", "old": "x"},
+				Status:  JobStatusRunning,
+			},
+			body:        `{"labels": {"env": "prod", "old": null}}`,
+			wantPayload: MathJobPayload{Number: 1},
+			wantLabels:  map[string]string{"team": "core", "env": "prod"},
+		},
+		{
+			name: "clear labels",
+			job: &Job{
+				Type:    "math",
+				Payload: MathJobPayload{Number: 1},
+				Labels:  map[string]string{"team": "core"},
+				Status:  JobStatusPending,
+			},
+			body:        `{"labels": null}`,
+			wantPayload: MathJobPayload{Number: 1},
+		},
+		{
+			name: "payload of pending job",
+			job: &Job{
+				Type:    "sleep",
+				Payload: SleepJobPayload{Duration: "1s"},
+				Status:  JobStatusPending,
+			},
+			body:        `{"payload": {"duration": "2s"}}`,
+			wantPayload: SleepJobPayload{Duration: "2s"},
+		},
+		{
+			name: "payload of running job",
+			job: &Job{
+				Type:    "sleep",
+				Payload: SleepJobPayload{Duration: "1s"},
+				Status:  JobStatusRunning,
+			},
+			body:    `{"payload": {"duration": "2s"}}`,
+			wantErr: ErrJobNotMutable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParseJobPatch([]byte(tt.body))
+			assert.NoError(t, err)
+
+			err = patch.Apply(tt.job)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantPayload, tt.job.Payload)
+				assert.Equal(t, tt.wantLabels, tt.job.Labels)
+			}
+		})
+	}
+
+	t.Run("invalid patched payload", func(t *testing.T) {
+		job := &Job{
+			Type:    "sleep",
+			Payload: SleepJobPayload{Duration: "1s"},
+			Status:  JobStatusPending,
+		}
+		patch, err := ParseJobPatch([]byte(`{"payload": {"duration": null}}`))
+		assert.NoError(t, err)
+
+		err = patch.Apply(job)
+		assert.Error(t, err)
+		assert.Equal(t, "payload.duration: is required", err.Error())
+		assert.Equal(t, SleepJobPayload{Duration: "1s"}, job.Payload)
+	})
+	t.Run("binary fields", func(t *testing.T) {
+		size := int64(3)
+		stored := HTTPJobPayload{URL: "https://example.com/", BinaryBody: &Binary{Part: "body", Size: &size}}
+		job := &Job{Type: "http", Payload: stored, Status: JobStatusPending}
+
+		patch, err := ParseJobPatch([]byte(`{"payload": {"method": "PUT"}}`))
+		assert.NoError(t, err)
+		assert.NoError(t, patch.Apply(job))
+		assert.Equal(t, &size, job.Payload.(HTTPJobPayload).BinaryBody.Size)
+
+		for _, body := range []string{
+			`{"payload": {"binary_body": {"size": 4}}}`,
+			`{"payload": {"binary_body": null}}`,
+		} {
+			patch, err := ParseJobPatch([]byte(body))
+			assert.NoError(t, err)
+			err = patch.Apply(job)
+			if assert.Error(t, err, body) {
+				assert.Contains(t, err.Error(), "payload.binary_body: binary fields can't be changed")
+			}
+		}
+	})
+}
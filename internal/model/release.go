@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReleasesAnnotation is the annotation recording how releasing a job's
+// result to each target went
+const ReleasesAnnotation = "releases"
+
+// ResultRelease is the document a completed job's result is released to
+// external targets as
+type ResultRelease struct {
+	JobUID      uuid.UUID         `json:"job_uid"`
+	JobType     string            `json:"job_type"`
+	Tenant      string            `json:"tenant,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Result      JobResult         `json:"result"`
+	CompletedAt *time.Time        `json:"completed_at"`
+}
+
+func NewResultRelease(job *Job) ResultRelease {
+	return ResultRelease{
+		JobUID:      job.UID,
+		JobType:     job.Type,
+		Tenant:      job.Tenant,
+		Labels:      job.Labels,
+		Result:      job.Result,
+		CompletedAt: job.CompletedAt,
+	}
+}
+
+// ReleaseOutcome is how releasing a result to one target went, once every
+// attempt has been made
+type ReleaseOutcome struct {
+	ReleasedAt *time.Time `json:"released_at,omitzero"`
+	Error      string     `json:"error,omitempty"`
+}
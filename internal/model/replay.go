@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobNotFinished is returned when replaying a job that is still pending
+// or running
+var ErrJobNotFinished = errors.New("job has not finished")
+
+// ReplayJobRequest asks for a finished job to be run again as a new job
+type ReplayJobRequest struct {
+	// Payload is a merge patch applied to the original's payload, so only
+	// the fields to change need be given
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewReplay builds the pending job that runs original again, with the
+// request's payload changes applied. It keeps the original's type, tenant,
+// labels and scheduling fields, but not its deadline, and links back to it
+// in ReplayedFrom. Its timeout, retries, priority and retention are left to
+// the type's defaults as they are now.
+func NewReplay(original *Job, req *ReplayJobRequest, now time.Time) (*Job, error) {
+	if !original.Status.Finished() {
+		return nil, ErrJobNotFinished
+	}
+	payload := original.Payload
+	if len(req.Payload) > 0 && !isJSONNull(req.Payload) {
+		var err error
+		if payload, err = patchPayload(original, req.Payload); err != nil {
+			return nil, err
+		}
+	}
+	from := original.UID
+	return &Job{
+		UID:              uuid.New(),
+		Type:             original.Type,
+		Payload:          payload,
+		Labels:           maps.Clone(original.Labels),
+		Tenant:           original.Tenant,
+		Requires:         slices.Clone(original.Requires),
+		Placement:        maps.Clone(original.Placement),
+		Weight:           original.Weight,
+		SerializationKey: original.SerializationKey,
+		Backoff:          original.Backoff,
+		ReplayedFrom:     &from,
+		Status:           JobStatusPending,
+		CreatedAt:        &now,
+	}, nil
+}
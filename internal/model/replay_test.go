@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReplay(t *testing.T) {
+	now := time.Now()
+	deadline := now.Add(-time.Hour)
+	original := &Job{
+		UID:         uuid.New(),
+		Type:        "sleep",
+		Payload:     SleepJobPayload{Duration: "1s"},
+		Labels:      map[string]string{"team": "core"},
+		Tenant:      "acme",
+		Deadline:    &deadline,
+		Status:      JobStatusFailed,
+		CompletedAt: &deadline,
+	}
+
+	replay, err := NewReplay(original, &ReplayJobRequest{Payload: json.RawMessage(`{"duration":"2s"}`)}, now)
+	require.NoError(t, err)
+	assert.NotEqual(t, original.UID, replay.UID)
+	assert.Equal(t, &original.UID, replay.ReplayedFrom)
+	assert.Equal(t, SleepJobPayload{Duration: "2s"}, replay.Payload)
+	assert.Equal(t, original.Labels, replay.Labels)
+	assert.Equal(t, "acme", replay.Tenant)
+	assert.Equal(t, JobStatusPending, replay.Status)
+	assert.Equal(t, &now, replay.CreatedAt)
+	assert.Nil(t, replay.Deadline)
+	assert.Nil(t, replay.CompletedAt)
+
+	replay.Labels["team"] = "other"
+	assert.Equal(t, "core", original.Labels["team"])
+
+	replay, err = NewReplay(original, &ReplayJobRequest{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, original.Payload, replay.Payload)
+
+	_, err = NewReplay(original, &ReplayJobRequest{Payload: json.RawMessage(`{"duration":"soon"}`)}, now)
+	var invalid *ValidationError
+	assert.ErrorAs(t, err, &invalid)
+
+	original.Status = JobStatusRunning
+	_, err = NewReplay(original, &ReplayJobRequest{}, now)
+	assert.ErrorIs(t, err, ErrJobNotFinished)
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 500
+	maxQueryDepth      = 8
+)
+
+// JobQuery is a node in a compound job query. All predicates set on a node
+// must match, every And child must match and at least one Or child must
+// match when any are given.
+type JobQuery struct {
+	And           []JobQuery        `json:"and,omitempty"`
+	Or            []JobQuery        `json:"or,omitempty"`
+	Type          *string           `json:"type,omitempty"`
+	Status        *JobStatus        `json:"status,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	CreatedAfter  *time.Time        `json:"created_after,omitempty"`
+	CreatedBefore *time.Time        `json:"created_before,omitempty"`
+	ErrorContains *string           `json:"error_contains,omitempty"`
+}
+
+func (q *JobQuery) Validate() error {
+	return q.validate(0)
+}
+
+func (q *JobQuery) validate(depth int) error {
+	if depth > maxQueryDepth {
+		return fmt.Errorf("query nesting exceeds %d levels", maxQueryDepth)
+	}
+
+	filter := JobFilter{Type: q.Type, Status: q.Status}
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	if q.CreatedAfter != nil && q.CreatedBefore != nil && !q.CreatedAfter.Before(*q.CreatedBefore) {
+		return errors.New("created_after must be before created_before")
+	}
+
+	for i := range q.And {
+		if err := q.And[i].validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	for i := range q.Or {
+		if err := q.Or[i].validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the job satisfies the query
+func (q *JobQuery) Matches(job *Job) bool {
+	if q.Type != nil && *q.Type != job.Type {
+		return false
+	}
+	if q.Status != nil && *q.Status != job.Status {
+		return false
+	}
+	for k, v := range q.Labels {
+		if job.Labels[k] != v {
+			return false
+		}
+	}
+	if q.CreatedAfter != nil && (job.CreatedAt == nil || !job.CreatedAt.After(*q.CreatedAfter)) {
+		return false
+	}
+	if q.CreatedBefore != nil && (job.CreatedAt == nil || !job.CreatedAt.Before(*q.CreatedBefore)) {
+		return false
+	}
+	if q.ErrorContains != nil && !strings.Contains(strings.ToLower(job.Error), strings.ToLower(*q.ErrorContains)) {
+		return false
+	}
+
+	for i := range q.And {
+		if !q.And[i].Matches(job) {
+			return false
+		}
+	}
+	if len(q.Or) == 0 {
+		return true
+	}
+	for i := range q.Or {
+		if q.Or[i].Matches(job) {
+			return true
+		}
+	}
+	return false
+}
+
+type SearchJobsRequest struct {
+	Query  JobQuery `json:"query"`
+	Limit  int      `json:"limit,omitempty"`
+	Cursor string   `json:"cursor,omitempty"`
+}
+
+func (r *SearchJobsRequest) Validate() error {
+	if r.Limit < 0 || r.Limit > MaxSearchLimit {
+		return fmt.Errorf("limit must be between 0 and %d", MaxSearchLimit)
+	}
+	if r.Cursor != "" {
+		if _, err := ParseJobCursor(r.Cursor); err != nil {
+			return err
+		}
+	}
+	return r.Query.Validate()
+}
+
+type SearchJobsResponse struct {
+	Jobs       []*Job `json:"jobs"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// JobCursor marks a position in the (created_at, uid) ordering of jobs
+type JobCursor struct {
+	CreatedAt time.Time
+	UID       uuid.UUID
+}
+
+func NewJobCursor(job *Job) JobCursor {
+	return JobCursor{CreatedAt: jobCreatedAt(job), UID: job.UID}
+}
+
+// ParseJobCursor decodes an opaque cursor returned by a previous search
+func ParseJobCursor(s string) (JobCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return JobCursor{}, errors.New("invalid cursor")
+	}
+	ts, uid, ok := strings.Cut(string(data), ",")
+	if !ok {
+		return JobCursor{}, errors.New("invalid cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return JobCursor{}, errors.New("invalid cursor")
+	}
+	id, err := uuid.Parse(uid)
+	if err != nil {
+		return JobCursor{}, errors.New("invalid cursor")
+	}
+	return JobCursor{CreatedAt: createdAt, UID: id}, nil
+}
+
+func (c JobCursor) String() string {
+	raw := c.CreatedAt.Format(time.RFC3339Nano) + "," + c.UID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// After reports whether the job sorts after the cursor position
+func (c JobCursor) After(job *Job) bool {
+	return CompareJobs(job, &Job{UID: c.UID, CreatedAt: &c.CreatedAt}) > 0
+}
+
+// CompareJobs orders jobs by creation time, breaking ties by UID
+func CompareJobs(a, b *Job) int {
+	ta, tb := jobCreatedAt(a), jobCreatedAt(b)
+	if c := ta.Compare(tb); c != 0 {
+		return c
+	}
+	return strings.Compare(a.UID.String(), b.UID.String())
+}
+
+func jobCreatedAt(job *Job) time.Time {
+	if job.CreatedAt == nil {
+		return time.Time{}
+	}
+	return *job.CreatedAt
+}
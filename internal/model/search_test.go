@@ -0,0 +1,180 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobQuery_Matches(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	job := &Job{
+		UID:       uuid.New(),
+		Type:      "sleep",
+		Status:    JobStatusFailed,
+		Labels:    map[string]string{"team": "core"},
+		Error:     "context deadline exceeded",
+		CreatedAt: &now,
+	}
+
+	tests := []struct {
+		name  string
+		query JobQuery
+		want  bool
+	}{
+		{
+			name:  "empty query",
+			query: JobQuery{},
+			want:  true,
+		},
+		{
+			name:  "type and status",
+			query: JobQuery{Type: stringPtr("sleep"), Status: jobStatusPtr(JobStatusFailed)},
+			want:  true,
+		},
+		{
+			name:  "type mismatch",
+			query: JobQuery{Type: stringPtr("math")},
+			want:  false,
+		},
+		{
+			name:  "label match",
+			query: JobQuery{Labels: map[string]string{"team": "core"}},
+			want:  true,
+		},
+		{
+			name:  "label mismatch",
+			query: JobQuery{Labels: map[string]string{"team": "edge"}},
+			want:  false,
+		},
+		{
+			name:  "error text is case insensitive",
+			query: JobQuery{ErrorContains: stringPtr("DEADLINE")},
+			want:  true,
+		},
+		{
+			name:  "created after",
+			query: JobQuery{CreatedAfter: &earlier},
+			want:  true,
+		},
+		{
+			name:  "created before",
+			query: JobQuery{CreatedBefore: &earlier},
+			want:  false,
+		},
+		{
+			name: "or with one match",
+			query: JobQuery{Or: []JobQuery{
+				{Status: jobStatusPtr(JobStatusRunning)},
+				{Status: jobStatusPtr(JobStatusFailed)},
+			}},
+			want: true,
+		},
+		{
+			name: "or with no match",
+			query: JobQuery{Or: []JobQuery{
+				{Status: jobStatusPtr(JobStatusRunning)},
+				{Status: jobStatusPtr(JobStatusCompleted)},
+			}},
+			want: false,
+		},
+		{
+			name: "and with nested or",
+			query: JobQuery{And: []JobQuery{
+				{Type: stringPtr("sleep")},
+				{Or: []JobQuery{
+					{ErrorContains: stringPtr("timeout")},
+					{ErrorContains: stringPtr("deadline")},
+				}},
+			}},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.query.Matches(job))
+		})
+	}
+}
+
+func TestSearchJobsRequest_Validate(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		req     SearchJobsRequest
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "empty request",
+			req:  SearchJobsRequest{},
+		},
+		{
+			name: "valid cursor",
+			req: SearchJobsRequest{
+				Cursor: NewJobCursor(&Job{UID: uuid.New(), CreatedAt: &now}).String(),
+			},
+		},
+		{
+			name:    "invalid cursor",
+			req:     SearchJobsRequest{Cursor: "not-a-cursor"},
+			wantErr: true,
+			errMsg:  "invalid cursor",
+		},
+		{
+			name:    "limit too large",
+			req:     SearchJobsRequest{Limit: MaxSearchLimit + 1},
+			wantErr: true,
+			errMsg:  "limit must be between 0 and 500",
+		},
+		{
+			name: "invalid nested status",
+			req: SearchJobsRequest{Query: JobQuery{
+				Or: []JobQuery{{Status: jobStatusPtr(JobStatus("bad"))}},
+			}},
+			wantErr: true,
+			errMsg:  "invalid status: bad",
+		},
+		{
+			name: "inverted time range",
+			req: SearchJobsRequest{Query: JobQuery{
+				CreatedAfter:  &later,
+				CreatedBefore: &now,
+			}},
+			wantErr: true,
+			errMsg:  "created_after must be before created_before",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestJobCursor_RoundTrip(t *testing.T) {
+	now := time.Now()
+	job := &Job{UID: uuid.New(), CreatedAt: &now}
+
+	cursor, err := ParseJobCursor(NewJobCursor(job).String())
+	assert.NoError(t, err)
+	assert.Equal(t, job.UID, cursor.UID)
+	assert.True(t, now.Equal(cursor.CreatedAt))
+	assert.False(t, cursor.After(job))
+
+	later := now.Add(time.Millisecond)
+	assert.True(t, cursor.After(&Job{UID: uuid.New(), CreatedAt: &later}))
+}
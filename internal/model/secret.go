@@ -0,0 +1,65 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SecretString is a payload value given either literally or as a reference
+// to a secret, written {"$secret": "name"}. References are resolved only
+// while the job runs, so the secret's value is never stored or returned.
+type SecretString struct {
+	Value string
+	// Secret names the secret the value comes from
+	Secret string
+}
+
+func (s SecretString) MarshalJSON() ([]byte, error) {
+	if s.Secret != "" {
+		return json.Marshal(map[string]string{"$secret": s.Secret})
+	}
+	return json.Marshal(s.Value)
+}
+
+func (s *SecretString) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		*s = SecretString{}
+		return json.Unmarshal(data, &s.Value)
+	}
+	var ref map[string]string
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return err
+	}
+	name, ok := ref["$secret"]
+	if !ok || len(ref) != 1 {
+		return errors.New(`secret reference must be {"$secret": "name"}`)
+	}
+	*s = SecretString{Secret: name}
+	return nil
+}
+
+// SecretUser is implemented by payloads whose values may reference secrets
+type SecretUser interface {
+	// Secrets returns the names of the secrets the payload references
+	Secrets() []string
+	// WithSecrets returns a copy of the payload with every reference
+	// replaced by the secret's value
+	WithSecrets(values map[string]string) JobPayload
+}
+
+// MaxSecretNameLength bounds the names secrets are referenced by
+const MaxSecretNameLength = 256
+
+var secretName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// ValidateSecretName checks the name a payload references a secret by
+func ValidateSecretName(name string) error {
+	if len(name) > MaxSecretNameLength || !secretName.MatchString(name) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid secret name %q", name)
+	}
+	return nil
+}
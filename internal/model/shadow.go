@@ -0,0 +1,26 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShadowRun compares the run of a job by its type's executor with the copy
+// run by a shadow executor being validated alongside it. Differences lists
+// where the two outcomes disagree, as JSON paths into the results or
+// "error"; the run matched when it is empty.
+type ShadowRun struct {
+	JobUID          uuid.UUID       `json:"job_uid"`
+	JobType         string          `json:"job_type"`
+	Match           bool            `json:"match"`
+	Differences     []string        `json:"differences,omitempty"`
+	Result          json.RawMessage `json:"result,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	ShadowResult    json.RawMessage `json:"shadow_result,omitempty"`
+	ShadowError     string          `json:"shadow_error,omitempty"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	ShadowSeconds   float64         `json:"shadow_seconds"`
+	ComparedAt      time.Time       `json:"compared_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QueueSnapshot is the contents of the job queue at one moment, oldest job
+// first. It can be replayed into a queue later, on this instance or another.
+type QueueSnapshot struct {
+	TakenAt time.Time `json:"taken_at"`
+	Jobs    []*Job    `json:"jobs"`
+}
+
+// ReplayResult reports what replaying a queue snapshot did with its jobs
+type ReplayResult struct {
+	Queued  []uuid.UUID  `json:"queued"`
+	Skipped []ReplaySkip `json:"skipped,omitempty"`
+}
+
+// ReplaySkip is a job in a snapshot that was not queued, and why
+type ReplaySkip struct {
+	JobUID uuid.UUID `json:"job_uid"`
+	Reason string    `json:"reason"`
+}
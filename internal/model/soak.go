@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// PoolInternals counts the pool's internal bookkeeping, so long-running
+// processes can be checked for leaks
+type PoolInternals struct {
+	Jobs           int `json:"jobs"`
+	ActiveJobs     int `json:"active_jobs"`
+	RunningJobs    int `json:"running_jobs"`
+	Logs           int `json:"logs"`
+	OpenLogs       int `json:"open_logs"`
+	Leases         int `json:"leases"`
+	Lanes          int `json:"lanes"`
+	Subscribers    int `json:"subscribers"`
+	QueueDepth     int `json:"queue_depth"`
+	QueueCapacity  int `json:"queue_capacity"`
+	ResultBacklog  int `json:"result_backlog"`
+	ResultCapacity int `json:"result_capacity"`
+}
+
+// SoakReport is the outcome of one soak self-check
+type SoakReport struct {
+	CheckedAt  time.Time     `json:"checked_at"`
+	Goroutines int           `json:"goroutines"`
+	Pool       PoolInternals `json:"pool"`
+	Warnings   []string      `json:"warnings,omitempty"`
+}
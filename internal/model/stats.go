@@ -0,0 +1,172 @@
+package model
+
+import "time"
+
+// DefaultTenant is used for jobs submitted without a tenant
+const DefaultTenant = "default"
+
+// DefaultTenantHeader is the request header jobs are attributed to a tenant
+// by, unless another is configured
+const DefaultTenantHeader = "X-Tenant-ID"
+
+// TenantBudget is the daily execution time allowance per tenant. A zero
+// limit means the tenant is unbudgeted.
+type TenantBudget struct {
+	Default   time.Duration
+	Overrides map[string]time.Duration
+}
+
+func (b TenantBudget) Limit(tenant string) time.Duration {
+	if limit, ok := b.Overrides[tenant]; ok {
+		return limit
+	}
+	return b.Default
+}
+
+type TenantUsage struct {
+	ExecutionSeconds float64 `json:"execution_seconds"`
+	BudgetSeconds    float64 `json:"budget_seconds,omitempty"`
+}
+
+type PoolStats struct {
+	Workers       int                    `json:"workers"`
+	Slots         int                    `json:"slots"`
+	SlotsInUse    int                    `json:"slots_in_use"`
+	QueueDepth    int                    `json:"queue_depth"`
+	QueueCapacity int                    `json:"queue_capacity"`
+	Jobs          map[JobStatus]int      `json:"jobs"`
+	TenantUsage   map[string]TenantUsage `json:"tenant_usage"`
+	WorkerDetails []WorkerStats          `json:"worker_details"`
+	// ResultCache describes the cache of job results, when any job type
+	// uses it
+	ResultCache *CacheStats `json:"result_cache,omitempty"`
+	// Memo describes the values executors have memoized, by job type
+	Memo map[string]CacheStats `json:"memo,omitempty"`
+	// Variants describes how the job types split between executor
+	// implementations ran on each
+	Variants []VariantStats `json:"executor_variants,omitempty"`
+	// LargeJobs describes the lane jobs with large payloads are queued and
+	// run in, when it is on. QueueDepth and QueueCapacity exclude them.
+	LargeJobs *LaneStats `json:"large_jobs,omitempty"`
+	// Overflow describes the queue on disk jobs spill to once the queue is
+	// full, when there is one
+	Overflow *OverflowStats `json:"overflow,omitempty"`
+	// Retention describes the cap on finished jobs kept, when there is one
+	Retention *RetentionStats `json:"retention,omitempty"`
+	// Payloads describes the payloads shared between jobs submitted with
+	// identical ones, when that's on
+	Payloads *PayloadStats `json:"payloads,omitempty"`
+	// Latency splits the time each job type's jobs take between waiting
+	// in the queue and running, by type
+	Latency map[string]LatencyStats `json:"latency,omitempty"`
+	// AdaptiveConcurrency describes the concurrency limits that adjust
+	// themselves, by job type
+	AdaptiveConcurrency map[string]AdaptiveConcurrencyStats `json:"adaptive_concurrency,omitempty"`
+}
+
+// AdaptiveConcurrencyStats describes where an adaptive concurrency limit
+// stands and how often it has moved
+type AdaptiveConcurrencyStats struct {
+	Limit     int   `json:"limit"`
+	Min       int   `json:"min"`
+	Max       int   `json:"max"`
+	Increases int64 `json:"increases"`
+	Decreases int64 `json:"decreases"`
+}
+
+// LatencyStats tells whether a job type's jobs are slow to be dispatched or
+// slow to run
+type LatencyStats struct {
+	// Queue is how long jobs waited from submission to the start of their
+	// first attempt
+	Queue DurationStats `json:"queue"`
+	// Execution is how long attempts that completed or failed ran
+	Execution DurationStats `json:"execution"`
+}
+
+// DurationStats summarizes durations. Count and the mean cover every one
+// recorded, and the percentiles and maximum the most recent thousand.
+type DurationStats struct {
+	Count       int64   `json:"count"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	P50Seconds  float64 `json:"p50_seconds"`
+	P95Seconds  float64 `json:"p95_seconds"`
+	P99Seconds  float64 `json:"p99_seconds"`
+	MaxSeconds  float64 `json:"max_seconds"`
+}
+
+// PayloadStats describes the large payloads stored once and shared by every
+// job holding an identical one
+type PayloadStats struct {
+	// ThresholdBytes is the encoded size from which payloads are shared
+	ThresholdBytes int `json:"threshold_bytes"`
+	// Unique counts the distinct payloads held, and Jobs the jobs sharing
+	// them
+	Unique int `json:"unique"`
+	Jobs   int `json:"jobs"`
+	// BytesSaved is how much encoded payload the jobs sharing a copy
+	// would hold between them otherwise
+	BytesSaved int64 `json:"bytes_saved"`
+}
+
+// RetentionStats describes the finished jobs kept and those evicted to stay
+// within the limit
+type RetentionStats struct {
+	Limit    int `json:"limit"`
+	Retained int `json:"retained"`
+	// Evicted counts the jobs ever evicted, and EvictedUnread those of
+	// them nobody read after they finished
+	Evicted       int64 `json:"evicted"`
+	EvictedUnread int64 `json:"evicted_unread"`
+}
+
+// OverflowStats describes the queue on disk jobs spill to
+type OverflowStats struct {
+	// Depth is how many jobs are waiting on disk to be queued
+	Depth    int `json:"depth"`
+	Capacity int `json:"capacity"`
+	// Spilled counts the jobs ever spilled
+	Spilled int64 `json:"spilled"`
+}
+
+// LaneStats describes a lane of jobs queued and run apart from the rest
+type LaneStats struct {
+	// ThresholdBytes is the payload size above which jobs join the lane
+	ThresholdBytes int `json:"threshold_bytes"`
+	Slots          int `json:"slots"`
+	Running        int `json:"running"`
+	QueueDepth     int `json:"queue_depth"`
+	QueueCapacity  int `json:"queue_capacity"`
+}
+
+// VariantStats describes the runs of a job type on one of the executor
+// implementations its jobs are split between
+type VariantStats struct {
+	JobType string  `json:"job_type"`
+	Variant string  `json:"variant"`
+	Percent float64 `json:"percent"`
+	Runs    int     `json:"runs"`
+	Failed  int     `json:"failed"`
+	// Seconds is the run time of the variant's runs in total
+	Seconds float64 `json:"seconds"`
+}
+
+// CacheStats describes a cache's size and how often lookups found an entry
+type CacheStats struct {
+	Entries int `json:"entries"`
+	Hits    int `json:"hits"`
+	Misses  int `json:"misses"`
+	// Evictions counts entries dropped to make room before they expired
+	Evictions int `json:"evictions,omitempty"`
+}
+
+// WorkerStats describes a local worker
+type WorkerStats struct {
+	ID           int      `json:"id"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Capacity     int      `json:"capacity"`
+	SlotsInUse   int      `json:"slots_in_use"`
+	// RoutedKeys are the serialization keys of the jobs the worker took
+	// most recently, newest first
+	RoutedKeys []string `json:"routed_keys,omitempty"`
+}
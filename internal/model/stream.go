@@ -0,0 +1,18 @@
+package model
+
+import "github.com/google/uuid"
+
+// StreamedJobResult answers one line of a streamed submission. Lines are
+// numbered from 1, counting blank lines, which are skipped. A rejected line
+// carries the status it would have got submitted on its own. A line skipped
+// by unless_exists or folded into a coalesced job is neither accepted nor
+// rejected, and names the existing job.
+type StreamedJobResult struct {
+	Line     int          `json:"line"`
+	Accepted bool         `json:"accepted"`
+	Existing bool         `json:"existing,omitempty"`
+	JobUID   *uuid.UUID   `json:"job_uid,omitzero"`
+	Status   int          `json:"status,omitempty"`
+	Error    string       `json:"error,omitempty"`
+	Fields   []FieldError `json:"fields,omitempty"`
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Events a job timeseries can count
+const (
+	TimeseriesSubmitted = "submitted"
+	TimeseriesStarted   = "started"
+	TimeseriesCompleted = "completed"
+	TimeseriesFailed    = "failed"
+)
+
+// Limits on a timeseries request, so one can't build an unbounded response
+const (
+	MaxTimeseriesWindow = 7 * 24 * time.Hour
+	MaxTimeseriesPoints = 1440
+)
+
+// TimeseriesRequest asks for the number of jobs that reached Metric in each
+// Step over the last Window, optionally of one job Type
+type TimeseriesRequest struct {
+	Metric string
+	Type   string
+	Window time.Duration
+	Step   time.Duration
+}
+
+func (r *TimeseriesRequest) Validate() error {
+	var problems ValidationError
+	if !slices.Contains([]string{TimeseriesSubmitted, TimeseriesStarted, TimeseriesCompleted, TimeseriesFailed}, r.Metric) {
+		problems.Add("metric", "must be %s, %s, %s or %s", TimeseriesSubmitted, TimeseriesStarted, TimeseriesCompleted, TimeseriesFailed)
+	}
+	if r.Type != "" && !IsBuiltinJobType(r.Type) {
+		problems.Add("type", "unknown job type %q", r.Type)
+	}
+	if r.Window <= 0 || r.Window > MaxTimeseriesWindow {
+		problems.Add("window", "must be positive and at most %s", MaxTimeseriesWindow)
+	}
+	if r.Step <= 0 || r.Step > r.Window {
+		problems.Add("step", "must be positive and at most the window")
+	} else if r.Window/r.Step > MaxTimeseriesPoints {
+		problems.Add("step", "gives more than %d points over the window", MaxTimeseriesPoints)
+	}
+	return problems.Err()
+}
+
+// Timeseries holds the number of jobs that reached a metric in each step,
+// oldest first. For completed and failed jobs each point also has the mean
+// and longest run time of the jobs that ran.
+type Timeseries struct {
+	Metric string            `json:"metric"`
+	Type   string            `json:"type,omitempty"`
+	Window string            `json:"window"`
+	Step   string            `json:"step"`
+	Points []TimeseriesPoint `json:"points"`
+}
+
+// TimeseriesPoint covers the step starting at Time
+type TimeseriesPoint struct {
+	Time        time.Time `json:"time"`
+	Count       int       `json:"count"`
+	MeanSeconds float64   `json:"mean_seconds,omitempty"`
+	MaxSeconds  float64   `json:"max_seconds,omitempty"`
+}
+
+// NewTimeseries buckets jobs for the request as of now. Steps are aligned to
+// multiples of the step, and the last one holds now.
+func NewTimeseries(req *TimeseriesRequest, jobs []*Job, now time.Time) *Timeseries {
+	n := int(req.Window / req.Step)
+	if req.Window%req.Step != 0 {
+		n++
+	}
+	end := now.Truncate(req.Step).Add(req.Step)
+	start := end.Add(-time.Duration(n) * req.Step)
+
+	ts := &Timeseries{
+		Metric: req.Metric,
+		Type:   req.Type,
+		Window: req.Window.String(),
+		Step:   req.Step.String(),
+		Points: make([]TimeseriesPoint, n),
+	}
+	// Jobs completed from the cache never ran, so they are counted without
+	// adding to the run times
+	totals := make([]float64, n)
+	runs := make([]int, n)
+	for i := range ts.Points {
+		ts.Points[i].Time = start.Add(time.Duration(i) * req.Step)
+	}
+
+	for _, job := range jobs {
+		if req.Type != "" && job.Type != req.Type {
+			continue
+		}
+		at := timeseriesTime(req.Metric, job)
+		if at == nil || at.Before(start) || !at.Before(end) {
+			continue
+		}
+		i := int(at.Sub(start) / req.Step)
+		p := &ts.Points[i]
+		p.Count++
+		if req.Metric == TimeseriesCompleted || req.Metric == TimeseriesFailed {
+			if job.StartedAt != nil {
+				d := job.CompletedAt.Sub(*job.StartedAt).Seconds()
+				totals[i] += d
+				runs[i]++
+				p.MaxSeconds = max(p.MaxSeconds, d)
+			}
+		}
+	}
+	for i := range ts.Points {
+		if runs[i] > 0 {
+			ts.Points[i].MeanSeconds = totals[i] / float64(runs[i])
+		}
+	}
+	return ts
+}
+
+// timeseriesTime is when the job reached the metric, or nil if it hasn't
+func timeseriesTime(metric string, job *Job) *time.Time {
+	switch metric {
+	case TimeseriesSubmitted:
+		return job.CreatedAt
+	case TimeseriesStarted:
+		return job.StartedAt
+	case TimeseriesCompleted, TimeseriesFailed:
+		if string(job.Status) != metric {
+			return nil
+		}
+		return job.CompletedAt
+	default:
+		return nil
+	}
+}
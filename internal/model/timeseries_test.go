@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeseriesRequest_Validate(t *testing.T) {
+	valid := TimeseriesRequest{Metric: TimeseriesCompleted, Window: time.Hour, Step: time.Minute}
+	assert.NoError(t, valid.Validate())
+
+	req := TimeseriesRequest{Metric: "cancelled", Type: "ftp", Window: 8 * 24 * time.Hour, Step: time.Second}
+	assert.EqualError(t, req.Validate(), "metric: must be submitted, started, completed or failed; type: unknown job type \"ftp\"; "+
+		"window: must be positive and at most 168h0m0s; step: gives more than 1440 points over the window")
+
+	req = TimeseriesRequest{Metric: TimeseriesFailed, Window: time.Minute, Step: time.Hour}
+	assert.EqualError(t, req.Validate(), "step: must be positive and at most the window")
+}
+
+func TestNewTimeseries(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 2, 30, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	jobs := []*Job{
+		// Ran 10s, finishing in the current step
+		{Type: "math", Status: JobStatusCompleted, CreatedAt: at(-20 * time.Second), StartedAt: at(-20 * time.Second), CompletedAt: at(-10 * time.Second)},
+		// Ran 30s, finishing in the step before
+		{Type: "sleep", Status: JobStatusCompleted, CreatedAt: at(-2 * time.Minute), StartedAt: at(-2 * time.Minute), CompletedAt: at(-90 * time.Second)},
+		// Completed from the cache without running
+		{Type: "math", Status: JobStatusCompleted, CreatedAt: at(-5 * time.Second), CompletedAt: at(-5 * time.Second)},
+		{Type: "math", Status: JobStatusFailed, CreatedAt: at(-time.Minute), StartedAt: at(-time.Minute), CompletedAt: at(-50 * time.Second)},
+		{Type: "math", Status: JobStatusPending, CreatedAt: at(-time.Second)},
+		// Outside the window
+		{Type: "math", Status: JobStatusCompleted, CreatedAt: at(-time.Hour), StartedAt: at(-time.Hour), CompletedAt: at(-time.Hour)},
+	}
+
+	ts := NewTimeseries(&TimeseriesRequest{Metric: TimeseriesCompleted, Window: 3 * time.Minute, Step: time.Minute}, jobs, now)
+	assert.Equal(t, "3m0s", ts.Window)
+	assert.Equal(t, "1m0s", ts.Step)
+	assert.Equal(t, []TimeseriesPoint{
+		{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)},
+		{Time: time.Date(2026, 10, 16, 12, 1, 0, 0, time.UTC), Count: 1, MeanSeconds: 30, MaxSeconds: 30},
+		{Time: time.Date(2026, 10, 16, 12, 2, 0, 0, time.UTC), Count: 2, MeanSeconds: 10, MaxSeconds: 10},
+	}, ts.Points)
+
+	ts = NewTimeseries(&TimeseriesRequest{Metric: TimeseriesSubmitted, Type: "math", Window: 3 * time.Minute, Step: time.Minute}, jobs, now)
+	counts := make([]int, len(ts.Points))
+	for i, p := range ts.Points {
+		counts[i] = p.Count
+		assert.Zero(t, p.MeanSeconds)
+	}
+	assert.Equal(t, []int{0, 1, 3}, counts)
+
+	// A window that isn't a whole number of steps is rounded up
+	ts = NewTimeseries(&TimeseriesRequest{Metric: TimeseriesFailed, Window: 90 * time.Second, Step: time.Minute}, jobs, now)
+	require.Len(t, ts.Points, 2)
+	assert.Equal(t, 1, ts.Points[0].Count)
+	assert.Equal(t, 10.0, ts.Points[0].MaxSeconds)
+}
@@ -0,0 +1,98 @@
+package model
+
+import "time"
+
+// UsageMonthFormat is how usage report months are written, e.g. 2026-10
+const UsageMonthFormat = "2006-01"
+
+// UsageReport summarises a tenant's jobs over a calendar month (UTC) for
+// chargeback. Jobs are counted in the month they finished, and the time each
+// attempt ran and what it cost in the month it ended.
+type UsageReport struct {
+	Tenant           string                  `json:"tenant"`
+	Month            string                  `json:"month"`
+	Jobs             int                     `json:"jobs"`
+	Failed           int                     `json:"failed"`
+	ExecutionSeconds float64                 `json:"execution_seconds"`
+	Cost             float64                 `json:"cost"`
+	ByType           map[string]JobTypeUsage `json:"by_type"`
+	// Complete is false while the month is still under way
+	Complete    bool      `json:"complete"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// JobTypeUsage is a tenant's usage of one job type
+type JobTypeUsage struct {
+	Jobs             int     `json:"jobs"`
+	Failed           int     `json:"failed"`
+	ExecutionSeconds float64 `json:"execution_seconds"`
+	Cost             float64 `json:"cost"`
+}
+
+// NewUsageReports summarises the jobs of every tenant that used the service
+// in the month starting at month, as of now
+func NewUsageReports(month time.Time, jobs []*Job, now time.Time) map[string]*UsageReport {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	within := func(t *time.Time) bool {
+		return t != nil && !t.Before(start) && t.Before(end)
+	}
+
+	reports := make(map[string]*UsageReport)
+	report := func(tenant string) *UsageReport {
+		r, ok := reports[tenant]
+		if !ok {
+			r = NewUsageReport(tenant, start, now)
+			reports[tenant] = r
+		}
+		return r
+	}
+	for _, job := range jobs {
+		tenant := job.Tenant
+		if tenant == "" {
+			tenant = DefaultTenant
+		}
+		var seconds, cost float64
+		for _, a := range job.Attempts {
+			if within(a.EndedAt) {
+				seconds += a.EndedAt.Sub(a.StartedAt).Seconds()
+				if a.Cost != nil {
+					cost += *a.Cost
+				}
+			}
+		}
+		finished := within(job.CompletedAt) && (job.Status == JobStatusCompleted || job.Status == JobStatusFailed)
+		if seconds == 0 && !finished {
+			continue
+		}
+
+		r := report(tenant)
+		usage := r.ByType[job.Type]
+		usage.ExecutionSeconds += seconds
+		r.ExecutionSeconds += seconds
+		usage.Cost += cost
+		r.Cost += cost
+		if finished {
+			usage.Jobs++
+			r.Jobs++
+			if job.Status == JobStatusFailed {
+				usage.Failed++
+				r.Failed++
+			}
+		}
+		r.ByType[job.Type] = usage
+	}
+	return reports
+}
+
+// NewUsageReport returns an empty report for the tenant's usage in the month
+// starting at start
+func NewUsageReport(tenant string, start, now time.Time) *UsageReport {
+	return &UsageReport{
+		Tenant:      tenant,
+		Month:       start.Format(UsageMonthFormat),
+		ByType:      make(map[string]JobTypeUsage),
+		Complete:    !now.Before(start.AddDate(0, 1, 0)),
+		GeneratedAt: now,
+	}
+}
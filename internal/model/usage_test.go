@@ -0,0 +1,74 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUsageReports(t *testing.T) {
+	october := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := october.Add(d)
+		return &t
+	}
+	attempt := func(start, end time.Duration, outcome AttemptOutcome) JobAttempt {
+		return JobAttempt{StartedAt: *at(start), EndedAt: at(end), Outcome: outcome}
+	}
+	jobs := []*Job{
+		{Tenant: "team-a", Type: "math", Status: JobStatusCompleted, CompletedAt: at(time.Hour),
+			Attempts: []JobAttempt{attempt(time.Hour-10*time.Second, time.Hour, AttemptCompleted)}},
+		// Retried once before failing
+		{Tenant: "team-a", Type: "sleep", Status: JobStatusFailed, CompletedAt: at(2 * time.Hour), Error: "boom",
+			Attempts: []JobAttempt{attempt(time.Hour, time.Hour+time.Minute, AttemptFailed), attempt(2*time.Hour-time.Minute, 2*time.Hour, AttemptFailed)}},
+		// Started in September, and counted in October where its run ended
+		{Tenant: "team-a", Type: "sleep", Status: JobStatusCompleted, CompletedAt: at(30 * time.Second),
+			Attempts: []JobAttempt{attempt(-30*time.Second, 30*time.Second, AttemptCompleted)}},
+		// Still running, with a lapsed lease earlier in the month
+		{Tenant: "team-b", Type: "math", Status: JobStatusRunning,
+			Attempts: []JobAttempt{attempt(0, 5*time.Second, AttemptLeaseExpired), {StartedAt: *at(10 * time.Second)}}},
+		// Finished in November
+		{Tenant: "team-c", Type: "math", Status: JobStatusCompleted, CompletedAt: at(31 * 24 * time.Hour),
+			Attempts: []JobAttempt{attempt(31*24*time.Hour-time.Second, 31*24*time.Hour, AttemptCompleted)}},
+		// Submitted without a tenant and completed from the cache
+		{Type: "math", Status: JobStatusCompleted, CompletedAt: at(time.Minute), Cached: true},
+	}
+	cost := func(c float64) *float64 { return &c }
+	jobs[0].Attempts[0].Cost = cost(0.5)
+	jobs[1].Attempts[0].Cost = cost(0.25)
+	jobs[1].Attempts[1].Cost = cost(0.25)
+
+	now := october.Add(15 * 24 * time.Hour)
+	reports := NewUsageReports(october.Add(12*time.Hour), jobs, now)
+	require.Len(t, reports, 3)
+
+	a := reports["team-a"]
+	assert.Equal(t, "2026-10", a.Month)
+	assert.Equal(t, 3, a.Jobs)
+	assert.Equal(t, 1, a.Failed)
+	assert.Equal(t, 190.0, a.ExecutionSeconds)
+	assert.Equal(t, 1.0, a.Cost)
+	assert.Equal(t, map[string]JobTypeUsage{
+		"math":  {Jobs: 1, ExecutionSeconds: 10, Cost: 0.5},
+		"sleep": {Jobs: 2, Failed: 1, ExecutionSeconds: 180, Cost: 0.5},
+	}, a.ByType)
+	assert.False(t, a.Complete)
+	assert.Equal(t, now, a.GeneratedAt)
+
+	b := reports["team-b"]
+	assert.Zero(t, b.Jobs)
+	assert.Equal(t, 5.0, b.ExecutionSeconds)
+
+	assert.Equal(t, &UsageReport{
+		Tenant:      DefaultTenant,
+		Month:       "2026-10",
+		Jobs:        1,
+		ByType:      map[string]JobTypeUsage{"math": {Jobs: 1}},
+		GeneratedAt: now,
+	}, reports[DefaultTenant])
+
+	reports = NewUsageReports(october, jobs, october.AddDate(0, 1, 0))
+	assert.True(t, reports["team-a"].Complete)
+}
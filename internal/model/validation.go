@@ -0,0 +1,144 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// FieldError describes what is wrong with one field of a request body.
+// Field is a dotted path such as "payload.duration", or empty when the
+// body as a whole is at fault.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError lists every problem found in a request body
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add records a problem with field
+func (e *ValidationError) Add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// AddErr records err against field. The fields of a ValidationError are
+// nested under it, and any other error becomes the field's message.
+func (e *ValidationError) AddErr(field string, err error) {
+	if err == nil {
+		return
+	}
+	var nested *ValidationError
+	if !errors.As(err, &nested) {
+		e.Fields = append(e.Fields, FieldError{Field: field, Message: err.Error()})
+		return
+	}
+	for _, f := range nested.Fields {
+		e.Fields = append(e.Fields, FieldError{Field: joinPath(field, f.Field), Message: f.Message})
+	}
+}
+
+// Err returns e, or nil if no problems were recorded
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// fieldError returns a ValidationError for a single field
+func fieldError(field, format string, args ...any) error {
+	var e ValidationError
+	e.Add(field, format, args...)
+	return &e
+}
+
+// nestedError returns err with its fields nested under field
+func nestedError(field string, err error) error {
+	var e ValidationError
+	e.AddErr(field, err)
+	return e.Err()
+}
+
+func joinPath(parent, field string) string {
+	switch {
+	case parent == "":
+		return field
+	case field == "":
+		return parent
+	default:
+		return parent + "." + field
+	}
+}
+
+// DecodeError turns an error from decoding a JSON request body into a
+// ValidationError naming the offending field and the type expected there
+func DecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		return fieldError(typeErr.Field, "expected %s", jsonType(typeErr.Type))
+	case errors.As(err, &syntaxErr):
+		return fieldError("", "invalid JSON at offset %d: %s", syntaxErr.Offset, syntaxErr)
+	case errors.Is(err, io.EOF):
+		return fieldError("", "request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fieldError("", "invalid JSON: unexpected end of input")
+	default:
+		return fieldError("", "%s", err)
+	}
+}
+
+// jsonType names the JSON type a Go value is decoded from
+func jsonType(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	default:
+		return t.String()
+	}
+}
+
+// ValidationErrorResponse is the body of a 400 response to a request that
+// failed validation
+type ValidationErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
+}
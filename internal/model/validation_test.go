@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeError(t *testing.T) {
+	type request struct {
+		Type    string            `json:"type"`
+		Weight  int               `json:"weight"`
+		Labels  map[string]string `json:"labels"`
+		Command []string          `json:"command"`
+	}
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: `{"type": 3}`, want: "type: expected string"},
+		{body: `{"weight": "heavy"}`, want: "weight: expected integer"},
+		{body: `{"weight": 1.5}`, want: "weight: expected integer"},
+		{body: `{"labels": []}`, want: "labels: expected object"},
+		{body: `{"labels": {"team": 1}}`, want: "labels.team: expected string"},
+		{body: `{"command": ["sh", 1]}`, want: "command.1: expected string"},
+		{body: `[]`, want: "expected object"},
+		{body: `{"type": `, want: "invalid JSON: unexpected end of input"},
+		{body: `{"type" "sleep"}`, want: "invalid JSON at offset 9: invalid character '\"' after object key"},
+		{body: ``, want: "request body is empty"},
+	}
+
+	for _, tt := range tests {
+		var req request
+		err := DecodeError(json.NewDecoder(strings.NewReader(tt.body)).Decode(&req))
+		assert.EqualError(t, err, tt.want, tt.body)
+		var invalid *ValidationError
+		assert.True(t, errors.As(err, &invalid), tt.body)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	var problems ValidationError
+	assert.NoError(t, problems.Err())
+
+	problems.AddErr("weight", nil)
+	problems.AddErr("weight", errors.New("must be between 1 and 64"))
+	problems.AddErr("payload", ContainerJobPayload{Env: map[string]SecretString{"A=B": {Value: "x"}}}.Validate())
+	problems.AddErr("", fieldError("type", "is required"))
+
+	assert.Equal(t, []FieldError{
+		{Field: "weight", Message: "must be between 1 and 64"},
+		{Field: "payload.image", Message: "is required"},
+		{Field: "payload.env", Message: `invalid environment variable name "A=B"`},
+		{Field: "type", Message: "is required"},
+	}, problems.Fields)
+	assert.EqualError(t, problems.Err(), `weight: must be between 1 and 64; payload.image: is required; payload.env: invalid environment variable name "A=B"; type: is required`)
+}
@@ -0,0 +1,13 @@
+package model
+
+// BuildInfo describes the running binary and the features the deployment
+// has enabled
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"git_sha"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	// Features names the backend chosen for each pluggable part of the
+	// service, e.g. "blob_storage": "s3"
+	Features map[string]string `json:"features,omitempty"`
+}
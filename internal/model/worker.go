@@ -0,0 +1,87 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxLeaseWait bounds how long a lease request may long-poll for work
+const MaxLeaseWait = 30 * time.Second
+
+// LeaseRequest is sent by a remote worker asking for a job
+type LeaseRequest struct {
+	WorkerID     string   `json:"worker_id"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Labels describe where the worker runs, such as its region, zone or
+	// hardware, so jobs placed there are leased to it
+	Labels map[string]string `json:"labels,omitempty"`
+	Wait   string            `json:"wait,omitempty"`
+}
+
+// WaitDuration parses the optional long-poll duration
+func (r *LeaseRequest) WaitDuration() (time.Duration, error) {
+	if r.Wait == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(r.Wait)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wait: %w", err)
+	}
+	if d < 0 || d > MaxLeaseWait {
+		return 0, fmt.Errorf("wait must be between 0s and %s", MaxLeaseWait)
+	}
+	return d, nil
+}
+
+func (r *LeaseRequest) Validate() error {
+	if r.WorkerID == "" {
+		return errors.New("worker_id is required")
+	}
+	if err := ValidateCapabilities(r.Capabilities); err != nil {
+		return err
+	}
+	if err := ValidateLabels(r.Labels); err != nil {
+		return fmt.Errorf("labels: %w", err)
+	}
+	_, err := r.WaitDuration()
+	return err
+}
+
+// HeartbeatRequest keeps a remote worker's lease on a job alive
+type HeartbeatRequest struct {
+	JobUID uuid.UUID `json:"job_uid"`
+	// Annotations are added to the job's annotations
+	Annotations map[string]json.RawMessage `json:"annotations,omitempty"`
+}
+
+// CompleteJobRequest reports the outcome of a leased job
+type CompleteJobRequest struct {
+	JobUID uuid.UUID       `json:"job_uid"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	// Permanent marks the error as one retrying cannot fix, so the job is
+	// failed even if it has retries left
+	Permanent bool `json:"permanent,omitempty"`
+	// Annotations are added to the job's annotations before it finishes
+	Annotations map[string]json.RawMessage `json:"annotations,omitempty"`
+	// Cost is added to the job's cost before it finishes, in place of
+	// charging its run time at the job type's rate
+	Cost *float64 `json:"cost,omitempty"`
+}
+
+func (r *CompleteJobRequest) Validate() error {
+	if r.JobUID == uuid.Nil {
+		return errors.New("job_uid is required")
+	}
+	if r.Error == "" && len(r.Result) == 0 {
+		return errors.New("either result or error is required")
+	}
+	if r.Cost != nil && *r.Cost < 0 {
+		return errors.New("cost must not be negative")
+	}
+	return nil
+}
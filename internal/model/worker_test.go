@@ -0,0 +1,133 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaseRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     LeaseRequest
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid request",
+			req:  LeaseRequest{WorkerID: "w1", Capabilities: []string{"gpu"}, Wait: "5s"},
+		},
+		{
+			name:    "missing worker id",
+			req:     LeaseRequest{},
+			wantErr: true,
+			errMsg:  "worker_id is required",
+		},
+		{
+			name:    "wait too long",
+			req:     LeaseRequest{WorkerID: "w1", Wait: "1m"},
+			wantErr: true,
+			errMsg:  "wait must be between 0s and 30s",
+		},
+		{
+			name:    "invalid wait",
+			req:     LeaseRequest{WorkerID: "w1", Wait: "soon"},
+			wantErr: true,
+			errMsg:  "invalid wait",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCompleteJobRequest_Validate(t *testing.T) {
+	cost := func(c float64) *float64 { return &c }
+	tests := []struct {
+		name    string
+		req     CompleteJobRequest
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "result",
+			req:  CompleteJobRequest{JobUID: uuid.New(), Result: json.RawMessage(`{"result": 1}`)},
+		},
+		{
+			name: "error",
+			req:  CompleteJobRequest{JobUID: uuid.New(), Error: "boom"},
+		},
+		{
+			name:    "missing job uid",
+			req:     CompleteJobRequest{Error: "boom"},
+			wantErr: true,
+			errMsg:  "job_uid is required",
+		},
+		{
+			name:    "missing outcome",
+			req:     CompleteJobRequest{JobUID: uuid.New()},
+			wantErr: true,
+			errMsg:  "either result or error is required",
+		},
+		{
+			name: "cost",
+			req:  CompleteJobRequest{JobUID: uuid.New(), Error: "boom", Cost: cost(0.02)},
+		},
+		{
+			name:    "negative cost",
+			req:     CompleteJobRequest{JobUID: uuid.New(), Error: "boom", Cost: cost(-1)},
+			wantErr: true,
+			errMsg:  "cost must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDecodeJobResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobType string
+		data    string
+		want    JobResult
+		wantErr bool
+	}{
+		{"math result", "math", `{"result": 6}`, MathJobResult{Result: 6}, false},
+		{"sleep result", "sleep", `{"slept_for": "1s"}`, SleepJobResult{SleptFor: "1s"}, false},
+		{"malformed result", "math", `{"result": "six"}`, nil, true},
+		{"unknown type", "invalid", `{}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeJobResult(tt.jobType, json.RawMessage(tt.data))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
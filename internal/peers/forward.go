@@ -0,0 +1,160 @@
+// Package peers hands submissions an instance can't run to other instances
+// of the service that may be able to, and keeps track of which instances
+// make up the cluster.
+package peers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// ErrNoPeer is returned when no peer accepted a submission
+var ErrNoPeer = errors.New("no peer accepted the job")
+
+// Forwarder submits jobs to peers, trying each in turn until one accepts
+type Forwarder struct {
+	node         string
+	token        string
+	tenantHeader string
+	peers        []*url.URL
+	client       *http.Client
+}
+
+// NewForwarder forwards on behalf of the named node to the peers at the
+// given base URLs
+func NewForwarder(node string, peers []string) (*Forwarder, error) {
+	urls, err := parseURLs(peers)
+	if err != nil {
+		return nil, err
+	}
+	return &Forwarder{node: node, peers: urls, tenantHeader: model.DefaultTenantHeader, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// SetToken sets the cluster token forwarded submissions are sent with, by
+// which peers know to trust that they were forwarded
+func (f *Forwarder) SetToken(token string) {
+	f.token = token
+}
+
+// SetTenantHeader sets the header a forwarded submission names its tenant
+// in, which must be the one peers read it from
+func (f *Forwarder) SetTenantHeader(header string) {
+	f.tenantHeader = header
+}
+
+// parseURLs parses the base URLs of peers
+func parseURLs(peers []string) ([]*url.URL, error) {
+	urls := make([]*url.URL, 0, len(peers))
+	for _, p := range peers {
+		u, err := parseURL(p)
+		if err != nil {
+			return nil, fmt.Errorf("peer %q: %w", p, err)
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+func parseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("expected an http or https URL")
+	}
+	return u, nil
+}
+
+// Forward submits the job to the first peer that accepts it and returns the
+// job as that peer created it, or the job an unless_exists or coalesced
+// submission there stood in for, which existing reports. Peers that turn it
+// away, whether they can't run it either or can't be reached, are passed
+// over.
+func (f *Forwarder) Forward(ctx context.Context, job *model.Job, unlessExists *model.ExistingJobMatch, coalesce *model.CoalesceOptions) (*model.Job, bool, error) {
+	req := createRequest(job)
+	req.UnlessExists, req.Coalesce = unlessExists, coalesce
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, false, err
+	}
+	var problems []string
+	for _, peer := range f.peers {
+		created, existing, err := f.submit(ctx, peer, job.Tenant, body)
+		if err == nil {
+			return created, existing, nil
+		}
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		problems = append(problems, fmt.Sprintf("%s: %v", peer.Host, err))
+	}
+	if len(problems) == 0 {
+		return nil, false, ErrNoPeer
+	}
+	return nil, false, fmt.Errorf("%w: %s", ErrNoPeer, strings.Join(problems, "; "))
+}
+
+func (f *Forwarder) submit(ctx context.Context, peer *url.URL, tenant string, body []byte) (*model.Job, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, peer.JoinPath("v1", "jobs").String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(model.ForwardedByHeader, f.node)
+	req.Header.Set(model.ClusterTokenHeader, f.token)
+	if tenant != "" {
+		req.Header.Set(f.tenantHeader, tenant)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, false, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var created model.Job
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, false, err
+	}
+	// A submission that stood in for a job already there is answered 200
+	return &created, resp.StatusCode == http.StatusOK, nil
+}
+
+// createRequest rebuilds the submission a job was made from. The peer
+// chooses its UID and fills in its own defaults. The parent is left out, as
+// it lives on this instance where the peer can't find it.
+func createRequest(job *model.Job) *model.CreateJobRequest {
+	payload, _ := json.Marshal(job.Payload)
+	req := &model.CreateJobRequest{
+		Type:             job.Type,
+		Payload:          payload,
+		Labels:           job.Labels,
+		Requires:         job.Requires,
+		Placement:        job.Placement,
+		Weight:           job.Weight,
+		Backoff:          job.Backoff,
+		Timeout:          job.Timeout,
+		MaxRetries:       job.MaxRetries,
+		Priority:         job.Priority,
+		Retention:        job.Retention,
+		SerializationKey: job.SerializationKey,
+	}
+	if job.Deadline != nil {
+		req.Deadline = job.Deadline.Format(time.RFC3339Nano)
+	}
+	return req
+}
@@ -0,0 +1,126 @@
+package peers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePeer answers submissions with status, echoing the job it would
+// create as the named node
+func fakePeer(t *testing.T, node string, status int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/jobs", r.URL.Path)
+		assert.Equal(t, "node-a", r.Header.Get(model.ForwardedByHeader))
+		assert.Equal(t, "secret", r.Header.Get(model.ClusterTokenHeader))
+		assert.Equal(t, "acme", r.Header.Get("X-Tenant-ID"))
+		var req model.CreateJobRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if status != http.StatusCreated && status != http.StatusOK {
+			http.Error(w, "job type disabled: sleep jobs are not enabled in this deployment", status)
+			return
+		}
+		payload, err := req.ParsePayload()
+		assert.NoError(t, err)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&model.Job{
+			UID:      uuid.New(),
+			Type:     req.Type,
+			Payload:  payload,
+			Labels:   req.Labels,
+			Priority: req.Priority,
+			Node:     node,
+			Status:   model.JobStatusPending,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestForwarder_Forward(t *testing.T) {
+	disabled := fakePeer(t, "node-b", http.StatusForbidden)
+	accepting := fakePeer(t, "node-c", http.StatusCreated)
+	f, err := NewForwarder("node-a", []string{disabled.URL, accepting.URL})
+	assert.NoError(t, err)
+	f.SetToken("secret")
+
+	priority := 5
+	deadline := time.Now().Add(time.Hour)
+	job := &model.Job{
+		UID:      uuid.New(),
+		Type:     "sleep",
+		Payload:  model.SleepJobPayload{Duration: "1s"},
+		Labels:   map[string]string{"team": "data"},
+		Tenant:   "acme",
+		Priority: &priority,
+		Deadline: &deadline,
+	}
+	created, existing, err := f.Forward(context.Background(), job, nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, existing)
+	assert.Equal(t, "node-c", created.Node)
+	assert.NotEqual(t, job.UID, created.UID)
+	assert.Equal(t, model.SleepJobPayload{Duration: "1s"}, created.Payload)
+	assert.Equal(t, map[string]string{"team": "data"}, created.Labels)
+	assert.Equal(t, &priority, created.Priority)
+}
+
+func TestForwarder_NoPeer(t *testing.T) {
+	disabled := fakePeer(t, "node-b", http.StatusForbidden)
+	f, err := NewForwarder("node-a", []string{disabled.URL})
+	assert.NoError(t, err)
+	f.SetToken("secret")
+
+	job := &model.Job{Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Tenant: "acme"}
+	_, _, err = f.Forward(context.Background(), job, nil, nil)
+	assert.ErrorIs(t, err, ErrNoPeer)
+	assert.ErrorContains(t, err, "403 Forbidden: job type disabled")
+
+	f, err = NewForwarder("node-a", nil)
+	assert.NoError(t, err)
+	_, _, err = f.Forward(context.Background(), job, nil, nil)
+	assert.ErrorIs(t, err, ErrNoPeer)
+}
+
+func TestForwarder_Conditional(t *testing.T) {
+	// The peer answers with a job it already had
+	var got model.CreateJobRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "acme", r.Header.Get("X-Authenticated-Tenant"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&model.Job{UID: uuid.New(), Type: got.Type, Payload: model.SleepJobPayload{Duration: "1s"}, Node: "node-b", Status: model.JobStatusRunning})
+	}))
+	defer srv.Close()
+	f, err := NewForwarder("node-a", []string{srv.URL})
+	assert.NoError(t, err)
+	f.SetTenantHeader("X-Authenticated-Tenant")
+
+	job := &model.Job{Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Tenant: "acme", Labels: map[string]string{"report": "daily"}}
+	match := &model.ExistingJobMatch{Labels: []string{"report"}}
+	created, existing, err := f.Forward(context.Background(), job, match, nil)
+	assert.NoError(t, err)
+	assert.True(t, existing)
+	assert.Equal(t, "node-b", created.Node)
+	assert.Equal(t, match, got.UnlessExists)
+	assert.Nil(t, got.Coalesce)
+
+	got = model.CreateJobRequest{}
+	coalesce := &model.CoalesceOptions{Key: "daily", Window: "10s"}
+	_, _, err = f.Forward(context.Background(), job, nil, coalesce)
+	assert.NoError(t, err)
+	assert.Equal(t, coalesce, got.Coalesce)
+	assert.Nil(t, got.UnlessExists)
+}
+
+func TestNewForwarder(t *testing.T) {
+	_, err := NewForwarder("node-a", []string{"node-b:8080"})
+	assert.EqualError(t, err, `peer "node-b:8080": expected an http or https URL`)
+}
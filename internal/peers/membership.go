@@ -0,0 +1,293 @@
+package peers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/egress"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// DefaultGossipInterval is how often an instance gossips with a peer unless
+// configured otherwise
+const DefaultGossipInterval = 2 * time.Second
+
+const (
+	// deadAfter is how many intervals a member's heartbeat may stand still
+	// before it is taken to have left the cluster
+	deadAfter = 5
+	// forgetAfter is how many intervals a member that left is remembered,
+	// so gossip still carrying its last heartbeat doesn't bring it back
+	forgetAfter = 30
+	// maxMembers bounds how many other instances are remembered, so gossip
+	// can't grow the member list without end
+	maxMembers = 256
+)
+
+// Membership keeps track of the instances in a cluster by gossip. Each
+// round, an instance raises its own heartbeat and swaps what it knows of
+// the cluster with one peer picked at random, so news of every member
+// spreads to all of them within a few rounds, even those that only know a
+// single peer to start with.
+type Membership struct {
+	seeds    []string
+	hosts    []string
+	token    string
+	interval time.Duration
+	load     func(context.Context) model.NodeLoad
+	client   *http.Client
+	now      func() time.Time
+
+	mu      sync.Mutex
+	self    model.Member
+	members map[string]*member
+}
+
+// member is another instance as last heard of
+type member struct {
+	model.Member
+	// seen is when its heartbeat last rose
+	seen time.Time
+}
+
+// NewMembership joins the named node to the cluster its seed peers belong
+// to. advertise is the base URL other instances reach this one at, which
+// may be empty if it only ever gossips out. load reports the node's own
+// load to share with the cluster.
+func NewMembership(node, advertise string, seeds []string, interval time.Duration, load func(context.Context) model.NodeLoad) (*Membership, error) {
+	urls, err := parseURLs(seeds)
+	if err != nil {
+		return nil, err
+	}
+	if advertise != "" {
+		if _, err := parseURL(advertise); err != nil {
+			return nil, fmt.Errorf("advertise URL %q: %w", advertise, err)
+		}
+	}
+	if interval <= 0 {
+		interval = DefaultGossipInterval
+	}
+	m := &Membership{
+		interval: interval,
+		load:     load,
+		client:   &http.Client{Timeout: interval},
+		now:      time.Now,
+		members:  make(map[string]*member),
+	}
+	for _, u := range urls {
+		m.seeds = append(m.seeds, u.String())
+		m.hosts = append(m.hosts, u.Hostname())
+	}
+	// The heartbeat starts from when the instance started, so one that
+	// restarts outranks what the cluster remembers of it
+	m.self = model.Member{Node: node, URL: advertise, Heartbeat: uint64(time.Now().UnixNano())}
+	return m, nil
+}
+
+// SetToken sets the bearer token gossip is sent with, which every member
+// of the cluster must share
+func (m *Membership) SetToken(token string) {
+	m.token = token
+}
+
+// AllowHosts adds host names, or patterns such as *.example.com, that
+// members may be reached at besides those of the seed peers. Members that
+// advertise a URL on any other host are ignored, so gossip can't point
+// this instance at arbitrary addresses.
+func (m *Membership) AllowHosts(hosts ...string) {
+	m.hosts = append(m.hosts, hosts...)
+}
+
+// Run gossips every interval until ctx is done
+func (m *Membership) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	m.Round(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			m.Round(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Round raises this instance's heartbeat and gossips with one peer
+func (m *Membership) Round(ctx context.Context) {
+	load := m.load(ctx)
+	m.mu.Lock()
+	m.self.Heartbeat++
+	m.self.Load = load
+	m.mu.Unlock()
+
+	targets := m.targets()
+	if len(targets) == 0 {
+		return
+	}
+	target := targets[rand.IntN(len(targets))]
+	reply, err := m.exchange(ctx, target, m.view())
+	if err != nil {
+		slog.Debug("Gossip failed", "peer", target, "error", err)
+		return
+	}
+	m.merge(reply)
+}
+
+// Gossip takes in what a peer knows of the cluster and answers with what
+// this instance knows
+func (m *Membership) Gossip(ctx context.Context, gossip *model.Gossip) *model.Gossip {
+	m.merge(gossip)
+	return m.view()
+}
+
+// Members describes every live instance in the cluster, this one included,
+// ordered by name
+func (m *Membership) Members(ctx context.Context) []model.NodeStats {
+	load := m.load(ctx)
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	nodes := []model.NodeStats{nodeStats(m.self.Node, m.self.URL, load, now)}
+	for _, mem := range m.members {
+		if m.alive(mem, now) {
+			nodes = append(nodes, nodeStats(mem.Node, mem.URL, mem.Load, mem.seen))
+		}
+	}
+	slices.SortFunc(nodes, func(a, b model.NodeStats) int { return strings.Compare(a.Node, b.Node) })
+	return nodes
+}
+
+func nodeStats(node, url string, load model.NodeLoad, seen time.Time) model.NodeStats {
+	return model.NodeStats{Node: node, URL: url, NodeLoad: load, Utilization: load.Utilization(), Seen: seen}
+}
+
+// alive reports whether mem's heartbeat rose recently. m.mu must be held.
+func (m *Membership) alive(mem *member, now time.Time) bool {
+	return now.Sub(mem.seen) < deadAfter*m.interval
+}
+
+// view is what this instance passes on: itself and the members it
+// believes are alive
+func (m *Membership) view() *model.Gossip {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	gossip := &model.Gossip{Members: []model.Member{m.self}}
+	for _, mem := range m.members {
+		if m.alive(mem, now) {
+			gossip.Members = append(gossip.Members, mem.Member)
+		}
+	}
+	return gossip
+}
+
+// merge keeps whichever of what it already knew and what it is told of
+// each member carries the higher heartbeat, and forgets members long gone
+func (m *Membership) merge(gossip *model.Gossip) {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for node, mem := range m.members {
+		if now.Sub(mem.seen) >= forgetAfter*m.interval {
+			slog.Info("Forgot cluster member", "node", node)
+			delete(m.members, node)
+		}
+	}
+	for _, in := range gossip.Members {
+		if in.Node == "" || in.Node == m.self.Node {
+			continue
+		}
+		mem, ok := m.members[in.Node]
+		if ok && in.Heartbeat <= mem.Heartbeat {
+			continue
+		}
+		if !m.reachable(in.URL) {
+			slog.Warn("Ignored cluster member at a host that isn't allowed", "node", in.Node, "url", in.URL)
+			continue
+		}
+		if !ok {
+			if len(m.members) >= maxMembers {
+				slog.Warn("Ignored cluster member, as the cluster is full", "node", in.Node, "max_members", maxMembers)
+				continue
+			}
+			slog.Info("Cluster member joined", "node", in.Node, "url", in.URL)
+		}
+		m.members[in.Node] = &member{Member: in, seen: now}
+	}
+}
+
+// reachable reports whether gossip may be sent to a member's URL: none at
+// all, or an http or https URL on a seed's host or an allowed one
+func (m *Membership) reachable(rawURL string) bool {
+	if rawURL == "" {
+		return true
+	}
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return false
+	}
+	return len(m.hosts) > 0 && egress.Allowed(m.hosts, u.Hostname())
+}
+
+// targets are the base URLs gossip may go to: the seeds, and the live
+// members that said where they can be reached
+func (m *Membership) targets() []string {
+	now := m.now()
+	targets := slices.Clone(m.seeds)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mem := range m.members {
+		if mem.URL != "" && m.alive(mem, now) && !slices.Contains(targets, mem.URL) {
+			targets = append(targets, mem.URL)
+		}
+	}
+	return targets
+}
+
+func (m *Membership) exchange(ctx context.Context, target string, gossip *model.Gossip) (*model.Gossip, error) {
+	body, err := json.Marshal(gossip)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.JoinPath(target, "v1", "cluster", "gossip")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.token)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var reply model.Gossip
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
@@ -0,0 +1,142 @@
+package peers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMember starts an instance that gossips as the named node with the
+// given queue depth, reachable at the URL it advertises
+func fakeMember(t *testing.T, node string, depth int, seeds ...string) *Membership {
+	var m *Membership
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/cluster/gossip", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var gossip model.Gossip
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&gossip))
+		json.NewEncoder(w).Encode(m.Gossip(r.Context(), &gossip))
+	}))
+	t.Cleanup(srv.Close)
+
+	load := func(context.Context) model.NodeLoad {
+		return model.NodeLoad{Workers: 2, Slots: 2, SlotsInUse: 1, QueueDepth: depth, QueueCapacity: 10}
+	}
+	m, err := NewMembership(node, srv.URL, seeds, time.Second, load)
+	require.NoError(t, err)
+	m.SetToken("secret")
+	m.AllowHosts("127.0.0.1")
+	return m
+}
+
+func names(nodes []model.NodeStats) []string {
+	var names []string
+	for _, n := range nodes {
+		names = append(names, n.Node)
+	}
+	return names
+}
+
+func TestMembership_Gossip(t *testing.T) {
+	ctx := context.Background()
+	b := fakeMember(t, "node-b", 2)
+	a := fakeMember(t, "node-a", 1, b.self.URL)
+	c := fakeMember(t, "node-c", 3, b.self.URL)
+	// B knows no peers, so its rounds only update its own load
+	b.Round(ctx)
+
+	// B learns of A when A gossips with it, and C learns of both from B
+	a.Round(ctx)
+	assert.Equal(t, []string{"node-a", "node-b"}, names(b.Members(ctx)))
+	c.Round(ctx)
+	assert.Equal(t, []string{"node-a", "node-b", "node-c"}, names(c.Members(ctx)))
+
+	// A never gossips with C directly, but hears of it through B
+	assert.Equal(t, []string{"node-a", "node-b"}, names(a.Members(ctx)))
+	a.Round(ctx)
+	nodes := a.Members(ctx)
+	assert.Equal(t, []string{"node-a", "node-b", "node-c"}, names(nodes))
+	assert.Equal(t, 3, nodes[2].QueueDepth)
+	assert.Equal(t, c.self.URL, nodes[2].URL)
+	assert.Equal(t, 0.5, nodes[2].Utilization)
+
+	stats := model.NewClusterStats(nodes)
+	assert.Equal(t, 6, stats.QueueDepth)
+	assert.Equal(t, 30, stats.QueueCapacity)
+	assert.Equal(t, 6, stats.Slots)
+	assert.Equal(t, 3, stats.SlotsInUse)
+	assert.Equal(t, 0.5, stats.Utilization)
+}
+
+func TestMembership_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, err := NewMembership("node-a", "", nil, time.Second, func(context.Context) model.NodeLoad { return model.NodeLoad{} })
+	require.NoError(t, err)
+	m.now = func() time.Time { return now }
+
+	b := model.Member{Node: "node-b", Heartbeat: 10}
+	m.Gossip(ctx, &model.Gossip{Members: []model.Member{b}})
+	assert.Equal(t, []string{"node-a", "node-b"}, names(m.Members(ctx)))
+
+	// A heartbeat that doesn't rise doesn't count as hearing from it
+	now = now.Add(deadAfter * time.Second)
+	reply := m.Gossip(ctx, &model.Gossip{Members: []model.Member{b}})
+	assert.Equal(t, []string{"node-a"}, names(m.Members(ctx)))
+	assert.Len(t, reply.Members, 1, "members that left aren't passed on")
+
+	// and is forgotten a while later
+	now = now.Add(forgetAfter * time.Second)
+	m.Gossip(ctx, &model.Gossip{})
+	assert.Empty(t, m.members)
+
+	// It rejoins once heard from again
+	b.Heartbeat++
+	m.Gossip(ctx, &model.Gossip{Members: []model.Member{b}})
+	assert.Equal(t, []string{"node-a", "node-b"}, names(m.Members(ctx)))
+}
+
+func TestMembership_AllowedHosts(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewMembership("node-a", "", []string{"http://node-b:8080"}, time.Second, func(context.Context) model.NodeLoad { return model.NodeLoad{} })
+	require.NoError(t, err)
+	m.AllowHosts("*.cluster.internal")
+
+	m.Gossip(ctx, &model.Gossip{Members: []model.Member{
+		{Node: "node-b", URL: "http://node-b:9090", Heartbeat: 1},
+		{Node: "node-c", URL: "http://node-c.cluster.internal:8080", Heartbeat: 1},
+		{Node: "node-d", Heartbeat: 1},
+		{Node: "metadata", URL: "http://169.254.169.254/latest", Heartbeat: 1},
+		{Node: "bad", URL: "file:///etc/passwd", Heartbeat: 1},
+	}})
+	assert.Equal(t, []string{"node-a", "node-b", "node-c", "node-d"}, names(m.Members(ctx)))
+	assert.ElementsMatch(t, []string{"http://node-b:8080", "http://node-b:9090", "http://node-c.cluster.internal:8080"}, m.targets())
+}
+
+func TestMembership_MaxMembers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, err := NewMembership("node-a", "", nil, time.Second, func(context.Context) model.NodeLoad { return model.NodeLoad{} })
+	require.NoError(t, err)
+	m.now = func() time.Time { return now }
+
+	var gossip model.Gossip
+	for i := range maxMembers + 10 {
+		gossip.Members = append(gossip.Members, model.Member{Node: fmt.Sprintf("node-%d", i), Heartbeat: 1})
+	}
+	m.Gossip(ctx, &gossip)
+	assert.Len(t, m.members, maxMembers)
+
+	// Room is made once members that left are forgotten
+	now = now.Add(forgetAfter * time.Second)
+	m.Gossip(ctx, &model.Gossip{Members: []model.Member{{Node: "node-new", Heartbeat: 1}}})
+	assert.Equal(t, []string{"node-a", "node-new"}, names(m.Members(ctx)))
+}
@@ -0,0 +1,205 @@
+// Package policy decides whether jobs may be submitted by asking an Open
+// Policy Agent server, so rules shared across an organisation are kept in
+// one place rather than in each service.
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// ErrUnavailable is returned when no decision could be had from OPA
+var ErrUnavailable = errors.New("policy unavailable")
+
+// OPAConfig locates the decision a submission is checked against
+type OPAConfig struct {
+	// URL is the decision's address in OPA's Data API, such as
+	// http://localhost:8181/v1/data/wps/submit
+	URL string
+	// Token is sent as a bearer token when set
+	Token string
+	// FailOpen accepts submissions when OPA can't be reached or its answer
+	// can't be understood, rather than rejecting them
+	FailOpen bool
+}
+
+// Input is what a policy is evaluated against, as input in Rego
+type Input struct {
+	Type     string            `json:"type"`
+	Payload  model.JobPayload  `json:"payload"`
+	Tenant   string            `json:"tenant,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Priority *int              `json:"priority,omitempty"`
+	Requires []string          `json:"requires,omitempty"`
+	// Placement is the worker labels the job asks to run on
+	Placement map[string]string `json:"placement,omitempty"`
+	// Time is when the job was submitted, in UTC
+	Time time.Time `json:"time"`
+}
+
+// Decision is a policy's answer. A policy may answer with a bare boolean,
+// which is taken as Allow.
+type Decision struct {
+	Allow bool `json:"allow"`
+	// Reason explains a denial to the submitter
+	Reason string `json:"reason,omitempty"`
+	// Priority replaces the job's priority when set
+	Priority *int `json:"priority,omitempty"`
+	// Labels are added to the job's, replacing any with the same keys
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func (d *Decision) UnmarshalJSON(data []byte) error {
+	var allow bool
+	if err := json.Unmarshal(data, &allow); err == nil {
+		*d = Decision{Allow: allow}
+		return nil
+	}
+	type decision Decision
+	return json.Unmarshal(data, (*decision)(d))
+}
+
+// validate checks the changes a decision makes to a job
+func (d *Decision) validate() error {
+	var problems model.ValidationError
+	if d.Priority != nil {
+		problems.AddErr("priority", model.ValidatePriority(*d.Priority))
+	}
+	problems.AddErr("labels", model.ValidateLabels(d.Labels))
+	return problems.Err()
+}
+
+// OPA checks submissions against a decision of an OPA server
+type OPA struct {
+	cfg    OPAConfig
+	url    *url.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewOPA(cfg OPAConfig) (*OPA, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("opa decision URL is required")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("opa decision URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("opa decision URL: unsupported scheme %q", u.Scheme)
+	}
+	return &OPA{
+		cfg:    cfg,
+		url:    u,
+		client: &http.Client{Timeout: 5 * time.Second},
+		now:    time.Now,
+	}, nil
+}
+
+// Evaluate asks OPA for its decision on input. A decision OPA has no
+// answer for, such as when no rule matches, denies the job.
+func (o *OPA) Evaluate(ctx context.Context, input Input) (*Decision, error) {
+	data, err := json.Marshal(map[string]any{"input": input})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+o.cfg.Token)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%w: opa: %s: %s", ErrUnavailable, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var body struct {
+		Result *Decision `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: opa: %w", ErrUnavailable, err)
+	}
+	if body.Result == nil {
+		return &Decision{Reason: "no policy decision"}, nil
+	}
+	if err := body.Result.validate(); err != nil {
+		return nil, fmt.Errorf("%w: opa decision: %w", ErrUnavailable, err)
+	}
+	return body.Result, nil
+}
+
+// BeforeSubmit is a pool.Hooks BeforeSubmit hook that rejects jobs the
+// policy denies and applies the priority and labels it chooses to the rest
+func (o *OPA) BeforeSubmit(ctx context.Context, job *model.Job) error {
+	d, err := o.Evaluate(ctx, Input{
+		Type:      job.Type,
+		Payload:   job.Payload,
+		Tenant:    job.Tenant,
+		Labels:    job.Labels,
+		Priority:  job.Priority,
+		Requires:  job.Requires,
+		Placement: job.Placement,
+		Time:      o.now().UTC(),
+	})
+	if err != nil {
+		if o.cfg.FailOpen {
+			return nil
+		}
+		return err
+	}
+	if !d.Allow {
+		if d.Reason == "" {
+			return errors.New("denied by policy")
+		}
+		return errors.New(d.Reason)
+	}
+	if d.Priority != nil {
+		priority := *d.Priority
+		job.Priority = &priority
+	}
+	if len(d.Labels) > 0 {
+		labels := make(map[string]string, len(job.Labels)+len(d.Labels))
+		maps.Copy(labels, job.Labels)
+		maps.Copy(labels, d.Labels)
+		job.Labels = labels
+	}
+	return nil
+}
+
+// Check confirms the OPA server is up and ready to make decisions
+func (o *OPA) Check(ctx context.Context) error {
+	health := *o.url
+	health.Path, health.RawQuery = "/health", ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, health.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("opa: health check: %s", resp.Status)
+	}
+	return nil
+}
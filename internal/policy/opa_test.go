@@ -0,0 +1,120 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOPA answers the wps/submit decision with whatever respond returns
+// for the input it was sent
+func fakeOPA(t *testing.T, respond func(input map[string]any) any) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/data/wps/submit":
+			assert.Equal(t, "Bearer opa-token", r.Header.Get("Authorization"))
+			var body struct {
+				Input map[string]any `json:"input"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			json.NewEncoder(w).Encode(respond(body.Input))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestOPA(t *testing.T, url string, failOpen bool) *OPA {
+	opa, err := NewOPA(OPAConfig{URL: url, Token: "opa-token", FailOpen: failOpen})
+	assert.NoError(t, err)
+	opa.now = func() time.Time { return time.Date(2026, 10, 17, 22, 0, 0, 0, time.UTC) }
+	return opa
+}
+
+func TestOPA_BeforeSubmit(t *testing.T) {
+	// No sleep jobs outside business hours, and the payments tenant's math
+	// jobs jump the queue
+	srv := fakeOPA(t, func(input map[string]any) any {
+		switch {
+		case input["type"] == "sleep" && input["time"] == "2026-10-17T22:00:00Z":
+			return map[string]any{"result": map[string]any{"allow": false, "reason": "sleep jobs only run in business hours"}}
+		case input["tenant"] == "payments":
+			assert.Equal(t, map[string]any{"number": float64(7)}, input["payload"])
+			return map[string]any{"result": map[string]any{"allow": true, "priority": 50, "labels": map[string]string{"reviewed": "opa"}}}
+		case input["tenant"] == "undecided":
+			return map[string]any{}
+		default:
+			return map[string]any{"result": true}
+		}
+	})
+	opa := newTestOPA(t, srv.URL+"/v1/data/wps/submit", false)
+	ctx := context.Background()
+
+	job := &model.Job{Type: "math", Tenant: "payments", Payload: model.MathJobPayload{Number: 7}, Labels: map[string]string{"team": "billing"}}
+	assert.NoError(t, opa.BeforeSubmit(ctx, job))
+	if assert.NotNil(t, job.Priority) {
+		assert.Equal(t, 50, *job.Priority)
+	}
+	assert.Equal(t, map[string]string{"team": "billing", "reviewed": "opa"}, job.Labels)
+
+	job = &model.Job{Type: "math", Tenant: "research", Payload: model.MathJobPayload{Number: 7}}
+	assert.NoError(t, opa.BeforeSubmit(ctx, job))
+	assert.Nil(t, job.Priority)
+	assert.Nil(t, job.Labels)
+
+	job = &model.Job{Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}}
+	assert.EqualError(t, opa.BeforeSubmit(ctx, job), "sleep jobs only run in business hours")
+
+	// A decision without a result is a denial
+	job = &model.Job{Type: "math", Tenant: "undecided", Payload: model.MathJobPayload{Number: 7}}
+	assert.EqualError(t, opa.BeforeSubmit(ctx, job), "no policy decision")
+}
+
+func TestOPA_Unavailable(t *testing.T) {
+	srv := fakeOPA(t, func(map[string]any) any {
+		return map[string]any{"result": map[string]any{"allow": true, "priority": 1000}}
+	})
+	ctx := context.Background()
+	job := &model.Job{Type: "math", Payload: model.MathJobPayload{Number: 7}}
+
+	// Changes the pool wouldn't accept from a submitter aren't accepted
+	// from a policy either
+	opa := newTestOPA(t, srv.URL+"/v1/data/wps/submit", false)
+	err := opa.BeforeSubmit(ctx, job)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorContains(t, err, "priority: must be between -100 and 100")
+
+	opa = newTestOPA(t, srv.URL+"/v1/data/wps/missing", false)
+	assert.ErrorIs(t, opa.BeforeSubmit(ctx, job), ErrUnavailable)
+
+	// Failing open accepts the job unchanged
+	opa = newTestOPA(t, srv.URL+"/v1/data/wps/missing", true)
+	assert.NoError(t, opa.BeforeSubmit(ctx, job))
+	assert.Nil(t, job.Priority)
+}
+
+func TestOPA_Check(t *testing.T) {
+	srv := fakeOPA(t, nil)
+	opa := newTestOPA(t, srv.URL+"/v1/data/wps/submit?pretty=true", false)
+	assert.NoError(t, opa.Check(context.Background()))
+
+	srv.Close()
+	assert.Error(t, opa.Check(context.Background()))
+}
+
+func TestNewOPA(t *testing.T) {
+	_, err := NewOPA(OPAConfig{})
+	assert.EqualError(t, err, "opa decision URL is required")
+	_, err = NewOPA(OPAConfig{URL: "ftp://opa/v1/data/wps"})
+	assert.EqualError(t, err, `opa decision URL: unsupported scheme "ftp"`)
+}
@@ -0,0 +1,169 @@
+package pool
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+var ErrAdaptiveConcurrency = errors.New("the job type's concurrency limit adjusts itself")
+
+// adaptiveController moves the concurrency limits of job types configured
+// with adaptive concurrency, additively up while their runs go well and
+// multiplicatively down when too many fail or run slow, so a struggling
+// dependency gets fewer jobs at once without a static cap
+type adaptiveController struct {
+	mu    sync.Mutex
+	types map[string]*adaptiveLimit
+}
+
+// adaptiveLimit is one type's limit and the round of runs it is being
+// judged on
+type adaptiveLimit struct {
+	cfg            model.AdaptiveConcurrency
+	target         time.Duration
+	maxFailureRate float64
+	limit          int
+
+	// runs counts the round's runs so far, and bad those that failed or
+	// ran slow. saturated is set once a run ends with the limit reached.
+	runs, bad int
+	saturated bool
+
+	increases, decreases int64
+}
+
+func newAdaptiveController() *adaptiveController {
+	return &adaptiveController{types: make(map[string]*adaptiveLimit)}
+}
+
+// configure has the type's limit adapt within cfg, starting at its
+// minimum, and returns that starting limit
+func (c *adaptiveController) configure(jobType string, cfg model.AdaptiveConcurrency) int {
+	if cfg.Min == 0 {
+		cfg.Min = 1
+	}
+	l := &adaptiveLimit{cfg: cfg, maxFailureRate: model.DefaultMaxFailureRate, limit: cfg.Min}
+	if cfg.TargetLatency != "" {
+		l.target, _ = time.ParseDuration(cfg.TargetLatency)
+	}
+	if cfg.MaxFailureRate != nil {
+		l.maxFailureRate = *cfg.MaxFailureRate
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.types[jobType] = l
+	return l.limit
+}
+
+// adapts reports whether the type's limit adjusts itself
+func (c *adaptiveController) adapts(jobType string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.types[jobType]
+	return ok
+}
+
+func (c *adaptiveController) config(jobType string) *model.AdaptiveConcurrency {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l, ok := c.types[jobType]; ok {
+		cfg := l.cfg
+		return &cfg
+	}
+	return nil
+}
+
+// observe judges a run of the type that just ended, given whether it
+// failed, how long it ran and whether the type was at its limit when it
+// ended, and returns the type's new limit if it moved
+func (c *adaptiveController) observe(jobType string, failed bool, runtime time.Duration, atLimit bool) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.types[jobType]
+	if !ok {
+		return 0, false
+	}
+	l.runs++
+	if failed || l.target > 0 && runtime > l.target {
+		l.bad++
+	}
+	l.saturated = l.saturated || atLimit
+
+	// Cut as soon as the round can't end below the failure rate, so an
+	// outage isn't met with a whole round at full concurrency
+	if float64(l.bad) > l.maxFailureRate*float64(l.limit) {
+		from := l.limit
+		l.limit = max(l.cfg.Min, int(math.Ceil(float64(l.limit)/2)))
+		if l.limit == from {
+			l.endRound()
+			return 0, false
+		}
+		l.decreases++
+		slog.Warn("Adaptive concurrency cut", "job_type", jobType, "limit", l.limit, "failed_or_slow", l.bad, "runs", l.runs)
+		l.endRound()
+		return l.limit, true
+	}
+	if l.runs < l.limit {
+		return 0, false
+	}
+	// The limit only grows after a round that reached it, so a quiet type
+	// doesn't drift up to its maximum untested
+	grow := l.saturated && l.limit < l.cfg.Max
+	l.endRound()
+	if !grow {
+		return 0, false
+	}
+	l.limit++
+	l.increases++
+	slog.Info("Adaptive concurrency raised", "job_type", jobType, "limit", l.limit)
+	return l.limit, true
+}
+
+func (l *adaptiveLimit) endRound() {
+	l.runs, l.bad, l.saturated = 0, 0, false
+}
+
+func (c *adaptiveController) stats() map[string]model.AdaptiveConcurrencyStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.types) == 0 {
+		return nil
+	}
+	stats := make(map[string]model.AdaptiveConcurrencyStats, len(c.types))
+	for jobType, l := range c.types {
+		stats[jobType] = model.AdaptiveConcurrencyStats{
+			Limit:     l.limit,
+			Min:       l.cfg.Min,
+			Max:       l.cfg.Max,
+			Increases: l.increases,
+			Decreases: l.decreases,
+		}
+	}
+	return stats
+}
+
+// adapt feeds a run that just ended with err to its type's adaptive limit.
+// Failures marked Permanent say the job was bad rather than that its
+// dependency struggled, so they don't count against the limit.
+func (p *WorkerPool) adapt(job *model.Job, err error) {
+	if len(job.Attempts) == 0 || p.ctx.Err() != nil {
+		return
+	}
+	attempt := job.Attempts[len(job.Attempts)-1]
+	var runtime time.Duration
+	if attempt.EndedAt != nil {
+		runtime = attempt.EndedAt.Sub(attempt.StartedAt)
+	}
+	// The run has already given up its place, so the type was at its
+	// limit if the rest and this one filled it
+	atLimit := p.concurrency.inUse(job.Type)+1 >= p.concurrency.limit(job.Type)
+	if limit, moved := p.adaptive.observe(job.Type, err != nil && !IsPermanent(err), runtime, atLimit); moved {
+		p.concurrency.setLimit(job.Type, limit)
+		p.jobQueue.wake()
+	}
+}
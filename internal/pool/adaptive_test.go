@@ -0,0 +1,110 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveController(t *testing.T) {
+	c := newAdaptiveController()
+	assert.Equal(t, 2, c.configure("http", model.AdaptiveConcurrency{Min: 2, Max: 4, TargetLatency: "1s"}))
+
+	// runs ends a round of n runs at the limit, n of which are bad
+	runs := func(n, bad int) (int, bool) {
+		var limit int
+		var moved bool
+		for i := range n {
+			runtime := 100 * time.Millisecond
+			if i < bad {
+				runtime = 2 * time.Second
+			}
+			if l, ok := c.observe("http", false, runtime, true); ok {
+				limit, moved = l, true
+			}
+		}
+		return limit, moved
+	}
+
+	// Each round that goes well adds one, up to the maximum
+	assert.Equal(t, []any{3, true}, pair(runs(2, 0)))
+	assert.Equal(t, []any{4, true}, pair(runs(3, 0)))
+	assert.Equal(t, []any{0, false}, pair(runs(4, 0)))
+
+	// A round that didn't reach the limit doesn't raise it
+	c.configure("math", model.AdaptiveConcurrency{Max: 10})
+	c.observe("math", false, time.Second, false)
+	assert.Equal(t, 1, c.stats()["math"].Limit)
+
+	// A tenth of four runs is less than one, so a single slow run halves
+	// the limit, which never goes below the minimum
+	assert.Equal(t, []any{2, true}, pair(runs(1, 1)))
+	assert.Equal(t, []any{0, false}, pair(runs(2, 2)))
+	assert.Equal(t, model.AdaptiveConcurrencyStats{Limit: 2, Min: 2, Max: 4, Increases: 2, Decreases: 1}, c.stats()["http"])
+
+	// A higher rate tolerates more, and without a target latency only
+	// failures count
+	rate := 0.5
+	c.configure("sleep", model.AdaptiveConcurrency{Max: 3, MaxFailureRate: &rate})
+	assert.Equal(t, []any{2, true}, pair(c.observe("sleep", false, time.Hour, true)))
+	assert.Equal(t, []any{0, false}, pair(c.observe("sleep", true, 0, true)))
+	assert.Equal(t, []any{1, true}, pair(c.observe("sleep", true, 0, true)))
+
+	assert.Equal(t, []any{0, false}, pair(c.observe("container", true, 0, true)))
+}
+
+func TestWorkerPool_AdaptiveConcurrency(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 10)
+	pool.SetMaxRetries(0)
+	exec := &flakyExecutor{failures: 1}
+	pool.RegisterExecutor("math", exec)
+	err := pool.SetJobTypeDefaults("math", model.JobTypeDefaults{Concurrency: 2, AdaptiveConcurrency: &model.AdaptiveConcurrency{Max: 0}})
+	assert.EqualError(t, err, "concurrency: can't be given with adaptive_concurrency; adaptive_concurrency.max: must be at least 1")
+	assert.NoError(t, pool.SetJobTypeDefaults("math", model.JobTypeDefaults{AdaptiveConcurrency: &model.AdaptiveConcurrency{Max: 3}}))
+
+	mathType := func() model.JobType {
+		for _, jt := range pool.JobTypes(ctx) {
+			if jt.Name == "math" {
+				return jt
+			}
+		}
+		return model.JobType{}
+	}
+	assert.Equal(t, 1, mathType().Concurrency)
+	assert.Equal(t, &model.AdaptiveConcurrency{Min: 1, Max: 3}, mathType().AdaptiveConcurrency)
+
+	// A transient failure at a limit of one leaves it there, and the next
+	// run going well raises it
+	for range 2 {
+		assert.NoError(t, pool.SubmitJob(ctx, weightedJob(1)))
+		assert.True(t, pool.ProcessNext())
+	}
+	assert.Equal(t, 2, mathType().Concurrency)
+
+	// A transient failure cuts it, while a failure marked Permanent is the
+	// job's fault and doesn't
+	exec.failures, exec.permanent = exec.runs.Load()+1, true
+	assert.NoError(t, pool.SubmitJob(ctx, weightedJob(1)))
+	assert.True(t, pool.ProcessNext())
+	assert.Equal(t, 2, mathType().Concurrency)
+	exec.failures, exec.permanent = exec.runs.Load()+1, false
+	assert.NoError(t, pool.SubmitJob(ctx, weightedJob(1)))
+	assert.True(t, pool.ProcessNext())
+	assert.Equal(t, 1, mathType().Concurrency)
+	assert.Equal(t, model.AdaptiveConcurrencyStats{Limit: 1, Min: 1, Max: 3, Increases: 1, Decreases: 1}, pool.Stats(ctx).AdaptiveConcurrency["math"])
+
+	// The limit can't be set by hand, but the timeout still can
+	_, err = pool.ConfigureJobType(ctx, "math", model.JobTypeConfig{Concurrency: 5})
+	assert.ErrorIs(t, err, ErrAdaptiveConcurrency)
+	jt, err := pool.ConfigureJobType(ctx, "math", model.JobTypeConfig{Timeout: "1m"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, jt.Concurrency)
+}
+
+func pair(limit int, moved bool) []any {
+	return []any{limit, moved}
+}
@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+type annotatorKey struct{}
+
+// annotator attaches annotations to the job an executor is running
+type annotator struct {
+	pool    *WorkerPool
+	jobID   string
+	jobType string
+}
+
+// Annotate attaches value, encoded as JSON, to the job whose execution ctx
+// belongs to. Executors use it to record details such as external IDs
+// without waiting for the result. Outside a job, for example when an
+// executor is exercised on its own, it does nothing.
+func Annotate(ctx context.Context, key string, value any) error {
+	a, ok := ctx.Value(annotatorKey{}).(*annotator)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("annotation %q: %w", key, err)
+	}
+	return a.pool.annotate(a.jobID, map[string]json.RawMessage{key: data})
+}
+
+// AnnotateLeasedJob adds annotations reported by the remote worker holding
+// the job's lease
+func (p *WorkerPool) AnnotateLeasedJob(ctx context.Context, workerID string, jobID string, annotations map[string]json.RawMessage) error {
+	if !p.leases.heldBy(jobID, workerID) {
+		return ErrNotLeased
+	}
+	return p.annotate(jobID, annotations)
+}
+
+// AnnotateJob attaches annotations to a job whatever its status, for
+// components outside the pool that act on jobs, such as result releases
+func (p *WorkerPool) AnnotateJob(ctx context.Context, jobID string, annotations map[string]json.RawMessage) error {
+	return p.annotate(jobID, annotations)
+}
+
+func (p *WorkerPool) annotate(jobID string, annotations map[string]json.RawMessage) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+	_, err := p.store.Update(jobID, 0, func(j *model.Job) error {
+		merged, err := model.MergeAnnotations(j.Annotations, annotations)
+		if err != nil {
+			return err
+		}
+		j.Annotations = merged
+		return nil
+	})
+	return err
+}
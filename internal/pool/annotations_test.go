@@ -0,0 +1,69 @@
+package pool_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// annotatingExecutor annotates each job before returning a fixed result
+type annotatingExecutor struct{}
+
+func (annotatingExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	if err := pool.Annotate(ctx, "external_id", "ext-1"); err != nil {
+		return nil, err
+	}
+	if err := pool.Annotate(ctx, "bytes", 1024); err != nil {
+		return nil, err
+	}
+	return model.MathJobResult{Result: 6}, nil
+}
+
+func TestWorkerPool_Annotate(t *testing.T) {
+	h := pooltest.New(t, pooltest.WithExecutor("math", annotatingExecutor{}))
+	job := h.Submit(&model.Job{Type: "math", Payload: model.MathJobPayload{Number: 4}})
+
+	assert.True(t, h.RunNext())
+	assert.Equal(t, map[string]json.RawMessage{
+		"external_id": json.RawMessage(`"ext-1"`),
+		"bytes":       json.RawMessage(`1024`),
+	}, h.Job(job.UID).Annotations)
+
+	// Without a running job there is nothing to annotate
+	assert.NoError(t, pool.Annotate(context.Background(), "ignored", true))
+}
+
+func TestWorkerPool_AnnotateLeasedJob(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 0, 5)
+	p.Start()
+	defer p.Stop()
+
+	job := &model.Job{
+		UID:     uuid.New(),
+		Type:    "math",
+		Payload: model.MathJobPayload{Number: 4},
+		Status:  model.JobStatusPending,
+	}
+	assert.NoError(t, p.SubmitJob(ctx, job))
+	id := job.UID.String()
+
+	annotations := map[string]json.RawMessage{"progress": json.RawMessage(`0.5`)}
+	assert.ErrorIs(t, p.AnnotateLeasedJob(ctx, "remote-1", id, annotations), pool.ErrNotLeased)
+
+	_, ok := p.LeaseJob(ctx, "remote-1", nil, nil, 0)
+	assert.True(t, ok)
+	assert.NoError(t, p.AnnotateLeasedJob(ctx, "remote-1", id, annotations))
+	assert.ErrorIs(t, p.AnnotateLeasedJob(ctx, "remote-2", id, annotations), pool.ErrNotLeased)
+	assert.Error(t, p.AnnotateLeasedJob(ctx, "remote-1", id, map[string]json.RawMessage{"": json.RawMessage(`1`)}))
+
+	leased, _ := p.GetJob(ctx, id)
+	assert.Equal(t, annotations, leased.Annotations)
+}
@@ -0,0 +1,237 @@
+package pool
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrArtifactNotFound = errors.New("artifact not found")
+	// ErrNoJob is returned by helpers for executors called outside a job
+	ErrNoJob = errors.New("not running a job")
+)
+
+// artifactSweepInterval is how often expired artifacts are looked for
+const artifactSweepInterval = time.Minute
+
+// artifactTable holds the metadata of stored artifacts; their contents live
+// in the blob store
+type artifactTable struct {
+	mu        sync.Mutex
+	artifacts map[string]*model.Artifact
+	// seqs numbers the artifacts in the order they were created, so those
+	// created at the same instant are still listed in order
+	seqs    map[string]uint64
+	nextSeq uint64
+	ttl     time.Duration
+	// urlExpiry is how long pre-signed download URLs stay valid, zero to
+	// serve artifacts through the service instead
+	urlExpiry time.Duration
+}
+
+func newArtifactTable() *artifactTable {
+	return &artifactTable{artifacts: make(map[string]*model.Artifact), seqs: make(map[string]uint64)}
+}
+
+// add records an artifact as the latest created. t.mu must be held.
+func (t *artifactTable) add(artifact *model.Artifact) {
+	t.nextSeq++
+	t.artifacts[artifact.ID] = artifact
+	t.seqs[artifact.ID] = t.nextSeq
+}
+
+// remove forgets an artifact. t.mu must be held.
+func (t *artifactTable) remove(id string) {
+	delete(t.artifacts, id)
+	delete(t.seqs, id)
+}
+
+func artifactBlobName(id string) string {
+	return id + ".artifact"
+}
+
+// SetArtifactTTL sets how long artifacts are kept before they are deleted.
+// Zero keeps them until they are deleted through the API. It must be called
+// before Start.
+func (p *WorkerPool) SetArtifactTTL(d time.Duration) {
+	p.artifacts.ttl = d
+}
+
+// SetArtifactURLExpiry makes artifacts in a blob store that supports it,
+// such as S3, downloadable from pre-signed URLs valid for d. Zero serves
+// every artifact through the service. It must be called before Start.
+func (p *WorkerPool) SetArtifactURLExpiry(d time.Duration) {
+	p.artifacts.urlExpiry = d
+}
+
+// ArtifactURL returns a pre-signed URL the artifact can be downloaded from
+// directly, or "" if it must be served through the service
+func (p *WorkerPool) ArtifactURL(ctx context.Context, id string) (string, error) {
+	artifact, ok := p.GetArtifact(ctx, id)
+	if !ok {
+		return "", ErrArtifactNotFound
+	}
+	presigner, ok := p.blobs.(blob.Presigner)
+	if !ok || p.artifacts.urlExpiry <= 0 {
+		return "", nil
+	}
+	return presigner.PresignGet(ctx, artifactBlobName(id), artifact.Name, p.artifacts.urlExpiry)
+}
+
+// CreateArtifact stores a file produced by a job and lists it in the job's
+// artifacts. Files larger than model.MaxArtifactSize are rejected.
+func (p *WorkerPool) CreateArtifact(ctx context.Context, jobID, name, contentType string, r io.Reader) (*model.Artifact, error) {
+	if p.blobs == nil {
+		return nil, ErrNoBlobStore
+	}
+	job, exists := p.store.Get(jobID)
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	id := uuid.NewString()
+	n, err := p.blobs.Put(ctx, artifactBlobName(id), io.LimitReader(r, model.MaxArtifactSize+1))
+	if err == nil && n > model.MaxArtifactSize {
+		err = fmt.Errorf("%w: the limit is %d bytes", model.ErrArtifactTooLarge, model.MaxArtifactSize)
+	}
+	if err != nil {
+		p.blobs.Delete(context.WithoutCancel(ctx), artifactBlobName(id))
+		return nil, err
+	}
+
+	now := p.clock.Now()
+	artifact := &model.Artifact{
+		ID:          id,
+		JobUID:      job.UID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        n,
+		CreatedAt:   now,
+	}
+	p.artifacts.mu.Lock()
+	if p.artifacts.ttl > 0 {
+		expires := now.Add(p.artifacts.ttl)
+		artifact.ExpiresAt = &expires
+	}
+	p.artifacts.add(artifact)
+	p.artifacts.mu.Unlock()
+
+	p.store.Update(jobID, 0, func(j *model.Job) error {
+		j.Artifacts = append(slices.Clip(j.Artifacts), id)
+		return nil
+	})
+	return artifact, nil
+}
+
+// SaveArtifact stores a file produced by the job whose execution ctx belongs
+// to. Outside a job it fails with ErrNoJob.
+func SaveArtifact(ctx context.Context, name, contentType string, r io.Reader) (*model.Artifact, error) {
+	a, ok := ctx.Value(annotatorKey{}).(*annotator)
+	if !ok {
+		return nil, ErrNoJob
+	}
+	return a.pool.CreateArtifact(ctx, a.jobID, name, contentType, r)
+}
+
+func (p *WorkerPool) GetArtifact(ctx context.Context, id string) (*model.Artifact, bool) {
+	p.artifacts.mu.Lock()
+	defer p.artifacts.mu.Unlock()
+	a, ok := p.artifacts.artifacts[id]
+	return a, ok
+}
+
+// OpenArtifact returns an artifact with its contents
+func (p *WorkerPool) OpenArtifact(ctx context.Context, id string) (*model.Artifact, io.ReadCloser, error) {
+	artifact, ok := p.GetArtifact(ctx, id)
+	if !ok {
+		return nil, nil, ErrArtifactNotFound
+	}
+	r, err := p.blobs.Open(ctx, artifactBlobName(id))
+	if err != nil {
+		return nil, nil, err
+	}
+	return artifact, r, nil
+}
+
+// ListArtifacts returns the artifacts of a job, or of every job when jobID is
+// empty, oldest first
+func (p *WorkerPool) ListArtifacts(ctx context.Context, jobID string) []*model.Artifact {
+	p.artifacts.mu.Lock()
+	defer p.artifacts.mu.Unlock()
+	var artifacts []*model.Artifact
+	for _, a := range p.artifacts.artifacts {
+		if jobID == "" || a.JobUID.String() == jobID {
+			artifacts = append(artifacts, a)
+		}
+	}
+	slices.SortFunc(artifacts, func(a, b *model.Artifact) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(p.artifacts.seqs[a.ID], p.artifacts.seqs[b.ID]))
+	})
+	return artifacts
+}
+
+// DeleteArtifact removes an artifact and its contents
+func (p *WorkerPool) DeleteArtifact(ctx context.Context, id string) error {
+	p.artifacts.mu.Lock()
+	artifact, ok := p.artifacts.artifacts[id]
+	p.artifacts.remove(id)
+	p.artifacts.mu.Unlock()
+	if !ok {
+		return ErrArtifactNotFound
+	}
+
+	p.store.Update(artifact.JobUID.String(), 0, func(j *model.Job) error {
+		j.Artifacts = slices.DeleteFunc(slices.Clone(j.Artifacts), func(a string) bool { return a == id })
+		return nil
+	})
+	return p.blobs.Delete(ctx, artifactBlobName(id))
+}
+
+// artifactReaper periodically deletes expired artifacts
+func (p *WorkerPool) artifactReaper() {
+	defer p.wg.Done()
+
+	ticker := p.clock.NewTicker(artifactSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C():
+			p.expireArtifacts(now)
+		case <-p.quit:
+			return
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *WorkerPool) expireArtifacts(now time.Time) {
+	p.artifacts.mu.Lock()
+	var expired []string
+	for id, a := range p.artifacts.artifacts {
+		if a.ExpiresAt != nil && !now.Before(*a.ExpiresAt) {
+			expired = append(expired, id)
+		}
+	}
+	p.artifacts.mu.Unlock()
+
+	for _, id := range expired {
+		if err := p.DeleteArtifact(p.ctx, id); err != nil && !errors.Is(err, ErrArtifactNotFound) {
+			slog.Warn("Failed to delete expired artifact", "artifact_id", id, "error", err)
+			continue
+		}
+		slog.Info("Artifact expired", "artifact_id", id)
+	}
+}
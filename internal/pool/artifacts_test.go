@@ -0,0 +1,97 @@
+package pool
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newArtifactPool(t *testing.T) *WorkerPool {
+	t.Helper()
+	store, err := blob.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	pool := NewWorkerPool(context.Background(), 1, 5)
+	pool.SetBlobStore(store)
+	return pool
+}
+
+// presignStore is a disk store that also hands out download URLs
+type presignStore struct {
+	*blob.DiskStore
+}
+
+func (presignStore) PresignGet(ctx context.Context, name, filename string, expires time.Duration) (string, error) {
+	return "https://blobs.example.com/" + name + "?filename=" + filename + "&expires=" + expires.String(), nil
+}
+
+func TestWorkerPool_ArtifactURL(t *testing.T) {
+	ctx := context.Background()
+	pool := newArtifactPool(t)
+	job := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}, Status: model.JobStatusPending}
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+	artifact, err := pool.CreateArtifact(ctx, job.UID.String(), "report.pdf", "application/pdf", strings.NewReader("%PDF"))
+	assert.NoError(t, err)
+
+	// The disk store can't presign, so downloads go through the service
+	pool.SetArtifactURLExpiry(time.Minute)
+	url, err := pool.ArtifactURL(ctx, artifact.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, url)
+
+	pool.SetBlobStore(presignStore{pool.blobs.(*blob.DiskStore)})
+	url, err = pool.ArtifactURL(ctx, artifact.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://blobs.example.com/"+artifactBlobName(artifact.ID)+"?filename=report.pdf&expires=1m0s", url)
+
+	pool.SetArtifactURLExpiry(0)
+	url, err = pool.ArtifactURL(ctx, artifact.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, url)
+
+	_, err = pool.ArtifactURL(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, ErrArtifactNotFound)
+}
+
+func TestWorkerPool_ArtifactLimits(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 1)
+	job := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}, Status: model.JobStatusPending}
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+	_, err := pool.CreateArtifact(ctx, job.UID.String(), "x", "", strings.NewReader("x"))
+	assert.ErrorIs(t, err, ErrNoBlobStore)
+
+	store, err := blob.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	pool.SetBlobStore(store)
+	defer func(max int64) { model.MaxArtifactSize = max }(model.MaxArtifactSize)
+	model.MaxArtifactSize = 2
+	_, err = pool.CreateArtifact(ctx, job.UID.String(), "x", "", strings.NewReader("abc"))
+	assert.ErrorIs(t, err, model.ErrArtifactTooLarge)
+	assert.Empty(t, pool.ListArtifacts(ctx, ""))
+}
+
+func TestWorkerPool_ExpireArtifacts(t *testing.T) {
+	ctx := context.Background()
+	pool := newArtifactPool(t)
+	pool.SetArtifactTTL(time.Hour)
+	job := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}, Status: model.JobStatusPending}
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+
+	artifact, err := pool.CreateArtifact(ctx, job.UID.String(), "out.bin", "", strings.NewReader("x"))
+	assert.NoError(t, err)
+	assert.Equal(t, artifact.CreatedAt.Add(time.Hour), *artifact.ExpiresAt)
+
+	pool.expireArtifacts(artifact.CreatedAt.Add(59 * time.Minute))
+	assert.Len(t, pool.ListArtifacts(ctx, ""), 1)
+
+	pool.expireArtifacts(artifact.CreatedAt.Add(time.Hour))
+	assert.Empty(t, pool.ListArtifacts(ctx, ""))
+	stored, _ := pool.GetJob(ctx, job.UID.String())
+	assert.Empty(t, stored.Artifacts)
+}
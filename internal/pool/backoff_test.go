@@ -0,0 +1,74 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/backoff"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_RetryBackoff(t *testing.T) {
+	ctx := context.Background()
+	clock := pooltest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := pool.NewWorkerPool(ctx, 0, 5)
+	p.SetClock(clock)
+	p.SetMaxRetries(3)
+	p.SetRetryBackoff("", backoff.Constant(time.Hour))
+	p.SetRetryBackoff("math", backoff.Schedule{time.Second, time.Minute})
+	defer p.Stop()
+
+	queued := func() int { return p.Internals(ctx).QueueDepth }
+	submit := func(jobType string, payload model.JobPayload, strategy string) *model.Job {
+		job := &model.Job{UID: uuid.New(), Type: jobType, Payload: payload, Backoff: strategy, Status: model.JobStatusPending}
+		assert.NoError(t, p.SubmitJob(ctx, job))
+		return job
+	}
+	fail := func(job *model.Job) *model.Job {
+		t.Helper()
+		_, ok := p.LeaseJob(ctx, "remote-1", nil, nil, 0)
+		assert.True(t, ok)
+		assert.NoError(t, p.CompleteLeasedJob(ctx, "remote-1", job.UID.String(), nil, errors.New("unavailable")))
+		failed, _ := p.GetJob(ctx, job.UID.String())
+		assert.Equal(t, model.JobStatusPending, failed.Status)
+		return failed
+	}
+	waitOut := func(d time.Duration) {
+		t.Helper()
+		clock.BlockUntil(1)
+		clock.Advance(d)
+		assert.Eventually(t, func() bool { return queued() == 1 }, time.Second, time.Millisecond)
+	}
+
+	// The type's schedule applies to math jobs
+	math := submit("math", model.MathJobPayload{Number: 1}, "")
+	assert.Equal(t, clock.Now().Add(time.Second), *fail(math).RetryAt)
+	assert.Equal(t, 0, queued())
+	waitOut(time.Second)
+	requeued, _ := p.GetJob(ctx, math.UID.String())
+	assert.Nil(t, requeued.RetryAt)
+
+	assert.Equal(t, clock.Now().Add(time.Minute), *fail(math).RetryAt)
+	waitOut(time.Minute)
+	assert.Equal(t, clock.Now().Add(time.Minute), *fail(math).RetryAt)
+	waitOut(time.Minute)
+	_, ok := p.LeaseJob(ctx, "remote-1", nil, nil, 0)
+	assert.True(t, ok)
+
+	// The job's own strategy wins over the pool default
+	sleep := submit("sleep", model.SleepJobPayload{Duration: "1s"}, "constant:5s")
+	assert.Equal(t, clock.Now().Add(5*time.Second), *fail(sleep).RetryAt)
+	waitOut(5 * time.Second)
+	_, ok = p.LeaseJob(ctx, "remote-1", nil, nil, 0)
+	assert.True(t, ok)
+
+	// Everything else waits out the default
+	container := submit("container", model.ContainerJobPayload{Image: "alpine"}, "")
+	assert.Equal(t, clock.Now().Add(time.Hour), *fail(container).RetryAt)
+}
@@ -0,0 +1,140 @@
+package pool
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+var (
+	ErrNoBinary       = errors.New("payload has no such binary field")
+	ErrUnexpectedPart = errors.New("no binary field is given in this part")
+	ErrMissingPart    = errors.New("a binary field's part was not uploaded")
+)
+
+func binaryBlobName(jobID, field string) string {
+	return jobID + "." + field + ".bin"
+}
+
+// StoreBinary saves the data of the binary payload field that a job about to
+// be submitted streams in the multipart part named part. The data is bounded
+// by model.MaxInputSize, as an input file is.
+func (p *WorkerPool) StoreBinary(ctx context.Context, job *model.Job, part, contentType string, r io.Reader) error {
+	binaries := model.PayloadBinaries(job.Payload)
+	for _, name := range slices.Sorted(maps.Keys(binaries)) {
+		if b := binaries[name]; b.Part == part && !b.Stored() {
+			if b.ContentType == "" {
+				b.ContentType = contentType
+			}
+			return p.storeBinary(ctx, job, name, b, r)
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnexpectedPart, part)
+}
+
+// StoreBinaries saves the data given inline for the binary payload fields of
+// a job about to be submitted, and checks the rest had theirs uploaded in
+// parts
+func (p *WorkerPool) StoreBinaries(ctx context.Context, job *model.Job) error {
+	binaries := model.PayloadBinaries(job.Payload)
+	for _, name := range slices.Sorted(maps.Keys(binaries)) {
+		b := binaries[name]
+		switch {
+		case b.Stored():
+		case b.Base64 != "":
+			data := base64.NewDecoder(base64.StdEncoding, strings.NewReader(b.Base64))
+			if err := p.storeBinary(ctx, job, name, b, data); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %s is given in part %q", ErrMissingPart, name, b.Part)
+		}
+	}
+	return nil
+}
+
+func (p *WorkerPool) storeBinary(ctx context.Context, job *model.Job, name string, b *model.Binary, r io.Reader) error {
+	if p.blobs == nil {
+		return ErrNoBlobStore
+	}
+	blobName := binaryBlobName(job.UID.String(), name)
+	n, err := p.blobs.Put(ctx, blobName, io.LimitReader(r, model.MaxInputSize+1))
+	if err == nil && n > model.MaxInputSize {
+		err = fmt.Errorf("%w: %s is over the limit of %d bytes", model.ErrBinaryTooLarge, name, model.MaxInputSize)
+	}
+	if err != nil {
+		p.blobs.Delete(context.WithoutCancel(ctx), blobName)
+		return err
+	}
+	b.Base64 = ""
+	b.Size = &n
+	return nil
+}
+
+// CopyBinaries stores a copy of the data of from's binary payload fields for
+// job, which is about to be submitted with the same fields
+func (p *WorkerPool) CopyBinaries(ctx context.Context, from, job *model.Job) error {
+	binaries := model.PayloadBinaries(job.Payload)
+	for _, name := range slices.Sorted(maps.Keys(binaries)) {
+		_, r, err := p.OpenBinary(ctx, from.UID.String(), name)
+		if err != nil {
+			return err
+		}
+		blobName := binaryBlobName(job.UID.String(), name)
+		_, err = p.blobs.Put(ctx, blobName, r)
+		r.Close()
+		if err != nil {
+			p.blobs.Delete(context.WithoutCancel(ctx), blobName)
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBinaries removes the data stored for the binary payload fields of a
+// job that was not submitted after all
+func (p *WorkerPool) DeleteBinaries(ctx context.Context, job *model.Job) error {
+	if p.blobs == nil {
+		return nil
+	}
+	var errs []error
+	for name := range model.PayloadBinaries(job.Payload) {
+		errs = append(errs, p.blobs.Delete(ctx, binaryBlobName(job.UID.String(), name)))
+	}
+	return errors.Join(errs...)
+}
+
+// OpenBinary opens the data of a job's binary payload field
+func (p *WorkerPool) OpenBinary(ctx context.Context, jobID, field string) (*model.Binary, io.ReadCloser, error) {
+	job, exists := p.store.Get(jobID)
+	if !exists {
+		return nil, nil, ErrJobNotFound
+	}
+	b := model.PayloadBinaries(job.Payload)[field]
+	if b == nil || !b.Stored() || p.blobs == nil {
+		return nil, nil, ErrNoBinary
+	}
+	r, err := p.blobs.Open(ctx, binaryBlobName(jobID, field))
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, r, nil
+}
+
+// Binary opens the data of a binary payload field of the job whose
+// execution ctx belongs to. Executors for job types with binary fields read
+// them with it.
+func Binary(ctx context.Context, field string) (*model.Binary, io.ReadCloser, error) {
+	a, ok := ctx.Value(annotatorKey{}).(*annotator)
+	if !ok {
+		return nil, nil, ErrNoBinary
+	}
+	return a.pool.OpenBinary(ctx, a.jobID, field)
+}
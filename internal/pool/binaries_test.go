@@ -0,0 +1,95 @@
+package pool
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func binaryJob(b *model.Binary) *model.Job {
+	return &model.Job{UID: uuid.New(), Type: "http", Status: model.JobStatusPending, Payload: model.HTTPJobPayload{URL: "http://example.com", BinaryBody: b}}
+}
+
+func readBinary(t *testing.T, pool *WorkerPool, jobID string) string {
+	t.Helper()
+	_, r, err := pool.OpenBinary(context.Background(), jobID, "binary_body")
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	return string(data)
+}
+
+func TestWorkerPool_Binaries(t *testing.T) {
+	ctx := context.Background()
+	store, err := blob.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	pool := NewWorkerPool(ctx, 0, 5)
+	pool.SetBlobStore(store)
+
+	// Inline data is decoded into the blob store
+	inline := binaryJob(&model.Binary{Base64: base64.StdEncoding.EncodeToString([]byte("\x00\x01\x02"))})
+	assert.NoError(t, pool.StoreBinaries(ctx, inline))
+	b := inline.Payload.(model.HTTPJobPayload).BinaryBody
+	assert.Empty(t, b.Base64)
+	assert.Equal(t, int64(3), *b.Size)
+	assert.NoError(t, pool.SubmitJob(ctx, inline))
+	assert.Equal(t, "\x00\x01\x02", readBinary(t, pool, inline.UID.String()))
+
+	// Data streamed in a part takes the part's content type unless one is given
+	streamed := binaryJob(&model.Binary{Part: "body"})
+	assert.ErrorIs(t, pool.StoreBinaries(ctx, streamed), ErrMissingPart)
+	assert.ErrorIs(t, pool.StoreBinary(ctx, streamed, "other", "", strings.NewReader("x")), ErrUnexpectedPart)
+	assert.NoError(t, pool.StoreBinary(ctx, streamed, "body", "image/png", strings.NewReader("png")))
+	assert.ErrorIs(t, pool.StoreBinary(ctx, streamed, "body", "", strings.NewReader("again")), ErrUnexpectedPart)
+	assert.NoError(t, pool.StoreBinaries(ctx, streamed))
+	assert.Equal(t, "image/png", streamed.Payload.(model.HTTPJobPayload).BinaryBody.ContentType)
+	assert.NoError(t, pool.SubmitJob(ctx, streamed))
+
+	// A replay gets its own copy
+	replay := streamed.Clone()
+	replay.UID = uuid.New()
+	assert.NoError(t, pool.CopyBinaries(ctx, streamed, replay))
+	assert.NoError(t, pool.SubmitJob(ctx, replay))
+	assert.NoError(t, pool.DeleteBinaries(ctx, streamed))
+	assert.Equal(t, "png", readBinary(t, pool, replay.UID.String()))
+
+	plain := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}, Status: model.JobStatusPending}
+	assert.NoError(t, pool.SubmitJob(ctx, plain))
+	_, _, err = pool.OpenBinary(ctx, plain.UID.String(), "binary_body")
+	assert.ErrorIs(t, err, ErrNoBinary)
+	_, _, err = pool.OpenBinary(ctx, uuid.NewString(), "binary_body")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestWorkerPool_StoreBinaryLimits(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 1)
+	job := binaryJob(&model.Binary{Part: "body"})
+	assert.ErrorIs(t, pool.StoreBinary(ctx, job, "body", "", strings.NewReader("x")), ErrNoBlobStore)
+
+	store, err := blob.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	pool.SetBlobStore(store)
+
+	defer func(max int64) { model.MaxInputSize = max }(model.MaxInputSize)
+	model.MaxInputSize = 4
+	err = pool.StoreBinary(ctx, job, "body", "", strings.NewReader("12345"))
+	assert.ErrorIs(t, err, model.ErrBinaryTooLarge)
+	assert.False(t, job.Payload.(model.HTTPJobPayload).BinaryBody.Stored())
+	_, err = store.Open(ctx, binaryBlobName(job.UID.String(), "binary_body"))
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+
+	assert.NoError(t, pool.StoreBinary(ctx, job, "body", "", strings.NewReader("1234")))
+	assert.NoError(t, pool.DeleteBinaries(ctx, job))
+	_, err = store.Open(ctx, binaryBlobName(job.UID.String(), "binary_body"))
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+}
@@ -0,0 +1,93 @@
+package pool
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// gateExecutor holds every job until release is closed, counting how many
+// are running
+type gateExecutor struct {
+	release chan struct{}
+
+	mu      sync.Mutex
+	running int
+}
+
+func (e *gateExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	e.mu.Lock()
+	e.running++
+	e.mu.Unlock()
+
+	<-e.release
+
+	e.mu.Lock()
+	e.running--
+	e.mu.Unlock()
+	return model.MathJobResult{Result: 1}, nil
+}
+
+func weightedJob(weight int) *model.Job {
+	return &model.Job{
+		UID:     uuid.New(),
+		Type:    "math",
+		Payload: model.MathJobPayload{Number: 1},
+		Weight:  weight,
+		Status:  model.JobStatusPending,
+	}
+}
+
+func TestWorkerPool_WorkerCapacity(t *testing.T) {
+	tests := []struct {
+		name        string
+		capacity    int
+		weights     []int
+		wantRunning int
+		wantSlots   int
+	}{
+		{name: "light jobs share a worker", capacity: 3, weights: []int{1, 1, 1}, wantRunning: 3, wantSlots: 3},
+		{name: "heavy job runs alone", capacity: 3, weights: []int{3, 1, 1}, wantRunning: 1, wantSlots: 3},
+		{name: "mixed weights fill the slots", capacity: 3, weights: []int{2, 1, 2}, wantRunning: 2, wantSlots: 3},
+		{name: "default capacity", capacity: DefaultWorkerCapacity, weights: []int{1, 1}, wantRunning: 1, wantSlots: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			exec := &gateExecutor{release: make(chan struct{})}
+			pool := NewWorkerPool(ctx, 1, len(tt.weights))
+			pool.SetWorkerCapacity(tt.capacity)
+			pool.RegisterExecutor("math", exec)
+			pool.Start()
+			defer pool.Stop()
+
+			for _, w := range tt.weights {
+				assert.NoError(t, pool.SubmitJob(ctx, weightedJob(w)))
+			}
+
+			running := func() int {
+				exec.mu.Lock()
+				defer exec.mu.Unlock()
+				return exec.running
+			}
+			assert.Eventually(t, func() bool { return running() == tt.wantRunning }, 2*time.Second, 10*time.Millisecond)
+			// Nothing else starts while the slots are taken
+			time.Sleep(50 * time.Millisecond)
+			assert.Equal(t, tt.wantRunning, running())
+			stats := pool.Stats(ctx)
+			assert.Equal(t, tt.capacity, stats.Slots)
+			assert.Equal(t, tt.wantSlots, stats.SlotsInUse)
+
+			close(exec.release)
+			waitForNJobsWithStatus(t, pool, len(tt.weights), model.JobStatusCompleted)
+			assert.Eventually(t, func() bool { return pool.Stats(ctx).SlotsInUse == 0 }, time.Second, 10*time.Millisecond)
+		})
+	}
+}
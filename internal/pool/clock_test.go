@@ -0,0 +1,42 @@
+package pool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_FakeClock(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := pooltest.NewClock(start)
+
+	p := pool.NewWorkerPool(ctx, 1, 5)
+	p.SetClock(clock)
+	p.Start()
+	defer p.Stop()
+
+	job := &model.Job{
+		UID:     uuid.New(),
+		Type:    "sleep",
+		Payload: model.SleepJobPayload{Duration: "1h"},
+		Status:  model.JobStatusPending,
+	}
+	assert.NoError(t, p.SubmitJob(ctx, job))
+
+	// The lease, deadline and retention reapers' tickers plus the sleeping
+	// job
+	clock.BlockUntil(4)
+	clock.Advance(time.Hour)
+
+	completed := pooltest.WaitForStatus(t, p, job.UID.String(), model.JobStatusCompleted, time.Second)
+	assert.Equal(t, start, *completed.StartedAt)
+	assert.Equal(t, start.Add(time.Hour), *completed.CompletedAt)
+	assert.Equal(t, time.Hour, p.TenantUsage(ctx, ""))
+}
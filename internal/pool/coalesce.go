@@ -0,0 +1,101 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// coalesceTable tracks the jobs held back for more submissions with their
+// coalescing key
+type coalesceTable struct {
+	mu   sync.Mutex
+	held map[string]uuid.UUID
+	keys map[uuid.UUID]string
+}
+
+func newCoalesceTable() *coalesceTable {
+	return &coalesceTable{held: make(map[string]uuid.UUID), keys: make(map[uuid.UUID]string)}
+}
+
+// coalesceKey scopes a submission's key to its tenant and type
+func coalesceKey(job *model.Job, key string) string {
+	return job.Tenant + "\x00" + job.Type + "\x00" + key
+}
+
+// SubmitJobCoalesced folds the job into the one held back for its key, type
+// and tenant, which takes its payload and is returned. If no job is held,
+// this one is submitted and held back for window before it is queued, and
+// nil is returned.
+func (p *WorkerPool) SubmitJobCoalesced(ctx context.Context, job *model.Job, key string, window time.Duration) (*model.Job, error) {
+	c := p.coalescing
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := coalesceKey(job, key)
+	if id, held := c.held[k]; held {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Hooks vet the submission even though it only lends its payload
+		if err := p.beforeSubmit(ctx, job); err != nil {
+			return nil, err
+		}
+		updated, err := p.store.Update(id.String(), 0, func(j *model.Job) error {
+			j.Payload = job.Payload
+			j.Coalesced++
+			return nil
+		})
+		if err == nil {
+			return updated, nil
+		}
+		// The held job is gone, so this submission starts afresh
+		delete(c.held, k)
+		delete(c.keys, id)
+	}
+
+	until := p.clock.Now().Add(window)
+	job.CoalesceUntil = &until
+	job.Coalesced = 1
+	if err := p.submit(ctx, job, true); err != nil {
+		return nil, err
+	}
+	c.held[k] = job.UID
+	c.keys[job.UID] = k
+	return nil, nil
+}
+
+// releaseAt queues a held job into the place reserved for it once at has
+// passed
+func (p *WorkerPool) releaseAt(job *model.Job, at time.Time) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		select {
+		case <-p.clock.After(at.Sub(p.clock.Now())):
+		case <-p.quit:
+			return
+		case <-p.ctx.Done():
+			return
+		}
+
+		// Later submissions with the key start a job of their own
+		c := p.coalescing
+		c.mu.Lock()
+		if k, held := c.keys[job.UID]; held {
+			delete(c.held, k)
+			delete(c.keys, job.UID)
+		}
+		c.mu.Unlock()
+
+		// The store's copy carries the payload of the latest submission
+		stored, exists := p.store.Get(job.UID.String())
+		if !exists || stored.Status != model.JobStatusPending {
+			p.jobQueue.release(job.Lane)
+			return
+		}
+		p.jobQueue.pushReserved(stored)
+	}()
+}
@@ -0,0 +1,70 @@
+package pool
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// payloadExecutor sends each job's payload on ran
+type payloadExecutor struct {
+	ran chan model.JobPayload
+}
+
+func (e *payloadExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	e.ran <- job.Payload
+	return model.MathJobResult{Result: 1}, nil
+}
+
+func TestWorkerPool_SubmitJobCoalesced(t *testing.T) {
+	ctx := context.Background()
+	// Never started, so jobs only run when ProcessNext says
+	pool := NewWorkerPool(ctx, 1, 1)
+	exec := &payloadExecutor{ran: make(chan model.JobPayload, 2)}
+	pool.RegisterExecutor("math", exec)
+	defer pool.Stop()
+	const window = 200 * time.Millisecond
+
+	first := mathJob(1)
+	existing, err := pool.SubmitJobCoalesced(ctx, first, "refresh", window)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.Equal(t, 1, first.Coalesced)
+	assert.NotNil(t, first.CoalesceUntil)
+
+	// Later submissions within the window replace the payload
+	for n := 2; n <= 3; n++ {
+		existing, err = pool.SubmitJobCoalesced(ctx, mathJob(n), "refresh", window)
+		require.NoError(t, err)
+		require.NotNil(t, existing)
+		assert.Equal(t, first.UID, existing.UID)
+		assert.Equal(t, n, existing.Coalesced)
+	}
+	// Another key needs a job of its own, but the held job has kept its
+	// place in the queue, which is now full
+	existing, err = pool.SubmitJobCoalesced(ctx, mathJob(4), "other", window)
+	assert.Nil(t, existing)
+	assert.EqualError(t, err, "job queue is full")
+	assert.Len(t, pool.GetAllJobs(ctx, &model.JobFilter{}), 1)
+
+	// Nothing is queued until the window ends
+	assert.False(t, pool.ProcessNext())
+	assert.Eventually(t, pool.ProcessNext, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, model.MathJobPayload{Number: 3}, <-exec.ran)
+	job, _ := pool.GetJob(ctx, first.UID.String())
+	assert.Equal(t, model.JobStatusCompleted, job.Status)
+	assert.Equal(t, 3, job.Coalesced)
+
+	// Once the job was queued, the key starts a new one
+	next := mathJob(5)
+	existing, err = pool.SubmitJobCoalesced(ctx, next, "refresh", window)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.Eventually(t, pool.ProcessNext, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, model.MathJobPayload{Number: 5}, <-exec.ran)
+}
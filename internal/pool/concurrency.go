@@ -0,0 +1,81 @@
+package pool
+
+import (
+	"sync"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// concurrencyLimiter caps how many jobs of each type run at once, so a type
+// that is slow or leans on a fragile dependency can't take every worker
+type concurrencyLimiter struct {
+	mu     sync.Mutex
+	limits map[string]int
+	// running are the jobs of each limited type taken from the queue that
+	// haven't stopped running yet
+	running map[string]map[uuid.UUID]bool
+}
+
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{limits: make(map[string]int), running: make(map[string]map[uuid.UUID]bool)}
+}
+
+// limit returns the most jobs of the type that may run at once, zero for
+// unlimited
+func (c *concurrencyLimiter) limit(jobType string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limits[jobType]
+}
+
+// inUse returns how many jobs of the type hold a place under its limit
+func (c *concurrencyLimiter) inUse(jobType string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.running[jobType])
+}
+
+func (c *concurrencyLimiter) setLimit(jobType string, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n <= 0 {
+		delete(c.limits, jobType)
+		return
+	}
+	c.limits[jobType] = n
+}
+
+// admits reports whether the job could start without exceeding its type's
+// limit
+func (c *concurrencyLimiter) admits(job *model.Job) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limit, limited := c.limits[job.Type]
+	return !limited || len(c.running[job.Type]) < limit
+}
+
+// start records that a job admitted has been taken from the queue
+func (c *concurrencyLimiter) start(job *model.Job) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, limited := c.limits[job.Type]; !limited {
+		return
+	}
+	if c.running[job.Type] == nil {
+		c.running[job.Type] = make(map[uuid.UUID]bool)
+	}
+	c.running[job.Type][job.UID] = true
+}
+
+// stop frees the job's place under its type's limit, reporting whether it
+// held one
+func (c *concurrencyLimiter) stop(job *model.Job) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running[job.Type][job.UID] {
+		return false
+	}
+	delete(c.running[job.Type], job.UID)
+	return true
+}
@@ -0,0 +1,37 @@
+package pool
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// SubmitJobUnlessExists submits the job unless a pending or running job
+// equivalent to it under match already exists, in which case the oldest
+// such job is returned and nothing is submitted
+func (p *WorkerPool) SubmitJobUnlessExists(ctx context.Context, job *model.Job, match *model.ExistingJobMatch) (*model.Job, error) {
+	p.conditional.Lock()
+	defer p.conditional.Unlock()
+	if existing := p.equivalentJob(job, match); existing != nil {
+		return existing, nil
+	}
+	return nil, p.SubmitJob(ctx, job)
+}
+
+// equivalentJob returns the oldest pending or running job equivalent to job
+// under match, or nil if there is none
+func (p *WorkerPool) equivalentJob(job *model.Job, match *model.ExistingJobMatch) *model.Job {
+	var found []*model.Job
+	for _, status := range []model.JobStatus{model.JobStatusPending, model.JobStatusRunning} {
+		for _, other := range p.store.List(&model.JobFilter{Type: &job.Type, Status: &status}) {
+			if match.Equivalent(job, other) {
+				found = append(found, other)
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return slices.MinFunc(found, model.CompareJobs)
+}
@@ -0,0 +1,81 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_SubmitJobUnlessExists(t *testing.T) {
+	ctx := context.Background()
+	// Never started, so submitted jobs stay pending
+	pool := NewWorkerPool(ctx, 1, 10)
+	refresh := func(customer string) *model.Job {
+		job := mathJob(1)
+		job.Tenant = "acme"
+		job.Labels = map[string]string{"customer": customer, "attempt": uuid.NewString()}
+		return job
+	}
+	match := &model.ExistingJobMatch{Labels: []string{"customer"}}
+
+	first := refresh("x")
+	existing, err := pool.SubmitJobUnlessExists(ctx, first, match)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Labels the match doesn't name may differ
+	existing, err = pool.SubmitJobUnlessExists(ctx, refresh("x"), match)
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, first.UID, existing.UID)
+	}
+
+	// Other customers, tenants and types aren't equivalent
+	existing, err = pool.SubmitJobUnlessExists(ctx, refresh("y"), match)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+	otherTenant := refresh("x")
+	otherTenant.Tenant = "globex"
+	existing, err = pool.SubmitJobUnlessExists(ctx, otherTenant, match)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Once the job has finished, the next is submitted
+	assert.True(t, pool.ProcessNext())
+	existing, err = pool.SubmitJobUnlessExists(ctx, refresh("x"), match)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+}
+
+func TestWorkerPool_SubmitJobUnlessExists_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 100)
+	match := &model.ExistingJobMatch{SerializationKey: true}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	submitted := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job := mathJob(1)
+			job.SerializationKey = "cache:x"
+			existing, err := pool.SubmitJobUnlessExists(ctx, job, match)
+			assert.NoError(t, err)
+			if existing == nil {
+				mu.Lock()
+				submitted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, submitted)
+	assert.Len(t, pool.GetAllJobs(ctx, &model.JobFilter{}), 1)
+}
@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// IndexChecker is implemented by stores that keep secondary indexes, so
+// consistency checks can find entries that drifted from the jobs they point
+// to and rebuild them
+type IndexChecker interface {
+	CheckIndexes(repair bool) []model.Inconsistency
+}
+
+// SetConsistencyCheckInterval checks the pool's bookkeeping every d,
+// repairing what it finds. Zero turns the background check off. It must be
+// called before Start.
+func (p *WorkerPool) SetConsistencyCheckInterval(d time.Duration) {
+	p.checkInterval = d
+}
+
+// CheckConsistency looks for jobs, artifacts, logs and leases that disagree
+// with each other, and for store indexes that drifted from their jobs. With
+// repair set each inconsistency is fixed as it is found: orphans are
+// dropped, dangling references removed and indexes rebuilt.
+func (p *WorkerPool) CheckConsistency(ctx context.Context, repair bool) *model.ConsistencyReport {
+	report := &model.ConsistencyReport{CheckedAt: p.clock.Now()}
+	for _, n := range p.store.CountByStatus() {
+		report.Jobs += n
+	}
+	found := func(kind, id, detail string, repaired bool) {
+		report.Inconsistencies = append(report.Inconsistencies, model.Inconsistency{Kind: kind, ID: id, Detail: detail, Repaired: repaired})
+	}
+
+	if checker, ok := p.store.(IndexChecker); ok {
+		report.Inconsistencies = append(report.Inconsistencies, checker.CheckIndexes(repair)...)
+	}
+
+	// Artifacts are added to the table before the job lists them and
+	// dropped from it before the job stops listing them, so an artifact
+	// whose job exists is never orphaned
+	p.artifacts.mu.Lock()
+	artifacts := make(map[string]*model.Artifact, len(p.artifacts.artifacts))
+	for id, a := range p.artifacts.artifacts {
+		artifacts[id] = a
+	}
+	p.artifacts.mu.Unlock()
+	for id, a := range artifacts {
+		if _, exists := p.store.Get(a.JobUID.String()); exists {
+			continue
+		}
+		repaired := false
+		if repair {
+			p.artifacts.mu.Lock()
+			p.artifacts.remove(id)
+			p.artifacts.mu.Unlock()
+			repaired = p.blobs == nil || p.blobs.Delete(ctx, artifactBlobName(id)) == nil
+		}
+		found(model.InconsistencyOrphanedArtifact, id, "job "+a.JobUID.String()+" does not exist", repaired)
+	}
+	for _, job := range p.store.List(&model.JobFilter{}) {
+		for _, id := range job.Artifacts {
+			if _, ok := p.GetArtifact(ctx, id); ok {
+				continue
+			}
+			repaired := false
+			if repair {
+				_, err := p.store.Update(job.UID.String(), 0, func(j *model.Job) error {
+					j.Artifacts = slices.DeleteFunc(slices.Clone(j.Artifacts), func(a string) bool { return a == id })
+					return nil
+				})
+				repaired = err == nil
+			}
+			found(model.InconsistencyMissingArtifact, job.UID.String(), "artifact "+id+" does not exist", repaired)
+		}
+	}
+
+	p.logs.mu.Lock()
+	for id := range p.logs.logs {
+		if _, exists := p.store.Get(id); exists {
+			continue
+		}
+		if repair {
+			delete(p.logs.logs, id)
+		}
+		found(model.InconsistencyOrphanedLog, id, "job does not exist", repair)
+	}
+	p.logs.mu.Unlock()
+
+	// A lease is taken just before its job is marked running, so only
+	// leases on jobs that are gone or finished are orphans
+	p.leases.mu.Lock()
+	for id, l := range p.leases.leases {
+		job, exists := p.store.Get(id)
+		detail := "job does not exist"
+		if exists {
+			if job.Status == model.JobStatusPending || job.Status == model.JobStatusRunning {
+				continue
+			}
+			detail = "job is " + string(job.Status)
+		}
+		if repair {
+			delete(p.leases.leases, id)
+		}
+		found(model.InconsistencyOrphanedLease, id, detail+", leased by "+l.workerID, repair)
+	}
+	p.leases.mu.Unlock()
+
+	return report
+}
+
+// consistencyChecker periodically checks and repairs the pool's bookkeeping
+func (p *WorkerPool) consistencyChecker() {
+	defer p.wg.Done()
+
+	ticker := p.clock.NewTicker(p.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			report := p.CheckConsistency(p.ctx, true)
+			for _, i := range report.Inconsistencies {
+				slog.Warn("Inconsistency found", "kind", i.Kind, "id", i.ID, "detail", i.Detail, "repaired", i.Repaired)
+			}
+		case <-p.quit:
+			return
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package pool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool_CheckConsistency(t *testing.T) {
+	ctx := context.Background()
+	pool := newArtifactPool(t)
+
+	job := &model.Job{UID: uuid.New(), Type: "math", Status: model.JobStatusCompleted}
+	require.NoError(t, pool.store.Put(job))
+	kept, err := pool.CreateArtifact(ctx, job.UID.String(), "kept.txt", "text/plain", strings.NewReader("kept"))
+	require.NoError(t, err)
+	gone := uuid.New()
+
+	// Break each invariant behind the pool's back
+	orphan := &model.Artifact{ID: uuid.NewString(), JobUID: gone, Name: "orphan.txt"}
+	pool.artifacts.add(orphan)
+	pool.store.Update(job.UID.String(), 0, func(j *model.Job) error {
+		j.Artifacts = append(j.Artifacts, "missing")
+		return nil
+	})
+	pool.logs.open(gone.String())
+	pool.logs.open(job.UID.String())
+	pool.leases.leases[job.UID.String()] = &lease{workerID: "remote-1"}
+	store := pool.store.(*MemoryStore)
+	delete(store.byType["math"], job.UID.String())
+
+	report := pool.CheckConsistency(ctx, false)
+	assert.Equal(t, 1, report.Jobs)
+	assert.False(t, report.Consistent())
+	kinds := func(report *model.ConsistencyReport) []string {
+		var kinds []string
+		for _, i := range report.Inconsistencies {
+			kinds = append(kinds, i.Kind)
+		}
+		return kinds
+	}
+	assert.ElementsMatch(t, []string{
+		model.InconsistencyIndexDrift,
+		model.InconsistencyOrphanedArtifact,
+		model.InconsistencyMissingArtifact,
+		model.InconsistencyOrphanedLog,
+		model.InconsistencyOrphanedLease,
+	}, kinds(report))
+	// Reporting alone changes nothing
+	assert.Len(t, pool.CheckConsistency(ctx, false).Inconsistencies, 5)
+
+	report = pool.CheckConsistency(ctx, true)
+	assert.Len(t, report.Inconsistencies, 5)
+	assert.True(t, report.Consistent())
+	assert.Empty(t, pool.CheckConsistency(ctx, false).Inconsistencies)
+
+	got, _ := pool.GetJob(ctx, job.UID.String())
+	assert.Equal(t, []string{kept.ID}, got.Artifacts)
+	_, ok := pool.GetArtifact(ctx, orphan.ID)
+	assert.False(t, ok)
+	assert.Len(t, pool.store.List(&model.JobFilter{Type: stringPtr("math")}), 1)
+	assert.Contains(t, pool.logs.logs, job.UID.String())
+	assert.NotContains(t, pool.logs.logs, gone.String())
+	assert.Empty(t, pool.leases.leases)
+}
@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+var ErrNegativeCost = errors.New("cost must not be negative")
+
+// SetCostRate charges attempts at jobs of the given type whose executor
+// reports no cost perSecond for each second they run. Zero removes the
+// rate. It must be called before Start.
+func (p *WorkerPool) SetCostRate(jobType string, perSecond float64) {
+	if perSecond <= 0 {
+		delete(p.costRates, jobType)
+		return
+	}
+	p.costRates[jobType] = perSecond
+}
+
+// ReportCost adds amount to the cost of the job whose execution ctx belongs
+// to, for example what the external API it called charged. Once an attempt
+// reports a cost, its run time is not charged at the job type's rate.
+// Outside a job it fails with ErrNoJob.
+func ReportCost(ctx context.Context, amount float64) error {
+	a, ok := ctx.Value(annotatorKey{}).(*annotator)
+	if !ok {
+		return ErrNoJob
+	}
+	return a.pool.addCost(a.jobID, amount)
+}
+
+// ReportLeasedJobCost adds amount to the cost of a job reported by the
+// remote worker holding its lease
+func (p *WorkerPool) ReportLeasedJobCost(ctx context.Context, workerID string, jobID string, amount float64) error {
+	if !p.leases.heldBy(jobID, workerID) {
+		return ErrNotLeased
+	}
+	return p.addCost(jobID, amount)
+}
+
+func (p *WorkerPool) addCost(jobID string, amount float64) error {
+	if amount < 0 {
+		return ErrNegativeCost
+	}
+	_, err := p.store.Update(jobID, 0, func(j *model.Job) error {
+		if len(j.Attempts) == 0 || j.Attempts[len(j.Attempts)-1].EndedAt != nil {
+			return ErrNoJob
+		}
+		j.Attempts = slices.Clone(j.Attempts)
+		attempt := &j.Attempts[len(j.Attempts)-1]
+		cost := amount
+		if attempt.Cost != nil {
+			cost += *attempt.Cost
+		}
+		attempt.Cost = &cost
+		j.Cost += amount
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,110 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// costingExecutor reports the cost of each call it makes, failing the first
+// attempt after its first call
+type costingExecutor struct {
+	clock *pooltest.Clock
+	calls int
+}
+
+func (e *costingExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	e.calls++
+	if err := pool.ReportCost(ctx, 0.25); err != nil {
+		return nil, err
+	}
+	if e.calls == 1 {
+		return nil, errors.New("rate limited")
+	}
+	if err := pool.ReportCost(ctx, 0.5); err != nil {
+		return nil, err
+	}
+	if err := pool.ReportCost(ctx, -1); !errors.Is(err, pool.ErrNegativeCost) {
+		return nil, errors.New("negative cost accepted")
+	}
+	e.clock.Advance(time.Minute)
+	return model.MathJobResult{Result: 6}, nil
+}
+
+func TestWorkerPool_ReportCost(t *testing.T) {
+	ctx := context.Background()
+	clock := pooltest.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	p := pool.NewWorkerPool(ctx, 0, 5)
+	p.SetClock(clock)
+	p.SetMaxRetries(1)
+	p.SetCostRate("math", 1)
+	p.RegisterExecutor("math", &costingExecutor{clock: clock})
+
+	job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 4}, Status: model.JobStatusPending}
+	assert.NoError(t, p.SubmitJob(ctx, job))
+	assert.True(t, p.ProcessNext())
+	assert.True(t, p.ProcessNext())
+
+	// Reported costs replace the rate, even for the minute the second
+	// attempt ran
+	completed, _ := p.GetJob(ctx, job.UID.String())
+	assert.Equal(t, model.JobStatusCompleted, completed.Status)
+	assert.Len(t, completed.Attempts, 2)
+	assert.Equal(t, 0.25, *completed.Attempts[0].Cost)
+	assert.Equal(t, 0.75, *completed.Attempts[1].Cost)
+	assert.Equal(t, 1.0, completed.Cost)
+
+	// Outside a job there is nothing to charge
+	assert.ErrorIs(t, pool.ReportCost(ctx, 1), pool.ErrNoJob)
+}
+
+func TestWorkerPool_CostRate(t *testing.T) {
+	ctx := context.Background()
+	clock := pooltest.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	p := pool.NewWorkerPool(ctx, 0, 5)
+	p.SetClock(clock)
+	p.SetCostRate("math", 0.01)
+
+	lease := func() string {
+		t.Helper()
+		job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 4}, Status: model.JobStatusPending}
+		assert.NoError(t, p.SubmitJob(ctx, job))
+		_, ok := p.LeaseJob(ctx, "remote-1", nil, nil, 0)
+		assert.True(t, ok)
+		return job.UID.String()
+	}
+
+	// Run time is charged at the rate
+	charged := lease()
+	clock.Advance(30 * time.Second)
+	assert.NoError(t, p.CompleteLeasedJob(ctx, "remote-1", charged, model.MathJobResult{Result: 6}, nil))
+	job, _ := p.GetJob(ctx, charged)
+	assert.InDelta(t, 0.3, job.Cost, 1e-9)
+	assert.InDelta(t, 0.3, *job.Attempts[0].Cost, 1e-9)
+
+	// A cost reported by the worker is charged instead
+	reported := lease()
+	assert.ErrorIs(t, p.ReportLeasedJobCost(ctx, "remote-2", reported, 2), pool.ErrNotLeased)
+	assert.NoError(t, p.ReportLeasedJobCost(ctx, "remote-1", reported, 2))
+	clock.Advance(30 * time.Second)
+	assert.NoError(t, p.CompleteLeasedJob(ctx, "remote-1", reported, model.MathJobResult{Result: 6}, nil))
+	job, _ = p.GetJob(ctx, reported)
+	assert.Equal(t, 2.0, job.Cost)
+
+	// Job types without a rate cost nothing unless a cost is reported
+	p.SetCostRate("math", 0)
+	free := lease()
+	clock.Advance(30 * time.Second)
+	assert.NoError(t, p.CompleteLeasedJob(ctx, "remote-1", free, model.MathJobResult{Result: 6}, nil))
+	job, _ = p.GetJob(ctx, free)
+	assert.Zero(t, job.Cost)
+	assert.Nil(t, job.Attempts[0].Cost)
+}
@@ -0,0 +1,83 @@
+package pool
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// deadlineSweepInterval is how often jobs are checked against their
+// deadlines, and so how late a miss may be noticed
+const deadlineSweepInterval = time.Second
+
+// errDeadlineMet abandons the update of a job that finished in time
+var errDeadlineMet = errors.New("deadline met")
+
+// deadlineTable holds the deadlines of jobs that have not yet been checked
+// against them
+type deadlineTable struct {
+	mu  sync.Mutex
+	due map[string]time.Time
+}
+
+func newDeadlineTable() *deadlineTable {
+	return &deadlineTable{due: make(map[string]time.Time)}
+}
+
+// trackDeadline has the job checked once its deadline passes
+func (p *WorkerPool) trackDeadline(job *model.Job) {
+	if job.Deadline == nil {
+		return
+	}
+	p.deadlines.mu.Lock()
+	defer p.deadlines.mu.Unlock()
+	p.deadlines.due[job.UID.String()] = *job.Deadline
+}
+
+func (p *WorkerPool) deadlineReaper() {
+	defer p.wg.Done()
+
+	ticker := p.clock.NewTicker(deadlineSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C():
+			p.checkDeadlines(now)
+		case <-p.quit:
+			return
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkDeadlines flags the jobs whose deadlines have passed without them
+// finishing, or that finished late, and announces each miss
+func (p *WorkerPool) checkDeadlines(now time.Time) {
+	p.deadlines.mu.Lock()
+	var due []string
+	for jobID, deadline := range p.deadlines.due {
+		if !now.Before(deadline) {
+			due = append(due, jobID)
+			delete(p.deadlines.due, jobID)
+		}
+	}
+	p.deadlines.mu.Unlock()
+
+	for _, jobID := range due {
+		job, err := p.store.Update(jobID, 0, func(j *model.Job) error {
+			if j.DeadlineMissed || !j.MissedDeadline(now) {
+				return errDeadlineMet
+			}
+			j.DeadlineMissed = true
+			return nil
+		})
+		if err != nil {
+			continue
+		}
+		p.events.publishDeadlineMissed(job, now)
+	}
+}
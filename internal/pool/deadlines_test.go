@@ -0,0 +1,76 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_CheckDeadlines(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 10)
+	var events []model.JobEvent
+	pool.Subscribe(func(ev model.JobEvent) {
+		if ev.DeadlineMissed {
+			events = append(events, ev)
+		}
+	})
+
+	now := time.Now()
+	submit := func(deadline time.Duration) *model.Job {
+		t.Helper()
+		at := now.Add(deadline)
+		job := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}, Status: model.JobStatusPending, Deadline: &at}
+		assert.NoError(t, pool.SubmitJob(ctx, job))
+		return job
+	}
+	finish := func(job *model.Job, at time.Time) {
+		t.Helper()
+		_, err := pool.store.Transition(job.UID.String(), func(j *model.Job) {
+			j.Status = model.JobStatusCompleted
+			j.CompletedAt = &at
+		})
+		assert.NoError(t, err)
+	}
+	pending := submit(time.Minute)
+	later := submit(time.Hour)
+	onTime := submit(time.Minute)
+	finish(onTime, now.Add(30*time.Second))
+	late := submit(time.Minute)
+	finish(late, now.Add(90*time.Second))
+	noDeadline := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}, Status: model.JobStatusPending}
+	assert.NoError(t, pool.SubmitJob(ctx, noDeadline))
+
+	pool.checkDeadlines(now.Add(59 * time.Second))
+	assert.Empty(t, events)
+
+	pool.checkDeadlines(now.Add(2 * time.Minute))
+	missed := func(job *model.Job) bool {
+		stored, _ := pool.GetJob(ctx, job.UID.String())
+		return stored.DeadlineMissed
+	}
+	assert.True(t, missed(pending))
+	assert.True(t, missed(late))
+	assert.False(t, missed(onTime))
+	assert.False(t, missed(later))
+	assert.False(t, missed(noDeadline))
+
+	var uids []uuid.UUID
+	for _, ev := range events {
+		uids = append(uids, ev.JobUID)
+		assert.Equal(t, ev.From, ev.To)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{pending.UID, late.UID}, uids)
+
+	yes := true
+	flagged := pool.GetAllJobs(ctx, &model.JobFilter{DeadlineMissed: &yes})
+	assert.Len(t, flagged, 2)
+
+	// Each job is checked once
+	pool.checkDeadlines(now.Add(3 * time.Minute))
+	assert.Len(t, events, 2)
+}
@@ -0,0 +1,58 @@
+package pool
+
+import (
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// SetJobTypeDefaults sets what jobs of the given type get when their
+// submission doesn't choose its own timeout, retries, priority or
+// retention, and caps how many of them run at once, with a fixed limit or
+// one that adapts to how they fare. A timeout set here
+// replaces one set with SetJobTimeout. It must be called before Start.
+func (p *WorkerPool) SetJobTypeDefaults(jobType string, d model.JobTypeDefaults) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if d.Timeout != "" {
+		timeout, _ := time.ParseDuration(d.Timeout)
+		p.SetJobTimeout(jobType, timeout)
+	}
+	if d.Retention != "" {
+		// Written the way timeouts are, so jobs show both alike
+		retention, _ := time.ParseDuration(d.Retention)
+		d.Retention = retention.String()
+	}
+	limit := d.Concurrency
+	if d.AdaptiveConcurrency != nil {
+		limit = p.adaptive.configure(jobType, *d.AdaptiveConcurrency)
+	}
+	p.concurrency.setLimit(jobType, limit)
+	p.defaults[jobType] = d
+	return nil
+}
+
+// applyDefaults fills in the settings a submission left unset from its
+// type's defaults, then the pool's, so the job records the values it runs
+// with
+func (p *WorkerPool) applyDefaults(job *model.Job) {
+	d := p.defaults[job.Type]
+	if timeout, ok := p.typeTimeout(job.Type); ok && job.Timeout == "" {
+		job.Timeout = timeout.String()
+	}
+	if job.MaxRetries == nil {
+		retries := p.maxRetries
+		if d.MaxRetries != nil {
+			retries = *d.MaxRetries
+		}
+		job.MaxRetries = &retries
+	}
+	if job.Priority == nil && d.Priority != 0 {
+		priority := d.Priority
+		job.Priority = &priority
+	}
+	if job.Retention == "" {
+		job.Retention = d.Retention
+	}
+}
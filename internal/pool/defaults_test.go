@@ -0,0 +1,133 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestWorkerPool_JobTypeDefaults(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 5)
+	pool.SetMaxRetries(1)
+	assert.NoError(t, pool.SetJobTypeDefaults("math", model.JobTypeDefaults{
+		Timeout:     "30s",
+		MaxRetries:  intPtr(3),
+		Priority:    5,
+		Concurrency: 2,
+		Retention:   "1h",
+	}))
+	err := pool.SetJobTypeDefaults("sleep", model.JobTypeDefaults{Priority: 500, Concurrency: -1})
+	assert.EqualError(t, err, "priority: must be between -100 and 100; concurrency: cannot be negative")
+
+	defaulted := weightedJob(1)
+	assert.NoError(t, pool.SubmitJob(ctx, defaulted))
+	assert.Equal(t, "30s", defaulted.Timeout)
+	assert.Equal(t, intPtr(3), defaulted.MaxRetries)
+	assert.Equal(t, intPtr(5), defaulted.Priority)
+	assert.Equal(t, "1h0m0s", defaulted.Retention)
+	stored, _ := pool.GetJob(ctx, defaulted.UID.String())
+	assert.Equal(t, "30s", stored.Timeout)
+
+	// The submission's own settings win, even when they are zero
+	chosen := weightedJob(1)
+	chosen.Timeout, chosen.MaxRetries, chosen.Priority, chosen.Retention = "5s", intPtr(0), intPtr(0), "10m"
+	assert.NoError(t, pool.SubmitJob(ctx, chosen))
+	assert.Equal(t, "5s", chosen.Timeout)
+	assert.Equal(t, intPtr(0), chosen.MaxRetries)
+	assert.Equal(t, intPtr(0), chosen.Priority)
+	assert.Equal(t, "10m", chosen.Retention)
+
+	// Types without defaults get the pool's
+	sleep := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}, Status: model.JobStatusPending}
+	assert.NoError(t, pool.SubmitJob(ctx, sleep))
+	assert.Empty(t, sleep.Timeout)
+	assert.Equal(t, intPtr(1), sleep.MaxRetries)
+	assert.Nil(t, sleep.Priority)
+	assert.Empty(t, sleep.Retention)
+
+	types := make(map[string]model.JobType)
+	for _, jt := range pool.JobTypes(ctx) {
+		types[jt.Name] = jt
+	}
+	assert.Equal(t, "30s", types["math"].DefaultTimeout)
+	assert.Equal(t, 3, types["math"].MaxRetries)
+	assert.Equal(t, 5, types["math"].Priority)
+	assert.Equal(t, 2, types["math"].Concurrency)
+	assert.Equal(t, "1h0m0s", types["math"].Retention)
+	assert.Equal(t, 1, types["sleep"].MaxRetries)
+	assert.Zero(t, types["sleep"].Concurrency)
+}
+
+func TestWorkerPool_JobOwnRetries(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 5)
+	pool.SetMaxRetries(0)
+	pool.RegisterExecutor("math", &flakyExecutor{failures: 2})
+
+	job := weightedJob(1)
+	job.MaxRetries = intPtr(2)
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+	for pool.ProcessNext() {
+	}
+	completed, _ := pool.GetJob(ctx, job.UID.String())
+	assert.Equal(t, model.JobStatusCompleted, completed.Status)
+	assert.Len(t, completed.Attempts, 3)
+}
+
+func TestWorkerPool_TypeConcurrency(t *testing.T) {
+	ctx := context.Background()
+	exec := &gateExecutor{release: make(chan struct{})}
+	pool := NewWorkerPool(ctx, 3, 5)
+	pool.RegisterExecutor("math", exec)
+	assert.NoError(t, pool.SetJobTypeDefaults("math", model.JobTypeDefaults{Concurrency: 2}))
+	pool.Start()
+	defer pool.Stop()
+
+	for range 3 {
+		assert.NoError(t, pool.SubmitJob(ctx, weightedJob(1)))
+	}
+	running := func() int {
+		exec.mu.Lock()
+		defer exec.mu.Unlock()
+		return exec.running
+	}
+	assert.Eventually(t, func() bool { return running() == 2 }, 2*time.Second, 10*time.Millisecond)
+	// The idle worker leaves the third job alone
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, running())
+
+	close(exec.release)
+	waitForNJobsWithStatus(t, pool, 3, model.JobStatusCompleted)
+}
+
+func TestWorkerPool_ExpireJobs(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 5)
+	assert.NoError(t, pool.SetJobTypeDefaults("math", model.JobTypeDefaults{Retention: "1h"}))
+
+	kept := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}, Status: model.JobStatusPending}
+	expiring := weightedJob(1)
+	assert.NoError(t, pool.SubmitJob(ctx, kept))
+	assert.NoError(t, pool.SubmitJob(ctx, expiring))
+	for pool.ProcessNext() {
+	}
+	finished, _ := pool.GetJob(ctx, expiring.UID.String())
+	assert.Equal(t, model.JobStatusCompleted, finished.Status)
+
+	pool.expireJobs(finished.CompletedAt.Add(59 * time.Minute))
+	_, exists := pool.GetJob(ctx, expiring.UID.String())
+	assert.True(t, exists)
+
+	pool.expireJobs(finished.CompletedAt.Add(time.Hour))
+	_, exists = pool.GetJob(ctx, expiring.UID.String())
+	assert.False(t, exists)
+	_, exists = pool.GetJob(ctx, kept.UID.String())
+	assert.True(t, exists)
+}
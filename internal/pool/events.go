@@ -0,0 +1,72 @@
+package pool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// eventBus fans job events out to subscribers
+type eventBus struct {
+	mu   sync.RWMutex
+	subs map[int]func(model.JobEvent)
+	next int
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[int]func(model.JobEvent))}
+}
+
+// Subscribe calls fn for every job status change, in the order the changes
+// happen to each job. fn runs synchronously while the job is locked, so it
+// must return quickly and must not call back into the pool; hand events off
+// to a channel for anything slower. The returned func unsubscribes.
+func (p *WorkerPool) Subscribe(fn func(ev model.JobEvent)) func() {
+	b := p.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subs[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+func (b *eventBus) publish(job *model.Job, from model.JobStatus, at time.Time) {
+	b.send(model.JobEvent{
+		JobUID:  job.UID,
+		JobType: job.Type,
+		From:    from,
+		To:      job.Status,
+		Version: job.Version,
+		At:      at,
+		TraceID: job.TraceID,
+	})
+}
+
+// publishDeadlineMissed announces that the job missed its deadline, which
+// leaves its status as it was
+func (b *eventBus) publishDeadlineMissed(job *model.Job, at time.Time) {
+	b.send(model.JobEvent{
+		JobUID:         job.UID,
+		JobType:        job.Type,
+		From:           job.Status,
+		To:             job.Status,
+		Version:        job.Version,
+		At:             at,
+		TraceID:        job.TraceID,
+		DeadlineMissed: true,
+	})
+}
+
+func (b *eventBus) send(ev model.JobEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, fn := range b.subs {
+		fn(ev)
+	}
+}
@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+var (
+	ErrInjectedStorageFault = errors.New("injected fault: storage unavailable")
+	errInjectedCrash        = errors.New("injected fault: worker crashed")
+)
+
+// faults holds the active fault scenario. Checks are a single atomic load so
+// they cost nothing measurable when no scenario is set.
+type faults struct {
+	scenario     atomic.Pointer[model.FaultScenario]
+	queueLatency atomic.Int64
+}
+
+// SetFaults replaces the active fault scenario. The scenario must be valid.
+func (p *WorkerPool) SetFaults(scenario model.FaultScenario) {
+	latency, _ := scenario.QueueLatencyDuration()
+	p.faults.queueLatency.Store(int64(latency))
+	p.faults.scenario.Store(&scenario)
+}
+
+// Faults returns the active fault scenario
+func (p *WorkerPool) Faults() model.FaultScenario {
+	if s := p.faults.scenario.Load(); s != nil {
+		return *s
+	}
+	return model.FaultScenario{}
+}
+
+// delaySubmission applies the scenario's queue latency
+func (f *faults) delaySubmission(ctx context.Context, clock Clock) error {
+	latency := time.Duration(f.queueLatency.Load())
+	if latency <= 0 {
+		return nil
+	}
+	select {
+	case <-clock.After(latency):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *faults) storageError() error {
+	if s := f.scenario.Load(); s != nil && s.StorageErrorRate > 0 && rand.Float64() < s.StorageErrorRate {
+		return ErrInjectedStorageFault
+	}
+	return nil
+}
+
+func (f *faults) workerCrash() bool {
+	s := f.scenario.Load()
+	return s != nil && s.WorkerCrashRate > 0 && rand.Float64() < s.WorkerCrashRate
+}
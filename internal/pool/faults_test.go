@@ -0,0 +1,57 @@
+package pool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_Faults(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("storage errors", func(t *testing.T) {
+		p := pool.NewWorkerPool(ctx, 1, 5)
+		p.SetFaults(model.FaultScenario{StorageErrorRate: 1})
+
+		err := p.SubmitJob(ctx, mathJob(3))
+		assert.ErrorIs(t, err, pool.ErrInjectedStorageFault)
+		_, err = p.UpdateJob(ctx, uuid.NewString(), 0, func(*model.Job) error { return nil })
+		assert.ErrorIs(t, err, pool.ErrInjectedStorageFault)
+
+		// Clearing the scenario restores normal behaviour
+		p.SetFaults(model.FaultScenario{})
+		assert.NoError(t, p.SubmitJob(ctx, mathJob(3)))
+	})
+
+	t.Run("worker crashes", func(t *testing.T) {
+		h := pooltest.New(t)
+		h.Pool.SetFaults(model.FaultScenario{WorkerCrashRate: 1})
+
+		job := h.Submit(mathJob(3))
+		assert.True(t, h.RunNext())
+		failed := h.Job(job.UID)
+		assert.Equal(t, model.JobStatusFailed, failed.Status)
+		assert.Equal(t, "injected fault: worker crashed", failed.Error)
+	})
+
+	t.Run("queue latency", func(t *testing.T) {
+		p := pool.NewWorkerPool(ctx, 1, 5)
+		p.SetFaults(model.FaultScenario{QueueLatency: "50ms"})
+		assert.Equal(t, "50ms", p.Faults().QueueLatency)
+
+		start := time.Now()
+		assert.NoError(t, p.SubmitJob(ctx, mathJob(3)))
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		// The delay gives up with the caller
+		cancelled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, p.SubmitJob(cancelled, mathJob(3)), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,85 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// ErrJobRejected is returned for a job a hook refused
+var ErrJobRejected = errors.New("job rejected")
+
+// Hooks let code embedding the pool take part in every job's life without
+// changing the pool, such as to enforce policy or trigger side effects. Any
+// of them may be nil. They run on the goroutine handling the job, so slow
+// side effects belong on a goroutine of their own.
+type Hooks struct {
+	// BeforeSubmit runs before a job is accepted and may change it, such as
+	// adding labels or setting its priority, though not its UID or type.
+	// Returning an error rejects the submission with ErrJobRejected.
+	BeforeSubmit func(ctx context.Context, job *model.Job) error
+	// AfterEnqueue runs with a copy of each job once it is accepted and
+	// waiting to run
+	AfterEnqueue func(ctx context.Context, job *model.Job)
+	// BeforeExecute runs with a copy of the job as each attempt starts, on
+	// local and remote workers alike. Its context lets it record values
+	// with Annotate. Returning an error fails the job with ErrJobRejected
+	// without running or retrying it.
+	BeforeExecute func(ctx context.Context, job *model.Job) error
+	// AfterComplete runs with a copy of each job once it has finished,
+	// whatever its outcome
+	AfterComplete func(ctx context.Context, job *model.Job)
+}
+
+// AddHooks registers hooks, which run in the order they were added. It must
+// be called before Start.
+func (p *WorkerPool) AddHooks(h Hooks) {
+	p.hooks = append(p.hooks, h)
+}
+
+func (p *WorkerPool) beforeSubmit(ctx context.Context, job *model.Job) error {
+	for _, h := range p.hooks {
+		if h.BeforeSubmit == nil {
+			continue
+		}
+		if err := h.BeforeSubmit(ctx, job); err != nil {
+			return fmt.Errorf("%w: %w", ErrJobRejected, err)
+		}
+	}
+	return nil
+}
+
+func (p *WorkerPool) afterEnqueue(ctx context.Context, job *model.Job) {
+	// The job is accepted whether or not the caller is still waiting
+	ctx = context.WithoutCancel(ctx)
+	for _, h := range p.hooks {
+		if h.AfterEnqueue != nil {
+			h.AfterEnqueue(ctx, job.Clone())
+		}
+	}
+}
+
+// beforeExecute runs the BeforeExecute hooks, returning a permanent error
+// if one rejects the job
+func (p *WorkerPool) beforeExecute(ctx context.Context, job *model.Job) error {
+	for _, h := range p.hooks {
+		if h.BeforeExecute == nil {
+			continue
+		}
+		if err := h.BeforeExecute(ctx, job.Clone()); err != nil {
+			return Permanent(fmt.Errorf("%w: %w", ErrJobRejected, err))
+		}
+	}
+	return nil
+}
+
+func (p *WorkerPool) afterComplete(job *model.Job) {
+	ctx := context.WithoutCancel(p.ctx)
+	for _, h := range p.hooks {
+		if h.AfterComplete != nil {
+			h.AfterComplete(ctx, job.Clone())
+		}
+	}
+}
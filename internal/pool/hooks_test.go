@@ -0,0 +1,159 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_Hooks(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 5)
+	pool.SetMaxRetries(3)
+
+	var mu sync.Mutex
+	var enqueued, completed []*model.Job
+	pool.AddHooks(Hooks{
+		BeforeSubmit: func(ctx context.Context, job *model.Job) error {
+			if job.Payload.(model.MathJobPayload).Number > 100 {
+				return errors.New("numbers above 100 need approval")
+			}
+			job.Labels = map[string]string{"policy": "checked"}
+			return nil
+		},
+		AfterEnqueue: func(ctx context.Context, job *model.Job) {
+			mu.Lock()
+			defer mu.Unlock()
+			enqueued = append(enqueued, job)
+		},
+	})
+	pool.AddHooks(Hooks{
+		BeforeExecute: func(ctx context.Context, job *model.Job) error {
+			if job.Payload.(model.MathJobPayload).Number == 13 {
+				return errors.New("unlucky")
+			}
+			return Annotate(ctx, "approved_by", "policy")
+		},
+		AfterComplete: func(ctx context.Context, job *model.Job) {
+			mu.Lock()
+			defer mu.Unlock()
+			completed = append(completed, job)
+		},
+	})
+
+	big := weightedJob(1)
+	big.Payload = model.MathJobPayload{Number: 101}
+	err := pool.SubmitJob(ctx, big)
+	assert.ErrorIs(t, err, ErrJobRejected)
+	assert.EqualError(t, err, "job rejected: numbers above 100 need approval")
+	_, exists := pool.GetJob(ctx, big.UID.String())
+	assert.False(t, exists)
+
+	ok := weightedJob(1)
+	unlucky := weightedJob(1)
+	unlucky.Payload = model.MathJobPayload{Number: 13}
+	assert.NoError(t, pool.SubmitJob(ctx, ok))
+	assert.NoError(t, pool.SubmitJob(ctx, unlucky))
+	assert.Equal(t, map[string]string{"policy": "checked"}, ok.Labels)
+	if assert.Len(t, enqueued, 2) {
+		assert.Equal(t, ok.UID, enqueued[0].UID)
+		assert.Equal(t, model.JobStatusPending, enqueued[0].Status)
+	}
+
+	for pool.ProcessNext() {
+	}
+
+	done, _ := pool.GetJob(ctx, ok.UID.String())
+	assert.Equal(t, model.JobStatusCompleted, done.Status)
+	assert.Equal(t, json.RawMessage(`"policy"`), done.Annotations["approved_by"])
+
+	// A rejected job isn't retried
+	rejected, _ := pool.GetJob(ctx, unlucky.UID.String())
+	assert.Equal(t, model.JobStatusFailed, rejected.Status)
+	assert.Equal(t, "job rejected: unlucky", rejected.Error)
+	assert.Len(t, rejected.Attempts, 1)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, completed, 2) {
+		assert.Equal(t, ok.UID, completed[0].UID)
+		assert.Equal(t, model.JobStatusCompleted, completed[0].Status)
+		assert.Equal(t, unlucky.UID, completed[1].UID)
+	}
+}
+
+func TestWorkerPool_HooksRejectLeasedJob(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 5)
+	pool.AddHooks(Hooks{
+		BeforeExecute: func(ctx context.Context, job *model.Job) error {
+			if job.Payload.(model.MathJobPayload).Number == 13 {
+				return errors.New("unlucky")
+			}
+			return nil
+		},
+	})
+
+	unlucky := weightedJob(1)
+	unlucky.Payload = model.MathJobPayload{Number: 13}
+	next := weightedJob(1)
+	assert.NoError(t, pool.SubmitJob(ctx, unlucky))
+	assert.NoError(t, pool.SubmitJob(ctx, next))
+
+	// The worker is handed the next job instead
+	leased, ok := pool.LeaseJob(ctx, "remote-1", nil, nil, time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, next.UID, leased.UID)
+	rejected, _ := pool.GetJob(ctx, unlucky.UID.String())
+	assert.Equal(t, model.JobStatusFailed, rejected.Status)
+	assert.Equal(t, "job rejected: unlucky", rejected.Error)
+	assert.ErrorIs(t, pool.Heartbeat(ctx, "remote-1", unlucky.UID.String()), ErrNotLeased)
+}
+
+func TestWorkerPool_PatchJob(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 5)
+	// {"number":1} is in the regular lane and longer numbers in the large
+	pool.SetLargeJobs(20, 1, 5)
+	pool.AddHooks(Hooks{
+		BeforeSubmit: func(ctx context.Context, job *model.Job) error {
+			if job.Payload.(model.MathJobPayload).Number == 13 {
+				return errors.New("unlucky")
+			}
+			return nil
+		},
+	})
+	job := weightedJob(1)
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+	setNumber := func(n int) func(*model.Job) error {
+		return func(j *model.Job) error {
+			j.Payload = model.MathJobPayload{Number: n}
+			return nil
+		}
+	}
+
+	// The policy that would have refused the job refuses the patch
+	_, err := pool.PatchJob(ctx, job.UID.String(), 0, setNumber(13))
+	assert.ErrorIs(t, err, ErrJobRejected)
+	stored, _ := pool.GetJob(ctx, job.UID.String())
+	assert.Equal(t, int64(1), stored.Version)
+	assert.Equal(t, job.Payload, stored.Payload)
+
+	patched, err := pool.PatchJob(ctx, job.UID.String(), 1, setNumber(42))
+	assert.NoError(t, err)
+	assert.Equal(t, model.MathJobPayload{Number: 42}, patched.Payload)
+	assert.Equal(t, int64(2), patched.Version)
+
+	_, err = pool.PatchJob(ctx, job.UID.String(), 1, setNumber(43))
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	// A job can't be patched into the large lane
+	_, err = pool.PatchJob(ctx, job.UID.String(), 0, setNumber(1_000_000_000_000))
+	assert.ErrorIs(t, err, model.ErrJobNotMutable)
+}
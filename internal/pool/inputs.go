@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+var (
+	ErrNoBlobStore = errors.New("file uploads are not enabled")
+	ErrNoInput     = errors.New("job has no input file")
+)
+
+// SetBlobStore sets where files uploaded with jobs are kept. Without one,
+// jobs can't be submitted with a file. It must be called before Start.
+func (p *WorkerPool) SetBlobStore(s blob.Store) {
+	p.blobs = s
+}
+
+func inputBlobName(jobID string) string {
+	return jobID + ".input"
+}
+
+// StoreInput saves the file uploaded with a job that is about to be
+// submitted and records it in job.Input. Files larger than
+// model.MaxInputSize are rejected.
+func (p *WorkerPool) StoreInput(ctx context.Context, job *model.Job, filename, contentType string, r io.Reader) error {
+	if p.blobs == nil {
+		return ErrNoBlobStore
+	}
+	name := inputBlobName(job.UID.String())
+	n, err := p.blobs.Put(ctx, name, io.LimitReader(r, model.MaxInputSize+1))
+	if err == nil && n > model.MaxInputSize {
+		err = fmt.Errorf("%w: the limit is %d bytes", model.ErrInputTooLarge, model.MaxInputSize)
+	}
+	if err != nil {
+		// The upload usually fails because the client went away, which
+		// must not keep the partial file from being removed
+		p.blobs.Delete(context.WithoutCancel(ctx), name)
+		return err
+	}
+	job.Input = &model.JobInput{Filename: filename, ContentType: contentType, Size: n}
+	return nil
+}
+
+// CopyInput stores a copy of the file uploaded with from as the input of
+// job, which is about to be submitted
+func (p *WorkerPool) CopyInput(ctx context.Context, from, job *model.Job) error {
+	input, r, err := p.OpenInput(ctx, from.UID.String())
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return p.StoreInput(ctx, job, input.Filename, input.ContentType, r)
+}
+
+// DeleteInput removes the file stored for a job that was not submitted after
+// all
+func (p *WorkerPool) DeleteInput(ctx context.Context, job *model.Job) error {
+	if p.blobs == nil || job.Input == nil {
+		return nil
+	}
+	return p.blobs.Delete(ctx, inputBlobName(job.UID.String()))
+}
+
+// OpenInput opens the file uploaded with the job
+func (p *WorkerPool) OpenInput(ctx context.Context, jobID string) (*model.JobInput, io.ReadCloser, error) {
+	job, exists := p.store.Get(jobID)
+	if !exists {
+		return nil, nil, ErrJobNotFound
+	}
+	if job.Input == nil || p.blobs == nil {
+		return nil, nil, ErrNoInput
+	}
+	r, err := p.blobs.Open(ctx, inputBlobName(jobID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return job.Input, r, nil
+}
+
+// Input opens the file uploaded with the job whose execution ctx belongs to.
+// Executors for file-processing job types read their input with it.
+func Input(ctx context.Context) (*model.JobInput, io.ReadCloser, error) {
+	a, ok := ctx.Value(annotatorKey{}).(*annotator)
+	if !ok {
+		return nil, nil, ErrNoInput
+	}
+	return a.pool.OpenInput(ctx, a.jobID)
+}
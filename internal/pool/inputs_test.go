@@ -0,0 +1,37 @@
+package pool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_StoreInputLimits(t *testing.T) {
+	ctx := context.Background()
+	job := &model.Job{UID: uuid.New(), Type: "math"}
+
+	pool := NewWorkerPool(ctx, 0, 1)
+	assert.ErrorIs(t, pool.StoreInput(ctx, job, "f", "", strings.NewReader("x")), ErrNoBlobStore)
+
+	store, err := blob.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	pool.SetBlobStore(store)
+
+	defer func(max int64) { model.MaxInputSize = max }(model.MaxInputSize)
+	model.MaxInputSize = 4
+	err = pool.StoreInput(ctx, job, "f", "", strings.NewReader("12345"))
+	assert.ErrorIs(t, err, model.ErrInputTooLarge)
+	assert.Nil(t, job.Input)
+	_, err = store.Open(ctx, inputBlobName(job.UID.String()))
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+
+	assert.NoError(t, pool.StoreInput(ctx, job, "f", "", strings.NewReader("1234")))
+	assert.NoError(t, pool.DeleteInput(ctx, job))
+	_, err = store.Open(ctx, inputBlobName(job.UID.String()))
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+}
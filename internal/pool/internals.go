@@ -0,0 +1,42 @@
+package pool
+
+import (
+	"context"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// Internals counts the pool's internal bookkeeping. It takes each lock in
+// turn, so the counts are not a consistent snapshot.
+func (p *WorkerPool) Internals(ctx context.Context) *model.PoolInternals {
+	counts := p.store.CountByStatus()
+	jobs := 0
+	for _, n := range counts {
+		jobs += n
+	}
+
+	logs, openLogs := p.logs.counts()
+
+	p.leases.mu.Lock()
+	leases := len(p.leases.leases)
+	p.leases.mu.Unlock()
+
+	p.events.mu.RLock()
+	subscribers := len(p.events.subs)
+	p.events.mu.RUnlock()
+
+	return &model.PoolInternals{
+		Jobs:           jobs,
+		ActiveJobs:     counts[model.JobStatusPending] + counts[model.JobStatusRunning],
+		RunningJobs:    counts[model.JobStatusRunning],
+		Logs:           logs,
+		OpenLogs:       openLogs,
+		Leases:         leases,
+		Lanes:          p.jobQueue.laneCount(),
+		Subscribers:    subscribers,
+		QueueDepth:     p.jobQueue.len(""),
+		QueueCapacity:  p.jobQueue.capacity,
+		ResultBacklog:  len(p.resultQueue),
+		ResultCapacity: cap(p.resultQueue),
+	}
+}
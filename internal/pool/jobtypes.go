@@ -0,0 +1,106 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// Describer is implemented by executors that add to the description of the
+// job types they are registered for, such as naming themselves or adjusting
+// the payload schema
+type Describer interface {
+	Describe(jt *model.JobType)
+}
+
+// SetJobTimeout bounds how long a job of the given type may run before it is
+// failed. Zero removes the bound. It must be called before Start.
+func (p *WorkerPool) SetJobTimeout(jobType string, d time.Duration) {
+	p.timeoutsMu.Lock()
+	defer p.timeoutsMu.Unlock()
+	if d <= 0 {
+		delete(p.timeouts, jobType)
+		return
+	}
+	p.timeouts[jobType] = d
+}
+
+// typeTimeout returns the timeout of a job type, if it has one
+func (p *WorkerPool) typeTimeout(jobType string) (time.Duration, bool) {
+	p.timeoutsMu.RLock()
+	defer p.timeoutsMu.RUnlock()
+	timeout, ok := p.timeouts[jobType]
+	return timeout, ok
+}
+
+// CustomExecutor names registered executors that don't describe themselves
+const CustomExecutor = "custom"
+
+// JobTypes describes every built-in job type as it is run by this pool,
+// including the executor it is registered with, its timeout, its dispatch
+// rate, how long its results are cached and the defaults its jobs get
+func (p *WorkerPool) JobTypes(ctx context.Context) []model.JobType {
+	types := model.BuiltinJobTypes()
+	for i := range types {
+		jt := &types[i]
+		if e, ok := p.executors[jt.Name]; ok {
+			jt.Executor = CustomExecutor
+			if d, ok := e.(Describer); ok {
+				d.Describe(jt)
+			}
+		}
+		if timeout, ok := p.typeTimeout(jt.Name); ok {
+			jt.DefaultTimeout = timeout.String()
+		}
+		jt.DispatchRate = p.dispatch.rate(jt.Name)
+		if ttl, ok := p.results.ttls[jt.Name]; ok {
+			jt.ResultCacheTTL = ttl.String()
+		}
+		d := p.defaults[jt.Name]
+		jt.MaxRetries = p.maxRetries
+		if d.MaxRetries != nil {
+			jt.MaxRetries = *d.MaxRetries
+		}
+		jt.Priority = d.Priority
+		jt.Retention = d.Retention
+		jt.Concurrency = p.concurrency.limit(jt.Name)
+		jt.AdaptiveConcurrency = p.adaptive.config(jt.Name)
+	}
+	return types
+}
+
+// jobTimeout returns how long a job may run: its own timeout, or else its
+// type's
+func (p *WorkerPool) jobTimeout(job *model.Job) (time.Duration, bool) {
+	if job.Timeout != "" {
+		timeout, err := time.ParseDuration(job.Timeout)
+		if err == nil && timeout > 0 {
+			return timeout, true
+		}
+		slog.Warn("Ignoring invalid job timeout", "job_id", job.UID, "timeout", job.Timeout)
+	}
+	return p.typeTimeout(job.Type)
+}
+
+// withJobTimeout returns the context a job runs under, bounded by its
+// timeout if it has one
+func (p *WorkerPool) withJobTimeout(job *model.Job) (context.Context, context.CancelFunc) {
+	if timeout, ok := p.jobTimeout(job); ok {
+		return context.WithTimeout(p.ctx, timeout)
+	}
+	return context.WithCancel(p.ctx)
+}
+
+// timeoutError replaces the error of a job that ran out of time with one
+// saying so
+func (p *WorkerPool) timeoutError(ctx context.Context, job *model.Job, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && p.ctx.Err() == nil {
+		timeout, _ := p.jobTimeout(job)
+		return fmt.Errorf("job timed out after %s", timeout)
+	}
+	return err
+}
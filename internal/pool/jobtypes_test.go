@@ -0,0 +1,73 @@
+package pool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// describingExecutor names itself in the job type catalog
+type describingExecutor struct {
+	fakeExecutor
+}
+
+func (e *describingExecutor) Describe(jt *model.JobType) {
+	jt.Executor = "test"
+}
+
+func TestWorkerPool_JobTypes(t *testing.T) {
+	p := pool.NewWorkerPool(context.Background(), 0, 1)
+	p.RegisterExecutor("math", &fakeExecutor{})
+	p.RegisterExecutor("container", &describingExecutor{})
+	p.SetJobTimeout("sleep", 90*time.Second)
+	p.SetJobTimeout("math", time.Minute)
+	p.SetJobTimeout("math", 0)
+	p.SetResultCacheTTL("math", 10*time.Minute)
+
+	types := make(map[string]model.JobType)
+	for _, jt := range p.JobTypes(context.Background()) {
+		types[jt.Name] = jt
+	}
+	assert.Len(t, types, 4)
+
+	assert.Equal(t, model.BuiltinExecutor, types["sleep"].Executor)
+	assert.Equal(t, "1m30s", types["sleep"].DefaultTimeout)
+	assert.Equal(t, pool.CustomExecutor, types["math"].Executor)
+	assert.Empty(t, types["math"].DefaultTimeout)
+	assert.Equal(t, "10m0s", types["math"].ResultCacheTTL)
+	assert.Empty(t, types["sleep"].ResultCacheTTL)
+	assert.Equal(t, "test", types["container"].Executor)
+}
+
+func TestWorkerPool_JobTimeout(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 1, 5)
+	p.SetJobTimeout("sleep", 50*time.Millisecond)
+	p.Start()
+	defer p.Stop()
+
+	slow := &model.Job{
+		UID:     uuid.New(),
+		Type:    "sleep",
+		Payload: model.SleepJobPayload{Duration: "1h"},
+		Status:  model.JobStatusPending,
+	}
+	assert.NoError(t, p.SubmitJob(ctx, slow))
+	failed := pooltest.WaitForStatus(t, p, slow.UID.String(), model.JobStatusFailed, time.Second)
+	assert.Equal(t, "job timed out after 50ms", failed.Error)
+
+	quick := &model.Job{
+		UID:     uuid.New(),
+		Type:    "sleep",
+		Payload: model.SleepJobPayload{Duration: "1ms"},
+		Status:  model.JobStatusPending,
+	}
+	assert.NoError(t, p.SubmitJob(ctx, quick))
+	pooltest.WaitForStatus(t, p, quick.UID.String(), model.JobStatusCompleted, time.Second)
+}
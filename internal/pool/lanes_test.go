@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// orderExecutor records the order jobs run in and how many ran at once
+type orderExecutor struct {
+	mu      sync.Mutex
+	order   []int
+	running int
+	peak    int
+}
+
+func (e *orderExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	e.mu.Lock()
+	e.running++
+	e.peak = max(e.peak, e.running)
+	e.order = append(e.order, job.Payload.(model.MathJobPayload).Number)
+	e.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	e.mu.Lock()
+	e.running--
+	e.mu.Unlock()
+	return model.MathJobResult{Result: 1}, nil
+}
+
+func TestWorkerPool_SerializationKey(t *testing.T) {
+	ctx := context.Background()
+	exec := &orderExecutor{}
+	pool := NewWorkerPool(ctx, 4, 10)
+	pool.RegisterExecutor("math", exec)
+	pool.Start()
+	defer pool.Stop()
+
+	const n = 8
+	for i := 0; i < n; i++ {
+		job := &model.Job{
+			UID:              uuid.New(),
+			Type:             "math",
+			Payload:          model.MathJobPayload{Number: i},
+			SerializationKey: "customer-1",
+			Status:           model.JobStatusPending,
+		}
+		assert.NoError(t, pool.SubmitJob(ctx, job))
+	}
+	waitForNJobsWithStatus(t, pool, n, model.JobStatusCompleted)
+
+	exec.mu.Lock()
+	defer exec.mu.Unlock()
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, exec.order)
+	assert.Equal(t, 1, exec.peak)
+	assert.Eventually(t, func() bool { return pool.Internals(ctx).Lanes == 0 }, time.Second, 10*time.Millisecond)
+}
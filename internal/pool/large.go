@@ -0,0 +1,106 @@
+package pool
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// DefaultLargeJobSlots is how many large jobs may run at once unless
+// configured otherwise
+const DefaultLargeJobSlots = 1
+
+// largeLane keeps jobs with large payloads apart from the rest. They are
+// queued against a capacity of their own and only a few run at once, so a
+// burst of them can't take every worker or hold every payload in memory
+// while smaller jobs wait.
+type largeLane struct {
+	mu sync.Mutex
+	// threshold is the payload size in bytes above which a job is large,
+	// zero to put every job in the default lane
+	threshold int
+	slots     int
+	// running are the large jobs taken from the queue that haven't stopped
+	// running yet
+	running map[uuid.UUID]bool
+}
+
+func newLargeLane() *largeLane {
+	return &largeLane{slots: DefaultLargeJobSlots, running: make(map[uuid.UUID]bool)}
+}
+
+// SetLargeJobs puts jobs whose payload encodes to more than threshold bytes
+// in the large lane, where up to queued of them wait and up to slots run at
+// once. A zero threshold turns the lane off. It must be called before
+// Start.
+func (p *WorkerPool) SetLargeJobs(threshold, slots, queued int) {
+	p.large.threshold = threshold
+	p.large.slots = max(slots, 1)
+	p.jobQueue.largeCapacity = queued
+}
+
+// classify picks the lane a job is submitted to
+func (l *largeLane) classify(job *model.Job) string {
+	if l.threshold <= 0 {
+		return ""
+	}
+	data, err := json.Marshal(job.Payload)
+	if err != nil || len(data) <= l.threshold {
+		return ""
+	}
+	return model.JobLaneLarge
+}
+
+// admits reports whether the job could start without exceeding the large
+// lane's slots
+func (l *largeLane) admits(job *model.Job) bool {
+	if job.Lane != model.JobLaneLarge {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.running) < l.slots
+}
+
+// start records that a job admitted has been taken from the queue
+func (l *largeLane) start(job *model.Job) {
+	if job.Lane != model.JobLaneLarge {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running[job.UID] = true
+}
+
+// stop frees the job's slot in the large lane, reporting whether it held
+// one
+func (l *largeLane) stop(job *model.Job) bool {
+	if job.Lane != model.JobLaneLarge {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.running[job.UID] {
+		return false
+	}
+	delete(l.running, job.UID)
+	return true
+}
+
+func (l *largeLane) stats(q *jobQueue) *model.LaneStats {
+	if l.threshold <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	running := len(l.running)
+	l.mu.Unlock()
+	return &model.LaneStats{
+		ThresholdBytes: l.threshold,
+		Slots:          l.slots,
+		Running:        running,
+		QueueDepth:     q.len(model.JobLaneLarge),
+		QueueCapacity:  q.largeCapacity,
+	}
+}
@@ -0,0 +1,21 @@
+package pool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_LargeJobsOff(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 5)
+	job := &model.Job{UID: uuid.New(), Type: "http",
+		Payload: model.HTTPJobPayload{URL: "https://example.com/", Body: strings.Repeat("x", 200)}, Status: model.JobStatusPending}
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+	assert.Empty(t, job.Lane)
+	assert.Nil(t, pool.Stats(ctx).LargeJobs)
+}
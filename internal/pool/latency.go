@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// LatencyWindow is how many recent samples of each job type's queue wait
+// and run time its percentiles are taken from
+const LatencyWindow = 1000
+
+// latencyTracker splits the time jobs take, per type, between waiting to
+// start and running, to tell slow dispatch from slow execution
+type latencyTracker struct {
+	notify func(job *model.Job, wait time.Duration)
+
+	mu    sync.Mutex
+	types map[string]*typeLatency
+}
+
+type typeLatency struct {
+	queue, execution samples
+}
+
+// samples keeps the count and total of every duration recorded and the
+// most recent LatencyWindow of them
+type samples struct {
+	count  int64
+	total  time.Duration
+	recent []time.Duration
+	next   int
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{types: make(map[string]*typeLatency)}
+}
+
+// NotifyQueueWaits has fn called with how long each job waited from
+// submission to the start of its first attempt. It must be called before
+// Start.
+func (p *WorkerPool) NotifyQueueWaits(fn func(job *model.Job, wait time.Duration)) {
+	p.latency.notify = fn
+}
+
+// started records the queue wait of a job whose attempt just started.
+// Only the first attempt counts: a retry's wait includes its backoff, and
+// says nothing about dispatch.
+func (l *latencyTracker) started(job *model.Job) {
+	if len(job.Attempts) != 1 || job.CreatedAt == nil {
+		return
+	}
+	wait := max(job.Attempts[0].StartedAt.Sub(*job.CreatedAt), 0)
+	l.mu.Lock()
+	l.of(job.Type).queue.add(wait)
+	l.mu.Unlock()
+	if l.notify != nil {
+		l.notify(job, wait)
+	}
+}
+
+// ended records the run time of an attempt that completed or failed.
+// Attempts cut short by a restart or a lapsed lease didn't run their
+// course, so they're left out.
+func (l *latencyTracker) ended(jobType string, attempt model.JobAttempt) {
+	if attempt.EndedAt == nil || attempt.Outcome != model.AttemptCompleted && attempt.Outcome != model.AttemptFailed {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.of(jobType).execution.add(max(attempt.EndedAt.Sub(attempt.StartedAt), 0))
+}
+
+func (l *latencyTracker) of(jobType string) *typeLatency {
+	t, ok := l.types[jobType]
+	if !ok {
+		t = &typeLatency{}
+		l.types[jobType] = t
+	}
+	return t
+}
+
+func (l *latencyTracker) stats() map[string]model.LatencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.types) == 0 {
+		return nil
+	}
+	stats := make(map[string]model.LatencyStats, len(l.types))
+	for jobType, t := range l.types {
+		stats[jobType] = model.LatencyStats{Queue: t.queue.stats(), Execution: t.execution.stats()}
+	}
+	return stats
+}
+
+func (s *samples) add(d time.Duration) {
+	s.count++
+	s.total += d
+	if len(s.recent) < LatencyWindow {
+		s.recent = append(s.recent, d)
+		return
+	}
+	s.recent[s.next] = d
+	s.next = (s.next + 1) % LatencyWindow
+}
+
+func (s *samples) stats() model.DurationStats {
+	if s.count == 0 {
+		return model.DurationStats{}
+	}
+	sorted := slices.Sorted(slices.Values(s.recent))
+	// percentile picks the nearest rank
+	percentile := func(p float64) float64 {
+		i := int(p*float64(len(sorted))+0.5) - 1
+		return sorted[min(max(i, 0), len(sorted)-1)].Seconds()
+	}
+	return model.DurationStats{
+		Count:       s.count,
+		MeanSeconds: s.total.Seconds() / float64(s.count),
+		P50Seconds:  percentile(0.5),
+		P95Seconds:  percentile(0.95),
+		P99Seconds:  percentile(0.99),
+		MaxSeconds:  sorted[len(sorted)-1].Seconds(),
+	}
+}
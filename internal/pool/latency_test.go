@@ -0,0 +1,53 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/stretchr/testify/assert"
+)
+
+// timedExecutor takes as long as the job's number in seconds, failing odd
+// numbers on their first run
+type timedExecutor struct {
+	clock *pooltest.Clock
+	runs  map[int]int
+}
+
+func (e *timedExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	n := job.Payload.(model.MathJobPayload).Number
+	e.clock.Advance(time.Duration(n) * time.Second)
+	e.runs[n]++
+	if n%2 == 1 && e.runs[n] == 1 {
+		return nil, errors.New("flaky")
+	}
+	return model.MathJobResult{Result: n}, nil
+}
+
+func TestWorkerPool_Latency(t *testing.T) {
+	e := &timedExecutor{runs: make(map[int]int)}
+	h := pooltest.New(t, pooltest.WithExecutor("math", e))
+	e.clock = h.Clock
+	h.Pool.SetMaxRetries(1)
+	waits := make(map[int]time.Duration)
+	h.Pool.NotifyQueueWaits(func(job *model.Job, wait time.Duration) {
+		waits[job.Payload.(model.MathJobPayload).Number] = wait
+	})
+
+	// The second job waits while the first runs and is retried, and only
+	// the first attempts' waits count
+	h.Submit(&model.Job{Type: "math", Payload: model.MathJobPayload{Number: 3}})
+	h.Submit(&model.Job{Type: "math", Payload: model.MathJobPayload{Number: 2}})
+	h.Clock.Advance(time.Second)
+	assert.Equal(t, 3, h.RunAll())
+	assert.Equal(t, map[int]time.Duration{3: time.Second, 2: 7 * time.Second}, waits)
+
+	latency := h.Pool.Stats(context.Background()).Latency["math"]
+	assert.Equal(t, model.DurationStats{Count: 2, MeanSeconds: 4, P50Seconds: 1, P95Seconds: 7, P99Seconds: 7, MaxSeconds: 7}, latency.Queue)
+	assert.Equal(t, model.DurationStats{Count: 3, MeanSeconds: 8.0 / 3, P50Seconds: 3, P95Seconds: 3, P99Seconds: 3, MaxSeconds: 3}, latency.Execution)
+}
@@ -0,0 +1,231 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+var ErrNotLeased = errors.New("job is not leased by this worker")
+
+// lease records a job handed to a remote worker
+type lease struct {
+	workerID    string
+	heartbeatAt time.Time
+}
+
+type leaseTable struct {
+	mu     sync.Mutex
+	leases map[string]*lease
+	// workers holds the remote workers seen recently, so submissions
+	// requiring capabilities only remote workers offer are accepted
+	workers map[string]*remoteWorker
+}
+
+// remoteWorker is a remote worker as it last described itself
+type remoteWorker struct {
+	*worker
+	seenAt time.Time
+	// polling counts its lease requests still waiting for a job
+	polling int
+}
+
+func newLeaseTable() *leaseTable {
+	return &leaseTable{
+		leases:  make(map[string]*lease),
+		workers: make(map[string]*remoteWorker),
+	}
+}
+
+// seen records that the worker was heard from
+func (t *leaseTable) seen(workerID string, now time.Time) {
+	if w, exists := t.workers[workerID]; exists {
+		w.seenAt = now
+	}
+}
+
+// heldBy reports whether the worker holds the job's lease
+func (t *leaseTable) heldBy(jobID, workerID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, exists := t.leases[jobID]
+	return exists && l.workerID == workerID
+}
+
+// LeaseJob hands the oldest queued job the remote worker can run to it,
+// waiting up to wait for one to arrive. It returns false if none did. Jobs
+// placed on labels the worker's labels don't match are left for others.
+func (p *WorkerPool) LeaseJob(ctx context.Context, workerID string, capabilities []string, labels map[string]string, wait time.Duration) (*model.Job, bool) {
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+
+	remote := newWorker(-1, capabilities)
+	remote.labels = labels
+	p.leases.mu.Lock()
+	polling := 1
+	if known, exists := p.leases.workers[workerID]; exists {
+		polling += known.polling
+	}
+	p.leases.workers[workerID] = &remoteWorker{worker: remote, seenAt: p.clock.Now(), polling: polling}
+	p.leases.mu.Unlock()
+	defer func() {
+		p.leases.mu.Lock()
+		defer p.leases.mu.Unlock()
+		if known, exists := p.leases.workers[workerID]; exists {
+			known.polling--
+			known.seenAt = p.clock.Now()
+		}
+	}()
+
+	for {
+		job, ok := p.jobQueue.take(ctx, p.quit, p.dispatchable(p.leasable(remote.accepts)))
+		if !ok {
+			return nil, false
+		}
+
+		now := p.clock.Now()
+		p.leases.mu.Lock()
+		p.leases.leases[job.UID.String()] = &lease{workerID: workerID, heartbeatAt: now}
+		p.leases.mu.Unlock()
+
+		p.transition(job, UpdateStatus(model.JobStatusRunning, now), func(j *model.Job) {
+			j.LeasedBy = workerID
+			j.Attempts = append(j.Attempts, model.JobAttempt{Worker: workerID, StartedAt: now})
+		})
+		p.latency.started(job)
+
+		// A job a hook rejects fails without reaching the worker, which
+		// waits for the next one instead
+		hookCtx := context.WithValue(p.ctx, annotatorKey{}, &annotator{pool: p, jobID: job.UID.String(), jobType: job.Type})
+		if err := p.beforeExecute(hookCtx, job); err != nil {
+			p.leases.mu.Lock()
+			delete(p.leases.leases, job.UID.String())
+			p.leases.mu.Unlock()
+			p.finishJob(job, nil, err)
+			continue
+		}
+		slog.Info("Job leased", "worker_id", workerID, "job_id", job.UID)
+		return job, true
+	}
+}
+
+// Heartbeat renews a remote worker's lease on a job
+func (p *WorkerPool) Heartbeat(ctx context.Context, workerID string, jobID string) error {
+	p.leases.mu.Lock()
+	defer p.leases.mu.Unlock()
+	l, exists := p.leases.leases[jobID]
+	if !exists || l.workerID != workerID {
+		return ErrNotLeased
+	}
+	l.heartbeatAt = p.clock.Now()
+	p.leases.seen(workerID, l.heartbeatAt)
+	return nil
+}
+
+// CompleteLeasedJob records the outcome reported by the remote worker holding
+// the job's lease
+func (p *WorkerPool) CompleteLeasedJob(ctx context.Context, workerID string, jobID string, result model.JobResult, jobErr error) error {
+	p.leases.mu.Lock()
+	l, exists := p.leases.leases[jobID]
+	if !exists || l.workerID != workerID {
+		p.leases.mu.Unlock()
+		return ErrNotLeased
+	}
+	delete(p.leases.leases, jobID)
+	p.leases.seen(workerID, p.clock.Now())
+	p.leases.mu.Unlock()
+
+	job, exists := p.store.Get(jobID)
+	if !exists {
+		return ErrJobNotFound
+	}
+	p.finishJob(job, result, jobErr)
+	slog.Info("Job completed", "job_id", job.UID, "worker_id", workerID, "status", job.Status)
+	return nil
+}
+
+// leaseReaper periodically reassigns jobs whose remote worker stopped
+// sending heartbeats
+func (p *WorkerPool) leaseReaper() {
+	defer p.wg.Done()
+
+	interval := p.leaseTimeout / 4
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := p.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C():
+			p.expireLeases(now)
+			p.expireWorkers(now)
+		case <-p.quit:
+			return
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// expireWorkers forgets remote workers that have gone a lease timeout
+// without asking for a job, heartbeating or completing one, so jobs only
+// they could run are turned away again
+func (p *WorkerPool) expireWorkers(now time.Time) {
+	p.leases.mu.Lock()
+	defer p.leases.mu.Unlock()
+	for id, w := range p.leases.workers {
+		if w.polling == 0 && now.Sub(w.seenAt) > p.leaseTimeout {
+			delete(p.leases.workers, id)
+		}
+	}
+}
+
+// expireLeases returns jobs with lapsed leases to the front of the queue, or
+// fails them once they have used up their attempts
+func (p *WorkerPool) expireLeases(now time.Time) {
+	p.leases.mu.Lock()
+	var expired []string
+	for jobID, l := range p.leases.leases {
+		if now.Sub(l.heartbeatAt) > p.leaseTimeout {
+			expired = append(expired, jobID)
+			delete(p.leases.leases, jobID)
+		}
+	}
+	p.leases.mu.Unlock()
+
+	for _, jobID := range expired {
+		job, exists := p.store.Get(jobID)
+		if !exists {
+			continue
+		}
+
+		exhausted := false
+		p.transition(job, func(j *model.Job) {
+			p.endAttempt(j, now, model.AttemptLeaseExpired, nil)
+			if len(j.Attempts) >= p.maxAttempts {
+				exhausted = true
+				SetResult(nil, fmt.Errorf("lease expired after %d attempts", len(j.Attempts)), now)(j)
+				return
+			}
+			UpdateStatus(model.JobStatusPending, now)(j)
+		})
+
+		if exhausted {
+			p.jobQueue.finish(job)
+			p.logs.close(jobID)
+			slog.Warn("Job failed after lease expiry", "job_id", job.UID, "attempts", len(job.Attempts))
+			continue
+		}
+		p.jobQueue.requeue(job)
+		slog.Warn("Lease expired, job requeued", "job_id", job.UID)
+	}
+}
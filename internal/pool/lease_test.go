@@ -0,0 +1,173 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_LeaseJob(t *testing.T) {
+	ctx := context.Background()
+	// No local workers so every job is left for remote workers
+	pool := NewWorkerPool(ctx, 0, 5)
+	pool.Start()
+	defer pool.Stop()
+
+	job := &model.Job{
+		UID:      uuid.New(),
+		Type:     "math",
+		Payload:  model.MathJobPayload{Number: 4},
+		Requires: []string{"gpu"},
+		Status:   model.JobStatusPending,
+	}
+	// Nobody offering gpu has been seen yet
+	assert.ErrorIs(t, pool.SubmitJob(ctx, job), ErrUnschedulable)
+
+	_, ok := pool.LeaseJob(ctx, "gpu-1", []string{"gpu"}, nil, 0)
+	assert.False(t, ok)
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+
+	// A worker without the capability gets nothing
+	_, ok = pool.LeaseJob(ctx, "cpu-1", nil, nil, 0)
+	assert.False(t, ok)
+
+	leased, ok := pool.LeaseJob(ctx, "gpu-1", []string{"gpu"}, nil, 0)
+	assert.True(t, ok)
+	assert.Equal(t, job.UID, leased.UID)
+	assert.Equal(t, model.JobStatusRunning, leased.Status)
+	assert.Equal(t, "gpu-1", leased.LeasedBy)
+
+	id := job.UID.String()
+	assert.NoError(t, pool.Heartbeat(ctx, "gpu-1", id))
+	assert.ErrorIs(t, pool.Heartbeat(ctx, "gpu-2", id), ErrNotLeased)
+	assert.ErrorIs(t, pool.CompleteLeasedJob(ctx, "gpu-2", id, model.MathJobResult{Result: 6}, nil), ErrNotLeased)
+
+	err := pool.CompleteLeasedJob(ctx, "gpu-1", id, model.MathJobResult{Result: 6}, nil)
+	assert.NoError(t, err)
+	completed, _ := pool.GetJob(ctx, id)
+	assert.Equal(t, model.JobStatusCompleted, completed.Status)
+	assert.Equal(t, model.MathJobResult{Result: 6}, completed.Result)
+	assert.NotNil(t, completed.CompletedAt)
+
+	// The lease is released on completion
+	assert.ErrorIs(t, pool.CompleteLeasedJob(ctx, "gpu-1", id, nil, errors.New("boom")), ErrNotLeased)
+}
+
+func TestWorkerPool_LeaseJobWaits(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 5)
+	pool.Start()
+	defer pool.Stop()
+
+	job := &model.Job{
+		UID:     uuid.New(),
+		Type:    "sleep",
+		Payload: model.SleepJobPayload{Duration: "1s"},
+		Status:  model.JobStatusPending,
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		pool.SubmitJob(ctx, job)
+	}()
+
+	leased, ok := pool.LeaseJob(ctx, "remote-1", nil, nil, time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, job.UID, leased.UID)
+
+	err := pool.CompleteLeasedJob(ctx, "remote-1", job.UID.String(), nil, errors.New("out of memory"))
+	assert.NoError(t, err)
+	failed, _ := pool.GetJob(ctx, job.UID.String())
+	assert.Equal(t, model.JobStatusFailed, failed.Status)
+	assert.Equal(t, "out of memory", failed.Error)
+}
+
+func TestWorkerPool_ExpireLeases(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 1)
+	pool.SetLeaseTimeout(time.Minute)
+	pool.SetMaxAttempts(2)
+
+	job := &model.Job{
+		UID:     uuid.New(),
+		Type:    "math",
+		Payload: model.MathJobPayload{Number: 2},
+		Status:  model.JobStatusPending,
+	}
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+	id := job.UID.String()
+
+	// First lease lapses and the job goes back to the queue
+	_, ok := pool.LeaseJob(ctx, "remote-1", nil, nil, 0)
+	assert.True(t, ok)
+	pool.expireLeases(time.Now().Add(2 * time.Minute))
+
+	requeued, _ := pool.GetJob(ctx, id)
+	assert.Equal(t, model.JobStatusPending, requeued.Status)
+	assert.Empty(t, requeued.LeasedBy)
+	assert.Len(t, requeued.Attempts, 1)
+	assert.Equal(t, model.AttemptLeaseExpired, requeued.Attempts[0].Outcome)
+	assert.ErrorIs(t, pool.CompleteLeasedJob(ctx, "remote-1", id, model.MathJobResult{Result: 1}, nil), ErrNotLeased)
+
+	// A lease that keeps heartbeating survives
+	_, ok = pool.LeaseJob(ctx, "remote-2", nil, nil, 0)
+	assert.True(t, ok)
+	pool.expireLeases(time.Now().Add(30 * time.Second))
+	assert.NoError(t, pool.Heartbeat(ctx, "remote-2", id))
+
+	// The second lapse exhausts the attempts
+	pool.expireLeases(time.Now().Add(2 * time.Minute))
+	failed, _ := pool.GetJob(ctx, id)
+	assert.Equal(t, model.JobStatusFailed, failed.Status)
+	assert.Equal(t, "lease expired after 2 attempts", failed.Error)
+	assert.Len(t, failed.Attempts, 2)
+	assert.Equal(t, "remote-2", failed.Attempts[1].Worker)
+	assert.Equal(t, 0, pool.jobQueue.len(""))
+}
+
+func TestWorkerPool_ExpireWorkers(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 5)
+	pool.SetLeaseTimeout(time.Minute)
+
+	gpuJob := func() *model.Job {
+		return &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 2}, Requires: []string{"gpu"}, Status: model.JobStatusPending}
+	}
+	_, ok := pool.LeaseJob(ctx, "gpu-1", []string{"gpu"}, nil, 0)
+	assert.False(t, ok)
+	job := gpuJob()
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+
+	// A worker holding a lease stays known while it heartbeats
+	_, ok = pool.LeaseJob(ctx, "gpu-1", []string{"gpu"}, nil, 0)
+	assert.True(t, ok)
+	pool.expireWorkers(time.Now().Add(50 * time.Second))
+	assert.NoError(t, pool.Heartbeat(ctx, "gpu-1", job.UID.String()))
+	pool.expireWorkers(time.Now().Add(50 * time.Second))
+	assert.True(t, pool.schedulable(gpuJob()))
+
+	// One that stopped asking for jobs is forgotten
+	pool.expireWorkers(time.Now().Add(2 * time.Minute))
+	assert.ErrorIs(t, pool.SubmitJob(ctx, gpuJob()), ErrUnschedulable)
+
+	// A lease request waiting for a job keeps it known however long it waits
+	waiting, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.LeaseJob(waiting, "gpu-2", []string{"gpu"}, nil, time.Hour)
+	}()
+	assert.Eventually(t, func() bool {
+		pool.leases.mu.Lock()
+		defer pool.leases.mu.Unlock()
+		return pool.leases.workers["gpu-2"] != nil
+	}, time.Second, time.Millisecond)
+	pool.expireWorkers(time.Now().Add(2 * time.Minute))
+	assert.True(t, pool.schedulable(gpuJob()))
+	cancel()
+	<-done
+}
@@ -0,0 +1,65 @@
+package pool
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// Lineage returns the tree of jobs the job with the given ID belongs to,
+// linked by parent and replay. Finding a job's descendants scans the store,
+// so this is meant for debugging rather than the hot path.
+func (p *WorkerPool) Lineage(ctx context.Context, id string) (*model.Lineage, bool) {
+	job, ok := p.store.Get(id)
+	if !ok {
+		return nil, false
+	}
+
+	// Walk up to the oldest known ancestor. Origins always predate the job,
+	// but a bounded walk guards against hand-edited stores with cycles.
+	root := job
+	seen := map[uuid.UUID]bool{root.UID: true}
+	for {
+		origin, _ := root.Origin()
+		if origin == nil || seen[*origin] {
+			break
+		}
+		ancestor, ok := p.store.Get(origin.String())
+		if !ok {
+			break
+		}
+		seen[ancestor.UID] = true
+		root = ancestor
+	}
+
+	children := make(map[uuid.UUID][]*model.Job)
+	for _, j := range p.store.List(&model.JobFilter{}) {
+		if origin, _ := j.Origin(); origin != nil {
+			children[*origin] = append(children[*origin], j)
+		}
+	}
+	for _, jobs := range children {
+		slices.SortFunc(jobs, model.CompareJobs)
+	}
+
+	lineage := &model.Lineage{Job: job.UID, Root: root.UID, Edges: make([]model.LineageEdge, 0)}
+	visited := map[uuid.UUID]bool{}
+	queue := []*model.Job{root}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if visited[next.UID] {
+			continue
+		}
+		visited[next.UID] = true
+		lineage.Nodes = append(lineage.Nodes, model.NewLineageNode(next))
+		for _, child := range children[next.UID] {
+			_, kind := child.Origin()
+			lineage.Edges = append(lineage.Edges, model.LineageEdge{From: next.UID, To: child.UID, Kind: kind})
+			queue = append(queue, child)
+		}
+	}
+	return lineage, true
+}
@@ -0,0 +1,73 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool_Lineage(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 10)
+
+	clock := time.Now()
+	put := func(parent, replayedFrom *model.Job, attempts int) *model.Job {
+		t.Helper()
+		clock = clock.Add(time.Second)
+		created := clock
+		job := &model.Job{UID: uuid.New(), Type: "math", Status: model.JobStatusCompleted, CreatedAt: &created}
+		if parent != nil {
+			job.Parent = &parent.UID
+		}
+		if replayedFrom != nil {
+			job.ReplayedFrom = &replayedFrom.UID
+		}
+		for range attempts {
+			job.Attempts = append(job.Attempts, model.JobAttempt{Worker: "worker-1"})
+		}
+		require.NoError(t, pool.store.Put(job))
+		return job
+	}
+	root := put(nil, nil, 1)
+	child := put(root, nil, 2)
+	replay := put(nil, child, 1)
+	grandchild := put(replay, nil, 1)
+	sibling := put(root, nil, 1)
+	unrelated := put(nil, nil, 1)
+
+	lineage, ok := pool.Lineage(ctx, grandchild.UID.String())
+	require.True(t, ok)
+	assert.Equal(t, grandchild.UID, lineage.Job)
+	assert.Equal(t, root.UID, lineage.Root)
+	var uids []uuid.UUID
+	for _, node := range lineage.Nodes {
+		uids = append(uids, node.UID)
+	}
+	assert.Equal(t, []uuid.UUID{root.UID, child.UID, sibling.UID, replay.UID, grandchild.UID}, uids)
+	assert.NotContains(t, uids, unrelated.UID)
+	assert.Equal(t, 2, lineage.Nodes[1].Attempts)
+	assert.Equal(t, []model.LineageEdge{
+		{From: root.UID, To: child.UID, Kind: model.LineageParent},
+		{From: root.UID, To: sibling.UID, Kind: model.LineageParent},
+		{From: child.UID, To: replay.UID, Kind: model.LineageReplay},
+		{From: replay.UID, To: grandchild.UID, Kind: model.LineageParent},
+	}, lineage.Edges)
+
+	// A job whose origin is gone roots its own tree
+	gone := uuid.New()
+	orphan := &model.Job{UID: uuid.New(), Type: "math", Parent: &gone}
+	require.NoError(t, pool.store.Put(orphan))
+	lineage, ok = pool.Lineage(ctx, orphan.UID.String())
+	require.True(t, ok)
+	assert.Equal(t, orphan.UID, lineage.Root)
+	assert.Len(t, lineage.Nodes, 1)
+	assert.Empty(t, lineage.Edges)
+
+	_, ok = pool.Lineage(ctx, uuid.NewString())
+	assert.False(t, ok)
+}
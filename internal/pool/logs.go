@@ -0,0 +1,174 @@
+package pool
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// maxLogBytes caps how much output is kept per job
+const maxLogBytes = 1 << 20
+
+const truncatedMarker = "\n[log truncated]\n"
+
+// jobLog buffers one job's output. Readers following the log wait on changed,
+// which is closed and replaced on every write and when the log is closed.
+type jobLog struct {
+	mu        sync.Mutex
+	data      []byte
+	truncated bool
+	closed    bool
+	changed   chan struct{}
+}
+
+func newJobLog() *jobLog {
+	return &jobLog{changed: make(chan struct{})}
+}
+
+func (l *jobLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed || l.truncated {
+		return len(p), nil
+	}
+	if room := maxLogBytes - len(l.data); len(p) > room {
+		l.data = append(l.data, p[:room]...)
+		l.data = append(l.data, truncatedMarker...)
+		l.truncated = true
+	} else {
+		l.data = append(l.data, p...)
+	}
+	l.notify()
+	return len(p), nil
+}
+
+// close marks the log complete once the job has finished
+func (l *jobLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		l.notify()
+	}
+}
+
+// read returns the output past offset, whether the log is complete and a
+// channel that is closed when that changes
+func (l *jobLog) read(offset int) ([]byte, bool, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.data[offset:len(l.data):len(l.data)], l.closed, l.changed
+}
+
+func (l *jobLog) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+type logStore struct {
+	mu   sync.RWMutex
+	logs map[string]*jobLog
+}
+
+func newLogStore() *logStore {
+	return &logStore{logs: make(map[string]*jobLog)}
+}
+
+// open returns the job's log, creating it on first use
+func (s *logStore) open(id string) *jobLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, exists := s.logs[id]
+	if !exists {
+		l = newJobLog()
+		s.logs[id] = l
+	}
+	return l
+}
+
+// get returns the job's log if it has one
+func (s *logStore) get(id string) (*jobLog, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, exists := s.logs[id]
+	return l, exists
+}
+
+func (s *logStore) close(id string) {
+	s.mu.RLock()
+	l, exists := s.logs[id]
+	s.mu.RUnlock()
+	if exists {
+		l.close()
+	}
+}
+
+// remove forgets the job's log, closing it for anyone still following
+func (s *logStore) remove(id string) {
+	s.mu.Lock()
+	l, exists := s.logs[id]
+	delete(s.logs, id)
+	s.mu.Unlock()
+	if exists {
+		l.close()
+	}
+}
+
+// counts returns how many logs are kept and how many are still open
+func (s *logStore) counts() (total, open int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, l := range s.logs {
+		l.mu.Lock()
+		if !l.closed {
+			open++
+		}
+		l.mu.Unlock()
+	}
+	return len(s.logs), open
+}
+
+// StreamJobLogs copies the output a job has produced to w. With follow set it
+// keeps copying new output until the job finishes or ctx is done; for a job
+// that has already finished it returns at once.
+func (p *WorkerPool) StreamJobLogs(ctx context.Context, id string, follow bool, w io.Writer) error {
+	job, exists := p.store.Get(id)
+	if !exists {
+		return ErrJobNotFound
+	}
+	var l *jobLog
+	if job.Status.Finished() {
+		// A log opened now would never be closed, so a finished job only
+		// returns what it logged
+		if l, exists = p.logs.get(id); !exists {
+			return nil
+		}
+		follow = false
+	} else {
+		l = p.logs.open(id)
+		// The job may have finished since it was read, with no log to close
+		if current, exists := p.store.Get(id); !exists || current.Status.Finished() {
+			l.close()
+		}
+	}
+
+	offset := 0
+	for {
+		data, closed, changed := l.read(offset)
+		if len(data) > 0 {
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			offset += len(data)
+		}
+		if !follow || closed {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobLog_Truncates(t *testing.T) {
+	l := newJobLog()
+	l.Write([]byte(strings.Repeat("x", maxLogBytes-1)))
+	l.Write([]byte("yz"))
+	l.Write([]byte("dropped"))
+
+	data, closed, _ := l.read(0)
+	assert.False(t, closed)
+	assert.Equal(t, maxLogBytes+len(truncatedMarker), len(data))
+	assert.True(t, strings.HasSuffix(string(data), "y"+truncatedMarker))
+}
+
+func TestStreamJobLogs(t *testing.T) {
+	pool := &WorkerPool{store: NewMemoryStore(), logs: newLogStore()}
+	job := &model.Job{UID: uuid.New(), Type: "math", Status: model.JobStatusRunning}
+	pool.store.Put(job)
+	id := job.UID.String()
+
+	err := pool.StreamJobLogs(context.Background(), uuid.NewString(), false, &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	l := pool.logs.open(id)
+	l.Write([]byte("first\n"))
+
+	// Without follow only the output so far is returned
+	var snapshot bytes.Buffer
+	assert.NoError(t, pool.StreamJobLogs(context.Background(), id, false, &snapshot))
+	assert.Equal(t, "first\n", snapshot.String())
+
+	// Following returns once the log is closed
+	done := make(chan struct{})
+	var followed bytes.Buffer
+	go func() {
+		defer close(done)
+		assert.NoError(t, pool.StreamJobLogs(context.Background(), id, true, &followed))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	l.Write([]byte("second\n"))
+	pool.logs.close(id)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("follow did not return after the log was closed")
+	}
+	assert.Equal(t, "first\nsecond\n", followed.String())
+
+	// Following a cancelled request stops early
+	other := &model.Job{UID: uuid.New(), Type: "math", Status: model.JobStatusRunning}
+	pool.store.Put(other)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = pool.StreamJobLogs(ctx, other.UID.String(), true, &bytes.Buffer{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStreamJobLogs_FinishedJob(t *testing.T) {
+	pool := &WorkerPool{store: NewMemoryStore(), logs: newLogStore()}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// A job that finished without producing output returns straight away
+	quiet := &model.Job{UID: uuid.New(), Type: "math", Status: model.JobStatusCompleted}
+	pool.store.Put(quiet)
+	var out bytes.Buffer
+	assert.NoError(t, pool.StreamJobLogs(ctx, quiet.UID.String(), true, &out))
+	assert.Empty(t, out.String())
+	total, _ := pool.logs.counts()
+	assert.Zero(t, total)
+
+	// One that did returns its output
+	logged := &model.Job{UID: uuid.New(), Type: "container", Status: model.JobStatusFailed}
+	pool.store.Put(logged)
+	pool.logs.open(logged.UID.String()).Write([]byte("exit 1\n"))
+	assert.NoError(t, pool.StreamJobLogs(ctx, logged.UID.String(), true, &out))
+	assert.Equal(t, "exit 1\n", out.String())
+}
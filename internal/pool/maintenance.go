@@ -0,0 +1,130 @@
+package pool
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// maintenanceCheckInterval is how often the pool looks for a maintenance
+// window ending, so queued jobs start soon after it does
+const maintenanceCheckInterval = time.Second
+
+// maintenance pauses dispatch during scheduled windows, or whenever an
+// override says so
+type maintenance struct {
+	mu       sync.Mutex
+	windows  []model.MaintenanceWindow
+	override string
+}
+
+// window returns the scheduled window now falls in, if any
+func (m *maintenance) window(now time.Time) *model.MaintenanceWindow {
+	for _, w := range m.windows {
+		if w.Contains(now) {
+			return &w
+		}
+	}
+	return nil
+}
+
+func (m *maintenance) paused(now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch m.override {
+	case model.MaintenanceOverridePause:
+		return true
+	case model.MaintenanceOverrideResume:
+		return false
+	}
+	return m.window(now) != nil
+}
+
+// SetMaintenanceWindows schedules the windows during which no job is
+// started. Jobs are still accepted and queued meanwhile, and jobs already
+// running finish. The windows must be valid. It must be called before
+// Start.
+func (p *WorkerPool) SetMaintenanceWindows(windows []model.MaintenanceWindow) {
+	windows = slices.Clone(windows)
+	model.SortMaintenanceWindows(windows)
+	p.maintenance.mu.Lock()
+	defer p.maintenance.mu.Unlock()
+	p.maintenance.windows = windows
+}
+
+// SetMaintenanceOverride pauses or resumes dispatch regardless of the
+// scheduled windows, or follows them again when override is empty. The
+// override must be valid and lasts until the pool stops.
+func (p *WorkerPool) SetMaintenanceOverride(override string) {
+	p.maintenance.mu.Lock()
+	p.maintenance.override = override
+	p.maintenance.mu.Unlock()
+	slog.Info("Maintenance override set", "override", override)
+	// Queued jobs may start now
+	p.jobQueue.wake()
+}
+
+// Maintenance reports whether dispatch is paused, and the windows to come
+func (p *WorkerPool) Maintenance() model.MaintenanceStatus {
+	now := p.clock.Now()
+	m := &p.maintenance
+	paused := m.paused(now)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := model.MaintenanceStatus{
+		Paused:   paused,
+		Override: m.override,
+		Window:   m.window(now),
+		Windows:  []model.MaintenanceWindow{},
+	}
+	for _, w := range m.windows {
+		if !w.End.After(now) {
+			continue
+		}
+		status.Windows = append(status.Windows, w)
+		if status.Next == nil && w.Start.After(now) {
+			status.Next = &w
+		}
+	}
+	return status
+}
+
+// InMaintenance reports whether dispatch is paused
+func (p *WorkerPool) InMaintenance() bool {
+	return p.maintenance.paused(p.clock.Now())
+}
+
+// maintenanceWatcher wakes the queue when a maintenance window ends. It
+// only runs when windows are scheduled, as overrides wake the queue
+// themselves.
+func (p *WorkerPool) maintenanceWatcher() {
+	defer p.wg.Done()
+
+	ticker := p.clock.NewTicker(maintenanceCheckInterval)
+	defer ticker.Stop()
+
+	paused := p.maintenance.paused(p.clock.Now())
+	for {
+		select {
+		case <-ticker.C():
+			// A missed tick mustn't leave the pool paused, so this looks
+			// at the time now rather than when the tick was due
+			was := paused
+			paused = p.maintenance.paused(p.clock.Now())
+			if was && !paused {
+				slog.Info("Maintenance window ended, resuming dispatch")
+				p.jobQueue.wake()
+			} else if !was && paused {
+				slog.Info("Maintenance window started, pausing dispatch")
+			}
+		case <-p.quit:
+			return
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package pool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_MaintenanceWindows(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := pooltest.NewClock(start)
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	p.SetClock(clock)
+	later := model.MaintenanceWindow{Start: start.Add(3 * time.Hour), End: start.Add(4 * time.Hour)}
+	window := model.MaintenanceWindow{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Reason: "database upgrade"}
+	p.SetMaintenanceWindows([]model.MaintenanceWindow{later, window})
+	defer p.Stop()
+
+	status := p.Maintenance()
+	assert.False(t, status.Paused)
+	assert.Nil(t, status.Window)
+	assert.Equal(t, &window, status.Next)
+	assert.Equal(t, []model.MaintenanceWindow{window, later}, status.Windows)
+
+	// Jobs are still accepted during a window, but none start
+	clock.Advance(time.Hour)
+	assert.True(t, p.InMaintenance())
+	job := submitMath(t, p)
+	assert.False(t, p.ProcessNext())
+	status = p.Maintenance()
+	assert.True(t, status.Paused)
+	assert.Equal(t, &window, status.Window)
+	assert.Equal(t, &later, status.Next)
+
+	clock.Advance(time.Hour)
+	assert.False(t, p.InMaintenance())
+	assert.True(t, p.ProcessNext())
+	got, _ := p.GetJob(ctx, job.UID.String())
+	assert.Equal(t, model.JobStatusCompleted, got.Status)
+	assert.Equal(t, []model.MaintenanceWindow{later}, p.Maintenance().Windows)
+}
+
+func TestWorkerPool_MaintenanceOverride(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := pooltest.NewClock(start)
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	p.SetClock(clock)
+	p.SetMaintenanceWindows([]model.MaintenanceWindow{{Start: start, End: start.Add(time.Hour)}})
+	defer p.Stop()
+
+	// Resuming lets jobs start during a window
+	submitMath(t, p)
+	assert.False(t, p.ProcessNext())
+	p.SetMaintenanceOverride(model.MaintenanceOverrideResume)
+	assert.False(t, p.InMaintenance())
+	assert.True(t, p.ProcessNext())
+
+	// Pausing holds them outside one
+	clock.Advance(time.Hour)
+	p.SetMaintenanceOverride(model.MaintenanceOverridePause)
+	submitMath(t, p)
+	assert.False(t, p.ProcessNext())
+	assert.Equal(t, model.MaintenanceOverridePause, p.Maintenance().Override)
+
+	p.SetMaintenanceOverride("")
+	assert.True(t, p.ProcessNext())
+}
+
+func TestWorkerPool_MaintenanceWindowEnds(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := pooltest.NewClock(start)
+	p := pool.NewWorkerPool(ctx, 1, 10)
+	p.SetClock(clock)
+	p.SetMaintenanceWindows([]model.MaintenanceWindow{{Start: start, End: start.Add(time.Minute)}})
+	p.Start()
+	defer p.Stop()
+
+	job := submitMath(t, p)
+	// The lease, deadline and retention reapers' tickers plus the
+	// maintenance watcher's
+	clock.BlockUntil(4)
+	clock.Advance(time.Minute)
+
+	// The worker waiting on the queue is woken once the window ends
+	pooltest.WaitForStatus(t, p, job.UID.String(), model.JobStatusCompleted, time.Second)
+}
@@ -0,0 +1,175 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+const (
+	// DefaultMemoTTL is how long a memoized value is kept when no TTL is
+	// given
+	DefaultMemoTTL = time.Hour
+	// DefaultMemoEntries bounds the values memoized for each job type
+	DefaultMemoEntries = 10000
+)
+
+// memoTable holds a memo cache per job type, created when first used
+type memoTable struct {
+	mu         sync.Mutex
+	caches     map[string]*MemoCache
+	maxEntries int
+}
+
+func newMemoTable() *memoTable {
+	return &memoTable{caches: make(map[string]*MemoCache), maxEntries: DefaultMemoEntries}
+}
+
+// SetMemoLimit bounds how many values executors may memoize for each job
+// type. Once a type's cache is full, the value closest to expiring is
+// evicted to make room. It must be called before Start.
+func (p *WorkerPool) SetMemoLimit(entries int) {
+	p.memos.maxEntries = entries
+}
+
+// MemoCache is a key/value cache that executors share across the jobs of
+// one type, for memoizing expensive sub-computations. Values are returned
+// as stored, so they should not be modified once set.
+type MemoCache struct {
+	clock      Clock
+	maxEntries int
+
+	mu        sync.Mutex
+	entries   map[string]memoEntry
+	hits      int
+	misses    int
+	evictions int
+}
+
+type memoEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+// Memo returns the memo cache of the job type whose execution ctx belongs
+// to. Outside a job it returns nil, which acts as a cache that never holds
+// anything.
+func Memo(ctx context.Context) *MemoCache {
+	a, ok := ctx.Value(annotatorKey{}).(*annotator)
+	if !ok {
+		return nil
+	}
+	return a.pool.memo(a.jobType)
+}
+
+func (p *WorkerPool) memo(jobType string) *MemoCache {
+	p.memos.mu.Lock()
+	defer p.memos.mu.Unlock()
+	c, ok := p.memos.caches[jobType]
+	if !ok {
+		c = &MemoCache{clock: p.clock, maxEntries: p.memos.maxEntries, entries: make(map[string]memoEntry)}
+		p.memos.caches[jobType] = c
+	}
+	return c
+}
+
+// Get returns the value memoized under key, if it hasn't expired
+func (c *MemoCache) Get(key string) (any, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.clock.Now().Before(entry.expiresAt) {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return entry.value, true
+}
+
+// Set memoizes value under key for ttl, or DefaultMemoTTL if ttl isn't
+// positive
+func (c *MemoCache) Set(key string, value any, ttl time.Duration) {
+	if c == nil || c.maxEntries <= 0 {
+		return
+	}
+	if ttl <= 0 {
+		ttl = DefaultMemoTTL
+	}
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = memoEntry{value: value, expiresAt: now.Add(ttl)}
+}
+
+// evict drops every expired entry, or the one closest to expiring if none
+// has. c.mu must be held.
+func (c *MemoCache) evict(now time.Time) {
+	var soonest string
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+		if soonest == "" || entry.expiresAt.Before(c.entries[soonest].expiresAt) {
+			soonest = key
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, soonest)
+		c.evictions++
+	}
+}
+
+// Delete forgets the value memoized under key
+func (c *MemoCache) Delete(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Do returns the value memoized under key, computing and memoizing it with
+// fn on a miss. Errors from fn are returned without being memoized.
+// Concurrent misses for the same key each call fn.
+func (c *MemoCache) Do(key string, ttl time.Duration, fn func() (any, error)) (any, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+	value, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	c.Set(key, value, ttl)
+	return value, nil
+}
+
+func (c *MemoCache) stats() model.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return model.CacheStats{Entries: len(c.entries), Hits: c.hits, Misses: c.misses, Evictions: c.evictions}
+}
+
+// memoStats describes the memo cache of every job type that has used one
+func (p *WorkerPool) memoStats() map[string]model.CacheStats {
+	p.memos.mu.Lock()
+	defer p.memos.mu.Unlock()
+	if len(p.memos.caches) == 0 {
+		return nil
+	}
+	stats := make(map[string]model.CacheStats, len(p.memos.caches))
+	for jobType, c := range p.memos.caches {
+		stats[jobType] = c.stats()
+	}
+	return stats
+}
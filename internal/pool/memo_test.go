@@ -0,0 +1,99 @@
+package pool_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoExecutor memoizes the sum it computes for each math payload
+type memoExecutor struct {
+	computed int
+	memo     *pool.MemoCache
+}
+
+func (e *memoExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	e.memo = pool.Memo(ctx)
+	number := job.Payload.(model.MathJobPayload).Number
+	sum, err := e.memo.Do(fmt.Sprint(number), time.Minute, func() (any, error) {
+		e.computed++
+		return number * (number - 1) / 2, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return model.MathJobResult{Result: sum.(int)}, nil
+}
+
+func TestWorkerPool_Memo(t *testing.T) {
+	ctx := context.Background()
+	clock := pooltest.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	p.SetClock(clock)
+	p.SetMemoLimit(2)
+	executor := &memoExecutor{}
+	p.RegisterExecutor("math", executor)
+	assert.Nil(t, p.Stats(ctx).Memo)
+
+	run := func(number int) {
+		t.Helper()
+		job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: number}, Status: model.JobStatusPending}
+		assert.NoError(t, p.SubmitJob(ctx, job))
+		assert.True(t, p.ProcessNext())
+		completed, _ := p.GetJob(ctx, job.UID.String())
+		assert.Equal(t, model.MathJobResult{Result: number * (number - 1) / 2}, completed.Result)
+	}
+
+	run(10)
+	run(10)
+	assert.Equal(t, 1, executor.computed)
+
+	// A third value evicts the one closest to expiring
+	clock.Advance(time.Second)
+	run(20)
+	clock.Advance(time.Second)
+	run(30)
+	assert.Equal(t, 3, executor.computed)
+	_, ok := executor.memo.Get("10")
+	assert.False(t, ok)
+
+	// Values expire after their TTL
+	clock.Advance(time.Minute)
+	run(30)
+	assert.Equal(t, 4, executor.computed)
+
+	executor.memo.Delete("30")
+	_, ok = executor.memo.Get("30")
+	assert.False(t, ok)
+
+	assert.Equal(t, map[string]model.CacheStats{
+		"math": {Entries: 1, Hits: 1, Misses: 6, Evictions: 1},
+	}, p.Stats(ctx).Memo)
+}
+
+func TestMemo_OutsideJob(t *testing.T) {
+	memo := pool.Memo(context.Background())
+	assert.Nil(t, memo)
+	memo.Set("k", 1, time.Minute)
+	_, ok := memo.Get("k")
+	assert.False(t, ok)
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		value, err := memo.Do("k", time.Minute, func() (any, error) {
+			calls++
+			return "v", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "v", value)
+	}
+	assert.Equal(t, 2, calls)
+}
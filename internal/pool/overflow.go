@@ -0,0 +1,284 @@
+package pool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// DefaultOverflowJobs bounds how many jobs may spill to disk unless
+// configured otherwise
+const DefaultOverflowJobs = 100000
+
+var errOverflowFull = errors.New("job queue and overflow are full")
+
+// overflowQueue is a FIFO of pending jobs on disk that the queue spills to
+// when it is full. Jobs are appended to a file as JSON lines and read back
+// from an offset kept in a second file, so jobs spilled but not yet queued
+// survive a restart. Both files are emptied whenever the last job is read.
+type overflowQueue struct {
+	mu         sync.Mutex
+	data       *os.File
+	reader     *bufio.Reader
+	offsetPath string
+	// offset is where the next job starts and size where the file ends
+	offset int64
+	size   int64
+	depth  int
+	max    int
+	// spilled counts the jobs ever spilled
+	spilled int64
+}
+
+func openOverflow(dir string, max int) (*overflowQueue, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("overflow directory: %w", err)
+	}
+	data, err := os.OpenFile(filepath.Join(dir, "jobs.ndjson"), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("overflow queue: %w", err)
+	}
+	q := &overflowQueue{data: data, offsetPath: filepath.Join(dir, "offset"), max: max}
+	if err := q.load(); err != nil {
+		data.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// load finds the jobs left from a previous run, dropping a last line cut
+// short by a crash
+func (q *overflowQueue) load() error {
+	if b, err := os.ReadFile(q.offsetPath); err == nil {
+		q.offset, _ = strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("overflow queue: %w", err)
+	}
+	if _, err := q.data.Seek(q.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("overflow queue: %w", err)
+	}
+	q.size = q.offset
+	r := bufio.NewReader(q.data)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		q.size += int64(len(line))
+		q.depth++
+	}
+	if err := q.data.Truncate(q.size); err != nil {
+		return fmt.Errorf("overflow queue: %w", err)
+	}
+	return q.rewind()
+}
+
+// rewind points the reader at the offset
+func (q *overflowQueue) rewind() error {
+	if _, err := q.data.Seek(q.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("overflow queue: %w", err)
+	}
+	q.reader = bufio.NewReader(q.data)
+	return nil
+}
+
+// spill appends a job, calling commit once it is on disk and counting it
+// only if commit succeeds. Jobs can't be read back until then.
+func (q *overflowQueue) spill(job *model.Job, commit func() error) error {
+	line, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.depth >= q.max {
+		return errOverflowFull
+	}
+	if _, err := q.data.WriteAt(line, q.size); err != nil {
+		q.data.Truncate(q.size)
+		return fmt.Errorf("spilling job to disk: %w", err)
+	}
+	if err := commit(); err != nil {
+		q.data.Truncate(q.size)
+		return err
+	}
+	q.size += int64(len(line))
+	q.depth++
+	q.spilled++
+	return nil
+}
+
+// pop removes and returns the oldest job, or nil if there is none. A line
+// that can't be decoded is skipped with an error.
+func (q *overflowQueue) pop() (*model.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.depth == 0 {
+		return nil, nil
+	}
+	line, err := q.reader.ReadBytes('\n')
+	if err != nil {
+		// The file lost jobs it should hold, so start afresh rather than
+		// failing forever
+		q.depth = 0
+		q.saveOffset()
+		return nil, fmt.Errorf("reading overflow queue: %w", err)
+	}
+	q.offset += int64(len(line))
+	q.depth--
+	if err := q.saveOffset(); err != nil {
+		return nil, err
+	}
+	var job model.Job
+	if err := json.Unmarshal(bytes.TrimSpace(line), &job); err != nil {
+		return nil, fmt.Errorf("decoding spilled job: %w", err)
+	}
+	return &job, nil
+}
+
+// saveOffset records how far the queue has been read, emptying the files
+// once everything has been
+func (q *overflowQueue) saveOffset() error {
+	if q.depth == 0 {
+		if err := q.data.Truncate(0); err != nil {
+			return fmt.Errorf("overflow queue: %w", err)
+		}
+		q.offset, q.size = 0, 0
+		if err := q.rewind(); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(q.offsetPath, []byte(strconv.FormatInt(q.offset, 10)), 0o600); err != nil {
+		return fmt.Errorf("overflow queue: %w", err)
+	}
+	return nil
+}
+
+// pending returns the spilled jobs without removing them, oldest first
+func (q *overflowQueue) pending() ([]*model.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	r := bufio.NewReader(io.NewSectionReader(q.data, q.offset, q.size-q.offset))
+	var jobs []*model.Job
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return jobs, nil
+		}
+		var job model.Job
+		if err := json.Unmarshal(bytes.TrimSpace(line), &job); err != nil {
+			return jobs, fmt.Errorf("decoding spilled job: %w", err)
+		}
+		jobs = append(jobs, &job)
+	}
+}
+
+func (q *overflowQueue) stats() *model.OverflowStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return &model.OverflowStats{Depth: q.depth, Capacity: q.max, Spilled: q.spilled}
+}
+
+func (q *overflowQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.depth
+}
+
+func (q *overflowQueue) close() error {
+	return q.data.Close()
+}
+
+// SetOverflow makes jobs submitted while the queue is full spill to a queue
+// on disk in dir, holding up to max jobs, instead of being rejected. They
+// are queued in order as room frees up, and those still on disk when the
+// service stops are queued again when it starts. Jobs in the large lane are
+// never spilled. It must be called before Start.
+func (p *WorkerPool) SetOverflow(dir string, max int) error {
+	q, err := openOverflow(dir, max)
+	if err != nil {
+		return err
+	}
+	p.overflow = q
+	return nil
+}
+
+// spill stores a job that didn't fit in the queue and appends it to the
+// overflow queue
+func (p *WorkerPool) spill(job *model.Job) error {
+	return p.overflow.spill(job, func() error {
+		if err := p.store.Put(job); err != nil {
+			return err
+		}
+		p.events.publish(job, "", p.clock.Now())
+		p.trackDeadline(job)
+		return nil
+	})
+}
+
+// recoverOverflow stores the jobs left on disk by a previous run that the
+// store doesn't hold, so they are queued once they are read back
+func (p *WorkerPool) recoverOverflow() {
+	jobs, err := p.overflow.pending()
+	if err != nil {
+		slog.Error("Failed to read overflow queue", "error", err)
+	}
+	for _, job := range jobs {
+		if _, exists := p.store.Get(job.UID.String()); exists {
+			continue
+		}
+		if err := p.store.Put(job); err != nil {
+			slog.Error("Failed to recover spilled job", "job_id", job.UID, "error", err)
+			continue
+		}
+		p.events.publish(job, "", p.clock.Now())
+		p.trackDeadline(job)
+	}
+	if len(jobs) > 0 {
+		slog.Info("Recovered spilled jobs", "jobs", len(jobs))
+	}
+}
+
+// refill moves spilled jobs into the queue whenever it has room
+func (p *WorkerPool) refill() {
+	defer p.wg.Done()
+	for {
+		for p.overflow.len() > 0 && p.jobQueue.reserve("") {
+			job, err := p.overflow.pop()
+			if err != nil {
+				slog.Error("Failed to read spilled job", "error", err)
+			}
+			if job == nil {
+				p.jobQueue.release("")
+				continue
+			}
+			// The store's copy is the one transitions update
+			stored, exists := p.store.Get(job.UID.String())
+			if !exists || stored.Status != model.JobStatusPending {
+				p.jobQueue.release("")
+				continue
+			}
+			p.jobQueue.pushReserved(stored)
+		}
+		select {
+		case <-p.jobQueue.space:
+		case <-p.quit:
+			return
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// heldExecutor records the order jobs run in, holding each until release
+// is closed
+type heldExecutor struct {
+	release chan struct{}
+
+	mu    sync.Mutex
+	order []int
+}
+
+func (e *heldExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	e.mu.Lock()
+	e.order = append(e.order, job.Payload.(model.MathJobPayload).Number)
+	e.mu.Unlock()
+	<-e.release
+	return model.MathJobResult{Result: 1}, nil
+}
+
+func (e *heldExecutor) started() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.order)
+}
+
+func mathJob(n int) *model.Job {
+	return &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: n}, Status: model.JobStatusPending}
+}
+
+func TestWorkerPool_Overflow(t *testing.T) {
+	ctx := context.Background()
+	exec := &heldExecutor{release: make(chan struct{})}
+	pool := NewWorkerPool(ctx, 1, 1)
+	require.NoError(t, pool.SetOverflow(t.TempDir(), 3))
+	pool.RegisterExecutor("math", exec)
+	pool.Start()
+	defer pool.Stop()
+
+	assert.NoError(t, pool.SubmitJob(ctx, mathJob(0)))
+	assert.Eventually(t, func() bool { return exec.started() == 1 }, time.Second, 10*time.Millisecond)
+	assert.NoError(t, pool.SubmitJob(ctx, mathJob(1)))
+
+	// The queue is full, so further jobs spill to disk until it is too
+	for i := 2; i < 5; i++ {
+		job := mathJob(i)
+		assert.NoError(t, pool.SubmitJob(ctx, job))
+		stored, ok := pool.GetJob(ctx, job.UID.String())
+		assert.True(t, ok)
+		assert.Equal(t, model.JobStatusPending, stored.Status)
+	}
+	assert.ErrorIs(t, pool.SubmitJob(ctx, mathJob(5)), errOverflowFull)
+	assert.Equal(t, &model.OverflowStats{Depth: 3, Capacity: 3, Spilled: 3}, pool.Stats(ctx).Overflow)
+
+	close(exec.release)
+	waitForNJobsWithStatus(t, pool, 5, model.JobStatusCompleted)
+	exec.mu.Lock()
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, exec.order)
+	exec.mu.Unlock()
+	assert.Equal(t, 0, pool.Stats(ctx).Overflow.Depth)
+}
+
+func TestOverflowQueue_TornWrite(t *testing.T) {
+	dir := t.TempDir()
+	line, err := json.Marshal(mathJob(7))
+	require.NoError(t, err)
+	data := append(line, '\n')
+	data = append(data, line[:20]...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jobs.ndjson"), data, 0o600))
+
+	q, err := openOverflow(dir, 10)
+	require.NoError(t, err)
+	defer q.close()
+	assert.Equal(t, 1, q.len())
+
+	job, err := q.pop()
+	require.NoError(t, err)
+	assert.Equal(t, model.MathJobPayload{Number: 7}, job.Payload)
+	job, err = q.pop()
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	// Appending starts afresh once everything has been read
+	assert.NoError(t, q.spill(mathJob(8), func() error { return nil }))
+	job, err = q.pop()
+	require.NoError(t, err)
+	assert.Equal(t, model.MathJobPayload{Number: 8}, job.Payload)
+}
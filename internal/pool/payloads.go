@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// payloadKey identifies a payload by its type and the SHA-256 of its JSON
+// encoding
+type payloadKey [sha256.Size]byte
+
+// payloadTable shares one copy of each large payload between the stored jobs
+// holding an identical one, so a batch of near-identical submissions keeps
+// each distinct payload in memory once. Copies are counted by the jobs
+// referring to them and dropped once none does. The store's lock guards it.
+type payloadTable struct {
+	// threshold is the encoded size in bytes from which payloads are shared
+	threshold int
+	entries   map[payloadKey]*payloadEntry
+	// held is the entry each stored job refers to, by UID
+	held map[string]payloadKey
+}
+
+type payloadEntry struct {
+	payload model.JobPayload
+	// size is the payload's encoded size in bytes
+	size int
+	refs int
+}
+
+func newPayloadTable(threshold int) *payloadTable {
+	return &payloadTable{
+		threshold: threshold,
+		entries:   make(map[payloadKey]*payloadEntry),
+		held:      make(map[string]payloadKey),
+	}
+}
+
+// intern replaces the payload of the job stored as id with the copy held for
+// it, holding it if no other job has, and lets go of the one the job
+// referred to before. Payloads are treated as immutable once stored, so the
+// copy can be handed to every job.
+func (t *payloadTable) intern(id string, job *model.Job) {
+	old, had := t.held[id]
+	delete(t.held, id)
+	if key, size, ok := t.key(job.Payload); ok {
+		entry, exists := t.entries[key]
+		if exists {
+			job.Payload = entry.payload
+		} else {
+			entry = &payloadEntry{payload: job.Payload, size: size}
+			t.entries[key] = entry
+		}
+		entry.refs++
+		t.held[id] = key
+	}
+	// Let go last, so a job keeping its payload doesn't drop the entry
+	if had {
+		t.release(old)
+	}
+}
+
+// forget lets go of the payload of the job stored as id
+func (t *payloadTable) forget(id string) {
+	if key, had := t.held[id]; had {
+		delete(t.held, id)
+		t.release(key)
+	}
+}
+
+func (t *payloadTable) release(key payloadKey) {
+	entry := t.entries[key]
+	if entry.refs--; entry.refs <= 0 {
+		delete(t.entries, key)
+	}
+}
+
+// key identifies a payload large enough to share
+func (t *payloadTable) key(payload model.JobPayload) (payloadKey, int, bool) {
+	if payload == nil {
+		return payloadKey{}, 0, false
+	}
+	data, err := json.Marshal(payload)
+	if err != nil || len(data) < t.threshold {
+		return payloadKey{}, 0, false
+	}
+	h := sha256.New()
+	h.Write([]byte(payload.Type()))
+	h.Write([]byte{0})
+	h.Write(data)
+	var key payloadKey
+	h.Sum(key[:0])
+	return key, len(data), true
+}
+
+func (t *payloadTable) stats() *model.PayloadStats {
+	stats := &model.PayloadStats{ThresholdBytes: t.threshold, Unique: len(t.entries)}
+	for _, entry := range t.entries {
+		stats.Jobs += entry.refs
+		stats.BytesSaved += int64(entry.refs-1) * int64(entry.size)
+	}
+	return stats
+}
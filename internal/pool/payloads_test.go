@@ -0,0 +1,89 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_SharePayloads(t *testing.T) {
+	s := NewMemoryStore()
+	s.SharePayloads(64)
+	body := strings.Repeat("x", 100)
+	put := func(payload model.JobPayload) *model.Job {
+		job := &model.Job{UID: uuid.New(), Type: payload.Type(), Payload: payload, Status: model.JobStatusPending}
+		require.NoError(t, s.Put(job))
+		return job
+	}
+	stored := func(job *model.Job) model.HTTPJobPayload {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.jobs[job.UID.String()].Payload.(model.HTTPJobPayload)
+	}
+
+	size := func(payload model.JobPayload) int64 {
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+		return int64(len(data))
+	}
+
+	a := put(model.HTTPJobPayload{URL: "https://example.com", Body: body})
+	b := put(model.HTTPJobPayload{URL: "https://example.com", Body: strings.Clone(body)})
+	other := put(model.HTTPJobPayload{URL: "https://example.com", Body: body + "y"})
+	small := put(model.MathJobPayload{Number: 1})
+	assert.Equal(t, &model.PayloadStats{ThresholdBytes: 64, Unique: 2, Jobs: 3, BytesSaved: size(a.Payload)}, s.PayloadStats())
+	// b holds a's copy of the body rather than its own
+	assert.Same(t, unsafe.StringData(stored(a).Body), unsafe.StringData(stored(b).Body))
+
+	// Changing a payload lets go of the old one and shares the new
+	_, err := s.Update(b.UID.String(), 0, func(j *model.Job) error {
+		j.Payload = model.HTTPJobPayload{URL: "https://example.com", Body: body + "y"}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.PayloadStats().Unique)
+	assert.Same(t, unsafe.StringData(stored(other).Body), unsafe.StringData(stored(b).Body))
+
+	// Transitions and updates that leave the payload alone keep the count
+	_, err = s.Transition(a.UID.String(), UpdateStatus(model.JobStatusRunning, time.Now()))
+	require.NoError(t, err)
+	_, err = s.Update(a.UID.String(), 0, func(j *model.Job) error { j.Labels = map[string]string{"k": "v"}; return nil })
+	require.NoError(t, err)
+	assert.Equal(t, &model.PayloadStats{ThresholdBytes: 64, Unique: 2, Jobs: 3, BytesSaved: size(other.Payload)}, s.PayloadStats())
+
+	// Payloads are dropped once no job refers to them
+	for _, job := range []*model.Job{a, b, other, small} {
+		s.Delete(job.UID.String())
+	}
+	assert.Equal(t, &model.PayloadStats{ThresholdBytes: 64}, s.PayloadStats())
+	assert.Empty(t, s.payloads.held)
+}
+
+func TestWorkerPool_SharePayloads(t *testing.T) {
+	p := NewWorkerPool(context.Background(), 0, 10)
+	assert.Nil(t, p.Stats(context.Background()).Payloads)
+
+	p.SharePayloads(1)
+	for range 3 {
+		job := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: strings.Clone("1s")}, Status: model.JobStatusPending}
+		require.NoError(t, p.SubmitJob(context.Background(), job))
+	}
+	stats := p.Stats(context.Background()).Payloads
+	require.NotNil(t, stats)
+	assert.Equal(t, 1, stats.Unique)
+	assert.Equal(t, 3, stats.Jobs)
+
+	// Queued jobs share the stored copy too
+	all := func(*model.Job) bool { return true }
+	first, _ := p.jobQueue.tryTake(all)
+	second, _ := p.jobQueue.tryTake(all)
+	assert.Same(t, unsafe.StringData(first.Payload.(model.SleepJobPayload).Duration), unsafe.StringData(second.Payload.(model.SleepJobPayload).Duration))
+}
@@ -4,26 +4,91 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/dnakolan/worker-pool-service/internal/backoff"
+	"github.com/dnakolan/worker-pool-service/internal/blob"
 	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/secrets"
 )
 
+const (
+	DefaultLeaseTimeout = 30 * time.Second
+	DefaultMaxAttempts  = 3
+)
+
+var (
+	ErrJobNotFound     = errors.New("job not found")
+	ErrVersionConflict = errors.New("job version conflict")
+	ErrUnschedulable   = errors.New("no worker can run the job")
+)
+
+// Executor runs jobs of a particular type in place of the built-in
+// in-process execution. Output the job produces is written to logs as it
+// arrives.
+type Executor interface {
+	Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error)
+}
+
 type WorkerPool struct {
-	// Channels
-	jobQueue    chan *model.Job
+	// Queues
+	jobQueue    *jobQueue
 	resultQueue chan *model.Job
 	quit        chan struct{}
 
 	// State management
-	jobs      map[string]*model.Job
-	jobsMutex sync.RWMutex
+	store       JobStore
+	usage       *usageTracker
+	latency     *latencyTracker
+	leases      *leaseTable
+	logs        *logStore
+	faults      faults
+	maintenance maintenance
+	clock       Clock
+	events      *eventBus
+	deadlines   *deadlineTable
+	// conditional serializes submissions made unless an equivalent job
+	// exists, so two can't both find none
+	conditional sync.Mutex
+	coalescing  *coalesceTable
 
 	// Pool configuration
-	numWorkers int
-	wg         sync.WaitGroup
+	workers      []*worker
+	executors    map[string]Executor
+	hooks        []Hooks
+	node         string
+	labels       map[string]string
+	shadows      *shadowTable
+	splits       map[string]*executorSplit
+	timeouts     map[string]time.Duration
+	timeoutsMu   sync.RWMutex
+	defaults     map[string]model.JobTypeDefaults
+	costRates    map[string]float64
+	leaseTimeout time.Duration
+	maxAttempts  int
+	maxRetries   int
+	backoffs     map[string]backoff.Strategy
+	dispatch     *dispatchLimiter
+	large        *largeLane
+	concurrency  *concurrencyLimiter
+	adaptive     *adaptiveController
+	overflow     *overflowQueue
+	retention    *retentionTable
+	blobs        blob.Store
+	artifacts    *artifactTable
+	results      *resultCache
+	memos        *memoTable
+	secrets      secrets.Provider
+	// checkInterval is how often the bookkeeping is checked for
+	// inconsistencies, zero for never
+	checkInterval time.Duration
+	wg            sync.WaitGroup
 
 	// Context
 	ctx    context.Context
@@ -33,67 +98,405 @@ type WorkerPool struct {
 func NewWorkerPool(ctx context.Context, numWorkers int, poolSize int) *WorkerPool {
 	ctx, cancel := context.WithCancel(ctx)
 
-	return &WorkerPool{
-		jobQueue:    make(chan *model.Job, poolSize),
-		resultQueue: make(chan *model.Job, poolSize),
-		quit:        make(chan struct{}),
-		jobs:        make(map[string]*model.Job),
-		numWorkers:  numWorkers,
-		wg:          sync.WaitGroup{},
-		ctx:         ctx,
-		cancel:      cancel,
+	p := &WorkerPool{
+		jobQueue:     newJobQueue(poolSize),
+		resultQueue:  make(chan *model.Job, poolSize),
+		quit:         make(chan struct{}),
+		store:        NewMemoryStore(),
+		usage:        newUsageTracker(),
+		latency:      newLatencyTracker(),
+		leases:       newLeaseTable(),
+		logs:         newLogStore(),
+		events:       newEventBus(),
+		deadlines:    newDeadlineTable(),
+		clock:        realClock{},
+		executors:    make(map[string]Executor),
+		shadows:      newShadowTable(),
+		splits:       make(map[string]*executorSplit),
+		timeouts:     make(map[string]time.Duration),
+		defaults:     make(map[string]model.JobTypeDefaults),
+		costRates:    make(map[string]float64),
+		backoffs:     make(map[string]backoff.Strategy),
+		artifacts:    newArtifactTable(),
+		results:      newResultCache(),
+		memos:        newMemoTable(),
+		large:        newLargeLane(),
+		concurrency:  newConcurrencyLimiter(),
+		adaptive:     newAdaptiveController(),
+		retention:    newRetentionTable(),
+		coalescing:   newCoalesceTable(),
+		leaseTimeout: DefaultLeaseTimeout,
+		maxAttempts:  DefaultMaxAttempts,
+		wg:           sync.WaitGroup{},
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+	p.dispatch = newDispatchLimiter(p.clock, p.jobQueue.wake, p.quit)
+	p.AddWorkers(numWorkers)
+	return p
+}
+
+// SetWorkerCapacity sets how many slots each local worker has. A worker
+// runs as many jobs at once as their weights fit in its slots. It must be
+// called before Start.
+func (p *WorkerPool) SetWorkerCapacity(slots int) {
+	for _, w := range p.workers {
+		w.capacity = slots
+	}
+}
+
+// AddWorkers adds count workers offering the given capabilities. It must be
+// called before Start.
+func (p *WorkerPool) AddWorkers(count int, capabilities ...string) {
+	for i := 0; i < count; i++ {
+		w := newWorker(len(p.workers), capabilities)
+		w.capacity = DefaultWorkerCapacity
+		w.labels = p.labels
+		p.workers = append(p.workers, w)
 	}
 }
 
+// SetNodeName names this instance on the jobs it accepts. It must be
+// called before Start.
+func (p *WorkerPool) SetNodeName(name string) {
+	p.node = name
+}
+
+// NodeName is the name this instance goes by
+func (p *WorkerPool) NodeName() string {
+	return p.node
+}
+
+// SetInstanceLabels describes where this instance runs, such as its
+// region, zone or hardware. Its local workers only run jobs placed on
+// labels these match. It must be called before Start.
+func (p *WorkerPool) SetInstanceLabels(labels map[string]string) {
+	p.labels = maps.Clone(labels)
+	for _, w := range p.workers {
+		w.labels = p.labels
+	}
+}
+
+// SetStore replaces the in-memory job store. It must be called before any
+// jobs are submitted.
+func (p *WorkerPool) SetStore(s JobStore) {
+	p.store = s
+}
+
+// SharePayloads makes jobs whose payloads encode to at least threshold bytes
+// share one copy of each distinct payload in the in-memory store. Zero
+// turns sharing off. It must be called before any jobs are submitted, and
+// after SetStore if that's called.
+func (p *WorkerPool) SharePayloads(threshold int) {
+	if s, ok := p.store.(*MemoryStore); ok {
+		s.SharePayloads(threshold)
+	}
+}
+
+// RegisterExecutor routes jobs of the given type to e. It must be called
+// before Start.
+func (p *WorkerPool) RegisterExecutor(jobType string, e Executor) {
+	p.executors[jobType] = e
+}
+
+// SetLeaseTimeout sets how long a remote worker may go without a heartbeat
+// before its job is reassigned. It must be called before Start.
+func (p *WorkerPool) SetLeaseTimeout(d time.Duration) {
+	p.leaseTimeout = d
+}
+
+// SetMaxAttempts bounds how many times a job is handed out before it is
+// failed. It must be called before Start.
+func (p *WorkerPool) SetMaxAttempts(n int) {
+	p.maxAttempts = n
+}
+
+// SubmitJob stores and queues the job. It either commits the job or returns
+// an error and leaves no trace of it: a job whose ctx is cancelled before it
+// is committed is discarded, and one committed runs even if the caller has
+// gone away by the time SubmitJob returns. Settings the job leaves unset
+// are filled in from its type's defaults.
 func (p *WorkerPool) SubmitJob(ctx context.Context, job *model.Job) error {
-	select {
-	case p.jobQueue <- job:
-		p.storeJob(job)
+	return p.submit(ctx, job, false)
+}
+
+// submit is SubmitJob. A held job is stored with a place in the queue
+// reserved for it, but is only queued once its CoalesceUntil has passed.
+func (p *WorkerPool) submit(ctx context.Context, job *model.Job, held bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+	if err := p.beforeSubmit(ctx, job); err != nil {
+		return err
+	}
+	if (len(job.Requires) > 0 || len(job.Placement) > 0 || job.Slots() > 1) && !p.schedulable(job) {
+		return fmt.Errorf("%w: %s", ErrUnschedulable, unschedulableReason(job))
+	}
+	if err := p.faults.delaySubmission(ctx, p.clock); err != nil {
+		return err
+	}
+	if err := p.faults.storageError(); err != nil {
+		return err
+	}
+	// Nothing below gives up with the caller, so this is the last chance to
+	// discard the job
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// A job an identical one already ran for is stored completed and never
+	// reaches the queue
+	job.Version = 1
+	job.Node = p.node
+	p.applyDefaults(job)
+	if !held && p.completeFromCache(job) {
+		if err := p.store.Put(job); err != nil {
+			return err
+		}
+		p.events.publish(job, "", p.clock.Now())
+		p.retain(job)
+		p.afterComplete(job)
 		return nil
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-p.ctx.Done():
-		return p.ctx.Err()
-	default:
+	}
+
+	// Store the job and announce it before it becomes visible to workers,
+	// so its submission is always the first event they see
+	job.Lane = p.large.classify(job)
+	if p.overflow != nil && job.Lane == "" && !held {
+		// Once jobs have spilled, later ones follow them so they are
+		// queued in the order they were submitted
+		if p.overflow.len() > 0 || !p.jobQueue.reserve("") {
+			if err := p.spill(job); err != nil {
+				return err
+			}
+			p.afterEnqueue(ctx, job)
+			return nil
+		}
+	} else if !p.jobQueue.reserve(job.Lane) {
+		if job.Lane == model.JobLaneLarge {
+			return errors.New("large job queue is full")
+		}
 		return errors.New("job queue is full")
 	}
+	if err := p.store.Put(job); err != nil {
+		p.jobQueue.release(job.Lane)
+		return err
+	}
+	p.events.publish(job, "", p.clock.Now())
+	p.trackDeadline(job)
+	if held {
+		p.releaseAt(job, *job.CoalesceUntil)
+	} else {
+		// The queue gets a copy of its own, which workers refresh as the
+		// job changes, leaving the caller's as it was submitted. The
+		// stored copy is taken where there is one, so the queued job
+		// shares its payload if the store does.
+		queued, ok := p.store.Get(job.UID.String())
+		if !ok {
+			queued = job.Clone()
+		}
+		p.jobQueue.pushReserved(queued)
+	}
+	p.afterEnqueue(ctx, job)
+	return nil
+}
+
+// schedulable reports whether any local or known remote worker could run
+// the job
+func (p *WorkerPool) schedulable(job *model.Job) bool {
+	for _, w := range p.workers {
+		if w.accepts(job) {
+			return true
+		}
+	}
+
+	p.leases.mu.Lock()
+	defer p.leases.mu.Unlock()
+	for _, w := range p.leases.workers {
+		if w.accepts(job) {
+			return true
+		}
+	}
+	return false
+}
+
+func unschedulableReason(job *model.Job) string {
+	var reasons []string
+	if len(job.Requires) > 0 {
+		reasons = append(reasons, "requires "+strings.Join(job.Requires, ", "))
+	}
+	if len(job.Placement) > 0 {
+		var placement []string
+		for _, k := range slices.Sorted(maps.Keys(job.Placement)) {
+			placement = append(placement, k+"="+job.Placement[k])
+		}
+		reasons = append(reasons, "placed on "+strings.Join(placement, ", "))
+	}
+	if job.Slots() > 1 {
+		reasons = append(reasons, fmt.Sprintf("weight %d", job.Slots()))
+	}
+	return strings.Join(reasons, "; ")
 }
 
 func (p *WorkerPool) GetJob(ctx context.Context, id string) (*model.Job, bool) {
-	p.jobsMutex.RLock()
-	defer p.jobsMutex.RUnlock()
-	job, exists := p.jobs[id]
-	return job, exists
+	return p.store.Get(id)
 }
 
-func (p *WorkerPool) GetAllJobs(ctx context.Context, filter *model.JobFilter) []*model.Job {
-	p.jobsMutex.RLock()
-	defer p.jobsMutex.RUnlock()
-	jobs := make([]*model.Job, 0)
-	for _, v := range p.jobs {
-		if filter.Type != nil && *filter.Type != v.Type {
-			continue
+// UpdateJob applies fn to the stored job and bumps its version. When
+// expectedVersion is non-zero the update is rejected with ErrVersionConflict
+// unless it matches the stored version, so concurrent writers cannot clobber
+// each other.
+func (p *WorkerPool) UpdateJob(ctx context.Context, id string, expectedVersion int64, fn func(job *model.Job) error) (*model.Job, error) {
+	if err := p.faults.storageError(); err != nil {
+		return nil, err
+	}
+	var from model.JobStatus
+	job, err := p.store.Update(id, expectedVersion, func(j *model.Job) error {
+		from = j.Status
+		return fn(j)
+	})
+	if err == nil && job.Status != from {
+		p.events.publish(job, from, p.clock.Now())
+	}
+	return job, err
+}
+
+// patchAttempts bounds how often PatchJob starts over when the job changes
+// while its patch is being checked
+const patchAttempts = 5
+
+// PatchJob applies fn to a copy of the stored job and holds the result to
+// the checks a submission goes through, running the BeforeSubmit hooks
+// again, before it replaces the stored job. A payload that would move the
+// job to another lane is refused, since the job keeps its place in the
+// queue, as is a priority the hooks choose. The checks run outside the
+// store, so when the job changes meanwhile the patch is applied afresh,
+// unless expectedVersion asks for the version it was checked against.
+func (p *WorkerPool) PatchJob(ctx context.Context, id string, expectedVersion int64, fn func(job *model.Job) error) (*model.Job, error) {
+	for range patchAttempts {
+		current, exists := p.store.Get(id)
+		if !exists {
+			return nil, ErrJobNotFound
+		}
+		if expectedVersion != 0 && current.Version != expectedVersion {
+			return nil, ErrVersionConflict
+		}
+		patched := current.Clone()
+		if err := fn(patched); err != nil {
+			return nil, err
 		}
-		if filter.Status != nil && *filter.Status != v.Status {
-			continue
+		if err := p.beforeSubmit(ctx, patched); err != nil {
+			return nil, err
 		}
-		jobs = append(jobs, v)
+		if p.large.classify(patched) != current.Lane {
+			return nil, fmt.Errorf("%w: the payload would move the job to another lane", model.ErrJobNotMutable)
+		}
+		patched.Priority = current.Priority
+		job, err := p.UpdateJob(ctx, id, current.Version, func(job *model.Job) error {
+			*job = *patched
+			return nil
+		})
+		if !errors.Is(err, ErrVersionConflict) {
+			return job, err
+		}
+	}
+	return nil, ErrVersionConflict
+}
+
+func (p *WorkerPool) GetAllJobs(ctx context.Context, filter *model.JobFilter) []*model.Job {
+	return p.store.List(filter)
+}
+
+// SearchJobs returns up to limit jobs matching the compound query, oldest
+// first, starting after the cursor when one is given
+func (p *WorkerPool) SearchJobs(ctx context.Context, query *model.JobQuery, after *model.JobCursor, limit int) []*model.Job {
+	return p.store.Search(query, after, limit)
+}
+
+// TenantUsage returns the execution time the tenant has used today
+func (p *WorkerPool) TenantUsage(ctx context.Context, tenant string) time.Duration {
+	return p.usage.used(tenant, p.clock.Now())
+}
+
+func (p *WorkerPool) Stats(ctx context.Context) *model.PoolStats {
+	stats := &model.PoolStats{
+		Workers:             len(p.workers),
+		QueueDepth:          p.jobQueue.len(""),
+		QueueCapacity:       p.jobQueue.capacity,
+		LargeJobs:           p.large.stats(p.jobQueue),
+		Jobs:                p.store.CountByStatus(),
+		TenantUsage:         make(map[string]model.TenantUsage),
+		ResultCache:         p.results.stats(),
+		Memo:                p.memoStats(),
+		Variants:            p.variantStats(),
+		Latency:             p.latency.stats(),
+		AdaptiveConcurrency: p.adaptive.stats(),
+	}
+	if p.overflow != nil {
+		stats.Overflow = p.overflow.stats()
+	}
+	stats.Retention = p.retention.stats()
+	if s, ok := p.store.(interface{ PayloadStats() *model.PayloadStats }); ok {
+		stats.Payloads = s.PayloadStats()
+	}
+	for _, w := range p.workers {
+		ws := w.stats()
+		stats.Slots += ws.Capacity
+		stats.SlotsInUse += ws.SlotsInUse
+		stats.WorkerDetails = append(stats.WorkerDetails, ws)
+	}
+	for tenant, used := range p.usage.snapshot(p.clock.Now()) {
+		stats.TenantUsage[tenant] = model.TenantUsage{ExecutionSeconds: used.Seconds()}
 	}
-	return jobs
+	return stats
 }
 
 func (p *WorkerPool) Start() {
-	slog.Info("Starting worker pool", "workers", p.numWorkers)
+	slog.Info("Starting worker pool", "workers", len(p.workers))
+	p.restoreJobTypeConfigs()
+	if p.overflow != nil {
+		p.recoverOverflow()
+		p.wg.Add(1)
+		go p.refill()
+	}
 
 	// Start workers
-	for i := 0; i < p.numWorkers; i++ {
+	for _, w := range p.workers {
 		p.wg.Add(1)
-		go p.worker(i)
+		go p.worker(w)
 	}
 
 	// Start result processor
 	p.wg.Add(1)
 	go p.resultProcessor()
+
+	// Start lease reaper for remote workers
+	p.wg.Add(1)
+	go p.leaseReaper()
+
+	p.wg.Add(1)
+	go p.deadlineReaper()
+
+	p.wg.Add(1)
+	go p.retentionReaper()
+
+	if p.blobs != nil {
+		p.wg.Add(1)
+		go p.artifactReaper()
+	}
+
+	if p.checkInterval > 0 {
+		p.wg.Add(1)
+		go p.consistencyChecker()
+	}
+
+	if len(p.maintenance.windows) > 0 {
+		p.wg.Add(1)
+		go p.maintenanceWatcher()
+	}
 }
 
 func (p *WorkerPool) Stop() {
@@ -101,86 +504,243 @@ func (p *WorkerPool) Stop() {
 	p.cancel()
 	close(p.quit)
 	p.wg.Wait()
-	close(p.jobQueue)
 	close(p.resultQueue)
+	if p.overflow != nil {
+		p.overflow.close()
+	}
 }
 
-// Core worker goroutine
-func (p *WorkerPool) worker(id int) {
-	defer p.wg.Done()
+// Drain waits until no job is pending or running, or ctx is done. Callers stop
+// accepting submissions first.
+func (p *WorkerPool) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
 
 	for {
+		// Count from the store rather than the queue: a job a worker has
+		// just dequeued is still pending until it starts running
+		counts := p.store.CountByStatus()
+		if counts[model.JobStatusPending] == 0 && counts[model.JobStatusRunning] == 0 {
+			return nil
+		}
 		select {
-		case job := <-p.jobQueue:
-			p.processJob(id, job)
-		case <-p.quit:
-			slog.Info("Worker shutting down", "worker_id", id)
-			return
-		case <-p.ctx.Done():
-			slog.Info("Worker context cancelled", "worker_id", id)
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Core worker goroutine. Each job runs on its own goroutine holding as many
+// of the worker's slots as its weight, so light jobs share the worker while
+// a heavy one may need all of it.
+func (p *WorkerPool) worker(w *worker) {
+	defer p.wg.Done()
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	for {
+		job, ok := p.jobQueue.take(p.ctx, p.quit, p.dispatchable(p.routed(w, w.fits)))
+		if !ok {
+			slog.Info("Worker shutting down", "worker_id", w.id)
 			return
 		}
+		if job.SerializationKey != "" {
+			w.routedKey(job.SerializationKey)
+		}
+
+		slots := job.Slots()
+		w.acquire(slots)
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			p.processJob(w.id, job)
+			w.release(slots)
+			// Freed slots may let this worker take a job it couldn't before
+			p.jobQueue.wake()
+		}()
 	}
 }
 
+// ProcessNext runs the oldest queued job on the calling goroutine, reporting
+// false if the queue is empty. It lets tests drive a pool that was never
+// started, one job at a time.
+func (p *WorkerPool) ProcessNext() bool {
+	job, ok := p.jobQueue.tryTake(p.dispatchable(func(*model.Job) bool { return true }))
+	if !ok {
+		return false
+	}
+	p.runJob(-1, job)
+	slog.Info("Job completed", "job_id", job.UID, "status", job.Status)
+	return true
+}
+
 func (p *WorkerPool) processJob(workerID int, job *model.Job) {
+	if !p.runJob(workerID, job) {
+		return
+	}
+
+	// Send to result processor
+	select {
+	case p.resultQueue <- job:
+	case <-p.ctx.Done():
+		return
+	}
+}
+
+// runJob executes a job and records its outcome, reporting false if the job
+// was queued to be retried
+func (p *WorkerPool) runJob(workerID int, job *model.Job) bool {
 	slog.Info("Processing job", "worker_id", workerID, "job_id", job.UID)
 
 	// Update job status
-	now := time.Now()
-	job.Status = model.JobStatusRunning
-	job.StartedAt = &now
-	p.storeJob(job)
+	now := p.clock.Now()
+	p.transition(job, UpdateStatus(model.JobStatusRunning, now), func(j *model.Job) {
+		j.Attempts = append(j.Attempts, model.JobAttempt{
+			Worker:    fmt.Sprintf("local-%d", workerID),
+			StartedAt: now,
+		})
+	})
+	p.latency.started(job)
 
 	// Execute the job
-	result, err := p.executeJob(job)
+	var result model.JobResult
+	var err error
+	if p.faults.workerCrash() {
+		err = errInjectedCrash
+	} else {
+		compare := p.startShadow(job)
+		started := p.clock.Now()
+		result, err = p.executeJob(job)
+		compare(result, err, p.clock.Now().Sub(started))
+	}
 
 	// Update final status
-	completedAt := time.Now()
-	job.CompletedAt = &completedAt
+	return p.finishJob(job, result, err)
+}
 
-	if err != nil {
-		job.Status = model.JobStatusFailed
-		job.Error = err.Error()
-	} else {
-		job.Status = model.JobStatusCompleted
-		job.Result = result
+// finishJob records a job's outcome and charges its run time to the tenant.
+// A failed job with retries left is queued again instead, and false is
+// returned.
+func (p *WorkerPool) finishJob(job *model.Job, result model.JobResult, err error) bool {
+	var elapsed time.Duration
+	retry := false
+	var delay time.Duration
+	completedAt := p.clock.Now()
+	p.transition(job, func(j *model.Job) {
+		if j.StartedAt != nil {
+			elapsed = completedAt.Sub(*j.StartedAt)
+		}
+
+		if err != nil {
+			p.endAttempt(j, completedAt, model.AttemptFailed, err)
+			if retry = p.shouldRetry(j, err); retry {
+				delay = p.retryJob(j, err, completedAt)
+				return
+			}
+			SetResult(nil, err, completedAt)(j)
+		} else {
+			SetResult(result, nil, completedAt)(j)
+			p.endAttempt(j, completedAt, model.AttemptCompleted, nil)
+		}
+	})
+	if err == nil {
+		p.results.add(job, result, completedAt)
+	}
+	p.usage.record(job.Tenant, elapsed, completedAt)
+	p.adapt(job, err)
+	if retry {
+		p.requeueAfter(job, delay)
+		slog.Warn("Job failed, retrying", "job_id", job.UID, "attempts", len(job.Attempts), "delay", delay, "error", err)
+		return false
 	}
+	p.jobQueue.finish(job)
+	p.logs.close(job.UID.String())
+	return true
+}
 
-	// Send to result processor
-	select {
-	case p.resultQueue <- job:
-	case <-p.ctx.Done():
+// endAttempt closes the job's current attempt, recording err if it failed.
+// An attempt whose executor reported no cost is charged for its run time at
+// the job type's rate.
+func (p *WorkerPool) endAttempt(j *model.Job, at time.Time, outcome model.AttemptOutcome, err error) {
+	if len(j.Attempts) == 0 {
 		return
 	}
+	attempt := &j.Attempts[len(j.Attempts)-1]
+	attempt.EndedAt = &at
+	attempt.Outcome = outcome
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	p.latency.ended(j.Type, *attempt)
+	if rate, ok := p.costRates[j.Type]; ok && attempt.Cost == nil {
+		cost := at.Sub(attempt.StartedAt).Seconds() * rate
+		attempt.Cost = &cost
+		j.Cost += cost
+	}
 }
 
 func (p *WorkerPool) executeJob(job *model.Job) (model.JobResult, error) {
+	ctx, cancel := p.withJobTimeout(job)
+	defer cancel()
+	ctx = context.WithValue(ctx, annotatorKey{}, &annotator{pool: p, jobID: job.UID.String(), jobType: job.Type})
+	if err := p.beforeExecute(ctx, job); err != nil {
+		return nil, err
+	}
+	resolved, err := p.withSecrets(ctx, job, p.executorFor(job))
+	if err != nil {
+		return nil, err
+	}
+	result, err := p.execute(ctx, resolved)
+	return result, p.timeoutError(ctx, job, err)
+}
+
+// executorFor returns the executor that will run job, nil for the built-in
+// implementation
+func (p *WorkerPool) executorFor(job *model.Job) Executor {
+	if split, ok := p.splits[job.Type]; ok {
+		return split.variants[split.pick(job)].Executor
+	}
+	return p.executors[job.Type]
+}
+
+func (p *WorkerPool) execute(ctx context.Context, job *model.Job) (model.JobResult, error) {
+	if split, ok := p.splits[job.Type]; ok {
+		return p.executeSplit(ctx, split, job)
+	}
+	if e, ok := p.executors[job.Type]; ok {
+		return e.Execute(ctx, job, p.logs.open(job.UID.String()))
+	}
+	return p.executeBuiltin(ctx, job)
+}
+
+// executeBuiltin runs a job with its type's built-in implementation
+func (p *WorkerPool) executeBuiltin(ctx context.Context, job *model.Job) (model.JobResult, error) {
 	switch job.Type {
 	case "sleep":
 		payload, ok := job.Payload.(model.SleepJobPayload)
 		if !ok {
-			return nil, errors.New("invalid sleep payload type")
+			return nil, Permanent(errors.New("invalid sleep payload type"))
 		}
 
 		duration, err := time.ParseDuration(payload.Duration)
 		if err != nil {
-			return nil, fmt.Errorf("invalid duration: %w", err)
+			return nil, Permanent(fmt.Errorf("invalid duration: %w", err))
 		}
 
 		select {
-		case <-time.After(duration):
+		case <-p.clock.After(duration):
 			return model.SleepJobResult{
 				SleptFor: duration.String(),
 			}, nil
-		case <-p.ctx.Done():
-			return nil, p.ctx.Err()
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
 
 	case "math":
 		payload, ok := job.Payload.(model.MathJobPayload)
 		if !ok {
-			return nil, errors.New("invalid math payload type")
+			return nil, Permanent(errors.New("invalid math payload type"))
 		}
 
 		result := 0
@@ -191,8 +751,14 @@ func (p *WorkerPool) executeJob(job *model.Job) (model.JobResult, error) {
 			Result: result,
 		}, nil
 
+	case "container":
+		return nil, Permanent(errors.New("no container runtime is configured"))
+
+	case "http":
+		return nil, Permanent(errors.New("no HTTP client is configured"))
+
 	default:
-		return nil, errors.New("unknown job type")
+		return nil, Permanent(errors.New("unknown job type"))
 	}
 }
 
@@ -202,7 +768,6 @@ func (p *WorkerPool) resultProcessor() {
 	for {
 		select {
 		case job := <-p.resultQueue:
-			p.storeJob(job)
 			slog.Info("Job completed", "job_id", job.UID, "status", job.Status)
 		case <-p.quit:
 			return
@@ -212,8 +777,37 @@ func (p *WorkerPool) resultProcessor() {
 	}
 }
 
-func (p *WorkerPool) storeJob(job *model.Job) {
-	p.jobsMutex.Lock()
-	defer p.jobsMutex.Unlock()
-	p.jobs[job.UID.String()] = job
+// transition applies changes to a stored job in one write and bumps its
+// version, announcing status changes while the store still holds the job.
+// job is the caller's copy, which is refreshed from the store afterwards.
+func (p *WorkerPool) transition(job *model.Job, changes ...func(j *model.Job)) {
+	stopped, finished := false, false
+	updated, err := p.store.Transition(job.UID.String(), func(j *model.Job) {
+		from := j.Status
+		for _, change := range changes {
+			change(j)
+		}
+		if j.Status != from {
+			p.events.publish(j, from, p.clock.Now())
+		}
+		stopped = from == model.JobStatusRunning && j.Status != from
+		finished = !from.Finished() && j.Status.Finished()
+	})
+	if err != nil {
+		slog.Error("Failed to update job", "job_id", job.UID, "error", err)
+		return
+	}
+	// A job that stopped running may free a large slot or a place under
+	// its type's concurrency limit that another is waiting for
+	if stopped {
+		freedLarge := p.large.stop(job)
+		if p.concurrency.stop(job) || freedLarge {
+			p.jobQueue.wake()
+		}
+	}
+	*job = *updated
+	if finished {
+		p.retain(job)
+		p.afterComplete(job)
+	}
 }
@@ -10,22 +10,6 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// waitForJobStatus waits for a job to reach a specific status with timeout
-func waitForJobStatus(t *testing.T, pool *WorkerPool, jobID string, expectedStatus model.JobStatus) *model.Job {
-	t.Helper()
-	deadline := time.Now().Add(2 * time.Second)
-	for time.Now().Before(deadline) {
-		if job, exists := pool.GetJob(context.Background(), jobID); exists {
-			if job.Status == expectedStatus {
-				return job
-			}
-		}
-		time.Sleep(50 * time.Millisecond)
-	}
-	t.Fatalf("Job %s did not reach status %s within timeout", jobID, expectedStatus)
-	return nil
-}
-
 // waitForNJobsWithStatus waits for n jobs to reach a specific status
 func waitForNJobsWithStatus(t *testing.T, pool *WorkerPool, n int, status model.JobStatus) {
 	t.Helper()
@@ -45,36 +29,6 @@ func waitForNJobsWithStatus(t *testing.T, pool *WorkerPool, n int, status model.
 	t.Fatalf("Did not reach %d jobs with status %s within timeout", n, status)
 }
 
-func TestWorkerPool_Basic(t *testing.T) {
-	// Create a pool with 2 workers and queue size of 5
-	ctx := context.Background()
-	pool := NewWorkerPool(ctx, 2, 5)
-	pool.Start()
-	defer pool.Stop()
-
-	// Create a test job
-	job := &model.Job{
-		UID:     uuid.New(),
-		Type:    "sleep",
-		Payload: model.SleepJobPayload{Duration: "100ms"},
-		Status:  model.JobStatusPending,
-	}
-
-	// Submit the job
-	err := pool.SubmitJob(ctx, job)
-	assert.NoError(t, err)
-
-	// Wait for job completion
-	completedJob := waitForJobStatus(t, pool, job.UID.String(), model.JobStatusCompleted)
-	assert.NotNil(t, completedJob.StartedAt)
-	assert.NotNil(t, completedJob.CompletedAt)
-
-	// Verify result
-	result, ok := completedJob.Result.(model.SleepJobResult)
-	assert.True(t, ok)
-	assert.Equal(t, "100ms", result.SleptFor)
-}
-
 func TestWorkerPool_Concurrent(t *testing.T) {
 	// Create a pool with 3 workers and queue size of 10
 	ctx := context.Background()
@@ -129,37 +83,6 @@ func TestWorkerPool_Concurrent(t *testing.T) {
 	}
 }
 
-func TestWorkerPool_Cancellation(t *testing.T) {
-	// Create a pool with context
-	ctx, cancel := context.WithCancel(context.Background())
-	pool := NewWorkerPool(ctx, 2, 5)
-	pool.Start()
-	defer pool.Stop()
-
-	// Create a long-running job
-	job := &model.Job{
-		UID:     uuid.New(),
-		Type:    "sleep",
-		Payload: model.SleepJobPayload{Duration: "5s"},
-		Status:  model.JobStatusPending,
-	}
-
-	// Submit the job
-	err := pool.SubmitJob(ctx, job)
-	assert.NoError(t, err)
-
-	// Wait for job to start
-	runningJob := waitForJobStatus(t, pool, job.UID.String(), model.JobStatusRunning)
-	assert.NotNil(t, runningJob)
-
-	// Cancel context
-	cancel()
-
-	// Wait for job to fail
-	failedJob := waitForJobStatus(t, pool, job.UID.String(), model.JobStatusFailed)
-	assert.Contains(t, failedJob.Error, "context canceled")
-}
-
 func TestWorkerPool_QueueFull(t *testing.T) {
 	// Create a pool with small queue and NO workers
 	ctx := context.Background()
@@ -256,31 +179,68 @@ func TestWorkerPool_GetAllJobs(t *testing.T) {
 	}
 }
 
-func TestWorkerPool_InvalidJobType(t *testing.T) {
+func TestWorkerPool_UpdateJob(t *testing.T) {
 	ctx := context.Background()
-	pool := NewWorkerPool(ctx, 1, 5)
-	pool.Start()
-	defer pool.Stop()
+	pool := NewWorkerPool(ctx, 0, 5)
 
-	// Create a job with invalid type
 	job := &model.Job{
 		UID:     uuid.New(),
-		Type:    "invalid",
-		Payload: model.SleepJobPayload{Duration: "100ms"},
+		Type:    "sleep",
+		Payload: model.SleepJobPayload{Duration: "1s"},
 		Status:  model.JobStatusPending,
 	}
-
-	// Submit the job
 	err := pool.SubmitJob(ctx, job)
 	assert.NoError(t, err)
 
-	// Wait for job to fail
-	failedJob := waitForJobStatus(t, pool, job.UID.String(), model.JobStatusFailed)
-	assert.Contains(t, failedJob.Error, "unknown job type")
+	tests := []struct {
+		name            string
+		id              string
+		expectedVersion int64
+		wantVersion     int64
+		wantErr         error
+	}{
+		{
+			name:            "matching version",
+			id:              job.UID.String(),
+			expectedVersion: 1,
+			wantVersion:     2,
+		},
+		{
+			name:            "stale version",
+			id:              job.UID.String(),
+			expectedVersion: 1,
+			wantErr:         ErrVersionConflict,
+		},
+		{
+			name:            "unconditional update",
+			id:              job.UID.String(),
+			expectedVersion: 0,
+			wantVersion:     3,
+		},
+		{
+			name:    "unknown job",
+			id:      uuid.New().String(),
+			wantErr: ErrJobNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := pool.UpdateJob(ctx, tt.id, tt.expectedVersion, func(j *model.Job) error {
+				return nil
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantVersion, updated.Version)
+			}
+		})
+	}
 }
 
 func TestExecuteJob(t *testing.T) {
-	pool := &WorkerPool{}
+	pool := NewWorkerPool(context.Background(), 0, 1)
 
 	tests := []struct {
 		name    string
@@ -336,7 +296,7 @@ func TestExecuteJob(t *testing.T) {
 
 func TestGetAllJobs_Filtering(t *testing.T) {
 	pool := &WorkerPool{
-		jobs: make(map[string]*model.Job),
+		store: NewMemoryStore(),
 	}
 
 	// Create test jobs
@@ -352,8 +312,8 @@ func TestGetAllJobs_Filtering(t *testing.T) {
 	}
 
 	// Store jobs
-	pool.jobs[sleepJob.UID.String()] = sleepJob
-	pool.jobs[mathJob.UID.String()] = mathJob
+	pool.store.Put(sleepJob)
+	pool.store.Put(mathJob)
 
 	// Test cases
 	tests := []struct {
@@ -421,3 +381,44 @@ func stringPtr(s string) *string {
 func jobStatusPtr(s model.JobStatus) *model.JobStatus {
 	return &s
 }
+
+func TestWorkerPool_Drain(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 5)
+	pool.Start()
+	defer pool.Stop()
+
+	var jobs []*model.Job
+	for i := 0; i < 3; i++ {
+		job := &model.Job{
+			UID:     uuid.New(),
+			Type:    "sleep",
+			Payload: model.SleepJobPayload{Duration: "50ms"},
+			Status:  model.JobStatusPending,
+		}
+		assert.NoError(t, pool.SubmitJob(ctx, job))
+		jobs = append(jobs, job)
+	}
+
+	drainCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	assert.NoError(t, pool.Drain(drainCtx))
+	for _, job := range jobs {
+		stored, _ := pool.GetJob(ctx, job.UID.String())
+		assert.Equal(t, model.JobStatusCompleted, stored.Status)
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		job := &model.Job{
+			UID:     uuid.New(),
+			Type:    "sleep",
+			Payload: model.SleepJobPayload{Duration: "1s"},
+			Status:  model.JobStatusPending,
+		}
+		assert.NoError(t, pool.SubmitJob(ctx, job))
+
+		drainCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, pool.Drain(drainCtx), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,127 @@
+// Package pooltest provides helpers for testing code built on the worker pool.
+package pooltest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+// Clock is a fake pool.Clock whose time only moves when Advance is called.
+// Timers and tickers fire synchronously during Advance, in deadline order.
+type Clock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*waiter
+}
+
+var _ pool.Clock = (*Clock)(nil)
+
+type waiter struct {
+	at     time.Time
+	period time.Duration // zero for one-shot timers
+	ch     chan time.Time
+}
+
+// NewClock returns a fake clock set to start
+func NewClock(start time.Time) *Clock {
+	c := &Clock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.add(&waiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+func (c *Clock) NewTicker(d time.Duration) pool.Ticker {
+	if d <= 0 {
+		panic("pooltest: non-positive interval for NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &waiter{at: c.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	c.add(w)
+	return &ticker{clock: c, w: w}
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker that
+// falls due along the way
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	end := c.now.Add(d)
+
+	for len(c.waiters) > 0 && !c.waiters[0].at.After(end) {
+		w := c.waiters[0]
+		c.waiters = c.waiters[1:]
+		c.now = w.at
+
+		// Like time.Ticker, a slow reader misses ticks rather than queueing them
+		select {
+		case w.ch <- w.at:
+		default:
+		}
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+			c.add(w)
+		}
+	}
+	c.now = end
+}
+
+// BlockUntil waits until at least n timers and tickers are pending, so a test
+// can be sure the code under test is waiting before it advances the clock
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// add registers a waiter, keeping the list sorted by deadline. c.mu must be
+// held.
+func (c *Clock) add(w *waiter) {
+	c.waiters = append(c.waiters, w)
+	sort.SliceStable(c.waiters, func(i, j int) bool {
+		return c.waiters[i].at.Before(c.waiters[j].at)
+	})
+	c.cond.Broadcast()
+}
+
+func (c *Clock) remove(w *waiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+type ticker struct {
+	clock *Clock
+	w     *waiter
+}
+
+func (t *ticker) C() <-chan time.Time { return t.w.ch }
+func (t *ticker) Stop()               { t.clock.remove(t.w) }
@@ -0,0 +1,81 @@
+package pooltest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestClock_After(t *testing.T) {
+	clock := NewClock(epoch)
+	ch := clock.After(time.Minute)
+
+	clock.Advance(59 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	assert.Equal(t, epoch.Add(time.Minute), <-ch)
+	assert.Equal(t, epoch.Add(time.Minute), clock.Now())
+
+	// Non-positive durations fire immediately
+	assert.Equal(t, clock.Now(), <-clock.After(0))
+}
+
+func TestClock_Ticker(t *testing.T) {
+	clock := NewClock(epoch)
+	ticker := clock.NewTicker(10 * time.Second)
+
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, epoch.Add(10*time.Second), <-ticker.C())
+
+	// Ticks nobody reads are dropped rather than queued
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, epoch.Add(20*time.Second), <-ticker.C())
+	select {
+	case <-ticker.C():
+		t.Fatal("unexpected queued tick")
+	default:
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+}
+
+func TestClock_FiresInDeadlineOrder(t *testing.T) {
+	clock := NewClock(epoch)
+	late := clock.After(2 * time.Second)
+	early := clock.After(time.Second)
+
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, epoch.Add(time.Second), <-early)
+	assert.Equal(t, epoch.Add(2*time.Second), <-late)
+}
+
+func TestClock_BlockUntil(t *testing.T) {
+	clock := NewClock(epoch)
+	done := make(chan struct{})
+	go func() {
+		<-clock.After(time.Hour)
+		close(done)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not released")
+	}
+}
@@ -0,0 +1,199 @@
+package pooltest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/google/uuid"
+)
+
+// Harness wraps a pool whose workers are never started. Submitted jobs stay
+// queued until the test runs them with RunNext or RunAll on its own
+// goroutine, so tests need no sleeping or polling. Every status change is
+// recorded for assertions.
+//
+// Sleep jobs wait on the harness's fake clock, so running one blocks until
+// another goroutine advances it.
+type Harness struct {
+	Pool  *pool.WorkerPool
+	Clock *Clock
+
+	t      testing.TB
+	mu     sync.Mutex
+	events []model.JobEvent
+}
+
+type options struct {
+	queueSize int
+	executors map[string]pool.Executor
+	clock     *Clock
+}
+
+type Option func(*options)
+
+// WithQueueSize sets the queue capacity, 100 by default
+func WithQueueSize(n int) Option {
+	return func(o *options) { o.queueSize = n }
+}
+
+// WithExecutor registers an executor for a job type
+func WithExecutor(jobType string, e pool.Executor) Option {
+	return func(o *options) { o.executors[jobType] = e }
+}
+
+// WithClock replaces the harness's fake clock
+func WithClock(c *Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// New returns a harness whose pool is stopped when the test ends
+func New(t testing.TB, opts ...Option) *Harness {
+	t.Helper()
+	o := options{
+		queueSize: 100,
+		executors: make(map[string]pool.Executor),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.clock == nil {
+		o.clock = NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	}
+
+	p := pool.NewWorkerPool(context.Background(), 0, o.queueSize)
+	p.SetClock(o.clock)
+	for jobType, e := range o.executors {
+		p.RegisterExecutor(jobType, e)
+	}
+
+	h := &Harness{Pool: p, Clock: o.clock, t: t}
+	unsubscribe := p.Subscribe(h.record)
+	t.Cleanup(func() {
+		unsubscribe()
+		p.Stop()
+	})
+	return h
+}
+
+func (h *Harness) record(ev model.JobEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, ev)
+}
+
+// Submit fills in a job's UID, status and creation time when unset and
+// submits it, failing the test on error
+func (h *Harness) Submit(job *model.Job) *model.Job {
+	h.t.Helper()
+	if job.UID == uuid.Nil {
+		job.UID = uuid.New()
+	}
+	if job.Status == "" {
+		job.Status = model.JobStatusPending
+	}
+	if job.CreatedAt == nil {
+		now := h.Clock.Now()
+		job.CreatedAt = &now
+	}
+	if err := h.Pool.SubmitJob(context.Background(), job); err != nil {
+		h.t.Fatalf("submitting job %s: %v", job.UID, err)
+	}
+	return job
+}
+
+// RunNext runs the oldest queued job to completion, reporting false if the
+// queue was empty
+func (h *Harness) RunNext() bool {
+	return h.Pool.ProcessNext()
+}
+
+// RunAll runs queued jobs until the queue is empty and returns how many ran
+func (h *Harness) RunAll() int {
+	n := 0
+	for h.Pool.ProcessNext() {
+		n++
+	}
+	return n
+}
+
+// Job returns the stored job, failing the test if it doesn't exist
+func (h *Harness) Job(uid uuid.UUID) *model.Job {
+	h.t.Helper()
+	job, exists := h.Pool.GetJob(context.Background(), uid.String())
+	if !exists {
+		h.t.Fatalf("job %s not found", uid)
+	}
+	return job
+}
+
+// Events returns every event recorded so far
+func (h *Harness) Events() []model.JobEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]model.JobEvent(nil), h.events...)
+}
+
+// Transitions returns the statuses a job has passed through, starting with
+// the status it was submitted in
+func (h *Harness) Transitions(uid uuid.UUID) []model.JobStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var statuses []model.JobStatus
+	for _, ev := range h.events {
+		if ev.JobUID == uid {
+			statuses = append(statuses, ev.To)
+		}
+	}
+	return statuses
+}
+
+// AssertTransitions fails the test unless the job passed through exactly the
+// given statuses, in order
+func (h *Harness) AssertTransitions(uid uuid.UUID, want ...model.JobStatus) bool {
+	h.t.Helper()
+	got := h.Transitions(uid)
+	if len(got) != len(want) {
+		h.t.Errorf("job %s transitions = %v, want %v", uid, got, want)
+		return false
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			h.t.Errorf("job %s transitions = %v, want %v", uid, got, want)
+			return false
+		}
+	}
+	return true
+}
+
+// WaitForStatus blocks until the job reaches status on a running pool,
+// returning it. It waits on the pool's events rather than polling and fails
+// the test after timeout.
+func WaitForStatus(t testing.TB, p *pool.WorkerPool, uid string, status model.JobStatus, timeout time.Duration) *model.Job {
+	t.Helper()
+	reached := make(chan struct{})
+	var once sync.Once
+	unsubscribe := p.Subscribe(func(ev model.JobEvent) {
+		if ev.JobUID.String() == uid && ev.To == status {
+			once.Do(func() { close(reached) })
+		}
+	})
+	defer unsubscribe()
+
+	// The job may have got there before we subscribed
+	if job, exists := p.GetJob(context.Background(), uid); exists && job.Status == status {
+		return job
+	}
+
+	select {
+	case <-reached:
+		job, _ := p.GetJob(context.Background(), uid)
+		return job
+	case <-time.After(timeout):
+		t.Fatalf("job %s did not reach status %s within %s", uid, status, timeout)
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package pooltest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingExecutor struct{}
+
+func (failingExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	return nil, errors.New("boom")
+}
+
+func TestHarness(t *testing.T) {
+	h := New(t, WithExecutor("container", failingExecutor{}))
+
+	math := h.Submit(&model.Job{Type: "math", Payload: model.MathJobPayload{Number: 5}})
+	container := h.Submit(&model.Job{Type: "container", Payload: model.ContainerJobPayload{Image: "alpine"}})
+
+	// Nothing runs until the test says so
+	h.AssertTransitions(math.UID, model.JobStatusPending)
+	assert.Equal(t, 2, h.RunAll())
+	assert.False(t, h.RunNext())
+
+	h.AssertTransitions(math.UID, model.JobStatusPending, model.JobStatusRunning, model.JobStatusCompleted)
+	assert.Equal(t, model.MathJobResult{Result: 10}, h.Job(math.UID).Result)
+
+	h.AssertTransitions(container.UID, model.JobStatusPending, model.JobStatusRunning, model.JobStatusFailed)
+	assert.Equal(t, "boom", h.Job(container.UID).Error)
+
+	events := h.Events()
+	assert.Len(t, events, 6)
+	assert.Equal(t, model.JobStatus(""), events[0].From)
+	assert.Equal(t, int64(1), events[0].Version)
+	assert.Equal(t, h.Clock.Now(), events[0].At)
+}
+
+func TestWaitForStatus(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 1, 5)
+	p.Start()
+	defer p.Stop()
+
+	job := &model.Job{Type: "sleep", Payload: model.SleepJobPayload{Duration: "20ms"}}
+	h := &Harness{Pool: p, Clock: NewClock(time.Now()), t: t}
+	h.Submit(job)
+
+	completed := WaitForStatus(t, p, job.UID.String(), model.JobStatusCompleted, time.Second)
+	assert.Equal(t, model.SleepJobResult{SleptFor: "20ms"}, completed.Result)
+
+	// Returns immediately for a job already in the status
+	again := WaitForStatus(t, p, job.UID.String(), model.JobStatusCompleted, time.Second)
+	assert.Equal(t, completed, again)
+}
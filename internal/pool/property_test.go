@@ -0,0 +1,392 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"pgregory.net/rapid"
+)
+
+var (
+	errProbe     = errors.New("probe failed")
+	capabilities = []string{"gpu", "ssd", "large-mem"}
+)
+
+// probeExecutor runs "probe" jobs, failing those labelled fail=true, and
+// reports any job it is asked to run while it is already running it
+type probeExecutor struct {
+	mu     sync.Mutex
+	active map[uuid.UUID]bool
+	twice  []uuid.UUID
+}
+
+func newProbeExecutor() *probeExecutor {
+	return &probeExecutor{active: make(map[uuid.UUID]bool)}
+}
+
+func (e *probeExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	e.mu.Lock()
+	if e.active[job.UID] {
+		e.twice = append(e.twice, job.UID)
+	}
+	e.active[job.UID] = true
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		delete(e.active, job.UID)
+		e.mu.Unlock()
+	}()
+	if job.Labels["fail"] == "true" {
+		return nil, errProbe
+	}
+	return model.MathJobResult{Result: 1}, nil
+}
+
+// transitionChecker validates every job's event stream as it arrives
+type transitionChecker struct {
+	mu       sync.Mutex
+	last     map[uuid.UUID]model.JobEvent
+	terminal int
+	errs     []string
+}
+
+func newTransitionChecker() *transitionChecker {
+	return &transitionChecker{last: make(map[uuid.UUID]model.JobEvent)}
+}
+
+var allowedTransitions = map[model.JobStatus][]model.JobStatus{
+	"":                     {model.JobStatusPending},
+	model.JobStatusPending: {model.JobStatusRunning},
+	// A running job may return to pending when a remote worker's lease lapses
+	model.JobStatusRunning: {model.JobStatusCompleted, model.JobStatusFailed, model.JobStatusPending},
+}
+
+func (c *transitionChecker) record(ev model.JobEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, seen := c.last[ev.JobUID]
+	switch {
+	case !seen && ev.From != "":
+		c.errorf(ev, "first event is not the submission")
+	case seen && ev.From != prev.To:
+		c.errorf(ev, "follows a transition to %s", prev.To)
+	case seen && ev.Version <= prev.Version:
+		c.errorf(ev, "version did not increase from %d", prev.Version)
+	case !slices.Contains(allowedTransitions[ev.From], ev.To):
+		c.errorf(ev, "is not an allowed transition")
+	}
+	if ev.To == model.JobStatusCompleted || ev.To == model.JobStatusFailed {
+		c.terminal++
+	}
+	c.last[ev.JobUID] = ev
+}
+
+func (c *transitionChecker) errorf(ev model.JobEvent, format string, args ...any) {
+	c.errs = append(c.errs, ev.JobUID.String()+" "+string(ev.From)+"->"+string(ev.To)+": "+fmt.Sprintf(format, args...))
+}
+
+func (c *transitionChecker) terminalCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.terminal
+}
+
+func (c *transitionChecker) check(t *rapid.T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, err := range c.errs {
+		t.Error(err)
+	}
+}
+
+// queueModel is the pool's scheduling contract: the oldest queued job a
+// worker can run is handed out first
+type queueModel struct {
+	pool     *pool.WorkerPool
+	checker  *transitionChecker
+	executor *probeExecutor
+	queued   []*model.Job
+	leased   map[uuid.UUID]string
+	status   map[uuid.UUID]model.JobStatus
+}
+
+func (m *queueModel) submit(t *rapid.T) {
+	job := &model.Job{
+		UID:      uuid.New(),
+		Type:     "probe",
+		Requires: rapid.SliceOfNDistinct(rapid.SampledFrom(capabilities), 0, 2, rapid.ID[string]).Draw(t, "requires"),
+		Status:   model.JobStatusPending,
+		Labels:   map[string]string{},
+	}
+	if rapid.Bool().Draw(t, "fail") {
+		job.Labels["fail"] = "true"
+	}
+	if err := m.pool.SubmitJob(context.Background(), job); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	m.queued = append(m.queued, job)
+	m.status[job.UID] = model.JobStatusPending
+}
+
+// expectTake removes and returns the oldest queued job the capabilities
+// cover, if any
+func (m *queueModel) expectTake(offered []string) *model.Job {
+	for i, job := range m.queued {
+		if isSubset(job.Requires, offered) {
+			m.queued = slices.Delete(m.queued, i, i+1)
+			return job
+		}
+	}
+	return nil
+}
+
+func (m *queueModel) lease(t *rapid.T) {
+	workerID := rapid.SampledFrom([]string{"w1", "w2", "w3"}).Draw(t, "worker")
+	offered := rapid.SliceOfDistinct(rapid.SampledFrom(capabilities), rapid.ID[string]).Draw(t, "capabilities")
+
+	want := m.expectTake(offered)
+	got, ok := m.pool.LeaseJob(context.Background(), workerID, offered, nil, time.Millisecond)
+	switch {
+	case want == nil && ok:
+		t.Fatalf("leased %s with %v but no queued job matches", got.UID, offered)
+	case want != nil && !ok:
+		t.Fatalf("lease with %v returned nothing, want %s", offered, want.UID)
+	case want != nil && got.UID != want.UID:
+		t.Fatalf("lease with %v returned %s, want the oldest match %s", offered, got.UID, want.UID)
+	}
+	if ok {
+		m.leased[got.UID] = workerID
+		m.status[got.UID] = model.JobStatusRunning
+	}
+}
+
+func (m *queueModel) complete(t *rapid.T) {
+	if len(m.leased) == 0 {
+		t.Skip("nothing leased")
+	}
+	uids := make([]uuid.UUID, 0, len(m.leased))
+	for uid := range m.leased {
+		uids = append(uids, uid)
+	}
+	slices.SortFunc(uids, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	uid := rapid.SampledFrom(uids).Draw(t, "job")
+	failed := rapid.Bool().Draw(t, "failed")
+
+	var jobErr error
+	status := model.JobStatusCompleted
+	if failed {
+		jobErr, status = errProbe, model.JobStatusFailed
+	}
+	if err := m.pool.CompleteLeasedJob(context.Background(), m.leased[uid], uid.String(), model.MathJobResult{}, jobErr); err != nil {
+		t.Fatalf("complete %s: %v", uid, err)
+	}
+	delete(m.leased, uid)
+	m.status[uid] = status
+}
+
+// completeTwice reports a finished job again, which must be rejected
+func (m *queueModel) completeTwice(t *rapid.T) {
+	var finished []uuid.UUID
+	for uid, status := range m.status {
+		if status == model.JobStatusCompleted || status == model.JobStatusFailed {
+			finished = append(finished, uid)
+		}
+	}
+	if len(finished) == 0 {
+		t.Skip("nothing finished")
+	}
+	slices.SortFunc(finished, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	uid := rapid.SampledFrom(finished).Draw(t, "job")
+	workerID := rapid.SampledFrom([]string{"w1", "w2", "w3"}).Draw(t, "worker")
+
+	err := m.pool.CompleteLeasedJob(context.Background(), workerID, uid.String(), model.MathJobResult{}, nil)
+	if !errors.Is(err, pool.ErrNotLeased) {
+		t.Fatalf("completing finished job %s again: got %v, want ErrNotLeased", uid, err)
+	}
+}
+
+func (m *queueModel) processNext(t *rapid.T) {
+	want := m.expectTake(capabilities)
+	if ran := m.pool.ProcessNext(); ran != (want != nil) {
+		t.Fatalf("ProcessNext ran = %v, want %v", ran, want != nil)
+	}
+	if want == nil {
+		return
+	}
+	job, _ := m.pool.GetJob(context.Background(), want.UID.String())
+	if !slices.Contains([]model.JobStatus{model.JobStatusCompleted, model.JobStatusFailed}, job.Status) {
+		t.Fatalf("ProcessNext left %s in %s; the oldest job should have run", want.UID, job.Status)
+	}
+	m.status[want.UID] = job.Status
+}
+
+func (m *queueModel) check(t *rapid.T) {
+	m.checker.check(t)
+	for uid, want := range m.status {
+		job, exists := m.pool.GetJob(context.Background(), uid.String())
+		if !exists {
+			t.Fatalf("job %s disappeared", uid)
+		}
+		if job.Status != want {
+			t.Fatalf("job %s is %s, want %s", uid, job.Status, want)
+		}
+	}
+	if n := m.pool.Stats(context.Background()).QueueDepth; n != len(m.queued) {
+		t.Fatalf("queue depth %d, want %d", n, len(m.queued))
+	}
+}
+
+// TestScheduler_Properties drives an unstarted pool through random
+// submissions, leases and completions and compares it against queueModel.
+// It checks that jobs are handed out oldest first among those a worker can
+// run, that finished jobs never change status again, and that every job's
+// events form a valid path through the state machine.
+func TestScheduler_Properties(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		p := pool.NewWorkerPool(context.Background(), 0, 1000)
+		// An idle local worker offering everything makes every job
+		// schedulable without ever taking one from the queue
+		p.AddWorkers(1, capabilities...)
+		executor := newProbeExecutor()
+		p.RegisterExecutor("probe", executor)
+		defer p.Stop()
+
+		checker := newTransitionChecker()
+		defer p.Subscribe(checker.record)()
+
+		m := &queueModel{
+			pool:     p,
+			checker:  checker,
+			executor: executor,
+			leased:   make(map[uuid.UUID]string),
+			status:   make(map[uuid.UUID]model.JobStatus),
+		}
+		t.Repeat(map[string]func(*rapid.T){
+			"submit":        m.submit,
+			"lease":         m.lease,
+			"complete":      m.complete,
+			"completeTwice": m.completeTwice,
+			"processNext":   m.processNext,
+			"":              m.check,
+		})
+	})
+}
+
+// TestWorkerPool_ConcurrencyProperties runs random workloads on a started
+// pool with local workers and remote workers, some of which abandon their
+// leases, while a fake clock races ahead to expire them. Every job must
+// finish, no job may run on two local workers at once, and every job's
+// events must form a valid path through the state machine.
+func TestWorkerPool_ConcurrencyProperties(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		localWorkers := rapid.IntRange(0, 4).Draw(t, "localWorkers")
+		remoteWorkers := rapid.IntRange(0, 3).Draw(t, "remoteWorkers")
+		if localWorkers+remoteWorkers == 0 {
+			localWorkers = 1
+		}
+		fails := rapid.SliceOfN(rapid.Bool(), 1, 40).Draw(t, "fails")
+		abandons := rapid.SliceOfN(rapid.Bool(), 1, 10).Draw(t, "abandons")
+		maxAttempts := rapid.IntRange(1, 3).Draw(t, "maxAttempts")
+
+		clock := pooltest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		p := pool.NewWorkerPool(context.Background(), localWorkers, len(fails))
+		p.SetClock(clock)
+		p.SetLeaseTimeout(time.Second)
+		p.SetMaxAttempts(maxAttempts)
+		executor := newProbeExecutor()
+		p.RegisterExecutor("probe", executor)
+		checker := newTransitionChecker()
+		unsubscribe := p.Subscribe(checker.record)
+		p.Start()
+
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		for r := 0; r < remoteWorkers; r++ {
+			wg.Add(1)
+			go func(r int) {
+				defer wg.Done()
+				workerID := "remote-" + string(rune('a'+r))
+				for i := 0; ; i++ {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					job, ok := p.LeaseJob(context.Background(), workerID, nil, nil, time.Millisecond)
+					if !ok || abandons[(r+i)%len(abandons)] {
+						continue
+					}
+					// The lease may lapse first; the pool then rejects this
+					p.CompleteLeasedJob(context.Background(), workerID, job.UID.String(), model.MathJobResult{}, nil)
+				}
+			}(r)
+		}
+
+		// Keep expiring abandoned leases
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				case <-time.After(time.Millisecond):
+					clock.Advance(500 * time.Millisecond)
+				}
+			}
+		}()
+
+		for _, fail := range fails {
+			job := &model.Job{UID: uuid.New(), Type: "probe", Status: model.JobStatusPending, Labels: map[string]string{}}
+			if fail {
+				job.Labels["fail"] = "true"
+			}
+			if err := p.SubmitJob(context.Background(), job); err != nil {
+				t.Fatalf("submit: %v", err)
+			}
+		}
+
+		deadline := time.Now().Add(10 * time.Second)
+		for checker.terminalCount() < len(fails) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		close(done)
+		wg.Wait()
+		unsubscribe()
+		p.Stop()
+
+		checker.check(t)
+		if n := checker.terminalCount(); n != len(fails) {
+			t.Fatalf("%d of %d jobs finished", n, len(fails))
+		}
+		if len(executor.twice) > 0 {
+			t.Fatalf("jobs run twice concurrently: %v", executor.twice)
+		}
+		for _, job := range p.GetAllJobs(context.Background(), &model.JobFilter{}) {
+			if len(job.Attempts) > maxAttempts {
+				t.Fatalf("job %s made %d attempts, max %d", job.UID, len(job.Attempts), maxAttempts)
+			}
+		}
+	})
+}
+
+func isSubset(required, offered []string) bool {
+	for _, c := range required {
+		if !slices.Contains(offered, c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+package pool
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// jobQueue is a bounded queue of pending jobs, ordered by priority and then
+// by age. Workers take the first job they accept rather than the head of the
+// queue, so a job nobody can run yet doesn't hold up the ones behind it. The
+// exception is jobs sharing a serialization key, which form a lane that is
+// taken strictly in order and one job at a time, whatever their priorities.
+//
+// Jobs in the large lane are held against a capacity of their own, so a
+// burst of them can't fill the queue for everyone else.
+type jobQueue struct {
+	mu            sync.Mutex
+	items         []*model.Job
+	capacity      int
+	largeCapacity int
+	// held counts the jobs queued in each lane plus the slots reserved for
+	// them but not yet filled
+	held map[string]int
+	// changed is closed and replaced whenever jobs are added
+	changed chan struct{}
+	// space is signalled whenever a slot frees up
+	space chan struct{}
+	// lanes maps each serialization key with a job out of the queue to
+	// that job's ID, until the job finishes
+	lanes map[string]string
+}
+
+func newJobQueue(capacity int) *jobQueue {
+	return &jobQueue{
+		capacity:      capacity,
+		largeCapacity: capacity,
+		held:          make(map[string]int),
+		changed:       make(chan struct{}),
+		space:         make(chan struct{}, 1),
+		lanes:         make(map[string]string),
+	}
+}
+
+// push appends a job, returning false when its lane is full
+func (q *jobQueue) push(job *model.Job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.fullLocked(job.Lane) {
+		return false
+	}
+	q.held[job.Lane]++
+	q.insertLocked(job)
+	q.notify()
+	return true
+}
+
+// insertLocked places a job behind every queued job with at least its
+// priority, and behind every one in its serialization lane
+func (q *jobQueue) insertLocked(job *model.Job) {
+	i := len(q.items)
+	for i > 0 && priority(q.items[i-1]) < priority(job) && !sameLane(q.items[i-1], job) {
+		i--
+	}
+	q.items = slices.Insert(q.items, i, job)
+}
+
+func priority(job *model.Job) int {
+	if job.Priority == nil {
+		return 0
+	}
+	return *job.Priority
+}
+
+func sameLane(a, b *model.Job) bool {
+	return a.SerializationKey != "" && a.SerializationKey == b.SerializationKey
+}
+
+func (q *jobQueue) fullLocked(lane string) bool {
+	limit := q.capacity
+	if lane == model.JobLaneLarge {
+		limit = q.largeCapacity
+	}
+	return q.held[lane] >= limit
+}
+
+// reserve claims a slot in a lane for a job that will be pushed with
+// pushReserved, returning false when the lane is full
+func (q *jobQueue) reserve(lane string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.fullLocked(lane) {
+		return false
+	}
+	q.held[lane]++
+	return true
+}
+
+// release gives back a slot claimed by reserve without filling it
+func (q *jobQueue) release(lane string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.held[lane]--
+	q.freed()
+}
+
+// pushReserved fills a slot claimed by reserve for the job's lane
+func (q *jobQueue) pushReserved(job *model.Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.insertLocked(job)
+	q.notify()
+}
+
+// tryTake removes and returns the first job satisfying accept without
+// waiting
+func (q *jobQueue) tryTake(accept func(job *model.Job) bool) (*model.Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.takeLocked(accept)
+}
+
+func (q *jobQueue) takeLocked(accept func(job *model.Job) bool) (*model.Job, bool) {
+	// skipped holds the keys of lanes whose oldest queued job wasn't taken,
+	// so later jobs in those lanes can't overtake it
+	var skipped map[string]bool
+	for i, job := range q.items {
+		key := job.SerializationKey
+		if key != "" {
+			if holder, busy := q.lanes[key]; (busy && holder != job.UID.String()) || skipped[key] {
+				continue
+			}
+		}
+		if !accept(job) {
+			if key != "" {
+				if skipped == nil {
+					skipped = make(map[string]bool)
+				}
+				skipped[key] = true
+			}
+			continue
+		}
+		q.items = append(q.items[:i], q.items[i+1:]...)
+		q.held[job.Lane]--
+		q.freed()
+		if key != "" {
+			q.lanes[key] = job.UID.String()
+		}
+		return job, true
+	}
+	return nil, false
+}
+
+// finish opens the job's lane to the next job with its serialization key.
+// It is called once the job will not be queued again.
+func (q *jobQueue) finish(job *model.Job) {
+	if job.SerializationKey == "" {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.lanes[job.SerializationKey] == job.UID.String() {
+		delete(q.lanes, job.SerializationKey)
+		q.notify()
+	}
+}
+
+// requeue puts a job back ahead of every queued job with at most its
+// priority. It bypasses the capacity check since the job already held a
+// slot before it was handed out.
+func (q *jobQueue) requeue(job *model.Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.held[job.Lane]++
+	i := 0
+	for i < len(q.items) && priority(q.items[i]) > priority(job) && !sameLane(q.items[i], job) {
+		i++
+	}
+	q.items = slices.Insert(q.items, i, job)
+	q.notify()
+}
+
+// take blocks until a job satisfying accept is queued, removing and returning
+// it. It returns false once ctx is done or quit is closed.
+func (q *jobQueue) take(ctx context.Context, quit <-chan struct{}, accept func(job *model.Job) bool) (*model.Job, bool) {
+	for {
+		q.mu.Lock()
+		if job, ok := q.takeLocked(accept); ok {
+			q.mu.Unlock()
+			return job, true
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-quit:
+			return nil, false
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// len returns how many jobs are queued in a lane
+func (q *jobQueue) len(lane string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for _, job := range q.items {
+		if job.Lane == lane {
+			n++
+		}
+	}
+	return n
+}
+
+// laneCount returns how many serialization lanes have a job out of the queue
+func (q *jobQueue) laneCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.lanes)
+}
+
+// wake rouses every waiting taker to look at the queue again
+func (q *jobQueue) wake() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.notify()
+}
+
+// freed signals space without waiting for anyone to notice
+func (q *jobQueue) freed() {
+	select {
+	case q.space <- struct{}{}:
+	default:
+	}
+}
+
+func (q *jobQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
@@ -0,0 +1,183 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobQueue_TakeSkipsUnacceptedJobs(t *testing.T) {
+	q := newJobQueue(2)
+	gpuJob := &model.Job{UID: uuid.New(), Requires: []string{"gpu"}}
+	plainJob := &model.Job{UID: uuid.New()}
+	assert.True(t, q.push(gpuJob))
+	assert.True(t, q.push(plainJob))
+	assert.False(t, q.push(&model.Job{UID: uuid.New()}))
+
+	generic := newWorker(0, nil)
+	job, ok := q.take(context.Background(), nil, generic.accepts)
+	assert.True(t, ok)
+	assert.Same(t, plainJob, job)
+
+	gpu := newWorker(1, []string{"gpu"})
+	job, ok = q.take(context.Background(), nil, gpu.accepts)
+	assert.True(t, ok)
+	assert.Same(t, gpuJob, job)
+	assert.Equal(t, 0, q.len(""))
+}
+
+func TestJobQueue_TakeWaitsForPush(t *testing.T) {
+	q := newJobQueue(1)
+	job := &model.Job{UID: uuid.New()}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.push(job)
+	}()
+
+	got, ok := q.take(context.Background(), nil, func(*model.Job) bool { return true })
+	assert.True(t, ok)
+	assert.Same(t, job, got)
+
+	quit := make(chan struct{})
+	close(quit)
+	_, ok = q.take(context.Background(), quit, func(*model.Job) bool { return true })
+	assert.False(t, ok)
+}
+
+func TestJobQueue_ReserveCountsAgainstCapacity(t *testing.T) {
+	q := newJobQueue(2)
+	assert.True(t, q.reserve(""))
+	assert.True(t, q.push(&model.Job{UID: uuid.New()}))
+	assert.False(t, q.reserve(""))
+	assert.False(t, q.push(&model.Job{UID: uuid.New()}))
+
+	// The reserved job is invisible until it is pushed
+	_, ok := q.tryTake(func(*model.Job) bool { return true })
+	assert.True(t, ok)
+	_, ok = q.tryTake(func(*model.Job) bool { return true })
+	assert.False(t, ok)
+
+	reserved := &model.Job{UID: uuid.New()}
+	q.pushReserved(reserved)
+	job, ok := q.tryTake(func(*model.Job) bool { return true })
+	assert.True(t, ok)
+	assert.Same(t, reserved, job)
+}
+
+func TestJobQueue_SerializationLanes(t *testing.T) {
+	q := newJobQueue(5)
+	a1 := &model.Job{UID: uuid.New(), SerializationKey: "a", Requires: []string{"gpu"}}
+	a2 := &model.Job{UID: uuid.New(), SerializationKey: "a"}
+	b1 := &model.Job{UID: uuid.New(), SerializationKey: "b"}
+	b2 := &model.Job{UID: uuid.New(), SerializationKey: "b"}
+	plain := &model.Job{UID: uuid.New()}
+	for _, j := range []*model.Job{a1, a2, b1, b2, plain} {
+		assert.True(t, q.push(j))
+	}
+
+	// a2 may not overtake a1, which this worker can't run, and b2 waits for
+	// b1 to finish
+	generic := newWorker(0, nil)
+	var taken []*model.Job
+	for {
+		job, ok := q.tryTake(generic.accepts)
+		if !ok {
+			break
+		}
+		taken = append(taken, job)
+	}
+	assert.Equal(t, []*model.Job{b1, plain}, taken)
+	assert.Equal(t, 1, q.laneCount())
+
+	q.finish(b1)
+	job, ok := q.tryTake(generic.accepts)
+	assert.True(t, ok)
+	assert.Same(t, b2, job)
+
+	// A requeued job keeps its lane
+	q.requeue(b2)
+	job, ok = q.tryTake(generic.accepts)
+	assert.True(t, ok)
+	assert.Same(t, b2, job)
+	q.finish(b2)
+
+	gpu := newWorker(1, []string{"gpu"})
+	job, ok = q.tryTake(gpu.accepts)
+	assert.True(t, ok)
+	assert.Same(t, a1, job)
+	_, ok = q.tryTake(generic.accepts)
+	assert.False(t, ok)
+	q.finish(a1)
+	job, ok = q.tryTake(generic.accepts)
+	assert.True(t, ok)
+	assert.Same(t, a2, job)
+	q.finish(a2)
+	assert.Equal(t, 0, q.laneCount())
+}
+
+func TestJobQueue_LargeLaneCapacity(t *testing.T) {
+	q := newJobQueue(1)
+	q.largeCapacity = 2
+	large := func() *model.Job { return &model.Job{UID: uuid.New(), Lane: model.JobLaneLarge} }
+
+	// Large jobs don't count against the default lane, nor it against them
+	assert.True(t, q.push(large()))
+	assert.True(t, q.reserve(model.JobLaneLarge))
+	assert.False(t, q.push(large()))
+	assert.True(t, q.push(&model.Job{UID: uuid.New()}))
+	assert.False(t, q.reserve(""))
+	assert.Equal(t, 1, q.len(model.JobLaneLarge))
+	assert.Equal(t, 1, q.len(""))
+
+	q.release(model.JobLaneLarge)
+	_, ok := q.tryTake(func(job *model.Job) bool { return job.Lane == model.JobLaneLarge })
+	assert.True(t, ok)
+	assert.True(t, q.push(large()))
+	assert.True(t, q.push(large()))
+}
+
+func TestJobQueue_Priority(t *testing.T) {
+	q := newJobQueue(6)
+	prioritized := func(p int, key string) *model.Job {
+		return &model.Job{UID: uuid.New(), Priority: &p, SerializationKey: key}
+	}
+	low := prioritized(-5, "")
+	plain := &model.Job{UID: uuid.New()}
+	high := prioritized(10, "")
+	urgent := prioritized(50, "")
+	// a2 outranks a1 but may not overtake it in their lane
+	a1 := prioritized(0, "a")
+	a2 := prioritized(20, "a")
+	for _, j := range []*model.Job{low, plain, high, a1, a2, urgent} {
+		assert.True(t, q.push(j))
+	}
+
+	all := func(*model.Job) bool { return true }
+	var taken []*model.Job
+	for {
+		job, ok := q.tryTake(all)
+		if !ok {
+			break
+		}
+		taken = append(taken, job)
+		if job == a1 {
+			q.finish(a1)
+		}
+	}
+	assert.Equal(t, []*model.Job{urgent, high, plain, a1, a2, low}, taken)
+
+	// A requeued job goes ahead of jobs with its priority, but not of
+	// higher ones
+	assert.True(t, q.push(urgent))
+	assert.True(t, q.push(plain))
+	q.requeue(high)
+	job, _ := q.tryTake(all)
+	assert.Same(t, urgent, job)
+	job, _ = q.tryTake(all)
+	assert.Same(t, high, job)
+}
@@ -0,0 +1,117 @@
+package pool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// dispatchLimiter spaces out how often jobs of each type are handed to
+// workers. Each limited type has a token bucket holding a single token, so
+// jobs of that type start at most once every 1/rate seconds however many
+// workers are idle.
+type dispatchLimiter struct {
+	mu      sync.Mutex
+	clock   Clock
+	buckets map[string]*bucket
+	// wake is called once a denied type has a token again, so workers
+	// waiting on the queue look again
+	wake func()
+	done <-chan struct{}
+}
+
+type bucket struct {
+	rate    float64
+	tokens  float64
+	last    time.Time
+	waiting bool
+}
+
+func newDispatchLimiter(clock Clock, wake func(), done <-chan struct{}) *dispatchLimiter {
+	return &dispatchLimiter{clock: clock, buckets: make(map[string]*bucket), wake: wake, done: done}
+}
+
+func (l *dispatchLimiter) setRate(jobType string, perSecond float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if perSecond <= 0 {
+		delete(l.buckets, jobType)
+		return
+	}
+	l.buckets[jobType] = &bucket{rate: perSecond, tokens: 1}
+}
+
+func (l *dispatchLimiter) rate(jobType string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[jobType]; ok {
+		return b.rate
+	}
+	return 0
+}
+
+// allow takes a token for the job type, reporting false if none is left
+func (l *dispatchLimiter) allow(jobType string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[jobType]
+	if !ok {
+		return true
+	}
+
+	now := l.clock.Now()
+	if !b.last.IsZero() {
+		b.tokens = min(1, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+
+	if !b.waiting {
+		b.waiting = true
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		go l.wakeAfter(b, wait)
+	}
+	return false
+}
+
+func (l *dispatchLimiter) wakeAfter(b *bucket, wait time.Duration) {
+	select {
+	case <-l.clock.After(wait):
+	case <-l.done:
+		return
+	}
+	l.mu.Lock()
+	b.waiting = false
+	l.mu.Unlock()
+	l.wake()
+}
+
+// SetDispatchRate limits how many jobs of the given type are handed to
+// workers per second, spacing them evenly. Zero removes the limit. It must
+// be called before Start.
+func (p *WorkerPool) SetDispatchRate(jobType string, perSecond float64) {
+	p.dispatch.setRate(jobType, perSecond)
+}
+
+// dispatchable wraps a worker's accept function so it also respects
+// maintenance windows, the dispatch rates, the large lane's slots and each
+// type's concurrency limit
+func (p *WorkerPool) dispatchable(accept func(job *model.Job) bool) func(job *model.Job) bool {
+	return func(job *model.Job) bool {
+		// The queue's lock is held, so nothing else can take a large slot
+		// or a place under a limit between checking for one and taking it
+		if p.maintenance.paused(p.clock.Now()) {
+			return false
+		}
+		if !accept(job) || !p.large.admits(job) || !p.concurrency.admits(job) || !p.dispatch.allow(job.Type) {
+			return false
+		}
+		p.large.start(job)
+		p.concurrency.start(job)
+		return true
+	}
+}
@@ -0,0 +1,82 @@
+package pool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func submitMath(t *testing.T, p *pool.WorkerPool) *model.Job {
+	t.Helper()
+	job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 3}, Status: model.JobStatusPending}
+	assert.NoError(t, p.SubmitJob(context.Background(), job))
+	return job
+}
+
+func TestWorkerPool_DispatchRate(t *testing.T) {
+	ctx := context.Background()
+	clock := pooltest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	p.SetClock(clock)
+	p.SetDispatchRate("math", 2)
+	defer p.Stop()
+
+	for i := 0; i < 3; i++ {
+		submitMath(t, p)
+	}
+	sleep := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "0s"}, Status: model.JobStatusPending}
+	assert.NoError(t, p.SubmitJob(ctx, sleep))
+
+	// One math job may start straight away, then one every 500ms. Other
+	// types aren't held up behind the limited ones.
+	assert.True(t, p.ProcessNext())
+	assert.True(t, p.ProcessNext())
+	got, _ := p.GetJob(ctx, sleep.UID.String())
+	assert.Equal(t, model.JobStatusCompleted, got.Status)
+	assert.False(t, p.ProcessNext())
+
+	clock.Advance(499 * time.Millisecond)
+	assert.False(t, p.ProcessNext())
+	clock.Advance(time.Millisecond)
+	assert.True(t, p.ProcessNext())
+	assert.False(t, p.ProcessNext())
+
+	// An idle spell doesn't bank up a burst
+	clock.Advance(time.Minute)
+	assert.True(t, p.ProcessNext())
+	assert.Equal(t, 0, p.Internals(ctx).QueueDepth)
+
+	types := make(map[string]model.JobType)
+	for _, jt := range p.JobTypes(ctx) {
+		types[jt.Name] = jt
+	}
+	assert.Equal(t, 2.0, types["math"].DispatchRate)
+	assert.Zero(t, types["sleep"].DispatchRate)
+}
+
+func TestWorkerPool_DispatchRateWakesWorkers(t *testing.T) {
+	ctx := context.Background()
+	clock := pooltest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := pool.NewWorkerPool(ctx, 2, 10)
+	p.SetClock(clock)
+	p.SetDispatchRate("math", 1)
+	p.Start()
+	defer p.Stop()
+
+	first, second := submitMath(t, p), submitMath(t, p)
+	pooltest.WaitForStatus(t, p, first.UID.String(), model.JobStatusCompleted, time.Second)
+
+	// The idle worker is woken once the next token is due: the lease,
+	// deadline and retention reapers' tickers plus the limiter's timer
+	clock.BlockUntil(4)
+	got, _ := p.GetJob(ctx, second.UID.String())
+	assert.Equal(t, model.JobStatusPending, got.Status)
+	clock.Advance(time.Second)
+	pooltest.WaitForStatus(t, p, second.UID.String(), model.JobStatusCompleted, time.Second)
+}
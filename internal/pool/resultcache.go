@@ -0,0 +1,131 @@
+package pool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// resultSweepInterval is how often expired results are dropped from the
+// cache as new ones are added
+const resultSweepInterval = time.Minute
+
+// resultCache keeps the results of completed jobs of the types opted into
+// it, so an identical job submitted within the TTL completes without running
+type resultCache struct {
+	mu        sync.Mutex
+	ttls      map[string]time.Duration
+	entries   map[string]cachedResult
+	hits      int
+	misses    int
+	nextSweep time.Time
+}
+
+type cachedResult struct {
+	result    model.JobResult
+	expiresAt time.Time
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{
+		ttls:    make(map[string]time.Duration),
+		entries: make(map[string]cachedResult),
+	}
+}
+
+// SetResultCacheTTL reuses the results of completed jobs of the given type
+// for identical jobs submitted within d. Jobs are identical when they have
+// the same tenant and payload. Zero turns caching off for the type. It must
+// be called before Start.
+func (p *WorkerPool) SetResultCacheTTL(jobType string, d time.Duration) {
+	if d <= 0 {
+		delete(p.results.ttls, jobType)
+		return
+	}
+	p.results.ttls[jobType] = d
+}
+
+// key identifies jobs whose results are interchangeable, or returns ""
+// for jobs that aren't cached
+func (c *resultCache) key(job *model.Job) string {
+	if _, ok := c.ttls[job.Type]; !ok || job.Input != nil {
+		// An input file changes the outcome without showing in the payload
+		return ""
+	}
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(job.Type), []byte(job.Tenant), payload} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lookup returns the cached result for an identical job, if there is one
+func (c *resultCache) lookup(job *model.Job, now time.Time) (model.JobResult, bool) {
+	key := c.key(job)
+	if key == "" {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return entry.result, true
+}
+
+// add caches the result of a job that completed
+func (c *resultCache) add(job *model.Job, result model.JobResult, now time.Time) {
+	key := c.key(job)
+	if key == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cachedResult{result: result, expiresAt: now.Add(c.ttls[job.Type])}
+	if now.Before(c.nextSweep) {
+		return
+	}
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.nextSweep = now.Add(resultSweepInterval)
+}
+
+// stats reports the cache's size and use, or nil when no type is cached
+func (c *resultCache) stats() *model.CacheStats {
+	if len(c.ttls) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &model.CacheStats{Entries: len(c.entries), Hits: c.hits, Misses: c.misses}
+}
+
+// completeFromCache completes a job just submitted with the result of an
+// identical earlier job, reporting false if there is none to use
+func (p *WorkerPool) completeFromCache(job *model.Job) bool {
+	now := p.clock.Now()
+	result, ok := p.results.lookup(job, now)
+	if !ok {
+		return false
+	}
+	job.Status = model.JobStatusCompleted
+	job.Result = result
+	job.Cached = true
+	job.CompletedAt = &now
+	return true
+}
@@ -0,0 +1,75 @@
+package pool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_ResultCache(t *testing.T) {
+	ctx := context.Background()
+	clock := pooltest.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	p := pool.NewWorkerPool(ctx, 0, 5)
+	p.SetClock(clock)
+	p.SetResultCacheTTL("math", 10*time.Minute)
+	assert.Equal(t, &model.CacheStats{}, p.Stats(ctx).ResultCache)
+
+	submit := func(tenant string, number int) *model.Job {
+		job := &model.Job{UID: uuid.New(), Type: "math", Tenant: tenant, Payload: model.MathJobPayload{Number: number}, Status: model.JobStatusPending}
+		assert.NoError(t, p.SubmitJob(ctx, job))
+		stored, _ := p.GetJob(ctx, job.UID.String())
+		return stored
+	}
+
+	first := submit("acme", 10)
+	assert.Equal(t, model.JobStatusPending, first.Status)
+	assert.True(t, p.ProcessNext())
+
+	// An identical job completes straight away without being queued
+	cached := submit("acme", 10)
+	assert.Equal(t, model.JobStatusCompleted, cached.Status)
+	assert.True(t, cached.Cached)
+	assert.Equal(t, model.MathJobResult{Result: 45}, cached.Result)
+	assert.Equal(t, clock.Now(), *cached.CompletedAt)
+	assert.False(t, p.ProcessNext())
+
+	// Other payloads and tenants run
+	assert.Equal(t, model.JobStatusPending, submit("acme", 11).Status)
+	assert.Equal(t, model.JobStatusPending, submit("globex", 10).Status)
+	assert.True(t, p.ProcessNext())
+	assert.True(t, p.ProcessNext())
+
+	// Expired results aren't reused
+	clock.Advance(10 * time.Minute)
+	expired := submit("acme", 10)
+	assert.Equal(t, model.JobStatusPending, expired.Status)
+	assert.False(t, expired.Cached)
+
+	// Types that didn't opt in always run
+	sleep := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}, Status: model.JobStatusPending}
+	assert.NoError(t, p.SubmitJob(ctx, sleep))
+	assert.Equal(t, model.JobStatusPending, sleep.Status)
+
+	assert.Equal(t, &model.CacheStats{Entries: 3, Hits: 1, Misses: 4}, p.Stats(ctx).ResultCache)
+}
+
+func TestWorkerPool_ResultCacheDisabled(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 0, 5)
+	p.SetResultCacheTTL("math", time.Minute)
+	p.SetResultCacheTTL("math", 0)
+	assert.Nil(t, p.Stats(ctx).ResultCache)
+
+	for i := 0; i < 2; i++ {
+		job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 3}, Status: model.JobStatusPending}
+		assert.NoError(t, p.SubmitJob(ctx, job))
+		assert.True(t, p.ProcessNext())
+		assert.False(t, job.Cached)
+	}
+}
@@ -0,0 +1,239 @@
+package pool
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// retentionSweepInterval is how often jobs past their retention are looked
+// for
+const retentionSweepInterval = time.Minute
+
+// retentionTable bounds how many finished jobs are kept. Once there are more
+// than limit, the ones read least recently, or finished longest ago if never
+// read, are evicted. It is a backstop against bursts of jobs filling memory,
+// so it counts evicted jobs nobody read after they finished. Jobs with a
+// retention of their own are also deleted once it has passed.
+type retentionTable struct {
+	mu    sync.Mutex
+	limit int
+	// order holds the finished jobs, most recently read or finished first
+	order   *list.List
+	byID    map[uuid.UUID]*list.Element
+	evicted int64
+	unread  int64
+	// archive makes evicted jobs be written to the blob store first, to be
+	// read back from there
+	archive bool
+	// expiries holds when each finished job with a retention is deleted
+	expiries map[uuid.UUID]time.Time
+}
+
+type retainedJob struct {
+	id   uuid.UUID
+	read bool
+}
+
+func newRetentionTable() *retentionTable {
+	return &retentionTable{order: list.New(), byID: make(map[uuid.UUID]*list.Element), expiries: make(map[uuid.UUID]time.Time)}
+}
+
+// SetMaxFinishedJobs caps how many finished jobs are kept, evicting the
+// least recently read beyond that. Zero keeps every job. It must be called
+// before Start.
+func (p *WorkerPool) SetMaxFinishedJobs(n int) {
+	p.retention.limit = n
+}
+
+// SetArchiveEvicted makes finished jobs evicted by SetMaxFinishedJobs be
+// written to the blob store first, so ReadJob can still find them. It must
+// be called before Start.
+func (p *WorkerPool) SetArchiveEvicted(archive bool) {
+	p.retention.archive = archive
+}
+
+func archiveBlobName(jobID string) string {
+	return jobID + ".job"
+}
+
+// add records a job that has just finished, returning the jobs to evict to
+// stay within the limit
+func (t *retentionTable) add(id uuid.UUID) []uuid.UUID {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limit <= 0 {
+		return nil
+	}
+	if e, exists := t.byID[id]; exists {
+		t.order.MoveToFront(e)
+	} else {
+		t.byID[id] = t.order.PushFront(&retainedJob{id: id})
+	}
+	var victims []uuid.UUID
+	for t.order.Len() > t.limit {
+		oldest := t.order.Remove(t.order.Back()).(*retainedJob)
+		delete(t.byID, oldest.id)
+		delete(t.expiries, oldest.id)
+		t.evicted++
+		if !oldest.read {
+			t.unread++
+		}
+		victims = append(victims, oldest.id)
+	}
+	return victims
+}
+
+// expireAt records when a finished job's retention runs out
+func (t *retentionTable) expireAt(id uuid.UUID, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expiries[id] = at
+}
+
+// expired removes and returns the jobs whose retention has run out by now
+func (t *retentionTable) expired(now time.Time) []uuid.UUID {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var ids []uuid.UUID
+	for id, at := range t.expiries {
+		if now.Before(at) {
+			continue
+		}
+		delete(t.expiries, id)
+		if e, exists := t.byID[id]; exists {
+			t.order.Remove(e)
+			delete(t.byID, id)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// touch records that a finished job was read
+func (t *retentionTable) touch(id uuid.UUID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, exists := t.byID[id]; exists {
+		e.Value.(*retainedJob).read = true
+		t.order.MoveToFront(e)
+	}
+}
+
+func (t *retentionTable) stats() *model.RetentionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limit <= 0 {
+		return nil
+	}
+	return &model.RetentionStats{Limit: t.limit, Retained: t.order.Len(), Evicted: t.evicted, EvictedUnread: t.unread}
+}
+
+// retain records that a job finished, evicting the jobs that pushes out
+func (p *WorkerPool) retain(job *model.Job) {
+	if job.Retention != "" && job.CompletedAt != nil {
+		if d, err := time.ParseDuration(job.Retention); err == nil {
+			p.retention.expireAt(job.UID, job.CompletedAt.Add(d))
+		}
+	}
+	for _, id := range p.retention.add(job.UID) {
+		if p.retention.archive && p.blobs != nil {
+			p.archiveJob(id.String())
+		}
+		p.store.Delete(id.String())
+		p.logs.remove(id.String())
+		slog.Debug("Evicted finished job", "job_id", id)
+	}
+}
+
+// retentionReaper periodically deletes finished jobs past their retention
+func (p *WorkerPool) retentionReaper() {
+	defer p.wg.Done()
+
+	ticker := p.clock.NewTicker(retentionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C():
+			p.expireJobs(now)
+		case <-p.quit:
+			return
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// expireJobs deletes the finished jobs whose retention has run out by now.
+// Unlike evicted jobs, they are never archived.
+func (p *WorkerPool) expireJobs(now time.Time) {
+	for _, id := range p.retention.expired(now) {
+		p.store.Delete(id.String())
+		p.logs.remove(id.String())
+		slog.Debug("Deleted finished job past its retention", "job_id", id)
+	}
+}
+
+// archiveJob writes a job about to be evicted to the blob store. The job
+// is evicted even if that fails, since the limit guards memory. Jobs with a
+// retention of their own aren't archived, as nothing would delete them
+// from the archive once it ran out.
+func (p *WorkerPool) archiveJob(id string) {
+	job, exists := p.store.Get(id)
+	if !exists || job.Retention != "" {
+		return
+	}
+	data, err := json.Marshal(job)
+	if err == nil {
+		_, err = p.blobs.Put(context.WithoutCancel(p.ctx), archiveBlobName(id), bytes.NewReader(data))
+	}
+	if err != nil {
+		slog.Warn("Failed to archive evicted job", "job_id", id, "error", err)
+	}
+}
+
+// ReadJob returns a job for a client asking for it. Unlike GetJob, it
+// counts as reading the job, so a finished job read recently is kept over
+// those that weren't. A job that was evicted and archived is read back
+// from the archive, with Storage set to model.JobStorageArchive.
+func (p *WorkerPool) ReadJob(ctx context.Context, id string) (*model.Job, bool) {
+	job, exists := p.GetJob(ctx, id)
+	if exists && job.Status.Finished() {
+		p.retention.touch(job.UID)
+	}
+	if !exists && p.retention.archive && p.blobs != nil {
+		return p.readArchived(ctx, id)
+	}
+	return job, exists
+}
+
+func (p *WorkerPool) readArchived(ctx context.Context, id string) (*model.Job, bool) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, false
+	}
+	r, err := p.blobs.Open(ctx, archiveBlobName(id))
+	if err != nil {
+		if !errors.Is(err, blob.ErrNotFound) {
+			slog.Warn("Failed to read archived job", "job_id", id, "error", err)
+		}
+		return nil, false
+	}
+	defer r.Close()
+	var job model.Job
+	if err := json.NewDecoder(r).Decode(&job); err != nil {
+		slog.Warn("Failed to decode archived job", "job_id", id, "error", err)
+		return nil, false
+	}
+	job.Storage = model.JobStorageArchive
+	return &job, true
+}
@@ -0,0 +1,122 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool_MaxFinishedJobs(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 10)
+	pool.SetMaxFinishedJobs(2)
+	run := func(n int) string {
+		job := mathJob(n)
+		require.NoError(t, pool.SubmitJob(ctx, job))
+		require.True(t, pool.ProcessNext())
+		return job.UID.String()
+	}
+	exists := func(id string) bool {
+		_, ok := pool.GetJob(ctx, id)
+		return ok
+	}
+
+	first := run(1)
+	second := run(2)
+	// Reading the first job keeps it over the second
+	_, ok := pool.ReadJob(ctx, first)
+	assert.True(t, ok)
+	third := run(3)
+	assert.True(t, exists(first))
+	assert.False(t, exists(second))
+	assert.True(t, exists(third))
+	assert.Equal(t, &model.RetentionStats{Limit: 2, Retained: 2, Evicted: 1, EvictedUnread: 1}, pool.Stats(ctx).Retention)
+
+	// Pending jobs don't count towards the limit
+	pending := mathJob(4)
+	require.NoError(t, pool.SubmitJob(ctx, pending))
+	assert.True(t, exists(pending.UID.String()))
+
+	_, ok = pool.ReadJob(ctx, third)
+	assert.True(t, ok)
+	require.True(t, pool.ProcessNext())
+	assert.False(t, exists(first))
+	assert.True(t, exists(third))
+	assert.Equal(t, &model.RetentionStats{Limit: 2, Retained: 2, Evicted: 2, EvictedUnread: 1}, pool.Stats(ctx).Retention)
+	assert.Equal(t, 2, pool.store.CountByStatus()[model.JobStatusCompleted])
+}
+
+func TestWorkerPool_MaxFinishedJobsOff(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 10)
+	for i := range 3 {
+		require.NoError(t, pool.SubmitJob(ctx, mathJob(i)))
+		require.True(t, pool.ProcessNext())
+	}
+	assert.Equal(t, 3, pool.store.CountByStatus()[model.JobStatusCompleted])
+	assert.Nil(t, pool.Stats(ctx).Retention)
+}
+
+func TestWorkerPool_ArchiveEvicted(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := blob.NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+	pool := NewWorkerPool(ctx, 1, 10)
+	pool.SetBlobStore(blobs)
+	pool.SetMaxFinishedJobs(1)
+	pool.SetArchiveEvicted(true)
+
+	evicted := mathJob(5)
+	evicted.Labels = map[string]string{"team": "search"}
+	require.NoError(t, pool.SubmitJob(ctx, evicted))
+	require.True(t, pool.ProcessNext())
+	require.NoError(t, pool.SubmitJob(ctx, mathJob(6)))
+	require.True(t, pool.ProcessNext())
+
+	_, ok := pool.GetJob(ctx, evicted.UID.String())
+	assert.False(t, ok)
+	job, ok := pool.ReadJob(ctx, evicted.UID.String())
+	require.True(t, ok)
+	assert.Equal(t, model.JobStorageArchive, job.Storage)
+	assert.Equal(t, model.JobStatusCompleted, job.Status)
+	assert.Equal(t, model.MathJobPayload{Number: 5}, job.Payload)
+	assert.NotNil(t, job.Result)
+	assert.Equal(t, "search", job.Labels["team"])
+
+	_, ok = pool.ReadJob(ctx, uuid.NewString())
+	assert.False(t, ok)
+	_, ok = pool.ReadJob(ctx, "../secrets")
+	assert.False(t, ok)
+
+	// Jobs still in memory aren't marked
+	job, ok = pool.ReadJob(ctx, pool.GetAllJobs(ctx, &model.JobFilter{})[0].UID.String())
+	require.True(t, ok)
+	assert.Empty(t, job.Storage)
+}
+
+func TestWorkerPool_ArchiveEvictedSkipsRetention(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := blob.NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+	pool := NewWorkerPool(ctx, 1, 10)
+	pool.SetBlobStore(blobs)
+	pool.SetMaxFinishedJobs(1)
+	pool.SetArchiveEvicted(true)
+
+	retained := mathJob(5)
+	retained.Retention = "1h"
+	require.NoError(t, pool.SubmitJob(ctx, retained))
+	require.True(t, pool.ProcessNext())
+	require.NoError(t, pool.SubmitJob(ctx, mathJob(6)))
+	require.True(t, pool.ProcessNext())
+
+	_, ok := pool.ReadJob(ctx, retained.UID.String())
+	assert.False(t, ok)
+	_, err = blobs.Open(ctx, archiveBlobName(retained.UID.String()))
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+}
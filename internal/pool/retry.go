@@ -0,0 +1,135 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/backoff"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, such as a payload the executor
+// rejects, so the job fails straight away whatever retries it has left.
+// Unmarked errors are treated as transient.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err, or any error it wraps, was marked with
+// Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// SetMaxRetries sets how many times a job whose execution fails is run
+// again, unless the job or its type's defaults say otherwise. Failures
+// marked with Permanent are never retried. It must be called before Start.
+func (p *WorkerPool) SetMaxRetries(n int) {
+	p.maxRetries = n
+}
+
+// SetRetryBackoff sets the delay strategy between retries of the given job
+// type, or of every type without its own when jobType is empty. Jobs may
+// override it with their own Backoff. Without a strategy retries are
+// queued straight away. It must be called before Start.
+func (p *WorkerPool) SetRetryBackoff(jobType string, s backoff.Strategy) {
+	if s == nil {
+		delete(p.backoffs, jobType)
+		return
+	}
+	p.backoffs[jobType] = s
+}
+
+// shouldRetry reports whether a job that just failed with err gets another
+// attempt. j's latest attempt must already be ended.
+func (p *WorkerPool) shouldRetry(j *model.Job, err error) bool {
+	if IsPermanent(err) || p.ctx.Err() != nil {
+		return false
+	}
+	failures := 0
+	for _, attempt := range j.Attempts {
+		if attempt.Outcome == model.AttemptFailed {
+			failures++
+		}
+	}
+	maxRetries := p.maxRetries
+	if j.MaxRetries != nil {
+		maxRetries = *j.MaxRetries
+	}
+	return failures <= maxRetries
+}
+
+// retryJob marks a failed job pending again and returns how long it must
+// wait before it is queued
+func (p *WorkerPool) retryJob(j *model.Job, err error, now time.Time) time.Duration {
+	UpdateStatus(model.JobStatusPending, now)(j)
+	j.Error = fmt.Sprintf("attempt %d failed: %s", len(j.Attempts), err)
+
+	retries := 0
+	for _, attempt := range j.Attempts {
+		if attempt.Outcome == model.AttemptFailed {
+			retries++
+		}
+	}
+	delay := p.retryBackoff(j).Delay(retries)
+	if delay > 0 {
+		at := now.Add(delay)
+		j.RetryAt = &at
+	}
+	return delay
+}
+
+// retryBackoff picks the job's own strategy, then its type's, then the
+// pool default
+func (p *WorkerPool) retryBackoff(j *model.Job) backoff.Strategy {
+	if j.Backoff != "" {
+		s, err := backoff.Parse(j.Backoff)
+		if err == nil {
+			return s
+		}
+		slog.Warn("Ignoring invalid job backoff", "job_id", j.UID, "error", err)
+	}
+	if s, ok := p.backoffs[j.Type]; ok {
+		return s
+	}
+	if s, ok := p.backoffs[""]; ok {
+		return s
+	}
+	return backoff.Constant(0)
+}
+
+// requeueAfter returns a job to the queue once delay has passed
+func (p *WorkerPool) requeueAfter(job *model.Job, delay time.Duration) {
+	if delay <= 0 {
+		p.jobQueue.requeue(job)
+		return
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		select {
+		case <-p.clock.After(delay):
+		case <-p.quit:
+			return
+		case <-p.ctx.Done():
+			return
+		}
+		p.transition(job, func(j *model.Job) { j.RetryAt = nil })
+		p.jobQueue.requeue(job)
+	}()
+}
@@ -0,0 +1,148 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyExecutor fails the first failures runs, with a permanent error if
+// permanent is set, then succeeds
+type flakyExecutor struct {
+	failures  int32
+	permanent bool
+	runs      atomic.Int32
+}
+
+func (e *flakyExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	run := e.runs.Add(1)
+	if run <= e.failures {
+		err := fmt.Errorf("run %d failed", run)
+		if e.permanent {
+			return nil, Permanent(err)
+		}
+		return nil, err
+	}
+	return model.MathJobResult{Result: 6}, nil
+}
+
+func TestPermanent(t *testing.T) {
+	assert.Nil(t, Permanent(nil))
+
+	base := errors.New("bad payload")
+	err := fmt.Errorf("validating: %w", Permanent(base))
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, base)
+	assert.Equal(t, "validating: bad payload", err.Error())
+	assert.False(t, IsPermanent(base))
+}
+
+func TestWorkerPool_Retries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		executor   *flakyExecutor
+		wantStatus model.JobStatus
+		wantRuns   int32
+		wantError  string
+	}{
+		{
+			name:       "succeeds on a retry",
+			maxRetries: 2,
+			executor:   &flakyExecutor{failures: 2},
+			wantStatus: model.JobStatusCompleted,
+			wantRuns:   3,
+		},
+		{
+			name:       "runs out of retries",
+			maxRetries: 1,
+			executor:   &flakyExecutor{failures: 5},
+			wantStatus: model.JobStatusFailed,
+			wantRuns:   2,
+			wantError:  "run 2 failed",
+		},
+		{
+			name:       "no retries by default",
+			executor:   &flakyExecutor{failures: 1},
+			wantStatus: model.JobStatusFailed,
+			wantRuns:   1,
+			wantError:  "run 1 failed",
+		},
+		{
+			name:       "permanent failures are not retried",
+			maxRetries: 3,
+			executor:   &flakyExecutor{failures: 1, permanent: true},
+			wantStatus: model.JobStatusFailed,
+			wantRuns:   1,
+			wantError:  "run 1 failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewWorkerPool(context.Background(), 0, 5)
+			pool.RegisterExecutor("math", tt.executor)
+			pool.SetMaxRetries(tt.maxRetries)
+
+			job := &model.Job{
+				UID:     uuid.New(),
+				Type:    "math",
+				Payload: model.MathJobPayload{Number: 4},
+				Status:  model.JobStatusPending,
+			}
+			assert.NoError(t, pool.SubmitJob(context.Background(), job))
+			for pool.ProcessNext() {
+			}
+
+			finished, _ := pool.GetJob(context.Background(), job.UID.String())
+			assert.Equal(t, tt.wantStatus, finished.Status)
+			assert.Equal(t, tt.wantError, finished.Error)
+			assert.Equal(t, tt.wantRuns, tt.executor.runs.Load())
+			assert.Len(t, finished.Attempts, int(tt.wantRuns))
+			for i, attempt := range finished.Attempts[:min(tt.executor.failures, tt.wantRuns)] {
+				assert.Equal(t, model.AttemptFailed, attempt.Outcome)
+				assert.Equal(t, fmt.Sprintf("run %d failed", i+1), attempt.Error)
+			}
+		})
+	}
+}
+
+func TestWorkerPool_RetryLeasedJob(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 0, 5)
+	pool.SetMaxRetries(1)
+
+	job := &model.Job{
+		UID:     uuid.New(),
+		Type:    "math",
+		Payload: model.MathJobPayload{Number: 4},
+		Status:  model.JobStatusPending,
+	}
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+	id := job.UID.String()
+
+	_, ok := pool.LeaseJob(ctx, "remote-1", nil, nil, 0)
+	assert.True(t, ok)
+	assert.NoError(t, pool.CompleteLeasedJob(ctx, "remote-1", id, nil, errors.New("connection reset")))
+
+	retried, _ := pool.GetJob(ctx, id)
+	assert.Equal(t, model.JobStatusPending, retried.Status)
+	assert.Equal(t, "attempt 1 failed: connection reset", retried.Error)
+	assert.Empty(t, retried.LeasedBy)
+
+	// The retry is handed out again and a permanent failure ends it
+	_, ok = pool.LeaseJob(ctx, "remote-2", nil, nil, 0)
+	assert.True(t, ok)
+	assert.NoError(t, pool.CompleteLeasedJob(ctx, "remote-2", id, nil, Permanent(errors.New("bad input"))))
+	failed, _ := pool.GetJob(ctx, id)
+	assert.Equal(t, model.JobStatusFailed, failed.Status)
+	assert.Equal(t, "bad input", failed.Error)
+	assert.Equal(t, 0, pool.jobQueue.len(""))
+}
@@ -0,0 +1,92 @@
+package pool
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"slices"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// maxRoutedKeys bounds how many recently routed keys a worker reports
+const maxRoutedKeys = 32
+
+// keyOwner returns the local worker a keyed job is routed to: of the workers
+// able to run it, the one scoring highest for the key. Scores depend only on
+// the key and the worker, so a key keeps going to the same worker and
+// executors can cache per-key state in memory. It returns nil for jobs
+// without a key and jobs no local worker can run.
+func (p *WorkerPool) keyOwner(job *model.Job) *worker {
+	if job.SerializationKey == "" {
+		return nil
+	}
+	var owner *worker
+	var best uint64
+	for _, w := range p.workers {
+		if !w.accepts(job) {
+			continue
+		}
+		if score := routeScore(job.SerializationKey, w.id); owner == nil || score > best {
+			owner, best = w, score
+		}
+	}
+	return owner
+}
+
+func routeScore(key string, workerID int) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	binary.Write(h, binary.LittleEndian, int64(workerID))
+	return h.Sum64()
+}
+
+// routed wraps a local worker's accept function so it only takes keyed jobs
+// routed to it
+func (p *WorkerPool) routed(w *worker, accept func(job *model.Job) bool) func(job *model.Job) bool {
+	return func(job *model.Job) bool {
+		if owner := p.keyOwner(job); owner != nil && owner != w {
+			return false
+		}
+		return accept(job)
+	}
+}
+
+// leasable reports whether a job may be leased to a remote worker. Keyed
+// jobs stay on the local worker they are routed to if there is one.
+func (p *WorkerPool) leasable(accept func(job *model.Job) bool) func(job *model.Job) bool {
+	return func(job *model.Job) bool {
+		return p.keyOwner(job) == nil && accept(job)
+	}
+}
+
+// routedKey records that the worker took a job with the key
+func (w *worker) routedKey(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if i := slices.Index(w.keys, key); i >= 0 {
+		w.keys = slices.Delete(w.keys, i, i+1)
+	}
+	w.keys = append(w.keys, key)
+	if len(w.keys) > maxRoutedKeys {
+		w.keys = w.keys[len(w.keys)-maxRoutedKeys:]
+	}
+}
+
+// stats describes the worker, listing the keys it took most recently first
+func (w *worker) stats() model.WorkerStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := model.WorkerStats{
+		ID:         w.id,
+		Capacity:   w.capacity,
+		SlotsInUse: w.used,
+	}
+	for c := range w.capabilities {
+		stats.Capabilities = append(stats.Capabilities, c)
+	}
+	slices.Sort(stats.Capabilities)
+	for i := len(w.keys) - 1; i >= 0; i-- {
+		stats.RoutedKeys = append(stats.RoutedKeys, w.keys[i])
+	}
+	return stats
+}
@@ -0,0 +1,103 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_KeyOwner(t *testing.T) {
+	pool := NewWorkerPool(context.Background(), 4, 5)
+	pool.AddWorkers(1, "gpu")
+
+	assert.Nil(t, pool.keyOwner(&model.Job{}))
+	assert.Nil(t, pool.keyOwner(&model.Job{SerializationKey: "a", Requires: []string{"tpu"}}))
+
+	gpu := pool.keyOwner(&model.Job{SerializationKey: "a", Requires: []string{"gpu"}})
+	assert.Equal(t, 4, gpu.id)
+
+	// Keys spread over the workers and keep their owner
+	owners := make(map[int]bool)
+	for i := 0; i < 100; i++ {
+		job := &model.Job{SerializationKey: fmt.Sprintf("customer-%d", i)}
+		owner := pool.keyOwner(job)
+		assert.Same(t, owner, pool.keyOwner(job))
+		owners[owner.id] = true
+	}
+	assert.Len(t, owners, 5)
+}
+
+func TestWorkerPool_StickyRouting(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 4, 20)
+	pool.Start()
+	defer pool.Stop()
+
+	keys := []string{"customer-1", "customer-2", "customer-3"}
+	var jobs []*model.Job
+	for i := 0; i < 12; i++ {
+		job := &model.Job{
+			UID:              uuid.New(),
+			Type:             "math",
+			Payload:          model.MathJobPayload{Number: i},
+			SerializationKey: keys[i%len(keys)],
+			Status:           model.JobStatusPending,
+		}
+		assert.NoError(t, pool.SubmitJob(ctx, job))
+		jobs = append(jobs, job)
+	}
+	waitForNJobsWithStatus(t, pool, len(jobs), model.JobStatusCompleted)
+
+	ranOn := make(map[string]string)
+	for _, job := range jobs {
+		stored, _ := pool.GetJob(ctx, job.UID.String())
+		worker := stored.Attempts[0].Worker
+		if prev, ok := ranOn[job.SerializationKey]; ok {
+			assert.Equal(t, prev, worker, job.SerializationKey)
+		}
+		ranOn[job.SerializationKey] = worker
+	}
+
+	stats := pool.Stats(ctx)
+	assert.Len(t, stats.WorkerDetails, 4)
+	for _, key := range keys {
+		owner := pool.keyOwner(&model.Job{SerializationKey: key})
+		assert.Equal(t, fmt.Sprintf("local-%d", owner.id), ranOn[key])
+		assert.Contains(t, stats.WorkerDetails[owner.id].RoutedKeys, key)
+	}
+}
+
+func TestWorkerPool_KeyedJobsStayLocal(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 5)
+
+	keyed := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 1}, SerializationKey: "a", Status: model.JobStatusPending}
+	plain := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 2}, Status: model.JobStatusPending}
+	assert.NoError(t, pool.SubmitJob(ctx, keyed))
+	assert.NoError(t, pool.SubmitJob(ctx, plain))
+
+	job, ok := pool.LeaseJob(ctx, "remote-1", nil, nil, 10*time.Millisecond)
+	assert.True(t, ok)
+	assert.Equal(t, plain.UID, job.UID)
+	_, ok = pool.LeaseJob(ctx, "remote-1", nil, nil, 10*time.Millisecond)
+	assert.False(t, ok)
+}
+
+func TestWorker_RoutedKeys(t *testing.T) {
+	w := newWorker(0, []string{"gpu", "arm"})
+	for i := 0; i < maxRoutedKeys+5; i++ {
+		w.routedKey(fmt.Sprintf("k%d", i))
+	}
+	w.routedKey("k10")
+
+	stats := w.stats()
+	assert.Equal(t, []string{"arm", "gpu"}, stats.Capabilities)
+	assert.Len(t, stats.RoutedKeys, maxRoutedKeys)
+	assert.Equal(t, []string{"k10", fmt.Sprintf("k%d", maxRoutedKeys+4)}, stats.RoutedKeys[:2])
+	assert.NotContains(t, stats.RoutedKeys, "k4")
+}
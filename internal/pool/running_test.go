@@ -0,0 +1,478 @@
+package pool_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/pooltest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mathJob(n int) *model.Job {
+	return &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: n}, Status: model.JobStatusPending}
+}
+
+// fakeExecutor returns a fixed result for every job
+type fakeExecutor struct {
+	result model.JobResult
+}
+
+func (e *fakeExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	fmt.Fprintf(logs, "running %s\n", job.UID)
+	return e.result, nil
+}
+
+// blockingExecutor holds every job until release is closed, counting how
+// many are running
+type blockingExecutor struct {
+	release chan struct{}
+
+	mu      sync.Mutex
+	running int
+}
+
+func (e *blockingExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	e.mu.Lock()
+	e.running++
+	e.mu.Unlock()
+
+	<-e.release
+
+	e.mu.Lock()
+	e.running--
+	e.mu.Unlock()
+	return model.MathJobResult{Result: 1}, nil
+}
+
+func TestWorkerPool_Basic(t *testing.T) {
+	// Create a pool with 2 workers and queue size of 5
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 2, 5)
+	p.Start()
+	defer p.Stop()
+
+	// Create a test job
+	job := &model.Job{
+		UID:     uuid.New(),
+		Type:    "sleep",
+		Payload: model.SleepJobPayload{Duration: "100ms"},
+		Status:  model.JobStatusPending,
+	}
+
+	// Submit the job
+	err := p.SubmitJob(ctx, job)
+	assert.NoError(t, err)
+
+	// Wait for job completion
+	completedJob := pooltest.WaitForStatus(t, p, job.UID.String(), model.JobStatusCompleted, time.Second)
+	assert.NotNil(t, completedJob.StartedAt)
+	assert.NotNil(t, completedJob.CompletedAt)
+	assert.Len(t, completedJob.Attempts, 1)
+	assert.Equal(t, model.AttemptCompleted, completedJob.Attempts[0].Outcome)
+
+	// Verify result
+	result, ok := completedJob.Result.(model.SleepJobResult)
+	assert.True(t, ok)
+	assert.Equal(t, "100ms", result.SleptFor)
+}
+
+func TestWorkerPool_Cancellation(t *testing.T) {
+	// Create a pool with context
+	ctx, cancel := context.WithCancel(context.Background())
+	p := pool.NewWorkerPool(ctx, 2, 5)
+	p.Start()
+	defer p.Stop()
+
+	// Create a long-running job
+	job := &model.Job{
+		UID:     uuid.New(),
+		Type:    "sleep",
+		Payload: model.SleepJobPayload{Duration: "5s"},
+		Status:  model.JobStatusPending,
+	}
+
+	// Submit the job
+	err := p.SubmitJob(ctx, job)
+	assert.NoError(t, err)
+
+	// Wait for job to start
+	runningJob := pooltest.WaitForStatus(t, p, job.UID.String(), model.JobStatusRunning, time.Second)
+	assert.NotNil(t, runningJob)
+
+	// Cancel context
+	cancel()
+
+	// Wait for job to fail
+	failedJob := pooltest.WaitForStatus(t, p, job.UID.String(), model.JobStatusFailed, time.Second)
+	assert.Contains(t, failedJob.Error, "context canceled")
+}
+
+func TestWorkerPool_InvalidJobType(t *testing.T) {
+	h := pooltest.New(t)
+
+	// Create a job with invalid type
+	job := h.Submit(&model.Job{
+		Type:    "invalid",
+		Payload: model.SleepJobPayload{Duration: "100ms"},
+	})
+
+	assert.True(t, h.RunNext())
+	failedJob := h.Job(job.UID)
+	assert.Equal(t, model.JobStatusFailed, failedJob.Status)
+	assert.Contains(t, failedJob.Error, "unknown job type")
+}
+
+func TestWorkerPool_Capabilities(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 1, 5)
+	p.AddWorkers(1, "gpu", "large-mem")
+	p.Start()
+	defer p.Stop()
+
+	gpuJob := &model.Job{
+		UID:      uuid.New(),
+		Type:     "math",
+		Payload:  model.MathJobPayload{Number: 3},
+		Requires: []string{"gpu"},
+		Status:   model.JobStatusPending,
+	}
+	err := p.SubmitJob(ctx, gpuJob)
+	assert.NoError(t, err)
+	pooltest.WaitForStatus(t, p, gpuJob.UID.String(), model.JobStatusCompleted, time.Second)
+
+	tpuJob := &model.Job{
+		UID:      uuid.New(),
+		Type:     "math",
+		Payload:  model.MathJobPayload{Number: 3},
+		Requires: []string{"gpu", "tpu"},
+		Status:   model.JobStatusPending,
+	}
+	err = p.SubmitJob(ctx, tpuJob)
+	assert.ErrorIs(t, err, pool.ErrUnschedulable)
+	_, exists := p.GetJob(ctx, tpuJob.UID.String())
+	assert.False(t, exists)
+}
+
+func TestWorkerPool_Versioning(t *testing.T) {
+	h := pooltest.New(t)
+	job := h.Submit(mathJob(3))
+
+	// pending -> running -> completed
+	assert.True(t, h.RunNext())
+	assert.Equal(t, int64(3), h.Job(job.UID).Version)
+	h.AssertTransitions(job.UID, model.JobStatusPending, model.JobStatusRunning, model.JobStatusCompleted)
+}
+
+func TestWorkerPool_RegisterExecutor(t *testing.T) {
+	ctx := context.Background()
+	h := pooltest.New(t, pooltest.WithExecutor("math", &fakeExecutor{result: model.MathJobResult{Result: 99}}))
+	job := h.Submit(mathJob(4))
+
+	assert.True(t, h.RunNext())
+	completedJob := h.Job(job.UID)
+	assert.Equal(t, model.JobStatusCompleted, completedJob.Status)
+	assert.Equal(t, model.MathJobResult{Result: 99}, completedJob.Result)
+
+	var logs bytes.Buffer
+	assert.NoError(t, h.Pool.StreamJobLogs(ctx, job.UID.String(), true, &logs))
+	assert.Equal(t, fmt.Sprintf("running %s\n", job.UID), logs.String())
+}
+
+func TestWorkerPool_SubmitJobAbandoned(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("cancelled before commit", func(t *testing.T) {
+		p := pool.NewWorkerPool(ctx, 1, 1)
+		submitCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		job := mathJob(3)
+		assert.ErrorIs(t, p.SubmitJob(submitCtx, job), context.Canceled)
+		_, ok := p.GetJob(ctx, job.UID.String())
+		assert.False(t, ok)
+		// The queue slot was never taken
+		assert.NoError(t, p.SubmitJob(ctx, mathJob(3)))
+	})
+
+	t.Run("cancelled while delayed", func(t *testing.T) {
+		p := pool.NewWorkerPool(ctx, 1, 1)
+		p.SetFaults(model.FaultScenario{QueueLatency: "1s"})
+		submitCtx, cancel := context.WithCancel(ctx)
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		job := mathJob(3)
+		assert.ErrorIs(t, p.SubmitJob(submitCtx, job), context.Canceled)
+		_, ok := p.GetJob(ctx, job.UID.String())
+		assert.False(t, ok)
+	})
+
+	t.Run("cancelled after commit", func(t *testing.T) {
+		p := pool.NewWorkerPool(ctx, 1, 1)
+		p.Start()
+		defer p.Stop()
+		submitCtx, cancel := context.WithCancel(ctx)
+
+		job := mathJob(3)
+		assert.NoError(t, p.SubmitJob(submitCtx, job))
+		cancel()
+		pooltest.WaitForStatus(t, p, job.UID.String(), model.JobStatusCompleted, time.Second)
+	})
+}
+
+func TestWorkerPool_Placement(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 1, 5)
+	p.SetInstanceLabels(map[string]string{"region": "eu-west", "zone": "eu-west-1a"})
+	p.Start()
+	defer p.Stop()
+
+	placed := func(placement map[string]string) *model.Job {
+		return &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 4}, Placement: placement, Status: model.JobStatusPending}
+	}
+
+	// Nothing has been seen in us-east yet
+	east := placed(map[string]string{"region": "us-east", "hardware": "a100"})
+	err := p.SubmitJob(ctx, east)
+	assert.ErrorIs(t, err, pool.ErrUnschedulable)
+	assert.EqualError(t, err, "no worker can run the job: placed on hardware=a100, region=us-east")
+
+	_, ok := p.LeaseJob(ctx, "east-1", nil, map[string]string{"region": "us-east", "hardware": "a100"}, 0)
+	assert.False(t, ok)
+	assert.NoError(t, p.SubmitJob(ctx, east))
+	local := placed(map[string]string{"zone": "eu-west-1a"})
+	assert.NoError(t, p.SubmitJob(ctx, local))
+
+	// The local worker leaves the us-east job for a remote worker there
+	pooltest.WaitForStatus(t, p, local.UID.String(), model.JobStatusCompleted, time.Second)
+	got, _ := p.GetJob(ctx, east.UID.String())
+	assert.Equal(t, model.JobStatusPending, got.Status)
+
+	_, ok = p.LeaseJob(ctx, "east-2", nil, map[string]string{"region": "us-east", "hardware": "t4"}, 0)
+	assert.False(t, ok)
+	leased, ok := p.LeaseJob(ctx, "east-1", nil, map[string]string{"region": "us-east", "hardware": "a100"}, 0)
+	assert.True(t, ok)
+	assert.Equal(t, east.UID, leased.UID)
+}
+
+func TestWorkerPool_OverflowSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// A pool with no room in its queue spills everything
+	stopped := pool.NewWorkerPool(ctx, 1, 0)
+	require.NoError(t, stopped.SetOverflow(dir, 10))
+	var ids []string
+	for i := range 3 {
+		job := mathJob(i)
+		assert.NoError(t, stopped.SubmitJob(ctx, job))
+		ids = append(ids, job.UID.String())
+	}
+	stopped.Stop()
+
+	p := pool.NewWorkerPool(ctx, 1, 5)
+	require.NoError(t, p.SetOverflow(dir, 10))
+	p.Start()
+	defer p.Stop()
+	for _, id := range ids {
+		pooltest.WaitForStatus(t, p, id, model.JobStatusCompleted, time.Second)
+	}
+	assert.Eventually(t, func() bool { return p.Stats(ctx).Overflow.Depth == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestWorkerPool_JobTooHeavy(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 2, 5)
+	p.SetWorkerCapacity(2)
+	p.Start()
+	defer p.Stop()
+
+	job := mathJob(1)
+	job.Weight = 3
+	err := p.SubmitJob(ctx, job)
+	assert.ErrorIs(t, err, pool.ErrUnschedulable)
+	assert.EqualError(t, err, "no worker can run the job: weight 3")
+
+	job = mathJob(1)
+	job.Weight = 2
+	assert.NoError(t, p.SubmitJob(ctx, job))
+	pooltest.WaitForStatus(t, p, job.UID.String(), model.JobStatusCompleted, time.Second)
+}
+
+func TestWorkerPool_JobOwnTimeout(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 1, 5)
+	p.SetJobTimeout("sleep", time.Hour)
+	p.Start()
+	defer p.Stop()
+
+	slow := &model.Job{
+		UID:     uuid.New(),
+		Type:    "sleep",
+		Payload: model.SleepJobPayload{Duration: "1h"},
+		Timeout: "50ms",
+		Status:  model.JobStatusPending,
+	}
+	assert.NoError(t, p.SubmitJob(ctx, slow))
+	failed := pooltest.WaitForStatus(t, p, slow.UID.String(), model.JobStatusFailed, time.Second)
+	assert.Equal(t, "job timed out after 50ms", failed.Error)
+}
+
+// inputExecutor returns the size of the job's input file
+type inputExecutor struct{}
+
+func (inputExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	_, r, err := pool.Input(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	return model.MathJobResult{Result: len(data)}, err
+}
+
+func TestWorkerPool_Inputs(t *testing.T) {
+	ctx := context.Background()
+	store, err := blob.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	h := pooltest.New(t, pooltest.WithExecutor("math", inputExecutor{}))
+	h.Pool.SetBlobStore(store)
+
+	job := mathJob(1)
+	assert.NoError(t, h.Pool.StoreInput(ctx, job, "rows.csv", "text/csv", strings.NewReader("a,b\n1,2\n")))
+	assert.Equal(t, &model.JobInput{Filename: "rows.csv", ContentType: "text/csv", Size: 8}, job.Input)
+	h.Submit(job)
+
+	assert.True(t, h.RunNext())
+	assert.Equal(t, model.MathJobResult{Result: 8}, h.Job(job.UID).Result)
+
+	input, r, err := h.Pool.OpenInput(ctx, job.UID.String())
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "rows.csv", input.Filename)
+	assert.Equal(t, "a,b\n1,2\n", string(data))
+
+	plain := h.Submit(&model.Job{Type: "sleep", Payload: model.SleepJobPayload{Duration: "1ms"}})
+	_, _, err = h.Pool.OpenInput(ctx, plain.UID.String())
+	assert.ErrorIs(t, err, pool.ErrNoInput)
+	_, _, err = h.Pool.OpenInput(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, pool.ErrJobNotFound)
+}
+
+// reportExecutor saves a report artifact for every job it runs
+type reportExecutor struct{}
+
+func (reportExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	if _, err := pool.SaveArtifact(ctx, "report.txt", "text/plain", strings.NewReader("all good")); err != nil {
+		return nil, err
+	}
+	return model.MathJobResult{Result: 1}, nil
+}
+
+func TestWorkerPool_Artifacts(t *testing.T) {
+	ctx := context.Background()
+	store, err := blob.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+	h := pooltest.New(t, pooltest.WithExecutor("math", reportExecutor{}))
+	h.Pool.SetBlobStore(store)
+
+	job := h.Submit(mathJob(1))
+	assert.True(t, h.RunNext())
+	assert.Len(t, h.Job(job.UID).Artifacts, 1)
+
+	extra, err := h.Pool.CreateArtifact(ctx, job.UID.String(), "data.csv", "text/csv", strings.NewReader("a,b"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), extra.Size)
+	assert.Nil(t, extra.ExpiresAt)
+
+	artifacts := h.Pool.ListArtifacts(ctx, job.UID.String())
+	assert.Len(t, artifacts, 2)
+	assert.Equal(t, "report.txt", artifacts[0].Name)
+	assert.Equal(t, "data.csv", artifacts[1].Name)
+	assert.Empty(t, h.Pool.ListArtifacts(ctx, uuid.NewString()))
+
+	artifact, r, err := h.Pool.OpenArtifact(ctx, artifacts[0].ID)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "text/plain", artifact.ContentType)
+	assert.Equal(t, "all good", string(data))
+
+	assert.NoError(t, h.Pool.DeleteArtifact(ctx, extra.ID))
+	assert.ErrorIs(t, h.Pool.DeleteArtifact(ctx, extra.ID), pool.ErrArtifactNotFound)
+	_, _, err = h.Pool.OpenArtifact(ctx, extra.ID)
+	assert.ErrorIs(t, err, pool.ErrArtifactNotFound)
+	assert.Equal(t, []string{artifacts[0].ID}, h.Job(job.UID).Artifacts)
+
+	_, err = h.Pool.CreateArtifact(ctx, uuid.NewString(), "x", "", strings.NewReader("x"))
+	assert.ErrorIs(t, err, pool.ErrJobNotFound)
+	_, err = pool.SaveArtifact(ctx, "x", "", strings.NewReader("x"))
+	assert.ErrorIs(t, err, pool.ErrNoJob)
+}
+
+func TestWorkerPool_LargeJobs(t *testing.T) {
+	ctx := context.Background()
+	exec := &blockingExecutor{release: make(chan struct{})}
+	p := pool.NewWorkerPool(ctx, 3, 5)
+	p.SetLargeJobs(100, 1, 2)
+	p.RegisterExecutor("http", exec)
+	p.Start()
+	defer p.Stop()
+
+	var large []*model.Job
+	submitLarge := func() error {
+		job := &model.Job{
+			UID:     uuid.New(),
+			Type:    "http",
+			Payload: model.HTTPJobPayload{URL: "https://example.com/", Body: strings.Repeat("x", 200)},
+			Status:  model.JobStatusPending,
+		}
+		err := p.SubmitJob(ctx, job)
+		if err == nil {
+			large = append(large, job)
+		}
+		return err
+	}
+	running := func() int {
+		exec.mu.Lock()
+		defer exec.mu.Unlock()
+		return exec.running
+	}
+
+	assert.NoError(t, submitLarge())
+	assert.Equal(t, model.JobLaneLarge, large[0].Lane)
+	assert.Eventually(t, func() bool { return running() == 1 }, time.Second, 10*time.Millisecond)
+
+	// Further large jobs wait for the lane's only slot, in a queue of
+	// their own
+	assert.NoError(t, submitLarge())
+	assert.NoError(t, submitLarge())
+	assert.EqualError(t, submitLarge(), "large job queue is full")
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, running())
+	assert.Equal(t, &model.LaneStats{ThresholdBytes: 100, Slots: 1, Running: 1, QueueDepth: 2, QueueCapacity: 2}, p.Stats(ctx).LargeJobs)
+
+	// Small jobs keep flowing on the other workers
+	small := mathJob(4)
+	assert.NoError(t, p.SubmitJob(ctx, small))
+	assert.Empty(t, small.Lane)
+	pooltest.WaitForStatus(t, p, small.UID.String(), model.JobStatusCompleted, time.Second)
+	assert.Equal(t, 0, p.Stats(ctx).QueueDepth)
+
+	close(exec.release)
+	for _, job := range large {
+		pooltest.WaitForStatus(t, p, job.UID.String(), model.JobStatusCompleted, time.Second)
+	}
+	assert.Eventually(t, func() bool { return p.Stats(ctx).LargeJobs.Running == 0 }, time.Second, 10*time.Millisecond)
+}
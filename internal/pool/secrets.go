@@ -0,0 +1,68 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/secrets"
+)
+
+// SetSecrets sets where the secrets job payloads reference are looked up.
+// Without it, jobs referencing secrets fail. It must be called before Start.
+func (p *WorkerPool) SetSecrets(provider secrets.Provider) {
+	p.secrets = provider
+}
+
+// PayloadExporter is implemented by executors that copy the payload they
+// are handed out of the service, e.g. into a Kubernetes object. Jobs
+// referencing secrets fail on them instead of having the values resolved.
+type PayloadExporter interface {
+	ExportsPayload()
+}
+
+// withSecrets returns the job e is handed: a copy whose payload has its
+// secret references replaced by their values, or the job itself if it
+// references none. The copy is never stored.
+func (p *WorkerPool) withSecrets(ctx context.Context, job *model.Job, e Executor) (*model.Job, error) {
+	user, ok := job.Payload.(model.SecretUser)
+	if !ok {
+		return job, nil
+	}
+	names := user.Secrets()
+	if len(names) == 0 {
+		return job, nil
+	}
+	if _, ok := e.(PayloadExporter); ok {
+		return nil, Permanent(fmt.Errorf("secret %q is referenced but the executor for %s jobs would expose its value", names[0], job.Type))
+	}
+	if p.secrets == nil {
+		return nil, Permanent(fmt.Errorf("secret %q is referenced but no secrets backend is configured", names[0]))
+	}
+
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := p.lookupSecret(ctx, job.Type, name)
+		if errors.Is(err, secrets.ErrNotFound) {
+			return nil, Permanent(fmt.Errorf("secret %q not found", name))
+		}
+		if errors.Is(err, secrets.ErrInvalidName) {
+			return nil, Permanent(err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", name, err)
+		}
+		values[name] = value
+	}
+	resolved := *job
+	resolved.Payload = user.WithSecrets(values)
+	return &resolved, nil
+}
+
+func (p *WorkerPool) lookupSecret(ctx context.Context, jobType, name string) (string, error) {
+	if typed, ok := p.secrets.(secrets.TypedProvider); ok {
+		return typed.GetForType(ctx, jobType, name)
+	}
+	return p.secrets.Get(ctx, name)
+}
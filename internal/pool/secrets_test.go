@@ -0,0 +1,133 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/secrets"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// mapSecrets serves secrets from a map, failing every lookup with err if set
+type mapSecrets struct {
+	values map[string]string
+	err    error
+}
+
+func (s mapSecrets) Get(ctx context.Context, name string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	value, ok := s.values[name]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+// envExecutor records the container environment it is handed
+type envExecutor struct {
+	env map[string]model.SecretString
+}
+
+func (e *envExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	e.env = job.Payload.(model.ContainerJobPayload).Env
+	return model.ContainerJobResult{}, nil
+}
+
+// exportingExecutor is an envExecutor that copies payloads out of the service
+type exportingExecutor struct {
+	envExecutor
+}
+
+func (e *exportingExecutor) ExportsPayload() {}
+
+func TestWorkerPool_Secrets(t *testing.T) {
+	ctx := context.Background()
+	submit := func(pool *WorkerPool) *model.Job {
+		job := &model.Job{
+			UID:  uuid.New(),
+			Type: "container",
+			Payload: model.ContainerJobPayload{
+				Image: "alpine:3",
+				Env:   map[string]model.SecretString{"GREETING": {Value: "hello"}, "TOKEN": {Secret: "github"}},
+			},
+			Status: model.JobStatusPending,
+		}
+		assert.NoError(t, pool.SubmitJob(ctx, job))
+		assert.True(t, pool.ProcessNext())
+		stored, _ := pool.GetJob(ctx, job.UID.String())
+		return stored
+	}
+
+	t.Run("resolved for the executor only", func(t *testing.T) {
+		pool := NewWorkerPool(ctx, 0, 5)
+		executor := &envExecutor{}
+		pool.RegisterExecutor("container", executor)
+		pool.SetSecrets(mapSecrets{values: map[string]string{"github": "gh-token"}})
+
+		job := submit(pool)
+		assert.Equal(t, model.JobStatusCompleted, job.Status)
+		assert.Equal(t, map[string]model.SecretString{"GREETING": {Value: "hello"}, "TOKEN": {Value: "gh-token"}}, executor.env)
+		assert.Equal(t, model.SecretString{Secret: "github"}, job.Payload.(model.ContainerJobPayload).Env["TOKEN"])
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		pool := NewWorkerPool(ctx, 0, 5)
+		pool.RegisterExecutor("container", &envExecutor{})
+		pool.SetMaxRetries(3)
+		pool.SetSecrets(mapSecrets{})
+
+		job := submit(pool)
+		assert.Equal(t, model.JobStatusFailed, job.Status)
+		assert.Equal(t, `secret "github" not found`, job.Error)
+	})
+
+	t.Run("no secrets backend", func(t *testing.T) {
+		pool := NewWorkerPool(ctx, 0, 5)
+		pool.RegisterExecutor("container", &envExecutor{})
+
+		job := submit(pool)
+		assert.Equal(t, model.JobStatusFailed, job.Status)
+		assert.Equal(t, `secret "github" is referenced but no secrets backend is configured`, job.Error)
+	})
+
+	t.Run("refused by an executor exporting the payload", func(t *testing.T) {
+		pool := NewWorkerPool(ctx, 0, 5)
+		executor := &exportingExecutor{}
+		pool.RegisterExecutor("container", executor)
+		pool.SetSecrets(mapSecrets{values: map[string]string{"github": "gh-token"}})
+
+		job := submit(pool)
+		assert.Equal(t, model.JobStatusFailed, job.Status)
+		assert.Equal(t, `secret "github" is referenced but the executor for container jobs would expose its value`, job.Error)
+		assert.Nil(t, executor.env)
+	})
+
+	t.Run("name the backend can't look up", func(t *testing.T) {
+		pool := NewWorkerPool(ctx, 0, 5)
+		pool.RegisterExecutor("container", &envExecutor{})
+		pool.SetMaxRetries(3)
+		pool.SetSecrets(mapSecrets{err: fmt.Errorf("%w: %q", secrets.ErrInvalidName, "github")})
+
+		job := submit(pool)
+		assert.Equal(t, model.JobStatusFailed, job.Status)
+		assert.Equal(t, `invalid secret name: "github"`, job.Error)
+	})
+
+	t.Run("backend failure is retried", func(t *testing.T) {
+		pool := NewWorkerPool(ctx, 0, 5)
+		pool.RegisterExecutor("container", &envExecutor{})
+		pool.SetMaxRetries(1)
+		pool.SetSecrets(mapSecrets{err: errors.New("vault sealed")})
+
+		job := submit(pool)
+		assert.Equal(t, model.JobStatusPending, job.Status)
+		assert.Equal(t, `secret "github": vault sealed`, job.Attempts[0].Error)
+	})
+}
@@ -0,0 +1,198 @@
+package pool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// MaxShadowHistory is how many shadow runs are kept
+const MaxShadowHistory = 1000
+
+// shadowTable holds the shadow executors being validated and the outcome
+// of their recent runs
+type shadowTable struct {
+	executors map[string]Executor
+	notify    func(model.ShadowRun)
+
+	mu      sync.Mutex
+	history []model.ShadowRun
+}
+
+func newShadowTable() *shadowTable {
+	return &shadowTable{executors: make(map[string]Executor)}
+}
+
+// shadowOutcome is how one side of a shadow run ended
+type shadowOutcome struct {
+	result  model.JobResult
+	err     error
+	elapsed time.Duration
+}
+
+// RegisterShadowExecutor runs a copy of every job of the given type that a
+// local worker runs on e as well, alongside the executor that runs it. The
+// shadow's outcome never affects the job; it is compared with the job's and
+// the comparison recorded. Shadow runs discard their output and can't
+// annotate the job, save artifacts or report costs. It must be called
+// before Start.
+func (p *WorkerPool) RegisterShadowExecutor(jobType string, e Executor) {
+	p.shadows.executors[jobType] = e
+}
+
+// NotifyShadowRuns has fn called with every shadow run once it has been
+// compared. It must be called before Start.
+func (p *WorkerPool) NotifyShadowRuns(fn func(model.ShadowRun)) {
+	p.shadows.notify = fn
+}
+
+// ShadowRuns returns the recent shadow runs of a job type, or of every type
+// when jobType is empty, oldest first
+func (p *WorkerPool) ShadowRuns(jobType string) []model.ShadowRun {
+	p.shadows.mu.Lock()
+	defer p.shadows.mu.Unlock()
+	runs := make([]model.ShadowRun, 0)
+	for _, run := range p.shadows.history {
+		if jobType == "" || run.JobType == jobType {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+// startShadow starts a shadow run of the job if its type has a shadow
+// executor. The returned func hands it the job's own outcome to compare
+// with, without waiting for the shadow to finish.
+func (p *WorkerPool) startShadow(job *model.Job) func(result model.JobResult, err error, elapsed time.Duration) {
+	e, ok := p.shadows.executors[job.Type]
+	if !ok {
+		return func(model.JobResult, error, time.Duration) {}
+	}
+	copied := *job
+	official := make(chan shadowOutcome, 1)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ctx, cancel := p.withJobTimeout(&copied)
+		defer cancel()
+		started := p.clock.Now()
+		var shadow shadowOutcome
+		resolved, err := p.withSecrets(ctx, &copied, e)
+		if err == nil {
+			shadow.result, err = e.Execute(ctx, resolved, io.Discard)
+		}
+		shadow.err = p.timeoutError(ctx, &copied, err)
+		shadow.elapsed = p.clock.Now().Sub(started)
+
+		select {
+		case outcome := <-official:
+			p.recordShadowRun(compareShadowRun(&copied, outcome, shadow, p.clock.Now()))
+		case <-p.ctx.Done():
+		}
+	}()
+	return func(result model.JobResult, err error, elapsed time.Duration) {
+		official <- shadowOutcome{result: result, err: err, elapsed: elapsed}
+	}
+}
+
+func (p *WorkerPool) recordShadowRun(run model.ShadowRun) {
+	p.shadows.mu.Lock()
+	p.shadows.history = append(p.shadows.history, run)
+	if len(p.shadows.history) > MaxShadowHistory {
+		p.shadows.history = slices.Delete(p.shadows.history, 0, len(p.shadows.history)-MaxShadowHistory)
+	}
+	p.shadows.mu.Unlock()
+	if p.shadows.notify != nil {
+		p.shadows.notify(run)
+	}
+}
+
+func compareShadowRun(job *model.Job, official, shadow shadowOutcome, at time.Time) model.ShadowRun {
+	run := model.ShadowRun{
+		JobUID:          job.UID,
+		JobType:         job.Type,
+		Result:          encodeResult(official.result),
+		ShadowResult:    encodeResult(shadow.result),
+		DurationSeconds: official.elapsed.Seconds(),
+		ShadowSeconds:   shadow.elapsed.Seconds(),
+		ComparedAt:      at,
+	}
+	if official.err != nil {
+		run.Error = official.err.Error()
+	}
+	if shadow.err != nil {
+		run.ShadowError = shadow.err.Error()
+	}
+	if run.Error != run.ShadowError {
+		run.Differences = append(run.Differences, "error")
+	}
+	run.Differences = append(run.Differences, jsonDifferences("result", run.Result, run.ShadowResult)...)
+	run.Match = len(run.Differences) == 0
+	return run
+}
+
+func encodeResult(result model.JobResult) json.RawMessage {
+	if result == nil {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// jsonDifferences lists the paths below path at which two JSON documents
+// differ
+func jsonDifferences(path string, a, b json.RawMessage) []string {
+	var va, vb any
+	if len(a) > 0 {
+		json.Unmarshal(a, &va)
+	}
+	if len(b) > 0 {
+		json.Unmarshal(b, &vb)
+	}
+	return valueDifferences(path, va, vb)
+}
+
+func valueDifferences(path string, a, b any) []string {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		var diffs []string
+		for _, key := range slices.Sorted(maps.Keys(a)) {
+			diffs = append(diffs, valueDifferences(path+"."+key, a[key], b[key])...)
+		}
+		for _, key := range slices.Sorted(maps.Keys(b)) {
+			if _, ok := a[key]; !ok {
+				diffs = append(diffs, path+"."+key)
+			}
+		}
+		return diffs
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			break
+		}
+		var diffs []string
+		for i := range a {
+			diffs = append(diffs, valueDifferences(fmt.Sprintf("%s[%d]", path, i), a[i], b[i])...)
+		}
+		return diffs
+	}
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+	return []string{path}
+}
@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// offByOneExecutor is a math executor rewrite that gets every sum wrong
+// past 10
+type offByOneExecutor struct{}
+
+func (offByOneExecutor) Execute(ctx context.Context, job *model.Job, logs io.Writer) (model.JobResult, error) {
+	n := job.Payload.(model.MathJobPayload).Number
+	if n == 0 {
+		return nil, errors.New("shadow failed")
+	}
+	result := n * (n - 1) / 2
+	if n > 10 {
+		result++
+	}
+	return model.MathJobResult{Result: result}, nil
+}
+
+func TestWorkerPool_ShadowExecutor(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 10)
+	pool.RegisterShadowExecutor("math", offByOneExecutor{})
+	notified := make(chan model.ShadowRun, 1)
+	pool.NotifyShadowRuns(func(run model.ShadowRun) { notified <- run })
+
+	run := func(n int) *model.Job {
+		t.Helper()
+		job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: n}, Status: model.JobStatusPending}
+		require.NoError(t, pool.store.Put(job))
+		pool.runJob(0, job)
+		select {
+		case run := <-notified:
+			assert.Equal(t, job.UID, run.JobUID)
+		case <-time.After(time.Second):
+			t.Fatal("shadow run was not compared")
+		}
+		return job
+	}
+	matched := run(5)
+	differed := run(20)
+	failed := run(0)
+
+	// The shadow never changes the job's outcome
+	job, _ := pool.GetJob(ctx, differed.UID.String())
+	assert.Equal(t, model.MathJobResult{Result: 190}, job.Result)
+
+	runs := pool.ShadowRuns("")
+	require.Len(t, runs, 3)
+	assert.Equal(t, matched.UID, runs[0].JobUID)
+	assert.True(t, runs[0].Match)
+	assert.Empty(t, runs[0].Differences)
+
+	assert.Equal(t, differed.UID, runs[1].JobUID)
+	assert.False(t, runs[1].Match)
+	assert.Equal(t, []string{"result.result"}, runs[1].Differences)
+	assert.JSONEq(t, `{"result": 190}`, string(runs[1].Result))
+	assert.JSONEq(t, `{"result": 191}`, string(runs[1].ShadowResult))
+
+	assert.Equal(t, failed.UID, runs[2].JobUID)
+	assert.Equal(t, "shadow failed", runs[2].ShadowError)
+	assert.Equal(t, []string{"error", "result"}, runs[2].Differences)
+
+	assert.Empty(t, pool.ShadowRuns("sleep"))
+}
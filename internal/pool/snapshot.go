@@ -0,0 +1,93 @@
+package pool
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// snapshot returns the queued jobs, oldest first
+func (q *jobQueue) snapshot() []*model.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.items)
+}
+
+// SnapshotQueue returns copies of the jobs waiting in the queue, oldest
+// first, followed by those spilled to the overflow queue
+func (p *WorkerPool) SnapshotQueue(ctx context.Context) *model.QueueSnapshot {
+	// The overflow queue is read first, so a job refilled from it in the
+	// meantime shows up twice rather than not at all
+	var spilled []*model.Job
+	if p.overflow != nil {
+		var err error
+		if spilled, err = p.overflow.pending(); err != nil {
+			slog.Error("Failed to read overflow queue", "error", err)
+		}
+	}
+	snapshot := &model.QueueSnapshot{TakenAt: p.clock.Now(), Jobs: make([]*model.Job, 0)}
+	seen := make(map[uuid.UUID]bool)
+	for _, queued := range append(p.jobQueue.snapshot(), spilled...) {
+		if seen[queued.UID] {
+			continue
+		}
+		seen[queued.UID] = true
+		job, exists := p.store.Get(queued.UID.String())
+		if !exists || job.Status != model.JobStatusPending {
+			continue
+		}
+		snapshot.Jobs = append(snapshot.Jobs, job)
+	}
+	return snapshot
+}
+
+// ReplayQueue submits the jobs of a snapshot in order, keeping their UIDs so
+// a snapshot replayed twice queues each job once. Jobs are queued afresh:
+// their attempts, leases and progress are dropped. Each job is passed to
+// admit first, which may turn it away as a submission would be. Jobs that
+// already exist, are turned away or can't be submitted are skipped, and the
+// rest are still queued.
+func (p *WorkerPool) ReplayQueue(ctx context.Context, snapshot *model.QueueSnapshot, admit func(context.Context, *model.Job) error) *model.ReplayResult {
+	result := &model.ReplayResult{Queued: make([]uuid.UUID, 0)}
+	skip := func(job *model.Job, reason string) {
+		result.Skipped = append(result.Skipped, model.ReplaySkip{JobUID: job.UID, Reason: reason})
+	}
+	for _, job := range snapshot.Jobs {
+		if job.UID == uuid.Nil || job.Payload == nil {
+			skip(job, "job has no uid or payload")
+			continue
+		}
+		if _, exists := p.store.Get(job.UID.String()); exists {
+			skip(job, "job already exists")
+			continue
+		}
+		now := p.clock.Now()
+		replayed := *job
+		replayed.Status = model.JobStatusPending
+		replayed.LeasedBy = ""
+		replayed.Attempts = nil
+		replayed.RetryAt = nil
+		replayed.StartedAt = nil
+		replayed.CompletedAt = nil
+		replayed.Result = nil
+		replayed.Error = ""
+		replayed.Cost = 0
+		replayed.ArtifactURLs = nil
+		if replayed.CreatedAt == nil {
+			replayed.CreatedAt = &now
+		}
+		if err := admit(ctx, &replayed); err != nil {
+			skip(job, err.Error())
+			continue
+		}
+		if err := p.SubmitJob(ctx, &replayed); err != nil {
+			skip(job, err.Error())
+			continue
+		}
+		result.Queued = append(result.Queued, job.UID)
+	}
+	return result
+}
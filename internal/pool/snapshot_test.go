@@ -0,0 +1,93 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func admitAll(context.Context, *model.Job) error { return nil }
+
+func TestWorkerPool_SnapshotAndReplayQueue(t *testing.T) {
+	ctx := context.Background()
+	source := NewWorkerPool(ctx, 1, 10)
+	var submitted []uuid.UUID
+	for _, n := range []int{1, 2, 3} {
+		job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: n}, Status: model.JobStatusPending, Labels: map[string]string{"n": "x"}}
+		require.NoError(t, source.SubmitJob(ctx, job))
+		submitted = append(submitted, job.UID)
+	}
+
+	snapshot := source.SnapshotQueue(ctx)
+	require.Len(t, snapshot.Jobs, 3)
+	for i, job := range snapshot.Jobs {
+		assert.Equal(t, submitted[i], job.UID)
+	}
+
+	target := NewWorkerPool(ctx, 1, 10)
+	existing := &model.Job{UID: submitted[1], Type: "math", Payload: model.MathJobPayload{Number: 2}, Status: model.JobStatusCompleted}
+	require.NoError(t, target.store.Put(existing))
+	snapshot.Jobs[0].Attempts = []model.JobAttempt{{Worker: "worker-0"}}
+
+	result := target.ReplayQueue(ctx, snapshot, admitAll)
+	assert.Equal(t, []uuid.UUID{submitted[0], submitted[2]}, result.Queued)
+	assert.Equal(t, []model.ReplaySkip{{JobUID: submitted[1], Reason: "job already exists"}}, result.Skipped)
+	assert.Equal(t, 2, target.jobQueue.len(""))
+
+	job, ok := target.GetJob(ctx, submitted[0].String())
+	require.True(t, ok)
+	assert.Equal(t, model.JobStatusPending, job.Status)
+	assert.Empty(t, job.Attempts)
+	assert.Equal(t, map[string]string{"n": "x"}, job.Labels)
+	assert.Equal(t, model.MathJobPayload{Number: 1}, job.Payload)
+
+	// Replaying again queues nothing new
+	result = target.ReplayQueue(ctx, snapshot, admitAll)
+	assert.Empty(t, result.Queued)
+	assert.Len(t, result.Skipped, 3)
+}
+
+func TestWorkerPool_SnapshotQueueOverflow(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 2)
+	require.NoError(t, pool.SetOverflow(t.TempDir(), 5))
+
+	// The pool isn't started, so two jobs fill the queue and the rest spill
+	var submitted []uuid.UUID
+	for i := range 4 {
+		job := mathJob(i)
+		require.NoError(t, pool.SubmitJob(ctx, job))
+		submitted = append(submitted, job.UID)
+	}
+	require.Equal(t, 2, pool.Stats(ctx).Overflow.Depth)
+
+	snapshot := pool.SnapshotQueue(ctx)
+	var uids []uuid.UUID
+	for _, job := range snapshot.Jobs {
+		uids = append(uids, job.UID)
+	}
+	assert.Equal(t, submitted, uids)
+}
+
+func TestWorkerPool_ReplayQueueAdmit(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 10)
+	snapshot := &model.QueueSnapshot{Jobs: []*model.Job{mathJob(1), mathJob(2)}}
+	snapshot.Jobs[1].Type = "sleep"
+
+	result := pool.ReplayQueue(ctx, snapshot, func(_ context.Context, job *model.Job) error {
+		if job.Type != "math" {
+			return errors.New(job.Type + " jobs are not enabled")
+		}
+		return nil
+	})
+	assert.Equal(t, []uuid.UUID{snapshot.Jobs[0].UID}, result.Queued)
+	assert.Equal(t, []model.ReplaySkip{{JobUID: snapshot.Jobs[1].UID, Reason: "sleep jobs are not enabled"}}, result.Skipped)
+	_, exists := pool.GetJob(ctx, snapshot.Jobs[1].UID.String())
+	assert.False(t, exists)
+}
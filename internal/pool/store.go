@@ -0,0 +1,343 @@
+package pool
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// JobStore holds the pool's jobs. Implementations must be safe for
+// concurrent use; the storetest package checks the contract.
+//
+// Stored jobs change only through Update and Transition. A store keeps its
+// own copy of every job it is given and hands out copies, so a job passed to
+// Put or returned by any method is never changed by the store afterwards,
+// and changing it doesn't change the stored job.
+type JobStore interface {
+	// Put inserts a job, replacing any stored job with the same UID
+	Put(job *model.Job) error
+	Get(id string) (*model.Job, bool)
+	// Delete removes a job, reporting whether it was stored
+	Delete(id string) bool
+	// Update applies fn to the stored job atomically and bumps its version.
+	// A non-zero expectedVersion must match the stored one or the update
+	// fails with ErrVersionConflict. If fn fails nothing is written.
+	Update(id string, expectedVersion int64, fn func(job *model.Job) error) (*model.Job, error)
+	// Transition bumps the stored job's version and applies fn to it
+	// atomically. fn sees the new version.
+	Transition(id string, fn func(job *model.Job)) (*model.Job, error)
+	List(filter *model.JobFilter) []*model.Job
+	// Search returns jobs matching the query in model.CompareJobs order,
+	// starting after the cursor when one is given and returning at most
+	// limit jobs when limit is positive
+	Search(query *model.JobQuery, after *model.JobCursor, limit int) []*model.Job
+	CountByStatus() map[model.JobStatus]int
+}
+
+// UpdateStatus returns a change for Transition that moves a job to status.
+// A job starting to run records when it started, one queued again loses its
+// start and lease, and one finishing records when it finished.
+func UpdateStatus(status model.JobStatus, at time.Time) func(j *model.Job) {
+	return func(j *model.Job) {
+		j.Status = status
+		switch {
+		case status == model.JobStatusRunning:
+			j.StartedAt = &at
+		case status == model.JobStatusPending:
+			j.StartedAt = nil
+			j.LeasedBy = ""
+		case status.Finished():
+			j.CompletedAt = &at
+		}
+	}
+}
+
+// SetResult returns a change for Transition that finishes a job: completed
+// with result when err is nil, failed with err's message otherwise
+func SetResult(result model.JobResult, err error, at time.Time) func(j *model.Job) {
+	return func(j *model.Job) {
+		if err != nil {
+			UpdateStatus(model.JobStatusFailed, at)(j)
+			j.Error = err.Error()
+			return
+		}
+		UpdateStatus(model.JobStatusCompleted, at)(j)
+		j.Error = ""
+		j.Result = result
+	}
+}
+
+// MemoryStore is the in-memory job store. Alongside the primary map it keeps
+// secondary indexes by status and type, maintained on every write, so
+// filtered reads only visit matching jobs.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	jobs     map[string]*model.Job
+	byStatus map[model.JobStatus]map[string]*model.Job
+	byType   map[string]map[string]*model.Job
+	// payloads shares large payloads between jobs, when that's on
+	payloads *payloadTable
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs:     make(map[string]*model.Job),
+		byStatus: make(map[model.JobStatus]map[string]*model.Job),
+		byType:   make(map[string]map[string]*model.Job),
+	}
+}
+
+// SharePayloads makes stored jobs whose payloads encode to at least
+// threshold bytes share one copy of each distinct payload, rather than each
+// holding its own. Zero turns sharing off. It must be called before any jobs
+// are stored.
+func (s *MemoryStore) SharePayloads(threshold int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads = nil
+	if threshold > 0 {
+		s.payloads = newPayloadTable(threshold)
+	}
+}
+
+// PayloadStats describes the payloads shared between stored jobs, or is nil
+// when payloads aren't shared
+func (s *MemoryStore) PayloadStats() *model.PayloadStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.payloads == nil {
+		return nil
+	}
+	return s.payloads.stats()
+}
+
+func (s *MemoryStore) Put(job *model.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := job.UID.String()
+	if old, exists := s.jobs[id]; exists {
+		s.unindex(id, old.Status, old.Type)
+	}
+	job = job.Clone()
+	if s.payloads != nil {
+		s.payloads.intern(id, job)
+	}
+	s.jobs[id] = job
+	s.index(id, job)
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (*model.Job, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, false
+	}
+	return job.Clone(), true
+}
+
+func (s *MemoryStore) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[id]
+	if !exists {
+		return false
+	}
+	delete(s.jobs, id)
+	s.unindex(id, job.Status, job.Type)
+	if s.payloads != nil {
+		s.payloads.forget(id)
+	}
+	return true
+}
+
+func (s *MemoryStore) Update(id string, expectedVersion int64, fn func(job *model.Job) error) (*model.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	if expectedVersion != 0 && job.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+
+	// Apply fn to a copy so a failed update leaves the stored job untouched
+	updated := job.Clone()
+	if err := fn(updated); err != nil {
+		return nil, err
+	}
+	// An update may change the payload, unlike a transition
+	if s.payloads != nil {
+		s.payloads.intern(id, updated)
+	}
+	updated.Version = job.Version + 1
+	s.replace(id, job, updated)
+	return updated.Clone(), nil
+}
+
+func (s *MemoryStore) Transition(id string, fn func(job *model.Job)) (*model.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	updated := job.Clone()
+	updated.Version++
+	fn(updated)
+	s.replace(id, job, updated)
+	return updated.Clone(), nil
+}
+
+// replace swaps a stored job for its updated copy. Stored jobs are never
+// changed in place, so a job handed out is never caught halfway through an
+// update.
+func (s *MemoryStore) replace(id string, old, updated *model.Job) {
+	s.unindex(id, old.Status, old.Type)
+	s.jobs[id] = updated
+	s.index(id, updated)
+}
+
+// List and Search return copies of the matching jobs, so callers may keep
+// or change them without affecting the store
+func (s *MemoryStore) List(filter *model.JobFilter) []*model.Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	jobs := make([]*model.Job, 0)
+	for _, v := range s.candidates(filter.Type, filter.Status) {
+		if filter.Type != nil && *filter.Type != v.Type {
+			continue
+		}
+		if filter.Status != nil && *filter.Status != v.Status {
+			continue
+		}
+		if filter.DeadlineMissed != nil && *filter.DeadlineMissed != v.DeadlineMissed {
+			continue
+		}
+		jobs = append(jobs, v.Clone())
+	}
+	return jobs
+}
+
+func (s *MemoryStore) Search(query *model.JobQuery, after *model.JobCursor, limit int) []*model.Job {
+	s.mu.RLock()
+	jobs := make([]*model.Job, 0)
+	for _, v := range s.candidates(query.Type, query.Status) {
+		if after != nil && !after.After(v) {
+			continue
+		}
+		if query.Matches(v) {
+			jobs = append(jobs, v.Clone())
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(jobs, model.CompareJobs)
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs
+}
+
+// CountByStatus returns the number of jobs in each status
+func (s *MemoryStore) CountByStatus() map[model.JobStatus]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[model.JobStatus]int, len(s.byStatus))
+	for status, jobs := range s.byStatus {
+		counts[status] = len(jobs)
+	}
+	return counts
+}
+
+// candidates picks the smallest index that can satisfy the given predicates
+func (s *MemoryStore) candidates(jobType *string, status *model.JobStatus) map[string]*model.Job {
+	switch {
+	case jobType != nil && status != nil:
+		if len(s.byType[*jobType]) < len(s.byStatus[*status]) {
+			return s.byType[*jobType]
+		}
+		return s.byStatus[*status]
+	case status != nil:
+		return s.byStatus[*status]
+	case jobType != nil:
+		return s.byType[*jobType]
+	default:
+		return s.jobs
+	}
+}
+
+func (s *MemoryStore) index(id string, job *model.Job) {
+	if s.byStatus[job.Status] == nil {
+		s.byStatus[job.Status] = make(map[string]*model.Job)
+	}
+	s.byStatus[job.Status][id] = job
+	if s.byType[job.Type] == nil {
+		s.byType[job.Type] = make(map[string]*model.Job)
+	}
+	s.byType[job.Type][id] = job
+}
+
+func (s *MemoryStore) unindex(id string, status model.JobStatus, jobType string) {
+	delete(s.byStatus[status], id)
+	if len(s.byStatus[status]) == 0 {
+		delete(s.byStatus, status)
+	}
+	delete(s.byType[jobType], id)
+	if len(s.byType[jobType]) == 0 {
+		delete(s.byType, jobType)
+	}
+}
+
+// CheckIndexes compares the status and type indexes with the jobs they
+// point to, rebuilding them from the primary map when repair is set
+func (s *MemoryStore) CheckIndexes(repair bool) []model.Inconsistency {
+	if repair {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	} else {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	}
+
+	var found []model.Inconsistency
+	drift := func(id, detail string) {
+		found = append(found, model.Inconsistency{Kind: model.InconsistencyIndexDrift, ID: id, Detail: detail, Repaired: repair})
+	}
+	for id, job := range s.jobs {
+		if s.byStatus[job.Status][id] != job {
+			drift(id, fmt.Sprintf("not indexed under status %q", job.Status))
+		}
+		if s.byType[job.Type][id] != job {
+			drift(id, fmt.Sprintf("not indexed under type %q", job.Type))
+		}
+	}
+	for status, jobs := range s.byStatus {
+		for id, job := range jobs {
+			if s.jobs[id] != job || job.Status != status {
+				drift(id, fmt.Sprintf("stale entry under status %q", status))
+			}
+		}
+	}
+	for jobType, jobs := range s.byType {
+		for id, job := range jobs {
+			if s.jobs[id] != job || job.Type != jobType {
+				drift(id, fmt.Sprintf("stale entry under type %q", jobType))
+			}
+		}
+	}
+
+	if repair && len(found) > 0 {
+		s.byStatus = make(map[model.JobStatus]map[string]*model.Job)
+		s.byType = make(map[string]map[string]*model.Job)
+		for id, job := range s.jobs {
+			s.index(id, job)
+		}
+	}
+	return found
+}
@@ -0,0 +1,165 @@
+package pool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertIndexesConsistent checks every job is indexed exactly under its
+// current status and type, and that the indexes hold nothing else
+func assertIndexesConsistent(t *testing.T, s *MemoryStore) {
+	t.Helper()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statusTotal, typeTotal := 0, 0
+	for status, jobs := range s.byStatus {
+		statusTotal += len(jobs)
+		for id, job := range jobs {
+			assert.Equal(t, status, job.Status, "job %s indexed under wrong status", id)
+			assert.Same(t, s.jobs[id], job)
+		}
+	}
+	for jobType, jobs := range s.byType {
+		typeTotal += len(jobs)
+		for id, job := range jobs {
+			assert.Equal(t, jobType, job.Type, "job %s indexed under wrong type", id)
+			assert.Same(t, s.jobs[id], job)
+		}
+	}
+	assert.Equal(t, len(s.jobs), statusTotal)
+	assert.Equal(t, len(s.jobs), typeTotal)
+}
+
+func TestJobStore_Indexes(t *testing.T) {
+	s := NewMemoryStore()
+	sleepJob := &model.Job{UID: uuid.New(), Type: "sleep", Status: model.JobStatusPending}
+	mathJob := &model.Job{UID: uuid.New(), Type: "math", Status: model.JobStatusPending}
+	s.Put(sleepJob)
+	s.Put(mathJob)
+
+	s.Transition(sleepJob.UID.String(), func(j *model.Job) {
+		j.Status = model.JobStatusRunning
+	})
+	_, err := s.Update(mathJob.UID.String(), 0, func(j *model.Job) error {
+		j.Status = model.JobStatusFailed
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assertIndexesConsistent(t, s)
+	assert.Len(t, s.List(&model.JobFilter{Status: jobStatusPtr(model.JobStatusRunning)}), 1)
+	assert.Len(t, s.List(&model.JobFilter{Status: jobStatusPtr(model.JobStatusPending)}), 0)
+	assert.Len(t, s.List(&model.JobFilter{Type: stringPtr("math"), Status: jobStatusPtr(model.JobStatusFailed)}), 1)
+	assert.Len(t, s.Search(&model.JobQuery{Type: stringPtr("sleep")}, nil, 0), 1)
+	assert.NotContains(t, s.byStatus, model.JobStatusPending)
+}
+
+func TestJobStore_ConcurrentTransitions(t *testing.T) {
+	s := NewMemoryStore()
+	jobTypes := []string{"sleep", "math"}
+	statuses := []model.JobStatus{
+		model.JobStatusPending,
+		model.JobStatusRunning,
+		model.JobStatusCompleted,
+		model.JobStatusFailed,
+	}
+
+	jobs := make([]*model.Job, 50)
+	for i := range jobs {
+		jobs[i] = &model.Job{
+			UID:    uuid.New(),
+			Type:   jobTypes[i%len(jobTypes)],
+			Status: model.JobStatusPending,
+		}
+		s.Put(jobs[i])
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				job := jobs[(w*31+i)%len(jobs)]
+				status := statuses[(w+i)%len(statuses)]
+				if i%2 == 0 {
+					s.Transition(job.UID.String(), func(j *model.Job) {
+						j.Status = status
+					})
+				} else {
+					s.Update(job.UID.String(), 0, func(j *model.Job) error {
+						j.Status = status
+						return nil
+					})
+				}
+				s.List(&model.JobFilter{Status: &status})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assertIndexesConsistent(t, s)
+	total := 0
+	for _, status := range statuses {
+		total += len(s.List(&model.JobFilter{Status: &status}))
+	}
+	assert.Equal(t, len(jobs), total)
+}
+
+func TestUpdateStatus(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	started := at.Add(-time.Minute)
+	job := &model.Job{UID: uuid.New(), Status: model.JobStatusPending}
+
+	UpdateStatus(model.JobStatusRunning, started)(job)
+	assert.Equal(t, model.JobStatusRunning, job.Status)
+	assert.Equal(t, started, *job.StartedAt)
+
+	job.LeasedBy = "remote-1"
+	UpdateStatus(model.JobStatusPending, at)(job)
+	assert.Nil(t, job.StartedAt)
+	assert.Empty(t, job.LeasedBy)
+	assert.Nil(t, job.CompletedAt)
+
+	UpdateStatus(model.JobStatusFailed, at)(job)
+	assert.Equal(t, at, *job.CompletedAt)
+}
+
+func TestSetResult(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	job := &model.Job{UID: uuid.New(), Status: model.JobStatusRunning, Error: "attempt 1 failed: boom"}
+	SetResult(model.MathJobResult{Result: 2}, nil, at)(job)
+	assert.Equal(t, model.JobStatusCompleted, job.Status)
+	assert.Equal(t, model.MathJobResult{Result: 2}, job.Result)
+	assert.Empty(t, job.Error)
+	assert.Equal(t, at, *job.CompletedAt)
+
+	job = &model.Job{UID: uuid.New(), Status: model.JobStatusRunning}
+	SetResult(nil, errors.New("boom"), at)(job)
+	assert.Equal(t, model.JobStatusFailed, job.Status)
+	assert.Equal(t, "boom", job.Error)
+	assert.Nil(t, job.Result)
+	assert.Equal(t, at, *job.CompletedAt)
+}
+
+func TestUsageTracker_Rollover(t *testing.T) {
+	u := newUsageTracker()
+	day1 := time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+
+	u.record("team-a", time.Minute, day1)
+	u.record("team-a", time.Minute, day1)
+	u.record("team-b", time.Second, day1)
+	assert.Equal(t, 2*time.Minute, u.used("team-a", day1))
+	assert.Len(t, u.snapshot(day1), 2)
+
+	assert.Equal(t, time.Duration(0), u.used("team-a", day2))
+	assert.Empty(t, u.snapshot(day2))
+}
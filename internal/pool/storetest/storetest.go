@@ -0,0 +1,598 @@
+// Package storetest is a conformance suite for pool.JobStore implementations.
+// A backend's tests call Run with a constructor for empty stores:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) pool.JobStore {
+//			return newTestStore(t)
+//		})
+//	}
+//
+// The suite never relies on a store keeping the pointers it is given, so
+// backends that serialize jobs pass as long as every field round-trips.
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run checks a JobStore backend against the contract the pool relies on.
+// newStore must return an empty store each time it is called.
+func Run(t *testing.T, newStore func(t *testing.T) pool.JobStore) {
+	t.Run("PutAndGet", func(t *testing.T) { testPutAndGet(t, newStore(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStore(t)) })
+	t.Run("Copies", func(t *testing.T) { testCopies(t, newStore(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newStore(t)) })
+	t.Run("Transition", func(t *testing.T) { testTransition(t, newStore(t)) })
+	t.Run("ConcurrentWrites", func(t *testing.T) { testConcurrentWrites(t, newStore(t)) })
+	t.Run("FilterSemantics", func(t *testing.T) { testFilterSemantics(t, newStore(t)) })
+	t.Run("Pagination", func(t *testing.T) { testPagination(t, newStore(t)) })
+	t.Run("TransitionAtomicity", func(t *testing.T) { testTransitionAtomicity(t, newStore(t)) })
+	t.Run("ConsistentReads", func(t *testing.T) { testConsistentReads(t, newStore(t)) })
+}
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newJob(jobType string, status model.JobStatus, createdAt time.Time) *model.Job {
+	return &model.Job{
+		UID:       uuid.New(),
+		Type:      jobType,
+		Status:    status,
+		Labels:    map[string]string{},
+		CreatedAt: &createdAt,
+		Version:   1,
+	}
+}
+
+func put(t *testing.T, s pool.JobStore, job *model.Job) *model.Job {
+	t.Helper()
+	require.NoError(t, s.Put(job))
+	return job
+}
+
+func get(t *testing.T, s pool.JobStore, uid uuid.UUID) *model.Job {
+	t.Helper()
+	job, exists := s.Get(uid.String())
+	require.True(t, exists, "job %s not found", uid)
+	return job
+}
+
+func uids(jobs []*model.Job) []uuid.UUID {
+	ids := make([]uuid.UUID, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.UID
+	}
+	return ids
+}
+
+func testPutAndGet(t *testing.T, s pool.JobStore) {
+	job := newJob("math", model.JobStatusPending, epoch)
+	job.Labels["team"] = "search"
+	job.Tenant = "acme"
+	put(t, s, job)
+
+	got := get(t, s, job.UID)
+	assert.Equal(t, job.UID, got.UID)
+	assert.Equal(t, "math", got.Type)
+	assert.Equal(t, model.JobStatusPending, got.Status)
+	assert.Equal(t, "search", got.Labels["team"])
+	assert.Equal(t, "acme", got.Tenant)
+	assert.Equal(t, int64(1), got.Version)
+	assert.True(t, epoch.Equal(*got.CreatedAt))
+
+	_, exists := s.Get(uuid.NewString())
+	assert.False(t, exists)
+
+	// Putting a job with the same UID replaces it
+	replacement := newJob("sleep", model.JobStatusCompleted, epoch)
+	replacement.UID = job.UID
+	put(t, s, replacement)
+	got = get(t, s, job.UID)
+	assert.Equal(t, "sleep", got.Type)
+	assert.Empty(t, s.List(&model.JobFilter{Type: ptr("math")}))
+	assert.Equal(t, map[model.JobStatus]int{model.JobStatusCompleted: 1}, s.CountByStatus())
+}
+
+func testDelete(t *testing.T, s pool.JobStore) {
+	kept := put(t, s, newJob("math", model.JobStatusCompleted, epoch))
+	job := put(t, s, newJob("math", model.JobStatusCompleted, epoch))
+
+	assert.True(t, s.Delete(job.UID.String()))
+	_, exists := s.Get(job.UID.String())
+	assert.False(t, exists)
+	assert.False(t, s.Delete(job.UID.String()))
+
+	// Deleted jobs leave the indexes too
+	assert.Equal(t, []uuid.UUID{kept.UID}, uids(s.List(&model.JobFilter{Type: ptr("math")})))
+	assert.Equal(t, map[model.JobStatus]int{model.JobStatusCompleted: 1}, s.CountByStatus())
+}
+
+// testCopies checks that jobs go in and out of the store as copies, so only
+// Update and Transition change a stored job
+func testCopies(t *testing.T, s pool.JobStore) {
+	job := put(t, s, newJob("math", model.JobStatusPending, epoch))
+	job.Status = model.JobStatusFailed
+	job.Labels["team"] = "search"
+	got := get(t, s, job.UID)
+	assert.Equal(t, model.JobStatusPending, got.Status)
+	assert.Empty(t, got.Labels["team"])
+
+	got.Labels["team"] = "ads"
+	got.Attempts = append(got.Attempts, model.JobAttempt{Worker: "w1"})
+	listed := s.List(&model.JobFilter{})
+	require.Len(t, listed, 1)
+	listed[0].Status = model.JobStatusCompleted
+	got = get(t, s, job.UID)
+	assert.Empty(t, got.Labels["team"])
+	assert.Empty(t, got.Attempts)
+	assert.Equal(t, model.JobStatusPending, got.Status)
+
+	// Later writes don't show through jobs already handed out
+	updated, err := s.Transition(job.UID.String(), func(j *model.Job) {
+		j.Status = model.JobStatusRunning
+		j.Labels["team"] = "search"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.JobStatusPending, got.Status)
+	assert.Empty(t, got.Labels["team"])
+	updated.Labels["team"] = "ads"
+	_, err = s.Update(job.UID.String(), 0, func(j *model.Job) error {
+		assert.Equal(t, "search", j.Labels["team"])
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.JobStatusRunning, get(t, s, job.UID).Status)
+}
+
+func testUpdate(t *testing.T, s pool.JobStore) {
+	job := put(t, s, newJob("math", model.JobStatusPending, epoch))
+	id := job.UID.String()
+
+	updated, err := s.Update(id, 1, func(j *model.Job) error {
+		j.Labels = map[string]string{"env": "prod"}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+	assert.Equal(t, "prod", get(t, s, job.UID).Labels["env"])
+
+	// A stale version is rejected without applying fn
+	_, err = s.Update(id, 1, func(j *model.Job) error {
+		t.Error("fn called despite a version conflict")
+		return nil
+	})
+	assert.ErrorIs(t, err, pool.ErrVersionConflict)
+
+	// Zero skips the version check
+	_, err = s.Update(id, 0, func(j *model.Job) error { return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), get(t, s, job.UID).Version)
+
+	// A failing fn leaves the job as it was
+	errReject := errors.New("rejected")
+	_, err = s.Update(id, 0, func(j *model.Job) error {
+		j.Type = "sleep"
+		return errReject
+	})
+	assert.ErrorIs(t, err, errReject)
+	got := get(t, s, job.UID)
+	assert.Equal(t, "math", got.Type)
+	assert.Equal(t, int64(3), got.Version)
+	assert.Len(t, s.List(&model.JobFilter{Type: ptr("math")}), 1)
+
+	_, err = s.Update(uuid.NewString(), 0, func(j *model.Job) error { return nil })
+	assert.ErrorIs(t, err, pool.ErrJobNotFound)
+}
+
+func testTransition(t *testing.T, s pool.JobStore) {
+	job := put(t, s, newJob("math", model.JobStatusPending, epoch))
+
+	var seen int64
+	updated, err := s.Transition(job.UID.String(), func(j *model.Job) {
+		seen = j.Version
+		j.Status = model.JobStatusRunning
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), seen)
+	assert.Equal(t, int64(2), updated.Version)
+	assert.Equal(t, model.JobStatusRunning, updated.Status)
+
+	got := get(t, s, job.UID)
+	assert.Equal(t, model.JobStatusRunning, got.Status)
+	assert.Equal(t, map[model.JobStatus]int{model.JobStatusRunning: 1}, s.CountByStatus())
+
+	_, err = s.Transition(uuid.NewString(), func(j *model.Job) {
+		t.Error("fn called for a missing job")
+	})
+	assert.ErrorIs(t, err, pool.ErrJobNotFound)
+}
+
+// testConcurrentWrites runs optimistic read-modify-write loops against one
+// job while other goroutines insert jobs; no write may be lost
+func testConcurrentWrites(t *testing.T, s pool.JobStore) {
+	const writers, increments = 8, 25
+	counter := put(t, s, newJob("math", model.JobStatusPending, epoch))
+	id := counter.UID.String()
+
+	var wg sync.WaitGroup
+	inserted := make([][]uuid.UUID, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				for {
+					current, _ := s.Get(id)
+					version, n := current.Version, current.Labels["n"]
+					_, err := s.Update(id, version, func(j *model.Job) error {
+						next, _ := strconv.Atoi(n)
+						j.Labels = map[string]string{"n": strconv.Itoa(next + 1)}
+						return nil
+					})
+					if err == nil {
+						break
+					}
+					if !errors.Is(err, pool.ErrVersionConflict) {
+						t.Errorf("update: %v", err)
+						return
+					}
+				}
+
+				job := newJob("sleep", model.JobStatusPending, epoch.Add(time.Duration(i)*time.Second))
+				if err := s.Put(job); err != nil {
+					t.Errorf("put: %v", err)
+					return
+				}
+				inserted[w] = append(inserted[w], job.UID)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	got := get(t, s, counter.UID)
+	assert.Equal(t, strconv.Itoa(writers*increments), got.Labels["n"])
+	assert.Equal(t, int64(1+writers*increments), got.Version)
+
+	for _, ids := range inserted {
+		for _, uid := range ids {
+			get(t, s, uid)
+		}
+	}
+	assert.Len(t, s.List(&model.JobFilter{Type: ptr("sleep")}), writers*increments)
+	assert.Equal(t, writers*increments+1, s.CountByStatus()[model.JobStatusPending])
+}
+
+func testFilterSemantics(t *testing.T, s pool.JobStore) {
+	pendingSleep := newJob("sleep", model.JobStatusPending, epoch)
+	pendingSleep.Labels["env"] = "prod"
+	failedMath := newJob("math", model.JobStatusFailed, epoch.Add(time.Hour))
+	failedMath.Labels["env"] = "prod"
+	failedMath.Error = "Division By Zero"
+	failedMath.DeadlineMissed = true
+	completedMath := newJob("math", model.JobStatusCompleted, epoch.Add(2*time.Hour))
+	completedMath.Labels["env"] = "staging"
+	noCreatedAt := newJob("sleep", model.JobStatusCompleted, epoch)
+	noCreatedAt.CreatedAt = nil
+	for _, job := range []*model.Job{pendingSleep, failedMath, completedMath, noCreatedAt} {
+		put(t, s, job)
+	}
+
+	listTests := []struct {
+		name   string
+		filter model.JobFilter
+		want   []*model.Job
+	}{
+		{name: "no predicates", filter: model.JobFilter{}, want: []*model.Job{pendingSleep, failedMath, completedMath, noCreatedAt}},
+		{name: "type", filter: model.JobFilter{Type: ptr("math")}, want: []*model.Job{failedMath, completedMath}},
+		{name: "status", filter: model.JobFilter{Status: ptr(model.JobStatusCompleted)}, want: []*model.Job{completedMath, noCreatedAt}},
+		{name: "type and status", filter: model.JobFilter{Type: ptr("math"), Status: ptr(model.JobStatusFailed)}, want: []*model.Job{failedMath}},
+		{name: "no matches", filter: model.JobFilter{Type: ptr("sleep"), Status: ptr(model.JobStatusFailed)}, want: nil},
+		{name: "unknown type", filter: model.JobFilter{Type: ptr("email")}, want: nil},
+		{name: "deadline missed", filter: model.JobFilter{DeadlineMissed: ptr(true)}, want: []*model.Job{failedMath}},
+		{name: "deadline not missed", filter: model.JobFilter{Type: ptr("math"), DeadlineMissed: ptr(false)}, want: []*model.Job{completedMath}},
+	}
+	for _, tt := range listTests {
+		t.Run("List/"+tt.name, func(t *testing.T) {
+			got := s.List(&tt.filter)
+			assert.NotNil(t, got)
+			assert.ElementsMatch(t, uids(tt.want), uids(got))
+		})
+	}
+
+	searchTests := []struct {
+		name  string
+		query model.JobQuery
+		want  []*model.Job
+	}{
+		{name: "empty query", query: model.JobQuery{}, want: []*model.Job{noCreatedAt, pendingSleep, failedMath, completedMath}},
+		{name: "labels", query: model.JobQuery{Labels: map[string]string{"env": "prod"}}, want: []*model.Job{pendingSleep, failedMath}},
+		{name: "created after", query: model.JobQuery{CreatedAfter: ptr(epoch)}, want: []*model.Job{failedMath, completedMath}},
+		{name: "created before", query: model.JobQuery{CreatedBefore: ptr(epoch.Add(time.Hour))}, want: []*model.Job{pendingSleep}},
+		{name: "error contains", query: model.JobQuery{ErrorContains: ptr("division")}, want: []*model.Job{failedMath}},
+		{
+			name: "and",
+			query: model.JobQuery{And: []model.JobQuery{
+				{Type: ptr("math")},
+				{Labels: map[string]string{"env": "prod"}},
+			}},
+			want: []*model.Job{failedMath},
+		},
+		{
+			name: "or",
+			query: model.JobQuery{Or: []model.JobQuery{
+				{Status: ptr(model.JobStatusPending)},
+				{Labels: map[string]string{"env": "staging"}},
+			}},
+			want: []*model.Job{pendingSleep, completedMath},
+		},
+		{
+			name: "predicates and or",
+			query: model.JobQuery{
+				Type: ptr("sleep"),
+				Or: []model.JobQuery{
+					{Status: ptr(model.JobStatusPending)},
+					{Status: ptr(model.JobStatusFailed)},
+				},
+			},
+			want: []*model.Job{pendingSleep},
+		},
+	}
+	for _, tt := range searchTests {
+		t.Run("Search/"+tt.name, func(t *testing.T) {
+			assert.Equal(t, uids(tt.want), uids(s.Search(&tt.query, nil, 0)))
+		})
+	}
+}
+
+// testPagination walks the jobs a page at a time, including runs of jobs
+// created at the same instant, and expects every job exactly once in order
+func testPagination(t *testing.T, s pool.JobStore) {
+	var all []*model.Job
+	for i := 0; i < 23; i++ {
+		jobType := "sleep"
+		if i%3 == 0 {
+			jobType = "math"
+		}
+		// Every few jobs share a creation time so ties are broken by UID
+		all = append(all, put(t, s, newJob(jobType, model.JobStatusPending, epoch.Add(time.Duration(i/4)*time.Minute))))
+	}
+	slices.SortFunc(all, model.CompareJobs)
+
+	for _, pageSize := range []int{1, 5, 7, 23, 50} {
+		t.Run(fmt.Sprintf("page size %d", pageSize), func(t *testing.T) {
+			var walked []*model.Job
+			var after *model.JobCursor
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, len(all)+1, "pagination did not terminate")
+				page := s.Search(&model.JobQuery{}, after, pageSize)
+				assert.LessOrEqual(t, len(page), pageSize)
+				walked = append(walked, page...)
+				if len(page) < pageSize {
+					break
+				}
+				cursor := model.NewJobCursor(page[len(page)-1])
+				after = &cursor
+			}
+			assert.Equal(t, uids(all), uids(walked))
+		})
+	}
+
+	t.Run("no limit", func(t *testing.T) {
+		assert.Equal(t, uids(all), uids(s.Search(&model.JobQuery{}, nil, 0)))
+	})
+
+	t.Run("cursor past the end", func(t *testing.T) {
+		cursor := model.NewJobCursor(all[len(all)-1])
+		assert.Empty(t, s.Search(&model.JobQuery{}, &cursor, 10))
+	})
+
+	t.Run("cursor from a job that no longer matches", func(t *testing.T) {
+		// Cursors are positions, so one taken from any job resumes after it
+		cursor := model.NewJobCursor(all[10])
+		assert.Equal(t, uids(all[11:16]), uids(s.Search(&model.JobQuery{}, &cursor, 5)))
+	})
+
+	t.Run("with a query", func(t *testing.T) {
+		var want []*model.Job
+		for _, job := range all {
+			if job.Type == "math" {
+				want = append(want, job)
+			}
+		}
+		query := &model.JobQuery{Type: ptr("math")}
+		first := s.Search(query, nil, 3)
+		cursor := model.NewJobCursor(first[len(first)-1])
+		rest := s.Search(query, &cursor, 0)
+		assert.Equal(t, uids(want), uids(append(first, rest...)))
+	})
+}
+
+// testTransitionAtomicity moves jobs between statuses from many goroutines
+// while others read; every transition must be applied exactly once and no
+// reader may see a job in two statuses or in none
+func testTransitionAtomicity(t *testing.T, s pool.JobStore) {
+	const jobCount, writers, rounds = 20, 8, 50
+	statuses := []model.JobStatus{
+		model.JobStatusPending,
+		model.JobStatusRunning,
+		model.JobStatusCompleted,
+		model.JobStatusFailed,
+	}
+
+	jobs := make([]*model.Job, jobCount)
+	for i := range jobs {
+		jobs[i] = put(t, s, newJob("sleep", model.JobStatusPending, epoch))
+	}
+
+	var (
+		mu      sync.Mutex
+		applied = make(map[uuid.UUID]int64)
+		wg      sync.WaitGroup
+		done    = make(chan struct{})
+	)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				job := jobs[(w*7+i)%jobCount]
+				status := statuses[(w+i)%len(statuses)]
+				if _, err := s.Transition(job.UID.String(), func(j *model.Job) {
+					j.Status = status
+				}); err != nil {
+					t.Errorf("transition: %v", err)
+					return
+				}
+				mu.Lock()
+				applied[job.UID]++
+				mu.Unlock()
+			}
+		}(w)
+	}
+
+	var readers sync.WaitGroup
+	for r := 0; r < 2; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				total := 0
+				for _, n := range s.CountByStatus() {
+					total += n
+				}
+				if total != jobCount {
+					t.Errorf("status counts sum to %d, want %d", total, jobCount)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	total := 0
+	for _, status := range statuses {
+		listed := s.List(&model.JobFilter{Status: &status})
+		total += len(listed)
+		for _, job := range listed {
+			assert.Equal(t, status, get(t, s, job.UID).Status)
+		}
+		assert.Equal(t, len(listed), s.CountByStatus()[status])
+	}
+	assert.Equal(t, jobCount, total)
+
+	for _, job := range jobs {
+		assert.Equal(t, 1+applied[job.UID], get(t, s, job.UID).Version, "job %s lost a transition", job.UID)
+	}
+}
+
+// testConsistentReads checks that listed jobs are never caught halfway
+// through a transition, and that later transitions don't show through jobs
+// already returned. Each transition changes several fields together, which
+// must always agree.
+func testConsistentReads(t *testing.T, s pool.JobStore) {
+	const jobCount, writers, rounds = 10, 4, 100
+	jobs := make([]*model.Job, jobCount)
+	for i := range jobs {
+		jobs[i] = put(t, s, newJob("sleep", model.JobStatusRunning, epoch))
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				job := jobs[(w*3+i)%jobCount]
+				if _, err := s.Transition(job.UID.String(), func(j *model.Job) {
+					n := strconv.Itoa(len(j.Attempts) + 1)
+					j.Attempts = append(j.Attempts, model.JobAttempt{Worker: n, StartedAt: epoch})
+					j.Labels["attempts"] = n
+					j.Error = n
+				}); err != nil {
+					t.Errorf("transition: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+
+	consistent := func(job *model.Job) error {
+		n := strconv.Itoa(len(job.Attempts))
+		if len(job.Attempts) == 0 {
+			if job.Error != "" || job.Version != 1 {
+				return fmt.Errorf("job %s has no attempts but error %q at version %d", job.UID, job.Error, job.Version)
+			}
+			return nil
+		}
+		if job.Labels["attempts"] != n || job.Error != n || job.Attempts[len(job.Attempts)-1].Worker != n ||
+			job.Version != int64(len(job.Attempts))+1 {
+			return fmt.Errorf("job %s is torn: %d attempts, label %q, error %q, version %d",
+				job.UID, len(job.Attempts), job.Labels["attempts"], job.Error, job.Version)
+		}
+		return nil
+	}
+
+	var readers sync.WaitGroup
+	for r := 0; r < 2; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				listed := s.List(&model.JobFilter{})
+				searched := s.Search(&model.JobQuery{}, nil, 0)
+				for _, job := range append(listed, searched...) {
+					if err := consistent(job); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+				// Reading the same jobs again after more transitions
+				// must still find each as it was
+				time.Sleep(time.Millisecond)
+				for _, job := range append(listed, searched...) {
+					if err := consistent(job); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	for _, job := range s.List(&model.JobFilter{}) {
+		assert.NoError(t, consistent(job))
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
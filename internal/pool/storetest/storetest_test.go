@@ -0,0 +1,22 @@
+package storetest_test
+
+import (
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/dnakolan/worker-pool-service/internal/pool/storetest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) pool.JobStore {
+		return pool.NewMemoryStore()
+	})
+}
+
+func TestMemoryStore_SharingPayloads(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) pool.JobStore {
+		s := pool.NewMemoryStore()
+		s.SharePayloads(1)
+		return s
+	})
+}
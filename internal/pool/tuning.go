@@ -0,0 +1,96 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+var ErrUnknownJobType = errors.New("unknown job type")
+
+func jobTypeConfigBlobName(jobType string) string {
+	return jobType + ".jobtype"
+}
+
+// ConfigureJobType replaces a job type's timeout and concurrency limit while
+// the pool runs, including any set before Start, and returns the type as it
+// now stands. A type whose limit adapts only takes a timeout. Jobs already
+// submitted keep the timeout they were given. With a blob store the
+// settings are saved there first, and are restored when the pool starts
+// again.
+func (p *WorkerPool) ConfigureJobType(ctx context.Context, jobType string, cfg model.JobTypeConfig) (*model.JobType, error) {
+	if !model.IsBuiltinJobType(jobType) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownJobType, jobType)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Concurrency != 0 && p.adaptive.adapts(jobType) {
+		return nil, fmt.Errorf("%w: %s", ErrAdaptiveConcurrency, jobType)
+	}
+	if p.blobs != nil {
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.blobs.Put(ctx, jobTypeConfigBlobName(jobType), bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("saving job type config: %w", err)
+		}
+	}
+	p.applyJobTypeConfig(jobType, cfg)
+	slog.Info("Job type reconfigured", "job_type", jobType, "timeout", cfg.Timeout, "concurrency", cfg.Concurrency)
+
+	for _, jt := range p.JobTypes(ctx) {
+		if jt.Name == jobType {
+			return &jt, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownJobType, jobType)
+}
+
+func (p *WorkerPool) applyJobTypeConfig(jobType string, cfg model.JobTypeConfig) {
+	timeout, _ := time.ParseDuration(cfg.Timeout)
+	p.SetJobTimeout(jobType, timeout)
+	// An adaptive limit is left to adapt
+	if !p.adaptive.adapts(jobType) {
+		p.concurrency.setLimit(jobType, cfg.Concurrency)
+	}
+	// A higher limit may let queued jobs start
+	p.jobQueue.wake()
+}
+
+// restoreJobTypeConfigs applies the settings saved by ConfigureJobType
+// before the pool last stopped
+func (p *WorkerPool) restoreJobTypeConfigs() {
+	if p.blobs == nil {
+		return
+	}
+	for _, jt := range model.BuiltinJobTypes() {
+		r, err := p.blobs.Open(p.ctx, jobTypeConfigBlobName(jt.Name))
+		if err != nil {
+			if !errors.Is(err, blob.ErrNotFound) {
+				slog.Warn("Failed to read job type config", "job_type", jt.Name, "error", err)
+			}
+			continue
+		}
+		var cfg model.JobTypeConfig
+		err = json.NewDecoder(r).Decode(&cfg)
+		r.Close()
+		if err == nil {
+			err = cfg.Validate()
+		}
+		if err != nil {
+			slog.Warn("Ignoring invalid job type config", "job_type", jt.Name, "error", err)
+			continue
+		}
+		p.applyJobTypeConfig(jt.Name, cfg)
+		slog.Info("Restored job type config", "job_type", jt.Name, "timeout", cfg.Timeout, "concurrency", cfg.Concurrency)
+	}
+}
@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_ConfigureJobType(t *testing.T) {
+	ctx := context.Background()
+	exec := &gateExecutor{release: make(chan struct{})}
+	pool := NewWorkerPool(ctx, 3, 5)
+	pool.RegisterExecutor("math", exec)
+	assert.NoError(t, pool.SetJobTypeDefaults("math", model.JobTypeDefaults{Concurrency: 1}))
+	pool.Start()
+	defer pool.Stop()
+
+	running := func() int {
+		exec.mu.Lock()
+		defer exec.mu.Unlock()
+		return exec.running
+	}
+	for range 3 {
+		assert.NoError(t, pool.SubmitJob(ctx, weightedJob(1)))
+	}
+	assert.Eventually(t, func() bool { return running() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	// Raising the limit lets the queued jobs start straight away
+	jt, err := pool.ConfigureJobType(ctx, "math", model.JobTypeConfig{Timeout: "1m", Concurrency: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, "1m0s", jt.DefaultTimeout)
+	assert.Equal(t, 3, jt.Concurrency)
+	assert.Eventually(t, func() bool { return running() == 3 }, 2*time.Second, 10*time.Millisecond)
+
+	// Only jobs submitted from now on get the new timeout
+	job := weightedJob(1)
+	assert.NoError(t, pool.SubmitJob(ctx, job))
+	assert.Equal(t, "1m0s", job.Timeout)
+	close(exec.release)
+	waitForNJobsWithStatus(t, pool, 4, model.JobStatusCompleted)
+
+	_, err = pool.ConfigureJobType(ctx, "email", model.JobTypeConfig{})
+	assert.ErrorIs(t, err, ErrUnknownJobType)
+	_, err = pool.ConfigureJobType(ctx, "math", model.JobTypeConfig{Concurrency: -1})
+	assert.EqualError(t, err, "concurrency: cannot be negative")
+}
+
+func TestWorkerPool_ConfigureJobTypeRestored(t *testing.T) {
+	ctx := context.Background()
+	store, err := blob.NewDiskStore(t.TempDir())
+	assert.NoError(t, err)
+
+	first := NewWorkerPool(ctx, 0, 1)
+	first.SetBlobStore(store)
+	first.SetJobTimeout("sleep", time.Hour)
+	first.Start()
+	_, err = first.ConfigureJobType(ctx, "sleep", model.JobTypeConfig{Concurrency: 2})
+	assert.NoError(t, err)
+	first.Stop()
+
+	// The saved settings replace those configured before Start
+	second := NewWorkerPool(ctx, 0, 1)
+	second.SetBlobStore(store)
+	second.SetJobTimeout("sleep", time.Hour)
+	second.Start()
+	defer second.Stop()
+	for _, jt := range second.JobTypes(ctx) {
+		if jt.Name == "sleep" {
+			assert.Empty(t, jt.DefaultTimeout)
+			assert.Equal(t, 2, jt.Concurrency)
+		}
+	}
+}
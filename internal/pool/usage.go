@@ -0,0 +1,51 @@
+package pool
+
+import (
+	"sync"
+	"time"
+)
+
+// usageTracker accumulates execution time per tenant for the current UTC day
+type usageTracker struct {
+	mu    sync.Mutex
+	day   string
+	usage map[string]time.Duration
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{usage: make(map[string]time.Duration)}
+}
+
+func (u *usageTracker) record(tenant string, d time.Duration, now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.rollover(now)
+	u.usage[tenant] += d
+}
+
+func (u *usageTracker) used(tenant string, now time.Time) time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.rollover(now)
+	return u.usage[tenant]
+}
+
+func (u *usageTracker) snapshot(now time.Time) map[string]time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.rollover(now)
+	out := make(map[string]time.Duration, len(u.usage))
+	for tenant, d := range u.usage {
+		out[tenant] = d
+	}
+	return out
+}
+
+// rollover resets the counters when the UTC day changes
+func (u *usageTracker) rollover(now time.Time) {
+	day := now.UTC().Format(time.DateOnly)
+	if day != u.day {
+		u.day = day
+		u.usage = make(map[string]time.Duration)
+	}
+}
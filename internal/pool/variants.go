@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// ExecutorVariant is one of the implementations a job type's jobs are split
+// between. A nil Executor runs the type's built-in implementation.
+type ExecutorVariant struct {
+	Name     string
+	Executor Executor
+}
+
+// executorSplit routes a job type's jobs between two variants and counts
+// how each fared
+type executorSplit struct {
+	variants [2]ExecutorVariant
+	// percent is the share of jobs routed to the second variant
+	percent float64
+
+	mu    sync.Mutex
+	stats [2]model.VariantStats
+}
+
+// SplitExecutor routes percent of the jobs of the given type to candidate
+// and the rest to current, so a new executor can take over a type
+// gradually. A job is routed by its UID, so its retries run on the same
+// variant, and the variant is recorded in its "executor_variant"
+// annotation. It replaces any executor registered for the type and must be
+// called before Start.
+func (p *WorkerPool) SplitExecutor(jobType string, current, candidate ExecutorVariant, percent float64) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("split percent must be between 0 and 100, got %g", percent)
+	}
+	if current.Name == "" || candidate.Name == "" || current.Name == candidate.Name {
+		return fmt.Errorf("split variants need distinct names, got %q and %q", current.Name, candidate.Name)
+	}
+	p.splits[jobType] = &executorSplit{
+		variants: [2]ExecutorVariant{current, candidate},
+		percent:  percent,
+		stats: [2]model.VariantStats{
+			{JobType: jobType, Variant: current.Name, Percent: 100 - percent},
+			{JobType: jobType, Variant: candidate.Name, Percent: percent},
+		},
+	}
+	return nil
+}
+
+// pick returns the index of the variant the job is routed to. Hashing the
+// UID spreads jobs evenly while sending a job to the same variant each time.
+func (s *executorSplit) pick(job *model.Job) int {
+	h := fnv.New32a()
+	h.Write(job.UID[:])
+	if float64(h.Sum32()%10000) < s.percent*100 {
+		return 1
+	}
+	return 0
+}
+
+func (p *WorkerPool) executeSplit(ctx context.Context, split *executorSplit, job *model.Job) (model.JobResult, error) {
+	i := split.pick(job)
+	variant := split.variants[i]
+	if err := Annotate(ctx, "executor_variant", variant.Name); err != nil {
+		return nil, err
+	}
+
+	started := p.clock.Now()
+	var result model.JobResult
+	var err error
+	if variant.Executor != nil {
+		result, err = variant.Executor.Execute(ctx, job, p.logs.open(job.UID.String()))
+	} else {
+		result, err = p.executeBuiltin(ctx, job)
+	}
+	elapsed := p.clock.Now().Sub(started)
+
+	split.mu.Lock()
+	defer split.mu.Unlock()
+	split.stats[i].Runs++
+	if err != nil {
+		split.stats[i].Failed++
+	}
+	split.stats[i].Seconds += elapsed.Seconds()
+	return result, err
+}
+
+// variantStats describes the runs of every split job type's variants,
+// ordered by type and then as the variants were given
+func (p *WorkerPool) variantStats() []model.VariantStats {
+	var stats []model.VariantStats
+	for _, jobType := range slices.Sorted(maps.Keys(p.splits)) {
+		split := p.splits[jobType]
+		split.mu.Lock()
+		stats = append(stats, split.stats[:]...)
+		split.mu.Unlock()
+	}
+	return stats
+}
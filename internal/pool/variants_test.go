@@ -0,0 +1,49 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool_SplitExecutor(t *testing.T) {
+	ctx := context.Background()
+	pool := NewWorkerPool(ctx, 1, 10)
+	assert.Error(t, pool.SplitExecutor("math", ExecutorVariant{Name: "builtin"}, ExecutorVariant{Name: "rewrite"}, 101))
+	assert.Error(t, pool.SplitExecutor("math", ExecutorVariant{Name: "builtin"}, ExecutorVariant{Name: "builtin"}, 10))
+	require.NoError(t, pool.SplitExecutor("math", ExecutorVariant{Name: "builtin"}, ExecutorVariant{Name: "rewrite", Executor: offByOneExecutor{}}, 25))
+
+	variants := make(map[string]int)
+	for range 400 {
+		job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 20}, Status: model.JobStatusPending}
+		require.NoError(t, pool.store.Put(job))
+		pool.runJob(0, job)
+
+		got, _ := pool.GetJob(ctx, job.UID.String())
+		var variant string
+		require.NoError(t, json.Unmarshal(got.Annotations["executor_variant"], &variant))
+		variants[variant]++
+		want := model.MathJobResult{Result: 190}
+		if variant == "rewrite" {
+			want.Result = 191
+		}
+		assert.Equal(t, want, got.Result)
+
+		// A job is routed to the same variant every time
+		split := pool.splits["math"]
+		assert.Equal(t, split.pick(job), split.pick(job))
+	}
+	assert.InDelta(t, 100, variants["rewrite"], 40)
+
+	stats := pool.Stats(ctx).Variants
+	require.Len(t, stats, 2)
+	assert.Equal(t, model.VariantStats{JobType: "math", Variant: "builtin", Percent: 75, Runs: variants["builtin"], Seconds: stats[0].Seconds}, stats[0])
+	assert.Equal(t, "rewrite", stats[1].Variant)
+	assert.Equal(t, 25.0, stats[1].Percent)
+	assert.Equal(t, variants["rewrite"], stats[1].Runs)
+}
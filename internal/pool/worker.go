@@ -0,0 +1,74 @@
+package pool
+
+import (
+	"sync"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// DefaultWorkerCapacity is how many slots each local worker has
+const DefaultWorkerCapacity = 1
+
+type worker struct {
+	id           int
+	capabilities map[string]bool
+	// labels are matched against the placement jobs ask for
+	labels map[string]string
+	// capacity is the worker's slot count, zero for remote workers which
+	// take one job at a time whatever its weight
+	capacity int
+
+	mu   sync.Mutex
+	used int
+	// keys are the serialization keys of jobs recently routed to the
+	// worker, oldest first
+	keys []string
+}
+
+func newWorker(id int, capabilities []string) *worker {
+	w := &worker{id: id, capabilities: make(map[string]bool, len(capabilities))}
+	for _, c := range capabilities {
+		w.capabilities[c] = true
+	}
+	return w
+}
+
+// accepts reports whether the worker offers every capability the job
+// requires, has the labels it is placed on and could ever fit it
+func (w *worker) accepts(job *model.Job) bool {
+	if w.capacity > 0 && job.Slots() > w.capacity {
+		return false
+	}
+	for _, c := range job.Requires {
+		if !w.capabilities[c] {
+			return false
+		}
+	}
+	for k, v := range job.Placement {
+		if w.labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// fits reports whether the worker accepts the job and has enough free slots
+// to start it now
+func (w *worker) fits(job *model.Job) bool {
+	w.mu.Lock()
+	free := w.capacity - w.used
+	w.mu.Unlock()
+	return job.Slots() <= free && w.accepts(job)
+}
+
+func (w *worker) acquire(slots int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.used += slots
+}
+
+func (w *worker) release(slots int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.used -= slots
+}
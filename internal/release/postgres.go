@@ -0,0 +1,82 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// parseDSN checks dsn is a postgres:// URL naming a user and reads it into
+// a connection pool config. Other settings, such as sslmode, are as in libpq.
+func parseDSN(dsn string) (*pgxpool.Config, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") || u.Hostname() == "" {
+		return nil, errors.New("dsn must be a postgres:// URL")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, errors.New("dsn must name a user")
+	}
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	// Results are released one at a time
+	cfg.MaxConns = 1
+	return cfg, nil
+}
+
+// postgresSink inserts the result as a row. The table needs the columns
+// job_uid, job_type, tenant, labels, result and completed_at, and a unique
+// constraint on job_uid, as a result may be released more than once.
+type postgresSink struct {
+	db     *pgxpool.Pool
+	insert string
+}
+
+// newPostgresSink creates the sink without connecting; the connection is
+// made on first release and again after it fails
+func newPostgresSink(t Target) (*postgresSink, error) {
+	cfg, err := parseDSN(t.DSN)
+	if err != nil {
+		return nil, err
+	}
+	db, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresSink{
+		db: db,
+		insert: "INSERT INTO " + t.Table + " (job_uid, job_type, tenant, labels, result, completed_at) " +
+			"VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (job_uid) DO NOTHING",
+	}, nil
+}
+
+// release passes every value as text, leaving the server to convert it to
+// the column's type
+func (s *postgresSink) release(ctx context.Context, job *model.Job, data []byte) error {
+	labels, err := json.Marshal(job.Labels)
+	if err != nil {
+		return err
+	}
+	result, err := json.Marshal(job.Result)
+	if err != nil {
+		return err
+	}
+	var completedAt *string
+	if job.CompletedAt != nil {
+		formatted := job.CompletedAt.UTC().Format(time.RFC3339Nano)
+		completedAt = &formatted
+	}
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+	}
+	_, err = s.db.Exec(ctx, s.insert, job.UID.String(), job.Type, job.Tenant, string(labels), string(result), completedAt)
+	return err
+}
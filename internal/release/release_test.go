@@ -0,0 +1,158 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/backoff"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJobs holds jobs in memory, recording their annotations
+type fakeJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*model.Job
+}
+
+func (f *fakeJobs) GetJob(ctx context.Context, id string) (*model.Job, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job, ok := f.jobs[id]
+	return job, ok
+}
+
+func (f *fakeJobs) AnnotateJob(ctx context.Context, id string, annotations map[string]json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	merged, err := model.MergeAnnotations(f.jobs[id].Annotations, annotations)
+	f.jobs[id].Annotations = merged
+	return err
+}
+
+func (f *fakeJobs) releases(id string) map[string]model.ReleaseOutcome {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var outcomes map[string]model.ReleaseOutcome
+	json.Unmarshal(f.jobs[id].Annotations[model.ReleasesAnnotation], &outcomes)
+	return outcomes
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("RESULTS_TOKEN", "secret")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "releases.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	targets, err := LoadConfig(write(`{"math": [
+		{"name": "api", "kind": "http", "url": "https://results.example.com/{uid}", "headers": {"Authorization": "Bearer ${RESULTS_TOKEN}"}},
+		{"name": "archive", "kind": "s3", "bucket": "results", "region": "us-east-1", "key": "{type}/{uid}.json"},
+		{"name": "warehouse", "kind": "postgres", "dsn": "postgres://wps@db/results", "table": "public.results", "labels": {"release": "true"}}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, targets["math"], 3)
+	assert.Equal(t, "Bearer secret", targets["math"][0].Headers["Authorization"])
+	assert.Equal(t, "AKID", targets["math"][1].AccessKeyID)
+	assert.Equal(t, map[string]string{"release": "true"}, targets["math"][2].Labels)
+
+	for name, content := range map[string]string{
+		"unknown kind":  `{"math": [{"name": "x", "kind": "ftp"}]}`,
+		"missing name":  `{"math": [{"kind": "http", "url": "https://example.com"}]}`,
+		"bad url":       `{"math": [{"name": "x", "kind": "http", "url": "example.com"}]}`,
+		"bad table":     `{"math": [{"name": "x", "kind": "postgres", "dsn": "postgres://wps@db/results", "table": "results; drop table jobs"}]}`,
+		"bad dsn":       `{"math": [{"name": "x", "kind": "postgres", "dsn": "mysql://db", "table": "results"}]}`,
+		"incomplete s3": `{"math": [{"name": "x", "kind": "s3", "bucket": "results"}]}`,
+		"duplicate":     `{"math": [{"name": "x", "kind": "http", "url": "https://a.example.com"}, {"name": "x", "kind": "http", "url": "https://b.example.com"}]}`,
+		"not json":      `[`,
+	} {
+		_, err := LoadConfig(write(content))
+		assert.Error(t, err, name)
+	}
+	_, err = LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestReleaser(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received = make(map[string][]byte)
+		failing  = true
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if r.URL.Path == "/flaky" && failing {
+			failing = false
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		received[r.URL.Path], _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+	defer func(b backoff.Strategy) { releaseBackoff = b }(releaseBackoff)
+	releaseBackoff = backoff.Constant(0)
+
+	completed := time.Date(2025, 3, 14, 9, 0, 0, 0, time.UTC)
+	tagged := &model.Job{UID: uuid.New(), Type: "math", Tenant: "acme", Labels: map[string]string{"release": "true"},
+		Status: model.JobStatusCompleted, Result: model.MathJobResult{Result: 49}, CompletedAt: &completed}
+	untagged := &model.Job{UID: uuid.New(), Type: "math", Status: model.JobStatusCompleted, Result: model.MathJobResult{Result: 4}, CompletedAt: &completed}
+	jobs := &fakeJobs{jobs: map[string]*model.Job{tagged.UID.String(): tagged, untagged.UID.String(): untagged}}
+
+	headers := map[string]string{"Authorization": "Bearer secret"}
+	r, err := NewReleaser(jobs, map[string][]Target{"math": {
+		{Name: "all", Kind: KindHTTP, URL: srv.URL + "/{tenant}/{date}/{uid}", Headers: headers},
+		{Name: "flaky", Kind: KindHTTP, URL: srv.URL + "/flaky", Headers: headers, Labels: map[string]string{"release": "true"}},
+		{Name: "down", Kind: KindHTTP, URL: "http://127.0.0.1:1/", Labels: map[string]string{"release": "true"}},
+	}}, 10)
+	require.NoError(t, err)
+
+	r.Observe(model.JobEvent{JobUID: tagged.UID, JobType: "math", To: model.JobStatusRunning})
+	r.Observe(model.JobEvent{JobUID: tagged.UID, JobType: "sleep", To: model.JobStatusCompleted})
+	assert.Empty(t, r.queue)
+	r.Observe(model.JobEvent{JobUID: tagged.UID, JobType: "math", To: model.JobStatusCompleted})
+	r.Observe(model.JobEvent{JobUID: untagged.UID, JobType: "math", To: model.JobStatusCompleted})
+	require.Len(t, r.queue, 2)
+	r.release(context.Background(), (<-r.queue).String())
+	r.release(context.Background(), (<-r.queue).String())
+
+	mu.Lock()
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(received["/acme/2025-03-14/"+tagged.UID.String()], &doc))
+	assert.Equal(t, tagged.UID.String(), doc["job_uid"])
+	assert.Equal(t, map[string]any{"result": float64(49)}, doc["result"])
+	assert.Contains(t, string(received["/flaky"]), `"result":49`)
+	assert.Contains(t, received, "//2025-03-14/"+untagged.UID.String())
+	mu.Unlock()
+
+	outcomes := jobs.releases(tagged.UID.String())
+	require.Len(t, outcomes, 3)
+	assert.NotNil(t, outcomes["all"].ReleasedAt)
+	assert.NotNil(t, outcomes["flaky"].ReleasedAt)
+	assert.Nil(t, outcomes["down"].ReleasedAt)
+	assert.NotEmpty(t, outcomes["down"].Error)
+	assert.Len(t, jobs.releases(untagged.UID.String()), 1)
+}
+
+func TestReleaser_FullBuffer(t *testing.T) {
+	r, err := NewReleaser(&fakeJobs{}, map[string][]Target{"math": {{Name: "all", Kind: KindHTTP, URL: "http://127.0.0.1:1/"}}}, 1)
+	require.NoError(t, err)
+	r.Observe(model.JobEvent{JobUID: uuid.New(), JobType: "math", To: model.JobStatusCompleted})
+	r.Observe(model.JobEvent{JobUID: uuid.New(), JobType: "math", To: model.JobStatusCompleted})
+	assert.Equal(t, int64(1), r.Dropped())
+}
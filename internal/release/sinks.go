@@ -0,0 +1,78 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// httpSink PUTs the result to a URL
+type httpSink struct {
+	target Target
+	client *http.Client
+}
+
+func newHTTPSink(t Target) *httpSink {
+	return &httpSink{target: t, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (s *httpSink) release(ctx context.Context, job *model.Job, data []byte) error {
+	u := expand(s.target.URL, job, url.PathEscape)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range expandHeaders(s.target.Headers, job) {
+		req.Header.Set(name, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s responded %s: %s", u, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// s3Sink writes the result as an object
+type s3Sink struct {
+	target Target
+}
+
+func newS3Sink(t Target) *s3Sink {
+	return &s3Sink{target: t}
+}
+
+// release writes to a store rooted at the key's directory, since blob
+// stores take plain names
+func (s *s3Sink) release(ctx context.Context, job *model.Job, data []byte) error {
+	key := strings.TrimPrefix(expand(s.target.Key, job, func(s string) string { return s }), "/")
+	dir, name := path.Split(key)
+	store, err := blob.NewS3Store(blob.S3Config{
+		Bucket:          s.target.Bucket,
+		Region:          s.target.Region,
+		Endpoint:        s.target.Endpoint,
+		AccessKeyID:     s.target.AccessKeyID,
+		SecretAccessKey: s.target.SecretAccessKey,
+		SessionToken:    s.target.SessionToken,
+		Prefix:          dir,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = store.Put(ctx, name, bytes.NewReader(data))
+	return err
+}
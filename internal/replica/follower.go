@@ -0,0 +1,150 @@
+// Package replica keeps a read-only copy of another instance's jobs, so
+// queries can be served without taking execution capacity from it.
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+// DefaultSyncInterval is how often a follower copies the primary's jobs
+// unless configured otherwise
+const DefaultSyncInterval = 5 * time.Second
+
+// Follower copies the jobs of a primary instance into a store
+type Follower struct {
+	primary  *url.URL
+	store    pool.JobStore
+	interval time.Duration
+	client   *http.Client
+	now      func() time.Time
+
+	mu      sync.Mutex
+	synced  time.Time
+	lastErr error
+}
+
+// NewFollower follows the instance at the primary base URL, copying its
+// jobs into store every interval
+func NewFollower(primary string, store pool.JobStore, interval time.Duration) (*Follower, error) {
+	u, err := url.Parse(primary)
+	if err != nil {
+		return nil, fmt.Errorf("primary %q: %w", primary, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("primary %q: expected an http or https URL", primary)
+	}
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	return &Follower{
+		primary:  u,
+		store:    store,
+		interval: interval,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+// Run syncs straight away and then every interval until ctx is done
+func (f *Follower) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	f.sync(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			f.sync(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *Follower) sync(ctx context.Context) {
+	err := f.Sync(ctx)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil {
+		if f.lastErr == nil {
+			slog.Warn("Failed to sync with primary", "primary", f.primary.Host, "error", err)
+		}
+		f.lastErr = err
+		return
+	}
+	if f.lastErr != nil {
+		slog.Info("Syncing with primary again", "primary", f.primary.Host)
+	}
+	f.lastErr = nil
+	f.synced = f.now()
+}
+
+// Sync copies the primary's jobs into the store once. Jobs whose version
+// hasn't changed are left alone, and jobs the primary no longer holds are
+// removed.
+func (f *Follower) Sync(ctx context.Context) error {
+	jobs, err := f.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	held := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		id := job.UID.String()
+		held[id] = true
+		if stored, ok := f.store.Get(id); ok && stored.Version == job.Version {
+			continue
+		}
+		if err := f.store.Put(job); err != nil {
+			return fmt.Errorf("job %s: %w", id, err)
+		}
+	}
+	for _, job := range f.store.List(&model.JobFilter{}) {
+		if id := job.UID.String(); !held[id] {
+			f.store.Delete(id)
+		}
+	}
+	return nil
+}
+
+func (f *Follower) fetch(ctx context.Context) ([]*model.Job, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.primary.JoinPath("v1", "jobs").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var jobs []*model.Job
+	if err := json.NewDecoder(resp.Body).Decode(&jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Synced is when the jobs were last copied, or the zero time if they
+// never have been
+func (f *Follower) Synced() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.synced
+}
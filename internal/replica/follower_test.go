@@ -0,0 +1,92 @@
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePrimary serves whatever jobs it is given from GET /v1/jobs
+type fakePrimary struct {
+	mu   sync.Mutex
+	jobs []*model.Job
+}
+
+func (p *fakePrimary) set(jobs ...*model.Job) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.jobs = jobs
+}
+
+func (p *fakePrimary) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || r.URL.Path != "/v1/jobs" {
+		http.NotFound(w, r)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	json.NewEncoder(w).Encode(p.jobs)
+}
+
+func mathJob(version int64, status model.JobStatus) *model.Job {
+	return &model.Job{
+		UID:     uuid.New(),
+		Type:    "math",
+		Payload: model.MathJobPayload{Number: 7},
+		Status:  status,
+		Version: version,
+	}
+}
+
+func TestFollower_Sync(t *testing.T) {
+	ctx := context.Background()
+	primary := &fakePrimary{}
+	srv := httptest.NewServer(primary)
+	defer srv.Close()
+
+	store := pool.NewMemoryStore()
+	f, err := NewFollower(srv.URL, store, 0)
+	require.NoError(t, err)
+	assert.True(t, f.Synced().IsZero())
+
+	a, b := mathJob(1, model.JobStatusPending), mathJob(3, model.JobStatusCompleted)
+	primary.set(a, b)
+	f.sync(ctx)
+	assert.False(t, f.Synced().IsZero())
+	assert.Len(t, store.List(&model.JobFilter{}), 2)
+	got, ok := store.Get(b.UID.String())
+	require.True(t, ok)
+	assert.Equal(t, model.JobStatusCompleted, got.Status)
+	assert.Equal(t, model.MathJobPayload{Number: 7}, got.Payload)
+
+	// Changes are copied, and jobs the primary let go of are removed
+	running := *a
+	running.Status, running.Version = model.JobStatusRunning, 2
+	primary.set(&running)
+	require.NoError(t, f.Sync(ctx))
+	jobs := store.List(&model.JobFilter{})
+	require.Len(t, jobs, 1)
+	assert.Equal(t, model.JobStatusRunning, jobs[0].Status)
+	assert.Equal(t, int64(2), jobs[0].Version)
+
+	// The last copy is kept when the primary can't be reached
+	synced := f.Synced()
+	srv.Close()
+	f.sync(ctx)
+	assert.Equal(t, synced, f.Synced())
+	assert.Len(t, store.List(&model.JobFilter{}), 1)
+}
+
+func TestNewFollower(t *testing.T) {
+	_, err := NewFollower("primary:8080", nil, 0)
+	assert.EqualError(t, err, `primary "primary:8080": expected an http or https URL`)
+}
@@ -0,0 +1,100 @@
+// Package secrets looks up the secrets job payloads refer to by name, so
+// their values are only known while a job runs.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNotFound = errors.New("secret not found")
+
+// ErrInvalidName is returned for a name a provider can't look up
+var ErrInvalidName = errors.New("invalid secret name")
+
+// DefaultEnvPrefix starts the names of the environment variables the server
+// reads secrets from
+const DefaultEnvPrefix = "WPS_SECRET_"
+
+// Provider looks up secret values by name
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// TypedProvider is implemented by providers that can keep a separate copy
+// of a secret for each job type, so jobs of different types referencing the
+// same name can be given different values
+type TypedProvider interface {
+	Provider
+	GetForType(ctx context.Context, jobType, name string) (string, error)
+}
+
+// EnvProvider reads secrets from environment variables named by the prefix
+// and the upper-cased secret name, with anything other than letters and
+// digits replaced by underscores. With the prefix WPS_SECRET_, the secret
+// "github-token" is read from WPS_SECRET_GITHUB_TOKEN.
+type EnvProvider struct {
+	prefix string
+}
+
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix}
+}
+
+func (p *EnvProvider) Get(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(p.Variable(name))
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Variable returns the environment variable the secret is read from
+func (p *EnvProvider) Variable(name string) string {
+	return p.prefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// FileProvider reads each secret from the file of the same name in a
+// directory, the layout of a mounted Kubernetes secret. A trailing newline
+// is dropped.
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) (*FileProvider, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &FileProvider{dir: dir}, nil
+}
+
+func (p *FileProvider) Get(ctx context.Context, name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+}
@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvProvider(t *testing.T) {
+	ctx := context.Background()
+	p := NewEnvProvider("TEST_SECRET_")
+	assert.Equal(t, "TEST_SECRET_GITHUB_TOKEN", p.Variable("github-token"))
+	assert.Equal(t, "TEST_SECRET_CI_AWS_KEY", p.Variable("ci/aws.key"))
+
+	t.Setenv("TEST_SECRET_GITHUB_TOKEN", "gh-token")
+	value, err := p.Get(ctx, "github-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "gh-token", value)
+
+	_, err = p.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFileProvider(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "github"), []byte("gh-token\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("x"), 0o600))
+
+	p, err := NewFileProvider(dir)
+	assert.NoError(t, err)
+	value, err := p.Get(ctx, "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "gh-token", value)
+
+	for _, name := range []string{"missing", ".hidden", "../github", "sub/github", ""} {
+		_, err = p.Get(ctx, name)
+		assert.ErrorIs(t, err, ErrNotFound, name)
+	}
+
+	_, err = NewFileProvider(filepath.Join(dir, "github"))
+	assert.Error(t, err)
+	_, err = NewFileProvider(filepath.Join(dir, "nowhere"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,413 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Vault auth methods
+const (
+	VaultAuthToken      = "token"
+	VaultAuthKubernetes = "kubernetes"
+	VaultAuthAppRole    = "approle"
+)
+
+// DefaultVaultJWTPath is where Kubernetes mounts a pod's service account
+// token, which Kubernetes auth presents to Vault
+const DefaultVaultJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// VaultConfig locates a Vault server, how to log in to it and the KV
+// version 2 engine secrets are read from
+type VaultConfig struct {
+	Address string
+	// Auth is the auth method, VaultAuthToken when empty. Kubernetes and
+	// AppRole auth log in for a token, so no static token is needed.
+	Auth string
+	// AuthMount is where the auth method is mounted, its name by default
+	AuthMount string
+	// Token is used with token auth
+	Token string
+	// Role and JWTPath are used with Kubernetes auth
+	Role    string
+	JWTPath string
+	// RoleID and SecretID are used with AppRole auth
+	RoleID   string
+	SecretID string
+
+	// Mount is where the KV engine is mounted, "secret" by default
+	Mount string
+	// Path is prepended to every secret name
+	Path string
+	// Field is the key within each Vault secret holding its value, "value"
+	// by default
+	Field string
+}
+
+// VaultClient talks to Vault, logging in when it needs a token and renewing
+// the token before it expires
+type VaultClient struct {
+	cfg    VaultConfig
+	base   *url.URL
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	lease   lease
+	checked bool
+}
+
+// lease tracks when a token or dynamic secret should be renewed
+type lease struct {
+	renewable bool
+	duration  time.Duration
+	renewAt   time.Time
+	expiresAt time.Time
+}
+
+// newLease starts a lease of d at now, renewed two thirds of the way through.
+// A zero duration never expires.
+func newLease(now time.Time, d time.Duration, renewable bool) lease {
+	if d <= 0 {
+		return lease{}
+	}
+	return lease{renewable: renewable, duration: d, renewAt: now.Add(d * 2 / 3), expiresAt: now.Add(d)}
+}
+
+func (l lease) due(now time.Time) bool {
+	return !l.renewAt.IsZero() && !now.Before(l.renewAt)
+}
+
+func (l lease) expired(now time.Time) bool {
+	return !l.expiresAt.IsZero() && !now.Before(l.expiresAt)
+}
+
+func NewVaultClient(cfg VaultConfig) (*VaultClient, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("vault address is required")
+	}
+	base, err := url.Parse(cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("vault address: %w", err)
+	}
+	if cfg.Auth == "" {
+		cfg.Auth = VaultAuthToken
+	}
+	switch cfg.Auth {
+	case VaultAuthToken:
+		if cfg.Token == "" {
+			return nil, errors.New("vault token is required")
+		}
+	case VaultAuthKubernetes:
+		if cfg.Role == "" {
+			return nil, errors.New("vault role is required for kubernetes auth")
+		}
+		if cfg.JWTPath == "" {
+			cfg.JWTPath = DefaultVaultJWTPath
+		}
+	case VaultAuthAppRole:
+		if cfg.RoleID == "" || cfg.SecretID == "" {
+			return nil, errors.New("vault role ID and secret ID are required for approle auth")
+		}
+	default:
+		return nil, fmt.Errorf("unknown vault auth method %q", cfg.Auth)
+	}
+	if cfg.AuthMount == "" {
+		cfg.AuthMount = cfg.Auth
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = "secret"
+	}
+	if cfg.Field == "" {
+		cfg.Field = "value"
+	}
+	return &VaultClient{
+		cfg:    cfg,
+		base:   base,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+		token:  cfg.Token,
+	}, nil
+}
+
+// vaultAuth is the auth block of login and token renewal responses
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// authToken returns a token to make requests with, renewing it when it is
+// due or logging in again once it can't be
+func (c *VaultClient) authToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+
+	if c.cfg.Auth == VaultAuthToken && !c.checked {
+		// A static token's lifetime is only known by asking
+		var resp struct {
+			Data struct {
+				TTL       int  `json:"ttl"`
+				Renewable bool `json:"renewable"`
+			} `json:"data"`
+		}
+		if err := c.send(ctx, c.token, http.MethodGet, "auth/token/lookup-self", nil, &resp); err == nil {
+			c.lease = newLease(now, time.Duration(resp.Data.TTL)*time.Second, resp.Data.Renewable)
+		}
+		// A token that can't look itself up is used as it is
+		c.checked = true
+	}
+
+	if c.token != "" && !c.lease.due(now) {
+		return c.token, nil
+	}
+	if c.token != "" && c.lease.renewable && !c.lease.expired(now) {
+		var resp struct {
+			Auth vaultAuth `json:"auth"`
+		}
+		if err := c.send(ctx, c.token, http.MethodPost, "auth/token/renew-self", nil, &resp); err == nil {
+			c.lease = newLease(now, time.Duration(resp.Auth.LeaseDuration)*time.Second, resp.Auth.Renewable)
+			return c.token, nil
+		}
+	}
+	if c.cfg.Auth == VaultAuthToken {
+		// A static token can't be replaced, so it is used until it expires
+		return c.token, nil
+	}
+	if err := c.login(ctx, now); err != nil {
+		if c.token != "" && !c.lease.expired(now) {
+			// Try again next time while the current token lasts
+			return c.token, nil
+		}
+		return "", err
+	}
+	return c.token, nil
+}
+
+// login exchanges the configured credentials for a token. c.mu must be held.
+func (c *VaultClient) login(ctx context.Context, now time.Time) error {
+	var body map[string]string
+	switch c.cfg.Auth {
+	case VaultAuthKubernetes:
+		jwt, err := os.ReadFile(c.cfg.JWTPath)
+		if err != nil {
+			return fmt.Errorf("vault login: %w", err)
+		}
+		body = map[string]string{"role": c.cfg.Role, "jwt": strings.TrimSpace(string(jwt))}
+	case VaultAuthAppRole:
+		body = map[string]string{"role_id": c.cfg.RoleID, "secret_id": c.cfg.SecretID}
+	}
+	var resp struct {
+		Auth vaultAuth `json:"auth"`
+	}
+	if err := c.send(ctx, "", http.MethodPost, "auth/"+strings.Trim(c.cfg.AuthMount, "/")+"/login", body, &resp); err != nil {
+		return fmt.Errorf("vault login: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return errors.New("vault login: no token returned")
+	}
+	c.token = resp.Auth.ClientToken
+	c.lease = newLease(now, time.Duration(resp.Auth.LeaseDuration)*time.Second, resp.Auth.Renewable)
+	return nil
+}
+
+// Check logs in if needed and confirms Vault accepts the token
+func (c *VaultClient) Check(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "auth/token/lookup-self", nil, nil)
+}
+
+// do makes an authenticated request to the Vault API path, decoding the
+// response into out
+func (c *VaultClient) do(ctx context.Context, method, path string, body, out any) error {
+	token, err := c.authToken(ctx)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, token, method, path, body, out)
+}
+
+func (c *VaultClient) send(ctx context.Context, token, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base.JoinPath("v1", path).String(), r)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("vault: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	case out == nil || resp.StatusCode == http.StatusNoContent:
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("vault: %w", err)
+	}
+	return nil
+}
+
+// VaultProvider reads secrets from Vault's KV engine. The secret "github"
+// is read from Field of the KV secret at Path/github, or Path/sleep/github
+// for a sleep job when that exists.
+type VaultProvider struct {
+	client *VaultClient
+}
+
+func NewVaultProvider(client *VaultClient) *VaultProvider {
+	return &VaultProvider{client: client}
+}
+
+func (p *VaultProvider) Get(ctx context.Context, name string) (string, error) {
+	return p.read(ctx, name)
+}
+
+// GetForType prefers the job type's own copy of the secret. Names can't
+// contain a slash, as one such as http/token would read the http type's
+// copy of the token when the job type has none of its own.
+func (p *VaultProvider) GetForType(ctx context.Context, jobType, name string) (string, error) {
+	if strings.Contains(name, "/") {
+		return "", fmt.Errorf("%w: %q contains /, which Vault keeps for job types' own copies", ErrInvalidName, name)
+	}
+	value, err := p.read(ctx, jobType+"/"+name)
+	if !errors.Is(err, ErrNotFound) {
+		return value, err
+	}
+	return p.read(ctx, name)
+}
+
+func (p *VaultProvider) read(ctx context.Context, name string) (string, error) {
+	cfg := p.client.cfg
+	path := strings.Trim(cfg.Mount, "/") + "/data/"
+	if prefix := strings.Trim(cfg.Path, "/"); prefix != "" {
+		path += prefix + "/"
+	}
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := p.client.do(ctx, http.MethodGet, path+name, nil, &body); err != nil {
+		return "", err
+	}
+	value, ok := body.Data.Data[cfg.Field]
+	if !ok {
+		return "", ErrNotFound
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault: field %q of secret %q is not a string", cfg.Field, name)
+	}
+	return s, nil
+}
+
+// AWSKeys are credentials issued by Vault's AWS secrets engine
+type AWSKeys struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// VaultAWSCredentials issues AWS credentials from a role of Vault's AWS
+// secrets engine. Their lease is renewed as it comes due, and new
+// credentials are read once it can't be renewed or renewals stop extending
+// it by much, as happens near the role's maximum TTL.
+type VaultAWSCredentials struct {
+	client *VaultClient
+	path   string
+
+	mu      sync.Mutex
+	keys    AWSKeys
+	leaseID string
+	lease   lease
+}
+
+// AWSCredentials returns credentials for role of the AWS secrets engine
+// mounted at mount
+func (c *VaultClient) AWSCredentials(mount, role string) *VaultAWSCredentials {
+	return &VaultAWSCredentials{client: c, path: strings.Trim(mount, "/") + "/creds/" + role}
+}
+
+// Get returns current credentials, renewing or replacing them as needed
+func (a *VaultAWSCredentials) Get(ctx context.Context) (AWSKeys, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.client.now()
+
+	issued := a.keys != AWSKeys{}
+	if issued && !a.lease.due(now) {
+		return a.keys, nil
+	}
+	if issued && a.leaseID != "" && a.lease.renewable && !a.lease.expired(now) {
+		var resp struct {
+			LeaseDuration int  `json:"lease_duration"`
+			Renewable     bool `json:"renewable"`
+		}
+		body := map[string]any{"lease_id": a.leaseID, "increment": int(a.lease.duration.Seconds())}
+		err := a.client.do(ctx, http.MethodPut, "sys/leases/renew", body, &resp)
+		renewed := time.Duration(resp.LeaseDuration) * time.Second
+		if err == nil && renewed >= a.lease.duration/3 {
+			duration := a.lease.duration
+			a.lease = newLease(now, renewed, resp.Renewable)
+			// Keep asking for the original increment
+			a.lease.duration = duration
+			return a.keys, nil
+		}
+	}
+	return a.read(ctx, now)
+}
+
+// read fetches new credentials. a.mu must be held.
+func (a *VaultAWSCredentials) read(ctx context.Context, now time.Time) (AWSKeys, error) {
+	var resp struct {
+		LeaseID       string `json:"lease_id"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+		Data          struct {
+			AccessKey     string `json:"access_key"`
+			SecretKey     string `json:"secret_key"`
+			SecurityToken string `json:"security_token"`
+		} `json:"data"`
+	}
+	if err := a.client.do(ctx, http.MethodGet, a.path, nil, &resp); err != nil {
+		if a.keys != (AWSKeys{}) && !a.lease.expired(now) {
+			// The current credentials are still good for a while
+			return a.keys, nil
+		}
+		return AWSKeys{}, fmt.Errorf("vault aws credentials: %w", err)
+	}
+	a.keys = AWSKeys{AccessKeyID: resp.Data.AccessKey, SecretAccessKey: resp.Data.SecretKey, SessionToken: resp.Data.SecurityToken}
+	a.leaseID = resp.LeaseID
+	a.lease = newLease(now, time.Duration(resp.LeaseDuration)*time.Second, resp.Renewable)
+	return a.keys, nil
+}
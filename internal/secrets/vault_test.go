@@ -0,0 +1,284 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVault serves a KV engine, an AWS secrets engine and the token and
+// login endpoints from memory, recording the requests it is sent
+type fakeVault struct {
+	t *testing.T
+
+	mu        sync.Mutex
+	tokens    map[string]bool
+	issued    int
+	logins    int
+	renewals  int
+	leaseTTL  int
+	renewTTLs []int
+	requests  []string
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	return &fakeVault{t: t, tokens: map[string]bool{"s.static": true}, leaseTTL: 3600}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	write := func(v any) { json.NewEncoder(w).Encode(v) }
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			http.Error(w, `{"errors":["invalid role or secret ID"]}`, http.StatusBadRequest)
+			return
+		}
+		f.logins++
+		token := "s.login" + strings.Repeat("+", f.logins)
+		f.tokens[token] = true
+		write(map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": 60, "renewable": false}})
+		return
+	case "/v1/auth/k8s/login":
+		assert.Equal(f.t, "wps", body["role"])
+		assert.Equal(f.t, "service-account-jwt", body["jwt"])
+		f.tokens["s.k8s"] = true
+		write(map[string]any{"auth": map[string]any{"client_token": "s.k8s", "lease_duration": 60, "renewable": true}})
+		return
+	}
+
+	if !f.tokens[r.Header.Get("X-Vault-Token")] {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/auth/token/lookup-self":
+		write(map[string]any{"data": map[string]any{"ttl": 90, "renewable": true}})
+	case "/v1/auth/token/renew-self":
+		write(map[string]any{"auth": map[string]any{"client_token": r.Header.Get("X-Vault-Token"), "lease_duration": 90, "renewable": true}})
+	case "/v1/kv/data/wps/github":
+		write(map[string]any{"data": map[string]any{"data": map[string]any{"value": "gh-token"}}})
+	case "/v1/kv/data/wps/container/github":
+		write(map[string]any{"data": map[string]any{"data": map[string]any{"value": "container-gh-token"}}})
+	case "/v1/kv/data/wps/numeric":
+		write(map[string]any{"data": map[string]any{"data": map[string]any{"value": 42}}})
+	case "/v1/kv/data/wps/other-field":
+		write(map[string]any{"data": map[string]any{"data": map[string]any{"password": "x"}}})
+	case "/v1/aws/creds/uploader":
+		f.issued++
+		id := strings.Repeat("I", f.issued)
+		write(map[string]any{
+			"lease_id":       "aws/creds/uploader/" + id,
+			"lease_duration": f.leaseTTL,
+			"renewable":      true,
+			"data":           map[string]any{"access_key": "AKIA" + id, "secret_key": "secret" + id},
+		})
+	case "/v1/sys/leases/renew":
+		assert.Equal(f.t, "aws/creds/uploader/"+strings.Repeat("I", f.issued), body["lease_id"])
+		assert.Equal(f.t, float64(f.leaseTTL), body["increment"])
+		f.renewals++
+		ttl := f.leaseTTL
+		if len(f.renewTTLs) > 0 {
+			ttl, f.renewTTLs = f.renewTTLs[0], f.renewTTLs[1:]
+		}
+		write(map[string]any{"lease_id": body["lease_id"], "lease_duration": ttl, "renewable": true})
+	default:
+		http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+	}
+}
+
+// fakeNow is a clock the tests move by hand
+type fakeNow struct {
+	now time.Time
+}
+
+func (c *fakeNow) Now() time.Time { return c.now }
+
+func newTestVaultClient(t *testing.T, url string, cfg VaultConfig) (*VaultClient, *fakeNow) {
+	t.Helper()
+	cfg.Address = url
+	client, err := NewVaultClient(cfg)
+	assert.NoError(t, err)
+	clock := &fakeNow{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client.now = clock.Now
+	return client, clock
+}
+
+func TestVaultProvider(t *testing.T) {
+	ctx := context.Background()
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	client, _ := newTestVaultClient(t, server.URL, VaultConfig{Token: "s.static", Mount: "kv", Path: "/wps/"})
+	p := NewVaultProvider(client)
+	value, err := p.Get(ctx, "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "gh-token", value)
+
+	// Job types can have their own copy of a secret
+	value, err = p.GetForType(ctx, "container", "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "container-gh-token", value)
+	value, err = p.GetForType(ctx, "sleep", "github")
+	assert.NoError(t, err)
+	assert.Equal(t, "gh-token", value)
+	// Names can't reach into another type's copies
+	_, err = p.GetForType(ctx, "sleep", "container/github")
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	_, err = p.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = p.Get(ctx, "other-field")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = p.Get(ctx, "numeric")
+	assert.EqualError(t, err, `vault: field "value" of secret "numeric" is not a string`)
+
+	assert.NoError(t, client.Check(ctx))
+
+	denied, _ := newTestVaultClient(t, server.URL, VaultConfig{Token: "wrong", Mount: "kv", Path: "wps"})
+	_, err = NewVaultProvider(denied).Get(ctx, "github")
+	assert.ErrorContains(t, err, "403 Forbidden")
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.ErrorContains(t, denied.Check(ctx), "403 Forbidden")
+}
+
+func TestVaultClient_TokenRenewal(t *testing.T) {
+	ctx := context.Background()
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	client, clock := newTestVaultClient(t, server.URL, VaultConfig{Token: "s.static", Mount: "kv", Path: "wps"})
+	p := NewVaultProvider(client)
+	_, err := p.Get(ctx, "github")
+	assert.NoError(t, err)
+	clock.now = clock.now.Add(30 * time.Second)
+	_, err = p.Get(ctx, "github")
+	assert.NoError(t, err)
+	// The 90s token is renewed two thirds of the way through
+	clock.now = clock.now.Add(30 * time.Second)
+	_, err = p.Get(ctx, "github")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"GET /v1/auth/token/lookup-self",
+		"GET /v1/kv/data/wps/github",
+		"GET /v1/kv/data/wps/github",
+		"POST /v1/auth/token/renew-self",
+		"GET /v1/kv/data/wps/github",
+	}, vault.requests)
+}
+
+func TestVaultClient_Login(t *testing.T) {
+	ctx := context.Background()
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	t.Run("approle", func(t *testing.T) {
+		client, clock := newTestVaultClient(t, server.URL, VaultConfig{Auth: VaultAuthAppRole, RoleID: "role", SecretID: "secret", Mount: "kv", Path: "wps"})
+		p := NewVaultProvider(client)
+		_, err := p.Get(ctx, "github")
+		assert.NoError(t, err)
+		_, err = p.Get(ctx, "github")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, vault.logins)
+
+		// The token isn't renewable, so it is replaced when due
+		clock.now = clock.now.Add(45 * time.Second)
+		_, err = p.Get(ctx, "github")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, vault.logins)
+	})
+
+	t.Run("kubernetes", func(t *testing.T) {
+		jwt := filepath.Join(t.TempDir(), "token")
+		assert.NoError(t, os.WriteFile(jwt, []byte("service-account-jwt\n"), 0o600))
+		client, _ := newTestVaultClient(t, server.URL, VaultConfig{Auth: VaultAuthKubernetes, AuthMount: "k8s", Role: "wps", JWTPath: jwt, Mount: "kv", Path: "wps"})
+		value, err := NewVaultProvider(client).Get(ctx, "github")
+		assert.NoError(t, err)
+		assert.Equal(t, "gh-token", value)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		client, _ := newTestVaultClient(t, server.URL, VaultConfig{Auth: VaultAuthAppRole, RoleID: "role", SecretID: "wrong", Mount: "kv", Path: "wps"})
+		_, err := NewVaultProvider(client).Get(ctx, "github")
+		assert.ErrorContains(t, err, "vault login: vault: 400 Bad Request")
+	})
+}
+
+func TestVaultAWSCredentials(t *testing.T) {
+	ctx := context.Background()
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	client, clock := newTestVaultClient(t, server.URL, VaultConfig{Token: "s.static"})
+	creds := client.AWSCredentials("aws", "uploader")
+	keys, err := creds.Get(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, AWSKeys{AccessKeyID: "AKIAI", SecretAccessKey: "secretI"}, keys)
+
+	// Cached until the lease comes due, then renewed
+	clock.now = clock.now.Add(30 * time.Minute)
+	keys, err = creds.Get(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "AKIAI", keys.AccessKeyID)
+	assert.Equal(t, 0, vault.renewals)
+
+	vault.renewTTLs = []int{3600, 600}
+	clock.now = clock.now.Add(15 * time.Minute)
+	keys, err = creds.Get(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "AKIAI", keys.AccessKeyID)
+	assert.Equal(t, 1, vault.renewals)
+
+	// A renewal cut short by the role's max TTL gets new credentials
+	clock.now = clock.now.Add(45 * time.Minute)
+	keys, err = creds.Get(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, AWSKeys{AccessKeyID: "AKIAII", SecretAccessKey: "secretII"}, keys)
+	assert.Equal(t, 2, vault.renewals)
+
+	// Expired credentials are replaced without trying to renew them
+	clock.now = clock.now.Add(2 * time.Hour)
+	keys, err = creds.Get(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "AKIAIII", keys.AccessKeyID)
+	assert.Equal(t, 2, vault.renewals)
+}
+
+func TestNewVaultClient(t *testing.T) {
+	for _, cfg := range []VaultConfig{
+		{Token: "t"},
+		{Address: "http://vault:8200"},
+		{Address: "http://vault:8200", Auth: VaultAuthKubernetes},
+		{Address: "http://vault:8200", Auth: VaultAuthAppRole, RoleID: "r"},
+		{Address: "http://vault:8200", Auth: "ldap"},
+	} {
+		_, err := NewVaultClient(cfg)
+		assert.Error(t, err, cfg)
+	}
+
+	client, err := NewVaultClient(VaultConfig{Address: "http://vault:8200", Auth: VaultAuthKubernetes, Role: "wps"})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultVaultJWTPath, client.cfg.JWTPath)
+	assert.Equal(t, "kubernetes", client.cfg.AuthMount)
+	assert.Equal(t, "secret", client.cfg.Mount)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dnakolan/worker-pool-service/internal/alerts"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+)
+
+var ErrAlertRuleNotFound = alerts.ErrRuleNotFound
+
+type AlertsService interface {
+	CreateAlertRule(ctx context.Context, req *model.AlertRuleRequest) (*model.AlertRule, error)
+	ListAlertRules(ctx context.Context) ([]*model.AlertRule, error)
+	GetAlertRule(ctx context.Context, id uuid.UUID) (*model.AlertRule, error)
+	UpdateAlertRule(ctx context.Context, id uuid.UUID, req *model.AlertRuleRequest) (*model.AlertRule, error)
+	DeleteAlertRule(ctx context.Context, id uuid.UUID) error
+	ListAlerts(ctx context.Context) ([]*model.Alert, error)
+}
+
+type alertsService struct {
+	engine *alerts.Engine
+}
+
+func NewAlertsService(engine *alerts.Engine) *alertsService {
+	return &alertsService{engine: engine}
+}
+
+func (s *alertsService) CreateAlertRule(ctx context.Context, req *model.AlertRuleRequest) (*model.AlertRule, error) {
+	return s.engine.CreateRule(req), nil
+}
+
+func (s *alertsService) ListAlertRules(ctx context.Context) ([]*model.AlertRule, error) {
+	return s.engine.ListRules(), nil
+}
+
+func (s *alertsService) GetAlertRule(ctx context.Context, id uuid.UUID) (*model.AlertRule, error) {
+	return s.engine.GetRule(id)
+}
+
+func (s *alertsService) UpdateAlertRule(ctx context.Context, id uuid.UUID, req *model.AlertRuleRequest) (*model.AlertRule, error) {
+	return s.engine.UpdateRule(id, req)
+}
+
+func (s *alertsService) DeleteAlertRule(ctx context.Context, id uuid.UUID) error {
+	return s.engine.DeleteRule(id)
+}
+
+func (s *alertsService) ListAlerts(ctx context.Context) ([]*model.Alert, error) {
+	return s.engine.History(), nil
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dnakolan/worker-pool-service/internal/anomaly"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+type AnomaliesService interface {
+	ListAnomalies(ctx context.Context, jobType string) ([]model.DurationAnomaly, error)
+}
+
+type anomaliesService struct {
+	detector *anomaly.Detector
+}
+
+func NewAnomaliesService(detector *anomaly.Detector) *anomaliesService {
+	return &anomaliesService{detector: detector}
+}
+
+func (s *anomaliesService) ListAnomalies(ctx context.Context, jobType string) ([]model.DurationAnomaly, error) {
+	return s.detector.List(jobType), nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+var ErrArtifactNotFound = pool.ErrArtifactNotFound
+
+type ArtifactsService interface {
+	CreateArtifact(ctx context.Context, jobID, name, contentType string, content io.Reader) (*model.Artifact, error)
+	ListArtifacts(ctx context.Context, jobID string) ([]*model.Artifact, error)
+	GetArtifact(ctx context.Context, id string) (*model.Artifact, io.ReadCloser, error)
+	// ArtifactURL returns a pre-signed download URL, or "" when the
+	// artifact has to be served by GetArtifact
+	ArtifactURL(ctx context.Context, id string) (string, error)
+	DeleteArtifact(ctx context.Context, id string) error
+}
+
+type artifactsService struct {
+	pool *pool.WorkerPool
+}
+
+func NewArtifactsService(pool *pool.WorkerPool) *artifactsService {
+	return &artifactsService{pool: pool}
+}
+
+func (s *artifactsService) CreateArtifact(ctx context.Context, jobID, name, contentType string, content io.Reader) (*model.Artifact, error) {
+	return s.pool.CreateArtifact(ctx, jobID, name, contentType, content)
+}
+
+func (s *artifactsService) ListArtifacts(ctx context.Context, jobID string) ([]*model.Artifact, error) {
+	if jobID != "" {
+		if _, exists := s.pool.GetJob(ctx, jobID); !exists {
+			return nil, ErrJobNotFound
+		}
+	}
+	artifacts := make([]*model.Artifact, 0)
+	for _, a := range s.pool.ListArtifacts(ctx, jobID) {
+		url, err := s.pool.ArtifactURL(ctx, a.ID)
+		if err != nil && !errors.Is(err, ErrArtifactNotFound) {
+			return nil, err
+		}
+		artifact := *a
+		artifact.URL = url
+		artifacts = append(artifacts, &artifact)
+	}
+	return artifacts, nil
+}
+
+func (s *artifactsService) ArtifactURL(ctx context.Context, id string) (string, error) {
+	return s.pool.ArtifactURL(ctx, id)
+}
+
+func (s *artifactsService) GetArtifact(ctx context.Context, id string) (*model.Artifact, io.ReadCloser, error) {
+	return s.pool.OpenArtifact(ctx, id)
+}
+
+func (s *artifactsService) DeleteArtifact(ctx context.Context, id string) error {
+	return s.pool.DeleteArtifact(ctx, id)
+}
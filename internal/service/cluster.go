@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// Cluster keeps track of the instances of the service sharing the work
+type Cluster interface {
+	// Members describes every live instance, this one included
+	Members(ctx context.Context) []model.NodeStats
+	// Gossip takes in what a peer knows of the cluster and answers with
+	// what this instance knows
+	Gossip(ctx context.Context, gossip *model.Gossip) *model.Gossip
+}
+
+type ClusterService interface {
+	Gossip(ctx context.Context, gossip *model.Gossip) (*model.Gossip, error)
+}
+
+type clusterService struct {
+	cluster Cluster
+}
+
+func NewClusterService(cluster Cluster) *clusterService {
+	return &clusterService{cluster: cluster}
+}
+
+func (s *clusterService) Gossip(ctx context.Context, gossip *model.Gossip) (*model.Gossip, error) {
+	return s.cluster.Gossip(ctx, gossip), nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/dnakolan/worker-pool-service/internal/config"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+type ConfigService interface {
+	PreviewConfig(ctx context.Context, settings map[string]string) (*model.ConfigPreview, error)
+}
+
+type configService struct{}
+
+func NewConfigService() *configService {
+	return &configService{}
+}
+
+// PreviewConfig loads a configuration from the given settings alone, so
+// anything they leave out takes its default rather than this instance's
+// value. Settings the configuration never reads are warned about, as they're
+// either misspelled or have no effect alongside the others.
+func (s *configService) PreviewConfig(ctx context.Context, settings map[string]string) (*model.ConfigPreview, error) {
+	read := make(map[string]bool)
+	cfg, err := config.LoadFrom(func(key string) string {
+		read[key] = true
+		return settings[key]
+	})
+	if err != nil {
+		return &model.ConfigPreview{Errors: []string{err.Error()}}, nil
+	}
+
+	preview := &model.ConfigPreview{Valid: true, Warnings: cfg.Warnings(), Config: cfg.Redacted()}
+	for _, key := range slices.Sorted(maps.Keys(settings)) {
+		if !read[key] {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("%s is not a known setting or has no effect with the others", key))
+		}
+	}
+	return preview, nil
+}
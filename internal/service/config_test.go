@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigService_PreviewConfig(t *testing.T) {
+	ctx := context.Background()
+	svc := NewConfigService()
+
+	preview, err := svc.PreviewConfig(ctx, map[string]string{
+		"WPS_WORKERS":     "0",
+		"WPS_WORKRES":     "4",
+		"WPS_ADMIN_TOKEN": "admin-secret",
+		"VAULT_ROLE_ID":   "role",
+	})
+	require.NoError(t, err)
+	assert.True(t, preview.Valid)
+	assert.Empty(t, preview.Errors)
+	assert.Equal(t, []string{
+		"WPS_WORKERS is 0 and WPS_CAPABLE_WORKERS is unset, so only remote workers will run jobs",
+		"VAULT_ROLE_ID is not a known setting or has no effect with the others",
+		"WPS_WORKRES is not a known setting or has no effect with the others",
+	}, preview.Warnings)
+	cfg := preview.Config.(*config.Config)
+	assert.Equal(t, 0, cfg.Workers)
+	assert.Equal(t, 10, cfg.QueueSize)
+	assert.Equal(t, "REDACTED", cfg.AdminToken)
+
+	preview, err = svc.PreviewConfig(ctx, map[string]string{"WPS_QUEUE_SIZE": "lots"})
+	require.NoError(t, err)
+	assert.False(t, preview.Valid)
+	assert.Len(t, preview.Errors, 1)
+	assert.Contains(t, preview.Errors[0], "WPS_QUEUE_SIZE")
+	assert.Nil(t, preview.Config)
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+type FaultsService interface {
+	GetFaults(ctx context.Context) (*model.FaultScenario, error)
+	SetFaults(ctx context.Context, scenario *model.FaultScenario) error
+}
+
+type faultsService struct {
+	pool *pool.WorkerPool
+}
+
+func NewFaultsService(pool *pool.WorkerPool) *faultsService {
+	return &faultsService{pool: pool}
+}
+
+func (s *faultsService) GetFaults(ctx context.Context) (*model.FaultScenario, error) {
+	scenario := s.pool.Faults()
+	return &scenario, nil
+}
+
+func (s *faultsService) SetFaults(ctx context.Context, scenario *model.FaultScenario) error {
+	s.pool.SetFaults(*scenario)
+	return nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dnakolan/worker-pool-service/internal/features"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+var ErrUnknownFeatureFlag = features.ErrUnknownFlag
+
+type FeaturesService interface {
+	ListFeatureFlags(ctx context.Context) ([]model.FeatureFlag, error)
+	SetFeatureFlag(ctx context.Context, name string, enabled bool) (*model.FeatureFlag, error)
+}
+
+type featuresService struct {
+	flags *features.Set
+}
+
+func NewFeaturesService(flags *features.Set) *featuresService {
+	return &featuresService{flags: flags}
+}
+
+func (s *featuresService) ListFeatureFlags(ctx context.Context) ([]model.FeatureFlag, error) {
+	return s.flags.Flags(), nil
+}
+
+func (s *featuresService) SetFeatureFlag(ctx context.Context, name string, enabled bool) (*model.FeatureFlag, error) {
+	if err := s.flags.Set(name, enabled); err != nil {
+		return nil, err
+	}
+	for _, flag := range s.flags.Flags() {
+		if flag.Name == name {
+			return &flag, nil
+		}
+	}
+	return nil, ErrUnknownFeatureFlag
+}
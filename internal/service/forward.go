@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// Forwarder hands a job this instance can't run to a peer, returning the
+// job as the peer created it. A submission made unless an equivalent job
+// exists, or to be coalesced, is made so on the peer too, and existing
+// reports whether the peer answered with a job it already had.
+type Forwarder interface {
+	Forward(ctx context.Context, job *model.Job, unlessExists *model.ExistingJobMatch, coalesce *model.CoalesceOptions) (forwarded *model.Job, existing bool, err error)
+}
+
+type forwardedKey struct{}
+
+// Forwarded marks a submission as forwarded from the named peer, so it
+// isn't forwarded again
+func Forwarded(ctx context.Context, from string) context.Context {
+	return context.WithValue(ctx, forwardedKey{}, from)
+}
+
+// SetForwarder forwards submissions of disabled job types, or needing
+// capabilities or placement no worker here offers, to peers instead of
+// rejecting them
+func (s *jobsService) SetForwarder(f Forwarder) {
+	s.forwarder = f
+}
+
+// forward hands the job to a peer if err means this instance can't run
+// it, replacing the job with the peer's, or returning the job the peer
+// already had that the submission stood in for. It reports whether a peer
+// took it.
+func (s *jobsService) forward(ctx context.Context, job *model.Job, err error, unlessExists *model.ExistingJobMatch, coalesce *model.CoalesceOptions) (*model.Job, bool) {
+	if s.forwarder == nil || ctx.Value(forwardedKey{}) != nil {
+		return nil, false
+	}
+	if !errors.Is(err, ErrJobTypeDisabled) && !errors.Is(err, ErrUnschedulable) {
+		return nil, false
+	}
+	forwarded, existing, ferr := s.forwarder.Forward(ctx, job, unlessExists, coalesce)
+	if ferr != nil {
+		slog.Warn("Failed to forward job", "job_type", job.Type, "reason", err, "error", ferr)
+		return nil, false
+	}
+	slog.Info("Job forwarded", "job_id", forwarded.UID, "job_type", job.Type, "node", forwarded.Node, "existing", existing, "reason", err)
+	if existing {
+		return forwarded, true
+	}
+	*job = *forwarded
+	return nil, true
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/dnakolan/worker-pool-service/internal/graphql"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/google/uuid"
+	gql "github.com/graphql-go/graphql"
+)
+
+type GraphQLService interface {
+	Execute(ctx context.Context, req *graphql.Request) (*graphql.Response, error)
+}
+
+type graphQLService struct {
+	schema *graphql.Schema
+}
+
+// NewGraphQLService answers GraphQL queries over the same jobs, stats and
+// job types as the REST API. Objects have the fields of their JSON
+// representation, and jobs can be followed along their lineage.
+func NewGraphQLService(jobs JobsService, stats StatsService, jobTypes JobTypesService) *graphQLService {
+	schema := graphql.NewSchema()
+	job := schema.ObjectOf(model.Job{})
+	lineage := schema.ObjectOf(model.Lineage{})
+
+	// getJob reads a job, or nil if it's gone
+	getJob := func(ctx context.Context, uid string) (any, error) {
+		j, err := jobs.GetJobs(ctx, uid)
+		if errors.Is(err, ErrJobNotFound) {
+			return nil, nil
+		}
+		return j, err
+	}
+	// linked reads the jobs that came from one the given way
+	linked := func(kind string) gql.FieldResolveFn {
+		return func(p gql.ResolveParams) (any, error) {
+			from := p.Source.(*model.Job).UID
+			l, err := lineagesOf(p.Context).get(p.Context, jobs, from)
+			if l == nil || err != nil {
+				return nil, err
+			}
+			linked := make([]*model.Job, 0)
+			for _, edge := range l.from[from] {
+				if edge.Kind != kind {
+					continue
+				}
+				j, err := jobs.GetJobs(p.Context, edge.To.String())
+				if errors.Is(err, ErrJobNotFound) {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				linked = append(linked, j)
+			}
+			return linked, nil
+		}
+	}
+	origin := func(uid func(*model.Job) *uuid.UUID) gql.FieldResolveFn {
+		return func(p gql.ResolveParams) (any, error) {
+			if id := uid(p.Source.(*model.Job)); id != nil {
+				return getJob(p.Context, id.String())
+			}
+			return nil, nil
+		}
+	}
+
+	job.AddFieldConfig("parent_job", &gql.Field{
+		Type:    job,
+		Resolve: origin(func(j *model.Job) *uuid.UUID { return j.Parent }),
+	})
+	job.AddFieldConfig("children", &gql.Field{
+		Type:    gql.NewList(job),
+		Resolve: linked(model.LineageParent),
+	})
+	job.AddFieldConfig("replayed_from_job", &gql.Field{
+		Type:    job,
+		Resolve: origin(func(j *model.Job) *uuid.UUID { return j.ReplayedFrom }),
+	})
+	job.AddFieldConfig("replays", &gql.Field{
+		Type:    gql.NewList(job),
+		Resolve: linked(model.LineageReplay),
+	})
+	job.AddFieldConfig("lineage", &gql.Field{
+		Type: lineage,
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			uid := p.Source.(*model.Job).UID
+			l, err := lineagesOf(p.Context).get(p.Context, jobs, uid)
+			if l == nil || err != nil {
+				return nil, err
+			}
+			return l.of(uid), nil
+		},
+	})
+
+	schema.Query.AddFieldConfig("job", &gql.Field{
+		Type: job,
+		Args: gql.FieldConfigArgument{"uid": {Type: gql.NewNonNull(gql.ID)}},
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return getJob(p.Context, p.Args["uid"].(string))
+		},
+	})
+	schema.Query.AddFieldConfig("jobs", &gql.Field{
+		Type: schema.ObjectOf(model.SearchJobsResponse{}),
+		Args: gql.FieldConfigArgument{
+			"query":  {Type: graphql.JSON},
+			"limit":  {Type: gql.Int},
+			"cursor": {Type: gql.String},
+		},
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			req, err := searchRequest(p.Args)
+			if err != nil {
+				return nil, err
+			}
+			return jobs.SearchJobs(p.Context, req)
+		},
+	})
+	schema.Query.AddFieldConfig("lineage", &gql.Field{
+		Type: lineage,
+		Args: gql.FieldConfigArgument{"uid": {Type: gql.NewNonNull(gql.ID)}},
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			uid, err := uuid.Parse(p.Args["uid"].(string))
+			if err != nil {
+				return nil, nil
+			}
+			l, err := lineagesOf(p.Context).get(p.Context, jobs, uid)
+			if l == nil || err != nil {
+				return nil, err
+			}
+			return l.of(uid), nil
+		},
+	})
+	schema.Query.AddFieldConfig("stats", &gql.Field{
+		Type: schema.ObjectOf(model.PoolStats{}),
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return stats.GetStats(p.Context)
+		},
+	})
+	schema.Query.AddFieldConfig("cluster_stats", &gql.Field{
+		Type: schema.ObjectOf(model.ClusterStats{}),
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return stats.GetClusterStats(p.Context)
+		},
+	})
+	schema.Query.AddFieldConfig("job_types", &gql.Field{
+		Type: gql.NewList(schema.ObjectOf(model.JobType{})),
+		Resolve: func(p gql.ResolveParams) (any, error) {
+			return jobTypes.ListJobTypes(p.Context)
+		},
+	})
+
+	if err := schema.Compile(); err != nil {
+		panic(err)
+	}
+	return &graphQLService{schema: schema}
+}
+
+type lineagesKey struct{}
+
+// lineages holds the lineages a query has read. A lineage is the whole
+// tree its job belongs to, so it is kept for every job in it, and
+// following children and replays down a tree reads it once.
+type lineages struct {
+	mu    sync.Mutex
+	byJob map[uuid.UUID]*cachedLineage
+}
+
+// cachedLineage is a lineage with its edges by the job they come from
+type cachedLineage struct {
+	*model.Lineage
+	from map[uuid.UUID][]model.LineageEdge
+}
+
+// lineagesOf returns the query's lineages, or an empty set outside one
+func lineagesOf(ctx context.Context) *lineages {
+	if l, ok := ctx.Value(lineagesKey{}).(*lineages); ok {
+		return l
+	}
+	return &lineages{byJob: make(map[uuid.UUID]*cachedLineage)}
+}
+
+// get returns the lineage of the job, or nil if it's gone
+func (l *lineages) get(ctx context.Context, jobs JobsService, uid uuid.UUID) (*cachedLineage, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cached, ok := l.byJob[uid]; ok {
+		return cached, nil
+	}
+	lineage, err := jobs.GetJobLineage(ctx, uid.String())
+	if errors.Is(err, ErrJobNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cached := &cachedLineage{Lineage: lineage, from: make(map[uuid.UUID][]model.LineageEdge)}
+	for _, edge := range lineage.Edges {
+		cached.from[edge.From] = append(cached.from[edge.From], edge)
+	}
+	for _, node := range lineage.Nodes {
+		l.byJob[node.UID] = cached
+	}
+	l.byJob[uid] = cached
+	return cached, nil
+}
+
+// of returns the lineage as read for the given job
+func (c *cachedLineage) of(uid uuid.UUID) *model.Lineage {
+	l := *c.Lineage
+	l.Job = uid
+	return &l
+}
+
+// searchRequest reads the jobs field's arguments as a job search, the query
+// taking the same form as POST /jobs/search's
+func searchRequest(args map[string]any) (*model.SearchJobsRequest, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	var req model.SearchJobsRequest
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return nil, model.DecodeError(err)
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (s *graphQLService) Execute(ctx context.Context, req *graphql.Request) (*graphql.Response, error) {
+	ctx = context.WithValue(ctx, lineagesKey{}, &lineages{byJob: make(map[uuid.UUID]*cachedLineage)})
+	return s.schema.Execute(ctx, req), nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/graphql"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/google/uuid"
+	gql "github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLService_Execute(t *testing.T) {
+	ctx := context.Background()
+	// No workers are started so submitted jobs stay pending
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	jobs := NewJobsService(p, model.TenantBudget{}, nil, model.DefaultPayloadLimits())
+	svc := NewGraphQLService(jobs, NewStatsService(p, model.TenantBudget{}), NewJobTypesService(p, nil, model.DefaultPayloadLimits()))
+
+	submit := func(number int, parent *uuid.UUID) *model.Job {
+		createdAt := time.Now().Add(time.Duration(number) * time.Second)
+		job := &model.Job{
+			UID:       uuid.New(),
+			Type:      "math",
+			Payload:   model.MathJobPayload{Number: number},
+			Labels:    map[string]string{"team": fmt.Sprint("team-", number%2)},
+			Parent:    parent,
+			Status:    model.JobStatusPending,
+			CreatedAt: &createdAt,
+		}
+		require.NoError(t, jobs.CreateJobs(ctx, job))
+		return job
+	}
+	root := submit(1, nil)
+	child := submit(2, &root.UID)
+	grandchild := submit(3, &child.UID)
+
+	execute := func(query string, vars map[string]any) string {
+		resp, err := svc.Execute(ctx, &graphql.Request{Query: query, Variables: vars})
+		require.NoError(t, err)
+		data, err := json.Marshal(resp)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	got := execute(`query($uid: ID!) {
+		job(uid: $uid) {
+			type payload labels
+			parent_job { uid children { uid } }
+			children { uid }
+			lineage { root }
+		}
+	}`, map[string]any{"uid": child.UID.String()})
+	assert.JSONEq(t, fmt.Sprintf(`{"data": {"job": {
+		"type": "math",
+		"payload": {"number": 2},
+		"labels": {"team": "team-0"},
+		"parent_job": {"uid": %q, "children": [{"uid": %q}]},
+		"children": [{"uid": %q}],
+		"lineage": {"root": %q}
+	}}}`, root.UID, child.UID, grandchild.UID, root.UID), got)
+
+	got = execute(`{
+		jobs(query: {labels: {team: "team-1"}}, limit: 1) { jobs { payload } next_cursor }
+		stats { queue_depth jobs }
+		missing: job(uid: "`+uuid.NewString()+`") { uid }
+	}`, nil)
+	var resp struct {
+		Data struct {
+			Jobs struct {
+				Jobs       []map[string]any `json:"jobs"`
+				NextCursor string           `json:"next_cursor"`
+			} `json:"jobs"`
+			Stats struct {
+				QueueDepth int            `json:"queue_depth"`
+				Jobs       map[string]int `json:"jobs"`
+			} `json:"stats"`
+			Missing *struct{} `json:"missing"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(got), &resp))
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, []map[string]any{{"payload": map[string]any{"number": 1.0}}}, resp.Data.Jobs.Jobs)
+	assert.NotEmpty(t, resp.Data.Jobs.NextCursor)
+	assert.Equal(t, 3, resp.Data.Stats.QueueDepth)
+	assert.Equal(t, 3, resp.Data.Stats.Jobs["pending"])
+	assert.Nil(t, resp.Data.Missing)
+
+	got = execute(`{ jobs(query: {status: "sleeping"}) { next_cursor } }`, nil)
+	assert.Contains(t, got, `"message":"invalid status: sleeping"`)
+	got = execute(`{ jobs(query: {colour: "red"}) { next_cursor } }`, nil)
+	assert.Contains(t, got, `colour`)
+}
+
+func TestGraphQLService_Schema(t *testing.T) {
+	svc := NewGraphQLService(nil, nil, nil)
+	// Every type the API returns is reachable
+	for _, path := range [][]string{
+		{"job", "attempts", "started_at"},
+		{"job", "input", "filename"},
+		{"jobs", "jobs", "replays", "uid"},
+		{"lineage", "nodes", "attempts"},
+		{"stats", "worker_details"},
+		{"cluster_stats", "nodes", "utilization"},
+		{"cluster_stats", "queue_depth"},
+		{"job_types", "payload_schema"},
+	} {
+		obj := svc.schema.Query
+		for i, name := range path {
+			f := obj.Fields()[name]
+			require.NotNil(t, f, "%v", path[:i+1])
+			if next, ok := unwrap(f.Type).(*gql.Object); ok {
+				obj = next
+			}
+		}
+	}
+}
+
+func unwrap(t gql.Type) gql.Type {
+	switch t := t.(type) {
+	case *gql.List:
+		return unwrap(t.OfType)
+	case *gql.NonNull:
+		return unwrap(t.OfType)
+	}
+	return t
+}
+
+// countingJobs counts the lineages read through it
+type countingJobs struct {
+	JobsService
+	lineages atomic.Int32
+}
+
+func (c *countingJobs) GetJobLineage(ctx context.Context, uid string) (*model.Lineage, error) {
+	c.lineages.Add(1)
+	return c.JobsService.GetJobLineage(ctx, uid)
+}
+
+func TestGraphQLService_ReadsLineageOnce(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 0, 100)
+	jobs := &countingJobs{JobsService: NewJobsService(p, model.TenantBudget{}, nil, model.DefaultPayloadLimits())}
+	svc := NewGraphQLService(jobs, nil, nil)
+
+	submit := func(parent *uuid.UUID) *model.Job {
+		job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 1}, Parent: parent, Status: model.JobStatusPending}
+		require.NoError(t, jobs.CreateJobs(ctx, job))
+		return job
+	}
+	root := submit(nil)
+	for range 3 {
+		child := submit(&root.UID)
+		for range 3 {
+			submit(&child.UID)
+		}
+	}
+
+	resp, err := svc.Execute(ctx, &graphql.Request{Query: `query($uid: ID!) {
+		job(uid: $uid) {
+			children { uid children { uid replays { uid } lineage { root } } }
+			again: children { children { uid } }
+		}
+		lineage(uid: $uid) { root }
+	}`, Variables: map[string]any{"uid": root.UID.String()}})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Errors)
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	var got struct {
+		Job struct {
+			Children []struct {
+				Children []struct {
+					Replays []any `json:"replays"`
+					Lineage struct {
+						Root uuid.UUID `json:"root"`
+					} `json:"lineage"`
+				} `json:"children"`
+			} `json:"children"`
+		} `json:"job"`
+	}
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Len(t, got.Job.Children, 3)
+	for _, child := range got.Job.Children {
+		require.Len(t, child.Children, 3)
+		for _, grandchild := range child.Children {
+			assert.Empty(t, grandchild.Replays)
+			assert.Equal(t, root.UID, grandchild.Lineage.Root)
+		}
+	}
+	assert.Equal(t, int32(1), jobs.lineages.Load())
+
+	// Each query reads it again
+	_, err = svc.Execute(ctx, &graphql.Request{Query: `{ lineage(uid: "` + root.UID.String() + `") { root } }`})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), jobs.lineages.Load())
+}
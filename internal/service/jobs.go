@@ -3,27 +3,238 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/policy"
 	"github.com/dnakolan/worker-pool-service/internal/pool"
 )
 
+var (
+	ErrJobNotFound     = pool.ErrJobNotFound
+	ErrVersionConflict = pool.ErrVersionConflict
+	ErrUnschedulable   = pool.ErrUnschedulable
+	ErrJobRejected     = pool.ErrJobRejected
+	ErrStorageFault    = pool.ErrInjectedStorageFault
+	ErrBudgetExceeded  = errors.New("execution budget exceeded")
+	ErrJobTypeDisabled = errors.New("job type disabled")
+	ErrNoBlobStore     = pool.ErrNoBlobStore
+	ErrNoInput         = pool.ErrNoInput
+	ErrNoBinary        = pool.ErrNoBinary
+	ErrUnexpectedPart  = pool.ErrUnexpectedPart
+	ErrMissingPart     = pool.ErrMissingPart
+	ErrParentNotFound  = errors.New("parent job not found")
+
+	// ErrPolicyUnavailable is returned, wrapped in ErrJobRejected, when no
+	// policy decision could be had for a submission
+	ErrPolicyUnavailable = policy.ErrUnavailable
+)
+
 type JobsService interface {
 	CreateJobs(ctx context.Context, req *model.Job) error
+	CreateJobUnlessExists(ctx context.Context, req *model.Job, match *model.ExistingJobMatch) (*model.Job, error)
+	CreateJobCoalesced(ctx context.Context, req *model.Job, key string, window time.Duration) (*model.Job, error)
+	CreateJobWithInput(ctx context.Context, req *model.Job, filename, contentType string, input io.Reader) error
+	GetJobInput(ctx context.Context, uid string) (*model.JobInput, io.ReadCloser, error)
+	StoreJobBinary(ctx context.Context, req *model.Job, part, contentType string, data io.Reader) error
+	DeleteJobBinaries(ctx context.Context, req *model.Job) error
+	GetJobBinary(ctx context.Context, uid, field string) (*model.Binary, io.ReadCloser, error)
+	ReplayJob(ctx context.Context, uid string, version int64, req *model.ReplayJobRequest, traceID string) (*model.Job, error)
+	GetJobLineage(ctx context.Context, uid string) (*model.Lineage, error)
 	ListJobs(ctx context.Context, filter *model.JobFilter) ([]*model.Job, error)
 	GetJobs(ctx context.Context, uid string) (*model.Job, error)
+	UpdateJobs(ctx context.Context, uid string, version int64, patch *model.JobPatch) (*model.Job, error)
+	SearchJobs(ctx context.Context, req *model.SearchJobsRequest) (*model.SearchJobsResponse, error)
+	StreamJobLogs(ctx context.Context, uid string, follow bool, w io.Writer) error
 }
 
 type jobsService struct {
-	pool *pool.WorkerPool
+	pool      *pool.WorkerPool
+	budget    model.TenantBudget
+	enabled   model.EnabledJobTypes
+	limits    model.PayloadLimits
+	forwarder Forwarder
 }
 
-func NewJobsService(pool *pool.WorkerPool) *jobsService {
-	return &jobsService{pool: pool}
+func NewJobsService(pool *pool.WorkerPool, budget model.TenantBudget, enabled model.EnabledJobTypes, limits model.PayloadLimits) *jobsService {
+	return &jobsService{pool: pool, budget: budget, enabled: enabled, limits: limits}
 }
 
+// CreateJobs submits the job, or forwards it to a peer when this instance
+// can't run it and a forwarder is set. A job with binary payload fields is
+// only forwarded if it is turned away before their data is stored.
 func (s *jobsService) CreateJobs(ctx context.Context, req *model.Job) error {
-	return s.pool.SubmitJob(ctx, req)
+	err := s.admit(ctx, req)
+	if err == nil && len(model.PayloadBinaries(req.Payload)) > 0 {
+		return s.submitWithBinaries(ctx, req)
+	}
+	if err == nil {
+		err = s.pool.SubmitJob(ctx, req)
+	}
+	if err != nil {
+		if _, ok := s.forward(ctx, req, err, nil, nil); ok {
+			return nil
+		}
+	}
+	return err
+}
+
+// CreateJobUnlessExists submits the job unless an equivalent one is already
+// pending or running, returning that job instead. A job this instance can't
+// run is forwarded as CreateJobs does, on the same condition.
+func (s *jobsService) CreateJobUnlessExists(ctx context.Context, req *model.Job, match *model.ExistingJobMatch) (*model.Job, error) {
+	err := s.admit(ctx, req)
+	if err == nil {
+		var existing *model.Job
+		if existing, err = s.pool.SubmitJobUnlessExists(ctx, req, match); err == nil {
+			return existing, nil
+		}
+	}
+	if existing, ok := s.forward(ctx, req, err, match, nil); ok {
+		return existing, nil
+	}
+	return nil, err
+}
+
+// CreateJobCoalesced folds the job into the one waiting with the same key,
+// returning that job, or submits it to wait for window if there is none. A
+// job this instance can't run is forwarded as CreateJobs does, to be
+// coalesced on the peer.
+func (s *jobsService) CreateJobCoalesced(ctx context.Context, req *model.Job, key string, window time.Duration) (*model.Job, error) {
+	err := s.admit(ctx, req)
+	if err == nil {
+		var existing *model.Job
+		if existing, err = s.pool.SubmitJobCoalesced(ctx, req, key, window); err == nil {
+			return existing, nil
+		}
+	}
+	if existing, ok := s.forward(ctx, req, err, nil, &model.CoalesceOptions{Key: key, Window: window.String()}); ok {
+		return existing, nil
+	}
+	return nil, err
+}
+
+// CreateJobWithInput stores the file uploaded with a job and submits the
+// job, removing the file again if the job is turned away. Such jobs are
+// never forwarded: the file is streamed into this instance's blob store as
+// it arrives, so it can't also be sent on to a peer, which couldn't read it
+// from here either.
+func (s *jobsService) CreateJobWithInput(ctx context.Context, req *model.Job, filename, contentType string, input io.Reader) error {
+	if err := s.admit(ctx, req); err != nil {
+		return err
+	}
+	if err := s.pool.StoreInput(ctx, req, filename, contentType, input); err != nil {
+		return err
+	}
+	if err := s.submitWithBinaries(ctx, req); err != nil {
+		s.pool.DeleteInput(context.WithoutCancel(ctx), req)
+		return err
+	}
+	return nil
+}
+
+// submitWithBinaries stores the data given inline for the job's binary
+// payload fields and submits the job, removing the data of every binary
+// field again if the job is turned away
+func (s *jobsService) submitWithBinaries(ctx context.Context, req *model.Job) error {
+	err := s.pool.StoreBinaries(ctx, req)
+	if err == nil {
+		err = s.pool.SubmitJob(ctx, req)
+	}
+	if err != nil {
+		s.pool.DeleteBinaries(context.WithoutCancel(ctx), req)
+	}
+	return err
+}
+
+// StoreJobBinary stores the data of the job's binary payload field streamed
+// in the multipart part named part, before the job is submitted
+func (s *jobsService) StoreJobBinary(ctx context.Context, req *model.Job, part, contentType string, data io.Reader) error {
+	return s.pool.StoreBinary(ctx, req, part, contentType, data)
+}
+
+// DeleteJobBinaries removes the data stored for the binary payload fields
+// of a job that was not submitted after all
+func (s *jobsService) DeleteJobBinaries(ctx context.Context, req *model.Job) error {
+	return s.pool.DeleteBinaries(ctx, req)
+}
+
+func (s *jobsService) GetJobBinary(ctx context.Context, uid, field string) (*model.Binary, io.ReadCloser, error) {
+	return s.pool.OpenBinary(ctx, uid, field)
+}
+
+func (s *jobsService) GetJobInput(ctx context.Context, uid string) (*model.JobInput, io.ReadCloser, error) {
+	return s.pool.OpenInput(ctx, uid)
+}
+
+// ReplayJob submits a new job running a finished one again, with the
+// request's payload changes. A copy of the original's input file and binary
+// payload fields goes with it. When version is non-zero the original must
+// still be at that version, or the replay fails with ErrVersionConflict.
+func (s *jobsService) ReplayJob(ctx context.Context, uid string, version int64, req *model.ReplayJobRequest, traceID string) (*model.Job, error) {
+	original, exists := s.pool.GetJob(ctx, uid)
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	if version != 0 && original.Version != version {
+		return nil, ErrVersionConflict
+	}
+	job, err := model.NewReplay(original, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	job.TraceID = traceID
+	if err := s.admit(ctx, job); err != nil {
+		return nil, err
+	}
+	if original.Input != nil {
+		if err := s.pool.CopyInput(ctx, original, job); err != nil {
+			return nil, err
+		}
+	}
+	err = s.pool.CopyBinaries(ctx, original, job)
+	if err == nil {
+		err = s.pool.SubmitJob(ctx, job)
+	}
+	if err != nil {
+		s.pool.DeleteInput(context.WithoutCancel(ctx), job)
+		s.pool.DeleteBinaries(context.WithoutCancel(ctx), job)
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *jobsService) GetJobLineage(ctx context.Context, uid string) (*model.Lineage, error) {
+	lineage, exists := s.pool.Lineage(ctx, uid)
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	return lineage, nil
+}
+
+// admit checks the job against the enabled job types and its tenant's
+// budget, and that the parent it names exists
+func (s *jobsService) admit(ctx context.Context, req *model.Job) error {
+	if err := s.limits.Check(req.Payload); err != nil {
+		return err
+	}
+	if req.Parent != nil {
+		if _, exists := s.pool.GetJob(ctx, req.Parent.String()); !exists {
+			return fmt.Errorf("%w: %s", ErrParentNotFound, req.Parent)
+		}
+	}
+	if !s.enabled.Allows(req.Type) {
+		return fmt.Errorf("%w: %s jobs are not enabled in this deployment", ErrJobTypeDisabled, req.Type)
+	}
+	if limit := s.budget.Limit(req.Tenant); limit > 0 {
+		if used := s.pool.TenantUsage(ctx, req.Tenant); used >= limit {
+			return fmt.Errorf("%w: tenant %s has used %s of its %s daily execution budget",
+				ErrBudgetExceeded, req.Tenant, used.Round(time.Second), limit)
+		}
+	}
+	return nil
 }
 
 func (s *jobsService) ListJobs(ctx context.Context, filter *model.JobFilter) ([]*model.Job, error) {
@@ -36,9 +247,81 @@ func (s *jobsService) ListJobs(ctx context.Context, filter *model.JobFilter) ([]
 }
 
 func (s *jobsService) GetJobs(ctx context.Context, uid string) (*model.Job, error) {
-	job, exists := s.pool.GetJob(ctx, uid)
+	job, exists := s.pool.ReadJob(ctx, uid)
 	if !exists {
-		return nil, errors.New("job not found")
+		return nil, ErrJobNotFound
 	}
-	return job, nil
+	return s.withArtifactURLs(ctx, job)
+}
+
+// withArtifactURLs returns a copy of the job listing pre-signed download URLs
+// for its artifacts, or the job itself when there are none
+func (s *jobsService) withArtifactURLs(ctx context.Context, job *model.Job) (*model.Job, error) {
+	urls := make(map[string]string)
+	for _, id := range job.Artifacts {
+		url, err := s.pool.ArtifactURL(ctx, id)
+		if errors.Is(err, ErrArtifactNotFound) {
+			// Expired since the job listed it
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if url != "" {
+			urls[id] = url
+		}
+	}
+	if len(urls) == 0 {
+		return job, nil
+	}
+	withURLs := *job
+	withURLs.ArtifactURLs = urls
+	return &withURLs, nil
+}
+
+// UpdateJobs applies the patch, holding the patched job to the same checks
+// as a submitted one, so a patch can't make a job that would have been
+// turned away
+func (s *jobsService) UpdateJobs(ctx context.Context, uid string, version int64, patch *model.JobPatch) (*model.Job, error) {
+	return s.pool.PatchJob(ctx, uid, version, func(job *model.Job) error {
+		if err := patch.Apply(job); err != nil {
+			return err
+		}
+		if !s.enabled.Allows(job.Type) {
+			return fmt.Errorf("%w: %s jobs are not enabled in this deployment", ErrJobTypeDisabled, job.Type)
+		}
+		if patch.Payload != nil {
+			return s.limits.Check(job.Payload)
+		}
+		return nil
+	})
+}
+
+func (s *jobsService) SearchJobs(ctx context.Context, req *model.SearchJobsRequest) (*model.SearchJobsResponse, error) {
+	var after *model.JobCursor
+	if req.Cursor != "" {
+		cursor, err := model.ParseJobCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &cursor
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = model.DefaultSearchLimit
+	}
+
+	// Fetch one extra job to learn whether there is another page
+	jobs := s.pool.SearchJobs(ctx, &req.Query, after, limit+1)
+	resp := &model.SearchJobsResponse{Jobs: jobs}
+	if len(jobs) > limit {
+		resp.Jobs = jobs[:limit]
+		resp.NextCursor = model.NewJobCursor(resp.Jobs[limit-1]).String()
+	}
+	return resp, nil
+}
+
+func (s *jobsService) StreamJobLogs(ctx context.Context, uid string, follow bool, w io.Writer) error {
+	return s.pool.StreamJobLogs(ctx, uid, follow, w)
 }
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/policy"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobsService_SearchJobs_Pagination(t *testing.T) {
+	ctx := context.Background()
+	// No workers are started so submitted jobs stay pending
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	svc := NewJobsService(p, model.TenantBudget{}, nil, model.DefaultPayloadLimits())
+
+	base := time.Now()
+	var want []uuid.UUID
+	for i := 0; i < 5; i++ {
+		createdAt := base.Add(time.Duration(i) * time.Second)
+		job := &model.Job{
+			UID:       uuid.New(),
+			Type:      "math",
+			Payload:   model.MathJobPayload{Number: i},
+			Status:    model.JobStatusPending,
+			CreatedAt: &createdAt,
+		}
+		assert.NoError(t, svc.CreateJobs(ctx, job))
+		want = append(want, job.UID)
+	}
+
+	var got []uuid.UUID
+	req := &model.SearchJobsRequest{Limit: 2}
+	for pages := 0; pages < 5; pages++ {
+		resp, err := svc.SearchJobs(ctx, req)
+		assert.NoError(t, err)
+		for _, job := range resp.Jobs {
+			got = append(got, job.UID)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		req.Cursor = resp.NextCursor
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestJobsService_CreateJobs_TenantBudget(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 1, 10)
+	p.Start()
+	defer p.Stop()
+
+	budget := model.TenantBudget{
+		Default:   10 * time.Millisecond,
+		Overrides: map[string]time.Duration{"unlimited": 0},
+	}
+	svc := NewJobsService(p, budget, nil, model.DefaultPayloadLimits())
+
+	newJob := func(tenant string) *model.Job {
+		return &model.Job{
+			UID:     uuid.New(),
+			Type:    "sleep",
+			Payload: model.SleepJobPayload{Duration: "20ms"},
+			Tenant:  tenant,
+			Status:  model.JobStatusPending,
+		}
+	}
+
+	for _, tenant := range []string{"team-a", "unlimited"} {
+		job := newJob(tenant)
+		assert.NoError(t, svc.CreateJobs(ctx, job))
+		assert.Eventually(t, func() bool {
+			return p.TenantUsage(ctx, tenant) > 0
+		}, 2*time.Second, 10*time.Millisecond)
+	}
+
+	err := svc.CreateJobs(ctx, newJob("team-a"))
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Contains(t, err.Error(), "tenant team-a has used")
+
+	assert.NoError(t, svc.CreateJobs(ctx, newJob("unlimited")))
+	assert.NoError(t, svc.CreateJobs(ctx, newJob("team-b")))
+}
+
+func TestJobsService_CreateJobs_DisabledType(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	svc := NewJobsService(p, model.TenantBudget{}, model.EnabledJobTypes{"math"}, model.DefaultPayloadLimits())
+
+	err := svc.CreateJobs(ctx, &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Status: model.JobStatusPending})
+	assert.ErrorIs(t, err, ErrJobTypeDisabled)
+	assert.Equal(t, "job type disabled: sleep jobs are not enabled in this deployment", err.Error())
+
+	assert.NoError(t, svc.CreateJobs(ctx, &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 3}, Status: model.JobStatusPending}))
+}
+
+func TestJobsService_PayloadLimits(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	svc := NewJobsService(p, model.TenantBudget{}, nil, model.PayloadLimits{MaxSleepDuration: time.Minute, MaxMathNumber: 10})
+
+	var invalid *model.ValidationError
+	err := svc.CreateJobs(ctx, &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 11}, Status: model.JobStatusPending})
+	if assert.ErrorAs(t, err, &invalid) {
+		assert.Equal(t, []model.FieldError{{Field: "payload.number", Message: "cannot exceed 10"}}, invalid.Fields)
+	}
+
+	job := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1m"}, Status: model.JobStatusPending}
+	assert.NoError(t, svc.CreateJobs(ctx, job))
+	patch, err := model.ParseJobPatch([]byte(`{"payload":{"duration":"2m"}}`))
+	assert.NoError(t, err)
+	_, err = svc.UpdateJobs(ctx, job.UID.String(), 0, patch)
+	if assert.ErrorAs(t, err, &invalid) {
+		assert.Equal(t, []model.FieldError{{Field: "payload.duration", Message: "cannot exceed 1m0s"}}, invalid.Fields)
+	}
+	stored, err := svc.GetJobs(ctx, job.UID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, model.SleepJobPayload{Duration: "1m"}, stored.Payload)
+}
+
+func TestJobsService_ReplayJob_Version(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	svc := NewJobsService(p, model.TenantBudget{}, nil, model.DefaultPayloadLimits())
+
+	job := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Status: model.JobStatusPending}
+	assert.NoError(t, svc.CreateJobs(ctx, job))
+	stored, err := svc.GetJobs(ctx, job.UID.String())
+	assert.NoError(t, err)
+
+	// A stale version fails before anything else is looked at
+	_, err = svc.ReplayJob(ctx, job.UID.String(), stored.Version+1, &model.ReplayJobRequest{}, "")
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	_, err = svc.ReplayJob(ctx, job.UID.String(), stored.Version, &model.ReplayJobRequest{}, "")
+	assert.ErrorIs(t, err, model.ErrJobNotFinished)
+}
+
+// disconnectingReader cancels the submission once the upload has been read,
+// as a client hanging up before the response would
+type disconnectingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (d *disconnectingReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err == io.EOF {
+		d.cancel()
+	}
+	return n, err
+}
+
+func TestJobsService_CreateJobWithInput_Abandoned(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := blob.NewDiskStore(dir)
+	assert.NoError(t, err)
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	p.SetBlobStore(store)
+	svc := NewJobsService(p, model.TenantBudget{}, nil, model.DefaultPayloadLimits())
+
+	submitCtx, cancel := context.WithCancel(ctx)
+	job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 3}, Status: model.JobStatusPending}
+	err = svc.CreateJobWithInput(submitCtx, job, "input.txt", "text/plain", &disconnectingReader{r: strings.NewReader("data"), cancel: cancel})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = svc.GetJobs(ctx, job.UID.String())
+	assert.Error(t, err)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// stubForwarder hands every job to a peer named node, recording them. A
+// conditional submission finds the peer already has existing, when set.
+type stubForwarder struct {
+	node      string
+	existing  *model.Job
+	forwarded []*model.Job
+}
+
+func (f *stubForwarder) Forward(ctx context.Context, job *model.Job, unlessExists *model.ExistingJobMatch, coalesce *model.CoalesceOptions) (*model.Job, bool, error) {
+	f.forwarded = append(f.forwarded, job.Clone())
+	if f.existing != nil && (unlessExists != nil || coalesce != nil) {
+		return f.existing, true, nil
+	}
+	created := job.Clone()
+	created.UID = uuid.New()
+	created.Node = f.node
+	return created, false, nil
+}
+
+func TestJobsService_CreateJobs_Forwarded(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	p.SetNodeName("node-a")
+	svc := NewJobsService(p, model.TenantBudget{}, model.EnabledJobTypes{"math"}, model.DefaultPayloadLimits())
+	peer := &stubForwarder{node: "node-b"}
+	svc.SetForwarder(peer)
+
+	// A disabled type, or a capability nobody here offers, goes to the peer
+	sleep := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Status: model.JobStatusPending}
+	assert.NoError(t, svc.CreateJobs(ctx, sleep))
+	assert.Equal(t, "node-b", sleep.Node)
+	gpu := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 3}, Requires: []string{"gpu"}, Status: model.JobStatusPending}
+	assert.NoError(t, svc.CreateJobs(ctx, gpu))
+	assert.Equal(t, "node-b", gpu.Node)
+	assert.Len(t, peer.forwarded, 2)
+	_, exists := p.GetJob(ctx, gpu.UID.String())
+	assert.False(t, exists)
+
+	// Jobs this instance can run stay here
+	math := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 3}, Status: model.JobStatusPending}
+	assert.NoError(t, svc.CreateJobs(ctx, math))
+	assert.Equal(t, "node-a", math.Node)
+
+	// A forwarded submission isn't forwarded again
+	sleep = &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Status: model.JobStatusPending}
+	assert.ErrorIs(t, svc.CreateJobs(Forwarded(ctx, "node-c"), sleep), ErrJobTypeDisabled)
+	assert.Len(t, peer.forwarded, 2)
+}
+
+func TestJobsService_CreateJobs_ForwardedConditional(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	svc := NewJobsService(p, model.TenantBudget{}, model.EnabledJobTypes{"math"}, model.DefaultPayloadLimits())
+	peer := &stubForwarder{node: "node-b"}
+	svc.SetForwarder(peer)
+
+	// Conditional submissions of a disabled type go to the peer too
+	sleep := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Status: model.JobStatusPending}
+	existing, err := svc.CreateJobUnlessExists(ctx, sleep, &model.ExistingJobMatch{})
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.Equal(t, "node-b", sleep.Node)
+
+	sleep = &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Status: model.JobStatusPending}
+	existing, err = svc.CreateJobCoalesced(ctx, sleep, "daily", 10*time.Second)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.Equal(t, "node-b", sleep.Node)
+	assert.Len(t, peer.forwarded, 2)
+
+	// and come back with the job the peer already had
+	peer.existing = &model.Job{UID: uuid.New(), Type: "sleep", Node: "node-b", Status: model.JobStatusRunning}
+	sleep = &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Status: model.JobStatusPending}
+	existing, err = svc.CreateJobUnlessExists(ctx, sleep, &model.ExistingJobMatch{})
+	assert.NoError(t, err)
+	assert.Equal(t, peer.existing, existing)
+	existing, err = svc.CreateJobCoalesced(ctx, sleep, "daily", 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, peer.existing, existing)
+
+	// A forwarded submission isn't forwarded again
+	_, err = svc.CreateJobUnlessExists(Forwarded(ctx, "node-c"), sleep, &model.ExistingJobMatch{})
+	assert.ErrorIs(t, err, ErrJobTypeDisabled)
+	assert.Len(t, peer.forwarded, 4)
+}
+
+func TestJobsService_UpdateJobs_Policy(t *testing.T) {
+	ctx := context.Background()
+	// The policy allows math jobs on numbers up to 100
+	opaSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input struct {
+				Payload model.MathJobPayload `json:"payload"`
+			} `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{
+			"allow":  body.Input.Payload.Number <= 100,
+			"reason": "numbers above 100 need approval",
+		}})
+	}))
+	defer opaSrv.Close()
+	opa, err := policy.NewOPA(policy.OPAConfig{URL: opaSrv.URL + "/v1/data/wps/submit"})
+	require.NoError(t, err)
+
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	p.AddHooks(pool.Hooks{BeforeSubmit: opa.BeforeSubmit})
+	svc := NewJobsService(p, model.TenantBudget{}, nil, model.DefaultPayloadLimits())
+	job := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 3}, Status: model.JobStatusPending}
+	require.NoError(t, svc.CreateJobs(ctx, job))
+
+	// An allowed job can't be patched into one the policy denies
+	_, err = svc.UpdateJobs(ctx, job.UID.String(), 0, &model.JobPatch{Payload: json.RawMessage(`{"number": 500}`)})
+	assert.ErrorIs(t, err, ErrJobRejected)
+	assert.EqualError(t, err, "job rejected: numbers above 100 need approval")
+	stored, err := svc.GetJobs(ctx, job.UID.String())
+	require.NoError(t, err)
+	assert.Equal(t, model.MathJobPayload{Number: 3}, stored.Payload)
+
+	updated, err := svc.UpdateJobs(ctx, job.UID.String(), 0, &model.JobPatch{Payload: json.RawMessage(`{"number": 50}`)})
+	require.NoError(t, err)
+	assert.Equal(t, model.MathJobPayload{Number: 50}, updated.Payload)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+var (
+	ErrUnknownJobType      = pool.ErrUnknownJobType
+	ErrAdaptiveConcurrency = pool.ErrAdaptiveConcurrency
+)
+
+type JobTypesService interface {
+	ListJobTypes(ctx context.Context) ([]model.JobType, error)
+	ConfigureJobType(ctx context.Context, name string, cfg model.JobTypeConfig) (*model.JobType, error)
+}
+
+type jobTypesService struct {
+	pool    *pool.WorkerPool
+	enabled model.EnabledJobTypes
+	limits  model.PayloadLimits
+}
+
+func NewJobTypesService(pool *pool.WorkerPool, enabled model.EnabledJobTypes, limits model.PayloadLimits) *jobTypesService {
+	return &jobTypesService{pool: pool, enabled: enabled, limits: limits}
+}
+
+// ListJobTypes returns the job types this deployment accepts, with the
+// payload limits it holds them to
+func (s *jobTypesService) ListJobTypes(ctx context.Context) ([]model.JobType, error) {
+	types := make([]model.JobType, 0)
+	for _, jt := range s.pool.JobTypes(ctx) {
+		if s.enabled.Allows(jt.Name) {
+			s.limits.Describe(&jt)
+			types = append(types, jt)
+		}
+	}
+	return types, nil
+}
+
+// ConfigureJobType changes the runtime settings of a job type this
+// deployment accepts
+func (s *jobTypesService) ConfigureJobType(ctx context.Context, name string, cfg model.JobTypeConfig) (*model.JobType, error) {
+	if !s.enabled.Allows(name) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownJobType, name)
+	}
+	jt, err := s.pool.ConfigureJobType(ctx, name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.limits.Describe(jt)
+	return jt, nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+type MaintenanceService interface {
+	GetMaintenance(ctx context.Context) (*model.MaintenanceStatus, error)
+	SetMaintenanceOverride(ctx context.Context, override *model.MaintenanceOverride) (*model.MaintenanceStatus, error)
+}
+
+type maintenanceService struct {
+	pool *pool.WorkerPool
+}
+
+func NewMaintenanceService(pool *pool.WorkerPool) *maintenanceService {
+	return &maintenanceService{pool: pool}
+}
+
+func (s *maintenanceService) GetMaintenance(ctx context.Context) (*model.MaintenanceStatus, error) {
+	status := s.pool.Maintenance()
+	return &status, nil
+}
+
+func (s *maintenanceService) SetMaintenanceOverride(ctx context.Context, override *model.MaintenanceOverride) (*model.MaintenanceStatus, error) {
+	s.pool.SetMaintenanceOverride(override.Override)
+	status := s.pool.Maintenance()
+	return &status, nil
+}
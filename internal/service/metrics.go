@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/dnakolan/worker-pool-service/internal/metrics"
+)
+
+type MetricsService interface {
+	WriteMetrics(ctx context.Context, w io.Writer, openMetrics bool) error
+	GetDashboard(ctx context.Context) (map[string]any, error)
+}
+
+type metricsService struct {
+	collector *metrics.Collector
+}
+
+func NewMetricsService(collector *metrics.Collector) *metricsService {
+	return &metricsService{collector: collector}
+}
+
+func (s *metricsService) WriteMetrics(ctx context.Context, w io.Writer, openMetrics bool) error {
+	return s.collector.Write(w, openMetrics)
+}
+
+func (s *metricsService) GetDashboard(ctx context.Context) (map[string]any, error) {
+	return metrics.Dashboard(), nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+type QueueService interface {
+	SnapshotQueue(ctx context.Context) (*model.QueueSnapshot, error)
+	ReplayQueue(ctx context.Context, snapshot *model.QueueSnapshot) (*model.ReplayResult, error)
+}
+
+type queueService struct {
+	pool *pool.WorkerPool
+	jobs *jobsService
+}
+
+// NewQueueService replays snapshots through jobs, so replayed jobs are
+// checked as submissions are
+func NewQueueService(pool *pool.WorkerPool, jobs *jobsService) *queueService {
+	return &queueService{pool: pool, jobs: jobs}
+}
+
+func (s *queueService) SnapshotQueue(ctx context.Context) (*model.QueueSnapshot, error) {
+	return s.pool.SnapshotQueue(ctx), nil
+}
+
+func (s *queueService) ReplayQueue(ctx context.Context, snapshot *model.QueueSnapshot) (*model.ReplayResult, error) {
+	return s.pool.ReplayQueue(ctx, snapshot, s.jobs.admit), nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueService_ReplayQueue_DisabledType(t *testing.T) {
+	ctx := context.Background()
+	p := pool.NewWorkerPool(ctx, 0, 10)
+	jobs := NewJobsService(p, model.TenantBudget{}, model.EnabledJobTypes{"math"}, model.DefaultPayloadLimits())
+	svc := NewQueueService(p, jobs)
+
+	// A snapshot taken on an instance that runs sleep jobs
+	math := &model.Job{UID: uuid.New(), Type: "math", Payload: model.MathJobPayload{Number: 3}, Status: model.JobStatusPending}
+	sleep := &model.Job{UID: uuid.New(), Type: "sleep", Payload: model.SleepJobPayload{Duration: "1s"}, Status: model.JobStatusPending}
+	result, err := svc.ReplayQueue(ctx, &model.QueueSnapshot{Jobs: []*model.Job{math, sleep}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []uuid.UUID{math.UID}, result.Queued)
+	assert.Equal(t, []model.ReplaySkip{{JobUID: sleep.UID, Reason: "job type disabled: sleep jobs are not enabled in this deployment"}}, result.Skipped)
+	_, err = jobs.GetJobs(ctx, sleep.UID.String())
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
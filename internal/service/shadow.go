@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+type ShadowRunsService interface {
+	ListShadowRuns(ctx context.Context, jobType string) ([]model.ShadowRun, error)
+}
+
+type shadowRunsService struct {
+	pool *pool.WorkerPool
+}
+
+func NewShadowRunsService(pool *pool.WorkerPool) *shadowRunsService {
+	return &shadowRunsService{pool: pool}
+}
+
+func (s *shadowRunsService) ListShadowRuns(ctx context.Context, jobType string) ([]model.ShadowRun, error) {
+	return s.pool.ShadowRuns(jobType), nil
+}
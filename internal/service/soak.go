@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/soak"
+)
+
+var ErrNoSoakReport = errors.New("no soak check has run yet")
+
+type SoakService interface {
+	GetSoakReport(ctx context.Context) (*model.SoakReport, error)
+}
+
+type soakService struct {
+	monitor *soak.Monitor
+}
+
+func NewSoakService(monitor *soak.Monitor) *soakService {
+	return &soakService{monitor: monitor}
+}
+
+func (s *soakService) GetSoakReport(ctx context.Context) (*model.SoakReport, error) {
+	report := s.monitor.Latest()
+	if report == nil {
+		return nil, ErrNoSoakReport
+	}
+	return report, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+type StatsService interface {
+	GetStats(ctx context.Context) (*model.PoolStats, error)
+	GetClusterStats(ctx context.Context) (*model.ClusterStats, error)
+	GetTimeseries(ctx context.Context, req *model.TimeseriesRequest) (*model.Timeseries, error)
+}
+
+type statsService struct {
+	pool    *pool.WorkerPool
+	budget  model.TenantBudget
+	cluster Cluster
+}
+
+func NewStatsService(pool *pool.WorkerPool, budget model.TenantBudget) *statsService {
+	return &statsService{pool: pool, budget: budget}
+}
+
+func (s *statsService) GetStats(ctx context.Context) (*model.PoolStats, error) {
+	stats := s.pool.Stats(ctx)
+	for tenant, usage := range stats.TenantUsage {
+		usage.BudgetSeconds = s.budget.Limit(tenant).Seconds()
+		stats.TenantUsage[tenant] = usage
+	}
+	return stats, nil
+}
+
+// SetCluster makes cluster stats cover every instance in the cluster
+// rather than this one alone
+func (s *statsService) SetCluster(cluster Cluster) {
+	s.cluster = cluster
+}
+
+// GetClusterStats sums the load of every live instance. Without a cluster,
+// this instance is the only one.
+func (s *statsService) GetClusterStats(ctx context.Context) (*model.ClusterStats, error) {
+	if s.cluster != nil {
+		return model.NewClusterStats(s.cluster.Members(ctx)), nil
+	}
+	load := s.pool.Stats(ctx).Load()
+	return model.NewClusterStats([]model.NodeStats{{
+		Node:        s.pool.NodeName(),
+		NodeLoad:    load,
+		Utilization: load.Utilization(),
+		Seen:        time.Now(),
+	}}), nil
+}
+
+// GetTimeseries buckets the jobs the pool still holds, so it reaches back
+// no further than they do
+func (s *statsService) GetTimeseries(ctx context.Context, req *model.TimeseriesRequest) (*model.Timeseries, error) {
+	filter := &model.JobFilter{}
+	if req.Type != "" {
+		filter.Type = &req.Type
+	}
+	return model.NewTimeseries(req, s.pool.GetAllJobs(ctx, filter), time.Now()), nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+type UsageService interface {
+	GetTenantUsage(ctx context.Context, tenant string, month time.Time) (*model.UsageReport, error)
+}
+
+type usageService struct {
+	pool *pool.WorkerPool
+}
+
+func NewUsageService(pool *pool.WorkerPool) *usageService {
+	return &usageService{pool: pool}
+}
+
+// GetTenantUsage reports the tenant's usage in the month starting at month.
+// A tenant that ran nothing gets an empty report rather than an error, as
+// tenants exist only as names on jobs.
+func (s *usageService) GetTenantUsage(ctx context.Context, tenant string, month time.Time) (*model.UsageReport, error) {
+	now := time.Now()
+	if r, ok := model.NewUsageReports(month, s.pool.GetAllJobs(ctx, &model.JobFilter{}), now)[tenant]; ok {
+		return r, nil
+	}
+	return model.NewUsageReport(tenant, month, now), nil
+}
+
+// Reports returns every tenant's usage in the month starting at month, by
+// tenant name
+func (s *usageService) Reports(ctx context.Context, month time.Time) []*model.UsageReport {
+	byTenant := model.NewUsageReports(month, s.pool.GetAllJobs(ctx, &model.JobFilter{}), time.Now())
+	reports := make([]*model.UsageReport, 0, len(byTenant))
+	for _, r := range byTenant {
+		reports = append(reports, r)
+	}
+	slices.SortFunc(reports, func(a, b *model.UsageReport) int { return cmp.Compare(a.Tenant, b.Tenant) })
+	return reports
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+var ErrNotLeased = pool.ErrNotLeased
+
+type WorkersService interface {
+	LeaseJob(ctx context.Context, req *model.LeaseRequest) (*model.Job, error)
+	Heartbeat(ctx context.Context, workerID string, req *model.HeartbeatRequest) error
+	CompleteJob(ctx context.Context, workerID string, req *model.CompleteJobRequest) error
+}
+
+type workersService struct {
+	pool *pool.WorkerPool
+}
+
+func NewWorkersService(pool *pool.WorkerPool) *workersService {
+	return &workersService{pool: pool}
+}
+
+// LeaseJob returns nil without an error when no job became available
+func (s *workersService) LeaseJob(ctx context.Context, req *model.LeaseRequest) (*model.Job, error) {
+	wait, err := req.WaitDuration()
+	if err != nil {
+		return nil, err
+	}
+	job, ok := s.pool.LeaseJob(ctx, req.WorkerID, req.Capabilities, req.Labels, wait)
+	if !ok {
+		return nil, nil
+	}
+	return job, nil
+}
+
+func (s *workersService) Heartbeat(ctx context.Context, workerID string, req *model.HeartbeatRequest) error {
+	if err := s.pool.Heartbeat(ctx, workerID, req.JobUID.String()); err != nil {
+		return err
+	}
+	return s.pool.AnnotateLeasedJob(ctx, workerID, req.JobUID.String(), req.Annotations)
+}
+
+func (s *workersService) CompleteJob(ctx context.Context, workerID string, req *model.CompleteJobRequest) error {
+	job, exists := s.pool.GetJob(ctx, req.JobUID.String())
+	if !exists {
+		return ErrJobNotFound
+	}
+
+	var result model.JobResult
+	var jobErr error
+	if req.Error != "" {
+		jobErr = errors.New(req.Error)
+		if req.Permanent {
+			jobErr = pool.Permanent(jobErr)
+		}
+	} else {
+		var err error
+		if result, err = model.DecodeJobResult(job.Type, req.Result); err != nil {
+			return err
+		}
+	}
+
+	if err := s.pool.AnnotateLeasedJob(ctx, workerID, req.JobUID.String(), req.Annotations); err != nil {
+		return err
+	}
+	if req.Cost != nil {
+		if err := s.pool.ReportLeasedJobCost(ctx, workerID, req.JobUID.String(), *req.Cost); err != nil {
+			return err
+		}
+	}
+	return s.pool.CompleteLeasedJob(ctx, workerID, req.JobUID.String(), result, jobErr)
+}
@@ -0,0 +1,175 @@
+// Package soak runs periodic self-checks that flag a long-running process
+// which appears to be leaking goroutines, jobs or pool bookkeeping.
+package soak
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+)
+
+// DefaultWindow is how many consecutive checks a trend must hold for
+const DefaultWindow = 5
+
+type Config struct {
+	// Interval between checks
+	Interval time.Duration
+	// Window is how many checks in a row a trend must hold before it is
+	// reported, which keeps a single busy moment from raising a warning
+	Window int
+	// MaxJobs is the number of jobs the run is expected to retain at most;
+	// zero skips the check
+	MaxJobs int
+}
+
+// Monitor samples the process and pool on an interval and logs a warning for
+// every sign of a leak it finds
+type Monitor struct {
+	cfg        Config
+	internals  func() *model.PoolInternals
+	goroutines func() int
+
+	mu      sync.Mutex
+	history []model.SoakReport
+}
+
+func NewMonitor(p *pool.WorkerPool, cfg Config) *Monitor {
+	if cfg.Window < 1 {
+		cfg.Window = DefaultWindow
+	}
+	return &Monitor{
+		cfg:        cfg,
+		internals:  func() *model.PoolInternals { return p.Internals(context.Background()) },
+		goroutines: runtime.NumGoroutine,
+	}
+}
+
+// Run checks once straight away and then every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	m.Check(time.Now())
+	for {
+		select {
+		case now := <-ticker.C:
+			m.Check(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check takes a sample, compares it with the previous ones and logs any
+// warnings
+func (m *Monitor) Check(now time.Time) *model.SoakReport {
+	report := model.SoakReport{
+		CheckedAt:  now,
+		Goroutines: m.goroutines(),
+		Pool:       *m.internals(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = append(m.history, report)
+	if len(m.history) > m.cfg.Window+1 {
+		m.history = m.history[len(m.history)-m.cfg.Window-1:]
+	}
+	report.Warnings = m.warnings()
+	m.history[len(m.history)-1] = report
+
+	for _, warning := range report.Warnings {
+		slog.Warn("Soak check: possible leak", "warning", warning)
+	}
+	return &report
+}
+
+// Latest returns the most recent report, or nil before the first check
+func (m *Monitor) Latest() *model.SoakReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.history) == 0 {
+		return nil
+	}
+	report := m.history[len(m.history)-1]
+	return &report
+}
+
+// warnings evaluates the history. m.mu must be held.
+func (m *Monitor) warnings() []string {
+	var warnings []string
+	first, last := m.history[0], m.history[len(m.history)-1]
+	p := last.Pool
+
+	if m.rising(func(r model.SoakReport) int { return r.Goroutines }) {
+		warnings = append(warnings, fmt.Sprintf("goroutines rose at each of the last %d checks, from %d to %d",
+			m.cfg.Window, first.Goroutines, last.Goroutines))
+	}
+	if m.rising(func(r model.SoakReport) int { return r.Pool.Subscribers }) {
+		warnings = append(warnings, fmt.Sprintf("event subscribers rose at each of the last %d checks, from %d to %d",
+			m.cfg.Window, first.Pool.Subscribers, p.Subscribers))
+	}
+
+	if m.cfg.MaxJobs > 0 && p.Jobs > m.cfg.MaxJobs {
+		warnings = append(warnings, fmt.Sprintf("retaining %d jobs, more than the expected %d", p.Jobs, m.cfg.MaxJobs))
+	}
+	if p.Logs > p.Jobs {
+		warnings = append(warnings, fmt.Sprintf("job logs (%d) outnumber stored jobs (%d)", p.Logs, p.Jobs))
+	}
+
+	// Bookkeeping briefly outlives a finishing job, so these only count
+	// when they persist
+	if m.persistent(func(i model.PoolInternals) bool { return i.OpenLogs > i.RunningJobs }) {
+		warnings = append(warnings, fmt.Sprintf("open job logs (%d) have outnumbered running jobs (%d) for the last %d checks",
+			p.OpenLogs, p.RunningJobs, m.cfg.Window+1))
+	}
+	if m.persistent(func(i model.PoolInternals) bool { return i.Leases > i.RunningJobs }) {
+		warnings = append(warnings, fmt.Sprintf("leases (%d) have outnumbered running jobs (%d) for the last %d checks",
+			p.Leases, p.RunningJobs, m.cfg.Window+1))
+	}
+	if m.persistent(func(i model.PoolInternals) bool { return i.Lanes > i.ActiveJobs }) {
+		warnings = append(warnings, fmt.Sprintf("serialization lanes (%d) have outnumbered active jobs (%d) for the last %d checks",
+			p.Lanes, p.ActiveJobs, m.cfg.Window+1))
+	}
+	if m.persistent(func(i model.PoolInternals) bool { return i.QueueCapacity > 0 && i.QueueDepth >= i.QueueCapacity }) {
+		warnings = append(warnings, fmt.Sprintf("job queue has been full for the last %d checks", m.cfg.Window+1))
+	}
+	if m.persistent(func(i model.PoolInternals) bool { return i.ResultCapacity > 0 && i.ResultBacklog*2 > i.ResultCapacity }) {
+		warnings = append(warnings, fmt.Sprintf("result queue has been over half full for the last %d checks (%d of %d)",
+			m.cfg.Window+1, p.ResultBacklog, p.ResultCapacity))
+	}
+	return warnings
+}
+
+// rising reports whether the value grew at every check in a full window
+func (m *Monitor) rising(value func(model.SoakReport) int) bool {
+	if len(m.history) <= m.cfg.Window {
+		return false
+	}
+	for i := 1; i < len(m.history); i++ {
+		if value(m.history[i]) <= value(m.history[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// persistent reports whether the condition held at every check in a full
+// window
+func (m *Monitor) persistent(cond func(model.PoolInternals) bool) bool {
+	if len(m.history) <= m.cfg.Window {
+		return false
+	}
+	for _, r := range m.history {
+		if !cond(r.Pool) {
+			return false
+		}
+	}
+	return true
+}
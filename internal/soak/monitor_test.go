@@ -0,0 +1,148 @@
+package soak
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/dnakolan/worker-pool-service/internal/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestMonitor returns a monitor fed from the given samples, one per check
+func newTestMonitor(cfg Config, goroutines []int, internals []model.PoolInternals) *Monitor {
+	m := NewMonitor(nil, cfg)
+	check := 0
+	m.goroutines = func() int { return goroutines[min(check, len(goroutines)-1)] }
+	m.internals = func() *model.PoolInternals {
+		i := internals[min(check, len(internals)-1)]
+		check++
+		return &i
+	}
+	return m
+}
+
+func runChecks(m *Monitor, n int) *model.SoakReport {
+	var report *model.SoakReport
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		report = m.Check(start.Add(time.Duration(i) * time.Minute))
+	}
+	return report
+}
+
+func TestMonitor_Check(t *testing.T) {
+	healthy := model.PoolInternals{Jobs: 10, ActiveJobs: 2, RunningJobs: 1, Logs: 5, OpenLogs: 1, QueueCapacity: 10, ResultCapacity: 10}
+
+	tests := []struct {
+		name       string
+		cfg        Config
+		goroutines []int
+		internals  []model.PoolInternals
+		checks     int
+		want       []string
+	}{
+		{
+			name:       "healthy",
+			cfg:        Config{Window: 3},
+			goroutines: []int{20, 25, 18, 30, 22},
+			internals:  []model.PoolInternals{healthy},
+			checks:     5,
+		},
+		{
+			name:       "growing goroutines",
+			cfg:        Config{Window: 3},
+			goroutines: []int{20, 21, 25, 30, 31},
+			internals:  []model.PoolInternals{healthy},
+			checks:     5,
+			want:       []string{"goroutines rose at each of the last 3 checks, from 21 to 31"},
+		},
+		{
+			name:       "growth shorter than the window",
+			cfg:        Config{Window: 3},
+			goroutines: []int{20, 21, 25},
+			internals:  []model.PoolInternals{healthy},
+			checks:     3,
+		},
+		{
+			name:       "more jobs than expected",
+			cfg:        Config{Window: 3, MaxJobs: 5},
+			goroutines: []int{20},
+			internals:  []model.PoolInternals{healthy},
+			checks:     1,
+			want:       []string{"retaining 10 jobs, more than the expected 5"},
+		},
+		{
+			name:       "logs outlive jobs",
+			cfg:        Config{Window: 3},
+			goroutines: []int{20},
+			internals:  []model.PoolInternals{{Jobs: 3, Logs: 4}},
+			checks:     1,
+			want:       []string{"job logs (4) outnumber stored jobs (3)"},
+		},
+		{
+			name:       "open logs that clear up",
+			cfg:        Config{Window: 2},
+			goroutines: []int{20},
+			internals:  []model.PoolInternals{{Jobs: 3, Logs: 3, OpenLogs: 1}, {Jobs: 3, Logs: 3, OpenLogs: 1}, {Jobs: 3, Logs: 3}},
+			checks:     3,
+		},
+		{
+			name:       "logs left open",
+			cfg:        Config{Window: 2},
+			goroutines: []int{20},
+			internals:  []model.PoolInternals{{Jobs: 3, Logs: 3, OpenLogs: 2, RunningJobs: 1}},
+			checks:     3,
+			want:       []string{"open job logs (2) have outnumbered running jobs (1) for the last 3 checks"},
+		},
+		{
+			name:       "leases without running jobs",
+			cfg:        Config{Window: 2},
+			goroutines: []int{20},
+			internals:  []model.PoolInternals{{Leases: 1}},
+			checks:     3,
+			want:       []string{"leases (1) have outnumbered running jobs (0) for the last 3 checks"},
+		},
+		{
+			name:       "lanes left held",
+			cfg:        Config{Window: 1},
+			goroutines: []int{20},
+			internals:  []model.PoolInternals{{Jobs: 2, Logs: 2, Lanes: 1}},
+			checks:     2,
+			want:       []string{"serialization lanes (1) have outnumbered active jobs (0) for the last 2 checks"},
+		},
+		{
+			name:       "backlogged channels",
+			cfg:        Config{Window: 1},
+			goroutines: []int{20},
+			internals:  []model.PoolInternals{{QueueDepth: 4, QueueCapacity: 4, ResultBacklog: 3, ResultCapacity: 4}},
+			checks:     2,
+			want: []string{
+				"job queue has been full for the last 2 checks",
+				"result queue has been over half full for the last 2 checks (3 of 4)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(tt.cfg, tt.goroutines, tt.internals)
+			report := runChecks(m, tt.checks)
+			assert.Equal(t, tt.want, report.Warnings)
+			assert.Equal(t, report, m.Latest())
+		})
+	}
+}
+
+func TestMonitor_Pool(t *testing.T) {
+	p := pool.NewWorkerPool(context.Background(), 1, 5)
+	m := NewMonitor(p, Config{})
+	assert.Nil(t, m.Latest())
+
+	report := m.Check(time.Now())
+	assert.Positive(t, report.Goroutines)
+	assert.Equal(t, 5, report.Pool.QueueCapacity)
+	assert.Equal(t, 5, report.Pool.ResultCapacity)
+	assert.Empty(t, report.Warnings)
+}
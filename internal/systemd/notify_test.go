@@ -0,0 +1,42 @@
+package systemd
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotify(t *testing.T) {
+	t.Run("without notify socket", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", "")
+		sent, err := Notify(Ready)
+		assert.NoError(t, err)
+		assert.False(t, sent)
+	})
+
+	t.Run("sends state", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notify.sock")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		t.Setenv("NOTIFY_SOCKET", path)
+		sent, err := Notify(Ready)
+		assert.NoError(t, err)
+		assert.True(t, sent)
+
+		buf := make([]byte, 64)
+		n, err := conn.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, Ready, string(buf[:n]))
+	})
+
+	t.Run("missing socket", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+		sent, err := Notify(Ready)
+		assert.Error(t, err)
+		assert.False(t, sent)
+	})
+}
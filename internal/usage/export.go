@@ -0,0 +1,82 @@
+// Package usage exports each month's tenant usage reports to blob storage
+// once the month is over, for chargeback.
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// checkInterval is how often the exporter looks for a month that has ended
+const checkInterval = time.Hour
+
+// BlobName is the name a month's reports are exported under
+func BlobName(month time.Time) string {
+	return "usage-" + month.Format(model.UsageMonthFormat) + ".json"
+}
+
+// Exporter writes every tenant's usage report for a month, as a JSON array,
+// to a blob store after the month ends
+type Exporter struct {
+	store   blob.Store
+	reports func(ctx context.Context, month time.Time) []*model.UsageReport
+	now     func() time.Time
+}
+
+func NewExporter(store blob.Store, reports func(ctx context.Context, month time.Time) []*model.UsageReport) *Exporter {
+	return &Exporter{store: store, reports: reports, now: time.Now}
+}
+
+// Run exports the month that ended last, unless that was done before, and
+// then checks for the next month ending until ctx is done
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		if err := e.ExportDue(ctx); err != nil {
+			slog.Warn("Failed to export usage reports", "error", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ExportDue exports the reports for the month before the current one, if
+// they are not in the store yet
+func (e *Exporter) ExportDue(ctx context.Context) error {
+	now := e.now().UTC()
+	month := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	r, err := e.store.Open(ctx, BlobName(month))
+	if err == nil {
+		return r.Close()
+	}
+	if !errors.Is(err, blob.ErrNotFound) {
+		return err
+	}
+	return e.Export(ctx, month)
+}
+
+// Export writes the reports for the month starting at month, replacing any
+// exported before
+func (e *Exporter) Export(ctx context.Context, month time.Time) error {
+	reports := e.reports(ctx, month)
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := e.store.Put(ctx, BlobName(month), bytes.NewReader(data)); err != nil {
+		return err
+	}
+	slog.Info("Exported usage reports", "month", month.Format(model.UsageMonthFormat), "tenants", len(reports))
+	return nil
+}
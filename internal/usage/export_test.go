@@ -0,0 +1,49 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dnakolan/worker-pool-service/internal/blob"
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExporter_ExportDue(t *testing.T) {
+	ctx := context.Background()
+	store, err := blob.NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+
+	var months []time.Time
+	e := NewExporter(store, func(ctx context.Context, month time.Time) []*model.UsageReport {
+		months = append(months, month)
+		return []*model.UsageReport{{Tenant: "team-a", Month: month.Format(model.UsageMonthFormat), Jobs: 3, Complete: true}}
+	})
+	e.now = func() time.Time { return time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, e.ExportDue(ctx))
+	december := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []time.Time{december}, months)
+	assert.Equal(t, "usage-2025-12.json", BlobName(december))
+
+	r, err := store.Open(ctx, "usage-2025-12.json")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	var reports []*model.UsageReport
+	require.NoError(t, json.Unmarshal(data, &reports))
+	require.Len(t, reports, 1)
+	assert.Equal(t, "2025-12", reports[0].Month)
+	assert.Equal(t, 3, reports[0].Jobs)
+
+	// A month is exported once, even across restarts
+	restarted := NewExporter(store, e.reports)
+	restarted.now = e.now
+	require.NoError(t, restarted.ExportDue(ctx))
+	assert.Len(t, months, 1)
+}
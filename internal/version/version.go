@@ -0,0 +1,74 @@
+// Package version reports which build of the service is running.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+)
+
+// Version, Commit and Date are set at build time with
+//
+//	go build -ldflags "-X github.com/dnakolan/worker-pool-service/internal/version.Version=v1.2.0 ..."
+//
+// When they are left empty, the module version and VCS details Go embeds in
+// the binary are used instead.
+var (
+	Version string
+	Commit  string
+	Date    string
+)
+
+// Get returns the build information of the running binary
+func Get() model.BuildInfo {
+	info, _ := debug.ReadBuildInfo()
+	return get(info)
+}
+
+func get(info *debug.BuildInfo) model.BuildInfo {
+	b := model.BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: Date,
+		GoVersion: runtime.Version(),
+	}
+	if info == nil {
+		return withDefaults(b)
+	}
+	if b.Version == "" && info.Main.Version != "(devel)" {
+		b.Version = info.Main.Version
+	}
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if b.Commit == "" {
+				b.Commit = setting.Value
+			}
+		case "vcs.time":
+			if b.BuildDate == "" {
+				b.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if modified && Commit == "" && b.Commit != "" {
+		b.Commit += "-dirty"
+	}
+	return withDefaults(b)
+}
+
+func withDefaults(b model.BuildInfo) model.BuildInfo {
+	if b.Version == "" {
+		b.Version = "dev"
+	}
+	if b.Commit == "" {
+		b.Commit = "unknown"
+	}
+	if b.BuildDate == "" {
+		b.BuildDate = "unknown"
+	}
+	return b
+}
@@ -0,0 +1,45 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/dnakolan/worker-pool-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	embedded := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2024-05-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	assert.Equal(t, model.BuildInfo{
+		Version:   "v1.4.0",
+		Commit:    "abc123-dirty",
+		BuildDate: "2024-05-01T12:00:00Z",
+		GoVersion: runtime.Version(),
+	}, get(embedded))
+
+	assert.Equal(t, model.BuildInfo{
+		Version:   "dev",
+		Commit:    "unknown",
+		BuildDate: "unknown",
+		GoVersion: runtime.Version(),
+	}, get(&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}))
+
+	// Values set with -ldflags take precedence
+	Version, Commit, Date = "v2.0.0", "def456", "2024-06-01"
+	defer func() { Version, Commit, Date = "", "", "" }()
+	assert.Equal(t, model.BuildInfo{
+		Version:   "v2.0.0",
+		Commit:    "def456",
+		BuildDate: "2024-06-01",
+		GoVersion: runtime.Version(),
+	}, get(embedded))
+}